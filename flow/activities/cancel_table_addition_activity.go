@@ -424,7 +424,7 @@ func (a *CancelTableAdditionActivity) StartNewCDCFlow(
 	})
 	defer shutdown()
 
-	taskQueue := internal.PeerFlowTaskQueueName(shared.PeerFlowTaskQueue)
+	taskQueue := internal.PeerFlowTaskQueueName(shared.WorkerPoolTaskQueue(flowConfig.WorkerPool))
 	workflowOptions := client.StartWorkflowOptions{
 		ID:                       workflowID,
 		TaskQueue:                taskQueue,
@@ -21,6 +21,7 @@ import (
 	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/log"
+	"go.temporal.io/sdk/temporal"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/protobuf/proto"
 
@@ -42,15 +43,28 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/shared/telemetry"
 )
 
+// UnsplittablePartitionErrorType is the application error type of SplitQRepPartition for partitions
+// whose range type cannot be split
+const UnsplittablePartitionErrorType = "unsplittable_partition"
+
 type CheckMetadataTablesResult struct {
 	NeedsSetupMetadataTables bool
 }
 
+// WorkerPoolLimits bounds concurrent sync, normalize and QRep partition work on a worker pool,
+// nil semaphores are unbounded
+type WorkerPoolLimits struct {
+	Sync      *concurrency.Semaphore
+	Normalize *concurrency.Semaphore
+	QRep      *concurrency.Semaphore
+}
+
 type FlowableActivity struct {
 	CatalogPool    shared.CatalogPool
 	Alerter        *alerting.Alerter
 	OtelManager    *otel_metrics.OtelManager
 	TemporalClient client.Client
+	PoolLimits     WorkerPoolLimits
 }
 
 type QRepStreamCloser interface {
@@ -423,12 +437,16 @@ func (a *FlowableActivity) SyncFlow(
 
 		var syncResponse *model.SyncResponse
 		var syncErr error
-		if config.System == protos.TypeSystem_Q {
-			syncResponse, syncErr = a.pullAndSync(groupCtx, config, options, srcConn.(connectors.CDCPullConnector),
-				normRequests, normResponses, normBufferSize, idleTimeout, &syncingBatchID, &syncState)
-		} else {
-			syncResponse, syncErr = a.pullAndSyncPg(groupCtx, config, options, srcConn.(connectors.CDCPullPgConnector),
-				normRequests, normResponses, normBufferSize, idleTimeout, &syncingBatchID, &syncState)
+		syncState.Store(new("waiting for worker pool slot"))
+		if syncErr = a.PoolLimits.Sync.Acquire(groupCtx); syncErr == nil {
+			if config.System == protos.TypeSystem_Q {
+				syncResponse, syncErr = a.pullAndSync(groupCtx, config, options, srcConn.(connectors.CDCPullConnector),
+					normRequests, normResponses, normBufferSize, idleTimeout, &syncingBatchID, &syncState)
+			} else {
+				syncResponse, syncErr = a.pullAndSyncPg(groupCtx, config, options, srcConn.(connectors.CDCPullPgConnector),
+					normRequests, normResponses, normBufferSize, idleTimeout, &syncingBatchID, &syncState)
+			}
+			a.PoolLimits.Sync.Release()
 		}
 
 		if syncErr != nil {
@@ -568,7 +586,7 @@ func (a *FlowableActivity) GetQRepPartitions(ctx context.Context,
 	if err := monitoring.InitializeQRepRun(ctx, logger, a.CatalogPool, config, runUUID, nil, config.ParentMirrorName); err != nil {
 		return nil, err
 	}
-	srcConn, srcClose, err := connectors.GetByNameAs[connectors.QRepPullConnectorCore](ctx, config.Env, a.CatalogPool, config.SourceName)
+	srcConn, srcClose, err := getQRepSourceAs[connectors.QRepPullConnectorCore](ctx, a.CatalogPool, config)
 	if err != nil {
 		return nil, a.Alerter.LogFlowError(ctx, config.FlowJobName, fmt.Errorf("failed to get qrep pull connector: %w", err))
 	}
@@ -650,8 +668,7 @@ func (a *FlowableActivity) ReplicateQRepPartitions(ctx context.Context,
 		slog.Int64("batchID", int64(partitions.BatchId)),
 		slog.Int("totalPartitions", numPartitions))
 
-	qRepPullCoreConn, qRepPullCoreClose, err := connectors.GetByNameAs[connectors.QRepPullConnectorCore](
-		ctx, config.Env, a.CatalogPool, config.SourceName)
+	qRepPullCoreConn, qRepPullCoreClose, err := getQRepSourceAs[connectors.QRepPullConnectorCore](ctx, a.CatalogPool, config)
 	if err != nil {
 		return a.Alerter.LogFlowError(ctx, config.FlowJobName, fmt.Errorf("failed to get qrep source connector: %w", err))
 	}
@@ -689,7 +706,12 @@ func (a *FlowableActivity) ReplicateQRepPartitions(ctx context.Context,
 		startTime := time.Now()
 		partLogger.Info(fmt.Sprintf("start replicating partition %d/%d of table %s", i+1, numPartitions, config.WatermarkTable))
 
-		if err := replicatePartitionFunc(partition); err != nil {
+		if err := a.PoolLimits.QRep.Acquire(ctx); err != nil {
+			return err
+		}
+		err := replicatePartitionFunc(partition)
+		a.PoolLimits.QRep.Release()
+		if err != nil {
 			partLogger.Error(fmt.Sprintf("failed to replicate partition %d/%d of table %s", i+1, numPartitions, config.WatermarkTable),
 				slog.Any("error", err))
 			return a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
@@ -708,6 +730,72 @@ func (a *FlowableActivity) ReplicateQRepPartitions(ctx context.Context,
 	return nil
 }
 
+// SplitQRepPartition splits a partition that is still being replicated into numPieces partitions,
+// offloading their ranges like GetQRepPartitions does. Returns nil when its range is too narrow to split,
+// or a non-retryable UnsplittablePartitionErrorType error for range types that cannot be split,
+// in both cases the partition is left to finish.
+func (a *FlowableActivity) SplitQRepPartition(ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	numPieces int64,
+	runUUID string,
+) ([]*protos.QRepPartition, error) {
+	ctx = context.WithValue(ctx, shared.FlowNameKey, config.FlowJobName)
+	logger := log.With(internal.LoggerFromCtx(ctx), slog.String(string(shared.FlowNameKey), config.FlowJobName))
+
+	offloaded := partition.RangeOffloaded
+	if err := connmetadata.RestoreOffloadedPartitionRanges(
+		ctx, a.CatalogPool, runUUID, []*protos.QRepPartition{partition},
+	); err != nil {
+		return nil, fmt.Errorf("failed to rehydrate partition range: %w", err)
+	}
+	// only the range left after the partition's last checkpoint needs to be split
+	checkpoint, err := connmetadata.LoadQRepPartitionCheckpoint(ctx, a.CatalogPool, runUUID, partition.PartitionId)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint != nil {
+		if checkpoint.Remaining == nil {
+			return nil, nil
+		}
+		partition.Range = checkpoint.Remaining
+	}
+	pieces, err := utils.SplitPartition(logger, partition, numPieces)
+	if errors.Is(err, utils.ErrPartitionRangeNotSplittable) {
+		return nil, temporal.NewNonRetryableApplicationError(err.Error(), UnsplittablePartitionErrorType, err)
+	} else if err != nil {
+		return nil, err
+	}
+	if offloaded && len(pieces) > 0 {
+		// pieces of a discarded split leave unused ranges behind until the mirror is dropped
+		if err := connmetadata.OffloadSplitPartitionRanges(
+			ctx, a.CatalogPool, config.ParentMirrorName, runUUID, pieces,
+		); err != nil {
+			return nil, fmt.Errorf("failed to offload partition ranges: %w", err)
+		}
+	}
+	return pieces, nil
+}
+
+// RecordQRepPartitionSplit records in the catalog that a cancelled partition was replaced by pieces
+func (a *FlowableActivity) RecordQRepPartitionSplit(ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	pieces []*protos.QRepPartition,
+	runUUID string,
+) error {
+	ctx = context.WithValue(ctx, shared.FlowNameKey, config.FlowJobName)
+	logger := log.With(internal.LoggerFromCtx(ctx), slog.String(string(shared.FlowNameKey), config.FlowJobName))
+
+	if err := monitoring.RecordPartitionSplit(ctx, logger, a.CatalogPool, config, runUUID, partition, pieces); err != nil {
+		return err
+	}
+
+	a.Alerter.LogFlowInfo(ctx, config.FlowJobName, fmt.Sprintf("split partition %s of table %s into %d partitions",
+		partition.PartitionId, config.WatermarkTable, len(pieces)))
+	return nil
+}
+
 func initializeReplicatePartitionFunc(
 	ctx context.Context,
 	a *FlowableActivity,
@@ -763,8 +851,33 @@ func initializeReplicatePartitionFunc(
 			}
 		}
 
+		checkpointRows, err := internal.PeerDBQRepCheckpointRows(ctx, config.Env)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read checkpoint rows setting: %w", err)
+		}
+		// a chunk synced again on retry overlaps rows already written, which only upserts and deduplicating tables absorb
+		resumableSrcConn, resumable := srcConn.(connectors.QRepResumablePullConnector)
+		resumable = resumable && checkpointRows > 0 && luaScript == nil
+		if resumable && config.WriteMode.GetWriteType() != protos.QRepWriteType_QREP_WRITE_MODE_UPSERT {
+			dedupConn, ok := destConn.(connectors.QRepDeduplicatingSyncConnector)
+			resumable = ok
+			if ok {
+				if resumable, err = dedupConn.DeduplicatesQRepRecords(ctx, config); err != nil {
+					return nil, fmt.Errorf("failed to check whether destination deduplicates records: %w", err)
+				}
+			}
+		}
+
 		return func(partition *protos.QRepPartition) error {
-			stream := model.NewQRecordStream(shared.QRepChannelSize)
+			if resumable && resumableSrcConn.SupportsQRepResumePoints(config, partition) {
+				return replicateQRepPartitionWithCheckpoints(
+					ctx, a, srcConn, destConn, dstType, config, partition, runUUID, checkpointRows)
+			}
+
+			stream, err := newQRepRecordStream(ctx, config.Env)
+			if err != nil {
+				return err
+			}
 			outstream := stream
 
 			if luaScript != nil {
@@ -935,13 +1048,13 @@ func (a *FlowableActivity) SendWALHeartbeat(ctx context.Context) error {
 
 		func() {
 			pgConfig := pgPeer.GetPostgresConfig()
-			pgConn, peerErr := connpostgres.NewPostgresConnector(ctx, nil, pgConfig)
+			pgConn, pgClose, peerErr := connectors.GetAs[*connpostgres.PostgresConnector](ctx, nil, pgPeer)
 			if peerErr != nil {
 				logger.Error("error creating connector for postgres peer",
 					slog.String("peer", pgPeer.Name), slog.String("host", pgConfig.Host), slog.Any("error", err))
 				return
 			}
-			defer pgConn.Close()
+			defer pgClose(ctx)
 			if cmdErr := pgConn.ExecuteCommand(ctx, walHeartbeatStatement); cmdErr != nil {
 				logger.Warn("could not send wal heartbeat to peer", slog.String("peer", pgPeer.Name), slog.Any("error", cmdErr))
 			}
@@ -1798,9 +1911,17 @@ func (a *FlowableActivity) ReplicateXminPartition(ctx context.Context,
 	})
 	defer shutdown()
 
+	if err := a.PoolLimits.QRep.Acquire(ctx); err != nil {
+		return 0, err
+	}
+	defer a.PoolLimits.QRep.Release()
+
 	switch config.System {
 	case protos.TypeSystem_Q:
-		stream := model.NewQRecordStream(shared.QRepChannelSize)
+		stream, err := newQRepRecordStream(ctx, config.Env)
+		if err != nil {
+			return 0, err
+		}
 		return replicateXminPartition(ctx, a, config, partition, runUUID,
 			stream, stream,
 			(*connpostgres.PostgresConnector).PullXminRecordStream,
@@ -2017,6 +2138,8 @@ func getPeerHostName(peerType protos.DBType, peer *protos.Peer) string {
 		return shared.Val(peer.GetMongoConfig()).TlsHost
 	case protos.DBType_CLICKHOUSE:
 		return shared.Val(peer.GetClickhouseConfig()).Host
+	case protos.DBType_ORACLE:
+		return shared.Val(peer.GetOracleConfig()).Host
 	}
 	return ""
 }
@@ -2101,6 +2224,36 @@ func (a *FlowableActivity) PeerDBFullRefreshOverwriteMode(ctx context.Context, e
 	return internal.PeerDBFullRefreshOverwriteMode(ctx, env)
 }
 
+// QRepPartitionSplitFactor returns how many times longer than the median a partition may run before it is split,
+// 0 when partitions must not be split: the pieces of a cancelled partition copy the rows it already wrote again,
+// which only upserts and deduplicating destination tables absorb
+func (a *FlowableActivity) QRepPartitionSplitFactor(ctx context.Context, config *protos.QRepConfig) (int64, error) {
+	splitFactor, err := internal.PeerDBQRepPartitionSplitFactor(ctx, config.Env)
+	if err != nil || splitFactor <= 0 {
+		return 0, err
+	}
+	if config.WriteMode.GetWriteType() == protos.QRepWriteType_QREP_WRITE_MODE_UPSERT {
+		return splitFactor, nil
+	}
+
+	dstConn, dstClose, err := connectors.GetByNameAs[connectors.QRepDeduplicatingSyncConnector](
+		ctx, config.Env, a.CatalogPool, config.DestinationName)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to get destination connector: %w", err)
+	}
+	defer dstClose(ctx)
+	deduplicates, err := dstConn.DeduplicatesQRepRecords(ctx, config)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check whether destination deduplicates records: %w", err)
+	}
+	if !deduplicates {
+		return 0, nil
+	}
+	return splitFactor, nil
+}
+
 func (a *FlowableActivity) PeerDBClickHouseInitialLoadAllowNonEmptyTables(
 	ctx context.Context, env map[string]string,
 ) (bool, error) {
@@ -2,6 +2,7 @@
 package activities
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
@@ -23,6 +24,7 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/connectors"
 	connmetadata "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
 	connpostgres "github.com/PeerDB-io/peerdb/flow/connectors/postgres"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils/monitoring"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
@@ -31,6 +33,7 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/shared"
 	"github.com/PeerDB-io/peerdb/flow/shared/concurrency"
 	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
 type PeerType string
@@ -40,6 +43,20 @@ const (
 	Destination PeerType = "destination"
 )
 
+// errStreamDone is returned by the sync side of a partition once it consumed the whole stream, cancelling the pull side.
+// Unlike context.Canceled it cannot be confused with a cancellation of the activity
+var errStreamDone = errors.New("stream done")
+
+// getQRepSourceAs connects to the QRep source peer, or to its read replica when the initial load reads from one
+func getQRepSourceAs[T connectors.Connector](
+	ctx context.Context, catalogPool shared.CatalogPool, config *protos.QRepConfig,
+) (T, func(context.Context), error) {
+	if config.ReadFromReplica {
+		return connectors.GetReplicaByNameAs[T](ctx, config.Env, catalogPool, config.SourceName)
+	}
+	return connectors.GetByNameAs[T](ctx, config.Env, catalogPool, config.SourceName)
+}
+
 func (a *FlowableActivity) getTableNameSchemaMapping(ctx context.Context, flowName string) (map[string]*protos.TableSchema, error) {
 	rows, err := a.CatalogPool.Query(ctx, "select table_name, table_schema from table_schema_mapping where flow_name = $1", flowName)
 	if err != nil {
@@ -187,6 +204,11 @@ func pullAndSyncCore[TPull connectors.CDCPullConnectorCore, TSync connectors.CDC
 		return nil, fmt.Errorf("failed to get CDC channel buffer size: %w", err)
 	}
 	recordBatchPull := model.NewCDCStream[Items](channelBufferSize)
+	timestampPolicy, err := model.NewTimestampPolicy(ctx, config.Env)
+	if err != nil {
+		return nil, err
+	}
+	recordBatchPull.SetTimestampPolicy(timestampPolicy)
 	recordBatchSync := recordBatchPull
 	if adaptStream != nil {
 		var err error
@@ -384,7 +406,7 @@ func pullAndSyncCore[TPull connectors.CDCPullConnectorCore, TSync connectors.CDC
 	}
 	if err := monitoring.UpdateNumRowsAndEndLSNForCDCBatch(
 		ctx, a.CatalogPool, flowName, res.CurrentSyncBatchID, uint32(res.NumRecordsSynced), lastCheckpoint,
-		firstRowReceivedAt, firstRowCommitTime,
+		firstRowReceivedAt, firstRowCommitTime, res.Warnings,
 	); err != nil {
 		return nil, a.Alerter.LogFlowError(ctx, flowName, err)
 	}
@@ -471,6 +493,18 @@ func (a *FlowableActivity) getPostgresPeerConfigs(ctx context.Context) ([]*proto
 	})
 }
 
+// newQRepRecordStream creates the stream a partition is pulled into, sending records through it applies
+// the timestamp policy of the mirror like CDC records get it when they are added to the CDC stream
+func newQRepRecordStream(ctx context.Context, env map[string]string) (*model.QRecordStream, error) {
+	timestampPolicy, err := model.NewTimestampPolicy(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	stream := model.NewQRecordStream(shared.QRepChannelSize)
+	stream.SetTimestampPolicy(timestampPolicy)
+	return stream, nil
+}
+
 // replicateQRepPartition replicates a QRepPartition from the source to the destination.
 func replicateQRepPartition[TRead any, TWrite QRepStreamCloser, TSync connectors.QRepSyncConnectorCore, TPull connectors.QRepPullConnectorCore](
 	ctx context.Context,
@@ -518,6 +552,7 @@ func replicateQRepPartition[TRead any, TWrite QRepStreamCloser, TSync connectors
 	logger.Info("replicating partition", slog.String("partitionId", partition.PartitionId))
 
 	var rowsSynced int64
+	var warnings shared.QRepWarnings
 	errGroup, errCtx := errgroup.WithContext(ctx)
 	errGroup.Go(func() error {
 		numRecords, numBytes, err := pullRecords(srcConn, errCtx, a.CatalogPool, a.OtelManager, config, dstType, partition, stream)
@@ -540,7 +575,6 @@ func replicateQRepPartition[TRead any, TWrite QRepStreamCloser, TSync connectors
 	})
 
 	errGroup.Go(func() error {
-		var warnings shared.QRepWarnings
 		var err error
 		rowsSynced, warnings, err = syncRecords(dstConn, errCtx, config, partition, outstream)
 		if err != nil {
@@ -550,12 +584,15 @@ func replicateQRepPartition[TRead any, TWrite QRepStreamCloser, TSync connectors
 		for _, warning := range warnings {
 			a.Alerter.LogFlowWarning(ctx, config.FlowJobName, warning)
 		}
-		return context.Canceled
+		return errStreamDone
 	})
 
-	if err := errGroup.Wait(); err != nil && err != context.Canceled {
+	if err := errGroup.Wait(); err != nil && !errors.Is(err, errStreamDone) {
 		return a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	if rowsSynced > 0 {
 		logger.Info(fmt.Sprintf("pushed %d records", rowsSynced))
@@ -564,7 +601,219 @@ func replicateQRepPartition[TRead any, TWrite QRepStreamCloser, TSync connectors
 		}
 	}
 
-	return monitoring.UpdateEndTimeForPartition(ctx, a.CatalogPool, runUUID, partition)
+	return monitoring.UpdateEndTimeForPartition(ctx, a.CatalogPool, config.FlowJobName, runUUID, partition, warnings)
+}
+
+// replicateQRepPartitionWithCheckpoints replicates a QRepPartition in chunks of about checkpointRows records,
+// each synced to the destination as a partition of its own. After a chunk is synced the source's resume point
+// is saved, so a retry pulls only what is left. A chunk that was synced without its checkpoint being saved
+// is read past on retry, but one whose sync failed midway may have written some of its rows,
+// so destinations must deduplicate.
+func replicateQRepPartitionWithCheckpoints(
+	ctx context.Context,
+	a *FlowableActivity,
+	srcConn connectors.QRepPullConnector,
+	dstConn connectors.QRepSyncConnector,
+	dstType protos.DBType,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	runUUID string,
+	checkpointRows int64,
+) error {
+	ctx = context.WithValue(ctx, shared.FlowNameKey, config.FlowJobName)
+	logger := log.With(internal.LoggerFromCtx(ctx), slog.String(string(shared.FlowNameKey), config.FlowJobName),
+		slog.String("partitionId", partition.PartitionId))
+
+	done, err := dstConn.IsQRepPartitionSynced(ctx, &protos.IsQRepPartitionSyncedInput{
+		FlowJobName: config.FlowJobName,
+		PartitionId: partition.PartitionId,
+	})
+	if err != nil {
+		return a.Alerter.LogFlowError(ctx, config.FlowJobName, fmt.Errorf("failed to get fetch status of partition: %w", err))
+	}
+	checkpoint, err := connmetadata.LoadQRepPartitionCheckpoint(ctx, a.CatalogPool, runUUID, partition.PartitionId)
+	if err != nil {
+		return a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
+	}
+	if done || (checkpoint != nil && checkpoint.Remaining == nil) {
+		logger.Info("no records to push for partition " + partition.PartitionId)
+		activity.RecordHeartbeat(ctx, "no records to push for partition "+partition.PartitionId)
+		return nil
+	}
+
+	pullPartition := partition
+	if checkpoint != nil {
+		logger.Info("resuming partition from checkpoint",
+			slog.Int("chunk", int(checkpoint.NextChunk)), slog.Int64("rowsSynced", checkpoint.RowsSynced))
+		pullPartition = proto.CloneOf(partition)
+		pullPartition.Range = checkpoint.Remaining
+	} else {
+		if err := monitoring.UpdateStartTimeForPartition(ctx, a.CatalogPool, runUUID, partition, time.Now()); err != nil {
+			return a.Alerter.LogFlowError(ctx, config.FlowJobName, fmt.Errorf("failed to update start time for partition: %w", err))
+		}
+		checkpoint = &connmetadata.QRepPartitionCheckpoint{Remaining: partition.Range}
+	}
+
+	logger.Info("replicating partition with checkpoints", slog.Int64("checkpointRows", checkpointRows))
+
+	stream, err := newQRepRecordStream(ctx, config.Env)
+	if err != nil {
+		return err
+	}
+	stream.EnableResumePoints(checkpointRows)
+	resumedRows := checkpoint.RowsSynced
+	errGroup, errCtx := errgroup.WithContext(ctx)
+	errGroup.Go(func() error {
+		numRecords, numBytes, err := srcConn.PullQRepRecords(errCtx, a.CatalogPool, a.OtelManager, config, dstType, pullPartition, stream)
+		stream.Close(err)
+		if err != nil {
+			return a.Alerter.LogFlowError(ctx, config.FlowJobName, shared.WrapError("[qrep] failed to pull records", err))
+		}
+		a.OtelManager.Metrics.FetchedBytesCounter.Add(ctx, numBytes)
+		if err := monitoring.UpdatePullEndTimeAndRowsForPartition(
+			errCtx, a.CatalogPool, runUUID, partition, resumedRows+numRecords,
+		); err != nil {
+			logger.Error(err.Error())
+		}
+		return nil
+	})
+
+	errGroup.Go(func() error {
+		var received int64
+		for {
+			chunkPartition := &protos.QRepPartition{
+				PartitionId: utils.PartitionChunkID(partition.PartitionId, checkpoint.NextChunk),
+				Range:       checkpoint.Remaining,
+			}
+			synced, err := dstConn.IsQRepPartitionSynced(errCtx, &protos.IsQRepPartitionSyncedInput{
+				FlowJobName: config.FlowJobName,
+				PartitionId: chunkPartition.PartitionId,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to get fetch status of chunk %s: %w", chunkPartition.PartitionId, err)
+			}
+			if synced {
+				logger.Info("skipping chunk synced before its checkpoint was saved", slog.String("chunkId", chunkPartition.PartitionId))
+			}
+			remaining, rowsSynced, warnings, err := syncQRepChunk(
+				errCtx, a, dstConn, config, chunkPartition, stream, &received, checkpointRows, synced)
+			if err != nil {
+				return err
+			}
+
+			checkpoint = &connmetadata.QRepPartitionCheckpoint{
+				Remaining:  remaining,
+				RowsSynced: checkpoint.RowsSynced + rowsSynced,
+				NextChunk:  checkpoint.NextChunk + 1,
+			}
+			// recorded before the checkpoint, so that a chunk whose counts failed to be written is not skipped on retry
+			if err := monitoring.UpdateRowsSyncedForPartitionChunk(errCtx, a.CatalogPool, config.FlowJobName,
+				checkpoint.RowsSynced, runUUID, partition, chunkPartition.PartitionId, warnings,
+			); err != nil {
+				return err
+			}
+			if err := connmetadata.SaveQRepPartitionCheckpoint(
+				errCtx, a.CatalogPool, cmp.Or(config.ParentMirrorName, config.FlowJobName), runUUID, partition.PartitionId, checkpoint,
+			); err != nil {
+				return err
+			}
+			activity.RecordHeartbeat(ctx, fmt.Sprintf("synced %d records of partition %s", checkpoint.RowsSynced, partition.PartitionId))
+			if remaining == nil {
+				return errStreamDone
+			}
+		}
+	})
+
+	if err := errGroup.Wait(); err != nil && !errors.Is(err, errStreamDone) {
+		return a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("pushed %d records", checkpoint.RowsSynced))
+	// the numeric truncation counts of each chunk were recorded with its rows synced
+	return monitoring.UpdateEndTimeForPartition(ctx, a.CatalogPool, config.FlowJobName, runUUID, partition, nil)
+}
+
+// syncQRepChunk syncs records from stream until at least checkpointRows were read and a resume point
+// was reached, received counts the records read from stream across chunks.
+// A chunk already synced is only read from stream, not synced again.
+// Returns the resume point, or nil once the stream is exhausted, and the warnings of the chunk's sync.
+func syncQRepChunk(
+	ctx context.Context,
+	a *FlowableActivity,
+	dstConn connectors.QRepSyncConnector,
+	config *protos.QRepConfig,
+	chunkPartition *protos.QRepPartition,
+	stream *model.QRecordStream,
+	received *int64,
+	checkpointRows int64,
+	synced bool,
+) (*protos.PartitionRange, int64, shared.QRepWarnings, error) {
+	schema, err := stream.Schema()
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	chunk := model.NewQRecordStream(shared.QRepChannelSize)
+	chunk.SetSchema(schema)
+	chunk.SetSchemaDebug(stream.SchemaDebug())
+
+	var remaining *protos.PartitionRange
+	var rowsSynced int64
+	var warnings shared.QRepWarnings
+	chunkGroup, chunkCtx := errgroup.WithContext(ctx)
+	chunkGroup.Go(func() error {
+		var chunkRows int64
+		for {
+			var record []types.QValue
+			var ok bool
+			select {
+			case record, ok = <-stream.Records:
+			case <-chunkCtx.Done():
+				chunk.Close(chunkCtx.Err())
+				return chunkCtx.Err()
+			}
+			if !ok {
+				// source errors fail the chunk's sync rather than committing a partial chunk
+				chunk.Close(stream.Err())
+				return stream.Err()
+			}
+			if err := chunk.Send(chunkCtx, record); err != nil {
+				chunk.Close(err)
+				return err
+			}
+			*received += 1
+			chunkRows += 1
+			if chunkRows >= checkpointRows {
+				if remaining = stream.TakeResumePoint(*received); remaining != nil {
+					chunk.Close(nil)
+					return nil
+				}
+			}
+		}
+	})
+	chunkGroup.Go(func() error {
+		if synced {
+			for range chunk.Records {
+				rowsSynced += 1
+			}
+			return chunk.Err()
+		}
+		var err error
+		rowsSynced, warnings, err = dstConn.SyncQRepRecords(chunkCtx, config, chunkPartition, chunk)
+		if err != nil {
+			return shared.WrapError("failed to sync records", err)
+		}
+		for _, warning := range warnings {
+			a.Alerter.LogFlowWarning(ctx, config.FlowJobName, warning)
+		}
+		return nil
+	})
+	if err := chunkGroup.Wait(); err != nil {
+		return nil, 0, nil, err
+	}
+	return remaining, rowsSynced, warnings, nil
 }
 
 // replicateXminPartition replicates a XminPartition from the source to the destination.
@@ -600,6 +849,7 @@ func replicateXminPartition[TRead any, TWrite QRepStreamCloser, TSync connectors
 
 	var currentSnapshotXmin int64
 	var rowsSynced int64
+	var warnings shared.QRepWarnings
 	errGroup.Go(func() error {
 		srcConn, srcClose, err := connectors.GetByNameAs[*connpostgres.PostgresConnector](ctx, config.Env, a.CatalogPool, config.SourceName)
 		if err != nil {
@@ -657,7 +907,6 @@ func replicateXminPartition[TRead any, TWrite QRepStreamCloser, TSync connectors
 	})
 
 	errGroup.Go(func() error {
-		var warnings shared.QRepWarnings
 		var err error
 		rowsSynced, warnings, err = syncRecords(dstConn, ctx, config, partition, outstream)
 		if err != nil {
@@ -667,12 +916,15 @@ func replicateXminPartition[TRead any, TWrite QRepStreamCloser, TSync connectors
 		for _, warning := range warnings {
 			a.Alerter.LogFlowWarning(ctx, config.FlowJobName, warning)
 		}
-		return context.Canceled
+		return errStreamDone
 	})
 
-	if err := errGroup.Wait(); err != nil && err != context.Canceled {
+	if err := errGroup.Wait(); err != nil && !errors.Is(err, errStreamDone) {
 		return 0, a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
 	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
 
 	if rowsSynced > 0 {
 		err := monitoring.UpdateRowsSyncedForPartition(ctx, a.CatalogPool, rowsSynced, runUUID, partition)
@@ -683,7 +935,7 @@ func replicateXminPartition[TRead any, TWrite QRepStreamCloser, TSync connectors
 		logger.Info(fmt.Sprintf("pushed %d records", rowsSynced))
 	}
 
-	if err := monitoring.UpdateEndTimeForPartition(ctx, a.CatalogPool, runUUID, partition); err != nil {
+	if err := monitoring.UpdateEndTimeForPartition(ctx, a.CatalogPool, config.FlowJobName, runUUID, partition, warnings); err != nil {
 		return 0, err
 	}
 
@@ -698,6 +950,11 @@ func (a *FlowableActivity) startNormalize(
 ) error {
 	logger := internal.LoggerFromCtx(ctx)
 
+	if err := a.PoolLimits.Normalize.Acquire(ctx); err != nil {
+		return err
+	}
+	defer a.PoolLimits.Normalize.Release()
+
 	// record gauge on error as well
 	defer func() {
 		a.OtelManager.Metrics.LastNormalizedBatchIdGauge.Record(ctx, normalizeResponses.Load(),
@@ -738,6 +995,7 @@ func (a *FlowableActivity) startNormalize(
 				TableMappings:          config.TableMappings,
 				SoftDeleteColName:      config.SoftDeleteColName,
 				SyncedAtColName:        config.SyncedAtColName,
+				ClickHouseDeleteMode:   config.ClickhouseDeleteMode,
 				SyncBatchID:            batchID,
 				Version:                config.Version,
 				Flags:                  config.Flags,
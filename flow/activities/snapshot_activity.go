@@ -7,10 +7,12 @@ import (
 	"sync"
 	"time"
 
+	"github.com/jackc/pglogrepl"
 	"go.temporal.io/sdk/activity"
 
 	"github.com/PeerDB-io/peerdb/flow/alerting"
 	"github.com/PeerDB-io/peerdb/flow/connectors"
+	connpostgres "github.com/PeerDB-io/peerdb/flow/connectors/postgres"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/pkg/common"
@@ -66,7 +68,8 @@ func (a *SnapshotActivity) SetupReplication(
 	logger := internal.LoggerFromCtx(ctx)
 	a.Alerter.LogFlowInfo(ctx, config.FlowJobName, "Setting up replication slot and publication")
 
-	conn, connClose, err := connectors.GetByNameAs[connectors.CDCPullConnectorCore](ctx, nil, a.CatalogPool, config.PeerName)
+	peer, conn, connClose, err := connectors.LoadPeerAndGetByNameAs[connectors.CDCPullConnectorCore](
+		ctx, nil, a.CatalogPool, config.PeerName)
 	if err != nil {
 		return nil, a.Alerter.LogFlowError(ctx, config.FlowJobName, fmt.Errorf("failed to get connector: %w", err))
 	}
@@ -98,8 +101,10 @@ func (a *SnapshotActivity) SetupReplication(
 	a.Alerter.LogFlowInfo(ctx, config.FlowJobName, "Replication slot and publication setup complete")
 
 	return &protos.SetupReplicationOutput{
-		SlotName:     slotInfo.SlotName,
-		SnapshotName: slotInfo.SnapshotName,
+		SlotName:               slotInfo.SlotName,
+		SnapshotName:           slotInfo.SnapshotName,
+		ConsistentPoint:        slotInfo.ConsistentPoint,
+		InitialLoadFromReplica: slotInfo.ConsistentPoint != "" && peer.GetPostgresConfig().GetReplicaHost() != "",
 	}, nil
 }
 
@@ -114,6 +119,65 @@ func (a *SnapshotActivity) MaintainTx(ctx context.Context, sessionID string, flo
 	}
 	defer connClose(ctx)
 
+	return a.maintainExportedTx(ctx, sessionID, flowName, conn, env)
+}
+
+// ReplicaSnapshotLSN returns the primary's current WAL position when the peer has a read replica,
+// so an initial snapshot only load can read from the replica once it replayed that far; empty otherwise.
+func (a *SnapshotActivity) ReplicaSnapshotLSN(ctx context.Context, flowName string, peerName string) (string, error) {
+	ctx = context.WithValue(ctx, shared.FlowNameKey, flowName)
+	peer, err := connectors.LoadPeer(ctx, a.CatalogPool, peerName)
+	if err != nil {
+		return "", a.Alerter.LogFlowError(ctx, flowName, fmt.Errorf("failed to load peer: %w", err))
+	}
+	if peer.GetPostgresConfig().GetReplicaHost() == "" {
+		return "", nil
+	}
+	conn, connClose, err := connectors.GetAs[*connpostgres.PostgresConnector](ctx, nil, peer)
+	if err != nil {
+		return "", a.Alerter.LogFlowError(ctx, flowName, fmt.Errorf("failed to get connector: %w", err))
+	}
+	defer connClose(ctx)
+
+	lsn, err := conn.CurrentWALLSN(ctx)
+	if err != nil {
+		return "", a.Alerter.LogFlowError(ctx, flowName, err)
+	}
+	return lsn.String(), nil
+}
+
+// MaintainReplicaTx exports a snapshot on the peer's read replica once it has replayed past consistentPoint,
+// letting initial load read from the replica instead of the primary.
+func (a *SnapshotActivity) MaintainReplicaTx(
+	ctx context.Context, sessionID string, flowName string, peer string, consistentPoint string, env map[string]string,
+) error {
+	ctx = context.WithValue(ctx, shared.FlowNameKey, flowName)
+	shutdown := common.HeartbeatRoutine(ctx, func() string {
+		return "maintaining replica transaction snapshot"
+	})
+	defer shutdown()
+	lsn, err := pglogrepl.ParseLSN(consistentPoint)
+	if err != nil {
+		return fmt.Errorf("failed to parse consistent point %s: %w", consistentPoint, err)
+	}
+	conn, connClose, err := connectors.GetReplicaByNameAs[*connpostgres.PostgresConnector](ctx, nil, a.CatalogPool, peer)
+	if err != nil {
+		return a.Alerter.LogFlowError(ctx, flowName, fmt.Errorf("failed to get replica connector: %w", err))
+	}
+	defer connClose(ctx)
+
+	a.Alerter.LogFlowInfo(ctx, flowName, "Waiting for read replica to replay past "+consistentPoint)
+	if err := conn.WaitForReplayLSN(ctx, lsn); err != nil {
+		return a.Alerter.LogFlowError(ctx, flowName, err)
+	}
+	a.Alerter.LogFlowInfo(ctx, flowName, "Read replica caught up, initial load will read from replica")
+
+	return a.maintainExportedTx(ctx, sessionID, flowName, conn, env)
+}
+
+func (a *SnapshotActivity) maintainExportedTx(
+	ctx context.Context, sessionID string, flowName string, conn connectors.CDCPullConnectorCore, env map[string]string,
+) error {
 	exportSnapshotOutput, tx, err := conn.ExportTxSnapshot(ctx, flowName, env)
 	if err != nil {
 		return err
@@ -96,8 +96,10 @@ const (
 	ErrorSourceClickHouse      ErrorSource = "clickhouse"
 	ErrorSourcePostgres        ErrorSource = "postgres"
 	ErrorSourceCockroachDB     ErrorSource = "cockroachdb"
+	ErrorSourceOracle          ErrorSource = "oracle"
 	ErrorSourceMySQL           ErrorSource = "mysql"
 	ErrorSourceMongoDB         ErrorSource = "mongodb"
+	ErrorSourceDynamoDB        ErrorSource = "dynamodb"
 	ErrorSourceBigQuery        ErrorSource = "bigquery"
 	ErrorSourceGCS             ErrorSource = "gcs"
 	ErrorSourcePostgresCatalog ErrorSource = "postgres_catalog"
@@ -160,6 +162,10 @@ var (
 	ErrorNotifyChangefeedInvalid = ErrorClass{
 		Class: "NOTIFY_CHANGEFEED_INVALID", action: NotifyUser,
 	}
+	// Oracle redo logs needed by LogMiner were purged before they were consumed
+	ErrorNotifyRedoLogInvalid = ErrorClass{
+		Class: "NOTIFY_REDO_LOG_INVALID", action: NotifyUser,
+	}
 	ErrorNotifyBinlogEventExceededMaxAllowedPacket = ErrorClass{
 		Class: "NOTIFY_BINLOG_EVENT_EXCEEDED_MAX_ALLOWED_PACKET", action: NotifyUser,
 	}
@@ -276,7 +282,7 @@ var (
 	ErrorNotifyAuroraFailover = ErrorClass{
 		Class: "NOTIFY_AURORA_FAILOVER", action: NotifyUser,
 	}
-	// Mongo specific, equivalent to slot invalidation in Postgres
+	// Mongo and DynamoDB Streams specific, equivalent to slot invalidation in Postgres
 	ErrorNotifyChangeStreamHistoryLost = ErrorClass{
 		Class: "NOTIFY_CHANGE_STREAM_HISTORY_LOST", action: NotifyUser,
 	}
@@ -389,6 +395,20 @@ func GetErrorClass(ctx context.Context, err error) (ErrorClass, ErrorInfo) {
 		}
 	}
 
+	if _, ok := errors.AsType[*exceptions.OracleRedoLogMissingError](err); ok {
+		return ErrorNotifyRedoLogInvalid, ErrorInfo{
+			Source: ErrorSourceOracle,
+			Code:   "REDO_LOG_MISSING",
+		}
+	}
+
+	if _, ok := errors.AsType[*exceptions.DynamoDBStreamHistoryLostError](err); ok {
+		return ErrorNotifyChangeStreamHistoryLost, ErrorInfo{
+			Source: ErrorSourceDynamoDB,
+			Code:   "STREAM_HISTORY_LOST",
+		}
+	}
+
 	if errors.Is(err, context.Canceled) {
 		// Generally happens during workflow cancellation
 		return ErrorIgnoreContextCancelled, ErrorInfo{
@@ -115,6 +115,32 @@ func TestCockroachChangefeedIrrecoverableErrorShouldNotifyUser(t *testing.T) {
 	}
 }
 
+func TestOracleRedoLogMissingErrorShouldNotifyUser(t *testing.T) {
+	t.Parallel()
+
+	err := fmt.Errorf("failed to mine redo: %w", exceptions.NewOracleRedoLogMissingError(1234567))
+	errorClass, errInfo := GetErrorClass(t.Context(), err)
+	assert.Equal(t, ErrorNotifyRedoLogInvalid, errorClass, "Unexpected error class")
+	assert.Equal(t, NotifyUser, errorClass.ErrorAction(), "Unexpected error action")
+	assert.Equal(t, ErrorInfo{
+		Source: ErrorSourceOracle,
+		Code:   "REDO_LOG_MISSING",
+	}, errInfo, "Unexpected error info")
+}
+
+func TestDynamoDBStreamHistoryLostErrorShouldNotifyUser(t *testing.T) {
+	t.Parallel()
+
+	err := fmt.Errorf("failed to read shard: %w",
+		exceptions.NewDynamoDBStreamHistoryLostError("us-east-1.orders", "shard records were trimmed"))
+	errorClass, errInfo := GetErrorClass(t.Context(), err)
+	assert.Equal(t, ErrorNotifyChangeStreamHistoryLost, errorClass, "Unexpected error class")
+	assert.Equal(t, ErrorInfo{
+		Source: ErrorSourceDynamoDB,
+		Code:   "STREAM_HISTORY_LOST",
+	}, errInfo, "Unexpected error info")
+}
+
 func TestUnwrappedPgErrorShouldKeepPostgresSource(t *testing.T) {
 	t.Parallel()
 
@@ -1112,8 +1138,8 @@ func TestNonClassifiedNonNormalizeErrorShouldBeOtherWithSourceClickHouse(t *test
 
 func TestNumericTruncateOrOutOfRangeWarningShouldBeLossyConversion(t *testing.T) {
 	for code, err := range map[string]error{
-		"NUMERIC_TRUNCATED":    exceptions.NewNumericTruncatedError(errors.New("testing numeric truncated warning"), "tableA1", "columnB2"),
-		"NUMERIC_OUT_OF_RANGE": exceptions.NewNumericOutOfRangeError(errors.New("testing numeric out of range warning"), "tableA1", "columnB2"),
+		"NUMERIC_TRUNCATED":    exceptions.NewNumericTruncatedError(errors.New("testing numeric truncated warning"), "tableA1", "columnB2", 1),
+		"NUMERIC_OUT_OF_RANGE": exceptions.NewNumericOutOfRangeError(errors.New("testing numeric out of range warning"), "tableA1", "columnB2", 1),
 	} {
 		t.Run(code, func(t *testing.T) {
 			errorClass, errInfo := GetErrorClass(t.Context(), fmt.Errorf("lossy conversion: %w", err))
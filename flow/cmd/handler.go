@@ -213,7 +213,7 @@ func (h *FlowRequestHandler) CreateCDCFlow(
 	}
 
 	if resp, err := h.createCDCFlow(ctx, connectionConfigsCore, workflowID); err != nil {
-		return nil, NewInternalApiError(err)
+		return nil, err
 	} else {
 		telemetry.LogActivityCreateFlow(ctx, connectionConfigsCore.FlowJobName)
 		return resp, nil
@@ -222,10 +222,14 @@ func (h *FlowRequestHandler) CreateCDCFlow(
 
 func (h *FlowRequestHandler) createCDCFlow(
 	ctx context.Context, connectionConfigs *protos.FlowConnectionConfigsCore, workflowID string,
-) (*protos.CreateCDCFlowResponse, error) {
+) (*protos.CreateCDCFlowResponse, APIError) {
+	if apiErr := h.checkWorkerPool(ctx, connectionConfigs.WorkerPool); apiErr != nil {
+		return nil, apiErr
+	}
+
 	workflowOptions := client.StartWorkflowOptions{
 		ID:                       workflowID,
-		TaskQueue:                h.peerflowTaskQueueID,
+		TaskQueue:                h.workerPoolTaskQueue(connectionConfigs.WorkerPool),
 		TypedSearchAttributes:    shared.NewSearchAttributes(connectionConfigs.FlowJobName),
 		WorkflowIDConflictPolicy: tEnums.WORKFLOW_ID_CONFLICT_POLICY_USE_EXISTING, // two racing requests end up with the same workflow
 		WorkflowIDReusePolicy:    tEnums.WORKFLOW_ID_REUSE_POLICY_ALLOW_DUPLICATE, // but creating the same id as a completed one is allowed
@@ -233,12 +237,12 @@ func (h *FlowRequestHandler) createCDCFlow(
 
 	if err := h.createCdcJobEntry(ctx, connectionConfigs, workflowID, true); err != nil {
 		slog.ErrorContext(ctx, "unable to create flow job entry", slog.Any("error", err))
-		return nil, fmt.Errorf("unable to create flow job entry: %w", err)
+		return nil, NewInternalApiError(fmt.Errorf("unable to create flow job entry: %w", err))
 	}
 
 	if _, err := h.temporalClient.ExecuteWorkflow(ctx, workflowOptions, peerflow.CDCFlowWorkflow, connectionConfigs, nil); err != nil {
 		slog.ErrorContext(ctx, "unable to start PeerFlow workflow", slog.Any("error", err))
-		return nil, fmt.Errorf("unable to start PeerFlow workflow: %w", err)
+		return nil, NewInternalApiError(fmt.Errorf("unable to start PeerFlow workflow: %w", err))
 	}
 
 	return &protos.CreateCDCFlowResponse{
@@ -255,6 +259,12 @@ func (h *FlowRequestHandler) CreateQRepFlow(
 	} else {
 		cfg.Version = internalVersion
 	}
+	if apiErr := checkTimestampPolicy(ctx, cfg.Env, cfg.System); apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := h.checkWorkerPool(ctx, cfg.WorkerPool); apiErr != nil {
+		return nil, apiErr
+	}
 	if flags, err := h.determineFlags(ctx, cfg.Env, cfg.DestinationName); err != nil {
 		return nil, NewInternalApiError(err)
 	} else {
@@ -264,7 +274,7 @@ func (h *FlowRequestHandler) CreateQRepFlow(
 	workflowID := fmt.Sprintf("%s-qrepflow-%s", cfg.FlowJobName, uuid.New())
 	workflowOptions := client.StartWorkflowOptions{
 		ID:                    workflowID,
-		TaskQueue:             h.peerflowTaskQueueID,
+		TaskQueue:             h.workerPoolTaskQueue(cfg.WorkerPool),
 		TypedSearchAttributes: shared.NewSearchAttributes(cfg.FlowJobName),
 	}
 	if err := h.createQRepJobEntry(ctx, req, workflowID); err != nil {
@@ -448,6 +458,7 @@ func (h *FlowRequestHandler) FlowStateChange(
 		return nil, NewInternalApiError(err)
 	}
 
+	var warnings []string
 	if req.FlowConfigUpdate != nil && req.FlowConfigUpdate.GetCdcFlowConfigUpdate() != nil &&
 		// Don't allow config updates if the flow is already in a terminal state since it can lead to confusion
 		//  where the config is updated but the flow is not reflecting those changes since it's already completed/failed
@@ -455,6 +466,11 @@ func (h *FlowRequestHandler) FlowStateChange(
 			currState != protos.FlowStatus_STATUS_TERMINATING &&
 			currState != protos.FlowStatus_STATUS_FAILED &&
 			currState != protos.FlowStatus_STATUS_COMPLETED) {
+		var apiErr APIError
+		if warnings, apiErr = h.validateCDCFlowConfigUpdate(ctx, req.FlowJobName,
+			req.FlowConfigUpdate.GetCdcFlowConfigUpdate()); apiErr != nil {
+			return nil, apiErr
+		}
 		if err := model.CDCDynamicPropertiesSignal.SignalClientWorkflow(
 			ctx,
 			h.temporalClient,
@@ -557,7 +573,7 @@ func (h *FlowRequestHandler) FlowStateChange(
 		}
 	}
 
-	return &protos.FlowStateChangeResponse{}, nil
+	return &protos.FlowStateChangeResponse{Warnings: warnings}, nil
 }
 
 func (h *FlowRequestHandler) handleCancelWorkflow(ctx context.Context, workflowID, runID string) error {
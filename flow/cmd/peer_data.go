@@ -18,6 +18,7 @@ import (
 	connpostgres "github.com/PeerDB-io/peerdb/flow/connectors/postgres"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
 )
 
@@ -109,9 +110,9 @@ func (h *FlowRequestHandler) ListPeers(
 	query := "SELECT name, type FROM peers"
 	if internal.PeerDBOnlyClickHouseAllowed() {
 		// only the sources offered in ClickHouse-only mode plus clickhouse itself
-		query += fmt.Sprintf(" WHERE type IN (%d,%d,%d,%d,%d)",
+		query += fmt.Sprintf(" WHERE type IN (%d,%d,%d,%d,%d,%d,%d)",
 			protos.DBType_POSTGRES, protos.DBType_MYSQL, protos.DBType_MONGO,
-			protos.DBType_COCKROACHDB, protos.DBType_CLICKHOUSE)
+			protos.DBType_COCKROACHDB, protos.DBType_ORACLE, protos.DBType_DYNAMODB, protos.DBType_CLICKHOUSE)
 	}
 	rows, err := h.pool.Query(ctx, query)
 	if err != nil {
@@ -134,12 +135,13 @@ func (h *FlowRequestHandler) ListPeers(
 			peer.Type == protos.DBType_MYSQL ||
 			peer.Type == protos.DBType_MONGO ||
 			peer.Type == protos.DBType_BIGQUERY ||
-			peer.Type == protos.DBType_COCKROACHDB {
+			peer.Type == protos.DBType_COCKROACHDB ||
+			peer.Type == protos.DBType_ORACLE ||
+			peer.Type == protos.DBType_DYNAMODB {
 			sourceItems = append(sourceItems, peer)
 		}
-		if peer.Type != protos.DBType_MYSQL &&
-			peer.Type != protos.DBType_COCKROACHDB &&
-			peer.Type != protos.DBType_MONGO && (!internal.PeerDBOnlyClickHouseAllowed() || peer.Type == protos.DBType_CLICKHOUSE) {
+		if peer.Type != protos.DBType_MYSQL && peer.Type != protos.DBType_ORACLE && peer.Type != protos.DBType_DYNAMODB &&
+			(!internal.PeerDBOnlyClickHouseAllowed() || peer.Type == protos.DBType_CLICKHOUSE) {
 			destinationItems = append(destinationItems, peer)
 		}
 	}
@@ -208,7 +210,28 @@ func (h *FlowRequestHandler) GetColumnsTypeConversion(
 	ctx context.Context,
 	req *protos.ColumnsTypeConversionRequest,
 ) (*protos.ColumnsTypeConversionResponse, APIError) {
-	return wrapErrorAsFailedPrecondition(connclickhouse.GetColumnsTypeConversion())
+	res, err := connclickhouse.GetColumnsTypeConversion()
+	if err != nil {
+		return nil, NewFailedPreconditionApiError(err)
+	}
+
+	var env map[string]string
+	if req.FlowJobName != "" {
+		config, err := h.getFlowConfigFromCatalog(ctx, req.FlowJobName)
+		if err != nil {
+			return nil, NewInternalApiError(fmt.Errorf("unable to get flow config: %w", err))
+		}
+		env = config.Env
+	}
+	timestampPolicy, err := model.NewTimestampPolicy(ctx, env)
+	if err != nil {
+		return nil, NewFailedPreconditionApiError(err)
+	}
+	res.TimestampPolicy = &protos.TimestampPolicy{TimestamptzConvertToUtc: timestampPolicy.ConvertToUTC}
+	if timestampPolicy.NaiveZone != nil {
+		res.TimestampPolicy.NaiveTimeZone = timestampPolicy.NaiveZone.String()
+	}
+	return res, nil
 }
 
 func (h *FlowRequestHandler) GetSlotInfo(
@@ -96,7 +96,7 @@ func SnapshotWorkerMain(ctx context.Context, opts *SnapshotWorkerOptions) (*Work
 
 	return &WorkerSetupResponse{
 		Client:         c,
-		Worker:         w,
+		Workers:        []worker.Worker{w},
 		OtelManager:    otelManager,
 		TracerProvider: tracerProvider,
 	}, nil
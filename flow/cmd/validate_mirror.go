@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"regexp"
 	"slices"
 
@@ -13,13 +14,15 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/connectors"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/pkg/common"
 	"github.com/PeerDB-io/peerdb/flow/proto_conversions"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
-var CustomColumnTypeRegex = regexp.MustCompile(`^$|^[a-zA-Z][a-zA-Z0-9(),]*$`)
+// allows quoted arguments such as time zones, e.g. DateTime64(6, 'Asia/Tokyo')
+var CustomColumnTypeRegex = regexp.MustCompile(`^$|^[a-zA-Z][a-zA-Z0-9(), ]*('[a-zA-Z0-9_/+\-]*'[a-zA-Z0-9(), ]*)*$`)
 
 type flagConstraint struct {
 	ErrorMessage  string
@@ -96,12 +99,16 @@ func (h *FlowRequestHandler) validateCDCMirrorImpl(
 			fmt.Errorf("invalid config: initial_snapshot_only is true but do_initial_snapshot is false"))
 	}
 
-	for _, tm := range connectionConfigs.TableMappings {
-		for _, col := range tm.Columns {
-			if !CustomColumnTypeRegex.MatchString(col.DestinationType) {
-				return nil, NewInvalidArgumentApiError(fmt.Errorf("invalid custom column type %s", col.DestinationType))
-			}
-		}
+	if apiErr := checkColumnSettings(connectionConfigs.TableMappings); apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := checkTimestampPolicy(ctx, connectionConfigs.Env, connectionConfigs.System); apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := h.checkWorkerPool(ctx, connectionConfigs.WorkerPool); apiErr != nil {
+		return nil, apiErr
 	}
 
 	if apiErr := h.checkSourcePeerReuse(ctx, connectionConfigs); apiErr != nil {
@@ -155,12 +162,18 @@ func (h *FlowRequestHandler) validateCDCMirrorImpl(
 		}
 	}
 
+	warnings, apiErr := h.validateColumnTypeOverrides(ctx, connectionConfigs.Env, connectionConfigs.Version, connectionConfigs.System,
+		connectionConfigs.SourceName, connectionConfigs.DestinationName, connectionConfigs.TableMappings)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
 	dstConn, dstClose, err := connectors.GetByNameAs[connectors.MirrorDestinationValidationConnector](
 		ctx, connectionConfigs.Env, h.pool, connectionConfigs.DestinationName,
 	)
 	if err != nil {
 		if errors.Is(err, errors.ErrUnsupported) {
-			return &protos.ValidateCDCMirrorResponse{}, nil
+			return &protos.ValidateCDCMirrorResponse{Warnings: warnings}, nil
 		}
 		return nil, NewFailedPreconditionApiError(fmt.Errorf("failed to create destination connector: %w", err))
 	}
@@ -180,7 +193,185 @@ func (h *FlowRequestHandler) validateCDCMirrorImpl(
 			fmt.Errorf("failed to validate destination connector %s: %w", connectionConfigs.DestinationName, err))
 	}
 
-	return &protos.ValidateCDCMirrorResponse{}, nil
+	return &protos.ValidateCDCMirrorResponse{Warnings: warnings}, nil
+}
+
+// checkColumnSettings checks the syntax of destination types in column settings of table mappings and their projections
+func checkColumnSettings(tableMappings []*protos.TableMapping) APIError {
+	for _, tm := range tableMappings {
+		for _, target := range append([]*protos.TableMapping{tm}, tm.Projections...) {
+			for _, col := range target.Columns {
+				if !CustomColumnTypeRegex.MatchString(col.DestinationType) {
+					return NewInvalidArgumentApiError(fmt.Errorf("invalid custom column type %s", col.DestinationType))
+				}
+				for _, field := range col.JsonArray.GetFields() {
+					if field.Name == "" || field.Type == "" || !CustomColumnTypeRegex.MatchString(field.Type) {
+						return NewInvalidArgumentApiError(
+							fmt.Errorf("invalid JSON array element %q of type %q for column %s", field.Name, field.Type, col.SourceName))
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validateCDCFlowConfigUpdate checks the table mappings a config update changes, like mirror creation does,
+// before the update reaches the workflow. Removed tables leave the mirror and may no longer exist at the source,
+// so only the syntax of their column settings is checked.
+func (h *FlowRequestHandler) validateCDCFlowConfigUpdate(
+	ctx context.Context, flowJobName string, update *protos.CDCFlowConfigUpdate,
+) ([]string, APIError) {
+	if apiErr := checkColumnSettings(slices.Concat(update.AdditionalTables, update.RemovedTables)); apiErr != nil {
+		return nil, apiErr
+	}
+	if len(update.UpdatedEnv) == 0 && !hasColumnTypeOverrides(update.AdditionalTables) {
+		return nil, nil
+	}
+
+	config, err := h.getFlowConfigFromCatalog(ctx, flowJobName)
+	if err != nil {
+		return nil, NewInternalApiError(fmt.Errorf("unable to get flow config: %w", err))
+	}
+	if len(update.UpdatedEnv) > 0 {
+		env := maps.Clone(config.Env)
+		if env == nil {
+			env = make(map[string]string, len(update.UpdatedEnv))
+		}
+		maps.Copy(env, update.UpdatedEnv)
+		if apiErr := checkTimestampPolicy(ctx, env, config.System); apiErr != nil {
+			return nil, apiErr
+		}
+	}
+	if !hasColumnTypeOverrides(update.AdditionalTables) {
+		return nil, nil
+	}
+	return h.validateColumnTypeOverrides(ctx, config.Env, config.Version, config.System,
+		config.SourceName, config.DestinationName, update.AdditionalTables)
+}
+
+// checkTimestampPolicy rejects a timestamp policy for mirrors with the PG type system,
+// their initial load and CDC copy raw Postgres values so the policy could not be applied to them
+func checkTimestampPolicy(ctx context.Context, env map[string]string, system protos.TypeSystem) APIError {
+	if system != protos.TypeSystem_PG {
+		return nil
+	}
+	policy, err := model.NewTimestampPolicy(ctx, env)
+	if err != nil {
+		return NewInvalidArgumentApiError(err)
+	}
+	if !policy.IsNoop() {
+		return NewInvalidArgumentApiError(errors.New(
+			"timestamp policy is not supported for mirrors with the PG type system, they replicate raw Postgres values"))
+	}
+	return nil
+}
+
+// hasColumnTypeOverrides reports whether table mappings or their projections override destination types or NUMERIC strategies
+func hasColumnTypeOverrides(tableMappings []*protos.TableMapping) bool {
+	return slices.ContainsFunc(tableMappings, func(tm *protos.TableMapping) bool {
+		return slices.ContainsFunc(append([]*protos.TableMapping{tm}, tm.Projections...), func(target *protos.TableMapping) bool {
+			return slices.ContainsFunc(target.Columns, func(col *protos.ColumnSetting) bool {
+				return col.DestinationType != "" || col.NumericStrategy != protos.NumericStrategy_NUMERIC_STRATEGY_TRUNCATE
+			})
+		})
+	})
+}
+
+// validateColumnTypeOverrides checks destination type overrides of table mappings against the source schema
+// with the compatibility matrix of the destination, destinations without one don't apply overrides and reject them
+func (h *FlowRequestHandler) validateColumnTypeOverrides(
+	ctx context.Context,
+	env map[string]string,
+	version uint32,
+	system protos.TypeSystem,
+	sourceName string,
+	destinationName string,
+	tableMappings []*protos.TableMapping,
+) ([]string, APIError) {
+	if !hasColumnTypeOverrides(tableMappings) {
+		return nil, nil
+	}
+	if apiErr := h.checkNumericStrategies(ctx, destinationName, tableMappings); apiErr != nil {
+		return nil, apiErr
+	}
+
+	dstConn, dstClose, err := connectors.GetByNameAs[connectors.ColumnTypeOverrideValidationConnector](
+		ctx, env, h.pool, destinationName,
+	)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			for _, tm := range tableMappings {
+				for _, target := range append([]*protos.TableMapping{tm}, tm.Projections...) {
+					for _, col := range target.Columns {
+						if col.DestinationType != "" {
+							return nil, NewInvalidArgumentApiError(fmt.Errorf(
+								"destination type %s for column %s of table %s: destination %s does not support column type overrides",
+								col.DestinationType, col.SourceName, target.DestinationTableIdentifier, destinationName))
+						}
+					}
+				}
+			}
+			return nil, nil
+		}
+		return nil, NewFailedPreconditionApiError(fmt.Errorf("failed to create destination connector: %w", err))
+	}
+	defer dstClose(ctx)
+
+	srcConn, srcClose, err := connectors.GetByNameAs[connectors.GetTableSchemaConnector](ctx, env, h.pool, sourceName)
+	if err != nil {
+		return nil, NewFailedPreconditionApiError(fmt.Errorf("failed to create source connector: %w", err))
+	}
+	defer srcClose(ctx)
+
+	tableSchemaMap, err := srcConn.GetTableSchema(ctx, env, version, system, tableMappings)
+	if err != nil {
+		return nil, NewFailedPreconditionApiError(fmt.Errorf("failed to get source table schema: %w", err))
+	}
+	warnings, err := dstConn.ValidateColumnTypeOverrides(ctx, tableMappings, tableSchemaMap)
+	if err != nil {
+		return nil, NewFailedPreconditionApiError(
+			fmt.Errorf("failed to validate destination connector %s: %w", destinationName, err))
+	}
+	return warnings, nil
+}
+
+// checkNumericStrategies rejects NUMERIC strategies for destinations other than ClickHouse, BigQuery and Snowflake,
+// which always truncate NUMERIC values to fit, and the string strategy for BigQuery and Snowflake
+func (h *FlowRequestHandler) checkNumericStrategies(
+	ctx context.Context, destinationName string, tableMappings []*protos.TableMapping,
+) APIError {
+	hasStrategies := slices.ContainsFunc(tableMappings, func(tm *protos.TableMapping) bool {
+		return slices.ContainsFunc(tm.Columns, func(col *protos.ColumnSetting) bool {
+			return col.NumericStrategy != protos.NumericStrategy_NUMERIC_STRATEGY_TRUNCATE
+		})
+	})
+	if !hasStrategies {
+		return nil
+	}
+	dstType, err := connectors.LoadPeerType(ctx, h.pool, destinationName)
+	if err != nil {
+		return NewInternalApiError(fmt.Errorf("failed to load destination peer %s: %w", destinationName, err))
+	}
+	switch dstType {
+	case protos.DBType_CLICKHOUSE:
+		return nil
+	case protos.DBType_BIGQUERY, protos.DBType_SNOWFLAKE:
+		// columns keep their NUMERIC type there, so values cannot be stored as strings
+		for _, tm := range tableMappings {
+			for _, col := range tm.Columns {
+				if col.NumericStrategy == protos.NumericStrategy_NUMERIC_STRATEGY_STRING {
+					return NewInvalidArgumentApiError(fmt.Errorf("%s for column %s of table %s is not supported for %s destinations",
+						col.NumericStrategy, col.SourceName, tm.DestinationTableIdentifier, dstType))
+				}
+			}
+		}
+		return nil
+	default:
+		return NewInvalidArgumentApiError(fmt.Errorf(
+			"NUMERIC strategies are only supported for ClickHouse, BigQuery and Snowflake destinations, %s is %s",
+			destinationName, dstType))
+	}
 }
 
 // checkSourcePeerReuse rejects a CDC mirror whose MySQL source peer pins a fixed server_id while
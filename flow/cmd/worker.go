@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
@@ -19,12 +22,20 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
 	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/concurrency"
 	peerflow "github.com/PeerDB-io/peerdb/flow/workflows"
 )
 
 type WorkerSetupOptions struct {
-	TemporalHostPort                   string
-	TemporalNamespace                  string
+	TemporalHostPort  string
+	TemporalNamespace string
+	// WorkerPools lists the pools whose task queues this worker subscribes to, empty subscribes to the default pool only
+	WorkerPools []string
+	// per-pool limits on concurrent sync, normalize and QRep partition activities given as pool=limit,
+	// a bare limit applies to pools without their own, 0 is unbounded
+	MaxConcurrentSyncs                 []string
+	MaxConcurrentNormalizes            []string
+	MaxConcurrentQRepPartitions        []string
 	TemporalMaxConcurrentActivities    int
 	TemporalMaxConcurrentWorkflowTasks int
 	EnableOtelMetrics                  bool
@@ -34,9 +45,36 @@ type WorkerSetupOptions struct {
 
 type WorkerSetupResponse struct {
 	Client         client.Client
-	Worker         worker.Worker
 	OtelManager    *otel_metrics.OtelManager
 	TracerProvider *sdktrace.TracerProvider
+	fatalErrors    chan error
+	Workers        []worker.Worker
+}
+
+// Run starts every worker and blocks until interrupted or one of them fails
+func (w *WorkerSetupResponse) Run(interruptCh <-chan any) error {
+	if len(w.Workers) == 1 {
+		return w.Workers[0].Run(interruptCh)
+	}
+	for i, wrk := range w.Workers {
+		if err := wrk.Start(); err != nil {
+			for _, started := range w.Workers[:i] {
+				started.Stop()
+			}
+			return fmt.Errorf("unable to start worker: %w", err)
+		}
+	}
+	defer func() {
+		for _, wrk := range w.Workers {
+			wrk.Stop()
+		}
+	}()
+	select {
+	case <-interruptCh:
+		return nil
+	case err := <-w.fatalErrors:
+		return err
+	}
 }
 
 func (w *WorkerSetupResponse) Close(ctx context.Context) {
@@ -98,58 +136,149 @@ func WorkerSetup(ctx context.Context, opts *WorkerSetupOptions) (*WorkerSetupRes
 		return nil, fmt.Errorf("unable to create Temporal client: %w", err)
 	}
 	slog.InfoContext(ctx, "Created temporal client")
-	queueId := shared.PeerFlowTaskQueue
-	if opts.UseMaintenanceTaskQueue {
-		queueId = shared.MaintenanceFlowTaskQueue
-	}
-	taskQueue := internal.PeerFlowTaskQueueName(queueId)
-	slog.InfoContext(ctx,
-		"Creating temporal worker",
-		slog.String("taskQueue", taskQueue),
-		slog.Int("workflowConcurrency", opts.TemporalMaxConcurrentWorkflowTasks),
-		slog.Int("activityConcurrency", opts.TemporalMaxConcurrentActivities),
-	)
-	w := worker.New(c, taskQueue, worker.Options{
-		EnableSessionWorker:                    true,
-		MaxConcurrentActivityExecutionSize:     opts.TemporalMaxConcurrentActivities,
-		MaxConcurrentWorkflowTaskExecutionSize: opts.TemporalMaxConcurrentWorkflowTasks,
-		OnFatalError: func(err error) {
-			slog.ErrorContext(ctx, "Peerflow Worker failed", slog.Any("error", err))
-		},
-		MaxHeartbeatThrottleInterval: 10 * time.Second,
-	})
-	peerflow.RegisterFlowWorkerWorkflows(w)
 
 	otelManager, err := otel_metrics.NewOtelManager(ctx, otel_metrics.FlowWorkerServiceName, opts.EnableOtelMetrics)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create otel manager: %w", err)
 	}
 
-	w.RegisterActivity(&activities.FlowableActivity{
-		CatalogPool:    conn,
-		Alerter:        alerting.NewAlerter(ctx, conn, otelManager),
-		OtelManager:    otelManager,
-		TemporalClient: c,
-	})
+	queueIds := []shared.TaskQueueID{shared.MaintenanceFlowTaskQueue}
+	if !opts.UseMaintenanceTaskQueue {
+		if queueIds, err = workerPoolTaskQueues(opts.WorkerPools); err != nil {
+			return nil, err
+		}
+	}
+	syncLimits, err := parseWorkerPoolLimits(opts.MaxConcurrentSyncs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sync limits: %w", err)
+	}
+	normalizeLimits, err := parseWorkerPoolLimits(opts.MaxConcurrentNormalizes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid normalize limits: %w", err)
+	}
+	qrepLimits, err := parseWorkerPoolLimits(opts.MaxConcurrentQRepPartitions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid QRep partition limits: %w", err)
+	}
+	fatalErrors := make(chan error, len(queueIds))
+	workers := make([]worker.Worker, 0, len(queueIds))
+	for _, queueId := range queueIds {
+		taskQueue := internal.PeerFlowTaskQueueName(queueId)
+		slog.InfoContext(ctx,
+			"Creating temporal worker",
+			slog.String("taskQueue", taskQueue),
+			slog.Int("workflowConcurrency", opts.TemporalMaxConcurrentWorkflowTasks),
+			slog.Int("activityConcurrency", opts.TemporalMaxConcurrentActivities),
+		)
+		w := worker.New(c, taskQueue, worker.Options{
+			EnableSessionWorker:                    true,
+			MaxConcurrentActivityExecutionSize:     opts.TemporalMaxConcurrentActivities,
+			MaxConcurrentWorkflowTaskExecutionSize: opts.TemporalMaxConcurrentWorkflowTasks,
+			OnFatalError: func(err error) {
+				slog.ErrorContext(ctx, "Peerflow Worker failed", slog.String("taskQueue", taskQueue), slog.Any("error", err))
+				select {
+				case fatalErrors <- fmt.Errorf("worker for task queue %s failed: %w", taskQueue, err):
+				default:
+				}
+			},
+			MaxHeartbeatThrottleInterval: 10 * time.Second,
+		})
+		peerflow.RegisterFlowWorkerWorkflows(w)
 
-	w.RegisterActivity(&activities.MaintenanceActivity{
-		CatalogPool:    conn,
-		Alerter:        alerting.NewAlerter(ctx, conn, otelManager),
-		OtelManager:    otelManager,
-		TemporalClient: c,
-	})
+		// each pool gets its own limits, so a busy pool cannot starve the others
+		w.RegisterActivity(&activities.FlowableActivity{
+			CatalogPool:    conn,
+			Alerter:        alerting.NewAlerter(ctx, conn, otelManager),
+			OtelManager:    otelManager,
+			TemporalClient: c,
+			PoolLimits: activities.WorkerPoolLimits{
+				Sync:      concurrency.NewSemaphore(syncLimits.forTaskQueue(queueId)),
+				Normalize: concurrency.NewSemaphore(normalizeLimits.forTaskQueue(queueId)),
+				QRep:      concurrency.NewSemaphore(qrepLimits.forTaskQueue(queueId)),
+			},
+		})
 
-	w.RegisterActivity(&activities.CancelTableAdditionActivity{
-		CatalogPool:    conn,
-		Alerter:        alerting.NewAlerter(ctx, conn, otelManager),
-		OtelManager:    otelManager,
-		TemporalClient: c,
-	})
+		w.RegisterActivity(&activities.MaintenanceActivity{
+			CatalogPool:    conn,
+			Alerter:        alerting.NewAlerter(ctx, conn, otelManager),
+			OtelManager:    otelManager,
+			TemporalClient: c,
+		})
+
+		w.RegisterActivity(&activities.CancelTableAdditionActivity{
+			CatalogPool:    conn,
+			Alerter:        alerting.NewAlerter(ctx, conn, otelManager),
+			OtelManager:    otelManager,
+			TemporalClient: c,
+		})
+		workers = append(workers, w)
+	}
 
 	return &WorkerSetupResponse{
 		Client:         c,
-		Worker:         w,
+		Workers:        workers,
 		OtelManager:    otelManager,
 		TracerProvider: tracerProvider,
+		fatalErrors:    fatalErrors,
 	}, nil
 }
+
+// workerPoolTaskQueues maps pool names to their deduplicated task queues, defaulting to the default pool
+func workerPoolTaskQueues(pools []string) ([]shared.TaskQueueID, error) {
+	if len(pools) == 0 {
+		return []shared.TaskQueueID{shared.PeerFlowTaskQueue}, nil
+	}
+	queueIds := make([]shared.TaskQueueID, 0, len(pools))
+	for _, pool := range pools {
+		pool = strings.TrimSpace(pool)
+		if err := validateWorkerPool(pool); err != nil {
+			return nil, err
+		}
+		if queueId := shared.WorkerPoolTaskQueue(pool); !slices.Contains(queueIds, queueId) {
+			queueIds = append(queueIds, queueId)
+		}
+	}
+	return queueIds, nil
+}
+
+// workerPoolLimits holds a limit for each pool's task queue and the limit of pools without their own
+type workerPoolLimits struct {
+	byTaskQueue map[shared.TaskQueueID]int
+	fallback    int
+}
+
+// parseWorkerPoolLimits parses limits given as pool=limit, or as a bare limit for pools without their own
+func parseWorkerPoolLimits(values []string) (workerPoolLimits, error) {
+	limits := workerPoolLimits{byTaskQueue: make(map[shared.TaskQueueID]int, len(values))}
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		pool, limitStr, hasPool := strings.Cut(value, "=")
+		if !hasPool {
+			pool, limitStr = "", value
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(limitStr))
+		if err != nil || limit < 0 {
+			return workerPoolLimits{}, fmt.Errorf("invalid limit %q, limits are non-negative integers given as pool=limit or limit", value)
+		}
+		if !hasPool {
+			limits.fallback = limit
+			continue
+		}
+		pool = strings.TrimSpace(pool)
+		if err := validateWorkerPool(pool); err != nil {
+			return workerPoolLimits{}, err
+		}
+		limits.byTaskQueue[shared.WorkerPoolTaskQueue(pool)] = limit
+	}
+	return limits, nil
+}
+
+func (l workerPoolLimits) forTaskQueue(queueId shared.TaskQueueID) int {
+	if limit, ok := l.byTaskQueue[queueId]; ok {
+		return limit
+	}
+	return l.fallback
+}
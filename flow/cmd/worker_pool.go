@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"regexp"
+
+	"go.temporal.io/api/enums/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// pool names end up in task queue names
+var workerPoolNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+func (h *FlowRequestHandler) workerPoolTaskQueue(pool string) string {
+	if shared.WorkerPoolTaskQueue(pool) == shared.PeerFlowTaskQueue {
+		return h.peerflowTaskQueueID
+	}
+	return internal.PeerFlowTaskQueueName(shared.WorkerPoolTaskQueue(pool))
+}
+
+func validateWorkerPool(pool string) error {
+	if pool != "" && !workerPoolNameRe.MatchString(pool) {
+		return fmt.Errorf("invalid worker pool %q, pool names are lowercase letters, digits, '-' and '_'", pool)
+	}
+	return nil
+}
+
+// checkWorkerPool validates a pool name and checks that workers poll its task queue,
+// a mirror started on a task queue no worker polls would never make progress
+func (h *FlowRequestHandler) checkWorkerPool(ctx context.Context, pool string) APIError {
+	if err := validateWorkerPool(pool); err != nil {
+		return NewInvalidArgumentApiError(err)
+	}
+	if shared.WorkerPoolTaskQueue(pool) == shared.PeerFlowTaskQueue {
+		return nil
+	}
+	taskQueue := h.workerPoolTaskQueue(pool)
+	desc, err := h.temporalClient.DescribeTaskQueue(ctx, taskQueue, enums.TASK_QUEUE_TYPE_WORKFLOW)
+	if err != nil {
+		return NewInternalApiError(fmt.Errorf("unable to describe task queue %s of worker pool %q: %w", taskQueue, pool, err))
+	}
+	if len(desc.Pollers) == 0 {
+		return NewFailedPreconditionApiError(fmt.Errorf("no workers are subscribed to worker pool %q", pool))
+	}
+	return nil
+}
+
+// MoveMirrorToWorkerPool moves a mirror to the task queue of another worker pool when its workflow next continues as new,
+// workers subscribed to the pool need to be running for the mirror to make progress
+func (h *FlowRequestHandler) MoveMirrorToWorkerPool(
+	ctx context.Context,
+	req *protos.MoveMirrorToWorkerPoolRequest,
+) (*protos.MoveMirrorToWorkerPoolResponse, APIError) {
+	pool := req.WorkerPool
+	if pool == shared.DefaultWorkerPool {
+		pool = ""
+	}
+	if apiErr := h.checkWorkerPool(ctx, pool); apiErr != nil {
+		return nil, apiErr
+	}
+
+	workflowID, err := h.getWorkflowID(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, NewInternalApiError(err)
+	}
+	isCdc, err := h.isCDCFlow(ctx, req.FlowJobName)
+	if err != nil {
+		return nil, NewInternalApiError(fmt.Errorf("unable to determine if mirror is cdc: %w", err))
+	}
+
+	if err := model.WorkerPoolSignal.SignalClientWorkflow(ctx, h.temporalClient, workflowID, "", pool); err != nil {
+		slog.ErrorContext(ctx, "unable to signal worker pool move", slog.String("flowName", req.FlowJobName), slog.Any("error", err))
+		return nil, NewInternalApiError(fmt.Errorf("unable to signal worker pool move: %w", err))
+	}
+	// CDC mirrors store their config when continuing as new, QRep mirrors never update theirs
+	if !isCdc {
+		if err := h.updateQRepWorkerPoolInCatalog(ctx, req.FlowJobName, pool); err != nil {
+			return nil, NewInternalApiError(err)
+		}
+	}
+
+	h.alerter.LogFlowInfo(ctx, req.FlowJobName, fmt.Sprintf("Mirror move to worker pool %q signaled", req.WorkerPool))
+	return &protos.MoveMirrorToWorkerPoolResponse{}, nil
+}
+
+func (h *FlowRequestHandler) updateQRepWorkerPoolInCatalog(ctx context.Context, flowJobName string, pool string) error {
+	var configBytes sql.RawBytes
+	if err := h.pool.QueryRow(ctx, "SELECT config_proto FROM flows WHERE name = $1", flowJobName).Scan(&configBytes); err != nil {
+		return fmt.Errorf("unable to query qrep config from catalog: %w", err)
+	}
+	var config protos.QRepConfig
+	if err := proto.Unmarshal(configBytes, &config); err != nil {
+		return fmt.Errorf("unable to unmarshal qrep config: %w", err)
+	}
+	config.WorkerPool = pool
+	updatedBytes, err := proto.Marshal(&config)
+	if err != nil {
+		return fmt.Errorf("unable to marshal qrep config: %w", err)
+	}
+	if _, err := h.pool.Exec(ctx, "UPDATE flows SET config_proto=$1,updated_at=now() WHERE name=$2", updatedBytes, flowJobName); err != nil {
+		return fmt.Errorf("unable to update qrep config in catalog: %w", err)
+	}
+	return nil
+}
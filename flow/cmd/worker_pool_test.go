@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func TestWorkerPoolTaskQueues(t *testing.T) {
+	t.Parallel()
+
+	queueIds, err := workerPoolTaskQueues(nil)
+	require.NoError(t, err)
+	require.Equal(t, []shared.TaskQueueID{shared.PeerFlowTaskQueue}, queueIds)
+
+	queueIds, err = workerPoolTaskQueues([]string{"default", " analytics ", "", "analytics"})
+	require.NoError(t, err)
+	require.Equal(t, []shared.TaskQueueID{shared.PeerFlowTaskQueue, shared.PeerFlowTaskQueue + "-analytics"}, queueIds)
+
+	_, err = workerPoolTaskQueues([]string{"Bad Pool"})
+	require.Error(t, err)
+}
+
+func TestParseWorkerPoolLimits(t *testing.T) {
+	t.Parallel()
+
+	limits, err := parseWorkerPoolLimits([]string{"4", "analytics=1", " default = 8 ", ""})
+	require.NoError(t, err)
+	require.Equal(t, 8, limits.forTaskQueue(shared.PeerFlowTaskQueue))
+	require.Equal(t, 1, limits.forTaskQueue(shared.WorkerPoolTaskQueue("analytics")))
+	require.Equal(t, 4, limits.forTaskQueue(shared.WorkerPoolTaskQueue("snapshots")))
+
+	limits, err = parseWorkerPoolLimits(nil)
+	require.NoError(t, err)
+	require.Zero(t, limits.forTaskQueue(shared.PeerFlowTaskQueue))
+
+	for _, invalid := range []string{"many", "analytics=-1", "Bad Pool=1"} {
+		_, err = parseWorkerPoolLimits([]string{invalid})
+		require.Error(t, err, invalid)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/model/qvalue"
 	"github.com/PeerDB-io/peerdb/flow/pkg/common"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 )
@@ -247,7 +248,7 @@ func (c *BigQueryConnector) ReplayTableSchemaDeltas(
 	ctx context.Context,
 	env map[string]string,
 	flowJobName string,
-	_ []*protos.TableMapping,
+	tableMappings []*protos.TableMapping,
 	schemaDeltas []*protos.TableSchemaDelta,
 	_ []string,
 ) error {
@@ -278,7 +279,9 @@ func (c *BigQueryConnector) ReplayTableSchemaDeltas(
 				}
 			}
 
-			addedColumnBigQueryType := qValueKindToBigQueryTypeString(addedColumn, schemaDelta.NullableEnabled, false)
+			addedColumnBigQueryType := bigQueryTypeString(addedColumn, numericStrategyBigQueryType(addedColumn, schemaDelta.NullableEnabled,
+				qvalue.ColumnNumericStrategy(tableMappings, schemaDelta.DstTableName, addedColumn.Name)),
+				schemaDelta.NullableEnabled, false)
 			query := c.queryWithLogging(fmt.Sprintf(
 				"ALTER TABLE `%s` ADD COLUMN IF NOT EXISTS `%s` %s",
 				dstDatasetTable.table, addedColumn.Name, addedColumnBigQueryType))
@@ -714,7 +717,8 @@ func (c *BigQueryConnector) SetupNormalizedTable(
 	// convert the column names and types to bigquery types
 	columns := make([]*bigquery.FieldSchema, 0, len(tableSchema.Columns)+2)
 	for _, column := range tableSchema.Columns {
-		bqFieldSchema := qValueKindToBigQueryType(column, tableSchema.NullableEnabled)
+		bqFieldSchema := numericStrategyBigQueryType(column, tableSchema.NullableEnabled,
+			qvalue.ColumnNumericStrategy(config.TableMappings, tableIdentifier, column.Name))
 		columns = append(columns, &bqFieldSchema)
 	}
 
@@ -29,35 +29,8 @@ func (m *mergeStmtGenerator) generateFlattenedCTE(dstTable string, normalizedTab
 	flattenedProjs := make([]string, 0, len(normalizedTableSchema.Columns)+3)
 
 	for _, column := range normalizedTableSchema.Columns {
-		colType := column.Type
-		bqTypeString := qValueKindToBigQueryTypeString(column, normalizedTableSchema.NullableEnabled, true)
-		var castStmt string
-		shortCol := m.shortColumn[column.Name]
-		switch types.QValueKind(colType) {
-		case types.QValueKindJSON, types.QValueKindJSONB, types.QValueKindHStore:
-			// if the type is JSON, then just extract JSON
-			castStmt = fmt.Sprintf("CAST(PARSE_JSON(JSON_VALUE(_peerdb_data, '$.%s'),wide_number_mode=>'round') AS %s) AS `%s`",
-				column.Name, bqTypeString, shortCol)
-		// expecting data in BASE64 format
-		case types.QValueKindBytes:
-			castStmt = fmt.Sprintf("FROM_BASE64(JSON_VALUE(_peerdb_data,'$.%s')) AS `%s`",
-				column.Name, shortCol)
-		case types.QValueKindArrayFloat32, types.QValueKindArrayFloat64, types.QValueKindArrayInt16,
-			types.QValueKindArrayInt32, types.QValueKindArrayInt64, types.QValueKindArrayString,
-			types.QValueKindArrayBoolean, types.QValueKindArrayTimestamp, types.QValueKindArrayTimestampTZ,
-			types.QValueKindArrayDate, types.QValueKindArrayInterval, types.QValueKindArrayUUID,
-			types.QValueKindArrayNumeric, types.QValueKindArrayEnum:
-			castStmt = fmt.Sprintf("ARRAY(SELECT CAST(element AS %s) FROM "+
-				"UNNEST(CAST(JSON_VALUE_ARRAY(_peerdb_data, '$.%s') AS ARRAY<STRING>)) AS element WHERE element IS NOT null) AS `%s`",
-				bqTypeString, column.Name, shortCol)
-		case types.QValueKindGeography, types.QValueKindGeometry, types.QValueKindPoint:
-			castStmt = fmt.Sprintf("CAST(ST_GEOGFROMTEXT(JSON_VALUE(_peerdb_data, '$.%s')) AS %s) AS `%s`",
-				column.Name, bqTypeString, shortCol)
-		default:
-			castStmt = fmt.Sprintf("CAST(JSON_VALUE(_peerdb_data, '$.%s') AS %s) AS `%s`",
-				column.Name, bqTypeString, shortCol)
-		}
-		flattenedProjs = append(flattenedProjs, castStmt)
+		flattenedProjs = append(flattenedProjs, fmt.Sprintf("%s AS `%s`",
+			jsonValueExpr("_peerdb_data", column, "$."+column.Name, normalizedTableSchema.NullableEnabled), m.shortColumn[column.Name]))
 	}
 	flattenedProjs = append(
 		flattenedProjs,
@@ -73,6 +46,58 @@ func (m *mergeStmtGenerator) generateFlattenedCTE(dstTable string, normalizedTab
 		strings.Join(flattenedProjs, ","), m.rawDatasetTable.string(), m.mergeBatchId, dstTable)
 }
 
+// jsonValueExpr extracts the value at path in the JSON data as the BigQuery type of column,
+// structs are rebuilt member by member so nested values keep their types
+func jsonValueExpr(data string, column *protos.FieldDescription, path string, nullableEnabled bool) string {
+	bqTypeString := qValueKindToBigQueryTypeString(column, nullableEnabled, true)
+	switch types.QValueKind(column.Type) {
+	case types.QValueKindJSON, types.QValueKindJSONB, types.QValueKindHStore, types.QValueKindMap:
+		// if the type is JSON, then just extract JSON
+		return fmt.Sprintf("CAST(PARSE_JSON(JSON_VALUE(%s, '%s'),wide_number_mode=>'round') AS %s)",
+			data, path, bqTypeString)
+	// expecting data in BASE64 format
+	case types.QValueKindBytes:
+		return fmt.Sprintf("FROM_BASE64(JSON_VALUE(%s,'%s'))", data, path)
+	case types.QValueKindArrayFloat32, types.QValueKindArrayFloat64, types.QValueKindArrayInt16,
+		types.QValueKindArrayInt32, types.QValueKindArrayInt64, types.QValueKindArrayString,
+		types.QValueKindArrayBoolean, types.QValueKindArrayTimestamp, types.QValueKindArrayTimestampTZ,
+		types.QValueKindArrayDate, types.QValueKindArrayInterval, types.QValueKindArrayUUID,
+		types.QValueKindArrayNumeric, types.QValueKindArrayEnum:
+		return fmt.Sprintf("ARRAY(SELECT CAST(element AS %s) FROM "+
+			"UNNEST(CAST(JSON_VALUE_ARRAY(%s, '%s') AS ARRAY<STRING>)) AS element WHERE element IS NOT null)",
+			bqTypeString, data, path)
+	case types.QValueKindGeography, types.QValueKindGeometry, types.QValueKindPoint:
+		return fmt.Sprintf("CAST(ST_GEOGFROMTEXT(JSON_VALUE(%s, '%s')) AS %s)", data, path, bqTypeString)
+	case types.QValueKindStruct:
+		if elementType, ok := bigQueryRangeElementType(column); ok {
+			elementTypeString := createTableCompatibleTypeName(elementType)
+			// BigQuery has no empty ranges, they are stored as NULL
+			return fmt.Sprintf("IF(COALESCE(JSON_QUERY(%[6]s, '%[1]s'),'null')='null' OR "+
+				"JSON_VALUE(%[6]s, '%[1]s.%[5]s')='true',NULL,"+
+				"RANGE(CAST(JSON_VALUE(%[6]s, '%[1]s.%[2]s') AS %[4]s),CAST(JSON_VALUE(%[6]s, '%[1]s.%[3]s') AS %[4]s)))",
+				path, types.RangeLowerMember, types.RangeUpperMember, elementTypeString, types.RangeEmptyMember, data)
+		}
+		// a null struct is not the same as a struct of null members
+		return fmt.Sprintf("IF(COALESCE(JSON_QUERY(%s, '%s'),'null')='null',NULL,%s)",
+			data, path, structValueExpr(data, column, path, nullableEnabled))
+	case types.QValueKindArrayStruct:
+		return fmt.Sprintf("ARRAY(SELECT %s FROM UNNEST(JSON_QUERY_ARRAY(%s, '%s')) AS element)",
+			structValueExpr("element", column, "$", nullableEnabled), data, path)
+	default:
+		return fmt.Sprintf("CAST(JSON_VALUE(%s, '%s') AS %s)", data, path, bqTypeString)
+	}
+}
+
+// structValueExpr rebuilds the struct at path in the JSON data from its members
+func structValueExpr(data string, column *protos.FieldDescription, path string, nullableEnabled bool) string {
+	members := make([]string, 0, len(column.Fields))
+	for _, member := range column.Fields {
+		members = append(members, fmt.Sprintf("%s AS `%s`",
+			jsonValueExpr(data, member, path+"."+member.Name, nullableEnabled), member.Name))
+	}
+	return "STRUCT(" + strings.Join(members, ",") + ")"
+}
+
 // This function is to support datatypes like JSON which cannot be partitioned by or compared by BigQuery
 func (m *mergeStmtGenerator) transformedPkeyStrings(normalizedTableSchema *protos.TableSchema, forPartition bool) []string {
 	pkeys := make([]string, 0, len(normalizedTableSchema.PrimaryKeyColumns))
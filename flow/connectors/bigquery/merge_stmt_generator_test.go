@@ -6,6 +6,7 @@ import (
 
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
 func TestGenerateUpdateStatement(t *testing.T) {
@@ -183,3 +184,93 @@ func TestGenerateUpdateStatement_WithUnchangedToastColsAndSoftDelete(t *testing.
 		t.Errorf("Unexpected result. Expected: %v,\nbut got: %v", expected, result)
 	}
 }
+
+func TestJSONValueExprStruct(t *testing.T) {
+	column := &protos.FieldDescription{
+		Name: "address",
+		Type: string(types.QValueKindStruct),
+		Fields: []*protos.FieldDescription{
+			{Name: "zip", Type: string(types.QValueKindInt32), Nullable: true},
+			{Name: "geo", Type: string(types.QValueKindStruct), Nullable: true, Fields: []*protos.FieldDescription{
+				{Name: "lat", Type: string(types.QValueKindFloat64), Nullable: true},
+			}},
+		},
+	}
+
+	expected := "IF(COALESCE(JSON_QUERY(_peerdb_data, '$.address'),'null')='null',NULL,STRUCT(" +
+		"CAST(JSON_VALUE(_peerdb_data, '$.address.zip') AS INTEGER) AS `zip`," +
+		"IF(COALESCE(JSON_QUERY(_peerdb_data, '$.address.geo'),'null')='null',NULL,STRUCT(" +
+		"CAST(JSON_VALUE(_peerdb_data, '$.address.geo.lat') AS FLOAT64) AS `lat`)) AS `geo`))"
+	if result := jsonValueExpr("_peerdb_data", column, "$.address", false); result != expected {
+		t.Errorf("Unexpected result. Expected: %v, but got: %v", expected, result)
+	}
+
+	if result := qValueKindToBigQueryTypeString(column, false, false); result != "STRUCT<`zip` INTEGER, `geo` STRUCT<`lat` FLOAT64>>" {
+		t.Errorf("Unexpected struct type: %v", result)
+	}
+}
+
+func TestJSONValueExprRange(t *testing.T) {
+	column := &protos.FieldDescription{
+		Name: "during",
+		Type: string(types.QValueKindStruct),
+		Fields: []*protos.FieldDescription{
+			{Name: types.RangeLowerMember, Type: string(types.QValueKindDate), Nullable: true},
+			{Name: types.RangeUpperMember, Type: string(types.QValueKindDate), Nullable: true},
+			{Name: types.RangeLowerIncMember, Type: string(types.QValueKindBoolean)},
+			{Name: types.RangeUpperIncMember, Type: string(types.QValueKindBoolean)},
+			{Name: types.RangeEmptyMember, Type: string(types.QValueKindBoolean)},
+		},
+	}
+
+	expected := "IF(COALESCE(JSON_QUERY(_peerdb_data, '$.during'),'null')='null' OR " +
+		"JSON_VALUE(_peerdb_data, '$.during.empty')='true',NULL,RANGE(" +
+		"CAST(JSON_VALUE(_peerdb_data, '$.during.lower') AS DATE)," +
+		"CAST(JSON_VALUE(_peerdb_data, '$.during.upper') AS DATE)))"
+	if result := jsonValueExpr("_peerdb_data", column, "$.during", false); result != expected {
+		t.Errorf("Unexpected result. Expected: %v, but got: %v", expected, result)
+	}
+
+	if result := qValueKindToBigQueryTypeString(column, false, false); result != "RANGE<DATE>" {
+		t.Errorf("Unexpected range type: %v", result)
+	}
+
+	// timestamp ranges can have inclusive upper bounds, which RANGE can't hold
+	for _, member := range column.Fields[:2] {
+		member.Type = string(types.QValueKindTimestamp)
+	}
+	if result := qValueKindToBigQueryTypeString(column, false, false); result !=
+		"STRUCT<`lower` TIMESTAMP, `upper` TIMESTAMP, `lower_inc` BOOL, `upper_inc` BOOL, `empty` BOOL>" {
+		t.Errorf("Unexpected timestamp range type: %v", result)
+	}
+}
+
+func TestJSONValueExprMultirange(t *testing.T) {
+	column := &protos.FieldDescription{
+		Name: "slots",
+		Type: string(types.QValueKindArrayStruct),
+		Fields: []*protos.FieldDescription{
+			{Name: types.RangeLowerMember, Type: string(types.QValueKindInt32), Nullable: true},
+			{Name: types.RangeUpperMember, Type: string(types.QValueKindInt32), Nullable: true},
+			{Name: types.RangeLowerIncMember, Type: string(types.QValueKindBoolean)},
+			{Name: types.RangeUpperIncMember, Type: string(types.QValueKindBoolean)},
+			{Name: types.RangeEmptyMember, Type: string(types.QValueKindBoolean)},
+		},
+	}
+
+	expected := "ARRAY(SELECT STRUCT(" +
+		"CAST(JSON_VALUE(element, '$.lower') AS INTEGER) AS `lower`," +
+		"CAST(JSON_VALUE(element, '$.upper') AS INTEGER) AS `upper`," +
+		"CAST(JSON_VALUE(element, '$.lower_inc') AS BOOL) AS `lower_inc`," +
+		"CAST(JSON_VALUE(element, '$.upper_inc') AS BOOL) AS `upper_inc`," +
+		"CAST(JSON_VALUE(element, '$.empty') AS BOOL) AS `empty`) " +
+		"FROM UNNEST(JSON_QUERY_ARRAY(_peerdb_data, '$.slots')) AS element)"
+	if result := jsonValueExpr("_peerdb_data", column, "$.slots", false); result != expected {
+		t.Errorf("Unexpected result. Expected: %v, but got: %v", expected, result)
+	}
+
+	if result := qValueKindToBigQueryTypeString(column, false, false); result !=
+		"ARRAY<STRUCT<`lower` INTEGER, `upper` INTEGER, `lower_inc` BOOL, `upper_inc` BOOL, `empty` BOOL>>" {
+		t.Errorf("Unexpected multirange type: %v", result)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/model/qvalue"
 	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
@@ -132,6 +133,11 @@ func getTransformedColumns(dstSchema *bigquery.Schema, syncedAtCol string, softD
 		case bigquery.JSONFieldType:
 			transformedColumns = append(transformedColumns,
 				fmt.Sprintf("PARSE_JSON(`%s`,wide_number_mode=>'round') AS `%s`", col.Name, col.Name))
+		case bigquery.RangeFieldType:
+			// BigQuery has no empty ranges, they are stored as NULL
+			transformedColumns = append(transformedColumns,
+				fmt.Sprintf("IF(`%[1]s` IS NULL OR `%[1]s`.%[2]s,NULL,RANGE(`%[1]s`.%[3]s,`%[1]s`.%[4]s)) AS `%[1]s`",
+					col.Name, types.RangeEmptyMember, types.RangeLowerMember, types.RangeUpperMember))
 		default:
 			transformedColumns = append(transformedColumns, fmt.Sprintf("`%s`", col.Name))
 		}
@@ -255,6 +261,10 @@ func DefineAvroSchema(dstTableName string,
 func GetAvroType(bqField *bigquery.FieldSchema) (avro.Schema, error) {
 	avroNumericPrecision, avroNumericScale := qvalue.DetermineNumericSettingForDWH(
 		int16(bqField.Precision), int16(bqField.Scale), protos.DBType_BIGQUERY)
+	if bqField.Precision > int64(datatypes.BigQueryNumericCompatibility{}.MaxPrecision()) {
+		// BIGNUMERIC of a column with the widest decimal strategy
+		avroNumericPrecision, avroNumericScale = int16(bqField.Precision), int16(bqField.Scale)
+	}
 
 	considerRepeated := func(typ avro.Type, repeated bool) avro.Schema {
 		if repeated {
@@ -314,14 +324,29 @@ func GetAvroType(bqField *bigquery.FieldSchema) (avro.Schema, error) {
 	case bigquery.RecordFieldType:
 		avroFields := []*avro.Field{}
 		for _, bqSubField := range bqField.Schema {
-			avroType, err := GetAvroType(bqSubField)
+			avroField, err := GetAvroField(bqSubField)
 			if err != nil {
 				return nil, err
 			}
-			avroField, err := avro.NewField(
-				bqSubField.Name,
-				avroType,
-			)
+			avroFields = append(avroFields, avroField)
+		}
+		recordSchema, err := avro.NewRecordSchema(bqField.Name, "", avroFields)
+		if err != nil {
+			return nil, err
+		}
+		if bqField.Repeated {
+			return avro.NewArraySchema(recordSchema), nil
+		}
+		return recordSchema, nil
+	case bigquery.RangeFieldType:
+		// ranges are staged as records of their bounds and rebuilt by getTransformedColumns
+		if bqField.RangeElementType == nil {
+			return nil, fmt.Errorf("range field %s has no element type", bqField.Name)
+		}
+		stagingSchema := bigQueryRangeStagingSchema(bqField)
+		avroFields := make([]*avro.Field, 0, len(stagingSchema))
+		for _, bqSubField := range stagingSchema {
+			avroField, err := GetAvroField(bqSubField)
 			if err != nil {
 				return nil, err
 			}
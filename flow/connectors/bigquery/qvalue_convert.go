@@ -2,10 +2,13 @@ package connbigquery
 
 import (
 	"fmt"
+	"strings"
 
 	"cloud.google.com/go/bigquery"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model/qvalue"
+	"github.com/PeerDB-io/peerdb/flow/pkg/common"
 	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
@@ -42,8 +45,22 @@ func qValueKindToBigQueryType(columnDescription *protos.FieldDescription, nullab
 	case types.QValueKindString, types.QValueKindEnum:
 		bqField.Type = bigquery.StringFieldType
 	// json related
-	case types.QValueKindJSON, types.QValueKindJSONB, types.QValueKindHStore:
+	case types.QValueKindJSON, types.QValueKindJSONB, types.QValueKindHStore, types.QValueKindMap:
 		bqField.Type = bigquery.JSONFieldType
+	// nested
+	case types.QValueKindStruct:
+		if elementType, ok := bigQueryRangeElementType(columnDescription); ok {
+			bqField.Type = bigquery.RangeFieldType
+			bqField.RangeElementType = &bigquery.RangeElementType{Type: elementType}
+			break
+		}
+		bqField.Type = bigquery.RecordFieldType
+		bqField.Schema = bigQueryRecordSchema(columnDescription, nullableEnabled)
+	case types.QValueKindArrayStruct:
+		// multiranges stay records too, BigQuery has no arrays of RANGE
+		bqField.Type = bigquery.RecordFieldType
+		bqField.Schema = bigQueryRecordSchema(columnDescription, nullableEnabled)
+		bqField.Repeated = true
 	// time related
 	case types.QValueKindTimestamp, types.QValueKindTimestampTZ:
 		bqField.Type = bigquery.TimestampFieldType
@@ -88,6 +105,38 @@ func qValueKindToBigQueryType(columnDescription *protos.FieldDescription, nullab
 	return bqField
 }
 
+func bigQueryRecordSchema(columnDescription *protos.FieldDescription, nullableEnabled bool) bigquery.Schema {
+	schema := make(bigquery.Schema, 0, len(columnDescription.Fields))
+	for _, member := range columnDescription.Fields {
+		memberField := qValueKindToBigQueryType(member, nullableEnabled)
+		schema = append(schema, &memberField)
+	}
+	return schema
+}
+
+// bigQueryRangeElementType returns the element type of the RANGE a struct column holding a range is created as.
+// BigQuery ranges are always [lower, upper), which date ranges are canonicalized to at the source,
+// while timestamp ranges keep inclusive bounds so they stay records like ranges BigQuery has no RANGE for
+func bigQueryRangeElementType(columnDescription *protos.FieldDescription) (bigquery.FieldType, bool) {
+	elementKind, ok := qvalue.RangeElementKind(columnDescription)
+	if !ok || elementKind != types.QValueKindDate {
+		return "", false
+	}
+	return bigquery.DateFieldType, true
+}
+
+// bigQueryRangeStagingSchema is the record a RANGE field is staged as, BigQuery ranges are always [lower, upper)
+// so the inclusive flags are only carried along to match the struct of the range
+func bigQueryRangeStagingSchema(bqField *bigquery.FieldSchema) bigquery.Schema {
+	return bigquery.Schema{
+		{Name: types.RangeLowerMember, Type: bqField.RangeElementType.Type},
+		{Name: types.RangeUpperMember, Type: bqField.RangeElementType.Type},
+		{Name: types.RangeLowerIncMember, Type: bigquery.BooleanFieldType, Required: true},
+		{Name: types.RangeUpperIncMember, Type: bigquery.BooleanFieldType, Required: true},
+		{Name: types.RangeEmptyMember, Type: bigquery.BooleanFieldType, Required: true},
+	}
+}
+
 // BigQueryTypeToQValueKind converts a bigquery.FieldType to a QValueKind
 func BigQueryTypeToQValueKind(fieldSchema *bigquery.FieldSchema) types.QValueKind {
 	switch fieldSchema.Type {
@@ -188,8 +237,41 @@ func createTableCompatibleTypeName(schemaType bigquery.FieldType) string {
 	return string(schemaType)
 }
 
+// numericStrategyBigQueryType is qValueKindToBigQueryType for a column with a NUMERIC strategy,
+// the widest decimal strategy widens the BIGNUMERIC of the column
+func numericStrategyBigQueryType(
+	columnDescription *protos.FieldDescription, nullableEnabled bool, strategy protos.NumericStrategy,
+) bigquery.FieldSchema {
+	bqField := qValueKindToBigQueryType(columnDescription, nullableEnabled)
+	if bqField.Type == bigquery.BigNumericFieldType && strategy == protos.NumericStrategy_NUMERIC_STRATEGY_WIDEST_DECIMAL {
+		precision, scale := common.ParseNumericTypmod(columnDescription.TypeModifier)
+		destType := qvalue.GetNumericDestinationTypeForStrategy(precision, scale, protos.DBType_BIGQUERY, false, strategy)
+		bqField.Precision = int64(destType.Precision)
+		bqField.Scale = int64(destType.Scale)
+	}
+	return bqField
+}
+
 func qValueKindToBigQueryTypeString(columnDescription *protos.FieldDescription, nullEnabled bool, forMerge bool) string {
-	bqTypeSchema := qValueKindToBigQueryType(columnDescription, nullEnabled)
+	return bigQueryTypeString(columnDescription, qValueKindToBigQueryType(columnDescription, nullEnabled), nullEnabled, forMerge)
+}
+
+func bigQueryTypeString(
+	columnDescription *protos.FieldDescription, bqTypeSchema bigquery.FieldSchema, nullEnabled bool, forMerge bool,
+) string {
+	if bqTypeSchema.Type == bigquery.RangeFieldType {
+		return "RANGE<" + createTableCompatibleTypeName(bqTypeSchema.RangeElementType.Type) + ">"
+	}
+	if bqTypeSchema.Type == bigquery.RecordFieldType {
+		members := make([]string, 0, len(columnDescription.Fields))
+		for _, member := range columnDescription.Fields {
+			members = append(members, fmt.Sprintf("`%s` %s", member.Name, qValueKindToBigQueryTypeString(member, nullEnabled, forMerge)))
+		}
+		if bqTypeSchema.Repeated && !forMerge {
+			return "ARRAY<STRUCT<" + strings.Join(members, ", ") + ">>"
+		}
+		return "STRUCT<" + strings.Join(members, ", ") + ">"
+	}
 	bqType := createTableCompatibleTypeName(bqTypeSchema.Type)
 	if bqTypeSchema.Type == bigquery.BigNumericFieldType && !forMerge {
 		bqType = fmt.Sprintf("BIGNUMERIC(%d,%d)", bqTypeSchema.Precision, bqTypeSchema.Scale)
@@ -201,6 +283,35 @@ func qValueKindToBigQueryTypeString(columnDescription *protos.FieldDescription,
 }
 
 func BigQueryFieldToQField(bqField *bigquery.FieldSchema) types.QField {
+	if bqField.Type == bigquery.RangeFieldType && bqField.RangeElementType != nil {
+		stagingSchema := bigQueryRangeStagingSchema(bqField)
+		fields := make([]types.QField, 0, len(stagingSchema))
+		for _, member := range stagingSchema {
+			fields = append(fields, BigQueryFieldToQField(member))
+		}
+		return types.QField{
+			Name:     bqField.Name,
+			Type:     types.QValueKindStruct,
+			Fields:   fields,
+			Nullable: !bqField.Required,
+		}
+	}
+	if bqField.Type == bigquery.RecordFieldType {
+		fields := make([]types.QField, 0, len(bqField.Schema))
+		for _, member := range bqField.Schema {
+			fields = append(fields, BigQueryFieldToQField(member))
+		}
+		kind := types.QValueKindStruct
+		if bqField.Repeated {
+			kind = types.QValueKindArrayStruct
+		}
+		return types.QField{
+			Name:     bqField.Name,
+			Type:     kind,
+			Fields:   fields,
+			Nullable: !bqField.Required,
+		}
+	}
 	return types.QField{
 		Name:      bqField.Name,
 		Type:      BigQueryTypeToQValueKind(bqField),
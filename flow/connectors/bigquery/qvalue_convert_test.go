@@ -6,6 +6,8 @@ import (
 	"cloud.google.com/go/bigquery"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
@@ -299,3 +301,17 @@ func TestBigQueryTypeToQValueKind(t *testing.T) {
 		})
 	}
 }
+
+func TestNumericStrategyBigQueryType(t *testing.T) {
+	column := &protos.FieldDescription{
+		Name:         "amount",
+		Type:         string(types.QValueKindNumeric),
+		TypeModifier: datatypes.MakeNumericTypmod(10, 2),
+	}
+	bqField := numericStrategyBigQueryType(column, false, protos.NumericStrategy_NUMERIC_STRATEGY_WIDEST_DECIMAL)
+	assert.Equal(t, "BIGNUMERIC(40,2)", bigQueryTypeString(column, bqField, false, false))
+	assert.Equal(t, "BIGNUMERIC", bigQueryTypeString(column, bqField, false, true))
+
+	bqField = numericStrategyBigQueryType(column, false, protos.NumericStrategy_NUMERIC_STRATEGY_ROUND)
+	assert.Equal(t, "BIGNUMERIC(10,2)", bigQueryTypeString(column, bqField, false, false))
+}
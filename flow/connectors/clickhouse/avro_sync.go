@@ -111,7 +111,8 @@ func (s *ClickHouseAvroSyncMethod) SyncQRepRecords(
 	if len(destTypeConversions) > 0 {
 		schema = applyTypeConversions(schema, destTypeConversions)
 	}
-	numericTruncator := model.NewSnapshotTableNumericTruncator(dstTableName, schema.Fields)
+	schema = applyNumericStrategies(schema, config.Columns)
+	numericTruncator := model.NewSnapshotTableNumericTruncator(dstTableName, schema.Fields, config.Columns)
 
 	columnNameAvroFieldMap := model.ConstructColumnNameAvroFieldMap(schema.Fields)
 	avroFiles, totalRecords, err := s.pushDataToStagingForSnapshot(ctx, config, dstTableName, schema,
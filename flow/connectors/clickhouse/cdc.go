@@ -37,6 +37,8 @@ const (
 		_peerdb_unchanged_toast_columns String
 	)`
 	zooPathPrefix = "/clickhouse/tables/{uuid}/{shard}/{database}/"
+	// plain MergeTree keeps deduplication tokens only within a window, direct insert retries rely on them
+	deduplicationWindowSetting = "non_replicated_deduplication_window = 1000"
 )
 
 // GetRawTableName returns the raw table name for the given table identifier.
@@ -79,11 +81,25 @@ func (c *ClickHouseConnector) CreateRawTable(ctx context.Context, req *protos.Cr
 	createRawTableSQL := `CREATE TABLE IF NOT EXISTS %s%s %s ENGINE = %s ORDER BY (_peerdb_batch_id, _peerdb_destination_table_name)` +
 		` TTL toDateTime(fromUnixTimestamp64Nano(_peerdb_timestamp)) + INTERVAL ` + strconv.FormatUint(uint64(ttlDays), 10) + ` DAY` +
 		` SETTINGS ttl_only_drop_parts = 1`
+	// replicated engines keep deduplication tokens by default, plain MergeTree only with a window
+	dedupWindow := c.directInsertEnabled() && !c.Config.Replicated
+	if dedupWindow {
+		createRawTableSQL += ", " + deduplicationWindowSetting
+	}
 	if err := c.execWithLogging(ctx,
 		fmt.Sprintf(createRawTableSQL, peerdb_clickhouse.QuoteIdentifier(rawTableName), onCluster, rawColumns, engine),
 	); err != nil {
 		return nil, fmt.Errorf("unable to create raw table: %w", err)
 	}
+	if dedupWindow {
+		// a raw table created before direct insert was enabled lacks the window
+		if err := c.execWithLogging(ctx, fmt.Sprintf(
+			"ALTER TABLE %s%s MODIFY SETTING %s",
+			peerdb_clickhouse.QuoteIdentifier(rawTableName), onCluster, deduplicationWindowSetting,
+		)); err != nil {
+			return nil, fmt.Errorf("unable to set deduplication window of raw table: %w", err)
+		}
+	}
 
 	if onCluster != "" {
 		createRawDistributedSQL := `CREATE TABLE IF NOT EXISTS %s%s %s ENGINE = Distributed(%s,%s,%s,cityHash64(_peerdb_uid))`
@@ -114,7 +130,9 @@ func (c *ClickHouseConnector) avroSyncMethod(flowJobName string, env map[string]
 	return NewClickHouseAvroSyncMethod(qrepConfig, c)
 }
 
-func (c *ClickHouseConnector) syncRecordsViaAvro(
+// syncRecordsToRawTable stages records as an Avro file that normalize copies into the raw table,
+// or inserts them into the raw table directly when the peer uses the direct insert sync method
+func (c *ClickHouseConnector) syncRecordsToRawTable(
 	ctx context.Context,
 	req *model.SyncRecordsRequest[model.RecordItems],
 	syncBatchID int64,
@@ -134,8 +152,13 @@ func (c *ClickHouseConnector) syncRecordsViaAvro(
 		return nil, fmt.Errorf("failed to convert records to raw table stream: %w", err)
 	}
 
-	avroSyncer := c.avroSyncMethod(req.FlowJobName, req.Env, req.Version)
-	numRecords, err := avroSyncer.SyncRecords(ctx, req.Env, stream, req.FlowJobName, syncBatchID)
+	var numRecords int64
+	if c.directInsertEnabled() {
+		numRecords, err = c.syncRawRecordsDirect(ctx, req.Env, stream, req.FlowJobName, syncBatchID)
+	} else {
+		numRecords, err = c.avroSyncMethod(req.FlowJobName, req.Env, req.Version).SyncRecords(
+			ctx, req.Env, stream, req.FlowJobName, syncBatchID)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -156,7 +179,7 @@ func (c *ClickHouseConnector) syncRecordsViaAvro(
 }
 
 func (c *ClickHouseConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
-	res, err := c.syncRecordsViaAvro(ctx, req, req.SyncBatchID)
+	res, err := c.syncRecordsToRawTable(ctx, req, req.SyncBatchID)
 	if err != nil {
 		return nil, err
 	}
@@ -237,7 +260,6 @@ func (c *ClickHouseConnector) ReplayTableSchemaDeltas(
 		return nil
 	}
 
-	onCluster := c.onCluster()
 	for _, schemaDelta := range schemaDeltas {
 		if schemaDelta == nil || len(schemaDelta.AddedColumns) == 0 {
 			continue
@@ -252,79 +274,112 @@ func (c *ClickHouseConnector) ReplayTableSchemaDeltas(
 			}
 		}
 
-		// Resolve the actual shard table name from the Distributed table definition.
-		// After resync, the shard table has a timestamp suffix (e.g., table_shard1710425678)
-		// and hardcoding "_shard" would target the wrong table.
-		// Distributed table isn't created for null tables
-		var shardTableName string
-		if c.Config.Cluster != "" && (tm == nil || tm.Engine != protos.TableEngine_CH_ENGINE_NULL) {
-			var err error
-			shardTableName, err = c.getDistributedShardTable(ctx, schemaDelta.DstTableName)
-			if err != nil {
-				return fmt.Errorf("failed to resolve shard table for %s: %w", schemaDelta.DstTableName, err)
+		if err := c.replayAddedColumns(ctx, env, schemaDelta.DstTableName,
+			tm == nil || tm.Engine != protos.TableEngine_CH_ENGINE_NULL, schemaDelta, nil, flags,
+		); err != nil {
+			return err
+		}
+		if tm != nil {
+			for _, projection := range tm.Projections {
+				if err := c.replayAddedColumns(ctx, env, projection.DestinationTableIdentifier,
+					projection.Engine != protos.TableEngine_CH_ENGINE_NULL, schemaDelta, projection.Exclude, flags,
+				); err != nil {
+					return err
+				}
 			}
 		}
+	}
 
-		for _, addedColumn := range schemaDelta.AddedColumns {
-			qvKind := types.QValueKind(addedColumn.Type)
-			clickHouseColType, err := qvalue.ToDWHColumnType(
-				ctx, qvKind, env, protos.DBType_CLICKHOUSE, c.chVersion, addedColumn, schemaDelta.NullableEnabled, flags,
-			)
-			if err != nil {
-				return fmt.Errorf("failed to convert column type %s to ClickHouse type: %w", addedColumn.Type, err)
-			}
+	return nil
+}
 
-			defaultExpr := addedColumn.DefaultExpr
-			if defaultExpr != nil && (qvKind == types.QValueKindTime || qvKind == types.QValueKindTimeTZ) &&
-				!slices.Contains(flags, shared.Flag_ClickHouseTime64Enabled) {
-				// on the legacy path time lands in DateTime64 as an offset from the epoch,
-				// which the source's 'HH:MM:SS' literal does not describe
-				c.logger.Warn("[schema delta replay] omitting source default for time column without Time64 support",
-					slog.String("column", addedColumn.Name), slog.String("default", *defaultExpr))
-				defaultExpr = nil
-			}
+// replayAddedColumns adds the columns of a schema delta to a destination table,
+// distributed is false for tables without a Distributed table in front of their shards
+func (c *ClickHouseConnector) replayAddedColumns(
+	ctx context.Context,
+	env map[string]string,
+	tableName string,
+	distributed bool,
+	schemaDelta *protos.TableSchemaDelta,
+	exclude []string,
+	flags []string,
+) error {
+	onCluster := c.onCluster()
 
-			columnDef := clickHouseColType
-			if defaultExpr != nil {
-				columnDef += " DEFAULT " + *defaultExpr
-			}
+	// Resolve the actual shard table name from the Distributed table definition.
+	// After resync, the shard table has a timestamp suffix (e.g., table_shard1710425678)
+	// and hardcoding "_shard" would target the wrong table.
+	// Distributed table isn't created for null tables
+	var shardTableName string
+	if c.Config.Cluster != "" && distributed {
+		var err error
+		shardTableName, err = c.getDistributedShardTable(ctx, tableName)
+		if err != nil {
+			return fmt.Errorf("failed to resolve shard table for %s: %w", tableName, err)
+		}
+	}
 
-			addColumn := func(tableName, def string) error {
-				return c.execWithLogging(ctx,
-					fmt.Sprintf("ALTER TABLE %s%s ADD COLUMN IF NOT EXISTS %s %s",
-						peerdb_clickhouse.QuoteIdentifier(tableName), onCluster,
-						peerdb_clickhouse.QuoteIdentifier(addedColumn.Name), def))
-			}
+	for _, addedColumn := range schemaDelta.AddedColumns {
+		if slices.Contains(exclude, addedColumn.Name) {
+			continue
+		}
+		qvKind := types.QValueKind(addedColumn.Type)
+		clickHouseColType, err := qvalue.ToDWHColumnType(
+			ctx, qvKind, env, protos.DBType_CLICKHOUSE, c.chVersion, addedColumn, schemaDelta.NullableEnabled, flags,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to convert column type %s to ClickHouse type: %w", addedColumn.Type, err)
+		}
 
-			// retry without default if ch rejected the expression
-			addColumnWithDefaultFallback := func(tableName string) error {
-				err := addColumn(tableName, columnDef)
-				if err == nil || defaultExpr == nil {
-					return err
-				}
-				c.logger.Warn("[schema delta replay] retrying added column without its source default",
-					slog.String("column", addedColumn.Name), slog.String("default", *defaultExpr),
-					slog.String("destination table name", tableName), slog.Any("error", err))
-				return addColumn(tableName, clickHouseColType)
-			}
+		defaultExpr := addedColumn.DefaultExpr
+		if defaultExpr != nil && (qvKind == types.QValueKindTime || qvKind == types.QValueKindTimeTZ) &&
+			!slices.Contains(flags, shared.Flag_ClickHouseTime64Enabled) {
+			// on the legacy path time lands in DateTime64 as an offset from the epoch,
+			// which the source's 'HH:MM:SS' literal does not describe
+			c.logger.Warn("[schema delta replay] omitting source default for time column without Time64 support",
+				slog.String("column", addedColumn.Name), slog.String("default", *defaultExpr))
+			defaultExpr = nil
+		}
 
-			if shardTableName != "" {
-				if err := addColumnWithDefaultFallback(shardTableName); err != nil {
-					return fmt.Errorf("failed to add column %s for table shards %s: %w", addedColumn.Name, schemaDelta.DstTableName, err)
-				}
+		columnDef := clickHouseColType
+		if defaultExpr != nil {
+			columnDef += " DEFAULT " + *defaultExpr
+		}
+
+		addColumn := func(table, def string) error {
+			return c.execWithLogging(ctx,
+				fmt.Sprintf("ALTER TABLE %s%s ADD COLUMN IF NOT EXISTS %s %s",
+					peerdb_clickhouse.QuoteIdentifier(table), onCluster,
+					peerdb_clickhouse.QuoteIdentifier(addedColumn.Name), def))
+		}
+
+		// retry without default if ch rejected the expression
+		addColumnWithDefaultFallback := func(table string) error {
+			err := addColumn(table, columnDef)
+			if err == nil || defaultExpr == nil {
+				return err
 			}
+			c.logger.Warn("[schema delta replay] retrying added column without its source default",
+				slog.String("column", addedColumn.Name), slog.String("default", *defaultExpr),
+				slog.String("destination table name", table), slog.Any("error", err))
+			return addColumn(table, clickHouseColType)
+		}
 
-			if err := addColumnWithDefaultFallback(schemaDelta.DstTableName); err != nil {
-				return fmt.Errorf("failed to add column %s for table %s: %w", addedColumn.Name, schemaDelta.DstTableName, err)
+		if shardTableName != "" {
+			if err := addColumnWithDefaultFallback(shardTableName); err != nil {
+				return fmt.Errorf("failed to add column %s for table shards %s: %w", addedColumn.Name, tableName, err)
 			}
-			c.logger.Info(
-				"[schema delta replay] added column",
-				slog.String("column", addedColumn.Name), slog.String("type", columnDef),
-				slog.String("destination table name", schemaDelta.DstTableName), slog.String("source table name", schemaDelta.SrcTableName),
-			)
 		}
-	}
 
+		if err := addColumnWithDefaultFallback(tableName); err != nil {
+			return fmt.Errorf("failed to add column %s for table %s: %w", addedColumn.Name, tableName, err)
+		}
+		c.logger.Info(
+			"[schema delta replay] added column",
+			slog.String("column", addedColumn.Name), slog.String("type", columnDef),
+			slog.String("destination table name", tableName), slog.String("source table name", schemaDelta.SrcTableName),
+		)
+	}
 	return nil
 }
 
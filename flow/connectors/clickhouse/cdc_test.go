@@ -142,3 +142,45 @@ func TestCreateRawTableHasTTL(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateRawTableAddsDeduplicationWindow(t *testing.T) {
+	// NewClickHouseConnector requires a staging bucket; CreateRawTable itself doesn't use it.
+	t.Setenv("PEERDB_CLICKHOUSE_AWS_S3_BUCKET_NAME", "dummy-bucket-for-dedup-test")
+
+	ctx := t.Context()
+	newConnector := func(syncMethod protos.ClickHouseSyncMethod) *ClickHouseConnector {
+		conn, err := NewClickHouseConnector(ctx, nil, &protos.ClickhouseConfig{
+			Host:       testutil.ClickHouseTestHost(),
+			Port:       testutil.ClickHouseTestPort(),
+			Database:   "default",
+			DisableTls: true,
+			SyncMethod: syncMethod,
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+
+	// the raw table exists from before direct insert was enabled
+	flowName := fmt.Sprintf("test_raw_table_dedup_%d", time.Now().UnixNano())
+	stagingConn := newConnector(protos.ClickHouseSyncMethod_CLICKHOUSE_SYNC_STAGING)
+	table, err := stagingConn.CreateRawTable(ctx, &protos.CreateRawTableInput{FlowJobName: flowName})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = stagingConn.execWithLogging(ctx,
+			"DROP TABLE IF EXISTS "+peerdb_clickhouse.QuoteIdentifier(table.TableIdentifier))
+	})
+
+	directConn := newConnector(protos.ClickHouseSyncMethod_CLICKHOUSE_SYNC_DIRECT_INSERT)
+	_, err = directConn.CreateRawTable(ctx, &protos.CreateRawTableInput{FlowJobName: flowName})
+	require.NoError(t, err)
+
+	var engineFull string
+	require.NoError(t, directConn.queryRow(ctx, fmt.Sprintf(
+		"SELECT engine_full FROM system.tables WHERE database = %s AND name = %s",
+		peerdb_clickhouse.QuoteLiteral("default"),
+		peerdb_clickhouse.QuoteLiteral(table.TableIdentifier),
+	)).Scan(&engineFull))
+	require.Contains(t, engineFull, "non_replicated_deduplication_window = 1000",
+		"direct insert should keep deduplication tokens in the raw table: %s", engineFull)
+}
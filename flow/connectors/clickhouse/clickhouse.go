@@ -58,9 +58,11 @@ func NewClickHouseConnector(
 		return nil, fmt.Errorf("failed to get ClickHouse version: %w", err)
 	}
 
-	staging, err := createStagingStore(ctx, env, config, clickHouseVersion.Version)
-	if err != nil {
-		return nil, err
+	var staging StagingStore
+	if config.SyncMethod != protos.ClickHouseSyncMethod_CLICKHOUSE_SYNC_DIRECT_INSERT {
+		if staging, err = createStagingStore(ctx, env, config, clickHouseVersion.Version); err != nil {
+			return nil, err
+		}
 	}
 
 	return &ClickHouseConnector{
@@ -77,14 +79,21 @@ func NewClickHouseConnector(
 func (c *ClickHouseConnector) ValidateCheck(ctx context.Context) error {
 	allowedDomains := internal.PeerDBClickHouseAllowedDomains()
 
+	// direct insert never has ClickHouse read staged files, so there is no access grant or bucket to check
+	stagingAccessMethod := ""
+	if c.staging != nil {
+		stagingAccessMethod = c.staging.ClickHouseAccessMethod()
+	}
 	if err := peerdb_clickhouse.ValidateClickHousePeer(
-		ctx, c.logger, allowedDomains, c.Config.Host, c.database, c.staging.ClickHouseAccessMethod(),
+		ctx, c.logger, allowedDomains, c.Config.Host, c.database, stagingAccessMethod,
 	); err != nil {
 		return err
 	}
 
-	if err := c.staging.Validate(ctx); err != nil {
-		return fmt.Errorf("failed to validate staging bucket: %w", err)
+	if c.staging != nil {
+		if err := c.staging.Validate(ctx); err != nil {
+			return fmt.Errorf("failed to validate staging bucket: %w", err)
+		}
 	}
 	return nil
 }
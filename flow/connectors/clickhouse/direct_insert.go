@@ -0,0 +1,710 @@
+package connclickhouse
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/shopspring/decimal"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	chinternal "github.com/PeerDB-io/peerdb/flow/internal/clickhouse"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/model/qvalue"
+	peerdb_clickhouse "github.com/PeerDB-io/peerdb/flow/pkg/clickhouse"
+	"github.com/PeerDB-io/peerdb/flow/pkg/common"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
+	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func (c *ClickHouseConnector) directInsertEnabled() bool {
+	return c.Config.SyncMethod == protos.ClickHouseSyncMethod_CLICKHOUSE_SYNC_DIRECT_INSERT
+}
+
+// directInsertConfig describes how a QRecordStream maps onto the columns of a ClickHouse table
+type directInsertConfig struct {
+	env             map[string]string
+	settings        *chinternal.CHSettings
+	typeConversions map[string]types.TypeConversion
+	// jsonArrays holds the element schema of JSON columns stored as arrays of tuples
+	jsonArrays       map[string]*protos.JsonArrayMapping
+	numericTruncator model.SnapshotTableNumericTruncator
+	destinationTable string
+	// tokenPrefix must identify the inserted rows across retries, it is suffixed with the block number and
+	// a hash of the block's rows to build the insert_deduplication_token of each block
+	tokenPrefix string
+	// columns are inserted in order, fieldIdx maps each to its position in the stream's records
+	columns  []string
+	fieldIdx []int
+	// constColumns are filled with the matching constValues for every row
+	constColumns []string
+	constValues  []any
+	time64       bool
+}
+
+type directInsertBlock struct {
+	rows [][]any
+	num  int
+}
+
+// directInsert streams records into cfg.destinationTable with native protocol batch inserts.
+// Blocks are inserted in parallel and retried with their deduplication token,
+// so that a block landing twice is dropped by ClickHouse instead of duplicating rows
+func (c *ClickHouseConnector) directInsert(
+	ctx context.Context,
+	cfg *directInsertConfig,
+	stream *model.QRecordStream,
+) (int64, error) {
+	parallelism, err := internal.PeerDBClickHouseDirectInsertParallelism(ctx, cfg.env)
+	if err != nil {
+		return 0, err
+	}
+	batchRows, err := internal.PeerDBClickHouseDirectInsertBatchRows(ctx, cfg.env)
+	if err != nil {
+		return 0, err
+	}
+	binaryFormat, err := internal.PeerDBBinaryFormat(ctx, cfg.env)
+	if err != nil {
+		return 0, err
+	}
+	unboundedNumericAsString, err := internal.PeerDBEnableClickHouseNumericAsString(ctx, cfg.env)
+	if err != nil {
+		return 0, err
+	}
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, err
+	}
+	maxRetries, err := internal.PeerDBClickHouseDirectInsertMaxRetries(ctx, cfg.env)
+	if err != nil {
+		return 0, err
+	}
+	batchRows = max(batchRows, 1)
+
+	quotedColumns := make([]string, 0, len(cfg.columns)+len(cfg.constColumns))
+	for _, col := range cfg.columns {
+		quotedColumns = append(quotedColumns, peerdb_clickhouse.QuoteIdentifier(col))
+	}
+	for _, col := range cfg.constColumns {
+		quotedColumns = append(quotedColumns, peerdb_clickhouse.QuoteIdentifier(col))
+	}
+	query := fmt.Sprintf("INSERT INTO %s(%s)",
+		peerdb_clickhouse.QuoteIdentifier(cfg.destinationTable), strings.Join(quotedColumns, ","))
+
+	converter := directInsertConverter{
+		numericTruncator:         cfg.numericTruncator,
+		binaryFormat:             binaryFormat,
+		unboundedNumericAsString: unboundedNumericAsString,
+		time64:                   cfg.time64,
+	}
+
+	insertCtx, cancelInserts := context.WithCancel(ctx)
+	defer cancelInserts()
+	group, groupCtx := errgroup.WithContext(insertCtx)
+	group.SetLimit(max(parallelism, 1))
+	var numRecords int64
+	block := directInsertBlock{rows: make([][]any, 0, batchRows)}
+	dispatch := func() {
+		b := block
+		group.Go(func() error {
+			return c.insertDirectBlock(groupCtx, cfg, query, maxRetries, b)
+		})
+		block = directInsertBlock{rows: make([][]any, 0, batchRows), num: b.num + 1}
+	}
+
+	for record := range stream.Records {
+		row := make([]any, 0, len(cfg.columns)+len(cfg.constColumns))
+		for _, idx := range cfg.fieldIdx {
+			val := record[idx]
+			if typeConversion, ok := cfg.typeConversions[schema.Fields[idx].Name]; ok {
+				val = typeConversion.ValueConversion(val)
+			}
+			var converted any
+			var err error
+			if mapping, ok := cfg.jsonArrays[schema.Fields[idx].Name]; ok {
+				converted, err = convertJSONArray(mapping, val)
+			} else {
+				converted, err = converter.convert(&schema.Fields[idx], idx, val)
+			}
+			if err != nil {
+				// stop pending inserts before returning, the stream is drained by its producer on cancellation
+				cancelInserts()
+				_ = group.Wait()
+				return 0, fmt.Errorf("failed to convert column %s for direct insert: %w", schema.Fields[idx].Name, err)
+			}
+			row = append(row, converted)
+		}
+		row = append(row, cfg.constValues...)
+		block.rows = append(block.rows, row)
+		numRecords++
+
+		if len(block.rows) >= batchRows {
+			dispatch()
+		}
+		if groupCtx.Err() != nil {
+			break
+		}
+	}
+	if err := stream.Err(); err != nil {
+		cancelInserts()
+		_ = group.Wait()
+		return 0, fmt.Errorf("failed to read records for direct insert: %w", err)
+	}
+	if len(block.rows) > 0 && groupCtx.Err() == nil {
+		dispatch()
+	}
+	if err := group.Wait(); err != nil {
+		return 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return numRecords, nil
+}
+
+func (c *ClickHouseConnector) insertDirectBlock(
+	ctx context.Context, cfg *directInsertConfig, query string, maxRetries int, block directInsertBlock,
+) error {
+	// a retried partition may pull its rows in another order, so the token must depend on the rows
+	// and not only on the block's position, else a different block would be dropped as a duplicate
+	token := fmt.Sprintf("%s_%d_%s", cfg.tokenPrefix, block.num, hashDirectBlockRows(block.rows))
+	querySettings := clickhouse.Settings{
+		string(chinternal.SettingInsertDeduplicate):        "1",
+		string(chinternal.SettingInsertDeduplicationToken): token,
+	}
+	if cfg.settings != nil {
+		for name, val := range cfg.settings.Map() {
+			querySettings[name] = val
+		}
+	}
+	insertCtx := clickhouse.Context(ctx, clickhouse.WithSettings(querySettings))
+
+	for attempt := 0; ; attempt++ {
+		err := c.sendDirectBlock(insertCtx, query, block.rows)
+		if err == nil {
+			return nil
+		}
+		var appendErr *directInsertAppendError
+		if errors.As(err, &appendErr) || ctx.Err() != nil || attempt >= maxRetries {
+			return fmt.Errorf("failed to insert block %d into %s: %w", block.num, cfg.destinationTable, err)
+		}
+		backoff := min(time.Second<<attempt, time.Minute)
+		c.logger.Warn("[clickhouse] retrying direct insert block",
+			slog.String("destinationTable", cfg.destinationTable),
+			slog.String("deduplicationToken", token),
+			slog.Int("attempt", attempt+1),
+			slog.Duration("backoff", backoff),
+			slog.Any("error", err))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// hashDirectBlockRows hashes the converted values of rows, fmt prints maps sorted by key
+func hashDirectBlockRows(rows [][]any) string {
+	hash := sha256.New()
+	for _, row := range rows {
+		for _, val := range row {
+			fmt.Fprintf(hash, "%v\x1f", val)
+		}
+		hash.Write([]byte{'\x1e'})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// ensureDeduplicationWindow keeps the insert_deduplication_token of recent blocks in a non-replicated
+// MergeTree table, ClickHouse ignores the token of a retried block otherwise
+func (c *ClickHouseConnector) ensureDeduplicationWindow(ctx context.Context, tableName string) error {
+	engine, err := c.getTableEngine(ctx, tableName)
+	if err != nil {
+		return err
+	}
+	if engine == "Distributed" {
+		if tableName, err = c.getDistributedShardTable(ctx, tableName); err != nil {
+			return err
+		}
+		if engine, err = c.getTableEngine(ctx, tableName); err != nil {
+			return err
+		}
+	}
+	// replicated and shared engines keep deduplication tokens by default
+	if !strings.HasSuffix(engine, "MergeTree") || strings.HasPrefix(engine, "Replicated") || strings.HasPrefix(engine, "Shared") {
+		return nil
+	}
+	if err := c.execWithLogging(ctx, fmt.Sprintf("ALTER TABLE %s%s MODIFY SETTING %s",
+		peerdb_clickhouse.QuoteIdentifier(tableName), c.onCluster(), deduplicationWindowSetting),
+	); err != nil {
+		return fmt.Errorf("failed to set deduplication window of %s for direct insert: %w", tableName, err)
+	}
+	return nil
+}
+
+// directInsertAppendError marks values the driver cannot encode, retrying such a block would fail the same way
+type directInsertAppendError struct {
+	err error
+}
+
+func (e *directInsertAppendError) Error() string {
+	return e.err.Error()
+}
+
+func (e *directInsertAppendError) Unwrap() error {
+	return e.err
+}
+
+func (c *ClickHouseConnector) sendDirectBlock(ctx context.Context, query string, rows [][]any) error {
+	batch, err := c.database.PrepareBatch(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare batch: %w", err)
+	}
+	defer batch.Close()
+
+	columns := batch.Columns()
+	columnTypes := make([]string, len(columns))
+	for i, col := range columns {
+		columnTypes[i] = string(col.Type())
+	}
+	args := make([]any, len(columnTypes))
+	for _, row := range rows {
+		if len(row) != len(columnTypes) {
+			return &directInsertAppendError{
+				fmt.Errorf("row has %d values for %d columns", len(row), len(columnTypes)),
+			}
+		}
+		for i, val := range row {
+			args[i] = coerceToColumnType(columnTypes[i], val)
+		}
+		if err := batch.Append(args...); err != nil {
+			return &directInsertAppendError{fmt.Errorf("failed to append row: %w", err)}
+		}
+	}
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to send batch: %w", err)
+	}
+	return nil
+}
+
+// baseColumnType strips Nullable and LowCardinality wrappers
+func baseColumnType(colType string) string {
+	for {
+		if inner, ok := strings.CutPrefix(colType, "Nullable("); ok {
+			colType = strings.TrimSuffix(inner, ")")
+		} else if inner, ok := strings.CutPrefix(colType, "LowCardinality("); ok {
+			colType = strings.TrimSuffix(inner, ")")
+		} else {
+			return colType
+		}
+	}
+}
+
+// coerceToColumnType converts scalar values whose Go type does not match the destination column,
+// the driver only accepts exact integer widths, and numbers or times headed for String columns
+func coerceToColumnType(colType string, val any) any {
+	if val == nil {
+		return nil
+	}
+	switch baseColumnType(colType) {
+	case "Int8":
+		if n, ok := asInt64(val); ok {
+			return int8(n)
+		}
+	case "Int16":
+		if n, ok := asInt64(val); ok {
+			return int16(n)
+		}
+	case "Int32":
+		if n, ok := asInt64(val); ok {
+			return int32(n)
+		}
+	case "Int64":
+		if n, ok := asInt64(val); ok {
+			return n
+		}
+	case "UInt8":
+		if n, ok := asInt64(val); ok {
+			return uint8(n)
+		}
+	case "UInt16":
+		if n, ok := asInt64(val); ok {
+			return uint16(n)
+		}
+	case "UInt32":
+		if n, ok := asInt64(val); ok {
+			return uint32(n)
+		}
+	case "UInt64":
+		if n, ok := val.(uint64); ok {
+			return n
+		} else if n, ok := asInt64(val); ok {
+			return uint64(n)
+		}
+	case "Float32":
+		if f, ok := val.(float64); ok {
+			return float32(f)
+		}
+	case "Float64":
+		if f, ok := val.(float32); ok {
+			return float64(f)
+		}
+	case "String":
+		switch v := val.(type) {
+		case string, []byte:
+			return v
+		case int64:
+			return strconv.FormatInt(v, 10)
+		case float64:
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		case bool:
+			return strconv.FormatBool(v)
+		case time.Time:
+			return v.Format(time.RFC3339Nano)
+		case fmt.Stringer:
+			return v.String()
+		}
+	}
+	return val
+}
+
+func asInt64(val any) (int64, bool) {
+	switch v := val.(type) {
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+// directInsertConverter turns QValues into the Go types clickhouse-go encodes for the columns PeerDB creates,
+// applying the same clamping, truncation and binary format as the Avro staging path
+type directInsertConverter struct {
+	numericTruncator         model.SnapshotTableNumericTruncator
+	binaryFormat             internal.BinaryFormat
+	unboundedNumericAsString bool
+	time64                   bool
+}
+
+func (c *directInsertConverter) convert(field *types.QField, idx int, value types.QValue) (any, error) {
+	if value == nil || value.Value() == nil {
+		return nil, nil
+	}
+
+	switch v := value.(type) {
+	case types.QValueQChar:
+		return string(v.Val), nil
+	case types.QValueTimestamp:
+		return clampClickHouseTime(v.Val), nil
+	case types.QValueTimestampTZ:
+		return clampClickHouseTime(v.Val), nil
+	case types.QValueDate:
+		return clampClickHouseTime(v.Val), nil
+	case types.QValueTime:
+		return c.convertTime(v.Val), nil
+	case types.QValueTimeTZ:
+		return c.convertTime(v.Val), nil
+	case types.QValueNumeric:
+		return c.convertNumeric(field, idx, v.Val)
+	case types.QValueArrayNumeric:
+		return c.convertArrayNumeric(field, idx, v.Val)
+	case types.QValueBytes:
+		switch c.binaryFormat {
+		case internal.BinaryFormatBase64:
+			return base64.StdEncoding.EncodeToString(v.Val), nil
+		case internal.BinaryFormatHex:
+			return strings.ToUpper(hex.EncodeToString(v.Val)), nil
+		default:
+			return v.Val, nil
+		}
+	case types.QValueHStore:
+		jsonString, err := datatypes.ParseHstore(v.Val)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %s: %w", v.Val, err)
+		}
+		return jsonString, nil
+	case types.QValueInt256:
+		return new(big.Int).Set(v.Val), nil
+	case types.QValueUInt256:
+		return new(big.Int).Set(v.Val), nil
+	case types.QValueArrayTimestamp:
+		return clampClickHouseTimes(v.Val), nil
+	case types.QValueArrayTimestampTZ:
+		return clampClickHouseTimes(v.Val), nil
+	case types.QValueArrayDate:
+		return clampClickHouseTimes(v.Val), nil
+	case types.QValueStruct:
+		// clickhouse-go encodes named tuples from maps keyed by element name
+		tuple := make(map[string]any, len(field.Fields))
+		for i := range field.Fields {
+			tuple[field.Fields[i].Name] = nil
+		}
+		for _, member := range v.Val {
+			memberIdx := slices.IndexFunc(field.Fields, func(f types.QField) bool { return f.Name == member.Name })
+			if memberIdx < 0 {
+				return nil, fmt.Errorf("struct member %s missing from schema of %s", member.Name, field.Name)
+			}
+			converted, err := c.convert(&field.Fields[memberIdx], idx, member.Val)
+			if err != nil {
+				return nil, err
+			}
+			tuple[member.Name] = converted
+		}
+		return tuple, nil
+	case types.QValueArrayStruct:
+		elementField := *field
+		elementField.Type = types.QValueKindStruct
+		tuples := make([]map[string]any, 0, len(v.Val))
+		for _, element := range v.Val {
+			tuple, err := c.convert(&elementField, idx, element)
+			if err != nil {
+				return nil, err
+			}
+			tuples = append(tuples, tuple.(map[string]any))
+		}
+		return tuples, nil
+	case types.QValueMap:
+		if len(field.Fields) != 2 {
+			return nil, fmt.Errorf("map field %s needs a key and a value field", field.Name)
+		}
+		entries := make(map[string]any, len(v.Val))
+		for key, val := range v.Val {
+			converted, err := c.convert(&field.Fields[1], idx, val)
+			if err != nil {
+				return nil, err
+			}
+			entries[key] = converted
+		}
+		return entries, nil
+	default:
+		return v.Value(), nil
+	}
+}
+
+func (c *directInsertConverter) convertTime(t time.Duration) any {
+	if c.time64 {
+		return t
+	}
+	// legacy DateTime64 columns hold the time of day as an offset from the epoch, as Avro time-micros reads in
+	return time.Unix(0, 0).UTC().Add(t.Truncate(time.Microsecond))
+}
+
+func (c *directInsertConverter) convertNumeric(field *types.QField, idx int, num decimal.Decimal) (any, error) {
+	destType := qvalue.GetNumericDestinationType(field.Precision, field.Scale, protos.DBType_CLICKHOUSE, c.unboundedNumericAsString)
+	if destType.IsString {
+		return num.String(), nil
+	}
+	num, _, ok, err := qvalue.TruncateNumeric(num, destType.Precision, destType.Scale, protos.DBType_CLICKHOUSE,
+		c.numericTruncator.Get(idx))
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		if field.Nullable {
+			return nil, nil
+		}
+		return decimal.Zero, nil
+	}
+	return num, nil
+}
+
+func (c *directInsertConverter) convertArrayNumeric(field *types.QField, idx int, nums []decimal.Decimal) (any, error) {
+	destType := qvalue.GetNumericDestinationType(field.Precision, field.Scale, protos.DBType_CLICKHOUSE, c.unboundedNumericAsString)
+	if destType.IsString {
+		strs := make([]string, 0, len(nums))
+		for _, num := range nums {
+			strs = append(strs, num.String())
+		}
+		return strs, nil
+	}
+	truncated := make([]decimal.Decimal, 0, len(nums))
+	for _, num := range nums {
+		num, _, ok, err := qvalue.TruncateNumeric(num, destType.Precision, destType.Scale, protos.DBType_CLICKHOUSE,
+			c.numericTruncator.Get(idx))
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			num = decimal.Zero
+		}
+		truncated = append(truncated, num)
+	}
+	return truncated, nil
+}
+
+func clampClickHouseTime(t time.Time) time.Time {
+	if year := t.Year(); year < qvalue.ClickHouseMinYear {
+		return time.Date(qvalue.ClickHouseMinYear, time.January, 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	} else if year > qvalue.ClickHouseMaxYear {
+		return time.Date(qvalue.ClickHouseMaxYear, time.December, 31, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	}
+	return t
+}
+
+func clampClickHouseTimes(ts []time.Time) []time.Time {
+	clamped := make([]time.Time, 0, len(ts))
+	for _, t := range ts {
+		clamped = append(clamped, clampClickHouseTime(t))
+	}
+	return clamped
+}
+
+// syncRawRecordsDirect inserts a CDC batch into the raw table and records it in the stage table,
+// so that normalize knows there is nothing left to copy for the batch
+func (c *ClickHouseConnector) syncRawRecordsDirect(
+	ctx context.Context,
+	env map[string]string,
+	stream *model.QRecordStream,
+	flowJobName string,
+	syncBatchID int64,
+) (int64, error) {
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, err
+	}
+	rawTableName := c.GetRawTableName(flowJobName)
+	cfg := &directInsertConfig{
+		env:              env,
+		destinationTable: rawTableName,
+		tokenPrefix:      fmt.Sprintf("%s_%d", rawTableName, syncBatchID),
+		columns:          make([]string, 0, len(schema.Fields)),
+		fieldIdx:         make([]int, 0, len(schema.Fields)),
+	}
+	for idx, field := range schema.Fields {
+		cfg.columns = append(cfg.columns, field.Name)
+		cfg.fieldIdx = append(cfg.fieldIdx, idx)
+	}
+
+	numRecords, err := c.directInsert(ctx, cfg, stream)
+	if err != nil {
+		return 0, err
+	}
+	c.logger.Info("[SyncRecords] inserted records into raw table",
+		slog.String("rawTable", rawTableName),
+		slog.Int64("numRecords", numRecords),
+		slog.Int64("syncBatchID", syncBatchID))
+
+	if err := SetAvroStage(ctx, flowJobName, syncBatchID, utils.AvroFile{
+		StorageLocation: utils.AvroDirectInsert,
+		NumRecords:      numRecords,
+	}); err != nil {
+		return 0, fmt.Errorf("failed to set avro stage: %w", err)
+	}
+	return numRecords, nil
+}
+
+func (c *ClickHouseConnector) syncQRepRecordsDirect(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, shared.QRepWarnings, error) {
+	dstTableName := config.DestinationTableIdentifier
+	startTime := time.Now()
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	destTypeConversions := findTypeConversions(schema, config.Columns)
+	if len(destTypeConversions) > 0 {
+		schema = applyTypeConversions(schema, destTypeConversions)
+	}
+	schema = applyNumericStrategies(schema, config.Columns)
+	numericTruncator := model.NewSnapshotTableNumericTruncator(dstTableName, schema.Fields, config.Columns)
+
+	chSettings := chinternal.NewCHSettings(c.chVersion)
+	chSettings.Add(chinternal.SettingThrowOnMaxPartitionsPerInsertBlock, "0")
+	chSettings.Add(chinternal.SettingTypeJsonSkipDuplicatedPaths, "1")
+	if config.Version >= shared.InternalVersion_JsonEscapeDotsInKeys {
+		chSettings.Add(chinternal.SettingJsonTypeEscapeDotsInKeys, "1")
+	}
+
+	cfg := &directInsertConfig{
+		env:              config.Env,
+		settings:         chSettings,
+		typeConversions:  destTypeConversions,
+		numericTruncator: numericTruncator,
+		destinationTable: dstTableName,
+		tokenPrefix:      fmt.Sprintf("%s_%s", config.FlowJobName, partition.PartitionId),
+		columns:          make([]string, 0, len(schema.Fields)),
+		fieldIdx:         make([]int, 0, len(schema.Fields)),
+		time64:           slices.Contains(config.Flags, shared.Flag_ClickHouseTime64Enabled),
+	}
+	for idx, field := range schema.Fields {
+		if slices.Contains(config.Exclude, field.Name) {
+			continue
+		}
+		cfg.columns = append(cfg.columns, field.Name)
+		cfg.fieldIdx = append(cfg.fieldIdx, idx)
+		if mapping := findJSONArrayMapping(config.Columns, field.Name, field.Type); mapping != nil {
+			if cfg.jsonArrays == nil {
+				cfg.jsonArrays = make(map[string]*protos.JsonArrayMapping)
+			}
+			cfg.jsonArrays[field.Name] = mapping
+		}
+	}
+
+	sourceSchemaAsDestinationColumn, err := internal.PeerDBSourceSchemaAsDestinationColumn(ctx, config.Env)
+	if err != nil {
+		return 0, nil, err
+	}
+	if sourceSchemaAsDestinationColumn {
+		qualifiedTable, err := common.ParseTableIdentifier(config.WatermarkTable)
+		if err != nil {
+			return 0, nil, err
+		}
+		cfg.constColumns = []string{sourceSchemaColName}
+		cfg.constValues = []any{qualifiedTable.Namespace}
+	}
+
+	if err := c.ensureDeduplicationWindow(ctx, dstTableName); err != nil {
+		return 0, nil, err
+	}
+	numRecords, err := c.directInsert(ctx, cfg, stream)
+	if err != nil {
+		c.logger.Error("failed to insert data into ClickHouse",
+			slog.String("dstTable", dstTableName),
+			slog.Any("error", err))
+		return 0, nil, exceptions.NewClickHouseQRepSyncError(err, dstTableName, c.Config.Database)
+	}
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		c.logger.Error("Failed to finish QRep partition", slog.Any("error", err))
+		return 0, nil, err
+	}
+
+	return numRecords, numericTruncator.Warnings(), nil
+}
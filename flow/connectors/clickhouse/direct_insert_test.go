@@ -0,0 +1,60 @@
+package connclickhouse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/model/qvalue"
+)
+
+func TestBaseColumnType(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "String", baseColumnType("LowCardinality(Nullable(String))"))
+	require.Equal(t, "Int32", baseColumnType("Nullable(Int32)"))
+	require.Equal(t, "Array(Nullable(Int32))", baseColumnType("Array(Nullable(Int32))"))
+}
+
+func TestCoerceToColumnType(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, int8(5), coerceToColumnType("Int8", int64(5)))
+	require.Equal(t, uint32(7), coerceToColumnType("Nullable(UInt32)", int16(7)))
+	require.Equal(t, float32(1.5), coerceToColumnType("Float32", float64(1.5)))
+	require.Equal(t, "42", coerceToColumnType("String", int64(42)))
+	require.Equal(t, "true", coerceToColumnType("LowCardinality(String)", true))
+	require.Nil(t, coerceToColumnType("Nullable(Int64)", nil))
+	require.Equal(t, []int32{1}, coerceToColumnType("Array(Int32)", []int32{1}))
+}
+
+func TestClampClickHouseTime(t *testing.T) {
+	t.Parallel()
+	low := time.Date(1700, time.March, 3, 10, 0, 0, 0, time.UTC)
+	require.Equal(t, time.Date(qvalue.ClickHouseMinYear, time.January, 1, 10, 0, 0, 0, time.UTC), clampClickHouseTime(low))
+	high := time.Date(3000, time.March, 3, 10, 0, 0, 0, time.UTC)
+	require.Equal(t, time.Date(qvalue.ClickHouseMaxYear, time.December, 31, 10, 0, 0, 0, time.UTC), clampClickHouseTime(high))
+	inRange := time.Date(2024, time.March, 3, 10, 0, 0, 0, time.UTC)
+	require.Equal(t, inRange, clampClickHouseTime(inRange))
+}
+
+func TestHashDirectBlockRows(t *testing.T) {
+	t.Parallel()
+	rows := [][]any{
+		{int64(1), "a", map[string]any{"x": 1, "y": 2}},
+		{int64(2), "b", map[string]any{"y": 2, "x": 1}},
+	}
+	same := [][]any{
+		{int64(1), "a", map[string]any{"y": 2, "x": 1}},
+		{int64(2), "b", map[string]any{"x": 1, "y": 2}},
+	}
+	reordered := [][]any{rows[1], rows[0]}
+	// same length, other rows
+	other := [][]any{
+		{int64(3), "c", map[string]any{"x": 1, "y": 2}},
+		{int64(4), "d", map[string]any{"x": 1, "y": 2}},
+	}
+	require.Equal(t, hashDirectBlockRows(rows), hashDirectBlockRows(same))
+	require.NotEqual(t, hashDirectBlockRows(rows), hashDirectBlockRows(reordered))
+	require.NotEqual(t, hashDirectBlockRows(rows), hashDirectBlockRows(other))
+	require.NotEqual(t, hashDirectBlockRows([][]any{{"ab", "c"}}), hashDirectBlockRows([][]any{{"a", "bc"}}))
+}
@@ -0,0 +1,121 @@
+package connclickhouse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	peerdb_clickhouse "github.com/PeerDB-io/peerdb/flow/pkg/clickhouse"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// findJSONArrayMapping returns the element schema a JSON column is exploded into, if any
+func findJSONArrayMapping(columns []*protos.ColumnSetting, sourceName string, colType types.QValueKind) *protos.JsonArrayMapping {
+	if colType != types.QValueKindJSON && colType != types.QValueKindJSONB {
+		return nil
+	}
+	for _, col := range columns {
+		if col.SourceName == sourceName {
+			if len(col.JsonArray.GetFields()) > 0 {
+				return col.JsonArray
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func jsonArrayTupleElements(mapping *protos.JsonArrayMapping) string {
+	elements := make([]string, 0, len(mapping.Fields))
+	for _, field := range mapping.Fields {
+		elements = append(elements, peerdb_clickhouse.QuoteIdentifier(field.Name)+" "+field.Type)
+	}
+	return strings.Join(elements, ", ")
+}
+
+// jsonArrayColumnType is the type of the destination column,
+// Nested needs flatten_nested=0 on creation to be stored as a single array of tuples
+func jsonArrayColumnType(mapping *protos.JsonArrayMapping) string {
+	if mapping.Nested {
+		return "Nested(" + jsonArrayTupleElements(mapping) + ")"
+	}
+	return "Array(Tuple(" + jsonArrayTupleElements(mapping) + "))"
+}
+
+// jsonArrayExtractExpr parses JSON text into the element schema, objects are matched to tuple elements by name
+func jsonArrayExtractExpr(jsonExpr string, mapping *protos.JsonArrayMapping) string {
+	return fmt.Sprintf("JSONExtract(%s, %s)", jsonExpr,
+		peerdb_clickhouse.QuoteLiteral("Array(Tuple("+jsonArrayTupleElements(mapping)+"))"))
+}
+
+// convertJSONArray converts a JSON value to the tuples of its mapped column, null becomes an empty array
+func convertJSONArray(mapping *protos.JsonArrayMapping, value types.QValue) (any, error) {
+	if value == nil || value.Value() == nil {
+		return []map[string]any{}, nil
+	}
+	jsonText, ok := value.Value().(string)
+	if !ok {
+		return nil, fmt.Errorf("expected JSON text, got %T", value.Value())
+	}
+	return decodeJSONArray(mapping, jsonText)
+}
+
+// decodeJSONArray converts JSON text to named tuples for direct insert,
+// numbers are kept exact until coerced to the declared element type
+func decodeJSONArray(mapping *protos.JsonArrayMapping, jsonText string) ([]map[string]any, error) {
+	decoder := json.NewDecoder(strings.NewReader(jsonText))
+	decoder.UseNumber()
+	var elements []map[string]any
+	if err := decoder.Decode(&elements); err != nil {
+		return nil, fmt.Errorf("expected a JSON array of objects: %w", err)
+	}
+
+	tuples := make([]map[string]any, 0, len(elements))
+	for _, element := range elements {
+		tuple := make(map[string]any, len(mapping.Fields))
+		for _, field := range mapping.Fields {
+			val, err := coerceJSONToColumnType(field.Type, element[field.Name])
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			tuple[field.Name] = val
+		}
+		tuples = append(tuples, tuple)
+	}
+	return tuples, nil
+}
+
+func coerceJSONToColumnType(colType string, val any) (any, error) {
+	switch v := val.(type) {
+	case nil:
+		return nil, nil
+	case json.Number:
+		switch baseType := baseColumnType(colType); {
+		case strings.HasPrefix(baseType, "Int"), strings.HasPrefix(baseType, "UInt"):
+			n, err := v.Int64()
+			if err != nil {
+				return nil, err
+			}
+			return coerceToColumnType(colType, n), nil
+		case strings.HasPrefix(baseType, "Float"):
+			f, err := v.Float64()
+			if err != nil {
+				return nil, err
+			}
+			return coerceToColumnType(colType, f), nil
+		default:
+			return v.String(), nil
+		}
+	case map[string]any, []any:
+		// nested JSON is kept as text, as JSONExtract does for String elements
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(v); err != nil {
+			return nil, err
+		}
+		return strings.TrimSuffix(buf.String(), "\n"), nil
+	default:
+		return coerceToColumnType(colType, v), nil
+	}
+}
@@ -0,0 +1,64 @@
+package connclickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func testJSONArrayMapping(nested bool) *protos.JsonArrayMapping {
+	return &protos.JsonArrayMapping{
+		Fields: []*protos.JsonArrayElementField{
+			{Name: "sku", Type: "String"},
+			{Name: "qty", Type: "Int32"},
+			{Name: "price", Type: "Nullable(Float64)"},
+		},
+		Nested: nested,
+	}
+}
+
+func TestFindJSONArrayMapping(t *testing.T) {
+	t.Parallel()
+	columns := []*protos.ColumnSetting{
+		{SourceName: "items", JsonArray: testJSONArrayMapping(false)},
+		{SourceName: "tags", DestinationType: "String"},
+	}
+	require.NotNil(t, findJSONArrayMapping(columns, "items", types.QValueKindJSONB))
+	require.Nil(t, findJSONArrayMapping(columns, "items", types.QValueKindString))
+	require.Nil(t, findJSONArrayMapping(columns, "tags", types.QValueKindJSON))
+	require.Nil(t, findJSONArrayMapping(columns, "other", types.QValueKindJSON))
+}
+
+func TestJSONArrayColumnType(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "Array(Tuple(`sku` String, `qty` Int32, `price` Nullable(Float64)))",
+		jsonArrayColumnType(testJSONArrayMapping(false)))
+	require.Equal(t, "Nested(`sku` String, `qty` Int32, `price` Nullable(Float64))",
+		jsonArrayColumnType(testJSONArrayMapping(true)))
+	require.Equal(t, "JSONExtract(x, 'Array(Tuple(\\`sku\\` String, \\`qty\\` Int32, \\`price\\` Nullable(Float64)))')",
+		jsonArrayExtractExpr("x", testJSONArrayMapping(true)))
+}
+
+func TestDecodeJSONArray(t *testing.T) {
+	t.Parallel()
+	mapping := testJSONArrayMapping(false)
+
+	tuples, err := decodeJSONArray(mapping, `[{"sku":"a","qty":2,"price":1.5,"extra":true},{"sku":"b","qty":3,"attrs":{"x":1}}]`)
+	require.NoError(t, err)
+	require.Equal(t, []map[string]any{
+		{"sku": "a", "qty": int32(2), "price": 1.5},
+		{"sku": "b", "qty": int32(3), "price": nil},
+	}, tuples)
+
+	_, err = decodeJSONArray(mapping, `{"sku":"a"}`)
+	require.Error(t, err)
+	_, err = decodeJSONArray(mapping, `[{"qty":1.5}]`)
+	require.Error(t, err)
+
+	empty, err := convertJSONArray(mapping, types.QValueNull(types.QValueKindJSON))
+	require.NoError(t, err)
+	require.Empty(t, empty)
+}
@@ -15,6 +15,7 @@ import (
 	chproto "github.com/ClickHouse/clickhouse-go/v2/lib/proto"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	chinternal "github.com/PeerDB-io/peerdb/flow/internal/clickhouse"
@@ -52,6 +53,29 @@ func (c *ClickHouseConnector) SetupNormalizedTable(
 	config *protos.SetupNormalizedTableBatchInput,
 	destinationTableIdentifier string,
 	sourceTableSchema *protos.TableSchema,
+) (bool, error) {
+	tableAlreadyExists, err := c.setupNormalizedTable(ctx, config, destinationTableIdentifier, sourceTableSchema)
+	if err != nil {
+		return false, err
+	}
+
+	if tableMapping, _ := findTableMapping(config.TableMappings, destinationTableIdentifier); tableMapping != nil {
+		for _, projection := range tableMapping.Projections {
+			if _, err := c.setupNormalizedTable(ctx, config, projection.DestinationTableIdentifier,
+				internal.ExcludeColumns(sourceTableSchema, projection.Exclude),
+			); err != nil {
+				return false, fmt.Errorf("failed to setup projection %s: %w", projection.DestinationTableIdentifier, err)
+			}
+		}
+	}
+	return tableAlreadyExists, nil
+}
+
+func (c *ClickHouseConnector) setupNormalizedTable(
+	ctx context.Context,
+	config *protos.SetupNormalizedTableBatchInput,
+	destinationTableIdentifier string,
+	sourceTableSchema *protos.TableSchema,
 ) (bool, error) {
 	tableAlreadyExists, err := c.checkIfTableExists(ctx, c.Config.Database, destinationTableIdentifier)
 	if err != nil {
@@ -96,13 +120,9 @@ func (c *ClickHouseConnector) generateCreateTableSQLForNormalizedTable(
 	var engine string
 	tmEngine := protos.TableEngine_CH_ENGINE_REPLACING_MERGE_TREE
 
-	var tableMapping *protos.TableMapping
-	for _, tm := range config.TableMappings {
-		if tm.DestinationTableIdentifier == tableIdentifier {
-			tmEngine = tm.Engine
-			tableMapping = tm
-			break
-		}
+	tableMapping, _ := findTableMapping(config.TableMappings, tableIdentifier)
+	if tableMapping != nil {
+		tmEngine = tableMapping.Engine
 	}
 
 	isDeletedColumn := defaultIsDeletedColName
@@ -110,6 +130,8 @@ func (c *ClickHouseConnector) generateCreateTableSQLForNormalizedTable(
 	if config.SoftDeleteColName != "" {
 		isDeletedColumn = config.SoftDeleteColName
 		isDeletedColumnPart = ", " + peerdb_clickhouse.QuoteIdentifier(isDeletedColumn)
+	} else if config.ClickhouseDeleteMode == protos.ClickHouseDeleteMode_CH_DELETE_MODE_IS_DELETED {
+		isDeletedColumnPart = ", " + peerdb_clickhouse.QuoteIdentifier(isDeletedColumn)
 	}
 
 	switch tmEngine {
@@ -164,6 +186,7 @@ func (c *ClickHouseConnector) generateCreateTableSQLForNormalizedTable(
 	}
 
 	colNameMap := make(map[string]string)
+	usesNested := false
 	shardSuffix := "_shard"
 	if config.IsResync {
 		shardSuffix += strconv.FormatInt(time.Now().Unix(), 10)
@@ -191,7 +214,10 @@ func (c *ClickHouseConnector) generateCreateTableSQLForNormalizedTable(
 			colType := types.QValueKind(column.Type)
 			var columnNullableEnabled bool
 			var clickHouseType string
+			var numericStrategy protos.NumericStrategy
+			var jsonArrayMapping *protos.JsonArrayMapping
 			if tableMapping != nil {
+				jsonArrayMapping = findJSONArrayMapping(tableMapping.Columns, colName, colType)
 				for _, col := range tableMapping.Columns {
 					if col.SourceName == colName {
 						if col.DestinationName != "" {
@@ -202,19 +228,27 @@ func (c *ClickHouseConnector) generateCreateTableSQLForNormalizedTable(
 							clickHouseType = col.DestinationType
 						}
 						columnNullableEnabled = col.NullableEnabled
+						numericStrategy = col.NumericStrategy
 						break
 					}
 				}
 			}
 
-			if clickHouseType == "" {
-				var err error
-				clickHouseType, err = qvalue.ToDWHColumnType(
-					ctx, colType, config.Env, protos.DBType_CLICKHOUSE, chVersion, column,
-					tableSchema.NullableEnabled || columnNullableEnabled, flags,
-				)
-				if err != nil {
-					return nil, fmt.Errorf("error while converting column type to ClickHouse type: %w", err)
+			if jsonArrayMapping != nil {
+				// arrays cannot be Nullable, a null JSON value becomes an empty array
+				clickHouseType = jsonArrayColumnType(jsonArrayMapping)
+				usesNested = usesNested || jsonArrayMapping.Nested
+			} else if clickHouseType == "" {
+				clickHouseType = numericStrategyColumnType(numericStrategy, column, tableSchema.NullableEnabled || columnNullableEnabled)
+				if clickHouseType == "" {
+					var err error
+					clickHouseType, err = qvalue.ToDWHColumnType(
+						ctx, colType, config.Env, protos.DBType_CLICKHOUSE, chVersion, column,
+						tableSchema.NullableEnabled || columnNullableEnabled, flags,
+					)
+					if err != nil {
+						return nil, fmt.Errorf("error while converting column type to ClickHouse type: %w", err)
+					}
 				}
 			} else if (tableSchema.NullableEnabled || columnNullableEnabled) && column.Nullable && !colType.IsArray() {
 				clickHouseType = fmt.Sprintf("Nullable(%s)", clickHouseType)
@@ -282,6 +316,10 @@ func (c *ClickHouseConnector) generateCreateTableSQLForNormalizedTable(
 			// non-empty if a resync is triggered on top of another resync that is mid-snapshot.
 			chSettings.Add(chinternal.SettingMaxTableSizeToDrop, "0")
 		}
+		if usesNested {
+			// keep Nested columns as a single array of tuples instead of one array per element
+			chSettings.Add(chinternal.SettingFlattenNested, "0")
+		}
 		stmtBuilder.WriteString(chSettings.String())
 
 		if c.Config.Cluster != "" {
@@ -299,7 +337,12 @@ func (c *ClickHouseConnector) generateCreateTableSQLForNormalizedTable(
 				}
 			}
 			stmtBuilderDistributed.WriteByte(')')
+			if usesNested {
+				stmtBuilderDistributed.WriteString(chinternal.NewCHSettingsString(chVersion, chinternal.SettingFlattenNested, "0"))
+			}
 		}
+	} else if usesNested {
+		stmtBuilder.WriteString(chinternal.NewCHSettingsString(chVersion, chinternal.SettingFlattenNested, "0"))
 	}
 
 	result := make([]string, len(builders))
@@ -452,7 +495,7 @@ func (c *ClickHouseConnector) NormalizeRecords(
 		return model.NormalizeResponse{}, fmt.Errorf("failed to copy avro stages to destination: %w", err)
 	}
 
-	destinationTableNames, err := c.getDistinctTableNamesInBatch(
+	destinationTableNames, deletesInBatch, err := c.getDistinctTableNamesInBatch(
 		ctx,
 		req.FlowJobName,
 		endBatchID,
@@ -464,6 +507,8 @@ func (c *ClickHouseConnector) NormalizeRecords(
 		return model.NormalizeResponse{}, err
 	}
 
+	destinationTableNames = withProjections(req.TableMappings, destinationTableNames)
+
 	enablePrimaryUpdate, err := internal.PeerDBEnableClickHousePrimaryUpdate(ctx, req.Env)
 	if err != nil {
 		return model.NormalizeResponse{}, err
@@ -498,6 +543,7 @@ func (c *ClickHouseConnector) NormalizeRecords(
 	type queryInfo struct {
 		table           string
 		query           string
+		deleteQuery     string
 		lastNormBatchID int64
 	}
 	queriesCh := make(chan queryInfo)
@@ -537,6 +583,13 @@ func (c *ClickHouseConnector) NormalizeRecords(
 					return fmt.Errorf("error while inserting into target clickhouse table %s: %w", q.table, err)
 				}
 
+				// deleted keys are removed after the insert, which also brings their soft deleted rows
+				if q.deleteQuery != "" {
+					if err := c.execWithConnection(errCtx, chConn, q.deleteQuery); err != nil {
+						return fmt.Errorf("error while deleting from target clickhouse table %s: %w", q.table, err)
+					}
+				}
+
 				c.logger.Info("[clickhouse] set last normalized batch id for table",
 					slog.String("table", q.table),
 					slog.Int64("endBatchID", endBatchID),
@@ -590,6 +643,7 @@ func (c *ClickHouseConnector) NormalizeRecords(
 				c.chVersion,
 				c.Config.Cluster != "",
 				req.SoftDeleteColName,
+				req.ClickHouseDeleteMode,
 				req.Version,
 				req.Flags,
 			)
@@ -602,11 +656,17 @@ func (c *ClickHouseConnector) NormalizeRecords(
 					slog.Any("error", err))
 				return fmt.Errorf("error while building insert into select query for table %s: %w", tbl, err)
 			}
+			// lightweight deletes are mutations, only run them when the raw rows of the table have deletes
+			var deleteQuery string
+			if deletesInBatch[queryGenerator.rawDestinationTableName] > 0 {
+				deleteQuery = queryGenerator.BuildDeleteQuery()
+			}
 
 			select {
 			case queriesCh <- queryInfo{
 				table:           tbl,
 				query:           query,
+				deleteQuery:     deleteQuery,
 				lastNormBatchID: lastNormBatchIDForTable,
 			}:
 			case <-errCtx.Done():
@@ -635,43 +695,50 @@ func (c *ClickHouseConnector) NormalizeRecords(
 	}, nil
 }
 
+// getDistinctTableNamesInBatch also returns the number of rows deleting a key per destination table,
+// which are deletes and, with primary key updates, updates that change the key
 func (c *ClickHouseConnector) getDistinctTableNamesInBatch(
 	ctx context.Context,
 	flowJobName string,
 	endBatchID int64,
 	lastNormBatchID int64,
 	tableToSchema map[string]*protos.TableSchema,
-) ([]string, error) {
+) ([]string, map[string]uint64, error) {
 	rawTbl := c.GetRawTableName(flowJobName)
 
 	q := fmt.Sprintf(
-		"SELECT DISTINCT _peerdb_destination_table_name FROM %s WHERE _peerdb_batch_id>%d AND _peerdb_batch_id<=%d",
+		"SELECT _peerdb_destination_table_name,"+
+			"countIf(_peerdb_record_type = 2 OR (_peerdb_record_type = 1 AND _peerdb_match_data != '')) FROM %s"+
+			" WHERE _peerdb_batch_id>%d AND _peerdb_batch_id<=%d GROUP BY _peerdb_destination_table_name",
 		peerdb_clickhouse.QuoteIdentifier(rawTbl), lastNormBatchID, endBatchID)
 
 	rows, err := c.query(ctx, q)
 	if err != nil {
-		return nil, fmt.Errorf("error while querying raw table for distinct table names in batch: %w", err)
+		return nil, nil, fmt.Errorf("error while querying raw table for distinct table names in batch: %w", err)
 	}
 	defer rows.Close()
 	var tableNames []string
+	deletes := make(map[string]uint64)
 	for rows.Next() {
 		var tableName string
-		if err := rows.Scan(&tableName); err != nil {
-			return nil, fmt.Errorf("error while scanning table name: %w", err)
+		var deleteCount uint64
+		if err := rows.Scan(&tableName, &deleteCount); err != nil {
+			return nil, nil, fmt.Errorf("error while scanning table name: %w", err)
 		}
 
 		if _, ok := tableToSchema[tableName]; ok {
 			tableNames = append(tableNames, tableName)
+			deletes[tableName] = deleteCount
 		} else {
 			c.logger.Warn("table not found in table to schema mapping", "table", tableName)
 		}
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to read rows: %w", err)
+		return nil, nil, fmt.Errorf("failed to read rows: %w", err)
 	}
 
-	return tableNames, nil
+	return tableNames, deletes, nil
 }
 
 func (c *ClickHouseConnector) copyAvroStageToDestination(
@@ -681,13 +748,21 @@ func (c *ClickHouseConnector) copyAvroStageToDestination(
 	env map[string]string,
 	version uint32,
 ) error {
-	avroSyncMethod := c.avroSyncMethod(flowJobName, env, version)
 	avroFile, err := GetAvroStage(ctx, flowJobName, syncBatchID)
 	if err != nil {
 		return fmt.Errorf("failed to get avro stage: %w", err)
 	}
 	defer avroFile.Cleanup(ctx)
+	if avroFile.StorageLocation == utils.AvroDirectInsert {
+		// sync already inserted the batch into the raw table
+		return nil
+	}
+	if c.staging == nil {
+		return fmt.Errorf("batch %d was staged before the peer switched to direct insert, it needs a staging bucket to be copied",
+			syncBatchID)
+	}
 
+	avroSyncMethod := c.avroSyncMethod(flowJobName, env, version)
 	if err := avroSyncMethod.CopyStageToDestination(ctx, avroFile); err != nil {
 		return fmt.Errorf("failed to copy stage to destination: %w", err)
 	}
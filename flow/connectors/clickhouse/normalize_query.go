@@ -20,11 +20,15 @@ import (
 type NormalizeQueryGenerator struct {
 	env                             map[string]string
 	flags                           []string
+	keyColumns                      []string
+	keyProjections                  []string
+	keyProjectionsUpdate            []string
 	tableNameSchemaMapping          map[string]*protos.TableSchema
 	chVersion                       *chproto.Version
 	Query                           string
 	TableName                       string
 	rawTableName                    string
+	rawDestinationTableName         string
 	isDeletedColName                string
 	tableMappings                   []*protos.TableMapping
 	lastNormBatchID                 int64
@@ -33,6 +37,7 @@ type NormalizeQueryGenerator struct {
 	sourceSchemaAsDestinationColumn bool
 	cluster                         bool
 	version                         uint32
+	deleteMode                      protos.ClickHouseDeleteMode
 }
 
 // NewTableNormalizeQuery constructs a TableNormalizeQuery with required fields.
@@ -49,6 +54,7 @@ func NewNormalizeQueryGenerator(
 	chVersion *chproto.Version,
 	cluster bool,
 	configuredSoftDeleteColName string,
+	deleteMode protos.ClickHouseDeleteMode,
 	version uint32,
 	flags []string,
 ) *NormalizeQueryGenerator {
@@ -69,6 +75,7 @@ func NewNormalizeQueryGenerator(
 		chVersion:                       chVersion,
 		cluster:                         cluster,
 		isDeletedColName:                isDeletedColumn,
+		deleteMode:                      deleteMode,
 		version:                         version,
 		flags:                           flags,
 	}
@@ -116,14 +123,15 @@ func (t *NormalizeQueryGenerator) BuildQuery(ctx context.Context) (string, error
 	colSelector := strings.Builder{}
 	colSelector.WriteByte('(')
 
-	schema := t.tableNameSchemaMapping[t.TableName]
-
-	var tableMapping *protos.TableMapping
-	for _, tm := range t.tableMappings {
-		if tm.DestinationTableIdentifier == t.TableName {
-			tableMapping = tm
-			break
-		}
+	// projections read the raw rows of the table they project
+	rawDestinationTableName := t.TableName
+	tableMapping, parentMapping := findTableMapping(t.tableMappings, t.TableName)
+	if parentMapping != nil {
+		rawDestinationTableName = parentMapping.DestinationTableIdentifier
+	}
+	schema := t.tableNameSchemaMapping[rawDestinationTableName]
+	if parentMapping != nil {
+		schema = internal.ExcludeColumns(schema, tableMapping.Exclude)
 	}
 
 	var escapedSourceSchemaSelectorFragment string
@@ -136,30 +144,62 @@ func (t *NormalizeQueryGenerator) BuildQuery(ctx context.Context) (string, error
 	projection := strings.Builder{}
 	projectionUpdate := strings.Builder{}
 
+	// offsets of each column in the projections, lightweight deletes reuse the expressions of key columns
+	projectionOffsets := make([]int, 0, len(schema.Columns)+1)
+	projectionUpdateOffsets := make([]int, 0, len(schema.Columns)+1)
+	colNameMap := make(map[string]string)
+
 	for _, column := range schema.Columns {
+		projectionOffsets = append(projectionOffsets, projection.Len())
+		projectionUpdateOffsets = append(projectionUpdateOffsets, projectionUpdate.Len())
 		colName := column.Name
 		dstColName := colName
 		colType := types.QValueKind(column.Type)
 
 		var clickHouseType string
 		var columnNullableEnabled bool
+		var typeOverridden bool
+		var numericStrategy protos.NumericStrategy
 		if tableMapping != nil {
 			for _, col := range tableMapping.Columns {
 				if col.SourceName == colName {
 					if col.DestinationName != "" {
 						dstColName = col.DestinationName
+						colNameMap[colName] = dstColName
 					}
 					if col.DestinationType != "" {
 						// TODO basic validation to avoid injection
 						clickHouseType = col.DestinationType
+						typeOverridden = true
 					}
 					columnNullableEnabled = col.NullableEnabled
+					numericStrategy = col.NumericStrategy
 					break
 				}
 			}
 		}
 
 		fmt.Fprintf(&colSelector, "%s,", peerdb_clickhouse.QuoteIdentifier(dstColName))
+		if tableMapping != nil {
+			if jsonArrayMapping := findJSONArrayMapping(tableMapping.Columns, colName, colType); jsonArrayMapping != nil {
+				fmt.Fprintf(&projection, "%s AS %s,",
+					jsonArrayExtractExpr(fmt.Sprintf("JSONExtractString(_peerdb_data, %s)", peerdb_clickhouse.QuoteLiteral(colName)),
+						jsonArrayMapping),
+					peerdb_clickhouse.QuoteIdentifier(dstColName),
+				)
+				if t.enablePrimaryUpdate {
+					fmt.Fprintf(&projectionUpdate, "%s AS %s,",
+						jsonArrayExtractExpr(fmt.Sprintf("JSONExtractString(_peerdb_match_data, %s)", peerdb_clickhouse.QuoteLiteral(colName)),
+							jsonArrayMapping),
+						peerdb_clickhouse.QuoteIdentifier(dstColName),
+					)
+				}
+				continue
+			}
+		}
+		if clickHouseType == "" {
+			clickHouseType = numericStrategyColumnType(numericStrategy, column, schema.NullableEnabled || columnNullableEnabled)
+		}
 		if clickHouseType == "" {
 			var err error
 			clickHouseType, err = qvalue.ToDWHColumnType(
@@ -170,6 +210,18 @@ func (t *NormalizeQueryGenerator) BuildQuery(ctx context.Context) (string, error
 			}
 		}
 
+		if typeOverridden {
+			if expr := typeOverrideExpr(colType, clickHouseType, "_peerdb_data", colName); expr != "" {
+				fmt.Fprintf(&projection, "%s AS %s,", expr, peerdb_clickhouse.QuoteIdentifier(dstColName))
+				if t.enablePrimaryUpdate {
+					fmt.Fprintf(&projectionUpdate, "%s AS %s,",
+						typeOverrideExpr(colType, clickHouseType, "_peerdb_match_data", colName),
+						peerdb_clickhouse.QuoteIdentifier(dstColName))
+				}
+				continue
+			}
+		}
+
 		switch clickHouseType {
 		case "Time64(6)", "Nullable(Time64(6))":
 			fmt.Fprintf(&projection,
@@ -318,6 +370,34 @@ func (t *NormalizeQueryGenerator) BuildQuery(ctx context.Context) (string, error
 		}
 	}
 
+	projectionOffsets = append(projectionOffsets, projection.Len())
+	projectionUpdateOffsets = append(projectionUpdateOffsets, projectionUpdate.Len())
+
+	if t.deleteMode == protos.ClickHouseDeleteMode_CH_DELETE_MODE_LIGHTWEIGHT &&
+		(tableMapping == nil || tableMapping.Engine != protos.TableEngine_CH_ENGINE_NULL) {
+		t.rawDestinationTableName = rawDestinationTableName
+		t.keyColumns, _ = getOrderedOrderByColumns(tableMapping, colNameMap, schema.PrimaryKeyColumns, func(string) bool { return false })
+		t.keyProjections = nil
+		t.keyProjectionsUpdate = nil
+		for _, keyColumn := range t.keyColumns {
+			for idx, column := range schema.Columns {
+				if peerdb_clickhouse.QuoteIdentifier(getColName(colNameMap, column.Name)) == keyColumn {
+					t.keyProjections = append(t.keyProjections,
+						strings.TrimSuffix(projection.String()[projectionOffsets[idx]:projectionOffsets[idx+1]], ","))
+					t.keyProjectionsUpdate = append(t.keyProjectionsUpdate,
+						strings.TrimSuffix(projectionUpdate.String()[projectionUpdateOffsets[idx]:projectionUpdateOffsets[idx+1]], ","))
+					break
+				}
+			}
+		}
+		if t.sourceSchemaAsDestinationColumn {
+			t.keyColumns = append([]string{peerdb_clickhouse.QuoteIdentifier(sourceSchemaColName)}, t.keyColumns...)
+			t.keyProjections = append([]string{strings.TrimSuffix(escapedSourceSchemaSelectorFragment, ",")}, t.keyProjections...)
+			t.keyProjectionsUpdate = append([]string{strings.TrimSuffix(escapedSourceSchemaSelectorFragment, ",")},
+				t.keyProjectionsUpdate...)
+		}
+	}
+
 	if t.sourceSchemaAsDestinationColumn {
 		projection.WriteString(escapedSourceSchemaSelectorFragment)
 		fmt.Fprintf(&colSelector, "%s,", peerdb_clickhouse.QuoteIdentifier(sourceSchemaColName))
@@ -334,7 +414,7 @@ func (t *NormalizeQueryGenerator) BuildQuery(ctx context.Context) (string, error
 	selectQuery.WriteString(projection.String())
 	fmt.Fprintf(&selectQuery,
 		" FROM %s WHERE _peerdb_batch_id > %d AND _peerdb_batch_id <= %d AND  _peerdb_destination_table_name = %s",
-		peerdb_clickhouse.QuoteIdentifier(t.rawTableName), t.lastNormBatchID, t.endBatchID, peerdb_clickhouse.QuoteLiteral(t.TableName))
+		peerdb_clickhouse.QuoteIdentifier(t.rawTableName), t.lastNormBatchID, t.endBatchID, peerdb_clickhouse.QuoteLiteral(rawDestinationTableName))
 
 	if t.enablePrimaryUpdate {
 		if t.sourceSchemaAsDestinationColumn {
@@ -353,7 +433,7 @@ func (t *NormalizeQueryGenerator) BuildQuery(ctx context.Context) (string, error
 			" FROM %s WHERE _peerdb_match_data != '' AND _peerdb_batch_id > %d AND _peerdb_batch_id <= %d"+
 				" AND  _peerdb_destination_table_name = %s AND _peerdb_record_type = 1",
 			peerdb_clickhouse.QuoteIdentifier(t.rawTableName),
-			t.lastNormBatchID, t.endBatchID, peerdb_clickhouse.QuoteLiteral(t.TableName))
+			t.lastNormBatchID, t.endBatchID, peerdb_clickhouse.QuoteLiteral(rawDestinationTableName))
 	}
 
 	chSettings := clickhouse.NewCHSettings(t.chVersion)
@@ -374,6 +454,34 @@ func (t *NormalizeQueryGenerator) BuildQuery(ctx context.Context) (string, error
 	return t.Query, nil
 }
 
+// BuildDeleteQuery returns the lightweight delete of keys whose latest change in the batches is a delete,
+// it is empty unless the mirror uses lightweight deletes. BuildQuery must be called first
+func (t *NormalizeQueryGenerator) BuildDeleteQuery() string {
+	if len(t.keyColumns) == 0 || len(t.keyColumns) != len(t.keyProjections) {
+		return ""
+	}
+
+	keys := strings.Join(t.keyColumns, ",")
+	var changes strings.Builder
+	fmt.Fprintf(&changes,
+		"SELECT %s,_peerdb_timestamp AS _peerdb_change_version,_peerdb_record_type AS _peerdb_change_type FROM %s"+
+			" WHERE _peerdb_batch_id > %d AND _peerdb_batch_id <= %d AND _peerdb_destination_table_name = %s",
+		strings.Join(t.keyProjections, ","), peerdb_clickhouse.QuoteIdentifier(t.rawTableName),
+		t.lastNormBatchID, t.endBatchID, peerdb_clickhouse.QuoteLiteral(t.rawDestinationTableName))
+	if t.enablePrimaryUpdate {
+		// updates that change the key delete the previous key, ordered before the update like in BuildQuery
+		fmt.Fprintf(&changes,
+			" UNION ALL SELECT %s,_peerdb_timestamp - 1,2 FROM %s WHERE _peerdb_match_data != '' AND _peerdb_batch_id > %d"+
+				" AND _peerdb_batch_id <= %d AND _peerdb_destination_table_name = %s AND _peerdb_record_type = 1",
+			strings.Join(t.keyProjectionsUpdate, ","), peerdb_clickhouse.QuoteIdentifier(t.rawTableName),
+			t.lastNormBatchID, t.endBatchID, peerdb_clickhouse.QuoteLiteral(t.rawDestinationTableName))
+	}
+
+	return fmt.Sprintf("DELETE FROM %s WHERE (%s) IN (SELECT %s FROM (%s) GROUP BY %s"+
+		" HAVING argMax(_peerdb_change_type, _peerdb_change_version) = 2)",
+		peerdb_clickhouse.QuoteIdentifier(t.TableName), keys, keys, changes.String(), keys)
+}
+
 func extendedTimeToDateTime(jsonExtractExpr string, time64Supported bool) string {
 	if time64Supported {
 		return fmt.Sprintf("toDateTime64(toTime64OrNull(%s, 6), 6)", jsonExtractExpr)
@@ -258,6 +258,7 @@ func TestBuildQuery_Basic(t *testing.T) {
 		nil,
 		false,
 		"",
+		protos.ClickHouseDeleteMode_CH_DELETE_MODE_SOFT,
 		shared.InternalVersion_Latest,
 		nil,
 	)
@@ -313,6 +314,7 @@ func TestBuildQuery_WithPrimaryUpdate(t *testing.T) {
 		nil,
 		false,
 		"",
+		protos.ClickHouseDeleteMode_CH_DELETE_MODE_SOFT,
 		shared.InternalVersion_Latest,
 		nil,
 	)
@@ -365,6 +367,7 @@ func TestBuildQuery_WithSourceSchemaAsDestinationColumn(t *testing.T) {
 		nil,
 		true,
 		"",
+		protos.ClickHouseDeleteMode_CH_DELETE_MODE_SOFT,
 		shared.InternalVersion_Latest,
 		nil,
 	)
@@ -517,3 +520,86 @@ func TestGenerateCreateTableSQLForNormalizedTable(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildDeleteQuery(t *testing.T) {
+	tableSchema := &protos.TableSchema{
+		Columns: []*protos.FieldDescription{
+			{Name: "id", Type: string(types.QValueKindInt64)},
+			{Name: "name", Type: string(types.QValueKindString)},
+		},
+		PrimaryKeyColumns: []string{"id"},
+	}
+	tableMappings := []*protos.TableMapping{
+		{
+			SourceTableIdentifier:      "public.my_table",
+			DestinationTableIdentifier: "my_table",
+			Columns:                    []*protos.ColumnSetting{{SourceName: "id", DestinationName: "my_id"}},
+		},
+	}
+
+	for _, deleteMode := range []protos.ClickHouseDeleteMode{
+		protos.ClickHouseDeleteMode_CH_DELETE_MODE_SOFT,
+		protos.ClickHouseDeleteMode_CH_DELETE_MODE_LIGHTWEIGHT,
+	} {
+		t.Run(deleteMode.String(), func(t *testing.T) {
+			g := NewNormalizeQueryGenerator(
+				"my_table",
+				map[string]*protos.TableSchema{"my_table": tableSchema},
+				tableMappings,
+				10,
+				5,
+				true,
+				false,
+				map[string]string{},
+				"raw_my_table",
+				nil,
+				false,
+				"",
+				deleteMode,
+				shared.InternalVersion_Latest,
+				nil,
+			)
+			_, err := g.BuildQuery(t.Context())
+			require.NoError(t, err)
+
+			deleteQuery := g.BuildDeleteQuery()
+			if deleteMode == protos.ClickHouseDeleteMode_CH_DELETE_MODE_SOFT {
+				require.Empty(t, deleteQuery)
+				return
+			}
+			// normalize looks up the deletes of the batch by the raw destination table name
+			require.Equal(t, "my_table", g.rawDestinationTableName)
+			require.Equal(t, "DELETE FROM `my_table` WHERE (`my_id`) IN (SELECT `my_id` FROM ("+
+				"SELECT JSONExtract(_peerdb_data, 'id', 'Int64') AS `my_id`,"+
+				"_peerdb_timestamp AS _peerdb_change_version,_peerdb_record_type AS _peerdb_change_type FROM `raw_my_table`"+
+				" WHERE _peerdb_batch_id > 5 AND _peerdb_batch_id <= 10 AND _peerdb_destination_table_name = 'my_table'"+
+				" UNION ALL SELECT JSONExtract(_peerdb_match_data, 'id', 'Int64') AS `my_id`,_peerdb_timestamp - 1,2"+
+				" FROM `raw_my_table` WHERE _peerdb_match_data != '' AND _peerdb_batch_id > 5 AND _peerdb_batch_id <= 10"+
+				" AND _peerdb_destination_table_name = 'my_table' AND _peerdb_record_type = 1)"+
+				" GROUP BY `my_id` HAVING argMax(_peerdb_change_type, _peerdb_change_version) = 2)", deleteQuery)
+		})
+	}
+}
+
+func TestGenerateCreateTableSQLForIsDeletedMode(t *testing.T) {
+	c := &ClickHouseConnector{
+		Config:    &protos.ClickhouseConfig{Database: "db"},
+		chVersion: &chproto.Version{Major: 25, Minor: 8, Patch: 0},
+	}
+	config := &protos.SetupNormalizedTableBatchInput{
+		Env: map[string]string{"PEERDB_SOURCE_SCHEMA_AS_DESTINATION_COLUMN": "false"},
+		TableMappings: []*protos.TableMapping{
+			{SourceTableIdentifier: "tbl", DestinationTableIdentifier: "tbl"},
+		},
+		ClickhouseDeleteMode: protos.ClickHouseDeleteMode_CH_DELETE_MODE_IS_DELETED,
+	}
+	tableSchema := &protos.TableSchema{
+		Columns:           []*protos.FieldDescription{{Name: "id", Type: string(types.QValueKindInt64)}},
+		PrimaryKeyColumns: []string{"id"},
+	}
+
+	result, err := c.generateCreateTableSQLForNormalizedTable(t.Context(), config, "tbl", tableSchema, c.chVersion, nil)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	require.Contains(t, result[0], "ENGINE = ReplacingMergeTree(`_peerdb_version`, `_peerdb_is_deleted`)")
+}
@@ -0,0 +1,178 @@
+package connclickhouse
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	chinternal "github.com/PeerDB-io/peerdb/flow/internal/clickhouse"
+	peerdb_clickhouse "github.com/PeerDB-io/peerdb/flow/pkg/clickhouse"
+)
+
+// findTableMapping returns the mapping of a destination table, which is either a table mapping
+// or one of its projections, in which case the table mapping it projects is returned as well
+func findTableMapping(
+	tableMappings []*protos.TableMapping, tableIdentifier string,
+) (*protos.TableMapping, *protos.TableMapping) {
+	for _, tm := range tableMappings {
+		if tm.DestinationTableIdentifier == tableIdentifier {
+			return tm, nil
+		}
+	}
+	for _, tm := range tableMappings {
+		for _, projection := range tm.Projections {
+			if projection.DestinationTableIdentifier == tableIdentifier {
+				return projection, tm
+			}
+		}
+	}
+	return nil, nil
+}
+
+// withProjections appends the projections of the given destination tables,
+// they are normalized from the same raw rows as the table they project
+func withProjections(tableMappings []*protos.TableMapping, tableNames []string) []string {
+	targets := tableNames
+	for _, tableName := range tableNames {
+		if tm, parent := findTableMapping(tableMappings, tableName); tm != nil && parent == nil {
+			for _, projection := range tm.Projections {
+				targets = append(targets, projection.DestinationTableIdentifier)
+			}
+		}
+	}
+	return targets
+}
+
+// validateProjections checks that projections have their own destination table,
+// and that the table they project keeps rows for the initial load to be copied from
+func validateProjections(tableMappings []*protos.TableMapping) error {
+	dstTableNames := make(map[string]struct{}, len(tableMappings))
+	for _, tm := range tableMappings {
+		dstTableNames[tm.DestinationTableIdentifier] = struct{}{}
+	}
+	for _, tm := range tableMappings {
+		if len(tm.Projections) > 0 && tm.Engine == protos.TableEngine_CH_ENGINE_NULL {
+			return fmt.Errorf("table %s has projections but uses the Null engine", tm.DestinationTableIdentifier)
+		}
+		for _, projection := range tm.Projections {
+			if projection.DestinationTableIdentifier == "" {
+				return fmt.Errorf("projection of table %s has an empty destination table identifier", tm.DestinationTableIdentifier)
+			}
+			if _, ok := dstTableNames[projection.DestinationTableIdentifier]; ok {
+				return fmt.Errorf("projection %s of table %s is already a destination table",
+					projection.DestinationTableIdentifier, tm.DestinationTableIdentifier)
+			}
+			dstTableNames[projection.DestinationTableIdentifier] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// fillProjections copies the destination table of an initial load into its projections,
+// projections are truncated first so that retries do not duplicate rows.
+// A deduplicating destination is read with FINAL and without deleted rows, as projections may not deduplicate
+func (c *ClickHouseConnector) fillProjections(ctx context.Context, config *protos.QRepConfig) error {
+	rows, err := c.query(ctx, fmt.Sprintf(
+		"SELECT name FROM system.columns WHERE database=currentDatabase() AND table=%s ORDER BY position",
+		peerdb_clickhouse.QuoteLiteral(config.DestinationTableIdentifier)))
+	if err != nil {
+		return fmt.Errorf("failed to get columns of %s: %w", config.DestinationTableIdentifier, err)
+	}
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan column of %s: %w", config.DestinationTableIdentifier, err)
+		}
+		columns = append(columns, column)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", config.DestinationTableIdentifier, err)
+	}
+
+	deduplicates, err := c.DeduplicatesQRepRecords(ctx, config)
+	if err != nil {
+		return err
+	}
+	fromClause := projectionSourceClause(config.DestinationTableIdentifier, columns, deduplicates,
+		cmp.Or(config.SoftDeleteColName, defaultIsDeletedColName))
+
+	// destination columns are renamed source columns, PeerDB columns keep their name everywhere
+	sourceNames := make(map[string]string, len(config.Columns))
+	for _, col := range config.Columns {
+		if col.DestinationName != "" {
+			sourceNames[col.DestinationName] = col.SourceName
+		}
+	}
+
+	for _, projection := range config.Projections {
+		projectionNames := make(map[string]string, len(projection.Columns))
+		for _, col := range projection.Columns {
+			if col.DestinationName != "" {
+				projectionNames[col.SourceName] = col.DestinationName
+			}
+		}
+
+		insertColumns := make([]string, 0, len(columns))
+		selectColumns := make([]string, 0, len(columns))
+		for _, column := range columns {
+			sourceName := getColName(sourceNames, column)
+			if slices.Contains(projection.Exclude, sourceName) {
+				continue
+			}
+			insertColumns = append(insertColumns, peerdb_clickhouse.QuoteIdentifier(getColName(projectionNames, sourceName)))
+			selectColumns = append(selectColumns, peerdb_clickhouse.QuoteIdentifier(column))
+		}
+
+		if projection.Engine != protos.TableEngine_CH_ENGINE_NULL {
+			truncateTable := projection.DestinationTableIdentifier
+			if c.Config.Cluster != "" {
+				if truncateTable, err = c.getDistributedShardTable(ctx, projection.DestinationTableIdentifier); err != nil {
+					return err
+				}
+			}
+			if err := c.execWithLogging(ctx, fmt.Sprintf("TRUNCATE TABLE IF EXISTS %s%s",
+				peerdb_clickhouse.QuoteIdentifier(truncateTable), c.onCluster())); err != nil {
+				return fmt.Errorf("failed to truncate projection %s: %w", projection.DestinationTableIdentifier, err)
+			}
+		}
+
+		chSettings := chinternal.NewCHSettings(c.chVersion)
+		chSettings.Add(chinternal.SettingThrowOnMaxPartitionsPerInsertBlock, "0")
+		if c.Config.Cluster != "" {
+			chSettings.Add(chinternal.SettingParallelDistributedInsertSelect, "0")
+		}
+		c.logger.Info("[clickhouse] filling projection from initial load",
+			slog.String("table", config.DestinationTableIdentifier),
+			slog.String("projection", projection.DestinationTableIdentifier))
+		if err := c.execWithLogging(ctx, fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s%s",
+			peerdb_clickhouse.QuoteIdentifier(projection.DestinationTableIdentifier),
+			strings.Join(insertColumns, ","),
+			strings.Join(selectColumns, ","),
+			fromClause,
+			chSettings.String(),
+		)); err != nil {
+			return fmt.Errorf("failed to fill projection %s: %w", projection.DestinationTableIdentifier, err)
+		}
+	}
+	return nil
+}
+
+// projectionSourceClause selects the current rows of a destination table, on a deduplicating engine
+// older versions are only dropped by FINAL and deletes are rows with the is_deleted column set
+func projectionSourceClause(table string, columns []string, deduplicates bool, isDeletedColumn string) string {
+	clause := peerdb_clickhouse.QuoteIdentifier(table)
+	if deduplicates {
+		clause += " FINAL"
+		if slices.Contains(columns, isDeletedColumn) {
+			clause += fmt.Sprintf(" WHERE %s = 0", peerdb_clickhouse.QuoteIdentifier(isDeletedColumn))
+		}
+	}
+	return clause
+}
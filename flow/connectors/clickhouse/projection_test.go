@@ -0,0 +1,134 @@
+package connclickhouse
+
+import (
+	"testing"
+
+	chproto "github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func testProjectionTableMappings() []*protos.TableMapping {
+	return []*protos.TableMapping{
+		{
+			SourceTableIdentifier:      "public.events",
+			DestinationTableIdentifier: "events",
+			Projections: []*protos.TableMapping{
+				{
+					DestinationTableIdentifier: "events_by_time",
+					Exclude:                    []string{"payload"},
+					Engine:                     protos.TableEngine_CH_ENGINE_MERGE_TREE,
+					Columns: []*protos.ColumnSetting{
+						{SourceName: "created_at", Ordering: 1},
+						{SourceName: "id", DestinationName: "event_id", Ordering: 2},
+					},
+				},
+			},
+		},
+		{
+			SourceTableIdentifier:      "public.users",
+			DestinationTableIdentifier: "users",
+		},
+	}
+}
+
+func testProjectionTableSchema() *protos.TableSchema {
+	return &protos.TableSchema{
+		Columns: []*protos.FieldDescription{
+			{Name: "id", Type: string(types.QValueKindInt64)},
+			{Name: "created_at", Type: string(types.QValueKindTimestamp)},
+			{Name: "payload", Type: string(types.QValueKindString)},
+		},
+		PrimaryKeyColumns: []string{"id"},
+	}
+}
+
+func TestFindTableMapping(t *testing.T) {
+	t.Parallel()
+	tableMappings := testProjectionTableMappings()
+
+	tm, parent := findTableMapping(tableMappings, "events")
+	require.Equal(t, tableMappings[0], tm)
+	require.Nil(t, parent)
+
+	tm, parent = findTableMapping(tableMappings, "events_by_time")
+	require.Equal(t, tableMappings[0].Projections[0], tm)
+	require.Equal(t, tableMappings[0], parent)
+
+	tm, parent = findTableMapping(tableMappings, "missing")
+	require.Nil(t, tm)
+	require.Nil(t, parent)
+
+	require.Equal(t, []string{"users", "events", "events_by_time"},
+		withProjections(tableMappings, []string{"users", "events"}))
+}
+
+func TestValidateProjections(t *testing.T) {
+	t.Parallel()
+	require.NoError(t, validateProjections(testProjectionTableMappings()))
+
+	tableMappings := testProjectionTableMappings()
+	tableMappings[0].Projections[0].DestinationTableIdentifier = "users"
+	require.ErrorContains(t, validateProjections(tableMappings), "already a destination table")
+
+	tableMappings = testProjectionTableMappings()
+	tableMappings[0].Engine = protos.TableEngine_CH_ENGINE_NULL
+	require.ErrorContains(t, validateProjections(tableMappings), "Null engine")
+}
+
+func TestBuildQuery_Projection(t *testing.T) {
+	g := NewNormalizeQueryGenerator(
+		"events_by_time",
+		map[string]*protos.TableSchema{"events": testProjectionTableSchema()},
+		testProjectionTableMappings(),
+		10,
+		5,
+		false,
+		false,
+		map[string]string{},
+		"raw_events",
+		nil,
+		false,
+		"",
+		protos.ClickHouseDeleteMode_CH_DELETE_MODE_SOFT,
+		shared.InternalVersion_Latest,
+		nil,
+	)
+
+	query, err := g.BuildQuery(t.Context())
+	require.NoError(t, err)
+	require.Contains(t, query, "INSERT INTO `events_by_time` (`event_id`,`created_at`,")
+	require.Contains(t, query, "JSONExtract(_peerdb_data, 'id', 'Int64') AS `event_id`")
+	require.NotContains(t, query, "payload")
+	require.Contains(t, query, "_peerdb_destination_table_name = 'events'")
+}
+
+func TestProjectionSourceClause(t *testing.T) {
+	columns := []string{"id", "_peerdb_version", "_peerdb_is_deleted"}
+	require.Equal(t, "`t`", projectionSourceClause("t", columns, false, "_peerdb_is_deleted"))
+	require.Equal(t, "`t` FINAL WHERE `_peerdb_is_deleted` = 0", projectionSourceClause("t", columns, true, "_peerdb_is_deleted"))
+	require.Equal(t, "`t` FINAL", projectionSourceClause("t", columns[:2], true, "_peerdb_is_deleted"))
+}
+
+func TestGenerateCreateTableSQLForProjection(t *testing.T) {
+	c := &ClickHouseConnector{
+		Config:    &protos.ClickhouseConfig{Database: "db"},
+		chVersion: &chproto.Version{Major: 25, Minor: 8, Patch: 0},
+	}
+	config := &protos.SetupNormalizedTableBatchInput{
+		Env:           map[string]string{"PEERDB_SOURCE_SCHEMA_AS_DESTINATION_COLUMN": "false"},
+		TableMappings: testProjectionTableMappings(),
+	}
+
+	result, err := c.generateCreateTableSQLForNormalizedTable(t.Context(), config, "events_by_time",
+		testProjectionTableSchema(), c.chVersion, nil)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	require.Contains(t, result[0], "CREATE TABLE IF NOT EXISTS `events_by_time`")
+	require.Contains(t, result[0], "`event_id` Int64")
+	require.Contains(t, result[0], "ENGINE = MergeTree()")
+	require.Contains(t, result[0], "PRIMARY KEY (`created_at`,`event_id`) ORDER BY (`created_at`,`event_id`)")
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
+	peerdb_clickhouse "github.com/PeerDB-io/peerdb/flow/pkg/clickhouse"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 )
 
@@ -30,14 +31,53 @@ func (c *ClickHouseConnector) SyncQRepRecords(
 
 	c.logger.Info("Called QRep sync function", flowLog)
 
+	if c.directInsertEnabled() {
+		return c.syncQRepRecordsDirect(ctx, config, partition, stream)
+	}
+
 	avroSync := NewClickHouseAvroSyncMethod(config, c)
 
 	return avroSync.SyncQRepRecords(ctx, config, partition, stream)
 }
 
+// DeduplicatesQRepRecords returns true if the destination table collapses rows synced again,
+// for a Distributed table the engine of its shard table decides
+func (c *ClickHouseConnector) DeduplicatesQRepRecords(ctx context.Context, config *protos.QRepConfig) (bool, error) {
+	tableName := config.DestinationTableIdentifier
+	engine, err := c.getTableEngine(ctx, tableName)
+	if err != nil {
+		return false, err
+	}
+	if engine == "Distributed" {
+		shardTable, err := c.getDistributedShardTable(ctx, tableName)
+		if err != nil {
+			return false, err
+		}
+		if engine, err = c.getTableEngine(ctx, shardTable); err != nil {
+			return false, err
+		}
+	}
+	return peerdb_clickhouse.EngineDeduplicates(engine), nil
+}
+
+func (c *ClickHouseConnector) getTableEngine(ctx context.Context, tableName string) (string, error) {
+	var engine string
+	if err := c.queryRow(ctx, fmt.Sprintf(
+		"SELECT engine FROM system.tables WHERE database = %s AND name = %s",
+		peerdb_clickhouse.QuoteLiteral(c.Config.Database),
+		peerdb_clickhouse.QuoteLiteral(tableName),
+	)).Scan(&engine); err != nil {
+		return "", fmt.Errorf("failed to get engine of table %s: %w", tableName, err)
+	}
+	return engine, nil
+}
+
 // We need to implement QRepConsolidateConnector interface so CleanQRepFlow is called
 // Otherwise we could have skipped this
-func (c *ClickHouseConnector) ConsolidateQRepPartitions(_ context.Context, config *protos.QRepConfig) error {
+func (c *ClickHouseConnector) ConsolidateQRepPartitions(ctx context.Context, config *protos.QRepConfig) error {
+	if len(config.Projections) > 0 {
+		return c.fillProjections(ctx, config)
+	}
 	c.logger.Info("ConsolidateQRepPartitions is a stub for ClickHouse")
 	return nil
 }
@@ -45,6 +85,10 @@ func (c *ClickHouseConnector) ConsolidateQRepPartitions(_ context.Context, confi
 // CleanupQRepFlow function for clickhouse connector
 func (c *ClickHouseConnector) CleanupQRepFlow(ctx context.Context, config *protos.QRepConfig) error {
 	flowName := config.FlowJobName
+	if c.staging == nil {
+		// direct insert leaves nothing staged
+		return nil
+	}
 
 	skipCleanup, err := internal.PeerDBClickHouseSkipStagingCleanup(ctx, config.Env)
 	if err != nil {
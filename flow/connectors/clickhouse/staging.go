@@ -39,7 +39,11 @@ func createStagingStore(
 		return newS3StagingStore(ctx, config, bucketName, chVersion)
 	case "gcs":
 		return newGCSStagingStore(ctx, bucketName)
+	case "azure":
+		return newAzureStagingStore(ctx, bucketName)
+	case "local":
+		return newLocalStagingStore(ctx, bucketName)
 	default:
-		return nil, fmt.Errorf("unsupported staging provider %q (expected s3 or gcs)", provider)
+		return nil, fmt.Errorf("unsupported staging provider %q (expected s3, gcs, azure or local)", provider)
 	}
 }
@@ -0,0 +1,206 @@
+package connclickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/service"
+	"github.com/google/uuid"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	peerdb_clickhouse "github.com/PeerDB-io/peerdb/flow/pkg/clickhouse"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+const azureSASExpiry = 1 * time.Hour
+
+// azureStagingStore implements StagingStore for Azure Blob Storage,
+// ClickHouse reads staged blobs through azureBlobStorage() with a read-only SAS.
+type azureStagingStore struct {
+	client    *azblob.Client
+	container string
+	prefix    string
+	fullPath  string
+	// sharedKey is false when authenticating with Azure default credentials,
+	// SAS tokens are then signed with a user delegation key
+	sharedKey bool
+}
+
+//nolint:iface // factory function intentionally returns interface
+func newAzureStagingStore(ctx context.Context, containerName string) (StagingStore, error) {
+	if containerName == "" {
+		return nil, errors.New("PEERDB_CLICKHOUSE_STAGING_BUCKET_NAME must be set to the container name when staging provider is azure")
+	}
+
+	connectionString, err := internal.PeerDBClickHouseAzureStorageConnectionString(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Azure storage connection string: %w", err)
+	}
+
+	var client *azblob.Client
+	if connectionString != "" {
+		client, err = azblob.NewClientFromConnectionString(connectionString, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure Blob client from connection string: %w", err)
+		}
+	} else {
+		accountURL := internal.PeerDBClickHouseAzureStorageAccountURL()
+		if accountURL == "" {
+			return nil, errors.New("PEERDB_CLICKHOUSE_AZURE_STORAGE_CONNECTION_STRING or " +
+				"PEERDB_CLICKHOUSE_AZURE_STORAGE_ACCOUNT_URL must be set when staging provider is azure")
+		}
+		creds, err := azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get Azure default credentials: %w", err)
+		}
+		client, err = azblob.NewClient(accountURL, creds, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure Blob client: %w", err)
+		}
+	}
+
+	deploymentUID := internal.PeerDBDeploymentUID()
+	flowName, _ := ctx.Value(shared.FlowNameKey).(string)
+	prefix := fmt.Sprintf("%s/%s", url.PathEscape(deploymentUID), url.PathEscape(flowName))
+
+	return &azureStagingStore{
+		client:    client,
+		container: containerName,
+		prefix:    prefix,
+		fullPath:  fmt.Sprintf("azure://%s/%s", containerName, prefix),
+		sharedKey: connectionString != "",
+	}, nil
+}
+
+func (a *azureStagingStore) Upload(ctx context.Context, env map[string]string, key string, body io.Reader) error {
+	logger := internal.LoggerFromCtx(ctx)
+
+	if _, err := a.client.UploadStream(ctx, a.container, key, body, nil); err != nil {
+		logger.Error("failed to upload file", slog.Any("error", err),
+			slog.String("azure_path", "azure://"+a.container+"/"+key))
+		return fmt.Errorf("failed to upload file to Azure Blob Storage: %w", err)
+	}
+
+	logger.Info("finished Azure Blob upload", slog.String("key", key))
+	return nil
+}
+
+// blobSAS returns a read-only SAS query string scoped to a single blob
+func (a *azureStagingStore) blobSAS(ctx context.Context, key string) (string, error) {
+	blobClient := a.client.ServiceClient().NewContainerClient(a.container).NewBlobClient(key)
+	expiry := time.Now().Add(azureSASExpiry)
+
+	if a.sharedKey {
+		sasURL, err := blobClient.GetSASURL(sas.BlobPermissions{Read: true}, expiry, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate SAS for %s: %w", key, err)
+		}
+		parts, err := blob.ParseURL(sasURL)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse SAS URL: %w", err)
+		}
+		return parts.SAS.Encode(), nil
+	}
+
+	start := time.Now().Add(-5 * time.Minute).UTC()
+	udc, err := a.client.ServiceClient().GetUserDelegationCredential(ctx, service.KeyInfo{
+		Start:  to.Ptr(start.Format(sas.TimeFormat)),
+		Expiry: to.Ptr(expiry.UTC().Format(sas.TimeFormat)),
+	}, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get Azure user delegation key: %w", err)
+	}
+	qps, err := sas.BlobSignatureValues{
+		Protocol:      sas.ProtocolHTTPS,
+		StartTime:     start,
+		ExpiryTime:    expiry.UTC(),
+		Permissions:   (&sas.BlobPermissions{Read: true}).String(),
+		ContainerName: a.container,
+		BlobName:      key,
+	}.SignWithUserDelegation(udc)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign SAS for %s: %w", key, err)
+	}
+	return qps.Encode(), nil
+}
+
+func (a *azureStagingStore) TableFunctionExpr(ctx context.Context, key string, format string) (string, error) {
+	sasQuery, err := a.blobSAS(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	var expr strings.Builder
+	expr.WriteString("azureBlobStorage(")
+	expr.WriteString(peerdb_clickhouse.QuoteLiteral(strings.TrimSuffix(a.client.URL(), "/") + "/?" + sasQuery))
+	expr.WriteByte(',')
+	expr.WriteString(peerdb_clickhouse.QuoteLiteral(a.container))
+	expr.WriteByte(',')
+	expr.WriteString(peerdb_clickhouse.QuoteLiteral(key))
+	expr.WriteByte(',')
+	expr.WriteString(peerdb_clickhouse.QuoteLiteral(format))
+	expr.WriteByte(')')
+	return expr.String(), nil
+}
+
+func (a *azureStagingStore) DeletePrefix(ctx context.Context, prefix string) error {
+	logger := internal.LoggerFromCtx(ctx)
+	logger.Info("Deleting blobs from Azure",
+		slog.String("container", a.container), slog.String("prefix", prefix))
+
+	pager := a.client.NewListBlobsFlatPager(a.container, &azblob.ListBlobsFlatOptions{Prefix: &prefix})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list blobs from container: %w", err)
+		}
+		for _, item := range page.Segment.BlobItems {
+			if item.Name == nil {
+				continue
+			}
+			if _, err := a.client.DeleteBlob(ctx, a.container, *item.Name, nil); err != nil {
+				return fmt.Errorf("failed to delete blob %s: %w", *item.Name, err)
+			}
+		}
+	}
+
+	logger.Info("Deleted blobs from Azure",
+		slog.String("container", a.container), slog.String("prefix", prefix))
+	return nil
+}
+
+func (a *azureStagingStore) Validate(ctx context.Context) error {
+	key := strings.TrimPrefix(a.prefix+"/"+stagingCheckObjectPrefix+uuid.NewString(), "/")
+
+	if _, err := a.client.UploadBuffer(ctx, a.container, key, []byte(time.Now().Format(time.RFC3339)), nil); err != nil {
+		return fmt.Errorf("failed to write test blob to Azure: %w", err)
+	}
+	if _, err := a.client.DeleteBlob(ctx, a.container, key, nil); err != nil {
+		return fmt.Errorf("failed to delete test blob from Azure: %w", err)
+	}
+
+	return nil
+}
+
+func (a *azureStagingStore) ClickHouseAccessMethod() string {
+	return "AZURE"
+}
+
+func (a *azureStagingStore) BucketPath() string {
+	return a.fullPath
+}
+
+func (a *azureStagingStore) KeyPrefix() string {
+	return a.prefix
+}
@@ -0,0 +1,181 @@
+package connclickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	peerdb_clickhouse "github.com/PeerDB-io/peerdb/flow/pkg/clickhouse"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// localStagingStore implements StagingStore for a directory shared between PeerDB and a single-node ClickHouse,
+// ClickHouse reads staged files through file(), so the directory has to be within its user_files_path.
+type localStagingStore struct {
+	root string
+	// clickhouseRoot is root as seen by ClickHouse, which may mount the directory elsewhere
+	clickhouseRoot string
+	prefix         string
+	fullPath       string
+}
+
+//nolint:iface // factory function intentionally returns interface
+func newLocalStagingStore(ctx context.Context, root string) (StagingStore, error) {
+	if root == "" {
+		return nil, errors.New("PEERDB_CLICKHOUSE_STAGING_BUCKET_NAME must be set to the staging directory when staging provider is local")
+	}
+	if !filepath.IsAbs(root) {
+		return nil, fmt.Errorf("local staging directory %q must be an absolute path", root)
+	}
+
+	clickhouseRoot := internal.PeerDBClickHouseLocalStagingClickHousePath()
+	if clickhouseRoot == "" {
+		clickhouseRoot = root
+	}
+
+	deploymentUID := internal.PeerDBDeploymentUID()
+	flowName, _ := ctx.Value(shared.FlowNameKey).(string)
+	prefix := fmt.Sprintf("%s/%s", url.PathEscape(deploymentUID), url.PathEscape(flowName))
+
+	return &localStagingStore{
+		root:           filepath.Clean(root),
+		clickhouseRoot: strings.TrimSuffix(clickhouseRoot, "/"),
+		prefix:         prefix,
+		fullPath:       "file://" + path.Join(filepath.ToSlash(root), prefix),
+	}, nil
+}
+
+func (l *localStagingStore) localPath(key string) (string, error) {
+	p := filepath.Join(l.root, filepath.FromSlash(key))
+	if p != l.root && !strings.HasPrefix(p, l.root+string(filepath.Separator)) {
+		return "", fmt.Errorf("staging key %q escapes staging directory", key)
+	}
+	return p, nil
+}
+
+func (l *localStagingStore) Upload(ctx context.Context, env map[string]string, key string, body io.Reader) error {
+	logger := internal.LoggerFromCtx(ctx)
+
+	dst, err := l.localPath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	// write to a temporary file first so ClickHouse never reads a partially written file
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create staging file: %w", err)
+	}
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write staging file %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to close staging file %s: %w", key, err)
+	}
+	// CreateTemp uses 0600, ClickHouse usually runs as another user
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to set permissions on staging file %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to move staging file %s into place: %w", key, err)
+	}
+
+	logger.Info("finished local staging write", slog.String("key", key))
+	return nil
+}
+
+func (l *localStagingStore) TableFunctionExpr(ctx context.Context, key string, format string) (string, error) {
+	var expr strings.Builder
+	expr.WriteString("file(")
+	expr.WriteString(peerdb_clickhouse.QuoteLiteral(l.clickhouseRoot + "/" + key))
+	expr.WriteByte(',')
+	expr.WriteString(peerdb_clickhouse.QuoteLiteral(format))
+	expr.WriteByte(')')
+	return expr.String(), nil
+}
+
+func (l *localStagingStore) DeletePrefix(ctx context.Context, prefix string) error {
+	logger := internal.LoggerFromCtx(ctx)
+	logger.Info("Deleting local staging files",
+		slog.String("root", l.root), slog.String("prefix", prefix))
+
+	if err := filepath.WalkDir(l.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(l.root, p)
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(filepath.ToSlash(rel), prefix) {
+			if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("failed to delete staging file %s: %w", rel, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to delete local staging files: %w", err)
+	}
+
+	logger.Info("Deleted local staging files",
+		slog.String("root", l.root), slog.String("prefix", prefix))
+	return nil
+}
+
+func (l *localStagingStore) Validate(ctx context.Context) error {
+	key := strings.TrimPrefix(l.prefix+"/"+stagingCheckObjectPrefix+uuid.NewString(), "/")
+	p, err := l.localPath(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	if err := os.WriteFile(p, []byte(time.Now().Format(time.RFC3339)), 0o644); err != nil {
+		return fmt.Errorf("failed to write test file to staging directory: %w", err)
+	}
+	if err := os.Remove(p); err != nil {
+		return fmt.Errorf("failed to delete test file from staging directory: %w", err)
+	}
+
+	return nil
+}
+
+func (l *localStagingStore) ClickHouseAccessMethod() string {
+	return "FILE"
+}
+
+func (l *localStagingStore) BucketPath() string {
+	return l.fullPath
+}
+
+func (l *localStagingStore) KeyPrefix() string {
+	return l.prefix
+}
@@ -14,7 +14,7 @@ const stagingCheckObjectPrefix = "_peerdb_check_"
 
 // StagingStore abstracts cloud storage used for staging Avro files.
 // Files are written by PeerDB and read by ClickHouse via table functions
-// (s3(), url(), etc.).
+// (s3(), url(), azureBlobStorage(), file()).
 type StagingStore interface {
 	// Upload streams data from body to the given key in the staging bucket.
 	Upload(ctx context.Context, env map[string]string, key string, body io.Reader) error
@@ -22,6 +22,8 @@ type StagingStore interface {
 	// TableFunctionExpr returns a ClickHouse SQL expression that reads the staged file.
 	// For S3:  s3('url', 'access_key', 'secret_key', 'Avro')
 	// For GCS: url('signed_url', 'Avro')
+	// For Azure: azureBlobStorage('account_url?sas', 'container', 'blob', 'Avro')
+	// For local: file('path', 'Avro')
 	TableFunctionExpr(ctx context.Context, key string, format string) (string, error)
 
 	// DeletePrefix removes all objects whose key starts with prefix.
@@ -31,7 +33,7 @@ type StagingStore interface {
 	Validate(ctx context.Context) error
 
 	// ClickHouseAccessMethod returns the access type ClickHouse uses to read staged files.
-	// This is S3 for direct S3 reads and URL for signed-URL reads.
+	// This is S3 for direct S3 reads, URL for signed-URL reads, AZURE for Azure Blob reads and FILE for local files.
 	ClickHouseAccessMethod() string
 
 	// BucketPath returns the full staging path (e.g. "s3://bucket/prefix") for logging.
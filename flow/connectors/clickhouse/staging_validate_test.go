@@ -3,11 +3,14 @@ package connclickhouse
 import (
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync/atomic"
 	"testing"
 
 	"cloud.google.com/go/storage"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/api/option"
@@ -127,6 +130,8 @@ func TestStagingStoreClickHouseAccessMethod(t *testing.T) {
 	}{
 		{name: "S3 uses direct S3 reads", store: &s3StagingStore{}, method: "S3"},
 		{name: "GCS uses signed URL reads", store: &gcsStagingStore{}, method: "URL"},
+		{name: "Azure uses azureBlobStorage reads", store: &azureStagingStore{}, method: "AZURE"},
+		{name: "local uses file reads", store: &localStagingStore{}, method: "FILE"},
 	}
 
 	for _, tt := range tests {
@@ -226,3 +231,113 @@ func TestGCSStagingStoreValidate_DeleteFailure(t *testing.T) {
 	err := newFakeGCSStore(t, server, "b", "p").Validate(t.Context())
 	require.ErrorContains(t, err, "failed to delete test object from GCS")
 }
+
+// Azure tests:
+
+// azuriteAccountKey is the well-known key of Azurite's devstoreaccount1
+const azuriteAccountKey = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+
+func newFakeAzureStore(t *testing.T, server *httptest.Server, container, prefix string) *azureStagingStore {
+	t.Helper()
+	client, err := azblob.NewClientFromConnectionString(
+		"DefaultEndpointsProtocol=http;AccountName=devstoreaccount1;AccountKey="+azuriteAccountKey+
+			";BlobEndpoint="+server.URL+"/devstoreaccount1;", nil)
+	require.NoError(t, err)
+	return &azureStagingStore{client: client, container: container, prefix: prefix, sharedKey: true}
+}
+
+func TestAzureStagingStoreValidate_HappyPath(t *testing.T) {
+	var puts, deletes atomic.Int32
+	var putPath, deletePath atomic.Value
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			puts.Add(1)
+			putPath.Store(r.URL.Path)
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodDelete:
+			deletes.Add(1)
+			deletePath.Store(r.URL.Path)
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			t.Errorf("unexpected method %s on %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	err := newFakeAzureStore(t, server, "my-container", "stage/1").Validate(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, int32(1), puts.Load(), "expected exactly one PUT")
+	require.Equal(t, int32(1), deletes.Load(), "expected exactly one DELETE")
+
+	put, _ := putPath.Load().(string)
+	del, _ := deletePath.Load().(string)
+	require.Equal(t, put, del, "PUT and DELETE must target the same blob")
+	require.True(t,
+		strings.HasPrefix(put, "/devstoreaccount1/my-container/stage/1/"+stagingCheckObjectPrefix),
+		"unexpected blob path %q", put,
+	)
+}
+
+func TestAzureStagingStoreValidate_PutFailure(t *testing.T) {
+	var deletes atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		deletes.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	err := newFakeAzureStore(t, server, "c", "p").Validate(t.Context())
+	require.ErrorContains(t, err, "failed to write test blob to Azure")
+	require.Equal(t, int32(0), deletes.Load(), "DELETE must not run when PUT fails")
+}
+
+func TestAzureStagingStoreTableFunctionExpr(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}))
+	defer server.Close()
+
+	expr, err := newFakeAzureStore(t, server, "c", "p").TableFunctionExpr(t.Context(), "p/file.avro", "Avro")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(expr, "azureBlobStorage('"+server.URL+"/devstoreaccount1/?"), "got %q", expr)
+	require.Contains(t, expr, "sp=r")
+	require.Contains(t, expr, "sig=")
+	require.True(t, strings.HasSuffix(expr, ",'c','p/file.avro','Avro')"), "got %q", expr)
+}
+
+// Local tests:
+
+func TestLocalStagingStore(t *testing.T) {
+	root := t.TempDir()
+	store := &localStagingStore{root: root, clickhouseRoot: "/var/lib/clickhouse/user_files/peerdb", prefix: "dep/flow"}
+
+	require.NoError(t, store.Validate(t.Context()))
+	entries, err := os.ReadDir(filepath.Join(root, "dep", "flow"))
+	require.NoError(t, err)
+	require.Empty(t, entries, "Validate must remove its test file")
+
+	require.NoError(t, store.Upload(t.Context(), nil, "dep/flow/a/1.avro", strings.NewReader("one")))
+	require.NoError(t, store.Upload(t.Context(), nil, "dep/flow/b/2.avro", strings.NewReader("two")))
+	content, err := os.ReadFile(filepath.Join(root, "dep", "flow", "a", "1.avro"))
+	require.NoError(t, err)
+	require.Equal(t, "one", string(content))
+
+	expr, err := store.TableFunctionExpr(t.Context(), "dep/flow/a/1.avro", "Avro")
+	require.NoError(t, err)
+	require.Equal(t, "file('/var/lib/clickhouse/user_files/peerdb/dep/flow/a/1.avro','Avro')", expr)
+
+	require.NoError(t, store.DeletePrefix(t.Context(), "dep/flow/a"))
+	_, err = os.Stat(filepath.Join(root, "dep", "flow", "a", "1.avro"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	_, err = os.Stat(filepath.Join(root, "dep", "flow", "b", "2.avro"))
+	require.NoError(t, err)
+
+	require.Error(t, store.Upload(t.Context(), nil, "../escape.avro", strings.NewReader("x")))
+}
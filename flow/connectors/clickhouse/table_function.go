@@ -47,6 +47,10 @@ func jsonFieldExpressionConverter(
 		return sourceFieldIdentifier, nil
 	}
 
+	if mapping := findJSONArrayMapping(config.config.Columns, field.Name, field.Type); mapping != nil {
+		return jsonArrayExtractExpr(sourceFieldIdentifier, mapping), nil
+	}
+
 	if !qvalue.ShouldUseNativeJSONType(ctx, config.config.Env, config.connector.chVersion) {
 		return sourceFieldIdentifier, nil
 	}
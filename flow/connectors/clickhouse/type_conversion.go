@@ -1,7 +1,16 @@
 package connclickhouse
 
 import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model/qvalue"
+	peerdb_clickhouse "github.com/PeerDB-io/peerdb/flow/pkg/clickhouse"
+	"github.com/PeerDB-io/peerdb/flow/pkg/common"
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
@@ -28,6 +37,33 @@ var SupportedDestinationTypes = map[string][]types.TypeConversion{
 			types.NumericToStringSchemaConversion,
 			types.NumericToStringValueConversion,
 		),
+		types.NewTypeConversion(types.ToStringSchemaConversion, types.ToStringValueConversion[types.QValueInt8]),
+		types.NewTypeConversion(types.ToStringSchemaConversion, types.ToStringValueConversion[types.QValueInt16]),
+		types.NewTypeConversion(types.ToStringSchemaConversion, types.ToStringValueConversion[types.QValueInt32]),
+		types.NewTypeConversion(types.ToStringSchemaConversion, types.ToStringValueConversion[types.QValueInt64]),
+		types.NewTypeConversion(types.ToStringSchemaConversion, types.ToStringValueConversion[types.QValueInt256]),
+		types.NewTypeConversion(types.ToStringSchemaConversion, types.ToStringValueConversion[types.QValueUInt8]),
+		types.NewTypeConversion(types.ToStringSchemaConversion, types.ToStringValueConversion[types.QValueUInt16]),
+		types.NewTypeConversion(types.ToStringSchemaConversion, types.ToStringValueConversion[types.QValueUInt32]),
+		types.NewTypeConversion(types.ToStringSchemaConversion, types.ToStringValueConversion[types.QValueUInt64]),
+		types.NewTypeConversion(types.ToStringSchemaConversion, types.ToStringValueConversion[types.QValueUInt256]),
+		types.NewTypeConversion(types.ToStringSchemaConversion, types.ToStringValueConversion[types.QValueUint16Enum]),
+		types.NewTypeConversion(types.ToStringSchemaConversion, types.ToStringValueConversion[types.QValueUint64Set]),
+		types.NewTypeConversion(types.ToStringSchemaConversion, types.ToStringValueConversion[types.QValueFloat32]),
+		types.NewTypeConversion(types.ToStringSchemaConversion, types.ToStringValueConversion[types.QValueFloat64]),
+		types.NewTypeConversion(types.ToStringSchemaConversion, types.ToStringValueConversion[types.QValueBoolean]),
+		types.NewTypeConversion(types.ToStringSchemaConversion, types.ToStringValueConversion[types.QValueUUID]),
+		types.NewTypeConversion(types.ToStringSchemaConversion, types.ToStringValueConversion[types.QValueDate]),
+		types.NewTypeConversion(types.ToStringSchemaConversion, types.ToStringValueConversion[types.QValueTimestamp]),
+		types.NewTypeConversion(types.ToStringSchemaConversion, types.ToStringValueConversion[types.QValueTimestampTZ]),
+		types.NewTypeConversion(types.ToStringSchemaConversion, types.ToStringValueConversion[types.QValueTime]),
+		types.NewTypeConversion(types.ToStringSchemaConversion, types.ToStringValueConversion[types.QValueTimeTZ]),
+	},
+	"Float64": {
+		types.NewTypeConversion(
+			types.NumericToFloat64SchemaConversion,
+			types.NumericToFloat64ValueConversion,
+		),
 	},
 	"Int256": {
 		types.NewTypeConversion(
@@ -88,7 +124,12 @@ func findTypeConversions(schema types.QRecordSchema, columns []*protos.ColumnSet
 		if !exist {
 			continue
 		}
-		conversions, exist := SupportedDestinationTypes[col.DestinationType]
+		destinationType := col.DestinationType
+		if destinationType == "" && col.NumericStrategy == protos.NumericStrategy_NUMERIC_STRATEGY_STRING {
+			// the string strategy converts values like a String override
+			destinationType = "String"
+		}
+		conversions, exist := SupportedDestinationTypes[baseColumnType(destinationType)]
 		if !exist {
 			continue
 		}
@@ -110,3 +151,278 @@ func applyTypeConversions(schema types.QRecordSchema, typeConversions map[string
 	}
 	return schema
 }
+
+// applyNumericStrategies widens NUMERIC fields of columns with the widest decimal strategy to Decimal256
+func applyNumericStrategies(schema types.QRecordSchema, columns []*protos.ColumnSetting) types.QRecordSchema {
+	for _, col := range columns {
+		if col.NumericStrategy != protos.NumericStrategy_NUMERIC_STRATEGY_WIDEST_DECIMAL || col.DestinationType != "" {
+			continue
+		}
+		for i, field := range schema.Fields {
+			if field.Name == col.SourceName && (field.Type == types.QValueKindNumeric || field.Type == types.QValueKindArrayNumeric) {
+				destType := qvalue.GetNumericDestinationTypeForStrategy(
+					field.Precision, field.Scale, protos.DBType_CLICKHOUSE, false, col.NumericStrategy)
+				schema.Fields[i].Precision = destType.Precision
+				schema.Fields[i].Scale = destType.Scale
+			}
+		}
+	}
+	return schema
+}
+
+// numericStrategyColumnType is the type of a NUMERIC column with the string or widest decimal strategy,
+// empty for columns that keep their default type
+func numericStrategyColumnType(strategy protos.NumericStrategy, column *protos.FieldDescription, nullableEnabled bool) string {
+	kind := types.QValueKind(column.Type)
+	if kind != types.QValueKindNumeric && kind != types.QValueKindArrayNumeric ||
+		strategy != protos.NumericStrategy_NUMERIC_STRATEGY_STRING && strategy != protos.NumericStrategy_NUMERIC_STRATEGY_WIDEST_DECIMAL {
+		return ""
+	}
+	precision, scale := common.ParseNumericTypmod(column.TypeModifier)
+	destType := qvalue.GetNumericDestinationTypeForStrategy(precision, scale, protos.DBType_CLICKHOUSE, false, strategy)
+	colType := "String"
+	if !destType.IsString {
+		colType = fmt.Sprintf("Decimal(%d, %d)", destType.Precision, destType.Scale)
+	}
+	if kind == types.QValueKindArrayNumeric {
+		return "Array(" + colType + ")"
+	} else if nullableEnabled && column.Nullable {
+		return "Nullable(" + colType + ")"
+	}
+	return colType
+}
+
+// validateNumericStrategy checks the NUMERIC strategy of a column applies to values of its source kind
+func validateNumericStrategy(col *protos.ColumnSetting, kind types.QValueKind) error {
+	switch col.NumericStrategy {
+	case protos.NumericStrategy_NUMERIC_STRATEGY_TRUNCATE:
+		return nil
+	case protos.NumericStrategy_NUMERIC_STRATEGY_STRING, protos.NumericStrategy_NUMERIC_STRATEGY_WIDEST_DECIMAL:
+		if col.DestinationType != "" {
+			return fmt.Errorf("%s conflicts with destination type %s", col.NumericStrategy, col.DestinationType)
+		}
+		if col.NumericStrategy == protos.NumericStrategy_NUMERIC_STRATEGY_STRING && kind == types.QValueKindArrayNumeric {
+			return fmt.Errorf("%s is not supported for NUMERIC arrays", col.NumericStrategy)
+		}
+	}
+	if kind != types.QValueKindNumeric && kind != types.QValueKindArrayNumeric {
+		return fmt.Errorf("%s needs a NUMERIC column, got %s", col.NumericStrategy, kind)
+	}
+	return nil
+}
+
+// splitColumnType splits a ClickHouse type into its family and arguments, ignoring Nullable and LowCardinality
+func splitColumnType(columnType string) (string, string) {
+	base := baseColumnType(strings.TrimSpace(columnType))
+	if family, args, ok := strings.Cut(base, "("); ok && strings.HasSuffix(args, ")") {
+		return family, strings.TrimSuffix(args, ")")
+	}
+	return base, ""
+}
+
+// isRawJSONNumber reports whether raw records encode values of the kind as JSON numbers or booleans
+func isRawJSONNumber(kind types.QValueKind) bool {
+	_, _, isInteger := kind.IntegerWidth()
+	return isInteger || kind == types.QValueKindFloat32 || kind == types.QValueKindFloat64 || kind == types.QValueKindBoolean
+}
+
+func isTimestampKind(kind types.QValueKind) bool {
+	return kind == types.QValueKindTimestamp || kind == types.QValueKindTimestampTZ
+}
+
+// TypeOverrideCompatibility classifies storing values of a source kind in a destination type override,
+// the reason explains why values are rejected or can lose information. Types outside the matrix are invalid.
+func TypeOverrideCompatibility(kind types.QValueKind, destinationType string) (types.TypeCompatibility, string) {
+	family, args := splitColumnType(destinationType)
+	invalid := fmt.Sprintf("%s values cannot be stored as %s", kind, family)
+	switch family {
+	case "String":
+		if kind == types.QValueKindStruct || kind == types.QValueKindMap || strings.HasPrefix(string(kind), "array_") {
+			return types.TypeCompatibilityInvalid, invalid
+		}
+		return types.TypeCompatibilityLossless, ""
+	case "FixedString":
+		if isRawJSONNumber(kind) || isTimestampKind(kind) || kind == types.QValueKindStruct || kind == types.QValueKindMap ||
+			strings.HasPrefix(string(kind), "array_") {
+			return types.TypeCompatibilityInvalid, invalid
+		}
+		return types.TypeCompatibilityLossy, "values longer than " + args + " bytes fail to insert"
+	case "Bool":
+		if kind == types.QValueKindBoolean {
+			return types.TypeCompatibilityLossless, ""
+		}
+	case "Int8", "Int16", "Int32", "Int64", "Int128", "Int256", "UInt8", "UInt16", "UInt32", "UInt64", "UInt128", "UInt256":
+		unsigned := strings.HasPrefix(family, "U")
+		bits, _ := strconv.Atoi(strings.TrimPrefix(strings.TrimPrefix(family, "U"), "Int"))
+		if kind == types.QValueKindBoolean {
+			return types.TypeCompatibilityLossless, ""
+		}
+		if kind == types.QValueKindNumeric && bits == 256 {
+			return types.TypeCompatibilityLossy, "fractional digits are dropped and out of range values overflow"
+		}
+		if kindBits, signed, ok := kind.IntegerWidth(); ok {
+			if signed == !unsigned && kindBits <= bits || !signed && !unsigned && kindBits < bits {
+				return types.TypeCompatibilityLossless, ""
+			}
+			return types.TypeCompatibilityLossy, "out of range values overflow"
+		}
+	case "Float32", "Float64":
+		mantissaBits := 24
+		if family == "Float64" {
+			mantissaBits = 53
+		}
+		switch kind {
+		case types.QValueKindFloat32:
+			return types.TypeCompatibilityLossless, ""
+		case types.QValueKindFloat64:
+			if family == "Float64" {
+				return types.TypeCompatibilityLossless, ""
+			}
+			return types.TypeCompatibilityLossy, "values are rounded to single precision"
+		case types.QValueKindNumeric:
+			if family == "Float64" {
+				return types.TypeCompatibilityLossy, "values are rounded to double precision"
+			}
+		default:
+			if kindBits, _, ok := kind.IntegerWidth(); ok {
+				if kindBits < mantissaBits {
+					return types.TypeCompatibilityLossless, ""
+				}
+				return types.TypeCompatibilityLossy, "large values are rounded"
+			}
+		}
+	case "Decimal", "Decimal32", "Decimal64", "Decimal128", "Decimal256":
+		if kind == types.QValueKindNumeric {
+			return types.TypeCompatibilityLossy, "values beyond the precision and scale of " + family + "(" + args + ") are truncated"
+		}
+	case "DateTime64":
+		precision, _ := strconv.Atoi(strings.TrimSpace(strings.Split(args, ",")[0]))
+		if kind == types.QValueKindDate || isTimestampKind(kind) && precision >= 6 {
+			return types.TypeCompatibilityLossless, ""
+		} else if isTimestampKind(kind) {
+			return types.TypeCompatibilityLossy, "fractional seconds beyond the precision are truncated"
+		}
+	case "DateTime":
+		if kind == types.QValueKindDate {
+			return types.TypeCompatibilityLossless, ""
+		} else if isTimestampKind(kind) {
+			return types.TypeCompatibilityLossy, "fractional seconds are truncated and values outside 1970-2106 are clamped"
+		}
+	case "Date", "Date32":
+		if kind == types.QValueKindDate && family == "Date32" {
+			return types.TypeCompatibilityLossless, ""
+		} else if kind == types.QValueKindDate {
+			return types.TypeCompatibilityLossy, "values outside 1970-2149 are clamped"
+		} else if isTimestampKind(kind) {
+			return types.TypeCompatibilityLossy, "the time of day is dropped"
+		}
+	case "Enum8", "Enum16":
+		if kind == types.QValueKindString || kind == types.QValueKindEnum {
+			return types.TypeCompatibilityLossy, "values outside the enum fail to insert"
+		}
+	case "Time64":
+		if kind == types.QValueKindTime || kind == types.QValueKindTimeTZ {
+			return types.TypeCompatibilityLossless, ""
+		}
+	case "UUID":
+		switch kind {
+		case types.QValueKindUUID:
+			return types.TypeCompatibilityLossless, ""
+		case types.QValueKindString, types.QValueKindQChar:
+			return types.TypeCompatibilityLossy, "values that are not UUIDs fail to insert"
+		}
+	case "JSON", "Object":
+		switch kind {
+		case types.QValueKindJSON, types.QValueKindJSONB:
+			return types.TypeCompatibilityLossless, ""
+		case types.QValueKindString:
+			return types.TypeCompatibilityLossy, "values that are not JSON objects fail to insert"
+		}
+	case "Array":
+		elementKind, ok := strings.CutPrefix(string(kind), "array_")
+		if !ok {
+			return types.TypeCompatibilityInvalid, invalid
+		}
+		// array elements are extracted as is, element conversions only apply to scalar columns
+		elementFamily, _ := splitColumnType(args)
+		if elementFamily == "String" && isRawJSONNumber(types.QValueKind(elementKind)) ||
+			strings.HasPrefix(elementFamily, "Float") && types.QValueKind(elementKind) == types.QValueKindNumeric {
+			return types.TypeCompatibilityInvalid, invalid
+		}
+		return TypeOverrideCompatibility(types.QValueKind(elementKind), args)
+	}
+	return types.TypeCompatibilityInvalid, invalid
+}
+
+// typeOverrideExpr extracts a column of raw records into its destination type override,
+// returns an empty string when extracting the override type as is already works
+func typeOverrideExpr(kind types.QValueKind, destinationType string, jsonColumn string, colName string) string {
+	family, _ := splitColumnType(destinationType)
+	switch family {
+	case "String", "FixedString":
+		if isRawJSONNumber(kind) {
+			// raw text of JSON numbers matches how initial load formats them
+			return fmt.Sprintf("nullIf(JSONExtractRaw(%s, %s), 'null')", jsonColumn, peerdb_clickhouse.QuoteLiteral(colName))
+		}
+	case "Float32", "Float64":
+		if kind == types.QValueKindNumeric {
+			return fmt.Sprintf("to%sOrNull(JSONExtractString(%s, %s))", family, jsonColumn, peerdb_clickhouse.QuoteLiteral(colName))
+		}
+	case "DateTime64", "DateTime", "Date", "Date32":
+		if (kind == types.QValueKindDate || isTimestampKind(kind)) &&
+			baseColumnType(destinationType) != "DateTime64(6)" && baseColumnType(destinationType) != "Date32" {
+			castType := destinationType
+			if !strings.HasPrefix(castType, "Nullable(") {
+				castType = "Nullable(" + castType + ")"
+			}
+			return fmt.Sprintf("CAST(%s, %s)",
+				clampTimestamps(fmt.Sprintf("parseDateTime64BestEffortOrNull(JSONExtractString(%s, %s),6,'UTC')",
+					jsonColumn, peerdb_clickhouse.QuoteLiteral(colName))),
+				peerdb_clickhouse.QuoteLiteral(castType))
+		}
+	}
+	return ""
+}
+
+// ValidateColumnTypeOverrides rejects destination type overrides that values of the source column cannot be stored as,
+// and NUMERIC strategies of columns they don't apply to, lossy overrides are allowed and returned as warnings
+func (c *ClickHouseConnector) ValidateColumnTypeOverrides(
+	_ context.Context,
+	tableMappings []*protos.TableMapping,
+	tableNameSchemaMapping map[string]*protos.TableSchema,
+) ([]string, error) {
+	var warnings []string
+	for _, tableMapping := range tableMappings {
+		tableSchema, ok := tableNameSchemaMapping[tableMapping.SourceTableIdentifier]
+		if !ok {
+			continue
+		}
+		for _, target := range append([]*protos.TableMapping{tableMapping}, tableMapping.Projections...) {
+			for _, col := range target.Columns {
+				idx := slices.IndexFunc(tableSchema.Columns, func(field *protos.FieldDescription) bool {
+					return field.Name == col.SourceName
+				})
+				if idx == -1 {
+					continue
+				}
+				kind := types.QValueKind(tableSchema.Columns[idx].Type)
+				if err := validateNumericStrategy(col, kind); err != nil {
+					return nil, fmt.Errorf("invalid numeric strategy for column %s of table %s: %w",
+						col.SourceName, target.DestinationTableIdentifier, err)
+				}
+				if col.DestinationType == "" {
+					continue
+				}
+				switch compatibility, reason := TypeOverrideCompatibility(kind, col.DestinationType); compatibility {
+				case types.TypeCompatibilityInvalid:
+					return nil, fmt.Errorf("invalid destination type %s for column %s of table %s: %s",
+						col.DestinationType, col.SourceName, target.DestinationTableIdentifier, reason)
+				case types.TypeCompatibilityLossy:
+					warnings = append(warnings, fmt.Sprintf("lossy destination type %s for column %s of table %s: %s",
+						col.DestinationType, col.SourceName, target.DestinationTableIdentifier, reason))
+				}
+			}
+		}
+	}
+	return warnings, nil
+}
@@ -0,0 +1,165 @@
+package connclickhouse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestTypeOverrideCompatibility(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		kind            types.QValueKind
+		destinationType string
+		expected        types.TypeCompatibility
+	}{
+		{types.QValueKindNumeric, "String", types.TypeCompatibilityLossless},
+		{types.QValueKindInt64, "Nullable(String)", types.TypeCompatibilityLossless},
+		{types.QValueKindJSONB, "LowCardinality(String)", types.TypeCompatibilityLossless},
+		{types.QValueKindArrayInt32, "String", types.TypeCompatibilityInvalid},
+		{types.QValueKindTimestampTZ, "DateTime64(6, 'Asia/Tokyo')", types.TypeCompatibilityLossless},
+		{types.QValueKindTimestampTZ, "DateTime64(3, 'UTC')", types.TypeCompatibilityLossy},
+		{types.QValueKindTimestamp, "Date", types.TypeCompatibilityLossy},
+		{types.QValueKindString, "DateTime64(6)", types.TypeCompatibilityInvalid},
+		{types.QValueKindInt32, "Int64", types.TypeCompatibilityLossless},
+		{types.QValueKindUInt32, "Int64", types.TypeCompatibilityLossless},
+		{types.QValueKindUInt64, "Int64", types.TypeCompatibilityLossy},
+		{types.QValueKindInt64, "Int16", types.TypeCompatibilityLossy},
+		{types.QValueKindInt64, "UInt64", types.TypeCompatibilityLossy},
+		{types.QValueKindNumeric, "Int256", types.TypeCompatibilityLossy},
+		{types.QValueKindNumeric, "Int64", types.TypeCompatibilityInvalid},
+		{types.QValueKindNumeric, "Float64", types.TypeCompatibilityLossy},
+		{types.QValueKindNumeric, "Float32", types.TypeCompatibilityInvalid},
+		{types.QValueKindNumeric, "Decimal(18, 2)", types.TypeCompatibilityLossy},
+		{types.QValueKindInt32, "Float64", types.TypeCompatibilityLossless},
+		{types.QValueKindInt64, "Float64", types.TypeCompatibilityLossy},
+		{types.QValueKindBoolean, "UUID", types.TypeCompatibilityInvalid},
+		{types.QValueKindArrayTimestamp, "Array(DateTime64(6))", types.TypeCompatibilityLossless},
+		{types.QValueKindArrayInt32, "Array(String)", types.TypeCompatibilityInvalid},
+		{types.QValueKindArrayString, "Array(LowCardinality(String))", types.TypeCompatibilityLossless},
+		{types.QValueKindString, "Enum8('a' = 1)", types.TypeCompatibilityLossy},
+		{types.QValueKindInt32, "Enum8('a' = 1)", types.TypeCompatibilityInvalid},
+		{types.QValueKindString, "SimpleAggregateFunction(any, String)", types.TypeCompatibilityInvalid},
+		{types.QValueKindMap, "String", types.TypeCompatibilityInvalid},
+	} {
+		compatibility, _ := TypeOverrideCompatibility(tc.kind, tc.destinationType)
+		require.Equal(t, tc.expected, compatibility, "%s -> %s", tc.kind, tc.destinationType)
+	}
+}
+
+func TestFindTypeConversions(t *testing.T) {
+	t.Parallel()
+	schema := types.NewQRecordSchema([]types.QField{
+		{Name: "amount", Type: types.QValueKindNumeric},
+		{Name: "count", Type: types.QValueKindInt64},
+		{Name: "created_at", Type: types.QValueKindTimestampTZ},
+		{Name: "ratio", Type: types.QValueKindNumeric},
+	})
+	conversions := findTypeConversions(schema, []*protos.ColumnSetting{
+		{SourceName: "amount", DestinationType: "Nullable(String)"},
+		{SourceName: "count", DestinationType: "String"},
+		{SourceName: "created_at", DestinationType: "LowCardinality(String)"},
+		{SourceName: "ratio", DestinationType: "Float64"},
+	})
+	require.Len(t, conversions, 4)
+	require.Equal(t, types.QValueString{Val: "1.5"},
+		conversions["amount"].ValueConversion(types.QValueNumeric{Val: decimal.RequireFromString("1.50")}))
+	require.Equal(t, types.QValueString{Val: "42"}, conversions["count"].ValueConversion(types.QValueInt64{Val: 42}))
+	require.Equal(t, types.QValueString{Val: "2024-01-02 03:04:05.5+0000"},
+		conversions["created_at"].ValueConversion(types.QValueTimestampTZ{Val: time.Date(2024, 1, 2, 3, 4, 5, 5e8, time.UTC)}))
+	require.Equal(t, types.QValueFloat64{Val: 0.25},
+		conversions["ratio"].ValueConversion(types.QValueNumeric{Val: decimal.RequireFromString("0.25")}))
+	require.Equal(t, types.QValueNull(types.QValueKindString), conversions["count"].ValueConversion(types.QValueNull(types.QValueKindInt64)))
+}
+
+func TestBuildQuery_TypeOverrides(t *testing.T) {
+	tableMappings := []*protos.TableMapping{{
+		SourceTableIdentifier:      "public.events",
+		DestinationTableIdentifier: "events",
+		Columns: []*protos.ColumnSetting{
+			{SourceName: "id", DestinationType: "String"},
+			{SourceName: "created_at", DestinationType: "DateTime64(3, 'Asia/Tokyo')"},
+			{SourceName: "amount", DestinationType: "Float64"},
+		},
+	}}
+	tableSchema := &protos.TableSchema{
+		Columns: []*protos.FieldDescription{
+			{Name: "id", Type: string(types.QValueKindInt64)},
+			{Name: "created_at", Type: string(types.QValueKindTimestampTZ)},
+			{Name: "amount", Type: string(types.QValueKindNumeric)},
+		},
+		PrimaryKeyColumns: []string{"id"},
+	}
+	g := NewNormalizeQueryGenerator(
+		"events",
+		map[string]*protos.TableSchema{"events": tableSchema},
+		tableMappings,
+		10,
+		5,
+		true,
+		false,
+		map[string]string{},
+		"raw_events",
+		nil,
+		false,
+		"",
+		protos.ClickHouseDeleteMode_CH_DELETE_MODE_SOFT,
+		shared.InternalVersion_Latest,
+		nil,
+	)
+
+	query, err := g.BuildQuery(t.Context())
+	require.NoError(t, err)
+	require.Contains(t, query, "nullIf(JSONExtractRaw(_peerdb_data, 'id'), 'null') AS `id`")
+	require.Contains(t, query, "nullIf(JSONExtractRaw(_peerdb_match_data, 'id'), 'null') AS `id`")
+	require.Contains(t, query, "'Nullable(DateTime64(3, \\'Asia/Tokyo\\'))') AS `created_at`")
+	require.Contains(t, query, "toFloat64OrNull(JSONExtractString(_peerdb_data, 'amount')) AS `amount`")
+}
+
+func TestNumericStrategies(t *testing.T) {
+	t.Parallel()
+	columns := []*protos.ColumnSetting{
+		{SourceName: "amount", NumericStrategy: protos.NumericStrategy_NUMERIC_STRATEGY_STRING},
+		{SourceName: "total", NumericStrategy: protos.NumericStrategy_NUMERIC_STRATEGY_WIDEST_DECIMAL},
+		{SourceName: "rate", NumericStrategy: protos.NumericStrategy_NUMERIC_STRATEGY_ROUND},
+	}
+	schema := types.NewQRecordSchema([]types.QField{
+		{Name: "amount", Type: types.QValueKindNumeric, Precision: 10, Scale: 2},
+		{Name: "total", Type: types.QValueKindNumeric, Precision: 10, Scale: 2, Nullable: true},
+		{Name: "rate", Type: types.QValueKindNumeric, Precision: 10, Scale: 2},
+	})
+
+	conversions := findTypeConversions(schema, columns)
+	require.Len(t, conversions, 1)
+	require.Contains(t, conversions, "amount")
+	schema = applyNumericStrategies(applyTypeConversions(schema, conversions), columns)
+	require.Equal(t, types.QValueKindString, schema.Fields[0].Type)
+	require.Equal(t, int16(76), schema.Fields[1].Precision)
+	require.Equal(t, int16(2), schema.Fields[1].Scale)
+	require.Equal(t, int16(10), schema.Fields[2].Precision)
+
+	typmod := datatypes.MakeNumericTypmod(10, 2)
+	numeric := &protos.FieldDescription{Name: "total", Type: string(types.QValueKindNumeric), TypeModifier: typmod, Nullable: true}
+	require.Equal(t, "Nullable(Decimal(76, 2))",
+		numericStrategyColumnType(protos.NumericStrategy_NUMERIC_STRATEGY_WIDEST_DECIMAL, numeric, true))
+	require.Equal(t, "String", numericStrategyColumnType(protos.NumericStrategy_NUMERIC_STRATEGY_STRING, numeric, false))
+	require.Empty(t, numericStrategyColumnType(protos.NumericStrategy_NUMERIC_STRATEGY_ROUND, numeric, false))
+	array := &protos.FieldDescription{Name: "totals", Type: string(types.QValueKindArrayNumeric), TypeModifier: typmod}
+	require.Equal(t, "Array(Decimal(76, 2))",
+		numericStrategyColumnType(protos.NumericStrategy_NUMERIC_STRATEGY_WIDEST_DECIMAL, array, true))
+
+	require.NoError(t, validateNumericStrategy(columns[0], types.QValueKindNumeric))
+	require.NoError(t, validateNumericStrategy(columns[1], types.QValueKindArrayNumeric))
+	require.ErrorContains(t, validateNumericStrategy(columns[0], types.QValueKindArrayNumeric), "not supported for NUMERIC arrays")
+	require.ErrorContains(t, validateNumericStrategy(columns[2], types.QValueKindInt64), "needs a NUMERIC column")
+	require.ErrorContains(t, validateNumericStrategy(&protos.ColumnSetting{
+		SourceName: "amount", DestinationType: "Float64", NumericStrategy: protos.NumericStrategy_NUMERIC_STRATEGY_STRING,
+	}, types.QValueKindNumeric), "conflicts with destination type")
+}
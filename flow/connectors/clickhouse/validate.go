@@ -2,11 +2,14 @@ package connclickhouse
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"maps"
 	"slices"
 	"strings"
 
+	chproto "github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	chvalidate "github.com/PeerDB-io/peerdb/flow/pkg/clickhouse"
@@ -31,6 +34,24 @@ func engineToString(e protos.TableEngine) (string, error) {
 	}
 }
 
+func (c *ClickHouseConnector) validateDeleteMode(deleteMode protos.ClickHouseDeleteMode) error {
+	switch deleteMode {
+	case protos.ClickHouseDeleteMode_CH_DELETE_MODE_LIGHTWEIGHT:
+		// DELETE FROM is not supported on Distributed tables
+		if c.Config.Cluster != "" {
+			return errors.New("lightweight deletes are not supported for ClickHouse clusters")
+		}
+		if c.chVersion != nil && !chproto.CheckMinVersion(chproto.Version{Major: 23, Minor: 3, Patch: 0}, *c.chVersion) {
+			return errors.New("lightweight deletes need ClickHouse 23.3 or later")
+		}
+	case protos.ClickHouseDeleteMode_CH_DELETE_MODE_IS_DELETED:
+		if c.chVersion != nil && !chproto.CheckMinVersion(chproto.Version{Major: 23, Minor: 2, Patch: 0}, *c.chVersion) {
+			return errors.New("ReplacingMergeTree with is_deleted needs ClickHouse 23.2 or later")
+		}
+	}
+	return nil
+}
+
 func (c *ClickHouseConnector) ValidateMirrorDestination(
 	ctx context.Context,
 	cfg *protos.FlowConnectionConfigsCore,
@@ -47,6 +68,13 @@ func (c *ClickHouseConnector) ValidateMirrorDestination(
 		}
 	}
 
+	if err := validateProjections(cfg.TableMappings); err != nil {
+		return err
+	}
+	if err := c.validateDeleteMode(cfg.ClickhouseDeleteMode); err != nil {
+		return err
+	}
+
 	if cfg.Resync {
 		return nil // no need to validate schema for resync, as we will create or replace the tables
 	}
@@ -63,7 +91,7 @@ func (c *ClickHouseConnector) ValidateMirrorDestination(
 
 	// this is for handling column exclusion, processed schema does that in a step
 	processedMapping := internal.BuildProcessedSchemaMapping(cfg.TableMappings, tableNameSchemaMapping, c.logger)
-	dstTableNames := slices.Collect(maps.Keys(processedMapping))
+	dstTableNames := withProjections(cfg.TableMappings, slices.Collect(maps.Keys(processedMapping)))
 
 	// In the case of resync, we don't need to check the content or structure of the original tables;
 	// they'll always get swapped out with the _resync tables which we CREATE OR REPLACE
@@ -103,44 +131,59 @@ func (c *ClickHouseConnector) ValidateMirrorDestination(
 			return fmt.Errorf("source table %s not found in schema mapping", tableMapping.SourceTableIdentifier)
 		}
 
-		var sortingKeys []string
-		for _, col := range tableMapping.Columns {
-			if col.Ordering > 0 {
-				sortingKeys = append(sortingKeys, col.SourceName)
+		// projections are validated like the table they project, minus their own excluded columns
+		for _, target := range append([]*protos.TableMapping{tableMapping}, tableMapping.Projections...) {
+			targetSchema := processedSchema
+			if target != tableMapping {
+				targetSchema = internal.ExcludeColumns(processedSchema, target.Exclude)
 			}
-		}
-		engine, err := engineToString(tableMapping.Engine)
-		if err != nil {
-			return err
-		}
-		if err := chvalidate.ValidateOrderingKeys(ctx, c.logger, c.database,
-			c.chVersion, tableMapping.SourceTableIdentifier,
-			len(processedSchema.PrimaryKeyColumns) > 0,
-			sortingKeys, engine,
-		); err != nil {
-			return err
-		}
-		if err := chvalidate.ValidateClusterShardingKey(
-			c.Config.Cluster,
-			tableMapping.ShardingKey,
-			tableMapping.SourceTableIdentifier,
-			len(processedSchema.PrimaryKeyColumns) > 0,
-			sortingKeys,
-		); err != nil {
-			return err
-		}
 
-		// if destination table does not exist, we're good
-		if _, ok := chTableColumnsMapping[dstTableName]; !ok {
-			continue
-		}
+			var sortingKeys []string
+			for _, col := range target.Columns {
+				if col.Ordering > 0 {
+					sortingKeys = append(sortingKeys, col.SourceName)
+				}
+			}
+			engine, err := engineToString(target.Engine)
+			if err != nil {
+				return err
+			}
+			if err := chvalidate.ValidateOrderingKeys(ctx, c.logger, c.database,
+				c.chVersion, tableMapping.SourceTableIdentifier,
+				len(targetSchema.PrimaryKeyColumns) > 0,
+				sortingKeys, engine,
+			); err != nil {
+				return err
+			}
+			if err := chvalidate.ValidateClusterShardingKey(
+				c.Config.Cluster,
+				target.ShardingKey,
+				tableMapping.SourceTableIdentifier,
+				len(targetSchema.PrimaryKeyColumns) > 0,
+				sortingKeys,
+			); err != nil {
+				return err
+			}
 
-		// for resync, we don't need to check the content or structure of the original tables;
-		// they'll anyways get swapped out with the _resync tables which we CREATE OR REPLACE
-		if err := c.processTableComparison(dstTableName, processedMapping[dstTableName],
-			chTableColumnsMapping[dstTableName], peerDBColumns, tableMapping,
-		); err != nil {
-			return err
+			if cfg.ClickhouseDeleteMode == protos.ClickHouseDeleteMode_CH_DELETE_MODE_LIGHTWEIGHT &&
+				target.Engine != protos.TableEngine_CH_ENGINE_NULL &&
+				len(targetSchema.PrimaryKeyColumns) == 0 && len(sortingKeys) == 0 {
+				return fmt.Errorf("lightweight deletes need a primary key or sorting key for table %s",
+					target.DestinationTableIdentifier)
+			}
+
+			// if destination table does not exist, we're good
+			if _, ok := chTableColumnsMapping[target.DestinationTableIdentifier]; !ok {
+				continue
+			}
+
+			// for resync, we don't need to check the content or structure of the original tables;
+			// they'll anyways get swapped out with the _resync tables which we CREATE OR REPLACE
+			if err := c.processTableComparison(target.DestinationTableIdentifier, targetSchema,
+				chTableColumnsMapping[target.DestinationTableIdentifier], peerDBColumns, target,
+			); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
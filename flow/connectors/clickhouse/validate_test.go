@@ -3,6 +3,7 @@ package connclickhouse
 import (
 	"testing"
 
+	chproto "github.com/ClickHouse/clickhouse-go/v2/lib/proto"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
@@ -14,3 +15,23 @@ func TestEngineToStringExhaustive(t *testing.T) {
 		assert.NoErrorf(t, err, "engineToString(%v) failed", protos.TableEngine(enumVal))
 	}
 }
+
+func TestValidateDeleteMode(t *testing.T) {
+	c := &ClickHouseConnector{
+		Config:    &protos.ClickhouseConfig{},
+		chVersion: &chproto.Version{Major: 23, Minor: 2, Patch: 0},
+	}
+	for deleteMode := range protos.ClickHouseDeleteMode_name {
+		err := c.validateDeleteMode(protos.ClickHouseDeleteMode(deleteMode))
+		if protos.ClickHouseDeleteMode(deleteMode) == protos.ClickHouseDeleteMode_CH_DELETE_MODE_LIGHTWEIGHT {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+
+	c.chVersion = &chproto.Version{Major: 25, Minor: 8, Patch: 0}
+	assert.NoError(t, c.validateDeleteMode(protos.ClickHouseDeleteMode_CH_DELETE_MODE_LIGHTWEIGHT))
+	c.Config.Cluster = "cluster"
+	assert.Error(t, c.validateDeleteMode(protos.ClickHouseDeleteMode_CH_DELETE_MODE_LIGHTWEIGHT))
+}
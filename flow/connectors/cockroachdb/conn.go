@@ -53,6 +53,14 @@ func (c *crdbConn) QueryRow(ctx context.Context, sql string, args ...any) pgx.Ro
 	return &crdbRow{c.conn.QueryRow(ctx, sql, args...)}
 }
 
+func (c *crdbConn) Begin(ctx context.Context) (*crdbTx, error) {
+	tx, err := c.conn.Begin(ctx)
+	if err != nil {
+		return nil, toCrdbError(err)
+	}
+	return &crdbTx{tx}, nil
+}
+
 func (c *crdbConn) Close(ctx context.Context) error {
 	return toCrdbError(c.conn.Close(ctx))
 }
@@ -61,6 +69,27 @@ func (c *crdbConn) TypeMap() *pgtype.Map {
 	return c.conn.TypeMap()
 }
 
+// crdbTx tags errors surfaced by the statements of a destination transaction.
+type crdbTx struct {
+	pgx.Tx
+}
+
+func (t *crdbTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	res, err := t.Tx.Exec(ctx, sql, args...)
+	return res, toCrdbError(err)
+}
+
+func (t *crdbTx) CopyFrom(
+	ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource,
+) (int64, error) {
+	n, err := t.Tx.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	return n, toCrdbError(err)
+}
+
+func (t *crdbTx) Commit(ctx context.Context) error {
+	return toCrdbError(t.Tx.Commit(ctx))
+}
+
 // crdbRows tags errors surfaced while iterating a result set.
 type crdbRows struct {
 	pgx.Rows
@@ -0,0 +1,447 @@
+package conncockroachdb
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/pkg/common"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// Sync and normalize progress lives in the catalog, CockroachDB only holds the raw table.
+const (
+	metadataSchema    = "_peerdb_internal"
+	rawTablePrefix    = "_peerdb_raw"
+	createSchemaSQL   = "CREATE SCHEMA IF NOT EXISTS %s"
+	createRawTableSQL = `CREATE TABLE IF NOT EXISTS %s(_peerdb_uid UUID NOT NULL PRIMARY KEY,
+		_peerdb_timestamp INT8 NOT NULL,_peerdb_destination_table_name STRING NOT NULL,_peerdb_data JSONB NOT NULL,
+		_peerdb_record_type INT4 NOT NULL,_peerdb_match_data JSONB,_peerdb_batch_id INT8 NOT NULL,
+		_peerdb_unchanged_toast_columns STRING,
+		INDEX (_peerdb_batch_id, _peerdb_destination_table_name))`
+	createNormalizedTableSQL = "CREATE TABLE IF NOT EXISTS %s(%s)"
+	dropTableIfExistsSQL     = "DROP TABLE IF EXISTS %s"
+	deleteRawBatchSQL        = "DELETE FROM %s WHERE _peerdb_batch_id=$1"
+	checkTableExistsSQL      = `SELECT EXISTS(SELECT 1 FROM information_schema.tables
+		WHERE table_schema=$1 AND table_name=$2)`
+
+	getDistinctDestinationTableNamesSQL = `SELECT DISTINCT _peerdb_destination_table_name FROM %s
+	WHERE _peerdb_batch_id=$1`
+	getTableNameToUnchangedToastColsSQL = `SELECT _peerdb_destination_table_name,
+	ARRAY_AGG(DISTINCT _peerdb_unchanged_toast_columns) FROM %s
+	WHERE _peerdb_batch_id=$1 AND _peerdb_record_type!=2 GROUP BY _peerdb_destination_table_name`
+)
+
+func getRawTableIdentifier(jobName string) pgx.Identifier {
+	return pgx.Identifier{
+		metadataSchema,
+		rawTablePrefix + "_" + strings.ToLower(shared.ReplaceIllegalCharactersWithUnderscores(jobName)),
+	}
+}
+
+func (c *CockroachDBConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	rawTable := getRawTableIdentifier(req.FlowJobName)
+	if _, err := c.conn.Exec(ctx, fmt.Sprintf(createSchemaSQL, common.QuoteIdentifier(metadataSchema))); err != nil {
+		return nil, fmt.Errorf("error creating internal schema: %w", err)
+	}
+	if _, err := c.conn.Exec(ctx, fmt.Sprintf(createRawTableSQL, rawTable.Sanitize())); err != nil {
+		return nil, fmt.Errorf("error creating raw table: %w", err)
+	}
+	return &protos.CreateRawTableOutput{TableIdentifier: rawTable.Sanitize()}, nil
+}
+
+func (c *CockroachDBConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	rawTable := getRawTableIdentifier(req.FlowJobName)
+	c.logger.Info("pushing records to CockroachDB table via COPY", slog.String("table", rawTable.Sanitize()))
+
+	jsonOptions := model.ToJSONOptions{
+		UnnestColumns: nil,
+		HStoreAsJSON:  true,
+	}
+	numRecords := int64(0)
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	streamReadFunc := func() ([]any, error) {
+		for record := range req.Records.GetRecords() {
+			var row []any
+			switch typedRecord := record.(type) {
+			case *model.InsertRecord[model.RecordItems]:
+				itemsJSON, err := typedRecord.Items.ToJSONWithOptions(jsonOptions)
+				if err != nil {
+					return nil, fmt.Errorf("failed to serialize insert record items to JSON: %w", err)
+				}
+				row = []any{
+					uuid.New(), time.Now().UnixNano(), typedRecord.DestinationTableName,
+					itemsJSON, 0, "{}", req.SyncBatchID, "",
+				}
+			case *model.UpdateRecord[model.RecordItems]:
+				newItemsJSON, err := typedRecord.NewItems.ToJSONWithOptions(jsonOptions)
+				if err != nil {
+					return nil, fmt.Errorf("failed to serialize update record new items to JSON: %w", err)
+				}
+				oldItemsJSON, err := typedRecord.OldItems.ToJSONWithOptions(jsonOptions)
+				if err != nil {
+					return nil, fmt.Errorf("failed to serialize update record old items to JSON: %w", err)
+				}
+				row = []any{
+					uuid.New(), time.Now().UnixNano(), typedRecord.DestinationTableName,
+					newItemsJSON, 1, oldItemsJSON, req.SyncBatchID, utils.KeysToString(typedRecord.UnchangedToastColumns),
+				}
+			case *model.DeleteRecord[model.RecordItems]:
+				itemsJSON, err := typedRecord.Items.ToJSONWithOptions(jsonOptions)
+				if err != nil {
+					return nil, fmt.Errorf("failed to serialize delete record items to JSON: %w", err)
+				}
+				row = []any{
+					uuid.New(), time.Now().UnixNano(), typedRecord.DestinationTableName,
+					itemsJSON, 2, itemsJSON, req.SyncBatchID, "",
+				}
+			case *model.MessageRecord[model.RecordItems]:
+				continue
+			default:
+				return nil, fmt.Errorf("unsupported record type for CockroachDB flow connector: %T", typedRecord)
+			}
+
+			record.PopulateCountMap(tableNameRowsMapping)
+			numRecords += 1
+			return row, nil
+		}
+		return nil, nil
+	}
+
+	tx, err := c.conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction for syncing records: %w", err)
+	}
+	defer shared.RollbackTx(tx, c.logger)
+
+	// a retried batch replaces whatever an earlier attempt left in the raw table
+	if _, err := tx.Exec(ctx, fmt.Sprintf(deleteRawBatchSQL, rawTable.Sanitize()), req.SyncBatchID); err != nil {
+		return nil, fmt.Errorf("error clearing previous attempt of batch %d: %w", req.SyncBatchID, err)
+	}
+	syncedRecordsCount, err := tx.CopyFrom(ctx, rawTable,
+		[]string{
+			"_peerdb_uid", "_peerdb_timestamp", "_peerdb_destination_table_name", "_peerdb_data",
+			"_peerdb_record_type", "_peerdb_match_data", "_peerdb_batch_id", "_peerdb_unchanged_toast_columns",
+		},
+		pgx.CopyFromFunc(streamReadFunc))
+	if err != nil {
+		return nil, fmt.Errorf("error syncing records: %w", err)
+	}
+	if syncedRecordsCount != numRecords {
+		return nil, fmt.Errorf("error syncing records: expected %d records to be synced, but %d were synced",
+			numRecords, syncedRecordsCount)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing synced records: %w", err)
+	}
+	c.logger.Info(fmt.Sprintf("synced %d records to CockroachDB table %s via COPY", syncedRecordsCount, rawTable.Sanitize()))
+
+	lastCP := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCP); err != nil {
+		return nil, err
+	}
+
+	if err := c.ReplayTableSchemaDeltas(ctx, req.Env, req.FlowJobName, req.TableMappings, req.Records.SchemaDeltas, nil); err != nil {
+		return nil, fmt.Errorf("failed to sync schema changes: %w", err)
+	}
+
+	return &model.SyncResponse{
+		LastSyncedCheckpoint: lastCP,
+		NumRecordsSynced:     numRecords,
+		CurrentSyncBatchID:   req.SyncBatchID,
+		TableNameRowsMapping: tableNameRowsMapping,
+		TableSchemaDeltas:    req.Records.SchemaDeltas,
+	}, nil
+}
+
+func (c *CockroachDBConnector) NormalizeRecords(
+	ctx context.Context,
+	req *model.NormalizeRecordsRequest,
+) (model.NormalizeResponse, error) {
+	normBatchID, err := c.GetLastNormalizeBatchID(ctx, req.FlowJobName)
+	if err != nil {
+		return model.NormalizeResponse{}, fmt.Errorf("failed to get batch for the current mirror: %w", err)
+	}
+
+	// normalize has caught up with sync, chill until more records are loaded.
+	if normBatchID >= req.SyncBatchID {
+		c.logger.Info(fmt.Sprintf("no records to normalize: syncBatchID %d, normalizeBatchID %d",
+			req.SyncBatchID, normBatchID))
+		return model.NormalizeResponse{
+			StartBatchID: normBatchID,
+			EndBatchID:   req.SyncBatchID,
+		}, nil
+	}
+
+	rawTable := getRawTableIdentifier(req.FlowJobName)
+	normalizeStmtGen := normalizeStmtGenerator{
+		rawTableName:       rawTable.Sanitize(),
+		tableSchemaMapping: req.TableNameSchemaMapping,
+		peerdbCols: &protos.PeerDBColumns{
+			SoftDeleteColName: req.SoftDeleteColName,
+			SyncedAtColName:   req.SyncedAtColName,
+		},
+	}
+
+	totalRowsAffected := 0
+	for batchID := normBatchID + 1; batchID <= req.SyncBatchID; batchID++ {
+		unchangedToastColumnsMap, err := c.getTableNametoUnchangedCols(ctx, rawTable, batchID)
+		if err != nil {
+			return model.NormalizeResponse{}, err
+		}
+		normalizeStmtGen.unchangedToastColumnsMap = unchangedToastColumnsMap
+
+		destinationTableNames, err := c.getDistinctTableNamesInBatch(ctx, rawTable, batchID, req.TableNameSchemaMapping)
+		if err != nil {
+			return model.NormalizeResponse{}, err
+		}
+		rowsAffected, err := c.normalizeBatch(ctx, rawTable, batchID, destinationTableNames, &normalizeStmtGen)
+		if err != nil {
+			return model.NormalizeResponse{}, err
+		}
+		if err := c.UpdateNormalizeBatchID(ctx, req.FlowJobName, batchID); err != nil {
+			return model.NormalizeResponse{}, err
+		}
+		totalRowsAffected += rowsAffected
+		c.logger.Info("normalize: committed batch to destination",
+			slog.Int64("batchID", batchID),
+			slog.Int64("syncBatchID", req.SyncBatchID),
+			slog.Int("rowsAffected", rowsAffected),
+		)
+	}
+	c.logger.Info(fmt.Sprintf("normalized %d records", totalRowsAffected))
+
+	return model.NormalizeResponse{
+		StartBatchID: normBatchID + 1,
+		EndBatchID:   req.SyncBatchID,
+	}, nil
+}
+
+// normalizeBatch applies one batch and removes it from the raw table in the same transaction,
+// so a batch replayed after a crash before the catalog update finds nothing left to apply.
+func (c *CockroachDBConnector) normalizeBatch(
+	ctx context.Context,
+	rawTable pgx.Identifier,
+	batchID int64,
+	destinationTableNames []string,
+	normalizeStmtGen *normalizeStmtGenerator,
+) (int, error) {
+	tx, err := c.conn.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction for normalizing records: %w", err)
+	}
+	defer shared.RollbackTx(tx, c.logger)
+
+	totalRowsAffected := 0
+	for _, destinationTableName := range destinationTableNames {
+		for _, stmt := range normalizeStmtGen.generateNormalizeStatements(destinationTableName) {
+			ct, err := tx.Exec(ctx, stmt, batchID, destinationTableName)
+			if err != nil {
+				c.logger.Error("error executing normalize statement",
+					slog.String("statement", stmt),
+					slog.Int64("batchID", batchID),
+					slog.String("destinationTableName", destinationTableName),
+					slog.Any("error", err),
+				)
+				return 0, fmt.Errorf("error executing normalize statement for table %s: %w", destinationTableName, err)
+			}
+			totalRowsAffected += int(ct.RowsAffected())
+		}
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf(deleteRawBatchSQL, rawTable.Sanitize()), batchID); err != nil {
+		return 0, fmt.Errorf("failed to remove normalized batch from raw table: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit normalize transaction: %w", err)
+	}
+	return totalRowsAffected, nil
+}
+
+func (c *CockroachDBConnector) getDistinctTableNamesInBatch(
+	ctx context.Context,
+	rawTable pgx.Identifier,
+	batchID int64,
+	tableToSchema map[string]*protos.TableSchema,
+) ([]string, error) {
+	rows, err := c.conn.Query(ctx, fmt.Sprintf(getDistinctDestinationTableNamesSQL, rawTable.Sanitize()), batchID)
+	if err != nil {
+		return nil, fmt.Errorf("error while retrieving table names for normalization: %w", err)
+	}
+	destinationTableNames, err := pgx.CollectRows[string](rows, pgx.RowTo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+	return slices.DeleteFunc(destinationTableNames, func(name string) bool {
+		if _, ok := tableToSchema[name]; !ok {
+			c.logger.Warn("table not found in table to schema mapping", slog.String("table", name))
+			return true
+		}
+		return false
+	}), nil
+}
+
+func (c *CockroachDBConnector) getTableNametoUnchangedCols(
+	ctx context.Context,
+	rawTable pgx.Identifier,
+	batchID int64,
+) (map[string][]string, error) {
+	rows, err := c.conn.Query(ctx, fmt.Sprintf(getTableNameToUnchangedToastColsSQL, rawTable.Sanitize()), batchID)
+	if err != nil {
+		return nil, fmt.Errorf("error while retrieving unchanged toast columns for normalization: %w", err)
+	}
+	defer rows.Close()
+
+	resultMap := make(map[string][]string)
+	var destinationTableName string
+	var unchangedToastColumns []string
+	for rows.Next() {
+		if err := rows.Scan(&destinationTableName, &unchangedToastColumns); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		resultMap[destinationTableName] = unchangedToastColumns
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+	return resultMap, nil
+}
+
+// CockroachDB discourages schema changes inside explicit transactions, tables are created one by one
+func (c *CockroachDBConnector) StartSetupNormalizedTables(_ context.Context) (any, error) {
+	return nil, nil
+}
+
+func (c *CockroachDBConnector) CleanupSetupNormalizedTables(_ context.Context, _ any) {
+}
+
+func (c *CockroachDBConnector) FinishSetupNormalizedTables(_ context.Context, _ any) error {
+	return nil
+}
+
+func (c *CockroachDBConnector) SetupNormalizedTable(
+	ctx context.Context,
+	_ any,
+	config *protos.SetupNormalizedTableBatchInput,
+	tableIdentifier string,
+	tableSchema *protos.TableSchema,
+) (bool, error) {
+	parsedNormalizedTable, err := common.ParseTableIdentifier(tableIdentifier)
+	if err != nil {
+		return false, fmt.Errorf("error while parsing table schema and name: %w", err)
+	}
+	var tableAlreadyExists bool
+	if err := c.conn.QueryRow(ctx, checkTableExistsSQL,
+		parsedNormalizedTable.Namespace, parsedNormalizedTable.Table,
+	).Scan(&tableAlreadyExists); err != nil {
+		return false, fmt.Errorf("error occurred while checking if normalized table exists: %w", err)
+	}
+	if tableAlreadyExists {
+		c.logger.Info("[cockroachdb] table already exists, skipping", slog.String("table", tableIdentifier))
+		if !config.IsResync {
+			return true, nil
+		}
+		if _, err := c.conn.Exec(ctx, fmt.Sprintf(dropTableIfExistsSQL, parsedNormalizedTable.String())); err != nil {
+			return false, fmt.Errorf("error while dropping _resync table: %w", err)
+		}
+		c.logger.Info("[cockroachdb] dropped resync table for resync", slog.String("resyncTable", parsedNormalizedTable.String()))
+	}
+
+	createSQL, err := generateCreateTableSQLForNormalizedTable(config, parsedNormalizedTable, tableSchema)
+	if err != nil {
+		return false, err
+	}
+	if _, err := c.conn.Exec(ctx, createSQL); err != nil {
+		return false, fmt.Errorf("error while creating normalized table: %w", err)
+	}
+	return false, nil
+}
+
+func generateCreateTableSQLForNormalizedTable(
+	config *protos.SetupNormalizedTableBatchInput,
+	dstSchemaTable *common.QualifiedTable,
+	tableSchema *protos.TableSchema,
+) (string, error) {
+	// normalize relies on ON CONFLICT, which needs the primary key of the source table
+	if len(tableSchema.PrimaryKeyColumns) == 0 || tableSchema.IsReplicaIdentityFull {
+		return "", fmt.Errorf("table %s has no primary key, which CockroachDB destinations require", dstSchemaTable)
+	}
+
+	createTableSQLArray := make([]string, 0, len(tableSchema.Columns)+3)
+	for _, column := range tableSchema.Columns {
+		var notNull string
+		if tableSchema.NullableEnabled && !column.Nullable {
+			notNull = " NOT NULL"
+		}
+		createTableSQLArray = append(createTableSQLArray, fmt.Sprintf("%s %s%s",
+			common.QuoteIdentifier(column.Name), columnTypeToCockroachDB(tableSchema.System, column), notNull))
+	}
+
+	if config.SoftDeleteColName != "" {
+		createTableSQLArray = append(createTableSQLArray,
+			common.QuoteIdentifier(config.SoftDeleteColName)+` BOOL DEFAULT FALSE`)
+	}
+	if config.SyncedAtColName != "" {
+		createTableSQLArray = append(createTableSQLArray,
+			common.QuoteIdentifier(config.SyncedAtColName)+` TIMESTAMP DEFAULT CURRENT_TIMESTAMP`)
+	}
+
+	primaryKeyColsQuoted := make([]string, 0, len(tableSchema.PrimaryKeyColumns))
+	for _, primaryKeyCol := range tableSchema.PrimaryKeyColumns {
+		primaryKeyColsQuoted = append(primaryKeyColsQuoted, common.QuoteIdentifier(primaryKeyCol))
+	}
+	createTableSQLArray = append(createTableSQLArray, fmt.Sprintf("PRIMARY KEY(%s)", strings.Join(primaryKeyColsQuoted, ",")))
+
+	return fmt.Sprintf(createNormalizedTableSQL, dstSchemaTable.String(), strings.Join(createTableSQLArray, ",")), nil
+}
+
+func (c *CockroachDBConnector) ReplayTableSchemaDeltas(
+	ctx context.Context,
+	_ map[string]string,
+	flowJobName string,
+	_ []*protos.TableMapping,
+	schemaDeltas []*protos.TableSchemaDelta,
+	_ []string,
+) error {
+	for _, schemaDelta := range schemaDeltas {
+		if schemaDelta == nil || len(schemaDelta.AddedColumns) == 0 {
+			continue
+		}
+
+		dstSchemaTable, err := common.ParseTableIdentifier(schemaDelta.DstTableName)
+		if err != nil {
+			return fmt.Errorf("error parsing schema and table for %s: %w", schemaDelta.DstTableName, err)
+		}
+		for _, addedColumn := range schemaDelta.AddedColumns {
+			columnType := columnTypeToCockroachDB(schemaDelta.System, addedColumn)
+			if _, err := c.conn.Exec(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s",
+				dstSchemaTable.String(), common.QuoteIdentifier(addedColumn.Name), columnType),
+			); err != nil {
+				return fmt.Errorf("failed to add column %s for table %s: %w", addedColumn.Name, schemaDelta.DstTableName, err)
+			}
+			c.logger.Info(fmt.Sprintf("[schema delta replay] added column %s with data type %s", addedColumn.Name, columnType),
+				slog.String("srcTableName", schemaDelta.SrcTableName),
+				slog.String("dstTableName", schemaDelta.DstTableName),
+			)
+		}
+	}
+	return nil
+}
+
+func (c *CockroachDBConnector) SyncFlowCleanup(ctx context.Context, jobName string) error {
+	rawTable := getRawTableIdentifier(jobName)
+	if _, err := c.conn.Exec(ctx, fmt.Sprintf(dropTableIfExistsSQL, rawTable.Sanitize())); err != nil {
+		return fmt.Errorf("[cockroachdb] unable to drop raw table: %w", err)
+	}
+	c.logger.Info("successfully dropped raw table", slog.String("table", rawTable.Sanitize()))
+	return nil
+}
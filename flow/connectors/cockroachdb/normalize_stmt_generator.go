@@ -0,0 +1,139 @@
+package conncockroachdb
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/pkg/common"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+const (
+	// latest change per primary key within a batch, $1 is the batch id and $2 the destination table
+	srcRankCTESQL = `WITH src_rank AS (
+		SELECT _peerdb_data,_peerdb_record_type,_peerdb_unchanged_toast_columns,
+		ROW_NUMBER() OVER (PARTITION BY %s ORDER BY _peerdb_timestamp DESC) AS _peerdb_rank
+		FROM %s WHERE _peerdb_batch_id=$1 AND _peerdb_destination_table_name=$2
+	)`
+	upsertStatementSQL = `%s UPSERT INTO %s (%s) SELECT %s FROM src_rank
+	WHERE _peerdb_rank=1 AND _peerdb_record_type!=2 AND _peerdb_unchanged_toast_columns=''`
+	insertOnConflictStatementSQL = `%s INSERT INTO %s (%s) SELECT %s FROM src_rank
+	WHERE _peerdb_rank=1 AND %s
+	ON CONFLICT (%s) DO UPDATE SET %s`
+	deleteStatementSQL = `%s DELETE FROM %s USING src_rank
+	WHERE %s AND src_rank._peerdb_rank=1 AND src_rank._peerdb_record_type=2`
+)
+
+// normalizeStmtGenerator builds the statements merging a batch of the raw table into a destination table.
+// CockroachDB has no MERGE, so changes are applied with UPSERT when every column changed
+// and with INSERT ... ON CONFLICT DO UPDATE for each combination of unchanged TOAST columns.
+type normalizeStmtGenerator struct {
+	// schema qualified and quoted _peerdb_raw_... table
+	rawTableName string
+	// the schema of the table to merge into
+	tableSchemaMapping map[string]*protos.TableSchema
+	// array of toast column combinations that are unchanged
+	unchangedToastColumnsMap map[string][]string
+	// _PEERDB_IS_DELETED and _SYNCED_AT columns
+	peerdbCols *protos.PeerDBColumns
+}
+
+func (n *normalizeStmtGenerator) generateExpr(
+	system protos.TypeSystem,
+	column *protos.FieldDescription,
+	crdbType string,
+) string {
+	stringCol := utils.QuoteLiteral(column.Name)
+	if system == protos.TypeSystem_Q {
+		qkind := types.QValueKind(column.Type)
+		if qkind.IsArray() {
+			return fmt.Sprintf("ARRAY(SELECT json_array_elements_text((_peerdb_data->>%s)::JSONB))::%s", stringCol, crdbType)
+		} else if qkind == types.QValueKindBytes {
+			return fmt.Sprintf("decode(_peerdb_data->>%s, 'base64')::%s", stringCol, crdbType)
+		}
+	}
+	return fmt.Sprintf("(_peerdb_data->>%s)::%s", stringCol, crdbType)
+}
+
+func (n *normalizeStmtGenerator) generateNormalizeStatements(dstTableName string) []string {
+	schema := n.tableSchemaMapping[dstTableName]
+	parsedDstTable, _ := common.ParseTableIdentifier(dstTableName)
+	dstTable := parsedDstTable.String()
+
+	quotedCols := make([]string, 0, len(schema.Columns))
+	colExprs := make([]string, 0, len(schema.Columns))
+	pkExprs := make([]string, 0, len(schema.PrimaryKeyColumns))
+	pkMatches := make([]string, 0, len(schema.PrimaryKeyColumns))
+	for _, column := range schema.Columns {
+		quotedCol := common.QuoteIdentifier(column.Name)
+		expr := n.generateExpr(schema.System, column, columnTypeToCockroachDB(schema.System, column))
+		quotedCols = append(quotedCols, quotedCol)
+		colExprs = append(colExprs, expr)
+		if slices.Contains(schema.PrimaryKeyColumns, column.Name) {
+			pkExprs = append(pkExprs, expr)
+			pkMatches = append(pkMatches, fmt.Sprintf("%s.%s=%s", dstTable, quotedCol, expr))
+		}
+	}
+	quotedPkCols := make([]string, 0, len(schema.PrimaryKeyColumns))
+	for _, col := range schema.PrimaryKeyColumns {
+		quotedPkCols = append(quotedPkCols, common.QuoteIdentifier(col))
+	}
+	srcRank := fmt.Sprintf(srcRankCTESQL, strings.Join(pkExprs, ","), n.rawTableName)
+	conflictTarget := strings.Join(quotedPkCols, ",")
+
+	insertCols := slices.Clone(quotedCols)
+	insertExprs := slices.Clone(colExprs)
+	var syncedAtSet string
+	if n.peerdbCols.SyncedAtColName != "" {
+		insertCols = append(insertCols, common.QuoteIdentifier(n.peerdbCols.SyncedAtColName))
+		insertExprs = append(insertExprs, "CURRENT_TIMESTAMP")
+		syncedAtSet = "," + common.QuoteIdentifier(n.peerdbCols.SyncedAtColName) + "=CURRENT_TIMESTAMP"
+	}
+	softDeleteCol := n.peerdbCols.SoftDeleteColName
+	if softDeleteCol != "" {
+		// re-inserting a key clears an earlier soft delete
+		insertCols = append(insertCols, common.QuoteIdentifier(softDeleteCol))
+		insertExprs = append(insertExprs, "FALSE")
+	}
+	insertColsSQL := strings.Join(insertCols, ",")
+
+	statements := make([]string, 0, len(n.unchangedToastColumnsMap[dstTableName])+1)
+	for _, unchangedToastColumns := range n.unchangedToastColumnsMap[dstTableName] {
+		if unchangedToastColumns == "" {
+			statements = append(statements, fmt.Sprintf(upsertStatementSQL,
+				srcRank, dstTable, insertColsSQL, strings.Join(insertExprs, ",")))
+			continue
+		}
+
+		unchangedCols := strings.Split(unchangedToastColumns, ",")
+		for i, col := range unchangedCols {
+			unchangedCols[i] = common.QuoteIdentifier(col)
+		}
+		updateCols := shared.ArrayMinus(insertCols, unchangedCols)
+		updateSets := make([]string, 0, len(updateCols))
+		for _, col := range updateCols {
+			updateSets = append(updateSets, fmt.Sprintf("%s=excluded.%s", col, col))
+		}
+		statements = append(statements, fmt.Sprintf(insertOnConflictStatementSQL,
+			srcRank, dstTable, insertColsSQL, strings.Join(insertExprs, ","),
+			"_peerdb_record_type!=2 AND _peerdb_unchanged_toast_columns="+utils.QuoteLiteral(unchangedToastColumns),
+			conflictTarget, strings.Join(updateSets, ",")))
+	}
+
+	if softDeleteCol == "" {
+		statements = append(statements, fmt.Sprintf(deleteStatementSQL,
+			srcRank, dstTable, strings.Join(pkMatches, " AND ")))
+	} else {
+		// deleted rows never seen before are inserted as soft deleted, like MERGE does on Postgres
+		insertExprs[len(insertExprs)-1] = "TRUE"
+		statements = append(statements, fmt.Sprintf(insertOnConflictStatementSQL,
+			srcRank, dstTable, insertColsSQL, strings.Join(insertExprs, ","),
+			"_peerdb_record_type=2", conflictTarget,
+			common.QuoteIdentifier(softDeleteCol)+"=TRUE"+syncedAtSet))
+	}
+	return statements
+}
@@ -0,0 +1,74 @@
+package conncockroachdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func testNormalizeSchema() map[string]*protos.TableSchema {
+	return map[string]*protos.TableSchema{
+		"public.t": {
+			System:            protos.TypeSystem_Q,
+			PrimaryKeyColumns: []string{"id"},
+			Columns: []*protos.FieldDescription{
+				{Name: "id", Type: string(types.QValueKindInt32), TypeModifier: -1},
+				{Name: "big", Type: string(types.QValueKindString), TypeModifier: -1},
+			},
+		},
+	}
+}
+
+func TestGenerateNormalizeStatements(t *testing.T) {
+	gen := normalizeStmtGenerator{
+		rawTableName:             `"_peerdb_internal"."_peerdb_raw_m"`,
+		tableSchemaMapping:       testNormalizeSchema(),
+		unchangedToastColumnsMap: map[string][]string{"public.t": {"", "big"}},
+		peerdbCols:               &protos.PeerDBColumns{SyncedAtColName: "_peerdb_synced_at"},
+	}
+	srcRank := `WITH src_rank AS (
+		SELECT _peerdb_data,_peerdb_record_type,_peerdb_unchanged_toast_columns,
+		ROW_NUMBER() OVER (PARTITION BY (_peerdb_data->>'id')::INT4 ORDER BY _peerdb_timestamp DESC) AS _peerdb_rank
+		FROM "_peerdb_internal"."_peerdb_raw_m" WHERE _peerdb_batch_id=$1 AND _peerdb_destination_table_name=$2)`
+	expected := []string{
+		srcRank + `UPSERT INTO "public"."t" ("id","big","_peerdb_synced_at")
+		SELECT (_peerdb_data->>'id')::INT4,(_peerdb_data->>'big')::STRING,CURRENT_TIMESTAMP FROM src_rank
+		WHERE _peerdb_rank=1 AND _peerdb_record_type!=2 AND _peerdb_unchanged_toast_columns=''`,
+		srcRank + `INSERT INTO "public"."t" ("id","big","_peerdb_synced_at")
+		SELECT (_peerdb_data->>'id')::INT4,(_peerdb_data->>'big')::STRING,CURRENT_TIMESTAMP FROM src_rank
+		WHERE _peerdb_rank=1 AND _peerdb_record_type!=2 AND _peerdb_unchanged_toast_columns='big'
+		ON CONFLICT ("id") DO UPDATE SET "id"=excluded."id","_peerdb_synced_at"=excluded."_peerdb_synced_at"`,
+		srcRank + `DELETE FROM "public"."t" USING src_rank
+		WHERE "public"."t"."id"=(_peerdb_data->>'id')::INT4 AND src_rank._peerdb_rank=1 AND src_rank._peerdb_record_type=2`,
+	}
+
+	result := gen.generateNormalizeStatements("public.t")
+	require.Len(t, result, len(expected))
+	for i := range expected {
+		require.Equal(t, utils.RemoveSpacesTabsNewlines(expected[i]), utils.RemoveSpacesTabsNewlines(result[i]))
+	}
+}
+
+func TestGenerateNormalizeStatements_WithSoftDelete(t *testing.T) {
+	gen := normalizeStmtGenerator{
+		rawTableName:             `"_peerdb_internal"."_peerdb_raw_m"`,
+		tableSchemaMapping:       testNormalizeSchema(),
+		unchangedToastColumnsMap: map[string][]string{},
+		peerdbCols:               &protos.PeerDBColumns{SoftDeleteColName: "_peerdb_is_deleted"},
+	}
+
+	result := gen.generateNormalizeStatements("public.t")
+	require.Len(t, result, 1)
+	require.Equal(t, utils.RemoveSpacesTabsNewlines(`WITH src_rank AS (
+		SELECT _peerdb_data,_peerdb_record_type,_peerdb_unchanged_toast_columns,
+		ROW_NUMBER() OVER (PARTITION BY (_peerdb_data->>'id')::INT4 ORDER BY _peerdb_timestamp DESC) AS _peerdb_rank
+		FROM "_peerdb_internal"."_peerdb_raw_m" WHERE _peerdb_batch_id=$1 AND _peerdb_destination_table_name=$2)
+		INSERT INTO "public"."t" ("id","big","_peerdb_is_deleted")
+		SELECT (_peerdb_data->>'id')::INT4,(_peerdb_data->>'big')::STRING,TRUE FROM src_rank
+		WHERE _peerdb_rank=1 AND _peerdb_record_type=2
+		ON CONFLICT ("id") DO UPDATE SET "_peerdb_is_deleted"=TRUE`), utils.RemoveSpacesTabsNewlines(result[0]))
+}
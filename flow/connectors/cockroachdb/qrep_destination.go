@@ -0,0 +1,149 @@
+package conncockroachdb
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/pkg/common"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// partitions are tracked in the catalog
+func (c *CockroachDBConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
+
+func (c *CockroachDBConnector) SyncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, shared.QRepWarnings, error) {
+	dstTable, err := common.ParseTableIdentifier(config.DestinationTableIdentifier)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to parse destination table identifier: %w", err)
+	}
+	dstTableIdentifier := pgx.Identifier{dstTable.Namespace, dstTable.Table}
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, nil, err
+	}
+	columnNames := schema.GetColumnNames()
+
+	syncLog := slog.Group("sync-qrep-log",
+		slog.String(string(shared.FlowNameKey), config.FlowJobName),
+		slog.String(string(shared.PartitionIDKey), partition.PartitionId),
+		slog.String("destinationTable", dstTable.String()),
+	)
+	startTime := time.Now()
+	writeMode := config.WriteMode
+	syncedAtCol := config.SyncedAtColName
+	upsert := writeMode != nil && writeMode.WriteType == protos.QRepWriteType_QREP_WRITE_MODE_UPSERT
+
+	// upserts go through a staging table, CockroachDB temporary tables are experimental
+	var stagingTableIdentifier pgx.Identifier
+	if upsert {
+		stagingTableIdentifier = pgx.Identifier{metadataSchema, "_peerdb_staging_" + common.RandomString(8)}
+		if _, err := c.conn.Exec(ctx, fmt.Sprintf(createSchemaSQL, common.QuoteIdentifier(metadataSchema))); err != nil {
+			return 0, nil, fmt.Errorf("failed to create internal schema: %w", err)
+		}
+		if _, err := c.conn.Exec(ctx, fmt.Sprintf("CREATE TABLE %s (LIKE %s)",
+			stagingTableIdentifier.Sanitize(), dstTableIdentifier.Sanitize()),
+		); err != nil {
+			return 0, nil, fmt.Errorf("failed to create staging table: %w", err)
+		}
+		defer func() {
+			if _, err := c.conn.Exec(context.WithoutCancel(ctx),
+				fmt.Sprintf(dropTableIfExistsSQL, stagingTableIdentifier.Sanitize()),
+			); err != nil {
+				c.logger.Warn("failed to drop staging table", slog.Any("error", err), syncLog)
+			}
+		}()
+	}
+
+	tx, err := c.conn.Begin(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer shared.RollbackTx(tx, c.logger)
+
+	var numRowsSynced int64
+	if !upsert {
+		if writeMode != nil && writeMode.WriteType == protos.QRepWriteType_QREP_WRITE_MODE_OVERWRITE {
+			c.logger.Info(fmt.Sprintf("Truncating table %s for overwrite mode", dstTable), syncLog)
+			if _, err := tx.Exec(ctx, "TRUNCATE TABLE "+dstTableIdentifier.Sanitize()); err != nil {
+				return 0, nil, fmt.Errorf("failed to TRUNCATE table before copy: %w", err)
+			}
+		}
+
+		numRowsSynced, err = tx.CopyFrom(ctx, dstTableIdentifier, columnNames, model.NewQRecordCopyFromSource(stream))
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to copy records into destination table: %w", err)
+		}
+
+		if syncedAtCol != "" {
+			if _, err := tx.Exec(ctx, fmt.Sprintf(`UPDATE %s SET %s = CURRENT_TIMESTAMP WHERE %s IS NULL`,
+				dstTableIdentifier.Sanitize(), common.QuoteIdentifier(syncedAtCol), common.QuoteIdentifier(syncedAtCol)),
+			); err != nil {
+				return 0, nil, fmt.Errorf("failed to update synced_at column: %w", err)
+			}
+		}
+	} else {
+		numRowsSynced, err = tx.CopyFrom(ctx, stagingTableIdentifier, columnNames, model.NewQRecordCopyFromSource(stream))
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to copy records into staging table: %w", err)
+		}
+
+		upsertKeyCols := make(map[string]struct{}, len(writeMode.UpsertKeyColumns))
+		quotedKeyCols := make([]string, 0, len(writeMode.UpsertKeyColumns))
+		for _, col := range writeMode.UpsertKeyColumns {
+			upsertKeyCols[col] = struct{}{}
+			quotedKeyCols = append(quotedKeyCols, common.QuoteIdentifier(col))
+		}
+		insertCols := make([]string, 0, len(columnNames)+1)
+		selectCols := make([]string, 0, len(columnNames)+1)
+		setClauses := make([]string, 0, len(columnNames)+1)
+		for _, col := range columnNames {
+			quotedCol := common.QuoteIdentifier(col)
+			insertCols = append(insertCols, quotedCol)
+			selectCols = append(selectCols, quotedCol)
+			if _, ok := upsertKeyCols[col]; !ok {
+				setClauses = append(setClauses, fmt.Sprintf("%s=excluded.%s", quotedCol, quotedCol))
+			}
+		}
+		if syncedAtCol != "" {
+			insertCols = append(insertCols, common.QuoteIdentifier(syncedAtCol))
+			selectCols = append(selectCols, "CURRENT_TIMESTAMP")
+			setClauses = append(setClauses, common.QuoteIdentifier(syncedAtCol)+"=CURRENT_TIMESTAMP")
+		}
+
+		conflictAction := "DO NOTHING"
+		if len(setClauses) > 0 {
+			conflictAction = "DO UPDATE SET " + strings.Join(setClauses, ",")
+		}
+		upsertStmt := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s ON CONFLICT (%s) %s",
+			dstTableIdentifier.Sanitize(), strings.Join(insertCols, ","), strings.Join(selectCols, ","),
+			stagingTableIdentifier.Sanitize(), strings.Join(quotedKeyCols, ","), conflictAction)
+		c.logger.Info("Performing upsert operation", slog.String("upsertStmt", upsertStmt), syncLog)
+		if _, err := tx.Exec(ctx, upsertStmt); err != nil {
+			return 0, nil, fmt.Errorf("failed to perform upsert operation: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	c.logger.Info(fmt.Sprintf("pushed %d records to %s", numRowsSynced, dstTable), syncLog)
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		return 0, nil, fmt.Errorf("failed to log partition info: %w", err)
+	}
+	return numRowsSynced, nil, nil
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/model/qvalue"
+	"github.com/PeerDB-io/peerdb/flow/pkg/common"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
@@ -600,3 +601,121 @@ func crdbArrayUdtToQValueKind(udtName string) types.QValueKind {
 		return types.QValueKindArrayString
 	}
 }
+
+// qValueKindToCockroachDBType maps QValueKinds to CockroachDB column types for destination tables.
+// Integer widths are always spelled out since a bare INT/INTEGER is INT8 in CockroachDB.
+func qValueKindToCockroachDBType(colTypeStr string) string {
+	switch types.QValueKind(colTypeStr) {
+	case types.QValueKindBoolean:
+		return "BOOL"
+	case types.QValueKindInt8, types.QValueKindUInt8, types.QValueKindInt16:
+		return "INT2"
+	case types.QValueKindUInt16, types.QValueKindInt32, types.QValueKindUint16Enum:
+		return "INT4"
+	case types.QValueKindUInt32, types.QValueKindInt64, types.QValueKindUInt64, types.QValueKindUint64Set:
+		return "INT8"
+	case types.QValueKindFloat32:
+		return "FLOAT4"
+	case types.QValueKindFloat64:
+		return "FLOAT8"
+	case types.QValueKindQChar:
+		return "\"char\""
+	case types.QValueKindBytes:
+		return "BYTES"
+	case types.QValueKindJSON, types.QValueKindJSONB, types.QValueKindHStore:
+		return "JSONB"
+	case types.QValueKindUUID:
+		return "UUID"
+	case types.QValueKindTime:
+		return "TIME"
+	case types.QValueKindTimeTZ:
+		return "TIMETZ"
+	case types.QValueKindDate:
+		return "DATE"
+	case types.QValueKindTimestamp:
+		return "TIMESTAMP"
+	case types.QValueKindTimestampTZ:
+		return "TIMESTAMPTZ"
+	case types.QValueKindNumeric:
+		return "DECIMAL"
+	case types.QValueKindINET, types.QValueKindCIDR:
+		// no separate CIDR type, INET holds networks as well
+		return "INET"
+	case types.QValueKindGeography:
+		return "GEOGRAPHY"
+	case types.QValueKindGeometry, types.QValueKindPoint:
+		return "GEOMETRY"
+	case types.QValueKindArrayInt16:
+		return "INT2[]"
+	case types.QValueKindArrayInt32:
+		return "INT4[]"
+	case types.QValueKindArrayInt64:
+		return "INT8[]"
+	case types.QValueKindArrayFloat32:
+		return "FLOAT4[]"
+	case types.QValueKindArrayFloat64:
+		return "FLOAT8[]"
+	case types.QValueKindArrayBoolean:
+		return "BOOL[]"
+	case types.QValueKindArrayDate:
+		return "DATE[]"
+	case types.QValueKindArrayTimestamp:
+		return "TIMESTAMP[]"
+	case types.QValueKindArrayTimestampTZ:
+		return "TIMESTAMPTZ[]"
+	case types.QValueKindArrayUUID:
+		return "UUID[]"
+	case types.QValueKindArrayNumeric:
+		return "DECIMAL[]"
+	case types.QValueKindArrayJSON, types.QValueKindArrayJSONB:
+		return "JSONB[]"
+	case types.QValueKindArrayString, types.QValueKindArrayEnum, types.QValueKindArrayInterval:
+		return "STRING[]"
+	default:
+		return "STRING"
+	}
+}
+
+// pgTypeToCockroachDBType maps Postgres type names to CockroachDB column types.
+// CockroachDB has no domains or composite types and lacks several built-in Postgres types,
+// user-defined types are replicated as their text representation.
+func pgTypeToCockroachDBType(pgType string, typeSchemaName string) string {
+	if typeSchemaName != "" {
+		return "STRING"
+	}
+	if elem, ok := strings.CutPrefix(pgType, "_"); ok {
+		return pgTypeToCockroachDBType(elem, "") + "[]"
+	}
+	switch pgType {
+	case "money":
+		return "DECIMAL"
+	case "cidr":
+		return "INET"
+	case "json":
+		return "JSONB"
+	case "point", "line", "lseg", "box", "path", "polygon", "circle":
+		return "GEOMETRY"
+	case "xml", "macaddr", "macaddr8", "txid_snapshot", "hstore":
+		return "STRING"
+	default:
+		return pgType
+	}
+}
+
+// columnTypeToCockroachDB resolves the destination column type, including DECIMAL precision and scale
+func columnTypeToCockroachDB(system protos.TypeSystem, column *protos.FieldDescription) string {
+	var crdbType string
+	switch system {
+	case protos.TypeSystem_PG:
+		crdbType = pgTypeToCockroachDBType(column.Type, column.TypeSchemaName)
+	default:
+		crdbType = qValueKindToCockroachDBType(column.Type)
+	}
+	if crdbType == "DECIMAL" || crdbType == "numeric" {
+		if column.TypeModifier != -1 {
+			precision, scale := common.ParseNumericTypmod(column.TypeModifier)
+			return fmt.Sprintf("DECIMAL(%d,%d)", precision, scale)
+		}
+	}
+	return crdbType
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
@@ -395,3 +396,31 @@ func TestParseUUIDArray(t *testing.T) {
 	_, err = parseUUIDArray(42)
 	require.Error(t, err)
 }
+
+func TestColumnTypeToCockroachDB(t *testing.T) {
+	testCases := []struct {
+		system   protos.TypeSystem
+		column   *protos.FieldDescription
+		expected string
+	}{
+		{protos.TypeSystem_Q, &protos.FieldDescription{Type: string(types.QValueKindInt32), TypeModifier: -1}, "INT4"},
+		{protos.TypeSystem_Q, &protos.FieldDescription{Type: string(types.QValueKindUInt32), TypeModifier: -1}, "INT8"},
+		{protos.TypeSystem_Q, &protos.FieldDescription{Type: string(types.QValueKindArrayInt32), TypeModifier: -1}, "INT4[]"},
+		{protos.TypeSystem_Q, &protos.FieldDescription{Type: string(types.QValueKindNumeric), TypeModifier: -1}, "DECIMAL"},
+		{
+			protos.TypeSystem_Q,
+			&protos.FieldDescription{Type: string(types.QValueKindNumeric), TypeModifier: datatypes.MakeNumericTypmod(10, 2)},
+			"DECIMAL(10,2)",
+		},
+		{protos.TypeSystem_Q, &protos.FieldDescription{Type: string(types.QValueKindCIDR), TypeModifier: -1}, "INET"},
+		{protos.TypeSystem_Q, &protos.FieldDescription{Type: string(types.QValueKindHStore), TypeModifier: -1}, "JSONB"},
+		{protos.TypeSystem_PG, &protos.FieldDescription{Type: "int4", TypeModifier: -1}, "int4"},
+		{protos.TypeSystem_PG, &protos.FieldDescription{Type: "_cidr", TypeModifier: -1}, "INET[]"},
+		{protos.TypeSystem_PG, &protos.FieldDescription{Type: "money", TypeModifier: -1}, "DECIMAL"},
+		{protos.TypeSystem_PG, &protos.FieldDescription{Type: "positive_int", TypeSchemaName: "public", TypeModifier: -1}, "STRING"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, columnTypeToCockroachDB(tc.system, tc.column), tc.column.Type)
+	}
+}
@@ -13,17 +13,20 @@ import (
 	connbigquery "github.com/PeerDB-io/peerdb/flow/connectors/bigquery"
 	connclickhouse "github.com/PeerDB-io/peerdb/flow/connectors/clickhouse"
 	conncockroachdb "github.com/PeerDB-io/peerdb/flow/connectors/cockroachdb"
+	conndynamodb "github.com/PeerDB-io/peerdb/flow/connectors/dynamodb"
 	connelasticsearch "github.com/PeerDB-io/peerdb/flow/connectors/elasticsearch"
 	conneventhub "github.com/PeerDB-io/peerdb/flow/connectors/eventhub"
 	connkafka "github.com/PeerDB-io/peerdb/flow/connectors/kafka"
 	connmongo "github.com/PeerDB-io/peerdb/flow/connectors/mongo"
 	connmysql "github.com/PeerDB-io/peerdb/flow/connectors/mysql"
+	connoracle "github.com/PeerDB-io/peerdb/flow/connectors/oracle"
 	connpostgres "github.com/PeerDB-io/peerdb/flow/connectors/postgres"
 	connpubsub "github.com/PeerDB-io/peerdb/flow/connectors/pubsub"
 	conns3 "github.com/PeerDB-io/peerdb/flow/connectors/s3"
 	connsnowflake "github.com/PeerDB-io/peerdb/flow/connectors/snowflake"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/internal/secrets"
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
 	"github.com/PeerDB-io/peerdb/flow/shared"
@@ -57,6 +60,14 @@ type MirrorDestinationValidationConnector interface {
 	ValidateMirrorDestination(context.Context, *protos.FlowConnectionConfigsCore, map[string]*protos.TableSchema) error
 }
 
+type ColumnTypeOverrideValidationConnector interface {
+	Connector
+
+	// ValidateColumnTypeOverrides checks destination type overrides of table mappings against the source schema,
+	// returning a warning for each lossy override.
+	ValidateColumnTypeOverrides(context.Context, []*protos.TableMapping, map[string]*protos.TableSchema) ([]string, error)
+}
+
 type StatActivityConnector interface {
 	Connector
 
@@ -249,6 +260,15 @@ type QRepPullConnector interface {
 	) (int64, int64, error)
 }
 
+// QRepResumablePullConnector is implemented by sources whose PullQRepRecords sets resume points on the stream
+// when asked to, so that a retried partition continues after the records already synced
+type QRepResumablePullConnector interface {
+	QRepPullConnector
+
+	// SupportsQRepResumePoints returns true if resume points can be set while pulling the partition
+	SupportsQRepResumePoints(*protos.QRepConfig, *protos.QRepPartition) bool
+}
+
 type QRepSyncConnectorCore interface {
 	Connector
 
@@ -268,6 +288,15 @@ type QRepSyncConnector interface {
 		stream *model.QRecordStream) (int64, shared.QRepWarnings, error)
 }
 
+// QRepDeduplicatingSyncConnector is implemented by destinations whose tables may collapse rows synced again,
+// which lets checkpointed partitions re-sync a chunk in append mode
+type QRepDeduplicatingSyncConnector interface {
+	QRepSyncConnector
+
+	// DeduplicatesQRepRecords returns true if rows synced again to the destination table replace the earlier ones
+	DeduplicatesQRepRecords(ctx context.Context, config *protos.QRepConfig) (bool, error)
+}
+
 type QRepPullObjectsConnector interface {
 	QRepPullConnectorCore
 
@@ -547,6 +576,18 @@ func BuildPeerConfig(ctx context.Context, encKeyID string, encPeerOptions []byte
 			return nil, fmt.Errorf("failed to unmarshal CockroachDB config: %w", err)
 		}
 		peer.Config = &protos.Peer_CockroachdbConfig{CockroachdbConfig: &config}
+	case protos.DBType_ORACLE:
+		var config protos.OracleConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal Oracle config: %w", err)
+		}
+		peer.Config = &protos.Peer_OracleConfig{OracleConfig: &config}
+	case protos.DBType_DYNAMODB:
+		var config protos.DynamoDBConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal DynamoDB config: %w", err)
+		}
+		peer.Config = &protos.Peer_DynamodbConfig{DynamodbConfig: &config}
 	default:
 		return nil, fmt.Errorf("unsupported peer type: %s", dbType)
 	}
@@ -566,6 +607,10 @@ func GetConnector(ctx context.Context, env map[string]string, config *protos.Pee
 }
 
 func getConnector(ctx context.Context, env map[string]string, config *protos.Peer, cdcDestinationType protos.DBType) (Connector, error) {
+	config, err := secrets.ResolvePeer(ctx, env, config)
+	if err != nil {
+		return nil, err
+	}
 	switch inner := config.Config.(type) {
 	case *protos.Peer_PostgresConfig:
 		return connpostgres.NewPostgresConnectorWithCDCDestination(ctx, env, inner.PostgresConfig, cdcDestinationType)
@@ -591,6 +636,10 @@ func getConnector(ctx context.Context, env map[string]string, config *protos.Pee
 		return connelasticsearch.NewElasticsearchConnector(ctx, inner.ElasticsearchConfig)
 	case *protos.Peer_CockroachdbConfig:
 		return conncockroachdb.NewCockroachDBConnector(ctx, env, inner.CockroachdbConfig)
+	case *protos.Peer_OracleConfig:
+		return connoracle.NewOracleConnector(ctx, inner.OracleConfig)
+	case *protos.Peer_DynamodbConfig:
+		return conndynamodb.NewDynamoDBConnector(ctx, inner.DynamodbConfig)
 	default:
 		return nil, errors.ErrUnsupported
 	}
@@ -688,6 +737,27 @@ func GetPostgresConnectorByName(
 	return GetAs[*connpostgres.PostgresConnector](ctx, env, peer)
 }
 
+// Gets connector by name connected to the peer's read replica, erroring if it has none.
+// Returns a close function to recruit the compiler into helping us avoid connection leaks.
+func GetReplicaByNameAs[T Connector](
+	ctx context.Context, env map[string]string, catalogPool shared.CatalogPool, name string,
+) (T, func(context.Context), error) {
+	var none T
+	peer, err := LoadPeer(ctx, catalogPool, name)
+	if err != nil {
+		return none, noopClose, err
+	}
+	replicaConfig := connpostgres.ReplicaConfig(peer.GetPostgresConfig())
+	if replicaConfig == nil {
+		return none, noopClose, fmt.Errorf("peer %s has no read replica configured", name)
+	}
+	return GetAs[T](ctx, env, &protos.Peer{
+		Name:   peer.Name,
+		Type:   peer.Type,
+		Config: &protos.Peer_PostgresConfig{PostgresConfig: replicaConfig},
+	})
+}
+
 // create type assertions to cause compile time error if connector interface not implemented
 var (
 	_ CDCPullConnector = &connpostgres.PostgresConnector{}
@@ -705,6 +775,7 @@ var (
 	_ CDCSyncConnector = &conns3.S3Connector{}
 	_ CDCSyncConnector = &connclickhouse.ClickHouseConnector{}
 	_ CDCSyncConnector = &connelasticsearch.ElasticsearchConnector{}
+	_ CDCSyncConnector = &connmongo.MongoConnector{}
 
 	_ CDCSyncPgConnector = &connpostgres.PostgresConnector{}
 
@@ -732,6 +803,7 @@ var (
 	_ NormalizedTablesConnector = &connbigquery.BigQueryConnector{}
 	_ NormalizedTablesConnector = &connsnowflake.SnowflakeConnector{}
 	_ NormalizedTablesConnector = &connclickhouse.ClickHouseConnector{}
+	_ NormalizedTablesConnector = &connelasticsearch.ElasticsearchConnector{}
 
 	_ CreateTablesFromExistingConnector = &connbigquery.BigQueryConnector{}
 	_ CreateTablesFromExistingConnector = &connsnowflake.SnowflakeConnector{}
@@ -741,6 +813,9 @@ var (
 	_ QRepPullConnector = &connmongo.MongoConnector{}
 	_ QRepPullConnector = &conncockroachdb.CockroachDBConnector{}
 
+	_ QRepResumablePullConnector = &connpostgres.PostgresConnector{}
+	_ QRepResumablePullConnector = &connmongo.MongoConnector{}
+
 	_ QRepSyncConnector = &connpostgres.PostgresConnector{}
 	_ QRepSyncConnector = &connbigquery.BigQueryConnector{}
 	_ QRepSyncConnector = &connsnowflake.SnowflakeConnector{}
@@ -749,6 +824,9 @@ var (
 	_ QRepSyncConnector = &connclickhouse.ClickHouseConnector{}
 	_ QRepSyncConnector = &connelasticsearch.ElasticsearchConnector{}
 	_ QRepSyncConnector = &connpubsub.PubSubConnector{}
+	_ QRepSyncConnector = &connmongo.MongoConnector{}
+
+	_ QRepDeduplicatingSyncConnector = &connclickhouse.ClickHouseConnector{}
 
 	_ QRepSyncPgConnector = &connpostgres.PostgresConnector{}
 
@@ -762,6 +840,7 @@ var (
 	_ RenameTablesWithSoftDeleteConnector = &connbigquery.BigQueryConnector{}
 	_ RenameTablesWithSoftDeleteConnector = &connpostgres.PostgresConnector{}
 	_ RenameTablesConnector               = &connclickhouse.ClickHouseConnector{}
+	_ RenameTablesConnector               = &connelasticsearch.ElasticsearchConnector{}
 
 	_ RawTableConnector = &connclickhouse.ClickHouseConnector{}
 	_ RawTableConnector = &connbigquery.BigQueryConnector{}
@@ -785,6 +864,9 @@ var (
 	_ MirrorDestinationValidationConnector = &connpostgres.PostgresConnector{}
 	_ MirrorDestinationValidationConnector = &connbigquery.BigQueryConnector{}
 
+	_ ColumnTypeOverrideValidationConnector = &connclickhouse.ClickHouseConnector{}
+	_ ColumnTypeOverrideValidationConnector = &connelasticsearch.ElasticsearchConnector{}
+
 	_ GetFlagsConnector = &connclickhouse.ClickHouseConnector{}
 
 	_ GetVersionConnector = &connclickhouse.ClickHouseConnector{}
@@ -818,4 +900,23 @@ var (
 	_ GetSchemaConnector              = &conncockroachdb.CockroachDBConnector{}
 	_ CDCPullConnector                = &conncockroachdb.CockroachDBConnector{}
 	_ MirrorSourceValidationConnector = &conncockroachdb.CockroachDBConnector{}
+	_ CDCSyncConnector                = &conncockroachdb.CockroachDBConnector{}
+	_ CDCNormalizeConnector           = &conncockroachdb.CockroachDBConnector{}
+	_ NormalizedTablesConnector       = &conncockroachdb.CockroachDBConnector{}
+	_ QRepSyncConnector               = &conncockroachdb.CockroachDBConnector{}
+
+	_ ValidationConnector             = &connoracle.OracleConnector{}
+	_ GetVersionConnector             = &connoracle.OracleConnector{}
+	_ GetTableSchemaConnector         = &connoracle.OracleConnector{}
+	_ GetSchemaConnector              = &connoracle.OracleConnector{}
+	_ CDCPullConnector                = &connoracle.OracleConnector{}
+	_ MirrorSourceValidationConnector = &connoracle.OracleConnector{}
+	_ QRepPullConnector               = &connoracle.OracleConnector{}
+
+	_ ValidationConnector             = &conndynamodb.DynamoDBConnector{}
+	_ GetTableSchemaConnector         = &conndynamodb.DynamoDBConnector{}
+	_ GetSchemaConnector              = &conndynamodb.DynamoDBConnector{}
+	_ CDCPullConnector                = &conndynamodb.DynamoDBConnector{}
+	_ MirrorSourceValidationConnector = &conndynamodb.DynamoDBConnector{}
+	_ QRepPullConnector               = &conndynamodb.DynamoDBConnector{}
 )
@@ -0,0 +1,106 @@
+package conndynamodb
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// attributeToJSONValue converts an attribute value into what encoding/json renders as
+// the natural JSON of the attribute: numbers stay exact via json.Number, binary is base64
+// and sets become arrays, the type tags DynamoDB JSON carries are dropped.
+func attributeToJSONValue(av ddbtypes.AttributeValue) (any, error) {
+	switch v := av.(type) {
+	case *ddbtypes.AttributeValueMemberS:
+		return v.Value, nil
+	case *ddbtypes.AttributeValueMemberN:
+		return json.Number(v.Value), nil
+	case *ddbtypes.AttributeValueMemberB:
+		// []byte marshals as base64
+		return v.Value, nil
+	case *ddbtypes.AttributeValueMemberBOOL:
+		return v.Value, nil
+	case *ddbtypes.AttributeValueMemberNULL:
+		return nil, nil
+	case *ddbtypes.AttributeValueMemberM:
+		return attributesToJSONValue(v.Value)
+	case *ddbtypes.AttributeValueMemberL:
+		list := make([]any, 0, len(v.Value))
+		for _, item := range v.Value {
+			converted, err := attributeToJSONValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, converted)
+		}
+		return list, nil
+	case *ddbtypes.AttributeValueMemberSS:
+		return v.Value, nil
+	case *ddbtypes.AttributeValueMemberNS:
+		numbers := make([]json.Number, 0, len(v.Value))
+		for _, n := range v.Value {
+			numbers = append(numbers, json.Number(n))
+		}
+		return numbers, nil
+	case *ddbtypes.AttributeValueMemberBS:
+		return v.Value, nil
+	default:
+		return nil, fmt.Errorf("unsupported DynamoDB attribute value %T", av)
+	}
+}
+
+func attributesToJSONValue(item map[string]ddbtypes.AttributeValue) (map[string]any, error) {
+	object := make(map[string]any, len(item))
+	for name, av := range item {
+		converted, err := attributeToJSONValue(av)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert attribute %s: %w", name, err)
+		}
+		object[name] = converted
+	}
+	return object, nil
+}
+
+// itemToJSON renders a whole item for the document column, encoding/json sorts object keys
+// so the same item always renders the same way
+func itemToJSON(item map[string]ddbtypes.AttributeValue) (types.QValueJSON, error) {
+	object, err := attributesToJSONValue(item)
+	if err != nil {
+		return types.QValueJSON{}, err
+	}
+	raw, err := json.Marshal(object)
+	if err != nil {
+		return types.QValueJSON{}, fmt.Errorf("failed to marshal item: %w", err)
+	}
+	return types.QValueJSON{Val: string(raw)}, nil
+}
+
+// keyAttributeToQValue converts a key attribute of the type declared in the table's
+// attribute definitions, keys can only be strings, numbers or binary
+func keyAttributeToQValue(column keyColumn, av ddbtypes.AttributeValue) (types.QValue, error) {
+	switch v := av.(type) {
+	case *ddbtypes.AttributeValueMemberS:
+		if column.qkind == types.QValueKindString {
+			return types.QValueString{Val: v.Value}, nil
+		}
+	case *ddbtypes.AttributeValueMemberN:
+		if column.qkind == types.QValueKindNumeric {
+			num, err := decimal.NewFromString(v.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q in key attribute %s: %w", v.Value, column.name, err)
+			}
+			return types.QValueNumeric{Val: num}, nil
+		}
+	case *ddbtypes.AttributeValueMemberB:
+		if column.qkind == types.QValueKindBytes {
+			return types.QValueBytes{Val: v.Value}, nil
+		}
+	case nil:
+		return nil, fmt.Errorf("item is missing key attribute %s", column.name)
+	}
+	return nil, fmt.Errorf("key attribute %s holds %T, expected %s", column.name, av, column.qkind)
+}
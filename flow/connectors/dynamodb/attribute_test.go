@@ -0,0 +1,94 @@
+package conndynamodb
+
+import (
+	"testing"
+
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestItemToJSON(t *testing.T) {
+	t.Parallel()
+
+	doc, err := itemToJSON(map[string]ddbtypes.AttributeValue{
+		"id":      &ddbtypes.AttributeValueMemberS{Value: "a1"},
+		"price":   &ddbtypes.AttributeValueMemberN{Value: "12345678901234567890.5"},
+		"raw":     &ddbtypes.AttributeValueMemberB{Value: []byte{0x0a, 0xff}},
+		"active":  &ddbtypes.AttributeValueMemberBOOL{Value: true},
+		"deleted": &ddbtypes.AttributeValueMemberNULL{Value: true},
+		"tags":    &ddbtypes.AttributeValueMemberSS{Value: []string{"x", "y"}},
+		"sizes":   &ddbtypes.AttributeValueMemberNS{Value: []string{"1", "2.5"}},
+		"nested": &ddbtypes.AttributeValueMemberM{Value: map[string]ddbtypes.AttributeValue{
+			"list": &ddbtypes.AttributeValueMemberL{Value: []ddbtypes.AttributeValue{
+				&ddbtypes.AttributeValueMemberN{Value: "1"},
+				&ddbtypes.AttributeValueMemberS{Value: "two"},
+			}},
+		}},
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"id": "a1",
+		"price": 12345678901234567890.5,
+		"raw": "Cv8=",
+		"active": true,
+		"deleted": null,
+		"tags": ["x", "y"],
+		"sizes": [1, 2.5],
+		"nested": {"list": [1, "two"]}
+	}`, doc.Val)
+	// numbers must keep every digit rather than round trip through float64
+	require.Contains(t, doc.Val, "12345678901234567890.5")
+}
+
+func TestKeyAttributeToQValue(t *testing.T) {
+	t.Parallel()
+
+	qv, err := keyAttributeToQValue(keyColumn{name: "pk", qkind: types.QValueKindNumeric},
+		&ddbtypes.AttributeValueMemberN{Value: "42.10"})
+	require.NoError(t, err)
+	require.Equal(t, types.QValueNumeric{Val: decimal.RequireFromString("42.10")}, qv)
+
+	qv, err = keyAttributeToQValue(keyColumn{name: "sk", qkind: types.QValueKindBytes},
+		&ddbtypes.AttributeValueMemberB{Value: []byte{1, 2}})
+	require.NoError(t, err)
+	require.Equal(t, types.QValueBytes{Val: []byte{1, 2}}, qv)
+
+	_, err = keyAttributeToQValue(keyColumn{name: "pk", qkind: types.QValueKindString},
+		&ddbtypes.AttributeValueMemberN{Value: "1"})
+	require.Error(t, err)
+
+	_, err = keyAttributeToQValue(keyColumn{name: "pk", qkind: types.QValueKindString}, nil)
+	require.Error(t, err)
+}
+
+func TestKeyColumns(t *testing.T) {
+	t.Parallel()
+
+	name := func(s string) *string { return &s }
+	columns, err := keyColumns(&ddbtypes.TableDescription{
+		TableName: name("orders"),
+		KeySchema: []ddbtypes.KeySchemaElement{
+			{AttributeName: name("createdAt"), KeyType: ddbtypes.KeyTypeRange},
+			{AttributeName: name("customer"), KeyType: ddbtypes.KeyTypeHash},
+		},
+		AttributeDefinitions: []ddbtypes.AttributeDefinition{
+			{AttributeName: name("customer"), AttributeType: ddbtypes.ScalarAttributeTypeS},
+			{AttributeName: name("createdAt"), AttributeType: ddbtypes.ScalarAttributeTypeN},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []keyColumn{
+		{name: "customer", attributeType: ddbtypes.ScalarAttributeTypeS, qkind: types.QValueKindString},
+		{name: "createdAt", attributeType: ddbtypes.ScalarAttributeTypeN, qkind: types.QValueKindNumeric},
+	}, columns)
+
+	_, err = keyColumns(&ddbtypes.TableDescription{
+		TableName:            name("docs"),
+		KeySchema:            []ddbtypes.KeySchemaElement{{AttributeName: name("doc"), KeyType: ddbtypes.KeyTypeHash}},
+		AttributeDefinitions: []ddbtypes.AttributeDefinition{{AttributeName: name("doc"), AttributeType: ddbtypes.ScalarAttributeTypeS}},
+	})
+	require.Error(t, err)
+}
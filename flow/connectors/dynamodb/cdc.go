@@ -0,0 +1,649 @@
+package conndynamodb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	streamtypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
+	"github.com/PeerDB-io/peerdb/flow/pkg/common"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+const (
+	// streamPollInterval is the pause between rounds over all shards once none returned records,
+	// GetRecords is limited to five calls per second per shard
+	streamPollInterval = time.Second
+	// minStreamRoundInterval keeps busy rounds over few shards under the GetRecords limit
+	minStreamRoundInterval = 250 * time.Millisecond
+	// shardRefreshInterval is how often shard listings are refreshed to pick up new shards,
+	// DynamoDB rolls shards over every few hours and splits them as throughput grows
+	shardRefreshInterval = time.Minute
+	// setupReplicationMargin is subtracted from the time SetupReplication starts at, records
+	// older than that are covered by the initial snapshot. Stream record times are approximate
+	// and replaying a change the snapshot already saw converges to the same item.
+	setupReplicationMargin = time.Minute
+)
+
+// streamCheckpoint is the CDC position of a mirror, stored as JSON in the checkpoint text.
+// Stream shards form a tree that rolls over every few hours, so the position is kept per
+// table as the last sequence number processed in each partially read shard plus the closed
+// shards read to the end, whose children can be read next.
+type streamCheckpoint struct {
+	Tables map[string]*tableStreamCheckpoint `json:"tables"`
+}
+
+type tableStreamCheckpoint struct {
+	// Shards maps partially read shards to the sequence number of their last processed record
+	Shards    map[string]string `json:"shards,omitempty"`
+	StreamArn string            `json:"streamArn"`
+	// Done lists closed shards read to the end, pruned once DynamoDB trims them
+	Done []string `json:"done,omitempty"`
+	// StartAfter skips records created before this unix time, they are covered by the initial snapshot
+	StartAfter int64 `json:"startAfter,omitempty"`
+}
+
+func parseStreamCheckpoint(text string) (*streamCheckpoint, error) {
+	checkpoint := &streamCheckpoint{}
+	if text != "" {
+		if err := json.Unmarshal([]byte(text), checkpoint); err != nil {
+			return nil, fmt.Errorf("invalid DynamoDB stream checkpoint: %w", err)
+		}
+	}
+	if checkpoint.Tables == nil {
+		checkpoint.Tables = make(map[string]*tableStreamCheckpoint)
+	}
+	for _, table := range checkpoint.Tables {
+		if table.Shards == nil {
+			table.Shards = make(map[string]string)
+		}
+	}
+	return checkpoint, nil
+}
+
+func (cp *streamCheckpoint) String() string {
+	// marshalling maps of strings cannot fail
+	raw, _ := json.Marshal(cp)
+	return string(raw)
+}
+
+// readyShards lists the shards of a stream that can be read now, in listing order: shards not
+// read to the end whose parent is read to the end or already trimmed. Records of an item only
+// move to a child shard once its parent is closed, reading parents first keeps them ordered.
+func readyShards(shards []streamtypes.Shard, done map[string]struct{}) []string {
+	listed := make(map[string]struct{}, len(shards))
+	for _, shard := range shards {
+		listed[aws.ToString(shard.ShardId)] = struct{}{}
+	}
+	var ready []string
+	for _, shard := range shards {
+		shardID := aws.ToString(shard.ShardId)
+		if _, ok := done[shardID]; ok {
+			continue
+		}
+		if parentID := aws.ToString(shard.ParentShardId); parentID != "" {
+			_, parentListed := listed[parentID]
+			_, parentDone := done[parentID]
+			if parentListed && !parentDone {
+				continue
+			}
+		}
+		ready = append(ready, shardID)
+	}
+	return ready
+}
+
+func (c *DynamoDBConnector) EnsurePullability(
+	ctx context.Context, req *protos.EnsurePullabilityBatchInput,
+) (*protos.EnsurePullabilityBatchOutput, error) {
+	return nil, nil
+}
+
+func (c *DynamoDBConnector) ExportTxSnapshot(context.Context, string, map[string]string) (*protos.ExportTxSnapshotOutput, any, error) {
+	// DynamoDB has no snapshot reads, each segment scan sees the table as it goes
+	return nil, nil, nil
+}
+
+func (c *DynamoDBConnector) FinishExport(any) error {
+	return nil
+}
+
+// SetupReplication records the stream of every table and the time the initial snapshot
+// starts at, CDC reads each stream from its oldest retained record and skips what is older.
+func (c *DynamoDBConnector) SetupReplication(
+	ctx context.Context,
+	catalogPool shared.CatalogPool,
+	req *protos.SetupReplicationInput,
+) (model.SetupReplicationResult, error) {
+	checkpoint := &streamCheckpoint{Tables: make(map[string]*tableStreamCheckpoint, len(req.TableNameMapping))}
+	startAfter := time.Now().Add(-setupReplicationMargin).Unix()
+	for source := range req.TableNameMapping {
+		table, err := c.parseTable(source)
+		if err != nil {
+			return model.SetupReplicationResult{}, err
+		}
+		description, err := c.describeTable(ctx, table)
+		if err != nil {
+			return model.SetupReplicationResult{}, err
+		}
+		if !canStream(description) {
+			return model.SetupReplicationResult{}, fmt.Errorf(
+				"table %s needs a stream with NEW_IMAGE or NEW_AND_OLD_IMAGES for CDC", source)
+		}
+		checkpoint.Tables[source] = &tableStreamCheckpoint{
+			StreamArn:  aws.ToString(description.LatestStreamArn),
+			StartAfter: startAfter,
+		}
+	}
+	if err := c.SetLastOffset(ctx, req.FlowJobName, model.CdcCheckpoint{Text: checkpoint.String()}); err != nil {
+		return model.SetupReplicationResult{}, fmt.Errorf("failed to store initial stream checkpoint: %w", err)
+	}
+	c.logger.Info("[dynamodb] SetupReplication stored initial stream checkpoint", slog.Int("tables", len(checkpoint.Tables)))
+	return model.SetupReplicationResult{}, nil
+}
+
+func (c *DynamoDBConnector) SetupReplConn(context.Context, map[string]string) error {
+	// shard iterators are acquired per PullRecords call
+	return nil
+}
+
+func (c *DynamoDBConnector) UpdateReplStateLastOffset(ctx context.Context, lastOffset model.CdcCheckpoint) error {
+	if lastOffset.Text == "" {
+		return nil
+	}
+	flowName := ctx.Value(shared.FlowNameKey).(string)
+	return c.SetLastOffset(ctx, flowName, lastOffset)
+}
+
+func (c *DynamoDBConnector) PullFlowCleanup(ctx context.Context, jobName string) error {
+	// streams keep no per reader state
+	return nil
+}
+
+// tableStream is the per source table state of PullRecords
+type tableStream struct {
+	checkpoint *tableStreamCheckpoint
+	done       map[string]struct{}
+	iterators  map[string]*string
+	listedAt   time.Time
+	source     string
+	table      string
+	columns    []keyColumn
+	shards     []streamtypes.Shard
+}
+
+//nolint:govet // keeping related fields together over alignment
+type streamPullState struct {
+	req           *model.PullRecordsRequest[model.RecordItems]
+	checkpoint    *streamCheckpoint
+	tables        []*tableStream
+	recordCount   uint32
+	batchDeadline time.Time
+	done          bool
+	deltaBytes    atomic.Int64
+	totalBytes    atomic.Int64
+}
+
+func (state *streamPullState) updateCheckpoint() {
+	state.req.RecordStream.UpdateLatestCheckpointText(state.checkpoint.String())
+}
+
+func (c *DynamoDBConnector) PullRecords(
+	ctx context.Context,
+	catalogPool shared.CatalogPool,
+	otelManager *otel_metrics.OtelManager,
+	req *model.PullRecordsRequest[model.RecordItems],
+) error {
+	defer req.RecordStream.Close()
+
+	checkpoint, err := parseStreamCheckpoint(req.LastOffset.Text)
+	if err != nil {
+		return err
+	}
+	state := &streamPullState{
+		req:        req,
+		checkpoint: checkpoint,
+		tables:     make([]*tableStream, 0, len(req.TableNameMapping)),
+	}
+	for _, source := range slices.Sorted(maps.Keys(req.TableNameMapping)) {
+		ts, err := c.newTableStream(ctx, checkpoint, source)
+		if err != nil {
+			return err
+		}
+		state.tables = append(state.tables, ts)
+	}
+	// drop tables removed from the mirror
+	maps.DeleteFunc(checkpoint.Tables, func(source string, _ *tableStreamCheckpoint) bool {
+		_, ok := req.TableNameMapping[source]
+		return !ok
+	})
+	// seed the checkpoint so a batch without records re-persists the prior one instead of clearing it
+	state.updateCheckpoint()
+
+	c.logger.Info("[dynamodb] started PullRecords for mirror "+req.FlowJobName,
+		slog.Int("tables", len(state.tables)),
+		slog.Uint64("maxBatchSize", uint64(req.MaxBatchSize)),
+		slog.Duration("syncInterval", req.IdleTimeout))
+
+	pullStart := time.Now()
+	defer func() {
+		if state.recordCount == 0 {
+			req.RecordStream.SignalAsEmpty()
+		}
+		span := trace.SpanFromContext(ctx)
+		span.SetAttributes(
+			attribute.Int64(otel_metrics.RowsInBatchKey, int64(state.recordCount)),
+			attribute.Int64(otel_metrics.BytesPulledKey, state.totalBytes.Load()),
+		)
+		c.logger.Info("[dynamodb] PullRecords finished streaming",
+			slog.Uint64("records", uint64(state.recordCount)),
+			slog.Int64("bytes", state.totalBytes.Load()),
+			slog.Float64("elapsedMinutes", time.Since(pullStart).Minutes()))
+	}()
+
+	reportBytesShutdown := common.Interval(ctx, 10*time.Second, func() {
+		read := state.deltaBytes.Swap(0)
+		otelManager.Metrics.FetchedBytesCounter.Add(ctx, read)
+		otelManager.Metrics.AllFetchedBytesCounter.Add(ctx, read)
+	})
+	defer func() {
+		reportBytesShutdown()
+		read := state.deltaBytes.Swap(0)
+		otelManager.Metrics.FetchedBytesCounter.Add(ctx, read)
+		otelManager.Metrics.AllFetchedBytesCounter.Add(ctx, read)
+	}()
+
+	persistedCheckpoint := req.LastOffset.Text
+	for {
+		roundStart := time.Now()
+		progressed := false
+		for _, ts := range state.tables {
+			read, err := c.readTableStream(ctx, otelManager, state, ts)
+			if err != nil {
+				return err
+			}
+			progressed = progressed || read
+			if state.done {
+				return nil
+			}
+		}
+
+		if state.recordCount > 0 && !time.Now().Before(state.batchDeadline) {
+			return nil
+		}
+		if state.recordCount == 0 {
+			// nothing handed to the sync flow yet, safe to persist directly so an idle mirror
+			// keeps up with shard rollovers instead of replaying them after a restart
+			if text := state.checkpoint.String(); text != persistedCheckpoint {
+				if err := c.SetLastOffset(ctx, req.FlowJobName, model.CdcCheckpoint{Text: text}); err != nil {
+					c.logger.Error("[dynamodb] failed to persist stream checkpoint", slog.Any("error", err))
+				} else {
+					persistedCheckpoint = text
+				}
+			}
+		}
+
+		wait := streamPollInterval
+		if progressed {
+			wait = minStreamRoundInterval - time.Since(roundStart)
+		}
+		if wait > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+	}
+}
+
+func (c *DynamoDBConnector) newTableStream(
+	ctx context.Context,
+	checkpoint *streamCheckpoint,
+	source string,
+) (*tableStream, error) {
+	table, err := c.parseTable(source)
+	if err != nil {
+		return nil, err
+	}
+	description, err := c.describeTable(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := keyColumns(description)
+	if err != nil {
+		return nil, err
+	}
+	if !canStream(description) {
+		return nil, exceptions.NewDynamoDBStreamHistoryLostError(source,
+			"the table's stream was disabled or no longer carries new item images")
+	}
+	streamArn := aws.ToString(description.LatestStreamArn)
+
+	tableCheckpoint, ok := checkpoint.Tables[source]
+	if !ok {
+		// added to the mirror after SetupReplication, replay everything the stream retains
+		c.logger.Info("[dynamodb] no stream checkpoint for table, reading its stream from the start",
+			slog.String("table", source))
+		tableCheckpoint = &tableStreamCheckpoint{StreamArn: streamArn, Shards: make(map[string]string)}
+		checkpoint.Tables[source] = tableCheckpoint
+	} else if tableCheckpoint.StreamArn != streamArn {
+		return nil, exceptions.NewDynamoDBStreamHistoryLostError(source,
+			"the table's stream was replaced, changes between the old and the new stream were not captured")
+	}
+
+	done := make(map[string]struct{}, len(tableCheckpoint.Done))
+	for _, shardID := range tableCheckpoint.Done {
+		done[shardID] = struct{}{}
+	}
+	return &tableStream{
+		checkpoint: tableCheckpoint,
+		done:       done,
+		iterators:  make(map[string]*string),
+		source:     source,
+		table:      table,
+		columns:    columns,
+	}, nil
+}
+
+func (c *DynamoDBConnector) listShards(ctx context.Context, streamArn string) ([]streamtypes.Shard, error) {
+	var shards []streamtypes.Shard
+	input := &dynamodbstreams.DescribeStreamInput{StreamArn: aws.String(streamArn)}
+	for {
+		output, err := c.streams.DescribeStream(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe stream %s: %w", streamArn, err)
+		}
+		shards = append(shards, output.StreamDescription.Shards...)
+		if output.StreamDescription.LastEvaluatedShardId == nil {
+			return shards, nil
+		}
+		input.ExclusiveStartShardId = output.StreamDescription.LastEvaluatedShardId
+	}
+}
+
+// refreshShards lists the shards of a table's stream and reconciles the checkpoint with it:
+// done shards DynamoDB trimmed are forgotten, while trimming a partially read shard means
+// records were never read
+func (c *DynamoDBConnector) refreshShards(ctx context.Context, ts *tableStream) error {
+	shards, err := c.listShards(ctx, ts.checkpoint.StreamArn)
+	if err != nil {
+		if _, ok := errors.AsType[*streamtypes.ResourceNotFoundException](err); ok {
+			return exceptions.NewDynamoDBStreamHistoryLostError(ts.source, "the table's stream no longer exists")
+		}
+		return err
+	}
+	listed := make(map[string]struct{}, len(shards))
+	for _, shard := range shards {
+		listed[aws.ToString(shard.ShardId)] = struct{}{}
+	}
+	for shardID := range ts.checkpoint.Shards {
+		if _, ok := listed[shardID]; !ok {
+			return exceptions.NewDynamoDBStreamHistoryLostError(ts.source,
+				fmt.Sprintf("shard %s was trimmed before it was read to the end", shardID))
+		}
+	}
+	maps.DeleteFunc(ts.done, func(shardID string, _ struct{}) bool {
+		_, ok := listed[shardID]
+		return !ok
+	})
+	ts.checkpoint.Done = slices.Sorted(maps.Keys(ts.done))
+	ts.shards = shards
+	ts.listedAt = time.Now()
+	return nil
+}
+
+func (c *DynamoDBConnector) shardIterator(ctx context.Context, ts *tableStream, shardID string) (*string, error) {
+	input := &dynamodbstreams.GetShardIteratorInput{
+		StreamArn:         aws.String(ts.checkpoint.StreamArn),
+		ShardId:           aws.String(shardID),
+		ShardIteratorType: streamtypes.ShardIteratorTypeTrimHorizon,
+	}
+	if sequenceNumber, ok := ts.checkpoint.Shards[shardID]; ok {
+		input.ShardIteratorType = streamtypes.ShardIteratorTypeAfterSequenceNumber
+		input.SequenceNumber = aws.String(sequenceNumber)
+	}
+	output, err := c.streams.GetShardIterator(ctx, input)
+	if err != nil {
+		if _, ok := errors.AsType[*streamtypes.TrimmedDataAccessException](err); ok {
+			return nil, exceptions.NewDynamoDBStreamHistoryLostError(ts.source,
+				fmt.Sprintf("records of shard %s were trimmed before they were read", shardID))
+		}
+		return nil, fmt.Errorf("failed to get iterator for shard %s of %s: %w", shardID, ts.source, err)
+	}
+	return output.ShardIterator, nil
+}
+
+// readTableStream reads one page from every shard of a table that is ready,
+// reporting whether any page carried records
+func (c *DynamoDBConnector) readTableStream(
+	ctx context.Context,
+	otelManager *otel_metrics.OtelManager,
+	state *streamPullState,
+	ts *tableStream,
+) (bool, error) {
+	if ts.shards == nil || time.Since(ts.listedAt) >= shardRefreshInterval {
+		if err := c.refreshShards(ctx, ts); err != nil {
+			return false, err
+		}
+	}
+
+	progressed := false
+	for _, shardID := range readyShards(ts.shards, ts.done) {
+		iterator, ok := ts.iterators[shardID]
+		if !ok {
+			var err error
+			if iterator, err = c.shardIterator(ctx, ts, shardID); err != nil {
+				return progressed, err
+			}
+		}
+		if iterator == nil {
+			// closed and read to the end before this call
+			c.markShardDone(ts, shardID)
+			continue
+		}
+
+		output, err := c.streams.GetRecords(ctx, &dynamodbstreams.GetRecordsInput{
+			ShardIterator: iterator,
+			Limit:         aws.Int32(int32(min(1000, state.req.MaxBatchSize-state.recordCount))),
+		})
+		if err != nil {
+			if _, ok := errors.AsType[*streamtypes.ExpiredIteratorException](err); ok {
+				// iterators expire after 15 minutes, acquire a new one next round
+				delete(ts.iterators, shardID)
+				continue
+			}
+			if _, ok := errors.AsType[*streamtypes.TrimmedDataAccessException](err); ok {
+				return progressed, exceptions.NewDynamoDBStreamHistoryLostError(ts.source,
+					fmt.Sprintf("records of shard %s were trimmed before they were read", shardID))
+			}
+			return progressed, fmt.Errorf("failed to read shard %s of %s: %w", shardID, ts.source, err)
+		}
+
+		for _, record := range output.Records {
+			if err := c.processStreamRecord(ctx, otelManager, state, ts, record); err != nil {
+				return progressed, err
+			}
+			ts.checkpoint.Shards[shardID] = aws.ToString(record.Dynamodb.SequenceNumber)
+			if state.recordCount >= state.req.MaxBatchSize {
+				// full batch: the rest of the page is read again after the last processed record
+				state.done = true
+				state.updateCheckpoint()
+				return true, nil
+			}
+		}
+		progressed = progressed || len(output.Records) > 0
+
+		if output.NextShardIterator == nil {
+			// closed shard read to the end, its children become ready
+			c.markShardDone(ts, shardID)
+		} else {
+			ts.iterators[shardID] = output.NextShardIterator
+		}
+		state.updateCheckpoint()
+	}
+	return progressed, nil
+}
+
+func (c *DynamoDBConnector) markShardDone(ts *tableStream, shardID string) {
+	delete(ts.iterators, shardID)
+	delete(ts.checkpoint.Shards, shardID)
+	ts.done[shardID] = struct{}{}
+	ts.checkpoint.Done = slices.Sorted(maps.Keys(ts.done))
+	c.logger.Info("[dynamodb] finished reading closed shard",
+		slog.String("table", ts.source), slog.String("shard", shardID))
+}
+
+func (c *DynamoDBConnector) processStreamRecord(
+	ctx context.Context,
+	otelManager *otel_metrics.OtelManager,
+	state *streamPullState,
+	ts *tableStream,
+	record streamtypes.Record,
+) error {
+	change := record.Dynamodb
+	if change == nil {
+		return nil
+	}
+	sizeBytes := aws.ToInt64(change.SizeBytes)
+	state.deltaBytes.Add(sizeBytes)
+	state.totalBytes.Add(sizeBytes)
+
+	createdAt := aws.ToTime(change.ApproximateCreationDateTime)
+	if createdAt.Unix() < ts.checkpoint.StartAfter {
+		// covered by the initial snapshot
+		return nil
+	}
+
+	converted, err := c.buildRecord(state, ts, record)
+	if err != nil {
+		return fmt.Errorf("failed to convert stream record %s of %s: %w",
+			aws.ToString(change.SequenceNumber), ts.source, err)
+	}
+	if err := state.req.RecordStream.AddRecord(ctx, converted); err != nil {
+		return err
+	}
+	state.recordCount++
+	if state.recordCount == 1 {
+		state.req.RecordStream.SignalAsNotEmpty()
+		state.batchDeadline = time.Now().Add(state.req.IdleTimeout)
+	}
+	if state.recordCount%pullProgressLogInterval == 0 {
+		c.logger.Info("[dynamodb] PullRecords streaming",
+			slog.Uint64("records", uint64(state.recordCount)),
+			slog.Int64("bytes", state.totalBytes.Load()))
+	}
+	otelManager.Metrics.LatestConsumedLogEventGauge.Record(ctx, createdAt.Unix())
+	otelManager.Metrics.SourceLagGauge.Record(ctx, time.Since(createdAt).Milliseconds())
+	return nil
+}
+
+// streamImageItems projects the keys and renders an item image, an absent image
+// renders as an empty document like mongo deletes do
+func streamImageItems(
+	columns []keyColumn,
+	keys map[string]ddbtypes.AttributeValue,
+	image map[string]streamtypes.AttributeValue,
+) (model.RecordItems, error) {
+	items := model.NewRecordItems(len(columns) + 1)
+	for _, column := range columns {
+		qv, err := keyAttributeToQValue(column, keys[column.name])
+		if err != nil {
+			return items, err
+		}
+		items.AddColumn(column.name, qv)
+	}
+	if image == nil {
+		items.AddColumn(DocumentColumnName, types.QValueJSON{Val: "{}"})
+		return items, nil
+	}
+	item, err := attributevalue.FromDynamoDBStreamsMap(image)
+	if err != nil {
+		return items, fmt.Errorf("failed to convert item image: %w", err)
+	}
+	doc, err := itemToJSON(item)
+	if err != nil {
+		return items, err
+	}
+	items.AddColumn(DocumentColumnName, doc)
+	return items, nil
+}
+
+func (c *DynamoDBConnector) buildRecord(
+	state *streamPullState,
+	ts *tableStream,
+	record streamtypes.Record,
+) (model.Record[model.RecordItems], error) {
+	change := record.Dynamodb
+	keys, err := attributevalue.FromDynamoDBStreamsMap(change.Keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert keys: %w", err)
+	}
+	destination := state.req.TableNameMapping[ts.source].Name
+	baseRecord := model.BaseRecord{
+		CommitTimeNano: aws.ToTime(change.ApproximateCreationDateTime).UnixNano(),
+	}
+
+	switch record.EventName {
+	case streamtypes.OperationTypeInsert, streamtypes.OperationTypeModify:
+		if change.NewImage == nil {
+			return nil, errors.New("stream record carries no new image, the stream view type must include NEW_IMAGE")
+		}
+		newItems, err := streamImageItems(ts.columns, keys, change.NewImage)
+		if err != nil {
+			return nil, err
+		}
+		if record.EventName == streamtypes.OperationTypeInsert {
+			return &model.InsertRecord[model.RecordItems]{
+				BaseRecord:           baseRecord,
+				Items:                newItems,
+				SourceTableName:      ts.source,
+				DestinationTableName: destination,
+			}, nil
+		}
+		oldItems := model.NewRecordItems(0)
+		if change.OldImage != nil {
+			if oldItems, err = streamImageItems(ts.columns, keys, change.OldImage); err != nil {
+				return nil, err
+			}
+		}
+		return &model.UpdateRecord[model.RecordItems]{
+			BaseRecord:           baseRecord,
+			OldItems:             oldItems,
+			NewItems:             newItems,
+			SourceTableName:      ts.source,
+			DestinationTableName: destination,
+		}, nil
+	case streamtypes.OperationTypeRemove:
+		items, err := streamImageItems(ts.columns, keys, change.OldImage)
+		if err != nil {
+			return nil, err
+		}
+		return &model.DeleteRecord[model.RecordItems]{
+			BaseRecord:           baseRecord,
+			Items:                items,
+			SourceTableName:      ts.source,
+			DestinationTableName: destination,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported stream event %q", record.EventName)
+	}
+}
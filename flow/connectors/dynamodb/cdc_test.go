@@ -0,0 +1,58 @@
+package conndynamodb
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	streamtypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamCheckpointRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	checkpoint, err := parseStreamCheckpoint("")
+	require.NoError(t, err)
+	require.Empty(t, checkpoint.Tables)
+
+	checkpoint.Tables["us-east-1.orders"] = &tableStreamCheckpoint{
+		StreamArn:  "arn:aws:dynamodb:us-east-1:123456789012:table/orders/stream/2024-05-01T00:00:00.000",
+		Shards:     map[string]string{"shardId-2": "300"},
+		Done:       []string{"shardId-1"},
+		StartAfter: 1714521600,
+	}
+	parsed, err := parseStreamCheckpoint(checkpoint.String())
+	require.NoError(t, err)
+	require.Equal(t, checkpoint, parsed)
+
+	// a table without partially read shards gets a usable map back
+	parsed, err = parseStreamCheckpoint(`{"tables":{"us-east-1.t":{"streamArn":"arn"}}}`)
+	require.NoError(t, err)
+	require.NotNil(t, parsed.Tables["us-east-1.t"].Shards)
+
+	_, err = parseStreamCheckpoint("1234")
+	require.Error(t, err)
+}
+
+func TestReadyShards(t *testing.T) {
+	t.Parallel()
+
+	shard := func(id string, parent string) streamtypes.Shard {
+		s := streamtypes.Shard{ShardId: aws.String(id)}
+		if parent != "" {
+			s.ParentShardId = aws.String(parent)
+		}
+		return s
+	}
+	shards := []streamtypes.Shard{
+		shard("a", "trimmed"),
+		shard("b", "a"),
+		shard("c", "b"),
+		shard("d", ""),
+	}
+
+	// children wait for parents still listed, a trimmed parent does not block
+	require.Equal(t, []string{"a", "d"}, readyShards(shards, map[string]struct{}{}))
+	require.Equal(t, []string{"b", "d"}, readyShards(shards, map[string]struct{}{"a": {}}))
+	require.Equal(t, []string{"c"}, readyShards(shards, map[string]struct{}{"a": {}, "b": {}, "d": {}}))
+}
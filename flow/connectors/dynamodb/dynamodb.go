@@ -0,0 +1,110 @@
+package conndynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+// DocumentColumnName holds the whole item as JSON, next to the projected key attributes,
+// the same way the mongo connector exposes _id and doc
+const DocumentColumnName = "doc"
+
+type DynamoDBConnector struct {
+	*metadataStore.PostgresMetadata
+	logger  log.Logger
+	client  *dynamodb.Client
+	streams *dynamodbstreams.Client
+	config  *protos.DynamoDBConfig
+	// DynamoDB tables live in a region rather than a schema, the region is used as
+	// the namespace of table identifiers: <region>.<table>
+	region string
+}
+
+func NewDynamoDBConnector(ctx context.Context, config *protos.DynamoDBConfig) (*DynamoDBConnector, error) {
+	logger := internal.LoggerFromCtx(ctx)
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var peerCredentials utils.PeerAWSCredentials
+	if config.AwsAuth != nil {
+		peerCredentials = utils.BuildPeerAWSCredentials(config.AwsAuth)
+	}
+	credsProvider, err := utils.GetAWSCredentialsProvider(ctx, "DYNAMODB", peerCredentials)
+	if err != nil {
+		logger.Error("failed to get AWS credentials provider", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to get AWS credentials provider: %w", err)
+	}
+	region := credsProvider.GetRegion()
+	if config.AwsAuth != nil && config.AwsAuth.Region != "" {
+		region = config.AwsAuth.Region
+	}
+	if region == "" {
+		return nil, errors.New("DynamoDB peer needs a region, set it in the peer's AWS authentication config")
+	}
+
+	var endpoint *string
+	if config.Endpoint != nil && *config.Endpoint != "" {
+		endpoint = config.Endpoint
+	}
+	client := dynamodb.New(dynamodb.Options{
+		Region:       region,
+		Credentials:  credsProvider.GetUnderlyingProvider(),
+		BaseEndpoint: endpoint,
+	})
+	// DynamoDB Local serves streams on the same endpoint as tables
+	streamsClient := dynamodbstreams.New(dynamodbstreams.Options{
+		Region:       region,
+		Credentials:  credsProvider.GetUnderlyingProvider(),
+		BaseEndpoint: endpoint,
+	})
+
+	return &DynamoDBConnector{
+		PostgresMetadata: pgMetadata,
+		logger:           logger,
+		client:           client,
+		streams:          streamsClient,
+		config:           config,
+		region:           region,
+	}, nil
+}
+
+func (c *DynamoDBConnector) Close() error {
+	// the SDK clients are stateless HTTP clients
+	return nil
+}
+
+func (c *DynamoDBConnector) ConnectionActive(ctx context.Context) error {
+	if _, err := c.client.ListTables(ctx, &dynamodb.ListTablesInput{Limit: aws.Int32(1)}); err != nil {
+		return fmt.Errorf("failed to list DynamoDB tables: %w", err)
+	}
+	return nil
+}
+
+// parseTable returns the DynamoDB table name of a <region>.<table> identifier.
+// Table names may contain dots, region names never do, so this splits at the first dot
+// rather than using common.ParseTableIdentifier.
+func (c *DynamoDBConnector) parseTable(tableIdentifier string) (string, error) {
+	region, table, found := strings.Cut(tableIdentifier, ".")
+	if !found || table == "" {
+		return "", fmt.Errorf("invalid table name: %s", tableIdentifier)
+	}
+	if !strings.EqualFold(region, c.region) {
+		return "", fmt.Errorf("table %s is not in the peer's region %s", tableIdentifier, c.region)
+	}
+	return table, nil
+}
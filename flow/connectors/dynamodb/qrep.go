@@ -0,0 +1,203 @@
+package conndynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// pullProgressLogInterval throttles pull progress logging to one line every this many records
+const pullProgressLogInterval = 50_000
+
+// scanSegmentPartition encodes segment i of a parallel Scan over n segments as the int range [i, n].
+// DynamoDB splits the key space into segments itself, so partitions carry no key bounds.
+func scanSegmentPartition(segment int64, totalSegments int64) *protos.QRepPartition {
+	return &protos.QRepPartition{
+		PartitionId: uuid.NewString(),
+		Range: &protos.PartitionRange{
+			Range: &protos.PartitionRange_IntRange{
+				IntRange: &protos.IntPartitionRange{Start: segment, End: totalSegments},
+			},
+		},
+	}
+}
+
+func (c *DynamoDBConnector) GetQRepPartitions(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	last *protos.QRepPartition,
+) ([]*protos.QRepPartition, error) {
+	if config.WatermarkColumn == "" || config.NumPartitionsOverride == 1 {
+		// if no watermark column is specified, return a single partition
+		return utils.FullTablePartition(), nil
+	}
+	if last != nil && last.Range != nil {
+		return nil, fmt.Errorf("last partition is not supported for DynamoDB connector, got: %v", last)
+	}
+
+	numSegments := int64(config.NumPartitionsOverride)
+	if numSegments == 0 {
+		if config.NumRowsPerPartition == 0 {
+			return nil, errors.New("num rows per partition must be greater than 0")
+		}
+		table, err := c.parseTable(config.WatermarkTable)
+		if err != nil {
+			return nil, err
+		}
+		description, err := c.describeTable(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		// ItemCount is refreshed roughly every six hours, good enough to size segments
+		totalRows := aws.ToInt64(description.ItemCount)
+		adjustedPartitions := shared.AdjustNumPartitions(totalRows, int64(config.NumRowsPerPartition))
+		c.logger.Info("[dynamodb] partition details",
+			slog.Int64("totalRows", totalRows),
+			slog.Int64("desiredNumRowsPerPartition", int64(config.NumRowsPerPartition)),
+			slog.Int64("adjustedNumPartitions", adjustedPartitions.AdjustedNumPartitions),
+			slog.Int64("adjustedNumRowsPerPartition", adjustedPartitions.AdjustedNumRowsPerPartition))
+		numSegments = adjustedPartitions.AdjustedNumPartitions
+	}
+	if numSegments <= 1 {
+		c.logger.Info("[dynamodb] insufficient partitions for parallel scan, falling back to full table partition")
+		return utils.FullTablePartition(), nil
+	}
+
+	partitions := make([]*protos.QRepPartition, 0, numSegments)
+	for segment := range numSegments {
+		partitions = append(partitions, scanSegmentPartition(segment, numSegments))
+	}
+	return partitions, nil
+}
+
+// GetDefaultPartitionKeyForTables maps every table to its partition key attribute: partitions
+// are parallel Scan segments, the column only has to be set for the snapshot to be partitioned
+func (c *DynamoDBConnector) GetDefaultPartitionKeyForTables(
+	ctx context.Context,
+	input *protos.GetDefaultPartitionKeyForTablesInput,
+) (*protos.GetDefaultPartitionKeyForTablesOutput, error) {
+	output := &protos.GetDefaultPartitionKeyForTablesOutput{
+		TableDefaultPartitionKeyMapping: make(map[string]string, len(input.TableMappings)),
+	}
+	for _, tm := range input.TableMappings {
+		schema, ok := input.TableSchemaMapping[tm.SourceTableIdentifier]
+		if !ok || len(schema.PrimaryKeyColumns) == 0 {
+			continue
+		}
+		output.TableDefaultPartitionKeyMapping[tm.SourceTableIdentifier] = schema.PrimaryKeyColumns[0]
+	}
+	return output, nil
+}
+
+func qrecordSchema(columns []keyColumn) types.QRecordSchema {
+	fields := make([]types.QField, 0, len(columns)+1)
+	for _, column := range columns {
+		fields = append(fields, types.QField{Name: column.name, Type: column.qkind, Nullable: false})
+	}
+	fields = append(fields, types.QField{Name: DocumentColumnName, Type: types.QValueKindJSON, Nullable: false})
+	return types.QRecordSchema{Fields: fields}
+}
+
+// itemToQValues projects the key attributes of an item and renders the whole item as the
+// document column, in the order of qrecordSchema
+func itemToQValues(columns []keyColumn, item map[string]ddbtypes.AttributeValue) ([]types.QValue, error) {
+	record := make([]types.QValue, 0, len(columns)+1)
+	for _, column := range columns {
+		qv, err := keyAttributeToQValue(column, item[column.name])
+		if err != nil {
+			return nil, err
+		}
+		record = append(record, qv)
+	}
+	doc, err := itemToJSON(item)
+	if err != nil {
+		return nil, err
+	}
+	return append(record, doc), nil
+}
+
+func (c *DynamoDBConnector) PullQRepRecords(
+	ctx context.Context,
+	_ shared.CatalogPool,
+	_ *otel_metrics.OtelManager,
+	config *protos.QRepConfig,
+	dstType protos.DBType,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, int64, error) {
+	partitionIdLog := slog.String(string(shared.PartitionIDKey), partition.PartitionId)
+
+	table, err := c.parseTable(config.WatermarkTable)
+	if err != nil {
+		return 0, 0, err
+	}
+	columns, err := c.getKeyColumns(ctx, table)
+	if err != nil {
+		return 0, 0, err
+	}
+	stream.SetSchema(qrecordSchema(columns))
+
+	input := &dynamodb.ScanInput{
+		TableName:      aws.String(table),
+		ConsistentRead: aws.Bool(true),
+	}
+	if !partition.FullTablePartition {
+		segmentRange, ok := partition.Range.GetRange().(*protos.PartitionRange_IntRange)
+		if !ok {
+			return 0, 0, fmt.Errorf("unsupported partition range %T for DynamoDB scan", partition.Range.GetRange())
+		}
+		input.Segment = aws.Int32(int32(segmentRange.IntRange.Start))
+		input.TotalSegments = aws.Int32(int32(segmentRange.IntRange.End))
+	}
+	c.logger.Info("[dynamodb] pulling records start", partitionIdLog,
+		slog.String("table", table),
+		slog.Int("segment", int(aws.ToInt32(input.Segment))),
+		slog.Int("totalSegments", int(aws.ToInt32(input.TotalSegments))))
+
+	var totalRecords, totalBytes int64
+	paginator := dynamodb.NewScanPaginator(c.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return totalRecords, totalBytes, fmt.Errorf("failed to scan table %s: %w", table, err)
+		}
+		for _, item := range page.Items {
+			record, err := itemToQValues(columns, item)
+			if err != nil {
+				return totalRecords, totalBytes, fmt.Errorf("failed to convert item of %s: %w", table, err)
+			}
+			totalBytes += int64(len(record[len(record)-1].(types.QValueJSON).Val))
+			if err := stream.Send(ctx, record); err != nil {
+				return totalRecords, totalBytes, fmt.Errorf("failed to send record to stream: %w", err)
+			}
+
+			totalRecords++
+			if totalRecords%pullProgressLogInterval == 0 {
+				c.logger.Info("[dynamodb] pulling records",
+					partitionIdLog,
+					slog.Int64("records", totalRecords),
+					slog.Int64("bytes", totalBytes),
+					slog.Int("channelLen", len(stream.Records)))
+			}
+		}
+	}
+
+	c.logger.Info("[dynamodb] pulled records",
+		partitionIdLog,
+		slog.Int64("records", totalRecords),
+		slog.Int64("bytes", totalBytes))
+	return totalRecords, totalBytes, nil
+}
@@ -0,0 +1,229 @@
+package conndynamodb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// keyColumn is a key attribute projected into its own column
+type keyColumn struct {
+	name          string
+	attributeType ddbtypes.ScalarAttributeType
+	qkind         types.QValueKind
+}
+
+func scalarAttributeTypeToQValueKind(attributeType ddbtypes.ScalarAttributeType) types.QValueKind {
+	switch attributeType {
+	case ddbtypes.ScalarAttributeTypeS:
+		return types.QValueKindString
+	case ddbtypes.ScalarAttributeTypeN:
+		return types.QValueKindNumeric
+	case ddbtypes.ScalarAttributeTypeB:
+		return types.QValueKindBytes
+	default:
+		return types.QValueKindInvalid
+	}
+}
+
+func (c *DynamoDBConnector) describeTable(ctx context.Context, table string) (*ddbtypes.TableDescription, error) {
+	output, err := c.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(table)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe DynamoDB table %s: %w", table, err)
+	}
+	return output.Table, nil
+}
+
+// keyColumns lists the key attributes of a table, the partition key first and the sort key,
+// if the table has one, second
+func keyColumns(description *ddbtypes.TableDescription) ([]keyColumn, error) {
+	attributeTypes := make(map[string]ddbtypes.ScalarAttributeType, len(description.AttributeDefinitions))
+	for _, definition := range description.AttributeDefinitions {
+		attributeTypes[aws.ToString(definition.AttributeName)] = definition.AttributeType
+	}
+	columns := make([]keyColumn, 0, 2)
+	for _, keyType := range []ddbtypes.KeyType{ddbtypes.KeyTypeHash, ddbtypes.KeyTypeRange} {
+		for _, element := range description.KeySchema {
+			if element.KeyType != keyType {
+				continue
+			}
+			name := aws.ToString(element.AttributeName)
+			if name == DocumentColumnName {
+				return nil, fmt.Errorf("key attribute %s of table %s collides with the document column",
+					name, aws.ToString(description.TableName))
+			}
+			attributeType := attributeTypes[name]
+			qkind := scalarAttributeTypeToQValueKind(attributeType)
+			if qkind == types.QValueKindInvalid {
+				return nil, fmt.Errorf("key attribute %s of table %s has unsupported type %q",
+					name, aws.ToString(description.TableName), attributeType)
+			}
+			columns = append(columns, keyColumn{name: name, attributeType: attributeType, qkind: qkind})
+		}
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %s has no key schema", aws.ToString(description.TableName))
+	}
+	return columns, nil
+}
+
+func (c *DynamoDBConnector) getKeyColumns(ctx context.Context, table string) ([]keyColumn, error) {
+	description, err := c.describeTable(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	return keyColumns(description)
+}
+
+// canStream reports whether a table's stream carries the new item image CDC needs
+func canStream(description *ddbtypes.TableDescription) bool {
+	spec := description.StreamSpecification
+	if spec == nil || !aws.ToBool(spec.StreamEnabled) || description.LatestStreamArn == nil {
+		return false
+	}
+	return spec.StreamViewType == ddbtypes.StreamViewTypeNewImage ||
+		spec.StreamViewType == ddbtypes.StreamViewTypeNewAndOldImages
+}
+
+func (c *DynamoDBConnector) GetTableSchema(
+	ctx context.Context,
+	_ map[string]string,
+	_ uint32,
+	_ protos.TypeSystem,
+	tableMappings []*protos.TableMapping,
+) (map[string]*protos.TableSchema, error) {
+	res := make(map[string]*protos.TableSchema, len(tableMappings))
+	for _, tm := range tableMappings {
+		table, err := c.parseTable(tm.SourceTableIdentifier)
+		if err != nil {
+			return nil, err
+		}
+		columns, err := c.getKeyColumns(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+
+		fields := make([]*protos.FieldDescription, 0, len(columns)+1)
+		pkCols := make([]string, 0, len(columns))
+		for _, column := range columns {
+			fields = append(fields, &protos.FieldDescription{
+				Name:         column.name,
+				Type:         string(column.qkind),
+				TypeModifier: -1,
+				Nullable:     false,
+			})
+			pkCols = append(pkCols, column.name)
+		}
+		fields = append(fields, &protos.FieldDescription{
+			Name:         DocumentColumnName,
+			Type:         string(types.QValueKindJSON),
+			TypeModifier: -1,
+			Nullable:     false,
+		})
+		res[tm.SourceTableIdentifier] = &protos.TableSchema{
+			TableIdentifier:   tm.SourceTableIdentifier,
+			PrimaryKeyColumns: pkCols,
+			// stream records carry whole items, never partial rows
+			IsReplicaIdentityFull: true,
+			System:                protos.TypeSystem_Q,
+			NullableEnabled:       false,
+			Columns:               fields,
+		}
+	}
+	return res, nil
+}
+
+func (c *DynamoDBConnector) listTables(ctx context.Context) ([]string, error) {
+	var tables []string
+	paginator := dynamodb.NewListTablesPaginator(c.client, &dynamodb.ListTablesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list DynamoDB tables: %w", err)
+		}
+		tables = append(tables, page.TableNames...)
+	}
+	return tables, nil
+}
+
+func (c *DynamoDBConnector) GetAllTables(ctx context.Context) (*protos.AllTablesResponse, error) {
+	tables, err := c.listTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tableNames := make([]string, 0, len(tables))
+	for _, table := range tables {
+		tableNames = append(tableNames, c.region+"."+table)
+	}
+	return &protos.AllTablesResponse{Tables: tableNames}, nil
+}
+
+func (c *DynamoDBConnector) GetSchemas(ctx context.Context) (*protos.PeerSchemasResponse, error) {
+	return &protos.PeerSchemasResponse{Schemas: []string{c.region}}, nil
+}
+
+func (c *DynamoDBConnector) GetTablesInSchema(
+	ctx context.Context,
+	schema string,
+	cdcEnabled bool,
+) (*protos.SchemaTablesResponse, error) {
+	if schema != c.region {
+		return &protos.SchemaTablesResponse{Tables: []*protos.TableResponse{}}, nil
+	}
+	tables, err := c.listTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &protos.SchemaTablesResponse{Tables: make([]*protos.TableResponse, 0, len(tables))}
+	for _, table := range tables {
+		canMirror := true
+		if cdcEnabled {
+			description, err := c.describeTable(ctx, table)
+			if err != nil {
+				return nil, err
+			}
+			canMirror = canStream(description)
+		}
+		response.Tables = append(response.Tables, &protos.TableResponse{
+			TableName: table,
+			CanMirror: canMirror,
+			TableSize: "",
+		})
+	}
+	return response, nil
+}
+
+func (c *DynamoDBConnector) GetColumns(
+	ctx context.Context,
+	version uint32,
+	schema string,
+	table string,
+) (*protos.TableColumnsResponse, error) {
+	columns, err := c.getKeyColumns(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*protos.ColumnsItem, 0, len(columns)+1)
+	for _, column := range columns {
+		items = append(items, &protos.ColumnsItem{
+			Name:  column.name,
+			Type:  string(column.attributeType),
+			IsKey: true,
+			Qkind: string(column.qkind),
+		})
+	}
+	items = append(items, &protos.ColumnsItem{
+		Name:  DocumentColumnName,
+		Type:  "M",
+		IsKey: false,
+		Qkind: string(types.QValueKindJSON),
+	})
+	return &protos.TableColumnsResponse{Columns: items}, nil
+}
@@ -0,0 +1,68 @@
+package conndynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/pkg/common"
+)
+
+func (c *DynamoDBConnector) ValidateCheck(ctx context.Context) error {
+	// listing tables is the least the peer needs, table level permissions are checked per mirror
+	return c.ConnectionActive(ctx)
+}
+
+func (c *DynamoDBConnector) ValidateMirrorSource(ctx context.Context, cfg *protos.FlowConnectionConfigsCore) error {
+	var missingTables []common.QualifiedTable
+	descriptions := make(map[string]*ddbtypes.TableDescription, len(cfg.TableMappings))
+	for _, tm := range cfg.TableMappings {
+		table, err := c.parseTable(tm.SourceTableIdentifier)
+		if err != nil {
+			return err
+		}
+		description, err := c.describeTable(ctx, table)
+		if err != nil {
+			if _, ok := errors.AsType[*ddbtypes.ResourceNotFoundException](err); ok {
+				missingTables = append(missingTables, common.QualifiedTable{Namespace: c.region, Table: table})
+				continue
+			}
+			return err
+		}
+		if _, err := keyColumns(description); err != nil {
+			return err
+		}
+		descriptions[tm.SourceTableIdentifier] = description
+	}
+	if len(missingTables) > 0 {
+		return common.NewSourceTablesMissingError(missingTables)
+	}
+
+	// snapshot-only mirrors never read streams
+	if cfg.DoInitialSnapshot && cfg.InitialSnapshotOnly {
+		return nil
+	}
+	for source, description := range descriptions {
+		if err := validateStream(source, description); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateStream(source string, description *ddbtypes.TableDescription) error {
+	spec := description.StreamSpecification
+	if spec == nil || !aws.ToBool(spec.StreamEnabled) || description.LatestStreamArn == nil {
+		return fmt.Errorf("table %s has no stream enabled, enable DynamoDB Streams with the"+
+			" NEW_AND_OLD_IMAGES view type for CDC", source)
+	}
+	if !canStream(description) {
+		return fmt.Errorf("stream of table %s uses the %s view type, CDC needs the whole item:"+
+			" recreate the stream with the NEW_AND_OLD_IMAGES or NEW_IMAGE view type", source, spec.StreamViewType)
+	}
+	return nil
+}
@@ -6,14 +6,17 @@ import (
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"maps"
 	"net/http"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/elastic/go-elasticsearch/v8/esutil"
 	"go.temporal.io/sdk/log"
@@ -42,24 +45,48 @@ type ElasticsearchConnector struct {
 	client                   *elasticsearch.Client
 	logger                   log.Logger
 	hushWarnUpsertColMissing map[tableUpsertCol]struct{}
+	// Amazon OpenSearch Service does not expose node discovery
+	awsSigned bool
 }
 
 func NewElasticsearchConnector(ctx context.Context,
 	config *protos.ElasticsearchConfig,
 ) (*ElasticsearchConnector, error) {
+	var transport http.RoundTripper = &http.Transport{
+		MaxIdleConnsPerHost: 4,
+		TLSClientConfig:     &tls.Config{MinVersion: tls.VersionTLS13},
+	}
 	esCfg := &elasticsearch.Config{
 		Addresses: config.Addresses,
-		Transport: &http.Transport{
-			MaxIdleConnsPerHost: 4,
-			TLSClientConfig:     &tls.Config{MinVersion: tls.VersionTLS13},
-		},
 	}
-	if config.AuthType == protos.ElasticsearchAuthType_BASIC {
+	switch config.AuthType {
+	case protos.ElasticsearchAuthType_BASIC:
 		esCfg.Username = *config.Username
 		esCfg.Password = *config.Password
-	} else if config.AuthType == protos.ElasticsearchAuthType_APIKEY {
+	case protos.ElasticsearchAuthType_APIKEY:
 		esCfg.APIKey = *config.ApiKey
+	case protos.ElasticsearchAuthType_AWS_SIGV4:
+		if config.AwsAuth == nil {
+			return nil, errors.New("[elasticsearch] aws auth config is required for SigV4 authentication")
+		}
+		credsProvider, err := utils.GetAWSCredentialsProvider(ctx, "ELASTICSEARCH",
+			utils.BuildPeerAWSCredentials(config.AwsAuth))
+		if err != nil {
+			return nil, fmt.Errorf("[elasticsearch] failed to get AWS credentials provider: %w", err)
+		}
+		service := awsServiceOpenSearch
+		if config.AwsServerless {
+			service = awsServiceOpenSearchServerless
+		}
+		transport = &sigV4Transport{
+			next:        transport,
+			signer:      v4.NewSigner(),
+			credentials: credsProvider.GetUnderlyingProvider(),
+			region:      config.AwsAuth.Region,
+			service:     service,
+		}
 	}
+	esCfg.Transport = &productHeaderTransport{next: transport}
 
 	esClient, err := elasticsearch.NewClient(*esCfg)
 	if err != nil {
@@ -75,10 +102,22 @@ func NewElasticsearchConnector(ctx context.Context,
 		client:                   esClient,
 		logger:                   internal.LoggerFromCtx(ctx),
 		hushWarnUpsertColMissing: make(map[tableUpsertCol]struct{}),
+		awsSigned:                config.AuthType == protos.ElasticsearchAuthType_AWS_SIGV4,
 	}, nil
 }
 
 func (esc *ElasticsearchConnector) ConnectionActive(ctx context.Context) error {
+	if esc.awsSigned {
+		res, err := esc.client.Cat.Indices(esc.client.Cat.Indices.WithContext(ctx), esc.client.Cat.Indices.WithH("index"))
+		if err != nil {
+			return fmt.Errorf("failed to check if elasticsearch peer is active: %w", err)
+		}
+		defer res.Body.Close()
+		if res.IsError() {
+			return fmt.Errorf("failed to check if elasticsearch peer is active: %s", res.String())
+		}
+		return nil
+	}
 	err := esc.client.DiscoverNodes()
 	if err != nil {
 		return fmt.Errorf("failed to check if elasticsearch peer is active: %w", err)
@@ -98,23 +137,36 @@ func (esc *ElasticsearchConnector) CreateRawTable(ctx context.Context,
 	return &protos.CreateRawTableOutput{TableIdentifier: "n/a"}, nil
 }
 
-// we handle schema changes by not handling them since no mapping is being enforced right now
-func (esc *ElasticsearchConnector) ReplayTableSchemaDeltas(ctx context.Context, env map[string]string,
-	flowJobName string, _ []*protos.TableMapping, schemaDeltas []*protos.TableSchemaDelta, _ []string,
-) error {
-	return nil
+// documentValue converts a value to what its field mapping accepts
+func documentValue(val types.QValue) any {
+	switch v := val.(type) {
+	case types.QValueJSON: // JSON is stored as a string, fix that
+		return json.RawMessage(shared.UnsafeFastStringToReadOnlyBytes(v.Val))
+	case types.QValueGeometry: // geo_shape only accepts WKT without the SRID=N; prefix of EWKT
+		return stripSRID(v.Val)
+	case types.QValueGeography:
+		return stripSRID(v.Val)
+	case types.QValuePoint:
+		return stripSRID(v.Val)
+	default:
+		return val.Value()
+	}
+}
+
+func stripSRID(wkt string) string {
+	if strings.HasPrefix(wkt, "SRID=") {
+		if _, wktWithoutSRID, found := strings.Cut(wkt, ";"); found {
+			return wktWithoutSRID
+		}
+	}
+	return wkt
 }
 
 func recordItemsProcessor(items model.RecordItems) ([]byte, error) {
 	qRecordJsonMap := make(map[string]any)
 
 	for key, val := range items.ColToVal {
-		if r, ok := val.(types.QValueJSON); ok { // JSON is stored as a string, fix that
-			qRecordJsonMap[key] = json.RawMessage(
-				shared.UnsafeFastStringToReadOnlyBytes(r.Val))
-		} else {
-			qRecordJsonMap[key] = val.Value()
-		}
+		qRecordJsonMap[key] = documentValue(val)
 	}
 
 	return json.Marshal(qRecordJsonMap)
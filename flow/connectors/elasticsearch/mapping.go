@@ -0,0 +1,537 @@
+package connelasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/pkg/common"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// scaled_float is stored as a long, so only numerics that fit in one keep every digit
+const maxScaledFloatPrecision = 18
+
+// textMapping mirrors what dynamic mapping picks for strings, so existing queries keep working
+var textMapping = map[string]any{
+	"type": "text",
+	"fields": map[string]any{
+		"keyword": map[string]any{"type": "keyword", "ignore_above": 256},
+	},
+}
+
+// qValueKindToElasticsearchMapping returns the field mapping for a column, or nil to leave it to dynamic mapping,
+// arrays map to their element type since every Elasticsearch field can hold multiple values
+func qValueKindToElasticsearchMapping(column *protos.FieldDescription) map[string]any {
+	kind := types.QValueKind(column.Type)
+	switch kind {
+	case types.QValueKindBoolean, types.QValueKindArrayBoolean:
+		return map[string]any{"type": "boolean"}
+	case types.QValueKindInt8:
+		return map[string]any{"type": "byte"}
+	case types.QValueKindInt16, types.QValueKindUInt8, types.QValueKindArrayInt16:
+		return map[string]any{"type": "short"}
+	case types.QValueKindInt32, types.QValueKindUInt16, types.QValueKindArrayInt32:
+		return map[string]any{"type": "integer"}
+	case types.QValueKindInt64, types.QValueKindUInt32, types.QValueKindArrayInt64:
+		return map[string]any{"type": "long"}
+	case types.QValueKindUInt64:
+		return map[string]any{"type": "unsigned_long"}
+	case types.QValueKindFloat32, types.QValueKindArrayFloat32:
+		return map[string]any{"type": "float"}
+	case types.QValueKindFloat64, types.QValueKindArrayFloat64:
+		return map[string]any{"type": "double"}
+	case types.QValueKindNumeric, types.QValueKindArrayNumeric:
+		if column.TypeModifier != -1 {
+			precision, scale := common.ParseNumericTypmod(column.TypeModifier)
+			if precision > 0 && precision <= maxScaledFloatPrecision && scale >= 0 {
+				return map[string]any{"type": "scaled_float", "scaling_factor": math.Pow10(int(scale))}
+			}
+		}
+		return map[string]any{"type": "double"}
+	case types.QValueKindDate, types.QValueKindTimestamp, types.QValueKindTimestampTZ,
+		types.QValueKindArrayDate, types.QValueKindArrayTimestamp, types.QValueKindArrayTimestampTZ:
+		return map[string]any{"type": "date"}
+	case types.QValueKindBytes:
+		return map[string]any{"type": "binary"}
+	case types.QValueKindUUID, types.QValueKindArrayUUID, types.QValueKindTime, types.QValueKindTimeTZ,
+		types.QValueKindInterval, types.QValueKindArrayInterval, types.QValueKindCIDR, types.QValueKindINET,
+		types.QValueKindMacaddr, types.QValueKindInt256, types.QValueKindUInt256, types.QValueKindEnum,
+		types.QValueKindArrayEnum:
+		return map[string]any{"type": "keyword"}
+	case types.QValueKindJSON, types.QValueKindJSONB, types.QValueKindArrayJSON, types.QValueKindArrayJSONB:
+		// JSON can be an object, array or scalar, which only dynamic mapping accepts
+		return nil
+	case types.QValueKindGeometry, types.QValueKindGeography:
+		return map[string]any{"type": "geo_shape"}
+	case types.QValueKindPoint:
+		return map[string]any{"type": "geo_point"}
+	default:
+		return textMapping
+	}
+}
+
+// buildProperties creates the mapping properties for columns,
+// the destination type of a column setting overrides the derived field type
+func buildProperties(columns []*protos.FieldDescription, tableMapping *protos.TableMapping) map[string]any {
+	properties := make(map[string]any, len(columns))
+	for _, column := range columns {
+		var fieldMapping map[string]any
+		if tableMapping != nil {
+			for _, col := range tableMapping.Columns {
+				if col.SourceName == column.Name && col.DestinationType != "" {
+					fieldMapping = map[string]any{"type": col.DestinationType}
+					break
+				}
+			}
+		}
+		if fieldMapping == nil {
+			fieldMapping = qValueKindToElasticsearchMapping(column)
+		}
+		if fieldMapping != nil {
+			properties[column.Name] = fieldMapping
+		}
+	}
+	return properties
+}
+
+// typeOverrideCompatibility classifies indexing values of a source kind into a field of a destination type override,
+// arrays check their element kind since every field can hold multiple values. Field types outside the matrix are invalid.
+func typeOverrideCompatibility(kind types.QValueKind, fieldType string) (types.TypeCompatibility, string) {
+	if elementKind, ok := strings.CutPrefix(string(kind), "array_"); ok {
+		kind = types.QValueKind(elementKind)
+	}
+	invalid := fmt.Sprintf("%s values cannot be indexed as %s", kind, fieldType)
+	isJSON := kind == types.QValueKindJSON || kind == types.QValueKindJSONB
+	isObject := kind == types.QValueKindStruct || kind == types.QValueKindMap
+	kindBits, signed, isInteger := kind.IntegerWidth()
+	switch fieldType {
+	case "text", "keyword", "wildcard", "match_only_text", "search_as_you_type":
+		if isObject {
+			return types.TypeCompatibilityInvalid, invalid
+		} else if isJSON {
+			return types.TypeCompatibilityLossy, "values that are JSON objects fail to index"
+		}
+		return types.TypeCompatibilityLossless, ""
+	case "boolean":
+		if kind == types.QValueKindBoolean {
+			return types.TypeCompatibilityLossless, ""
+		}
+	case "byte", "short", "integer", "long", "unsigned_long":
+		bits := map[string]int{"byte": 8, "short": 16, "integer": 32, "long": 64, "unsigned_long": 64}[fieldType]
+		unsigned := fieldType == "unsigned_long"
+		switch {
+		case isInteger && (signed == !unsigned && kindBits <= bits || !signed && !unsigned && kindBits < bits):
+			return types.TypeCompatibilityLossless, ""
+		case isInteger:
+			return types.TypeCompatibilityLossy, "out of range values fail to index"
+		case kind == types.QValueKindFloat32 || kind == types.QValueKindFloat64 || kind == types.QValueKindNumeric:
+			return types.TypeCompatibilityLossy, "fractional digits are dropped and out of range values fail to index"
+		case kind == types.QValueKindString:
+			return types.TypeCompatibilityLossy, "values that are not numbers fail to index"
+		}
+	case "half_float", "float", "double", "scaled_float":
+		mantissaBits := map[string]int{"half_float": 11, "float": 24, "double": 53, "scaled_float": 53}[fieldType]
+		switch {
+		case isInteger && kindBits < mantissaBits:
+			return types.TypeCompatibilityLossless, ""
+		case kind == types.QValueKindFloat32 && mantissaBits >= 24 && fieldType != "scaled_float",
+			kind == types.QValueKindFloat64 && fieldType == "double":
+			return types.TypeCompatibilityLossless, ""
+		case isInteger || kind == types.QValueKindFloat32 || kind == types.QValueKindFloat64 || kind == types.QValueKindNumeric:
+			return types.TypeCompatibilityLossy, "values are rounded to the precision of " + fieldType
+		case kind == types.QValueKindString:
+			return types.TypeCompatibilityLossy, "values that are not numbers fail to index"
+		}
+	case "date", "date_nanos":
+		switch kind {
+		case types.QValueKindDate:
+			return types.TypeCompatibilityLossless, ""
+		case types.QValueKindTimestamp, types.QValueKindTimestampTZ:
+			if fieldType == "date_nanos" {
+				return types.TypeCompatibilityLossy, "values before 1970 fail to index"
+			}
+			return types.TypeCompatibilityLossy, "fractional seconds beyond milliseconds are truncated"
+		case types.QValueKindString:
+			return types.TypeCompatibilityLossy, "values that are not dates fail to index"
+		}
+	case "binary":
+		if kind == types.QValueKindBytes {
+			return types.TypeCompatibilityLossless, ""
+		}
+	case "ip":
+		switch kind {
+		case types.QValueKindINET:
+			return types.TypeCompatibilityLossy, "values with a netmask fail to index"
+		case types.QValueKindString:
+			return types.TypeCompatibilityLossy, "values that are not IP addresses fail to index"
+		}
+	case "geo_point":
+		if kind == types.QValueKindPoint {
+			return types.TypeCompatibilityLossless, ""
+		}
+	case "geo_shape", "shape":
+		if kind == types.QValueKindGeometry || kind == types.QValueKindGeography || kind == types.QValueKindPoint {
+			return types.TypeCompatibilityLossless, ""
+		}
+	case "object", "flattened", "nested":
+		if isObject {
+			return types.TypeCompatibilityLossless, ""
+		} else if isJSON {
+			return types.TypeCompatibilityLossy, "values that are not JSON objects fail to index"
+		}
+	}
+	return types.TypeCompatibilityInvalid, invalid
+}
+
+// ValidateColumnTypeOverrides rejects destination type overrides that values of the source column cannot be indexed as,
+// lossy overrides are allowed and returned as warnings
+func (esc *ElasticsearchConnector) ValidateColumnTypeOverrides(
+	_ context.Context,
+	tableMappings []*protos.TableMapping,
+	tableNameSchemaMapping map[string]*protos.TableSchema,
+) ([]string, error) {
+	var warnings []string
+	for _, tableMapping := range tableMappings {
+		tableSchema, ok := tableNameSchemaMapping[tableMapping.SourceTableIdentifier]
+		if !ok {
+			continue
+		}
+		for _, col := range tableMapping.Columns {
+			if col.DestinationType == "" {
+				continue
+			}
+			idx := slices.IndexFunc(tableSchema.Columns, func(field *protos.FieldDescription) bool {
+				return field.Name == col.SourceName
+			})
+			if idx == -1 {
+				continue
+			}
+			kind := types.QValueKind(tableSchema.Columns[idx].Type)
+			switch compatibility, reason := typeOverrideCompatibility(kind, col.DestinationType); compatibility {
+			case types.TypeCompatibilityInvalid:
+				return nil, fmt.Errorf("invalid destination type %s for column %s of index %s: %s",
+					col.DestinationType, col.SourceName, tableMapping.DestinationTableIdentifier, reason)
+			case types.TypeCompatibilityLossy:
+				warnings = append(warnings, fmt.Sprintf("lossy destination type %s for column %s of index %s: %s",
+					col.DestinationType, col.SourceName, tableMapping.DestinationTableIdentifier, reason))
+			}
+		}
+	}
+	return warnings, nil
+}
+
+func findTableMapping(tableMappings []*protos.TableMapping, dstTableName string) *protos.TableMapping {
+	for _, tableMapping := range tableMappings {
+		if tableMapping.DestinationTableIdentifier == dstTableName {
+			return tableMapping
+		}
+	}
+	return nil
+}
+
+// physicalIndexName is the index behind the alias named after the destination table,
+// a resync builds a new index and swaps the alias over to it
+func physicalIndexName(alias string, createdAt time.Time) string {
+	return alias + "_peerdb_" + strconv.FormatInt(createdAt.Unix(), 10)
+}
+
+func responseError(res *esapi.Response, action string) error {
+	return fmt.Errorf("[elasticsearch] failed to %s: %s", action, res.String())
+}
+
+func (esc *ElasticsearchConnector) indexExists(ctx context.Context, name string) (bool, error) {
+	res, err := esc.client.Indices.Exists([]string{name}, esc.client.Indices.Exists.WithContext(ctx))
+	if err != nil {
+		return false, fmt.Errorf("[elasticsearch] failed to check if index %s exists: %w", name, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return false, nil
+	} else if res.IsError() {
+		return false, responseError(res, "check if index "+name+" exists")
+	}
+	return true, nil
+}
+
+// resolveAlias returns the indices behind an alias, or nil when name is not an alias
+func (esc *ElasticsearchConnector) resolveAlias(ctx context.Context, name string) ([]string, error) {
+	res, err := esc.client.Indices.GetAlias(
+		esc.client.Indices.GetAlias.WithContext(ctx),
+		esc.client.Indices.GetAlias.WithName(name),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("[elasticsearch] failed to get alias %s: %w", name, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	} else if res.IsError() {
+		return nil, responseError(res, "get alias "+name)
+	}
+
+	var aliases map[string]json.RawMessage
+	if err := json.NewDecoder(res.Body).Decode(&aliases); err != nil {
+		return nil, fmt.Errorf("[elasticsearch] failed to decode alias %s: %w", name, err)
+	}
+	indices := make([]string, 0, len(aliases))
+	for index := range aliases {
+		indices = append(indices, index)
+	}
+	return indices, nil
+}
+
+func (esc *ElasticsearchConnector) deleteIndices(ctx context.Context, indices []string) error {
+	res, err := esc.client.Indices.Delete(indices, esc.client.Indices.Delete.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("[elasticsearch] failed to delete indices %v: %w", indices, err)
+	}
+	defer res.Body.Close()
+	if res.IsError() && res.StatusCode != http.StatusNotFound {
+		return responseError(res, fmt.Sprintf("delete indices %v", indices))
+	}
+	return nil
+}
+
+// existingFields returns the fields already mapped on any index behind name
+func (esc *ElasticsearchConnector) existingFields(ctx context.Context, name string) (map[string]struct{}, error) {
+	res, err := esc.client.Indices.GetMapping(
+		esc.client.Indices.GetMapping.WithContext(ctx),
+		esc.client.Indices.GetMapping.WithIndex(name),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("[elasticsearch] failed to get mapping of %s: %w", name, err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, responseError(res, "get mapping of "+name)
+	}
+
+	var mappings map[string]struct {
+		Mappings struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"mappings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&mappings); err != nil {
+		return nil, fmt.Errorf("[elasticsearch] failed to decode mapping of %s: %w", name, err)
+	}
+	fields := make(map[string]struct{})
+	for _, index := range mappings {
+		for field := range index.Mappings.Properties {
+			fields[field] = struct{}{}
+		}
+	}
+	return fields, nil
+}
+
+// index setup is not transactional
+func (esc *ElasticsearchConnector) StartSetupNormalizedTables(_ context.Context) (any, error) {
+	return nil, nil
+}
+
+func (esc *ElasticsearchConnector) CleanupSetupNormalizedTables(_ context.Context, _ any) {}
+
+func (esc *ElasticsearchConnector) FinishSetupNormalizedTables(_ context.Context, _ any) error {
+	return nil
+}
+
+// SetupNormalizedTable creates an index with an explicit mapping and an alias named after the destination table.
+// Indices that already exist, including ones created by dynamic mapping, are left as is.
+func (esc *ElasticsearchConnector) SetupNormalizedTable(
+	ctx context.Context,
+	_ any,
+	config *protos.SetupNormalizedTableBatchInput,
+	tableIdentifier string,
+	tableSchema *protos.TableSchema,
+) (bool, error) {
+	exists, err := esc.indexExists(ctx, tableIdentifier)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		if !config.IsResync {
+			return true, nil
+		}
+		// leftover from an earlier attempt of this resync
+		indices, err := esc.resolveAlias(ctx, tableIdentifier)
+		if err != nil {
+			return false, err
+		}
+		if indices == nil {
+			indices = []string{tableIdentifier}
+		}
+		if err := esc.deleteIndices(ctx, indices); err != nil {
+			return false, err
+		}
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"mappings": map[string]any{
+			"properties": buildProperties(tableSchema.Columns, findTableMapping(config.TableMappings, tableIdentifier)),
+		},
+		"aliases": map[string]any{
+			tableIdentifier: map[string]any{"is_write_index": true},
+		},
+	})
+	if err != nil {
+		return false, fmt.Errorf("[elasticsearch] failed to marshal mapping for %s: %w", tableIdentifier, err)
+	}
+	index := physicalIndexName(tableIdentifier, time.Now())
+	res, err := esc.client.Indices.Create(index,
+		esc.client.Indices.Create.WithContext(ctx),
+		esc.client.Indices.Create.WithBody(bytes.NewReader(body)),
+	)
+	if err != nil {
+		return false, fmt.Errorf("[elasticsearch] failed to create index %s: %w", index, err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return false, responseError(res, "create index "+index)
+	}
+	esc.logger.Info("[elasticsearch] created index", slog.String("index", index), slog.String("alias", tableIdentifier))
+	return false, nil
+}
+
+// ReplayTableSchemaDeltas adds added columns to the mapping, fields mapped earlier
+// (possibly by dynamic mapping) are skipped since field types cannot be changed in place
+func (esc *ElasticsearchConnector) ReplayTableSchemaDeltas(ctx context.Context, env map[string]string,
+	flowJobName string, tableMappings []*protos.TableMapping, schemaDeltas []*protos.TableSchemaDelta, _ []string,
+) error {
+	for _, schemaDelta := range schemaDeltas {
+		if schemaDelta == nil || len(schemaDelta.AddedColumns) == 0 {
+			continue
+		}
+
+		exists, err := esc.indexExists(ctx, schemaDelta.DstTableName)
+		if err != nil {
+			return err
+		} else if !exists {
+			// index gets created by dynamic mapping on first write
+			continue
+		}
+		fields, err := esc.existingFields(ctx, schemaDelta.DstTableName)
+		if err != nil {
+			return err
+		}
+		addedColumns := make([]*protos.FieldDescription, 0, len(schemaDelta.AddedColumns))
+		for _, column := range schemaDelta.AddedColumns {
+			if _, ok := fields[column.Name]; !ok {
+				addedColumns = append(addedColumns, column)
+			}
+		}
+		if len(addedColumns) == 0 {
+			continue
+		}
+
+		body, err := json.Marshal(map[string]any{
+			"properties": buildProperties(addedColumns, findTableMapping(tableMappings, schemaDelta.DstTableName)),
+		})
+		if err != nil {
+			return fmt.Errorf("[elasticsearch] failed to marshal mapping for %s: %w", schemaDelta.DstTableName, err)
+		}
+		res, err := esc.client.Indices.PutMapping([]string{schemaDelta.DstTableName}, bytes.NewReader(body),
+			esc.client.Indices.PutMapping.WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("[elasticsearch] failed to update mapping of %s: %w", schemaDelta.DstTableName, err)
+		}
+		if res.IsError() {
+			err := responseError(res, "update mapping of "+schemaDelta.DstTableName)
+			res.Body.Close()
+			return err
+		}
+		res.Body.Close()
+		for _, column := range addedColumns {
+			esc.logger.Info("[elasticsearch] added field to mapping",
+				slog.String("field", column.Name),
+				slog.String("type", column.Type),
+				slog.String("index", schemaDelta.DstTableName))
+		}
+	}
+	return nil
+}
+
+// RenameTables swaps the alias of the resynced index over in a single atomic alias update,
+// removing the indices previously behind the alias, or the concrete index with that name
+func (esc *ElasticsearchConnector) RenameTables(
+	ctx context.Context,
+	req *protos.RenameTablesInput,
+) (*protos.RenameTablesOutput, error) {
+	for _, renameRequest := range req.RenameTableOptions {
+		if renameRequest.CurrentName == renameRequest.NewName {
+			continue
+		}
+
+		resyncIndices, err := esc.resolveAlias(ctx, renameRequest.CurrentName)
+		if err != nil {
+			return nil, err
+		}
+		if resyncIndices == nil {
+			exists, err := esc.indexExists(ctx, renameRequest.CurrentName)
+			if err != nil {
+				return nil, err
+			} else if !exists {
+				esc.logger.Info("[elasticsearch] index does not exist, skipping rename for it",
+					slog.String("index", renameRequest.CurrentName))
+				continue
+			}
+			return nil, fmt.Errorf("[elasticsearch] %s is an index without alias, cannot swap it in", renameRequest.CurrentName)
+		}
+
+		actions := make([]map[string]any, 0, 2*len(resyncIndices)+1)
+		for _, index := range resyncIndices {
+			actions = append(actions,
+				map[string]any{"remove": map[string]any{"index": index, "alias": renameRequest.CurrentName}},
+				map[string]any{"add": map[string]any{"index": index, "alias": renameRequest.NewName, "is_write_index": true}},
+			)
+		}
+		originalIndices, err := esc.resolveAlias(ctx, renameRequest.NewName)
+		if err != nil {
+			return nil, err
+		}
+		if originalIndices == nil {
+			exists, err := esc.indexExists(ctx, renameRequest.NewName)
+			if err != nil {
+				return nil, err
+			} else if exists {
+				originalIndices = []string{renameRequest.NewName}
+			}
+		}
+		for _, index := range originalIndices {
+			actions = append(actions, map[string]any{"remove_index": map[string]any{"index": index}})
+		}
+
+		body, err := json.Marshal(map[string]any{"actions": actions})
+		if err != nil {
+			return nil, fmt.Errorf("[elasticsearch] failed to marshal alias actions: %w", err)
+		}
+		esc.logger.Info("[elasticsearch] swapping alias",
+			slog.String("from", renameRequest.CurrentName), slog.String("to", renameRequest.NewName))
+		res, err := esc.client.Indices.UpdateAliases(bytes.NewReader(body),
+			esc.client.Indices.UpdateAliases.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("[elasticsearch] failed to swap alias %s to %s: %w",
+				renameRequest.CurrentName, renameRequest.NewName, err)
+		}
+		if res.IsError() {
+			err := responseError(res, fmt.Sprintf("swap alias %s to %s", renameRequest.CurrentName, renameRequest.NewName))
+			res.Body.Close()
+			return nil, err
+		}
+		res.Body.Close()
+	}
+
+	return &protos.RenameTablesOutput{
+		FlowJobName: req.FlowJobName,
+	}, nil
+}
@@ -0,0 +1,104 @@
+package connelasticsearch
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestBuildProperties(t *testing.T) {
+	t.Parallel()
+
+	columns := []*protos.FieldDescription{
+		{Name: "id", Type: string(types.QValueKindInt64), TypeModifier: -1},
+		{Name: "name", Type: string(types.QValueKindString), TypeModifier: -1},
+		{Name: "price", Type: string(types.QValueKindNumeric), TypeModifier: datatypes.MakeNumericTypmod(10, 2)},
+		{Name: "big", Type: string(types.QValueKindNumeric), TypeModifier: -1},
+		{Name: "created_at", Type: string(types.QValueKindTimestampTZ), TypeModifier: -1},
+		{Name: "tags", Type: string(types.QValueKindArrayString), TypeModifier: -1},
+		{Name: "area", Type: string(types.QValueKindGeometry), TypeModifier: -1},
+		{Name: "location", Type: string(types.QValueKindPoint), TypeModifier: -1},
+		{Name: "attrs", Type: string(types.QValueKindJSONB), TypeModifier: -1},
+		{Name: "code", Type: string(types.QValueKindString), TypeModifier: -1},
+	}
+	tableMapping := &protos.TableMapping{
+		DestinationTableIdentifier: "items",
+		Columns: []*protos.ColumnSetting{
+			{SourceName: "code", DestinationType: "keyword"},
+			{SourceName: "name", DestinationName: "ignored"},
+		},
+	}
+
+	properties := buildProperties(columns, tableMapping)
+	require.Equal(t, map[string]any{
+		"id":         map[string]any{"type": "long"},
+		"name":       textMapping,
+		"price":      map[string]any{"type": "scaled_float", "scaling_factor": float64(100)},
+		"big":        map[string]any{"type": "double"},
+		"created_at": map[string]any{"type": "date"},
+		"tags":       textMapping,
+		"area":       map[string]any{"type": "geo_shape"},
+		"location":   map[string]any{"type": "geo_point"},
+		"code":       map[string]any{"type": "keyword"},
+	}, properties)
+}
+
+func TestTypeOverrideCompatibility(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		kind      types.QValueKind
+		fieldType string
+		expected  types.TypeCompatibility
+	}{
+		{types.QValueKindString, "keyword", types.TypeCompatibilityLossless},
+		{types.QValueKindNumeric, "keyword", types.TypeCompatibilityLossless},
+		{types.QValueKindArrayString, "keyword", types.TypeCompatibilityLossless},
+		{types.QValueKindJSONB, "keyword", types.TypeCompatibilityLossy},
+		{types.QValueKindStruct, "text", types.TypeCompatibilityInvalid},
+		{types.QValueKindInt32, "long", types.TypeCompatibilityLossless},
+		{types.QValueKindUInt32, "long", types.TypeCompatibilityLossless},
+		{types.QValueKindUInt64, "long", types.TypeCompatibilityLossy},
+		{types.QValueKindUInt64, "unsigned_long", types.TypeCompatibilityLossless},
+		{types.QValueKindInt64, "short", types.TypeCompatibilityLossy},
+		{types.QValueKindNumeric, "long", types.TypeCompatibilityLossy},
+		{types.QValueKindBoolean, "long", types.TypeCompatibilityInvalid},
+		{types.QValueKindFloat32, "float", types.TypeCompatibilityLossless},
+		{types.QValueKindFloat64, "float", types.TypeCompatibilityLossy},
+		{types.QValueKindInt32, "double", types.TypeCompatibilityLossless},
+		{types.QValueKindArrayFloat64, "double", types.TypeCompatibilityLossless},
+		{types.QValueKindDate, "date", types.TypeCompatibilityLossless},
+		{types.QValueKindTimestampTZ, "date", types.TypeCompatibilityLossy},
+		{types.QValueKindUUID, "date", types.TypeCompatibilityInvalid},
+		{types.QValueKindINET, "ip", types.TypeCompatibilityLossy},
+		{types.QValueKindCIDR, "ip", types.TypeCompatibilityInvalid},
+		{types.QValueKindPoint, "geo_point", types.TypeCompatibilityLossless},
+		{types.QValueKindGeometry, "geo_point", types.TypeCompatibilityInvalid},
+		{types.QValueKindJSON, "flattened", types.TypeCompatibilityLossy},
+		{types.QValueKindString, "object", types.TypeCompatibilityInvalid},
+		{types.QValueKindString, "search_as_you_type", types.TypeCompatibilityLossless},
+		{types.QValueKindString, "percolator", types.TypeCompatibilityInvalid},
+	} {
+		compatibility, _ := typeOverrideCompatibility(tc.kind, tc.fieldType)
+		require.Equal(t, tc.expected, compatibility, "%s as %s", tc.kind, tc.fieldType)
+	}
+}
+
+func TestDocumentValue(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "POINT(1 2)", documentValue(types.QValueGeometry{Val: "SRID=4326;POINT(1 2)"}))
+	require.Equal(t, "POLYGON((0 0,1 0,1 1,0 0))", documentValue(types.QValueGeography{Val: "POLYGON((0 0,1 0,1 1,0 0))"}))
+	require.Equal(t, json.RawMessage(`[1,"a"]`), documentValue(types.QValueJSON{Val: `[1,"a"]`}))
+}
+
+func TestPhysicalIndexName(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "items_resync_peerdb_1700000000", physicalIndexName("items_resync", time.Unix(1700000000, 0)))
+}
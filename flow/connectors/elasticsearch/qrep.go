@@ -109,12 +109,7 @@ func (esc *ElasticsearchConnector) SyncQRepRecords(ctx context.Context, config *
 			docId = upsertKeyColsHash(qRecord, upsertKeyColIndices)
 		}
 		for i, field := range schema.Fields {
-			if r, ok := qRecord[i].(types.QValueJSON); ok { // JSON is stored as a string, fix that
-				qRecordJsonMap[field.Name] = json.RawMessage(
-					shared.UnsafeFastStringToReadOnlyBytes(r.Val))
-			} else {
-				qRecordJsonMap[field.Name] = qRecord[i].Value()
-			}
+			qRecordJsonMap[field.Name] = documentValue(qRecord[i])
 		}
 		qRecordJsonBytes, err := json.Marshal(qRecordJsonMap)
 		if err != nil {
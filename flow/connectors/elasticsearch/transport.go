@@ -0,0 +1,75 @@
+package connelasticsearch
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+const (
+	awsServiceOpenSearch           = "es"
+	awsServiceOpenSearchServerless = "aoss"
+)
+
+// sigV4Transport signs every request for Amazon OpenSearch Service.
+// The payload hash is always sent since OpenSearch Serverless rejects requests without it.
+type sigV4Transport struct {
+	next        http.RoundTripper
+	signer      *v4.Signer
+	credentials aws.CredentialsProvider
+	region      string
+	service     string
+}
+
+func (t *sigV4Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrip must not modify the request it was given
+	signedReq := req.Clone(req.Context())
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if closeErr := req.Body.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("[elasticsearch] failed to read request body for signing: %w", err)
+		}
+		signedReq.Body = io.NopCloser(bytes.NewReader(body))
+		signedReq.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+	payloadHash := sha256.Sum256(body)
+	payloadHashHex := hex.EncodeToString(payloadHash[:])
+	signedReq.Header.Set("X-Amz-Content-Sha256", payloadHashHex)
+
+	creds, err := t.credentials.Retrieve(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("[elasticsearch] failed to retrieve AWS credentials: %w", err)
+	}
+	if err := t.signer.SignHTTP(req.Context(), creds, signedReq, payloadHashHex, t.service, t.region, time.Now()); err != nil {
+		return nil, fmt.Errorf("[elasticsearch] failed to sign request: %w", err)
+	}
+	return t.next.RoundTrip(signedReq)
+}
+
+// productHeaderTransport lets the client talk to OpenSearch, which does not send the header
+// the Elasticsearch client checks to verify it is connected to a genuine Elasticsearch server
+type productHeaderTransport struct {
+	next http.RoundTripper
+}
+
+func (t *productHeaderTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.next.RoundTrip(req)
+	if err == nil && res.Header.Get("X-Elastic-Product") == "" {
+		res.Header.Set("X-Elastic-Product", "Elasticsearch")
+	}
+	return res, err
+}
@@ -30,6 +30,7 @@ const (
 	lastSyncStateTableName       = "metadata_last_sync_state"
 	qrepTableName                = "metadata_qrep_partitions"
 	qrepPartitionRangesTableName = "metadata_qrep_offloaded_partition_ranges"
+	qrepCheckpointsTableName     = "metadata_qrep_partition_checkpoints"
 )
 
 type PostgresMetadata struct {
@@ -327,6 +328,65 @@ func OffloadPartitionRanges(
 		return fmt.Errorf("failed to clear existing partition ranges: %w", err)
 	}
 
+	if err := copyPartitionRanges(ctx, tx, key, parentMirrorName, runUUID, partitions); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit partition ranges: %w", err)
+	}
+	return nil
+}
+
+// OffloadSplitPartitionRanges offloads the ranges of partitions split from a running partition,
+// next to the ranges already offloaded for the run
+func OffloadSplitPartitionRanges(
+	ctx context.Context,
+	pool shared.CatalogPool,
+	parentMirrorName string,
+	runUUID string,
+	partitions []*protos.QRepPartition,
+) error {
+	key, err := internal.PeerDBCurrentEncKey(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load current encryption key: %w", err)
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer shared.RollbackTx(tx, internal.LoggerFromCtx(ctx))
+
+	partitionIDs := make([]string, 0, len(partitions))
+	for _, partition := range partitions {
+		partitionIDs = append(partitionIDs, partition.PartitionId)
+	}
+	// split partition ids are stable, so a retried split replaces its own rows
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM `+qrepPartitionRangesTableName+` WHERE run_uuid=$1 AND partition_uuid=ANY($2)`, runUUID, partitionIDs,
+	); err != nil {
+		return fmt.Errorf("failed to clear existing partition ranges: %w", err)
+	}
+
+	if err := copyPartitionRanges(ctx, tx, key, parentMirrorName, runUUID, partitions); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit partition ranges: %w", err)
+	}
+	return nil
+}
+
+func copyPartitionRanges(
+	ctx context.Context,
+	tx pgx.Tx,
+	key shared.PeerDBEncKey,
+	parentMirrorName string,
+	runUUID string,
+	partitions []*protos.QRepPartition,
+) error {
 	insertRows := make([][]any, 0, len(partitions))
 	for _, partition := range partitions {
 		if partition.Range == nil {
@@ -353,10 +413,6 @@ func OffloadPartitionRanges(
 	); err != nil {
 		return fmt.Errorf("failed to persist encrypted partition ranges: %w", err)
 	}
-
-	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit partition ranges: %w", err)
-	}
 	return nil
 }
 
@@ -426,6 +482,87 @@ func RestoreOffloadedPartitionRanges(
 	return nil
 }
 
+// QRepPartitionCheckpoint records how far a partition replicated in chunks got
+type QRepPartitionCheckpoint struct {
+	// range left to replicate, nil once the partition completed
+	Remaining  *protos.PartitionRange
+	RowsSynced int64
+	NextChunk  int32
+}
+
+// LoadQRepPartitionCheckpoint returns the last checkpoint saved for a partition, or nil if there is none
+func LoadQRepPartitionCheckpoint(
+	ctx context.Context,
+	pool shared.CatalogPool,
+	runUUID string,
+	partitionID string,
+) (*QRepPartitionCheckpoint, error) {
+	var checkpoint QRepPartitionCheckpoint
+	var encKeyID pgtype.Text
+	var payload []byte
+	if err := pool.QueryRow(ctx,
+		`SELECT next_chunk,rows_synced,enc_key_id,range_payload FROM `+qrepCheckpointsTableName+
+			` WHERE run_uuid=$1 AND partition_uuid=$2`,
+		runUUID, partitionID,
+	).Scan(&checkpoint.NextChunk, &checkpoint.RowsSynced, &encKeyID, &payload); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query partition checkpoint: %w", err)
+	}
+
+	if payload != nil {
+		decrypted, err := internal.Decrypt(ctx, encKeyID.String, payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt partition checkpoint for %s: %w", partitionID, err)
+		}
+		var remaining protos.PartitionRange
+		if err := proto.Unmarshal(decrypted, &remaining); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal partition checkpoint for %s: %w", partitionID, err)
+		}
+		checkpoint.Remaining = &remaining
+	}
+	return &checkpoint, nil
+}
+
+// SaveQRepPartitionCheckpoint replaces the checkpoint of a partition, its range is encrypted like offloaded ranges
+func SaveQRepPartitionCheckpoint(
+	ctx context.Context,
+	pool shared.CatalogPool,
+	parentMirrorName string,
+	runUUID string,
+	partitionID string,
+	checkpoint *QRepPartitionCheckpoint,
+) error {
+	var encKeyID pgtype.Text
+	var payload []byte
+	if checkpoint.Remaining != nil {
+		key, err := internal.PeerDBCurrentEncKey(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load current encryption key: %w", err)
+		}
+		marshaled, err := proto.Marshal(checkpoint.Remaining)
+		if err != nil {
+			return fmt.Errorf("failed to marshal partition checkpoint: %w", err)
+		}
+		if payload, err = key.Encrypt(marshaled); err != nil {
+			return fmt.Errorf("failed to encrypt partition checkpoint: %w", err)
+		}
+		encKeyID = pgtype.Text{String: key.ID, Valid: true}
+	}
+
+	if _, err := pool.Exec(ctx,
+		`INSERT INTO `+qrepCheckpointsTableName+
+			` (parent_mirror_name,run_uuid,partition_uuid,next_chunk,rows_synced,enc_key_id,range_payload)`+
+			` VALUES ($1,$2,$3,$4,$5,$6,$7) ON CONFLICT (run_uuid,partition_uuid) DO UPDATE SET`+
+			` next_chunk=$4,rows_synced=$5,enc_key_id=$6,range_payload=$7,updated_at=NOW()`,
+		parentMirrorName, runUUID, partitionID, checkpoint.NextChunk, checkpoint.RowsSynced, encKeyID, payload,
+	); err != nil {
+		return fmt.Errorf("failed to save partition checkpoint: %w", err)
+	}
+	return nil
+}
+
 func (p *PostgresMetadata) SyncFlowCleanup(ctx context.Context, jobName string) error {
 	tx, err := p.pool.Begin(ctx)
 	if err != nil {
@@ -457,5 +594,9 @@ func SyncFlowCleanupInTx(ctx context.Context, tx pgx.Tx, jobName string) error {
 		return err
 	}
 
+	if _, err := tx.Exec(ctx, `DELETE FROM `+qrepCheckpointsTableName+` WHERE parent_mirror_name = $1`, jobName); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -14,6 +14,8 @@ import (
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 
@@ -22,6 +24,7 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
 	"github.com/PeerDB-io/peerdb/flow/pkg/common"
+	peerdb_mongo "github.com/PeerDB-io/peerdb/flow/pkg/mongo"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
@@ -51,12 +54,14 @@ type Namespace struct {
 }
 
 type ChangeEvent struct {
-	FullDocument  *bson.Raw      `bson:"fullDocument,omitempty"`
-	WallTime      *time.Time     `bson:"wallTime,omitempty"`
-	Ns            Namespace      `bson:"ns"`
-	OperationType string         `bson:"operationType"`
-	DocumentKey   bson.Raw       `bson:"documentKey,omitempty"`
-	ClusterTime   bson.Timestamp `bson:"clusterTime"`
+	FullDocument *bson.Raw `bson:"fullDocument,omitempty"`
+	// pre-image of updates, replaces and deletes, present when the collection records them
+	FullDocumentBeforeChange *bson.Raw      `bson:"fullDocumentBeforeChange,omitempty"`
+	WallTime                 *time.Time     `bson:"wallTime,omitempty"`
+	Ns                       Namespace      `bson:"ns"`
+	OperationType            string         `bson:"operationType"`
+	DocumentKey              bson.Raw       `bson:"documentKey,omitempty"`
+	ClusterTime              bson.Timestamp `bson:"clusterTime"`
 }
 
 const mongoClockOffsetTTL = time.Hour
@@ -134,11 +139,19 @@ func (w *changeStreamWrapper) Close() error {
 
 func (c *MongoConnector) GetTableSchema(
 	ctx context.Context,
-	_ map[string]string,
+	env map[string]string,
 	internalVersion uint32,
 	_ protos.TypeSystem,
 	tableMappings []*protos.TableMapping,
 ) (map[string]*protos.TableSchema, error) {
+	flatten, err := internal.PeerDBMongoDBFlattenDocuments(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	if flatten {
+		return c.getFlattenedTableSchema(ctx, env, tableMappings)
+	}
+
 	result := make(map[string]*protos.TableSchema, len(tableMappings))
 	idFieldDescription := &protos.FieldDescription{
 		Name:         DefaultDocumentKeyColumnName,
@@ -174,6 +187,67 @@ func (c *MongoConnector) GetTableSchema(
 	return result, nil
 }
 
+// getFlattenedTableSchema infers typed columns for every collection from a sample of its
+// documents, next to the _id key and the overflow column
+func (c *MongoConnector) getFlattenedTableSchema(
+	ctx context.Context,
+	env map[string]string,
+	tableMappings []*protos.TableMapping,
+) (map[string]*protos.TableSchema, error) {
+	sampleSize, err := internal.PeerDBMongoDBFlattenSampleSize(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	nullableEnabled, err := internal.PeerDBNullable(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*protos.TableSchema, len(tableMappings))
+	for _, tm := range tableMappings {
+		table, err := common.ParseTableIdentifier(tm.SourceTableIdentifier)
+		if err != nil {
+			return nil, err
+		}
+		documents, err := sampleDocuments(ctx, c.client.Database(table.Namespace).Collection(table.Table), sampleSize)
+		if err != nil {
+			return nil, err
+		}
+		inferred, err := inferColumns(documents)
+		if err != nil {
+			return nil, fmt.Errorf("failed to infer columns of %s: %w", tm.SourceTableIdentifier, err)
+		}
+		c.logger.Info("[mongo] inferred columns from sampled documents",
+			slog.String("table", tm.SourceTableIdentifier),
+			slog.Int("documents", len(documents)),
+			slog.Int("columns", len(inferred)))
+
+		columns := make([]*protos.FieldDescription, 0, len(inferred)+2)
+		columns = append(columns, &protos.FieldDescription{
+			Name:         DefaultDocumentKeyColumnName,
+			Type:         string(types.QValueKindString),
+			TypeModifier: -1,
+			Nullable:     false,
+		})
+		columns = append(columns, inferred...)
+		columns = append(columns, &protos.FieldDescription{
+			Name:         DefaultOverflowColumnName,
+			Type:         string(types.QValueKindJSON),
+			TypeModifier: -1,
+			Nullable:     false,
+		})
+		result[tm.SourceTableIdentifier] = &protos.TableSchema{
+			TableIdentifier:       tm.SourceTableIdentifier,
+			PrimaryKeyColumns:     []string{DefaultDocumentKeyColumnName},
+			IsReplicaIdentityFull: true,
+			System:                protos.TypeSystem_Q,
+			NullableEnabled:       nullableEnabled,
+			Columns:               columns,
+		}
+	}
+	return result, nil
+}
+
 func (c *MongoConnector) SetupReplication(
 	ctx context.Context,
 	catalogPool shared.CatalogPool,
@@ -206,7 +280,7 @@ func (c *MongoConnector) SetupReplication(
 			}
 		}
 	}
-	err = c.metadataStore.SetLastOffset(ctx, input.FlowJobName, model.CdcCheckpoint{
+	err = c.SetLastOffset(ctx, input.FlowJobName, model.CdcCheckpoint{
 		Text: base64.StdEncoding.EncodeToString(resumeToken),
 	})
 	if err != nil {
@@ -253,6 +327,10 @@ func (c *MongoConnector) PullRecords(
 		// getMore calls fall back to the server default (up to 16 MiB per batch).
 		// https://www.mongodb.com/docs/manual/reference/method/cursor.batchSize/
 		SetBatchSize(0)
+	if c.preImages {
+		// collections without changeStreamPreAndPostImages leave the field out
+		changeStreamOpts.SetFullDocumentBeforeChange(options.WhenAvailable)
+	}
 
 	var resumeToken bson.Raw
 	var err error
@@ -364,13 +442,60 @@ func (c *MongoConnector) PullRecords(
 		if text == "" {
 			return
 		}
-		if err := c.metadataStore.SetLastOffset(ctx, req.FlowJobName, model.CdcCheckpoint{Text: text}); err != nil {
+		if err := c.SetLastOffset(ctx, req.FlowJobName, model.CdcCheckpoint{Text: text}); err != nil {
 			c.logger.Error("failed to persist resume token", slog.String("resumeToken", text), slog.Any("error", err))
 		}
 	}
 
 	converter := NewDirectBsonConverter()
-	addRecordItems := func(documentKey bson.Raw, maybeFullDocument *bson.Raw, items *model.RecordItems, tableName string) error {
+	// flattened tables by source table, nil for collections replicated as a single document column
+	flattenedTables := make(map[string]*flattenedTable)
+	getFlattenedTable := func(sourceTableName string, destinationTableName string) *flattenedTable {
+		table, ok := flattenedTables[sourceTableName]
+		if !ok {
+			table = newFlattenedTable(req.TableNameSchemaMapping[destinationTableName],
+				req.TableNameMapping[sourceTableName].Exclude)
+			flattenedTables[sourceTableName] = table
+		}
+		return table
+	}
+	addFlattenedItems := func(
+		table *flattenedTable, maybeFullDocument *bson.Raw, items *model.RecordItems,
+		sourceTableName string, destinationTableName string,
+	) error {
+		if !hasDocument(maybeFullDocument) {
+			for column, value := range table.emptyValues() {
+				items.AddColumn(column, value)
+			}
+			return nil
+		}
+		delta := &protos.TableSchemaDelta{
+			SrcTableName:    sourceTableName,
+			DstTableName:    destinationTableName,
+			System:          protos.TypeSystem_Q,
+			NullableEnabled: req.TableNameSchemaMapping[destinationTableName].GetNullableEnabled(),
+		}
+		values, err := table.documentValues(*maybeFullDocument, converter, func(name string, kind types.QValueKind) bool {
+			delta.AddedColumns = append(delta.AddedColumns, flattenedFieldDescription(name, kind))
+			return true
+		})
+		if err != nil {
+			return fmt.Errorf("failed to flatten document: %w", err)
+		}
+		if len(delta.AddedColumns) > 0 {
+			req.RecordStream.AddSchemaDelta(req.TableNameMapping, delta)
+			c.logger.Info("[mongo] detected new fields in change stream",
+				slog.String("table", sourceTableName), slog.Any("delta", delta))
+		}
+		for column, value := range values {
+			items.AddColumn(column, value)
+		}
+		return nil
+	}
+	addRecordItems := func(
+		documentKey bson.Raw, maybeFullDocument *bson.Raw, items *model.RecordItems,
+		tableName string, destinationTableName string,
+	) error {
 		if len(documentKey) > 0 {
 			rv := documentKey.Lookup(DefaultDocumentKeyColumnName)
 			if rv.IsZero() || rv.Type == bson.TypeNull {
@@ -385,7 +510,11 @@ func (c *MongoConnector) PullRecords(
 			return fmt.Errorf("document key is nil")
 		}
 
-		if maybeFullDocument != nil && len(*maybeFullDocument) > 0 {
+		if table := getFlattenedTable(tableName, destinationTableName); table != nil {
+			return addFlattenedItems(table, maybeFullDocument, items, tableName, destinationTableName)
+		}
+
+		if hasDocument(maybeFullDocument) {
 			qValue, err := converter.QValueJSONFromDocument(*maybeFullDocument)
 			if err != nil {
 				return fmt.Errorf("failed to convert document: %w", err)
@@ -393,10 +522,10 @@ func (c *MongoConnector) PullRecords(
 			items.AddColumn(fullDocumentColumnName, qValue)
 		} else {
 			// `fullDocument` field will not exist in the following scenarios:
-			// 1) operationType is 'delete'
+			// 1) operationType is 'delete' and the collection records no pre-images
 			// 2) document is deleted / collection is dropped in between update and lookup
 			// 3) update changes the values for at least one of the fields in that collection's
-			//    shard key (although sharding is not supported today)
+			//    shard key, the update branch looks those up again by _id
 			items.AddColumn(fullDocumentColumnName, types.QValueJSON{Val: "{}"})
 		}
 		return nil
@@ -534,7 +663,7 @@ func (c *MongoConnector) PullRecords(
 		items := model.NewMongoRecordItems(2)
 		switch operationType(changeEvent.OperationType) {
 		case operationTypeInsert:
-			if err := addRecordItems(changeEvent.DocumentKey, changeEvent.FullDocument, &items, sourceTableName); err != nil {
+			if err := addRecordItems(changeEvent.DocumentKey, changeEvent.FullDocument, &items, sourceTableName, destinationTableName); err != nil {
 				return fmt.Errorf("failed to process document: %w", err)
 			}
 
@@ -547,12 +676,36 @@ func (c *MongoConnector) PullRecords(
 				return fmt.Errorf("failed to add insert record: %w", err)
 			}
 		case operationTypeUpdate, operationTypeReplace:
-			if err := addRecordItems(changeEvent.DocumentKey, changeEvent.FullDocument, &items, sourceTableName); err != nil {
+			fullDocument := changeEvent.FullDocument
+			if fullDocument == nil && hasShardKey(changeEvent.DocumentKey) {
+				document, err := c.lookupDocumentByID(ctx, changeEvent.Ns, changeEvent.DocumentKey)
+				if err != nil {
+					return err
+				}
+				if document == nil {
+					// deleted since, the delete event for it follows in the stream
+					c.logger.Debug("[mongo] skipping update of a document that no longer exists",
+						slog.String("table", sourceTableName))
+					continue
+				}
+				fullDocument = &document
+			}
+			if err := addRecordItems(changeEvent.DocumentKey, fullDocument, &items, sourceTableName, destinationTableName); err != nil {
 				return fmt.Errorf("failed to process document: %w", err)
 			}
+			var oldItems model.RecordItems
+			if hasDocument(changeEvent.FullDocumentBeforeChange) {
+				oldItems = model.NewMongoRecordItems(2)
+				if err := addRecordItems(
+					changeEvent.DocumentKey, changeEvent.FullDocumentBeforeChange, &oldItems, sourceTableName, destinationTableName,
+				); err != nil {
+					return fmt.Errorf("failed to process pre-image: %w", err)
+				}
+			}
 
 			if err := addRecord(ctx, &model.UpdateRecord[model.RecordItems]{
 				BaseRecord:           model.BaseRecord{CommitTimeNano: commitTimeNanos},
+				OldItems:             oldItems,
 				NewItems:             items,
 				SourceTableName:      sourceTableName,
 				DestinationTableName: destinationTableName,
@@ -560,7 +713,10 @@ func (c *MongoConnector) PullRecords(
 				return fmt.Errorf("failed to add update record: %w", err)
 			}
 		case operationTypeDelete:
-			if err := addRecordItems(changeEvent.DocumentKey, changeEvent.FullDocument, &items, sourceTableName); err != nil {
+			// deletes carry no fullDocument, the pre-image is the deleted document
+			if err := addRecordItems(
+				changeEvent.DocumentKey, changeEvent.FullDocumentBeforeChange, &items, sourceTableName, destinationTableName,
+			); err != nil {
 				return fmt.Errorf("failed to process document: %w", err)
 			}
 
@@ -633,6 +789,7 @@ func createPipeline(tableNameMapping map[string]model.NameAndExclude, excludedOp
 			{Key: "wallTime", Value: 1},
 			{Key: "documentKey", Value: 1},
 			{Key: "fullDocument", Value: 1},
+			{Key: "fullDocumentBeforeChange", Value: 1},
 			{Key: "ns", Value: 1},
 		}}},
 	)
@@ -640,6 +797,44 @@ func createPipeline(tableNameMapping map[string]model.NameAndExclude, excludedOp
 	return pipeline, nil
 }
 
+func hasDocument(document *bson.Raw) bool {
+	return document != nil && len(*document) > 0
+}
+
+// hasShardKey reports whether a document key carries shard key fields besides _id,
+// which change streams include for sharded collections only
+func hasShardKey(documentKey bson.Raw) bool {
+	elements, err := documentKey.Elements()
+	return err == nil && len(elements) > 1
+}
+
+// lookupDocumentByID fetches the current version of an updated document by _id alone.
+// updateLookup matches the whole document key, and on sharded collections that holds
+// the shard key values from before the update: when an update changes the shard key the
+// lookup misses and fullDocument is absent. Returns nil when the document is gone.
+func (c *MongoConnector) lookupDocumentByID(ctx context.Context, ns Namespace, documentKey bson.Raw) (bson.Raw, error) {
+	id := documentKey.Lookup(DefaultDocumentKeyColumnName)
+	if id.IsZero() {
+		return nil, exceptions.NewInvalidIdValueError(ns.Db + "." + ns.Coll)
+	}
+	// majority reads from the primary like updateLookup itself, and with no shard key in
+	// the filter mongos asks every shard
+	database := c.client.Database(ns.Db, options.Database().
+		SetReadPreference(readpref.Primary()).
+		SetReadConcern(readconcern.Majority()))
+	document, err := database.Collection(ns.Coll).FindOne(ctx,
+		bson.D{{Key: DefaultDocumentKeyColumnName, Value: id}},
+		options.FindOne().SetComment("PeerDB shard key update lookup"),
+	).Raw()
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to look up document %s in %s.%s: %w", id.String(), ns.Db, ns.Coll, err)
+	}
+	return document, nil
+}
+
 // This can happen if the resumeToken we are attempting to `ResumeAfter` refers to a table that has been
 // filtered out of the change stream pipeline (for example, if a user pauses and edits a mirror). If
 // this happens, we decode the resumeToken and extract its operation time, and start a new changeStream
@@ -686,9 +881,31 @@ func (c *MongoConnector) SetupReplConn(ctx context.Context, env map[string]strin
 	if len(c.excludedOps) > 0 {
 		c.logger.Info("excluding operation types from replication", slog.Any("operationTypes", c.excludedOps))
 	}
+
+	preImages, err := c.preImagesSupported(ctx)
+	if err != nil {
+		c.logger.Warn("[mongo] unable to check pre-image support, replicating without pre-images", slog.Any("error", err))
+	}
+	c.preImages = preImages
 	return nil
 }
 
+// preImagesSupported reports whether change streams on this server accept fullDocumentBeforeChange
+func (c *MongoConnector) preImagesSupported(ctx context.Context) (bool, error) {
+	if c.client == nil {
+		return false, errors.New("MongoDB client is nil")
+	}
+	version, err := c.GetVersion(ctx)
+	if err != nil {
+		return false, err
+	}
+	cmp, err := peerdb_mongo.CompareServerVersions(version, peerdb_mongo.MinPreImagesVersion)
+	if err != nil {
+		return false, err
+	}
+	return cmp >= 0, nil
+}
+
 func (c *MongoConnector) UpdateReplStateLastOffset(ctx context.Context, lastOffset model.CdcCheckpoint) error {
 	return nil
 }
@@ -14,11 +14,13 @@ import (
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 
+	metadata "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
 	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
 type iterationType int
@@ -28,6 +30,10 @@ const (
 	idle iterationType = iota
 	// insert returns true on Next() with a generated insert event
 	insert
+	// update returns true on Next() with a generated update event carrying a pre-image
+	updateWithPreImage
+	// delete returns true on Next() with a generated delete event carrying a pre-image
+	deleteWithPreImage
 )
 
 type mockChangeStream struct {
@@ -63,6 +69,14 @@ func (cs *mockChangeStream) Next(context.Context) bool {
 		cs.current = newInsertChangeEvent(bson.NewObjectID(), ts)
 		cs.err = nil
 		return true
+	case updateWithPreImage:
+		cs.current = newPreImageChangeEvent(operationTypeUpdate, bson.NewObjectID(), ts)
+		cs.err = nil
+		return true
+	case deleteWithPreImage:
+		cs.current = newPreImageChangeEvent(operationTypeDelete, bson.NewObjectID(), ts)
+		cs.err = nil
+		return true
 	case idle:
 		cs.err = context.DeadlineExceeded
 		return false
@@ -79,18 +93,17 @@ func (cs *mockChangeStream) Close() error          { return nil }
 
 var _ ChangeStream = (*mockChangeStream)(nil)
 
-type mockMetadataStore struct{ persisted []model.CdcCheckpoint }
-
-func (ms *mockMetadataStore) GetLastOffset(context.Context, string) (model.CdcCheckpoint, error) {
-	if n := len(ms.persisted); n > 0 {
-		return ms.persisted[n-1], nil
-	}
-	return model.CdcCheckpoint{}, nil
-}
-
-func (ms *mockMetadataStore) SetLastOffset(_ context.Context, _ string, off model.CdcCheckpoint) error {
-	ms.persisted = append(ms.persisted, off)
-	return nil
+// newTestMetadata returns the catalog backed store the connector persists offsets to, cleaned up after the test
+func newTestMetadata(t *testing.T, flowJobName string) *metadata.PostgresMetadata {
+	t.Helper()
+	pool, err := internal.GetCatalogConnectionPoolFromEnv(t.Context())
+	require.NoError(t, err)
+	store := metadata.NewPostgresMetadataFromCatalog(internal.LoggerFromCtx(t.Context()), pool)
+	require.NoError(t, store.SyncFlowCleanup(t.Context(), flowJobName))
+	t.Cleanup(func() {
+		require.NoError(t, store.SyncFlowCleanup(context.Background(), flowJobName))
+	})
+	return store
 }
 
 func drainMongoCDCRecordsAsync(t *testing.T, stream *model.CDCStream[model.RecordItems]) {
@@ -124,15 +137,15 @@ func TestChangeStreamIdleConnectionAdvancesOffset(t *testing.T) {
 	ctx := t.Context()
 
 	mockCS := newMockChangeStream(t, idle, idle, insert, idle)
-	mockStore := &mockMetadataStore{}
+	store := newTestMetadata(t, "test_mongo_idle")
 	connector := &MongoConnector{
-		logger: internal.LoggerFromCtx(t.Context()),
+		PostgresMetadata: store,
+		logger:           internal.LoggerFromCtx(t.Context()),
 		createChangeStream: func(
 			context.Context, mongo.Pipeline, ...options.Lister[options.ChangeStreamOptions],
 		) (ChangeStream, error) {
 			return mockCS, nil
 		},
-		metadataStore: mockStore,
 	}
 
 	otelManager, err := otel_metrics.NewOtelManager(ctx, "test", false)
@@ -149,9 +162,10 @@ func TestChangeStreamIdleConnectionAdvancesOffset(t *testing.T) {
 	drainMongoCDCRecordsAsync(t, req.RecordStream)
 
 	require.NoError(t, connector.PullRecords(ctx, shared.CatalogPool{}, otelManager, req))
-	require.Len(t, mockStore.persisted, 2)
-	require.Equal(t, b64(toResumeToken(mockCS.emittedTimes[0])), mockStore.persisted[0].Text)
-	require.Equal(t, b64(toResumeToken(mockCS.emittedTimes[1])), mockStore.persisted[1].Text)
+	// idle timeouts before the insert persist their resume token, later ones are only checkpointed in the stream
+	persisted, err := store.GetLastOffset(ctx, req.FlowJobName)
+	require.NoError(t, err)
+	require.Equal(t, b64(toResumeToken(mockCS.emittedTimes[1])), persisted.Text)
 	require.Equal(t, b64(toResumeToken(mockCS.emittedTimes[3])), req.RecordStream.GetLastCheckpoint().Text)
 }
 
@@ -161,10 +175,11 @@ func TestChangeStreamRecreationFailureReturnsError(t *testing.T) {
 	// idle triggers the DeadlineExceeded path for the mock, which closes
 	// the stream and calls createChangeStream again.
 	mockCS := newMockChangeStream(t, idle)
-	mockStore := &mockMetadataStore{}
+	store := newTestMetadata(t, "test_mongo_recreate_failure")
 	streamCreations := 0
 	connector := &MongoConnector{
-		logger: internal.LoggerFromCtx(t.Context()),
+		PostgresMetadata: store,
+		logger:           internal.LoggerFromCtx(t.Context()),
 		createChangeStream: func(
 			context.Context, mongo.Pipeline, ...options.Lister[options.ChangeStreamOptions],
 		) (ChangeStream, error) {
@@ -174,7 +189,6 @@ func TestChangeStreamRecreationFailureReturnsError(t *testing.T) {
 			}
 			return nil, errors.New("stream creation failed")
 		},
-		metadataStore: mockStore,
 	}
 
 	otelManager, err := otel_metrics.NewOtelManager(ctx, "test", false)
@@ -194,8 +208,9 @@ func TestChangeStreamRecreationFailureReturnsError(t *testing.T) {
 	require.ErrorContains(t, err, "failed to recreate change stream")
 	require.ErrorContains(t, err, "stream creation failed")
 	require.Equal(t, 2, streamCreations)
-	require.Len(t, mockStore.persisted, 1)
-	require.Equal(t, b64(toResumeToken(mockCS.emittedTimes[0])), mockStore.persisted[0].Text)
+	persisted, err := store.GetLastOffset(ctx, req.FlowJobName)
+	require.NoError(t, err)
+	require.Equal(t, b64(toResumeToken(mockCS.emittedTimes[0])), persisted.Text)
 }
 
 func b64(raw bson.Raw) string {
@@ -223,6 +238,32 @@ func TestResumeTokenHelpersRoundTrip(t *testing.T) {
 	require.Equal(t, toBsonTs(ts), bsonTs)
 }
 
+// newPreImageChangeEvent builds an event of a collection with changeStreamPreAndPostImages,
+// updates change val from "before" to "after" and deletes only carry the pre-image
+func newPreImageChangeEvent(op operationType, id bson.ObjectID, ts time.Time) bson.Raw {
+	event := bson.D{
+		{Key: "ns", Value: bson.D{
+			{Key: "db", Value: "db"},
+			{Key: "coll", Value: "coll"},
+		}},
+		{Key: "operationType", Value: string(op)},
+		{Key: "documentKey", Value: bson.D{{Key: "_id", Value: id}}},
+		{Key: "fullDocumentBeforeChange", Value: bson.D{
+			{Key: "_id", Value: id},
+			{Key: "val", Value: "before"},
+		}},
+		{Key: "clusterTime", Value: toBsonTs(ts)},
+	}
+	if op != operationTypeDelete {
+		event = append(event, bson.E{Key: "fullDocument", Value: bson.D{
+			{Key: "_id", Value: id},
+			{Key: "val", Value: "after"},
+		}})
+	}
+	raw, _ := bson.Marshal(event)
+	return raw
+}
+
 func TestCreatePipeline(t *testing.T) {
 	tableNameMapping := map[string]model.NameAndExclude{"db.coll": {Name: "db_coll"}}
 
@@ -256,7 +297,9 @@ func TestCreatePipeline(t *testing.T) {
 
 	requireProjectFields := func(t *testing.T, pipeline mongo.Pipeline) {
 		t.Helper()
-		for _, field := range []string{"operationType", "clusterTime", "documentKey", "fullDocument", "ns"} {
+		for _, field := range []string{
+			"operationType", "clusterTime", "documentKey", "fullDocument", "fullDocumentBeforeChange", "ns",
+		} {
 			require.False(t, lookupInPipeline(t, pipeline, "$project", field).IsZero())
 		}
 	}
@@ -364,6 +407,10 @@ func TestDecodeEvent(t *testing.T) {
 		{Key: "_id", Value: id},
 		{Key: "val", Value: "test"},
 	})
+	preImage := mustMarshal(bson.D{
+		{Key: "_id", Value: id},
+		{Key: "val", Value: "before"},
+	})
 
 	cases := []struct {
 		name    string
@@ -405,6 +452,17 @@ func TestDecodeEvent(t *testing.T) {
 				ClusterTime:   toBsonTs(deleteTs),
 			},
 		},
+		{
+			name: "delete carries the pre-image",
+			raw:  newPreImageChangeEvent(operationTypeDelete, id, deleteTs),
+			want: ChangeEvent{
+				Ns:                       Namespace{Db: "db", Coll: "coll"},
+				OperationType:            "delete",
+				DocumentKey:              mustMarshal(bson.D{{Key: "_id", Value: id}}),
+				FullDocumentBeforeChange: &preImage,
+				ClusterTime:              toBsonTs(deleteTs),
+			},
+		},
 		{
 			name: "empty document decodes to zero value",
 			raw:  mustMarshal(bson.D{}),
@@ -442,3 +500,72 @@ func TestCreatePipelineProjectsWallTime(t *testing.T) {
 	require.True(t, ok)
 	require.Contains(t, projectFields, bson.E{Key: "wallTime", Value: 1})
 }
+
+func TestHasShardKey(t *testing.T) {
+	mustMarshal := func(v bson.D) bson.Raw {
+		t.Helper()
+		raw, err := bson.Marshal(v)
+		require.NoError(t, err)
+		return raw
+	}
+	id := bson.NewObjectID()
+
+	require.False(t, hasShardKey(mustMarshal(bson.D{{Key: "_id", Value: id}})))
+	require.True(t, hasShardKey(mustMarshal(bson.D{{Key: "region", Value: "eu"}, {Key: "_id", Value: id}})))
+	require.False(t, hasShardKey(nil))
+}
+
+func TestChangeStreamPreImages(t *testing.T) {
+	ctx := t.Context()
+
+	mockCS := newMockChangeStream(t, updateWithPreImage, deleteWithPreImage, insert, idle)
+	connector := &MongoConnector{
+		PostgresMetadata: newTestMetadata(t, "test_mongo_pre_images"),
+		logger:           internal.LoggerFromCtx(t.Context()),
+		createChangeStream: func(
+			context.Context, mongo.Pipeline, ...options.Lister[options.ChangeStreamOptions],
+		) (ChangeStream, error) {
+			return mockCS, nil
+		},
+		preImages: true,
+	}
+
+	otelManager, err := otel_metrics.NewOtelManager(ctx, "test", false)
+	require.NoError(t, err)
+
+	req := &model.PullRecordsRequest[model.RecordItems]{
+		FlowJobName:            "test_mongo_pre_images",
+		RecordStream:           model.NewCDCStream[model.RecordItems](100),
+		TableNameMapping:       map[string]model.NameAndExclude{"db.coll": {Name: "db_coll"}},
+		TableNameSchemaMapping: map[string]*protos.TableSchema{},
+		MaxBatchSize:           10000,
+		IdleTimeout:            time.Minute,
+		InternalVersion:        shared.InternalVersion_Latest,
+	}
+	require.NoError(t, connector.PullRecords(ctx, shared.CatalogPool{}, otelManager, req))
+
+	var records []model.Record[model.RecordItems]
+	for record := range req.RecordStream.GetRecords() {
+		records = append(records, record)
+	}
+	require.Len(t, records, 3)
+	docValue := func(items model.RecordItems) string {
+		t.Helper()
+		qv, ok := items.GetColumnValue(DefaultFullDocumentColumnName).(types.QValueJSON)
+		require.True(t, ok)
+		return qv.Val
+	}
+
+	update, ok := records[0].(*model.UpdateRecord[model.RecordItems])
+	require.True(t, ok)
+	require.Contains(t, docValue(update.OldItems), `"val":"before"`)
+	require.Contains(t, docValue(update.NewItems), `"val":"after"`)
+
+	del, ok := records[1].(*model.DeleteRecord[model.RecordItems])
+	require.True(t, ok)
+	require.Contains(t, docValue(del.Items), `"val":"before"`)
+
+	insert, ok := records[2].(*model.InsertRecord[model.RecordItems])
+	require.True(t, ok)
+	require.Contains(t, docValue(insert.Items), `"val":"test"`)
+}
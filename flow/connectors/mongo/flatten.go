@@ -0,0 +1,493 @@
+package connmongo
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/x/bsonx/bsoncore"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+const (
+	// DefaultOverflowColumnName holds the fields of a flattened document that have no typed
+	// column of a fitting type, as one JSON object keyed by dotted field path
+	DefaultOverflowColumnName = "_overflow"
+	// flattenSeparator joins the path of a nested field into its column name
+	flattenSeparator = "_"
+	// subdocuments nested deeper than this are kept whole in a JSON column
+	maxFlattenDepth = 3
+	// fields beyond this many typed columns per collection go to the overflow column
+	maxFlattenedColumns = 500
+)
+
+// flatField is a leaf of a flattened document
+type flatField struct {
+	// column is empty when the path cannot name a column, e.g. it has an empty field name
+	column string
+	// dotted source path, the key of the field in the overflow column
+	path  string
+	value bsoncore.Value
+}
+
+// flattenDocument lists the leaves of a document in document order, descending into
+// subdocuments up to maxFlattenDepth levels deep. The top level _id is left out since it
+// has a column of its own.
+func flattenDocument(doc bsoncore.Document) ([]flatField, error) {
+	var fields []flatField
+	if err := appendFlatFields(&fields, doc, nil, 1); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func appendFlatFields(fields *[]flatField, doc bsoncore.Document, path []string, depth int) error {
+	elements, err := doc.Elements()
+	if err != nil {
+		return fmt.Errorf("failed to read document elements: %w", err)
+	}
+	for _, element := range elements {
+		key := element.Key()
+		if depth == 1 && key == DefaultDocumentKeyColumnName {
+			continue
+		}
+		fieldPath := append(path[:len(path):len(path)], key)
+		value := element.Value()
+		if value.Type == bsoncore.TypeEmbeddedDocument && depth < maxFlattenDepth {
+			// an empty subdocument is 5 bytes, it stays a leaf so the field is not lost
+			if subdocument := value.Document(); len(subdocument) > 5 {
+				if err := appendFlatFields(fields, subdocument, fieldPath, depth+1); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		var column string
+		if !slices.Contains(fieldPath, "") {
+			column = strings.Join(fieldPath, flattenSeparator)
+		}
+		*fields = append(*fields, flatField{column: column, path: strings.Join(fieldPath, "."), value: value})
+	}
+	return nil
+}
+
+// isReservedColumn reports column names a flattened field can never take
+func isReservedColumn(column string) bool {
+	return column == "" || column == DefaultDocumentKeyColumnName || column == DefaultOverflowColumnName
+}
+
+// bsonValueKind picks the column type for a value, false when the value carries no type
+// information: nulls and empty arrays
+func bsonValueKind(v bsoncore.Value) (types.QValueKind, bool) {
+	switch v.Type {
+	case bsoncore.TypeNull, bsoncore.TypeUndefined:
+		return "", false
+	case bsoncore.TypeDouble:
+		return types.QValueKindFloat64, true
+	case bsoncore.TypeString, bsoncore.TypeSymbol, bsoncore.TypeObjectID:
+		return types.QValueKindString, true
+	case bsoncore.TypeBoolean:
+		return types.QValueKindBoolean, true
+	case bsoncore.TypeInt32:
+		return types.QValueKindInt32, true
+	case bsoncore.TypeInt64:
+		return types.QValueKindInt64, true
+	case bsoncore.TypeDateTime:
+		return types.QValueKindTimestampTZ, true
+	case bsoncore.TypeDecimal128:
+		return types.QValueKindNumeric, true
+	case bsoncore.TypeBinary:
+		return types.QValueKindBytes, true
+	case bsoncore.TypeArray:
+		return arrayKind(v.Array())
+	default:
+		// subdocuments past the depth limit, regular expressions, internal timestamps, ...
+		return types.QValueKindJSON, true
+	}
+}
+
+// arrayKind types arrays of one scalar kind as array columns, anything else is JSON
+func arrayKind(arr bsoncore.Array) (types.QValueKind, bool) {
+	values, err := arr.Values()
+	if err != nil {
+		return types.QValueKindJSON, true
+	}
+	var elementKind types.QValueKind
+	for _, value := range values {
+		kind, ok := bsonValueKind(value)
+		if !ok {
+			// array columns cannot hold null elements
+			return types.QValueKindJSON, true
+		}
+		if elementKind == "" {
+			elementKind = kind
+		} else {
+			elementKind = mergeKinds(elementKind, kind)
+		}
+	}
+	switch elementKind {
+	case "":
+		return "", false
+	case types.QValueKindInt32:
+		return types.QValueKindArrayInt32, true
+	case types.QValueKindInt64:
+		return types.QValueKindArrayInt64, true
+	case types.QValueKindFloat64:
+		return types.QValueKindArrayFloat64, true
+	case types.QValueKindString:
+		return types.QValueKindArrayString, true
+	case types.QValueKindBoolean:
+		return types.QValueKindArrayBoolean, true
+	case types.QValueKindTimestampTZ:
+		return types.QValueKindArrayTimestampTZ, true
+	default:
+		return types.QValueKindJSON, true
+	}
+}
+
+// mergeKinds widens two kinds seen for the same field to one that holds both, JSON holds anything
+func mergeKinds(a types.QValueKind, b types.QValueKind) types.QValueKind {
+	if a == b {
+		return a
+	}
+	widen := func(narrow types.QValueKind, wide types.QValueKind) bool {
+		return (a == narrow && b == wide) || (a == wide && b == narrow)
+	}
+	switch {
+	case widen(types.QValueKindInt32, types.QValueKindInt64):
+		return types.QValueKindInt64
+	case widen(types.QValueKindInt32, types.QValueKindFloat64), widen(types.QValueKindInt64, types.QValueKindFloat64):
+		return types.QValueKindFloat64
+	case widen(types.QValueKindArrayInt32, types.QValueKindArrayInt64):
+		return types.QValueKindArrayInt64
+	case widen(types.QValueKindArrayInt32, types.QValueKindArrayFloat64), widen(types.QValueKindArrayInt64, types.QValueKindArrayFloat64):
+		return types.QValueKindArrayFloat64
+	default:
+		return types.QValueKindJSON
+	}
+}
+
+// inferColumns derives typed columns from sampled documents, in order of first appearance
+func inferColumns(documents []bson.Raw) ([]*protos.FieldDescription, error) {
+	kinds := make(map[string]types.QValueKind)
+	var order []string
+	for _, document := range documents {
+		fields, err := flattenDocument(bsoncore.Document(document))
+		if err != nil {
+			return nil, err
+		}
+		for _, field := range fields {
+			if isReservedColumn(field.column) {
+				continue
+			}
+			kind, ok := bsonValueKind(field.value)
+			if !ok {
+				continue
+			}
+			if known, seen := kinds[field.column]; seen {
+				kinds[field.column] = mergeKinds(known, kind)
+			} else if len(order) < maxFlattenedColumns {
+				kinds[field.column] = kind
+				order = append(order, field.column)
+			}
+		}
+	}
+
+	columns := make([]*protos.FieldDescription, 0, len(order))
+	for _, name := range order {
+		columns = append(columns, flattenedFieldDescription(name, kinds[name]))
+	}
+	return columns, nil
+}
+
+func flattenedFieldDescription(name string, kind types.QValueKind) *protos.FieldDescription {
+	return &protos.FieldDescription{
+		Name:         name,
+		Type:         string(kind),
+		TypeModifier: -1,
+		// documents are free to leave any field out
+		Nullable: true,
+	}
+}
+
+// sampleDocuments draws random documents of a collection for column inference
+func sampleDocuments(ctx context.Context, collection *mongo.Collection, sampleSize int32) ([]bson.Raw, error) {
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		bson.D{{Key: "$sample", Value: bson.D{{Key: "size", Value: sampleSize}}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample %s: %w", collection.Name(), err)
+	}
+	defer cursor.Close(ctx)
+
+	documents := make([]bson.Raw, 0, sampleSize)
+	for cursor.Next(ctx) {
+		documents = append(documents, append(bson.Raw(nil), cursor.Current...))
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to sample %s: %w", collection.Name(), err)
+	}
+	return documents, nil
+}
+
+// flattenedTable maps the documents of a flattened collection onto its typed columns
+type flattenedTable struct {
+	columns  map[string]types.QValueKind
+	excluded map[string]struct{}
+	numTyped int
+}
+
+// newFlattenedTable returns nil for collections replicated as a single document column,
+// a flattened schema is told apart by its overflow column
+func newFlattenedTable(schema *protos.TableSchema, excluded map[string]struct{}) *flattenedTable {
+	if schema == nil {
+		return nil
+	}
+	columns := make(map[string]types.QValueKind, len(schema.Columns))
+	flattened := false
+	numTyped := 0
+	for _, column := range schema.Columns {
+		if column.Name == DefaultOverflowColumnName {
+			flattened = true
+		} else if column.Name != DefaultDocumentKeyColumnName {
+			numTyped++
+		}
+		columns[column.Name] = types.QValueKind(column.Type)
+	}
+	if !flattened {
+		return nil
+	}
+	return &flattenedTable{columns: columns, excluded: excluded, numTyped: numTyped}
+}
+
+// documentValues converts a document into a value for every column of the table besides _id,
+// null for the fields the document lacks. Fields without a column are offered to addColumn
+// which returns whether to add a column of the value's kind, a nil addColumn sends them all
+// to the overflow column as it does with values their column's type cannot hold.
+func (t *flattenedTable) documentValues(
+	document bson.Raw,
+	converter *DirectBsonConverter,
+	addColumn func(name string, kind types.QValueKind) bool,
+) (map[string]types.QValue, error) {
+	fields, err := flattenDocument(bsoncore.Document(document))
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]types.QValue, len(t.columns))
+	var overflow []flatField
+	for _, field := range fields {
+		if _, ok := t.excluded[field.column]; ok {
+			continue
+		}
+		if _, taken := values[field.column]; taken || isReservedColumn(field.column) {
+			// different paths flattening to the same column name, first one wins
+			overflow = append(overflow, field)
+			continue
+		}
+		kind, known := t.columns[field.column]
+		if !known {
+			newKind, ok := bsonValueKind(field.value)
+			if !ok {
+				if field.value.Type != bsoncore.TypeNull && field.value.Type != bsoncore.TypeUndefined {
+					overflow = append(overflow, field)
+				}
+				continue
+			}
+			if addColumn == nil || t.numTyped >= maxFlattenedColumns || !addColumn(field.column, newKind) {
+				overflow = append(overflow, field)
+				continue
+			}
+			t.columns[field.column] = newKind
+			t.numTyped++
+			kind = newKind
+		}
+		qv, ok, err := bsonToQValue(kind, field.value, converter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert field %s: %w", field.path, err)
+		}
+		if !ok {
+			overflow = append(overflow, field)
+			continue
+		}
+		values[field.column] = qv
+	}
+
+	for column, kind := range t.columns {
+		if _, ok := values[column]; !ok && column != DefaultDocumentKeyColumnName && column != DefaultOverflowColumnName {
+			values[column] = types.QValueNull(kind)
+		}
+	}
+	overflowValue, err := converter.overflowJSON(overflow)
+	if err != nil {
+		return nil, err
+	}
+	values[DefaultOverflowColumnName] = overflowValue
+	return values, nil
+}
+
+// emptyValues is documentValues for events without a document, such as deletes
+func (t *flattenedTable) emptyValues() map[string]types.QValue {
+	values := make(map[string]types.QValue, len(t.columns))
+	for column, kind := range t.columns {
+		if column != DefaultDocumentKeyColumnName && column != DefaultOverflowColumnName {
+			values[column] = types.QValueNull(kind)
+		}
+	}
+	values[DefaultOverflowColumnName] = types.QValueJSON{Val: "{}"}
+	return values
+}
+
+// bsonToQValue converts a value into a column of the given kind, false when the kind cannot
+// hold the value and it belongs in the overflow column
+func bsonToQValue(kind types.QValueKind, v bsoncore.Value, converter *DirectBsonConverter) (types.QValue, bool, error) {
+	if v.Type == bsoncore.TypeNull || v.Type == bsoncore.TypeUndefined {
+		return types.QValueNull(kind), true, nil
+	}
+	switch kind {
+	case types.QValueKindJSON:
+		qv, err := converter.QValueJSONFromValue(v)
+		return qv, err == nil, err
+	case types.QValueKindFloat64:
+		if f, ok := bsonFloat64(v); ok {
+			return types.QValueFloat64{Val: f}, true, nil
+		}
+	case types.QValueKindInt64:
+		if i, ok := bsonInt64(v); ok {
+			return types.QValueInt64{Val: i}, true, nil
+		}
+	case types.QValueKindInt32:
+		if i, ok := v.Int32OK(); ok {
+			return types.QValueInt32{Val: i}, true, nil
+		}
+	case types.QValueKindString:
+		if s, ok := bsonString(v); ok {
+			return types.QValueString{Val: s}, true, nil
+		}
+	case types.QValueKindBoolean:
+		if b, ok := v.BooleanOK(); ok {
+			return types.QValueBoolean{Val: b}, true, nil
+		}
+	case types.QValueKindTimestampTZ:
+		if t, ok := v.TimeOK(); ok {
+			return types.QValueTimestampTZ{Val: t.UTC()}, true, nil
+		}
+	case types.QValueKindNumeric:
+		if d, ok := bsonDecimal(v); ok {
+			return types.QValueNumeric{Val: d}, true, nil
+		}
+	case types.QValueKindBytes:
+		if _, data, ok := v.BinaryOK(); ok {
+			return types.QValueBytes{Val: data}, true, nil
+		}
+	case types.QValueKindArrayInt32:
+		if vals, ok := bsonArray(v, func(e bsoncore.Value) (int32, bool) { return e.Int32OK() }); ok {
+			return types.QValueArrayInt32{Val: vals}, true, nil
+		}
+	case types.QValueKindArrayInt64:
+		if vals, ok := bsonArray(v, bsonInt64); ok {
+			return types.QValueArrayInt64{Val: vals}, true, nil
+		}
+	case types.QValueKindArrayFloat64:
+		if vals, ok := bsonArray(v, bsonFloat64); ok {
+			return types.QValueArrayFloat64{Val: vals}, true, nil
+		}
+	case types.QValueKindArrayString:
+		if vals, ok := bsonArray(v, bsonString); ok {
+			return types.QValueArrayString{Val: vals}, true, nil
+		}
+	case types.QValueKindArrayBoolean:
+		if vals, ok := bsonArray(v, func(e bsoncore.Value) (bool, bool) { return e.BooleanOK() }); ok {
+			return types.QValueArrayBoolean{Val: vals}, true, nil
+		}
+	case types.QValueKindArrayTimestampTZ:
+		if vals, ok := bsonArray(v, func(e bsoncore.Value) (time.Time, bool) {
+			t, ok := e.TimeOK()
+			return t.UTC(), ok
+		}); ok {
+			return types.QValueArrayTimestampTZ{Val: vals}, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func bsonFloat64(v bsoncore.Value) (float64, bool) {
+	switch v.Type {
+	case bsoncore.TypeDouble:
+		return v.Double(), true
+	case bsoncore.TypeInt32:
+		return float64(v.Int32()), true
+	case bsoncore.TypeInt64:
+		return float64(v.Int64()), true
+	default:
+		return 0, false
+	}
+}
+
+func bsonInt64(v bsoncore.Value) (int64, bool) {
+	switch v.Type {
+	case bsoncore.TypeInt32:
+		return int64(v.Int32()), true
+	case bsoncore.TypeInt64:
+		return v.Int64(), true
+	default:
+		return 0, false
+	}
+}
+
+func bsonString(v bsoncore.Value) (string, bool) {
+	switch v.Type {
+	case bsoncore.TypeString:
+		return v.StringValue(), true
+	case bsoncore.TypeSymbol:
+		return v.Symbol(), true
+	case bsoncore.TypeObjectID:
+		return bson.ObjectID(v.ObjectID()).Hex(), true
+	default:
+		return "", false
+	}
+}
+
+func bsonDecimal(v bsoncore.Value) (decimal.Decimal, bool) {
+	switch v.Type {
+	case bsoncore.TypeDecimal128:
+		h, l := v.Decimal128()
+		// NaN and infinities do not parse and go to the overflow column
+		d, err := decimal.NewFromString(bson.NewDecimal128(h, l).String())
+		return d, err == nil
+	case bsoncore.TypeInt32:
+		return decimal.NewFromInt32(v.Int32()), true
+	case bsoncore.TypeInt64:
+		return decimal.NewFromInt(v.Int64()), true
+	default:
+		return decimal.Decimal{}, false
+	}
+}
+
+// bsonArray converts every element of an array value, false when one does not convert
+func bsonArray[T any](v bsoncore.Value, convert func(bsoncore.Value) (T, bool)) ([]T, bool) {
+	arr, ok := v.ArrayOK()
+	if !ok {
+		return nil, false
+	}
+	values, err := arr.Values()
+	if err != nil {
+		return nil, false
+	}
+	converted := make([]T, 0, len(values))
+	for _, value := range values {
+		c, ok := convert(value)
+		if !ok {
+			return nil, false
+		}
+		converted = append(converted, c)
+	}
+	return converted, true
+}
@@ -0,0 +1,204 @@
+package connmongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/x/bsonx/bsoncore"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func mustMarshalDocument(t *testing.T, doc bson.D) bson.Raw {
+	t.Helper()
+	raw, err := bson.Marshal(doc)
+	require.NoError(t, err)
+	return raw
+}
+
+func TestFlattenDocument(t *testing.T) {
+	doc := mustMarshalDocument(t, bson.D{
+		{Key: "_id", Value: bson.NewObjectID()},
+		{Key: "name", Value: "widget"},
+		{Key: "address", Value: bson.D{
+			{Key: "city", Value: "Oslo"},
+			{Key: "geo", Value: bson.D{
+				{Key: "lat", Value: 59.9},
+				{Key: "deep", Value: bson.D{{Key: "x", Value: 1}}},
+			}},
+		}},
+		{Key: "empty", Value: bson.D{}},
+		{Key: "", Value: "nameless"},
+	})
+
+	fields, err := flattenDocument(bsoncore.Document(doc))
+	require.NoError(t, err)
+	columns := make([]string, 0, len(fields))
+	paths := make([]string, 0, len(fields))
+	for _, field := range fields {
+		columns = append(columns, field.column)
+		paths = append(paths, field.path)
+	}
+	require.Equal(t, []string{"name", "address_city", "address_geo_lat", "address_geo_deep", "empty", ""}, columns)
+	require.Equal(t, []string{"name", "address.city", "address.geo.lat", "address.geo.deep", "empty", ""}, paths)
+	require.Equal(t, bsoncore.TypeEmbeddedDocument, fields[3].value.Type, "subdocuments past the depth limit stay whole")
+}
+
+func TestMergeKinds(t *testing.T) {
+	require.Equal(t, types.QValueKindInt64, mergeKinds(types.QValueKindInt32, types.QValueKindInt64))
+	require.Equal(t, types.QValueKindFloat64, mergeKinds(types.QValueKindInt64, types.QValueKindFloat64))
+	require.Equal(t, types.QValueKindArrayFloat64, mergeKinds(types.QValueKindArrayFloat64, types.QValueKindArrayInt32))
+	require.Equal(t, types.QValueKindString, mergeKinds(types.QValueKindString, types.QValueKindString))
+	require.Equal(t, types.QValueKindJSON, mergeKinds(types.QValueKindString, types.QValueKindInt32))
+}
+
+func TestInferColumns(t *testing.T) {
+	documents := []bson.Raw{
+		mustMarshalDocument(t, bson.D{
+			{Key: "_id", Value: 1},
+			{Key: "qty", Value: int32(3)},
+			{Key: "tags", Value: bson.A{"a", "b"}},
+			{Key: "note", Value: nil},
+			{Key: "meta", Value: bson.D{{Key: "created", Value: time.Unix(0, 0)}}},
+		}),
+		mustMarshalDocument(t, bson.D{
+			{Key: "_id", Value: 2},
+			{Key: "qty", Value: 2.5},
+			{Key: "tags", Value: bson.A{}},
+			{Key: "mixed", Value: "x"},
+			{Key: "scores", Value: bson.A{int32(1), nil}},
+		}),
+		mustMarshalDocument(t, bson.D{
+			{Key: "_id", Value: 3},
+			{Key: "mixed", Value: int64(7)},
+			{Key: "_overflow", Value: "reserved"},
+		}),
+	}
+
+	columns, err := inferColumns(documents)
+	require.NoError(t, err)
+	kinds := make(map[string]string, len(columns))
+	names := make([]string, 0, len(columns))
+	for _, column := range columns {
+		require.True(t, column.Nullable)
+		kinds[column.Name] = column.Type
+		names = append(names, column.Name)
+	}
+	require.Equal(t, []string{"qty", "tags", "meta_created", "mixed", "scores"}, names)
+	require.Equal(t, map[string]string{
+		"qty":          string(types.QValueKindFloat64),
+		"tags":         string(types.QValueKindArrayString),
+		"meta_created": string(types.QValueKindTimestampTZ),
+		"mixed":        string(types.QValueKindJSON),
+		"scores":       string(types.QValueKindJSON),
+	}, kinds)
+}
+
+func flattenedSchema(columns ...*protos.FieldDescription) *protos.TableSchema {
+	fields := []*protos.FieldDescription{{Name: DefaultDocumentKeyColumnName, Type: string(types.QValueKindString)}}
+	fields = append(fields, columns...)
+	fields = append(fields, &protos.FieldDescription{Name: DefaultOverflowColumnName, Type: string(types.QValueKindJSON)})
+	return &protos.TableSchema{Columns: fields}
+}
+
+func TestNewFlattenedTable(t *testing.T) {
+	require.Nil(t, newFlattenedTable(nil, nil))
+	require.Nil(t, newFlattenedTable(&protos.TableSchema{Columns: []*protos.FieldDescription{
+		{Name: DefaultDocumentKeyColumnName, Type: string(types.QValueKindString)},
+		{Name: DefaultFullDocumentColumnName, Type: string(types.QValueKindJSON)},
+	}}, nil))
+
+	table := newFlattenedTable(flattenedSchema(flattenedFieldDescription("qty", types.QValueKindInt64)), nil)
+	require.NotNil(t, table)
+	require.Equal(t, 1, table.numTyped)
+}
+
+func TestFlattenedDocumentValues(t *testing.T) {
+	schema := flattenedSchema(
+		flattenedFieldDescription("qty", types.QValueKindInt64),
+		flattenedFieldDescription("name", types.QValueKindString),
+		flattenedFieldDescription("secret", types.QValueKindString),
+		flattenedFieldDescription("missing", types.QValueKindBoolean),
+	)
+	doc := mustMarshalDocument(t, bson.D{
+		{Key: "_id", Value: 1},
+		{Key: "qty", Value: "lots"},
+		{Key: "name", Value: "widget"},
+		{Key: "secret", Value: "hidden"},
+		{Key: "nested", Value: bson.D{{Key: "flag", Value: true}}},
+		{Key: "gone", Value: nil},
+	})
+
+	t.Run("unknown fields overflow without addColumn", func(t *testing.T) {
+		table := newFlattenedTable(schema, map[string]struct{}{"secret": {}})
+		values, err := table.documentValues(doc, NewDirectBsonConverter(), nil)
+		require.NoError(t, err)
+		require.Equal(t, map[string]types.QValue{
+			"qty":                     types.QValueNull(types.QValueKindInt64),
+			"name":                    types.QValueString{Val: "widget"},
+			"secret":                  types.QValueNull(types.QValueKindString),
+			"missing":                 types.QValueNull(types.QValueKindBoolean),
+			DefaultOverflowColumnName: types.QValueJSON{Val: `{"qty":"lots","nested.flag":true}`},
+		}, values)
+	})
+
+	t.Run("unknown fields become columns", func(t *testing.T) {
+		table := newFlattenedTable(schema, nil)
+		var added []string
+		values, err := table.documentValues(doc, NewDirectBsonConverter(), func(name string, kind types.QValueKind) bool {
+			added = append(added, name+":"+string(kind))
+			return true
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"nested_flag:bool"}, added)
+		require.Equal(t, types.QValueBoolean{Val: true}, values["nested_flag"])
+		require.Equal(t, types.QValueString{Val: "hidden"}, values["secret"])
+		require.Equal(t, types.QValueJSON{Val: `{"qty":"lots"}`}, values[DefaultOverflowColumnName])
+		require.Equal(t, types.QValueKindBoolean, table.columns["nested_flag"])
+	})
+
+	t.Run("events without a document", func(t *testing.T) {
+		values := newFlattenedTable(schema, nil).emptyValues()
+		require.Equal(t, types.QValueJSON{Val: "{}"}, values[DefaultOverflowColumnName])
+		require.Equal(t, types.QValueNull(types.QValueKindString), values["name"])
+		require.NotContains(t, values, DefaultDocumentKeyColumnName)
+	})
+}
+
+func TestBsonToQValue(t *testing.T) {
+	converter := NewDirectBsonConverter()
+	valueOf := func(v any) bsoncore.Value {
+		raw := mustMarshalDocument(t, bson.D{{Key: "v", Value: v}})
+		return bsoncore.Document(raw).Lookup("v")
+	}
+	oid := bson.NewObjectID()
+	ts := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	cases := []struct {
+		want  types.QValue
+		value any
+		kind  types.QValueKind
+		fits  bool
+	}{
+		{kind: types.QValueKindFloat64, value: int32(2), want: types.QValueFloat64{Val: 2}, fits: true},
+		{kind: types.QValueKindInt64, value: int32(2), want: types.QValueInt64{Val: 2}, fits: true},
+		{kind: types.QValueKindInt32, value: int64(2), fits: false},
+		{kind: types.QValueKindString, value: oid, want: types.QValueString{Val: oid.Hex()}, fits: true},
+		{kind: types.QValueKindTimestampTZ, value: ts, want: types.QValueTimestampTZ{Val: ts}, fits: true},
+		{kind: types.QValueKindArrayInt64, value: bson.A{int32(1), int64(2)}, want: types.QValueArrayInt64{Val: []int64{1, 2}}, fits: true},
+		{kind: types.QValueKindArrayString, value: bson.A{"a", 1}, fits: false},
+		{kind: types.QValueKindJSON, value: bson.D{{Key: "a", Value: 1}}, want: types.QValueJSON{Val: `{"a":1}`}, fits: true},
+		{kind: types.QValueKindBoolean, value: nil, want: types.QValueNull(types.QValueKindBoolean), fits: true},
+	}
+	for _, tc := range cases {
+		qv, fits, err := bsonToQValue(tc.kind, valueOf(tc.value), converter)
+		require.NoError(t, err)
+		require.Equal(t, tc.fits, fits, "%s <- %v", tc.kind, tc.value)
+		if tc.fits {
+			require.Equal(t, tc.want, qv)
+		}
+	}
+}
@@ -21,7 +21,6 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
-	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/pkg/common"
 	peerdb_mongo "github.com/PeerDB-io/peerdb/flow/pkg/mongo"
 )
@@ -50,19 +49,14 @@ var protoToReadPref = map[protos.ReadPreference]*readpref.ReadPref{
 	protos.ReadPreference_PREFERENCE_UNKNOWN:  readpref.SecondaryPreferred(),
 }
 
-type metadataStore interface {
-	GetLastOffset(ctx context.Context, jobName string) (model.CdcCheckpoint, error)
-	SetLastOffset(ctx context.Context, jobName string, offset model.CdcCheckpoint) error
-}
-
 type createChangeStreamFunc func(
 	ctx context.Context, pipeline mongo.Pipeline, opts ...options.Lister[options.ChangeStreamOptions],
 ) (ChangeStream, error)
 
 type MongoConnector struct {
+	*metadata.PostgresMetadata
 	clockOffsetUpdatedAt time.Time
 	logger               log.Logger
-	metadataStore        metadataStore
 	config               *protos.MongoConfig
 	client               *mongo.Client
 	ssh                  *utils.SSHTunnel
@@ -71,6 +65,8 @@ type MongoConnector struct {
 	totalBytesRead       atomic.Int64
 	deltaBytesRead       atomic.Int64
 	clockOffset          time.Duration
+	// resolved in SetupReplConn, whether change streams can request pre-images
+	preImages bool
 }
 
 func NewMongoConnector(ctx context.Context, config *protos.MongoConfig) (*MongoConnector, error) {
@@ -81,9 +77,9 @@ func NewMongoConnector(ctx context.Context, config *protos.MongoConfig) (*MongoC
 	}
 
 	mc := &MongoConnector{
-		metadataStore: pgMetadata,
-		config:        config,
-		logger:        logger,
+		PostgresMetadata: pgMetadata,
+		config:           config,
+		logger:           logger,
 	}
 	mc.createChangeStream = func(
 		ctx context.Context, pipeline mongo.Pipeline, opts ...options.Lister[options.ChangeStreamOptions],
@@ -223,7 +219,7 @@ func (c *MongoConnector) GetServerSideCommitLagMicroseconds(ctx context.Context,
 		return 0, fmt.Errorf("failed to get replica set status: %w", err)
 	}
 
-	lastOffset, err := c.metadataStore.GetLastOffset(ctx, flowJobName)
+	lastOffset, err := c.GetLastOffset(ctx, flowJobName)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get last offset: %w", err)
 	}
@@ -8,11 +8,13 @@ import (
 	"math"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
 	"github.com/PeerDB-io/peerdb/flow/pkg/common"
@@ -26,7 +28,9 @@ func (c *MongoConnector) GetQRepPartitions(
 	config *protos.QRepConfig,
 	last *protos.QRepPartition,
 ) ([]*protos.QRepPartition, error) {
-	if config.WatermarkColumn != DefaultDocumentKeyColumnName {
+	// a composite partition key is read along a compound index led by its fields
+	keyFields := shared.SplitPartitionKey(config.WatermarkColumn)
+	if len(keyFields) < 2 && config.WatermarkColumn != DefaultDocumentKeyColumnName {
 		c.logger.Warn("unexpected watermark column, falling back to full table partition")
 		return utils.FullTablePartition(), nil
 	}
@@ -65,6 +69,19 @@ func (c *MongoConnector) GetQRepPartitions(
 		return utils.FullTablePartition(), nil
 	}
 
+	if len(keyFields) > 1 {
+		// index ranges include documents with null or missing key fields, no null partition is needed
+		return c.compoundIndexPartitions(ctx, collection, keyFields, adjustedPartitions.AdjustedNumPartitions)
+	}
+
+	if partitions, err := c.chunkPartitions(ctx, parseWatermarkTable.Namespace, parseWatermarkTable.Table,
+		adjustedPartitions.AdjustedNumPartitions,
+	); err != nil {
+		return nil, fmt.Errorf("failed to partition along chunks: %w", err)
+	} else if partitions != nil {
+		return partitions, nil
+	}
+
 	return c.buildPartitions(ctx, collection, adjustedPartitions.AdjustedNumPartitions)
 }
 
@@ -83,7 +100,7 @@ func (c *MongoConnector) GetDefaultPartitionKeyForTables(
 
 func (c *MongoConnector) PullQRepRecords(
 	ctx context.Context,
-	_catalogPool shared.CatalogPool,
+	catalogPool shared.CatalogPool,
 	otelManager *otel_metrics.OtelManager,
 	config *protos.QRepConfig,
 	dstType protos.DBType,
@@ -98,7 +115,14 @@ func (c *MongoConnector) PullQRepRecords(
 	}
 	db := c.client.Database(parseWatermarkTable.Namespace)
 
-	stream.SetSchema(GetDefaultSchema(config.Version))
+	table, schema, err := c.getFlattenedQRepTable(ctx, catalogPool, config)
+	if err != nil {
+		return 0, 0, err
+	}
+	if table == nil {
+		schema = GetDefaultSchema(config.Version)
+	}
+	stream.SetSchema(schema)
 
 	c.totalBytesRead.Store(0)
 	c.deltaBytesRead.Store(0)
@@ -109,16 +133,23 @@ func (c *MongoConnector) PullQRepRecords(
 	defer shutDown()
 
 	filter := bson.D{}
+	var indexBounds bson.D
 	if !partition.FullTablePartition {
-		filter, err = toRangeFilter(config.WatermarkColumn, partition.Range)
-		if err != nil {
-			return 0, 0, fmt.Errorf("failed to convert partition range to filter: %w", err)
+		if r, ok := partition.Range.GetRange().(*protos.PartitionRange_MongoShardKeyRange); ok {
+			// mongos still sends the find to every shard, only the chunks' owner has documents in range
+			indexBounds = shardKeyIndexBounds(r.MongoShardKeyRange)
+		} else {
+			filter, err = toRangeFilter(config.WatermarkColumn, partition.Range)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to convert partition range to filter: %w", err)
+			}
 		}
 	}
 	c.logger.Info("[mongo] filter for partition",
 		slog.Any("partition", partition.PartitionId),
 		slog.String("watermark_table", config.WatermarkTable),
-		slog.Any("filter", filter))
+		slog.Any("filter", filter),
+		slog.Any("indexBounds", indexBounds))
 
 	batchSize := config.NumRowsPerPartition
 	if config.NumRowsPerPartition == 0 || config.NumRowsPerPartition > math.MaxInt32 {
@@ -138,6 +169,14 @@ func (c *MongoConnector) PullQRepRecords(
 		{Key: "batchSize", Value: int32(batchSize)},
 		{Key: "readConcern", Value: bson.D{{Key: "level", Value: "majority"}}},
 	}
+	findCmd = append(findCmd, indexBounds...)
+	resumePointInterval := stream.ResumePointInterval()
+	if resumePointInterval > 0 && c.SupportsQRepResumePoints(config, partition) {
+		// documents are read in _id order so the last _id read bounds what was sent
+		findCmd = append(findCmd, bson.E{Key: "sort", Value: bson.D{{Key: DefaultDocumentKeyColumnName, Value: 1}}})
+	} else {
+		resumePointInterval = 0
+	}
 	cursor, err := db.RunCommandCursor(ctx, findCmd,
 		options.RunCmd().SetReadPreference(protoToReadPref[c.config.ReadPreference]))
 	if err != nil {
@@ -147,7 +186,13 @@ func (c *MongoConnector) PullQRepRecords(
 
 	converter := NewDirectBsonConverter()
 	for cursor.Next(ctx) {
-		record, err := QValuesFromBsonRaw(cursor.Current, config.Version, converter, config.WatermarkTable)
+		var record []types.QValue
+		if table != nil {
+			record, err = flattenedQValuesFromBsonRaw(cursor.Current, config.Version, converter, config.WatermarkTable,
+				table, schema)
+		} else {
+			record, err = QValuesFromBsonRaw(cursor.Current, config.Version, converter, config.WatermarkTable)
+		}
 		if err != nil {
 			c.logger.Error("failed to convert record",
 				slog.String("error", err.Error()),
@@ -160,6 +205,15 @@ func (c *MongoConnector) PullQRepRecords(
 		}
 
 		totalRecords += 1
+		if resumePointInterval > 0 && totalRecords%resumePointInterval == 0 {
+			remaining, err := remainingObjectIdRange(cursor.Current, partition.Range.GetObjectIdRange())
+			if err != nil {
+				return 0, 0, err
+			}
+			if remaining != nil {
+				stream.SetResumePoint(remaining)
+			}
+		}
 		if totalRecords%50000 == 0 {
 			c.logger.Info("[mongo] pulling records",
 				slog.Int64("records", totalRecords),
@@ -188,6 +242,34 @@ func (c *MongoConnector) PullQRepRecords(
 	return totalRecords, c.deltaBytesRead.Swap(0), nil
 }
 
+// SupportsQRepResumePoints returns true for ObjectId ranges over _id, which can be read in order using the _id index
+func (c *MongoConnector) SupportsQRepResumePoints(config *protos.QRepConfig, partition *protos.QRepPartition) bool {
+	return !partition.FullTablePartition && config.WatermarkColumn == DefaultDocumentKeyColumnName &&
+		partition.Range.GetObjectIdRange() != nil
+}
+
+// remainingObjectIdRange returns the part of an ObjectId range after the _id of the given document,
+// nil if the document was the last possible one
+func remainingObjectIdRange(doc bson.Raw, objectIdRange *protos.ObjectIdPartitionRange) (*protos.PartitionRange, error) {
+	lastID, ok := doc.Lookup(DefaultDocumentKeyColumnName).ObjectIDOK()
+	if !ok {
+		return nil, errors.New("document in ObjectId partition has no ObjectId _id")
+	}
+	// _id values are unique, so the range resumes right after the last one sent
+	nextID := lastID
+	for i := len(nextID) - 1; i >= 0; i-- {
+		nextID[i] += 1
+		if nextID[i] != 0 {
+			break
+		} else if i == 0 {
+			return nil, nil
+		}
+	}
+	return &protos.PartitionRange{Range: &protos.PartitionRange_ObjectIdRange{
+		ObjectIdRange: &protos.ObjectIdPartitionRange{Start: nextID.Hex(), End: objectIdRange.End},
+	}}, nil
+}
+
 func GetDefaultSchema(internalVersion uint32) types.QRecordSchema {
 	fullDocumentColumnName := DefaultFullDocumentColumnName
 	if internalVersion < shared.InternalVersion_MongoDBFullDocumentColumnToDoc {
@@ -208,6 +290,75 @@ func GetDefaultSchema(internalVersion uint32) types.QRecordSchema {
 	return types.QRecordSchema{Fields: schema}
 }
 
+// getFlattenedQRepTable loads the schema of a snapshot's table from the catalog, the snapshot
+// must produce exactly the columns inferred when the mirror was set up. Returns a nil table
+// when the collection is replicated as a single document column.
+func (c *MongoConnector) getFlattenedQRepTable(
+	ctx context.Context,
+	catalogPool shared.CatalogPool,
+	config *protos.QRepConfig,
+) (*flattenedTable, types.QRecordSchema, error) {
+	if catalogPool.Pool == nil || config.ParentMirrorName == "" {
+		return nil, types.QRecordSchema{}, nil
+	}
+	tableSchema, err := internal.LoadTableSchemaFromCatalog(ctx, catalogPool, config.ParentMirrorName,
+		config.DestinationTableIdentifier)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, types.QRecordSchema{}, nil
+		}
+		return nil, types.QRecordSchema{}, fmt.Errorf("failed to load schema of %s: %w", config.DestinationTableIdentifier, err)
+	}
+	excluded := make(map[string]struct{}, len(config.Exclude))
+	for _, column := range config.Exclude {
+		excluded[column] = struct{}{}
+	}
+	table := newFlattenedTable(tableSchema, excluded)
+	if table == nil {
+		return nil, types.QRecordSchema{}, nil
+	}
+	fields := make([]types.QField, 0, len(tableSchema.Columns))
+	for _, column := range tableSchema.Columns {
+		fields = append(fields, types.QField{
+			Name:     column.Name,
+			Type:     types.QValueKind(column.Type),
+			Nullable: column.Nullable,
+		})
+	}
+	return table, types.QRecordSchema{Fields: fields}, nil
+}
+
+// flattenedQValuesFromBsonRaw converts a raw BSON document to QValues in the column order of
+// a flattened schema, fields the schema has no column for go to the overflow column.
+func flattenedQValuesFromBsonRaw(
+	raw bson.Raw,
+	version uint32,
+	converter *DirectBsonConverter,
+	tableName string,
+	table *flattenedTable,
+	schema types.QRecordSchema,
+) ([]types.QValue, error) {
+	rv := raw.Lookup(DefaultDocumentKeyColumnName)
+	if rv.IsZero() || rv.Type == bson.TypeNull {
+		return nil, exceptions.NewInvalidIdValueError(tableName)
+	}
+	idQValue, err := converter.QValueStringFromId(rv, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert key %s: %w", DefaultDocumentKeyColumnName, err)
+	}
+	values, err := table.documentValues(raw, converter, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to flatten document: %w", err)
+	}
+	values[DefaultDocumentKeyColumnName] = idQValue
+
+	record := make([]types.QValue, 0, len(schema.Fields))
+	for _, field := range schema.Fields {
+		record = append(record, values[field.Name])
+	}
+	return record, nil
+}
+
 func toRangeFilter(watermarkColumn string, partitionRange *protos.PartitionRange) (bson.D, error) {
 	switch r := partitionRange.Range.(type) {
 	case *protos.PartitionRange_ObjectIdRange:
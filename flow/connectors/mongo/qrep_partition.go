@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"math"
 	"math/big"
+	"slices"
+	"strings"
 
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -13,6 +16,7 @@ import (
 
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	peerdb_mongo "github.com/PeerDB-io/peerdb/flow/pkg/mongo"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 )
 
@@ -70,6 +74,91 @@ func (c *MongoConnector) buildPartitions(
 	}
 }
 
+// chunkPartitions lays partitions over the chunks of a sharded collection so that every
+// partition only reads from the shard owning its chunks. Adjacent chunks of one shard are
+// merged until there are about numPartitions partitions, a chunk is never split. Returns
+// nil when the collection is not sharded or its chunks are not readable, the caller then
+// partitions by _id.
+func (c *MongoConnector) chunkPartitions(
+	ctx context.Context,
+	database string,
+	collection string,
+	numPartitions int64,
+) ([]*protos.QRepPartition, error) {
+	topology, err := peerdb_mongo.GetTopologyType(ctx, c.client)
+	if err != nil {
+		return nil, err
+	}
+	if topology != peerdb_mongo.ShardedCluster {
+		return nil, nil
+	}
+
+	sc, err := peerdb_mongo.GetShardedCollection(ctx, c.client, database, collection)
+	if err != nil {
+		c.logger.Warn("[mongo] unable to read shard key, partitioning by _id instead", slog.Any("error", err))
+		return nil, nil
+	} else if sc == nil {
+		return nil, nil
+	}
+	chunks, err := peerdb_mongo.GetChunks(ctx, c.client, sc)
+	if err != nil {
+		c.logger.Warn("[mongo] unable to read chunks, partitioning by _id instead", slog.Any("error", err))
+		return nil, nil
+	}
+	if len(chunks) < 2 {
+		// a single chunk lives on a single shard anyway, _id ranges split it further
+		return nil, nil
+	}
+
+	groups := groupChunks(chunks, numPartitions)
+	c.logger.Info("[mongo] partitioning along chunk boundaries",
+		slog.String("shardKey", sc.Key.String()),
+		slog.Int("chunks", len(chunks)),
+		slog.Int("partitions", len(groups)))
+	partitions := make([]*protos.QRepPartition, 0, len(groups))
+	for _, group := range groups {
+		partitions = append(partitions, &protos.QRepPartition{
+			PartitionId: uuid.NewString(),
+			Range: &protos.PartitionRange{
+				Range: &protos.PartitionRange_MongoShardKeyRange{
+					MongoShardKeyRange: &protos.MongoShardKeyPartitionRange{
+						KeyPattern: sc.Key,
+						Min:        chunks[group[0]].Min,
+						Max:        chunks[group[1]-1].Max,
+					},
+				},
+			},
+		})
+	}
+	return partitions, nil
+}
+
+// groupChunks splits chunks, sorted by shard key, into runs of adjacent chunks on the same
+// shard holding at most ceil(len(chunks)/numPartitions) chunks each. Runs are returned as
+// [start, end) indexes into chunks.
+func groupChunks(chunks []peerdb_mongo.Chunk, numPartitions int64) [][2]int {
+	perGroup := max((int64(len(chunks))+numPartitions-1)/numPartitions, 1)
+	var groups [][2]int
+	start := 0
+	for i := 1; i <= len(chunks); i++ {
+		if i == len(chunks) || chunks[i].Shard != chunks[start].Shard || int64(i-start) >= perGroup {
+			groups = append(groups, [2]int{start, i})
+			start = i
+		}
+	}
+	return groups
+}
+
+// shardKeyIndexBounds restricts a find to a range of the shard key index. min and max
+// work for hashed and compound shard keys alike where a filter on the fields would not.
+func shardKeyIndexBounds(r *protos.MongoShardKeyPartitionRange) bson.D {
+	return bson.D{
+		{Key: "hint", Value: bson.Raw(r.KeyPattern)},
+		{Key: "min", Value: bson.Raw(r.Min)},
+		{Key: "max", Value: bson.Raw(r.Max)},
+	}
+}
+
 // objectIDPartitions divides the ObjectID keyspace uniformly using integer
 // arithmetic on the full 12-byte value. Since the leading 4-byte timestamp is the
 // most significant component, this naturally partitions by insertion time.
@@ -353,3 +442,255 @@ func findBoundaryID(
 	}
 	return cursor.Current.Lookup(DefaultDocumentKeyColumnName), nil
 }
+
+type indexSpec struct {
+	Name                    string   `bson:"name"`
+	Key                     bson.Raw `bson:"key"`
+	Collation               bson.Raw `bson:"collation,omitempty"`
+	PartialFilterExpression bson.Raw `bson:"partialFilterExpression,omitempty"`
+	Sparse                  bool     `bson:"sparse,omitempty"`
+}
+
+type indexField struct {
+	name      string
+	direction int
+}
+
+// compoundIndexPartitions partitions a collection along a compound index led by keyFields,
+// e.g. (tenant, _id) when a handful of tenants own most documents. Boundaries are quantiles
+// of a sample sorted in index order and every partition is an index range [min, max), read
+// with hint/min/max like shard key ranges. Falls back to a full table partition when no
+// index can serve the range scans.
+func (c *MongoConnector) compoundIndexPartitions(
+	ctx context.Context,
+	collection *mongo.Collection,
+	keyFields []string,
+	numPartitions int64,
+) ([]*protos.QRepPartition, error) {
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes: %w", err)
+	}
+	var specs []indexSpec
+	if err := cursor.All(ctx, &specs); err != nil {
+		return nil, fmt.Errorf("failed to read indexes: %w", err)
+	}
+	spec, fields := matchCompoundIndex(specs, keyFields)
+	if spec == nil {
+		c.logger.Warn("[mongo] no usable index starts with the partition key, falling back to full table partition",
+			slog.Any("partitionKey", keyFields))
+		return utils.FullTablePartition(), nil
+	}
+
+	// documents with array values appear once per element in a multikey index and would be
+	// read by several partitions
+	var explain bson.Raw
+	if err := collection.Database().RunCommand(ctx, bson.D{
+		{Key: "explain", Value: bson.D{
+			{Key: "find", Value: collection.Name()},
+			{Key: "filter", Value: bson.D{}},
+			{Key: "hint", Value: spec.Key},
+			{Key: "limit", Value: 1},
+		}},
+		{Key: "verbosity", Value: "queryPlanner"},
+	}).Decode(&explain); err != nil {
+		return nil, fmt.Errorf("failed to explain index scan on %s: %w", spec.Name, err)
+	}
+	if planIsMultiKey(explain) {
+		c.logger.Warn("[mongo] partition key index is multikey, falling back to full table partition",
+			slog.String("index", spec.Name))
+		return utils.FullTablePartition(), nil
+	}
+
+	sampleSize := min(numPartitions*stringSampleOversample, stringSampleMaxSize)
+	projection := make(bson.D, 0, len(keyFields))
+	sort := make(bson.D, 0, len(keyFields))
+	for _, field := range fields[:len(keyFields)] {
+		projection = append(projection, bson.E{Key: field.name, Value: 1})
+		sort = append(sort, bson.E{Key: field.name, Value: field.direction})
+	}
+	sampleCursor, err := collection.Database().RunCommandCursor(ctx, bson.D{
+		{Key: "aggregate", Value: collection.Name()},
+		{Key: "pipeline", Value: bson.A{
+			bson.D{{Key: "$sample", Value: bson.D{{Key: "size", Value: int32(sampleSize)}}}},
+			bson.D{{Key: "$project", Value: projection}},
+			bson.D{{Key: "$sort", Value: sort}},
+		}},
+		{Key: "cursor", Value: bson.D{}},
+	}, options.RunCmd().SetReadPreference(protoToReadPref[c.config.ReadPreference]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample partition key values: %w", err)
+	}
+	defer sampleCursor.Close(ctx)
+	var samples [][]bson.RawValue
+	for sampleCursor.Next(ctx) {
+		sample := make([]bson.RawValue, 0, len(keyFields))
+		for _, field := range keyFields {
+			sample = append(sample, sampleCursor.Current.Lookup(strings.Split(field, ".")...))
+		}
+		samples = append(samples, sample)
+	}
+	if err := sampleCursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor error while sampling partition key values: %w", err)
+	}
+
+	bounds, err := computeIndexBoundaries(fields, samples, numPartitions)
+	if err != nil {
+		return nil, err
+	}
+	c.logger.Info("[mongo] partitioning along compound index",
+		slog.String("index", spec.Name),
+		slog.Int("numPartitions", len(bounds)-1),
+		slog.Int("sampleCount", len(samples)))
+
+	partitions := make([]*protos.QRepPartition, 0, len(bounds)-1)
+	for i := range len(bounds) - 1 {
+		partitions = append(partitions, &protos.QRepPartition{
+			PartitionId: uuid.NewString(),
+			Range: &protos.PartitionRange{
+				Range: &protos.PartitionRange_MongoShardKeyRange{
+					MongoShardKeyRange: &protos.MongoShardKeyPartitionRange{
+						KeyPattern: spec.Key,
+						Min:        bounds[i],
+						Max:        bounds[i+1],
+					},
+				},
+			},
+		})
+	}
+	return partitions, nil
+}
+
+// matchCompoundIndex picks the shortest ascending/descending index whose leading fields are
+// keyFields in order. Sparse and partial indexes miss documents and collated indexes order
+// strings differently from the sample, so they are skipped.
+func matchCompoundIndex(specs []indexSpec, keyFields []string) (*indexSpec, []indexField) {
+	var best *indexSpec
+	var bestFields []indexField
+	for i, spec := range specs {
+		if spec.Sparse || len(spec.PartialFilterExpression) > 0 {
+			continue
+		}
+		if locale, ok := spec.Collation.Lookup("locale").StringValueOK(); len(spec.Collation) > 0 && (!ok || locale != "simple") {
+			continue
+		}
+		elements, err := spec.Key.Elements()
+		if err != nil || len(elements) < len(keyFields) {
+			continue
+		}
+		fields := make([]indexField, 0, len(elements))
+		for _, element := range elements {
+			direction, ok := element.Value().AsFloat64OK()
+			if !ok || direction == 0 {
+				// hashed, text and geo indexes don't keep values in order
+				fields = nil
+				break
+			}
+			fields = append(fields, indexField{name: element.Key(), direction: int(math.Copysign(1, direction))})
+		}
+		if fields == nil || !slices.EqualFunc(fields[:len(keyFields)], keyFields, func(field indexField, name string) bool {
+			return field.name == name
+		}) {
+			continue
+		}
+		if best == nil || len(fields) < len(bestFields) {
+			best, bestFields = &specs[i], fields
+		}
+	}
+	return best, bestFields
+}
+
+// computeIndexBoundaries turns samples of the leading index fields, sorted in index order,
+// into numPartitions+1 or fewer index bounds. The first and last bound span the whole index
+// and interior bounds are quantiles of the distinct samples, trailing index fields are set to
+// their lowest value so the bound sits before every document with that prefix.
+func computeIndexBoundaries(fields []indexField, samples [][]bson.RawValue, numPartitions int64) ([]bson.Raw, error) {
+	first := func(field indexField) any {
+		if field.direction > 0 {
+			return bson.MinKey{}
+		}
+		return bson.MaxKey{}
+	}
+	last := func(field indexField) any {
+		if field.direction > 0 {
+			return bson.MaxKey{}
+		}
+		return bson.MinKey{}
+	}
+
+	distinct := make([][]bson.RawValue, 0, len(samples))
+	for _, sample := range samples {
+		if len(distinct) > 0 && slices.EqualFunc(distinct[len(distinct)-1], sample, bson.RawValue.Equal) {
+			continue
+		}
+		distinct = append(distinct, sample)
+	}
+	picked := distinct
+	if desired := numPartitions - 1; int64(len(distinct)) > desired {
+		picked = make([][]bson.RawValue, 0, desired)
+		for i := int64(1); i <= desired; i++ {
+			picked = append(picked, distinct[i*int64(len(distinct))/numPartitions])
+		}
+	}
+
+	bounds := make([]bson.Raw, 0, len(picked)+2)
+	appendBound := func(value func(i int, field indexField) any) error {
+		bound := make(bson.D, 0, len(fields))
+		for i, field := range fields {
+			bound = append(bound, bson.E{Key: field.name, Value: value(i, field)})
+		}
+		raw, err := bson.Marshal(bound)
+		if err != nil {
+			return fmt.Errorf("failed to marshal index bound: %w", err)
+		}
+		bounds = append(bounds, raw)
+		return nil
+	}
+	if err := appendBound(func(_ int, field indexField) any { return first(field) }); err != nil {
+		return nil, err
+	}
+	for _, sample := range picked {
+		if err := appendBound(func(i int, field indexField) any {
+			if i >= len(sample) {
+				return first(field)
+			} else if sample[i].Type == 0 {
+				// missing fields are indexed as null
+				return nil
+			}
+			return sample[i]
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if err := appendBound(func(_ int, field indexField) any { return last(field) }); err != nil {
+		return nil, err
+	}
+	return bounds, nil
+}
+
+// planIsMultiKey looks for an index scan on a multikey index anywhere in an explain output,
+// sharded clusters nest one plan per shard
+func planIsMultiKey(doc bson.Raw) bool {
+	elements, err := doc.Elements()
+	if err != nil {
+		return false
+	}
+	for _, element := range elements {
+		value := element.Value()
+		switch value.Type {
+		case bson.TypeBoolean:
+			if element.Key() == "isMultiKey" && value.Boolean() {
+				return true
+			}
+		case bson.TypeEmbeddedDocument:
+			if planIsMultiKey(value.Document()) {
+				return true
+			}
+		case bson.TypeArray:
+			if planIsMultiKey(bson.Raw(value.Array())) {
+				return true
+			}
+		}
+	}
+	return false
+}
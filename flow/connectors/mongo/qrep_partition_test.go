@@ -8,6 +8,7 @@ import (
 
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	peerdb_mongo "github.com/PeerDB-io/peerdb/flow/pkg/mongo"
 )
 
 // rawValueOf marshals a single-field document and returns the _id RawValue, so we
@@ -180,3 +181,122 @@ func TestToRangeFilter(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestGroupChunks(t *testing.T) {
+	chunksOn := func(shards ...string) []peerdb_mongo.Chunk {
+		chunks := make([]peerdb_mongo.Chunk, 0, len(shards))
+		for _, shard := range shards {
+			chunks = append(chunks, peerdb_mongo.Chunk{Shard: shard})
+		}
+		return chunks
+	}
+
+	t.Run("one partition per chunk when partitions outnumber chunks", func(t *testing.T) {
+		require.Equal(t, [][2]int{{0, 1}, {1, 2}, {2, 3}}, groupChunks(chunksOn("a", "b", "a"), 10))
+	})
+
+	t.Run("adjacent chunks of a shard are merged", func(t *testing.T) {
+		require.Equal(t, [][2]int{{0, 2}, {2, 4}}, groupChunks(chunksOn("a", "a", "b", "b"), 2))
+	})
+
+	t.Run("runs never cross shards", func(t *testing.T) {
+		require.Equal(t, [][2]int{{0, 1}, {1, 3}, {3, 4}}, groupChunks(chunksOn("a", "b", "b", "a"), 2))
+	})
+
+	t.Run("long runs are split", func(t *testing.T) {
+		require.Equal(t, [][2]int{{0, 3}, {3, 6}, {6, 7}}, groupChunks(chunksOn("a", "a", "a", "a", "a", "a", "a"), 3))
+	})
+}
+
+func TestShardKeyIndexBounds(t *testing.T) {
+	marshal := func(v bson.D) []byte {
+		raw, err := bson.Marshal(v)
+		require.NoError(t, err)
+		return raw
+	}
+	keyPattern := marshal(bson.D{{Key: "user_id", Value: "hashed"}})
+	minBound := marshal(bson.D{{Key: "user_id", Value: bson.MinKey{}}})
+	maxBound := marshal(bson.D{{Key: "user_id", Value: int64(-4611686018427387902)}})
+
+	bounds := shardKeyIndexBounds(&protos.MongoShardKeyPartitionRange{KeyPattern: keyPattern, Min: minBound, Max: maxBound})
+	require.Equal(t, bson.D{
+		{Key: "hint", Value: bson.Raw(keyPattern)},
+		{Key: "min", Value: bson.Raw(minBound)},
+		{Key: "max", Value: bson.Raw(maxBound)},
+	}, bounds)
+}
+
+func TestMatchCompoundIndex(t *testing.T) {
+	index := func(name string, key bson.D) indexSpec {
+		return indexSpec{Name: name, Key: mustMarshalDocument(t, key)}
+	}
+	sparse := index("sparse", bson.D{{Key: "tenant", Value: 1}, {Key: "_id", Value: 1}})
+	sparse.Sparse = true
+	collated := index("collated", bson.D{{Key: "tenant", Value: 1}, {Key: "_id", Value: 1}})
+	collated.Collation = mustMarshalDocument(t, bson.D{{Key: "locale", Value: "fr"}})
+	specs := []indexSpec{
+		index("_id_", bson.D{{Key: "_id", Value: 1}}),
+		index("hashed", bson.D{{Key: "tenant", Value: "hashed"}, {Key: "_id", Value: 1}}),
+		sparse,
+		collated,
+		index("wide", bson.D{{Key: "tenant", Value: 1}, {Key: "_id", Value: -1}, {Key: "at", Value: 1}}),
+		index("tenant_id", bson.D{{Key: "tenant", Value: 1}, {Key: "_id", Value: -1.0}}),
+	}
+
+	spec, fields := matchCompoundIndex(specs, []string{"tenant", "_id"})
+	require.NotNil(t, spec)
+	require.Equal(t, "tenant_id", spec.Name)
+	require.Equal(t, []indexField{{name: "tenant", direction: 1}, {name: "_id", direction: -1}}, fields)
+
+	spec, _ = matchCompoundIndex(specs, []string{"_id", "tenant"})
+	require.Nil(t, spec)
+}
+
+func TestComputeIndexBoundaries(t *testing.T) {
+	fields := []indexField{{name: "tenant", direction: 1}, {name: "_id", direction: -1}, {name: "at", direction: 1}}
+	sample := func(tenant string, id int32) []bson.RawValue {
+		return []bson.RawValue{rawValueOf(t, tenant), rawValueOf(t, id)}
+	}
+	samples := [][]bson.RawValue{
+		sample("a", 9), sample("a", 9), sample("a", 3), sample("b", 7), sample("c", 5), {rawValueOf(t, "d"), {}},
+	}
+
+	bounds, err := computeIndexBoundaries(fields, samples, 3)
+	require.NoError(t, err)
+	require.Len(t, bounds, 4)
+	decode := func(raw bson.Raw) bson.D {
+		var doc bson.D
+		require.NoError(t, bson.Unmarshal(raw, &doc))
+		return doc
+	}
+	require.Equal(t, bson.D{{Key: "tenant", Value: bson.MinKey{}}, {Key: "_id", Value: bson.MaxKey{}}, {Key: "at", Value: bson.MinKey{}}},
+		decode(bounds[0]))
+	require.Equal(t, bson.D{{Key: "tenant", Value: "a"}, {Key: "_id", Value: int32(3)}, {Key: "at", Value: bson.MinKey{}}},
+		decode(bounds[1]))
+	require.Equal(t, bson.D{{Key: "tenant", Value: "c"}, {Key: "_id", Value: int32(5)}, {Key: "at", Value: bson.MinKey{}}},
+		decode(bounds[2]))
+	require.Equal(t, bson.D{{Key: "tenant", Value: bson.MaxKey{}}, {Key: "_id", Value: bson.MinKey{}}, {Key: "at", Value: bson.MaxKey{}}},
+		decode(bounds[3]))
+
+	// missing fields are indexed as null
+	bounds, err = computeIndexBoundaries(fields, samples, 10)
+	require.NoError(t, err)
+	require.Len(t, bounds, 7)
+	require.Equal(t, bson.D{{Key: "tenant", Value: "d"}, {Key: "_id", Value: nil}, {Key: "at", Value: bson.MinKey{}}},
+		decode(bounds[5]))
+}
+
+func TestPlanIsMultiKey(t *testing.T) {
+	plan := func(multiKey bool) bson.D {
+		return bson.D{{Key: "stage", Value: "IXSCAN"}, {Key: "isMultiKey", Value: multiKey}}
+	}
+	require.False(t, planIsMultiKey(mustMarshalDocument(t, bson.D{
+		{Key: "queryPlanner", Value: bson.D{{Key: "winningPlan", Value: bson.D{{Key: "inputStage", Value: plan(false)}}}}},
+	})))
+	require.True(t, planIsMultiKey(mustMarshalDocument(t, bson.D{
+		{Key: "queryPlanner", Value: bson.D{{Key: "winningPlan", Value: bson.D{{Key: "shards", Value: bson.A{
+			bson.D{{Key: "winningPlan", Value: plan(false)}},
+			bson.D{{Key: "winningPlan", Value: plan(true)}},
+		}}}}}},
+	})))
+}
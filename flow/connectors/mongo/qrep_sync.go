@@ -0,0 +1,82 @@
+package connmongo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func (c *MongoConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
+
+func (c *MongoConnector) SyncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, shared.QRepWarnings, error) {
+	startTime := time.Now()
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// in upsert mode documents are keyed by the upsert columns so snapshot and CDC target the same _id
+	var keyColumns []string
+	if config.WriteMode != nil && config.WriteMode.WriteType == protos.QRepWriteType_QREP_WRITE_MODE_UPSERT {
+		keyColumns = config.WriteMode.UpsertKeyColumns
+	}
+	tableSchema := &protos.TableSchema{PrimaryKeyColumns: keyColumns}
+
+	ls, err := c.loadScript(ctx, config.Script, config.FlowJobName)
+	if err != nil {
+		return 0, nil, err
+	}
+	if ls != nil {
+		defer ls.Close()
+	}
+
+	writer := c.newBulkWriter()
+	var numRecords int64
+	for qrecord := range stream.Records {
+		items := model.NewRecordItems(len(qrecord))
+		for i, val := range qrecord {
+			items.AddColumn(schema.Fields[i].Name, val)
+		}
+		record := &model.InsertRecord[model.RecordItems]{
+			Items:                items,
+			SourceTableName:      config.WatermarkTable,
+			DestinationTableName: config.DestinationTableIdentifier,
+		}
+
+		writeModels, err := recordWriteModels(ls, tableSchema, record)
+		if err != nil {
+			return 0, nil, fmt.Errorf("[mongo] failed to build write for %s: %w", config.DestinationTableIdentifier, err)
+		}
+		if writeModels == nil {
+			continue
+		}
+		for _, writeModel := range writeModels {
+			if err := writer.add(ctx, config.DestinationTableIdentifier, writeModel); err != nil {
+				return 0, nil, err
+			}
+		}
+		numRecords++
+	}
+	if err := stream.Err(); err != nil {
+		return 0, nil, fmt.Errorf("[mongo] failed to get record from stream: %w", err)
+	}
+	if err := writer.flush(ctx); err != nil {
+		return 0, nil, err
+	}
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		return 0, nil, fmt.Errorf("[mongo] failed to log partition info: %w", err)
+	}
+	return numRecords, nil, nil
+}
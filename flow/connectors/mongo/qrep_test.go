@@ -0,0 +1,39 @@
+package connmongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func TestRemainingObjectIdRange(t *testing.T) {
+	objectIdRange := &protos.ObjectIdPartitionRange{
+		Start: "000000000000000000000000",
+		End:   "ffffffffffffffffffffffff",
+	}
+	docWithID := func(id any) bson.Raw {
+		raw, err := bson.Marshal(bson.D{{Key: DefaultDocumentKeyColumnName, Value: id}})
+		require.NoError(t, err)
+		return raw
+	}
+	objectID := func(hex string) bson.ObjectID {
+		id, err := bson.ObjectIDFromHex(hex)
+		require.NoError(t, err)
+		return id
+	}
+
+	remaining, err := remainingObjectIdRange(docWithID(objectID("65a0000000000000000000ff")), objectIdRange)
+	require.NoError(t, err)
+	require.Equal(t, "65a000000000000000000100", remaining.GetObjectIdRange().Start)
+	require.Equal(t, objectIdRange.End, remaining.GetObjectIdRange().End)
+
+	remaining, err = remainingObjectIdRange(docWithID(objectID("ffffffffffffffffffffffff")), objectIdRange)
+	require.NoError(t, err)
+	require.Nil(t, remaining)
+
+	_, err = remainingObjectIdRange(docWithID("not-an-object-id"), objectIdRange)
+	require.Error(t, err)
+}
@@ -46,6 +46,34 @@ func (c *DirectBsonConverter) QValueJSONFromDocument(raw bson.Raw) (types.QValue
 	return types.QValueJSON{Val: string(c.stream.Buffer())}, nil
 }
 
+// QValueJSONFromValue converts a single raw BSON value to a QValueJSON.
+func (c *DirectBsonConverter) QValueJSONFromValue(v bsoncore.Value) (types.QValueJSON, error) {
+	c.stream.Reset(nil)
+	if err := rawValueToJSON(v, c.stream); err != nil {
+		return types.QValueJSON{}, fmt.Errorf("failed to convert value: %w", err)
+	}
+	return types.QValueJSON{Val: string(c.stream.Buffer())}, nil
+}
+
+// overflowJSON renders the fields of a flattened document that did not fit a typed column
+// as one object keyed by dotted field path.
+func (c *DirectBsonConverter) overflowJSON(fields []flatField) (types.QValueJSON, error) {
+	c.stream.Reset(nil)
+	c.stream.WriteRaw("{")
+	for i, field := range fields {
+		if i > 0 {
+			c.stream.WriteRaw(",")
+		}
+		c.stream.WriteStringWithHTMLEscaped(field.path)
+		c.stream.WriteRaw(":")
+		if err := rawValueToJSON(field.value, c.stream); err != nil {
+			return types.QValueJSON{}, fmt.Errorf("failed to convert overflow field %s: %w", field.path, err)
+		}
+	}
+	c.stream.WriteRaw("}")
+	return types.QValueJSON{Val: string(c.stream.Buffer())}, nil
+}
+
 func (c *DirectBsonConverter) QValueStringFromId(id bson.RawValue, version uint32) (types.QValueString, error) {
 	if version >= shared.InternalVersion_MongoDBIdWithoutRedundantQuotes {
 		switch id.Type {
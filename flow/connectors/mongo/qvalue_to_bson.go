@@ -0,0 +1,369 @@
+package connmongo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	lua "github.com/yuin/gopher-lua"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// QValueToBson converts a QValue into a value the BSON encoder understands,
+// this is the reverse direction of the conversions in qvalue_convert.go
+func QValueToBson(qv types.QValue) (any, error) {
+	switch v := qv.(type) {
+	case nil, types.QValueNull:
+		return nil, nil
+	case types.QValueInvalid:
+		return v.Val, nil
+	case types.QValueFloat32:
+		return float64(v.Val), nil
+	case types.QValueFloat64:
+		return v.Val, nil
+	case types.QValueInt8:
+		return int32(v.Val), nil
+	case types.QValueInt16:
+		return int32(v.Val), nil
+	case types.QValueInt32:
+		return v.Val, nil
+	case types.QValueInt64:
+		return v.Val, nil
+	case types.QValueInt256:
+		return bigIntToBson(v.Val), nil
+	case types.QValueUInt8:
+		return int32(v.Val), nil
+	case types.QValueUInt16:
+		return int32(v.Val), nil
+	case types.QValueUInt32:
+		return int64(v.Val), nil
+	case types.QValueUInt64:
+		if v.Val > math.MaxInt64 {
+			return bigIntToBson(new(big.Int).SetUint64(v.Val)), nil
+		}
+		return int64(v.Val), nil
+	case types.QValueUInt256:
+		return bigIntToBson(v.Val), nil
+	case types.QValueBoolean:
+		return v.Val, nil
+	case types.QValueQChar:
+		return string(rune(v.Val)), nil
+	case types.QValueString:
+		return v.Val, nil
+	case types.QValueEnum:
+		return v.Val, nil
+	case types.QValueUint16Enum:
+		return int32(v.Val), nil
+	case types.QValueUint64Set:
+		if v.Val > math.MaxInt64 {
+			return bigIntToBson(new(big.Int).SetUint64(v.Val)), nil
+		}
+		return int64(v.Val), nil
+	case types.QValueTimestamp:
+		return bson.NewDateTimeFromTime(v.Val), nil
+	case types.QValueTimestampTZ:
+		return bson.NewDateTimeFromTime(v.Val), nil
+	case types.QValueDate:
+		return bson.NewDateTimeFromTime(v.Val), nil
+	case types.QValueTime:
+		return formatTimeOfDay(v.Val), nil
+	case types.QValueTimeTZ:
+		return formatTimeOfDay(v.Val), nil
+	case types.QValueInterval:
+		return v.Val, nil
+	case types.QValueNumeric:
+		return decimalToBson(v.Val), nil
+	case types.QValueBytes:
+		return bson.Binary{Subtype: bson.TypeBinaryGeneric, Data: v.Val}, nil
+	case types.QValueUUID:
+		return uuidToBson(v.Val), nil
+	case types.QValueJSON:
+		return jsonToBson(v.Val)
+	case types.QValueHStore:
+		return v.Val, nil
+	case types.QValueStruct:
+		doc := make(bson.D, 0, len(v.Val))
+		for _, member := range v.Val {
+			val, err := QValueToBson(member.Val)
+			if err != nil {
+				return nil, fmt.Errorf("member %s: %w", member.Name, err)
+			}
+			doc = append(doc, bson.E{Key: member.Name, Value: val})
+		}
+		return doc, nil
+	case types.QValueArrayStruct:
+		arr := make(bson.A, 0, len(v.Val))
+		for i, element := range v.Val {
+			doc, err := QValueToBson(element)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			arr = append(arr, doc)
+		}
+		return arr, nil
+	case types.QValueMap:
+		return RecordItemsToBsonDocument(v.Val)
+	case types.QValueGeography:
+		return v.Val, nil
+	case types.QValueGeometry:
+		return v.Val, nil
+	case types.QValuePoint:
+		return v.Val, nil
+	case types.QValueCIDR:
+		return v.Val, nil
+	case types.QValueINET:
+		return v.Val, nil
+	case types.QValueMacaddr:
+		return v.Val, nil
+	case types.QValueArrayFloat32:
+		return sliceToBsonArray(v.Val, func(x float32) any { return float64(x) }), nil
+	case types.QValueArrayFloat64:
+		return sliceToBsonArray(v.Val, func(x float64) any { return x }), nil
+	case types.QValueArrayInt16:
+		return sliceToBsonArray(v.Val, func(x int16) any { return int32(x) }), nil
+	case types.QValueArrayInt32:
+		return sliceToBsonArray(v.Val, func(x int32) any { return x }), nil
+	case types.QValueArrayInt64:
+		return sliceToBsonArray(v.Val, func(x int64) any { return x }), nil
+	case types.QValueArrayString:
+		return sliceToBsonArray(v.Val, func(x string) any { return x }), nil
+	case types.QValueArrayEnum:
+		return sliceToBsonArray(v.Val, func(x string) any { return x }), nil
+	case types.QValueArrayInterval:
+		return sliceToBsonArray(v.Val, func(x string) any { return x }), nil
+	case types.QValueArrayDate:
+		return sliceToBsonArray(v.Val, func(x time.Time) any { return bson.NewDateTimeFromTime(x) }), nil
+	case types.QValueArrayTimestamp:
+		return sliceToBsonArray(v.Val, func(x time.Time) any { return bson.NewDateTimeFromTime(x) }), nil
+	case types.QValueArrayTimestampTZ:
+		return sliceToBsonArray(v.Val, func(x time.Time) any { return bson.NewDateTimeFromTime(x) }), nil
+	case types.QValueArrayBoolean:
+		return sliceToBsonArray(v.Val, func(x bool) any { return x }), nil
+	case types.QValueArrayUUID:
+		return sliceToBsonArray(v.Val, func(x uuid.UUID) any { return uuidToBson(x) }), nil
+	case types.QValueArrayNumeric:
+		return sliceToBsonArray(v.Val, func(x decimal.Decimal) any { return decimalToBson(x) }), nil
+	default:
+		return nil, fmt.Errorf("[mongo] unsupported QValue kind for BSON conversion: %s", qv.Kind())
+	}
+}
+
+func sliceToBsonArray[T any](vals []T, f func(T) any) bson.A {
+	arr := make(bson.A, 0, len(vals))
+	for _, val := range vals {
+		arr = append(arr, f(val))
+	}
+	return arr
+}
+
+func uuidToBson(u uuid.UUID) bson.Binary {
+	return bson.Binary{Subtype: bson.TypeBinaryUUID, Data: u[:]}
+}
+
+// Decimal128 holds 34 significant digits, anything wider falls back to its string representation
+func decimalToBson(d decimal.Decimal) any {
+	str := d.String()
+	if dec, err := bson.ParseDecimal128(str); err == nil {
+		return dec
+	}
+	return str
+}
+
+func bigIntToBson(b *big.Int) any {
+	if b == nil {
+		return nil
+	}
+	if b.IsInt64() {
+		return b.Int64()
+	}
+	return decimalToBson(decimal.NewFromBigInt(b, 0))
+}
+
+func formatTimeOfDay(d time.Duration) string {
+	return time.Time{}.Add(d).Format("15:04:05.999999")
+}
+
+// jsonToBson parses JSON text into BSON values, objects become embedded documents and arrays become BSON arrays.
+// The text is decoded as plain JSON, keys like $date or $numberLong stay fields instead of being read as Extended JSON.
+func jsonToBson(val string) (any, error) {
+	if strings.TrimSpace(val) == "" {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(val))
+	decoder.UseNumber()
+	doc, err := decodeJSONValue(decoder)
+	if err != nil {
+		return nil, fmt.Errorf("[mongo] failed to convert JSON to BSON: %w", err)
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("[mongo] failed to convert JSON to BSON: unexpected data after value")
+	}
+	return doc, nil
+}
+
+// decodeJSONValue decodes the next JSON value, keeping the order of object keys
+func decodeJSONValue(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := token.(type) {
+	case json.Delim:
+		if t == '[' {
+			values := bson.A{}
+			for decoder.More() {
+				val, err := decodeJSONValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, val)
+			}
+			_, err := decoder.Token() // closing ]
+			return values, err
+		}
+		doc := bson.D{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			val, err := decodeJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			doc = append(doc, bson.E{Key: key.(string), Value: val})
+		}
+		_, err := decoder.Token() // closing }
+		return doc, err
+	case json.Number:
+		return jsonNumberToBson(t), nil
+	default:
+		return t, nil
+	}
+}
+
+// jsonNumberToBson picks the narrowest BSON number that holds a JSON number,
+// integers beyond int64 become Decimal128 so their digits are kept
+func jsonNumberToBson(n json.Number) any {
+	if i, err := n.Int64(); err == nil {
+		if i >= math.MinInt32 && i <= math.MaxInt32 {
+			return int32(i)
+		}
+		return i
+	}
+	if !strings.ContainsAny(n.String(), ".eE") {
+		if d, err := bson.ParseDecimal128(n.String()); err == nil {
+			return d
+		}
+	}
+	f, _ := n.Float64()
+	return f
+}
+
+// RecordItemsToBsonDocument builds a document from all columns of a record, ordered by column name
+func RecordItemsToBsonDocument(colToVal map[string]types.QValue) (bson.D, error) {
+	cols := slices.Sorted(maps.Keys(colToVal))
+	doc := make(bson.D, 0, len(cols))
+	for _, col := range cols {
+		val, err := QValueToBson(colToVal[col])
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", col, err)
+		}
+		doc = append(doc, bson.E{Key: col, Value: val})
+	}
+	return doc, nil
+}
+
+// LValueToBson converts the result of a Lua script into BSON,
+// tables with only sequential integer keys become arrays and all other tables become documents
+func LValueToBson(lv lua.LValue) (any, error) {
+	switch v := lv.(type) {
+	case *lua.LNilType:
+		return nil, nil
+	case lua.LBool:
+		return bool(v), nil
+	case lua.LString:
+		return string(v), nil
+	case lua.LNumber:
+		f := float64(v)
+		if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			return int64(f), nil
+		}
+		return f, nil
+	case *lua.LUserData:
+		switch ud := v.Value.(type) {
+		case time.Time:
+			return bson.NewDateTimeFromTime(ud), nil
+		case uuid.UUID:
+			return uuidToBson(ud), nil
+		case decimal.Decimal:
+			return decimalToBson(ud), nil
+		case *big.Int:
+			return bigIntToBson(ud), nil
+		case int64:
+			return ud, nil
+		case uint64:
+			if ud > math.MaxInt64 {
+				return bigIntToBson(new(big.Int).SetUint64(ud)), nil
+			}
+			return int64(ud), nil
+		default:
+			return nil, fmt.Errorf("[mongo] unsupported Lua userdata for BSON conversion: %T", ud)
+		}
+	case *lua.LTable:
+		return lTableToBson(v)
+	default:
+		return nil, fmt.Errorf("[mongo] unsupported Lua value for BSON conversion: %s", lv.Type())
+	}
+}
+
+func lTableToBson(tbl *lua.LTable) (any, error) {
+	length := tbl.Len()
+	isArray := true
+	fields := make([]bson.E, 0, length)
+	var convErr error
+	tbl.ForEach(func(k lua.LValue, lv lua.LValue) {
+		if num, ok := k.(lua.LNumber); !ok || float64(num) != math.Trunc(float64(num)) ||
+			int(num) < 1 || int(num) > length {
+			isArray = false
+		}
+		if convErr != nil {
+			return
+		}
+		val, err := LValueToBson(lv)
+		if err != nil {
+			convErr = fmt.Errorf("field %s: %w", k.String(), err)
+			return
+		}
+		fields = append(fields, bson.E{Key: k.String(), Value: val})
+	})
+	if convErr != nil {
+		return nil, convErr
+	}
+
+	if isArray && len(fields) > 0 {
+		arr := make(bson.A, length)
+		for _, field := range fields {
+			idx, _ := strconv.Atoi(field.Key)
+			arr[idx-1] = field.Value
+		}
+		return arr, nil
+	}
+
+	slices.SortFunc(fields, func(a, b bson.E) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return bson.D(fields), nil
+}
@@ -0,0 +1,174 @@
+package connmongo
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestQValueToBson(t *testing.T) {
+	ts := time.Date(2024, 5, 6, 7, 8, 9, 123000000, time.UTC)
+	u := uuid.MustParse("0a8cd6f8-8d5c-4a66-9f4b-b4d3a46a0f8e")
+	dec128, err := bson.ParseDecimal128("123.456")
+	require.NoError(t, err)
+	bigInt, err := bson.ParseDecimal128("123456789012345678901234567890")
+	require.NoError(t, err)
+
+	tests := []struct {
+		input    types.QValue
+		expected any
+		desc     string
+	}{
+		{desc: "null", input: types.QValueNull(types.QValueKindString), expected: nil},
+		{desc: "int16", input: types.QValueInt16{Val: 12}, expected: int32(12)},
+		{desc: "int64", input: types.QValueInt64{Val: math.MaxInt64}, expected: int64(math.MaxInt64)},
+		{desc: "uint32", input: types.QValueUInt32{Val: math.MaxUint32}, expected: int64(math.MaxUint32)},
+		{desc: "float32", input: types.QValueFloat32{Val: 1.5}, expected: float64(1.5)},
+		{desc: "bool", input: types.QValueBoolean{Val: true}, expected: true},
+		{desc: "string", input: types.QValueString{Val: "hello"}, expected: "hello"},
+		{desc: "timestamptz", input: types.QValueTimestampTZ{Val: ts}, expected: bson.NewDateTimeFromTime(ts)},
+		{desc: "time", input: types.QValueTime{Val: 13*time.Hour + 14*time.Minute + 15*time.Second}, expected: "13:14:15"},
+		{desc: "numeric", input: types.QValueNumeric{Val: decimal.RequireFromString("123.456")}, expected: dec128},
+		{
+			desc:     "numeric wider than decimal128",
+			input:    types.QValueNumeric{Val: decimal.RequireFromString("1234567890123456789012345678901234567890")},
+			expected: "1234567890123456789012345678901234567890",
+		},
+		{desc: "uuid", input: types.QValueUUID{Val: u}, expected: bson.Binary{Subtype: bson.TypeBinaryUUID, Data: u[:]}},
+		{desc: "bytes", input: types.QValueBytes{Val: []byte{1, 2}}, expected: bson.Binary{Data: []byte{1, 2}}},
+		{
+			desc:     "json object",
+			input:    types.QValueJSON{Val: `{"a":1,"b":[true,"x"]}`},
+			expected: bson.D{{Key: "a", Value: int32(1)}, {Key: "b", Value: bson.A{true, "x"}}},
+		},
+		{desc: "json scalar", input: types.QValueJSON{Val: `"x"`}, expected: "x"},
+		{
+			desc:  "json extended json keys",
+			input: types.QValueJSON{Val: `{"d":{"$date":"2024-01-01T00:00:00Z"},"n":{"$numberLong":"5"}}`},
+			expected: bson.D{
+				{Key: "d", Value: bson.D{{Key: "$date", Value: "2024-01-01T00:00:00Z"}}},
+				{Key: "n", Value: bson.D{{Key: "$numberLong", Value: "5"}}},
+			},
+		},
+		{
+			desc:     "json numbers",
+			input:    types.QValueJSON{Val: `[1,5000000000,1.5,123456789012345678901234567890,{},[]]`},
+			expected: bson.A{int32(1), int64(5000000000), 1.5, bigInt, bson.D{}, bson.A{}},
+		},
+		{desc: "array int32", input: types.QValueArrayInt32{Val: []int32{1, 2}}, expected: bson.A{int32(1), int32(2)}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			val, err := QValueToBson(tc.input)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, val)
+		})
+	}
+}
+
+func TestLValueToBson(t *testing.T) {
+	ls := lua.NewState()
+	defer ls.Close()
+	require.NoError(t, ls.DoString(`result = { name = "a", items = { 1, 2.5 }, nested = { flag = true } }`))
+
+	val, err := LValueToBson(ls.GetGlobal("result"))
+	require.NoError(t, err)
+	require.Equal(t, bson.D{
+		{Key: "items", Value: bson.A{int64(1), 2.5}},
+		{Key: "name", Value: "a"},
+		{Key: "nested", Value: bson.D{{Key: "flag", Value: true}}},
+	}, val)
+}
+
+func TestRecordWriteModels(t *testing.T) {
+	schema := &protos.TableSchema{PrimaryKeyColumns: []string{"tenant", "id"}}
+	items := model.NewRecordItems(3)
+	items.AddColumn("tenant", types.QValueString{Val: "t1"})
+	items.AddColumn("id", types.QValueInt64{Val: 7})
+	items.AddColumn("name", types.QValueString{Val: "n"})
+	expectedID := bson.D{{Key: "tenant", Value: "t1"}, {Key: "id", Value: int64(7)}}
+
+	insert, err := recordWriteModels(nil, schema, &model.InsertRecord[model.RecordItems]{
+		Items:                items,
+		DestinationTableName: "db.coll",
+	})
+	require.NoError(t, err)
+	require.Len(t, insert, 1)
+	replace, ok := insert[0].(*mongo.ReplaceOneModel)
+	require.True(t, ok)
+	require.Equal(t, bson.D{{Key: "_id", Value: expectedID}}, replace.Filter)
+	require.Equal(t, bson.D{
+		{Key: "_id", Value: expectedID},
+		{Key: "id", Value: int64(7)},
+		{Key: "name", Value: "n"},
+		{Key: "tenant", Value: "t1"},
+	}, replace.Replacement)
+
+	del, err := recordWriteModels(nil, schema, &model.DeleteRecord[model.RecordItems]{
+		Items:                items,
+		DestinationTableName: "db.coll",
+	})
+	require.NoError(t, err)
+	require.Len(t, del, 1)
+	deleteModel, ok := del[0].(*mongo.DeleteOneModel)
+	require.True(t, ok)
+	require.Equal(t, bson.D{{Key: "_id", Value: expectedID}}, deleteModel.Filter)
+
+	_, err = recordWriteModels(nil, &protos.TableSchema{}, &model.DeleteRecord[model.RecordItems]{
+		Items:                items,
+		DestinationTableName: "db.coll",
+	})
+	require.Error(t, err)
+
+	// old values without the key columns, or with the same key, keep the document in place
+	unchanged := model.NewRecordItems(2)
+	unchanged.AddColumn("tenant", types.QValueString{Val: "t1"})
+	unchanged.AddColumn("id", types.QValueInt64{Val: 7})
+	for _, oldItems := range []model.RecordItems{model.NewRecordItems(0), unchanged} {
+		update, err := recordWriteModels(nil, schema, &model.UpdateRecord[model.RecordItems]{
+			OldItems:             oldItems,
+			NewItems:             items,
+			DestinationTableName: "db.coll",
+		})
+		require.NoError(t, err)
+		require.Len(t, update, 1)
+		require.IsType(t, &mongo.ReplaceOneModel{}, update[0])
+	}
+
+	moved := model.NewRecordItems(2)
+	moved.AddColumn("tenant", types.QValueString{Val: "t1"})
+	moved.AddColumn("id", types.QValueInt64{Val: 6})
+	update, err := recordWriteModels(nil, schema, &model.UpdateRecord[model.RecordItems]{
+		OldItems:             moved,
+		NewItems:             items,
+		DestinationTableName: "db.coll",
+	})
+	require.NoError(t, err)
+	require.Len(t, update, 2)
+	deleteModel, ok = update[0].(*mongo.DeleteOneModel)
+	require.True(t, ok)
+	require.Equal(t, bson.D{{Key: "_id", Value: bson.D{{Key: "tenant", Value: "t1"}, {Key: "id", Value: int64(6)}}}},
+		deleteModel.Filter)
+	replace, ok = update[1].(*mongo.ReplaceOneModel)
+	require.True(t, ok)
+	require.Equal(t, bson.D{{Key: "_id", Value: expectedID}}, replace.Filter)
+
+	_, err = recordWriteModels(nil, &protos.TableSchema{PrimaryKeyColumns: []string{"missing"}},
+		&model.InsertRecord[model.RecordItems]{
+			Items:                items,
+			DestinationTableName: "db.coll",
+		})
+	require.Error(t, err)
+}
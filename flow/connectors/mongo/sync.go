@@ -0,0 +1,333 @@
+package connmongo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+
+	lua "github.com/yuin/gopher-lua"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/pkg/common"
+	"github.com/PeerDB-io/peerdb/flow/pua"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// number of write models buffered per collection before a bulkWrite is issued
+const bulkWriteBatchSize = 1000
+
+// MongoDB is schemaless and collections are created on first write, no raw table staging needed
+func (c *MongoConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	return &protos.CreateRawTableOutput{TableIdentifier: "n/a"}, nil
+}
+
+// documents are rewritten in full on every change, new columns show up as new fields
+func (c *MongoConnector) ReplayTableSchemaDeltas(_ context.Context, _ map[string]string,
+	flowJobName string, _ []*protos.TableMapping, schemaDeltas []*protos.TableSchemaDelta, _ []string,
+) error {
+	return nil
+}
+
+// bulkWriter buffers write models per destination collection and flushes them with ordered bulkWrites,
+// ordering matters since one batch may contain several changes to the same document
+type bulkWriter struct {
+	client  *mongo.Client
+	pending map[string][]mongo.WriteModel
+}
+
+func (c *MongoConnector) newBulkWriter() *bulkWriter {
+	return &bulkWriter{
+		client:  c.client,
+		pending: make(map[string][]mongo.WriteModel),
+	}
+}
+
+func (w *bulkWriter) add(ctx context.Context, destinationTable string, model mongo.WriteModel) error {
+	w.pending[destinationTable] = append(w.pending[destinationTable], model)
+	if len(w.pending[destinationTable]) >= bulkWriteBatchSize {
+		return w.flushCollection(ctx, destinationTable)
+	}
+	return nil
+}
+
+func (w *bulkWriter) flushCollection(ctx context.Context, destinationTable string) error {
+	models := w.pending[destinationTable]
+	if len(models) == 0 {
+		return nil
+	}
+	parsedTable, err := common.ParseTableIdentifier(destinationTable)
+	if err != nil {
+		return fmt.Errorf("[mongo] unable to parse destination collection %s: %w", destinationTable, err)
+	}
+	collection := w.client.Database(parsedTable.Namespace).Collection(parsedTable.Table)
+	if _, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(true)); err != nil {
+		return fmt.Errorf("[mongo] bulkWrite to %s failed: %w", destinationTable, err)
+	}
+	w.pending[destinationTable] = models[:0]
+	return nil
+}
+
+func (w *bulkWriter) flush(ctx context.Context) error {
+	for destinationTable := range w.pending {
+		if err := w.flushCollection(ctx, destinationTable); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// documentID derives the _id of a destination document from its key columns,
+// a single key column is used as is while composite keys become an embedded document
+func documentID(keyColumns []string, colToVal map[string]types.QValue) (any, error) {
+	if len(keyColumns) == 1 {
+		qv, ok := colToVal[keyColumns[0]]
+		if !ok {
+			return nil, fmt.Errorf("key column %s not found in record", keyColumns[0])
+		}
+		return QValueToBson(qv)
+	}
+	id := make(bson.D, 0, len(keyColumns))
+	for _, col := range keyColumns {
+		qv, ok := colToVal[col]
+		if !ok {
+			return nil, fmt.Errorf("key column %s not found in record", col)
+		}
+		val, err := QValueToBson(qv)
+		if err != nil {
+			return nil, fmt.Errorf("key column %s: %w", col, err)
+		}
+		id = append(id, bson.E{Key: col, Value: val})
+	}
+	return id, nil
+}
+
+// setDocumentID places _id first in the document, overriding any _id the document already had
+func setDocumentID(doc bson.D, id any) bson.D {
+	withID := make(bson.D, 0, len(doc)+1)
+	withID = append(withID, bson.E{Key: DefaultDocumentKeyColumnName, Value: id})
+	for _, elem := range doc {
+		if elem.Key != DefaultDocumentKeyColumnName {
+			withID = append(withID, elem)
+		}
+	}
+	return withID
+}
+
+func idFromDocument(doc bson.D) (any, bool) {
+	for _, elem := range doc {
+		if elem.Key == DefaultDocumentKeyColumnName {
+			return elem.Value, true
+		}
+	}
+	return nil, false
+}
+
+// documentFromScript runs onRecord for a record, returning nil when the script skips the record
+func documentFromScript(ls *lua.LState, record model.Record[model.RecordItems]) (bson.D, error) {
+	lfn := ls.Env.RawGetString("onRecord")
+	fn, ok := lfn.(*lua.LFunction)
+	if !ok {
+		return nil, fmt.Errorf("script should define `onRecord` as function, not %s", lfn)
+	}
+
+	ls.Push(fn)
+	ls.Push(pua.LuaRecord.New(ls, record))
+	if err := ls.PCall(1, 1, nil); err != nil {
+		return nil, fmt.Errorf("script failed: %w", err)
+	}
+	result := ls.Get(-1)
+	ls.SetTop(0)
+
+	if result == lua.LNil {
+		return nil, nil
+	}
+	if _, ok := result.(*lua.LTable); !ok {
+		return nil, fmt.Errorf("script should return a table or nil from `onRecord`, not %s", result.Type())
+	}
+	val, err := LValueToBson(result)
+	if err != nil {
+		return nil, err
+	}
+	doc, ok := val.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("script should return a document from `onRecord`, not an array")
+	}
+	return doc, nil
+}
+
+func (c *MongoConnector) loadScript(ctx context.Context, script string, flowJobName string) (*lua.LState, error) {
+	if script == "" {
+		return nil, nil
+	}
+	ls, err := utils.LoadScript(ctx, script, utils.LuaPrintFn(func(s string) {
+		_ = c.LogFlowInfo(ctx, flowJobName, s)
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("[mongo] error loading script: %w", err)
+	}
+	return ls, nil
+}
+
+// movedDocumentID returns the _id of the document an update moves away from,
+// old values only carry the key columns when the update changed them
+func movedDocumentID(keyColumns []string, update *model.UpdateRecord[model.RecordItems], id any) (any, bool, error) {
+	for _, col := range keyColumns {
+		if _, ok := update.OldItems.ColToVal[col]; !ok {
+			return nil, false, nil
+		}
+	}
+	oldID, err := documentID(keyColumns, update.OldItems.ColToVal)
+	if err != nil {
+		return nil, false, err
+	}
+	oldFilter, err := bson.Marshal(bson.D{{Key: DefaultDocumentKeyColumnName, Value: oldID}})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal old key: %w", err)
+	}
+	filter, err := bson.Marshal(bson.D{{Key: DefaultDocumentKeyColumnName, Value: id}})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal key: %w", err)
+	}
+	return oldID, !bytes.Equal(oldFilter, filter), nil
+}
+
+// recordWriteModels converts a CDC record to the write models applied to its destination documents,
+// an update that changes the key deletes the document under the old key before writing the new one
+func recordWriteModels(
+	ls *lua.LState,
+	schema *protos.TableSchema,
+	record model.Record[model.RecordItems],
+) ([]mongo.WriteModel, error) {
+	var keyColumns []string
+	if schema != nil {
+		keyColumns = schema.PrimaryKeyColumns
+	}
+	items := record.GetItems()
+
+	if _, ok := record.(*model.DeleteRecord[model.RecordItems]); ok {
+		if len(keyColumns) == 0 {
+			return nil, fmt.Errorf("cannot delete from %s without primary key", record.GetDestinationTableName())
+		}
+		id, err := documentID(keyColumns, items.ColToVal)
+		if err != nil {
+			return nil, err
+		}
+		return []mongo.WriteModel{mongo.NewDeleteOneModel().SetFilter(bson.D{{Key: DefaultDocumentKeyColumnName, Value: id}})}, nil
+	}
+
+	var doc bson.D
+	var err error
+	if ls != nil {
+		doc, err = documentFromScript(ls, record)
+		if err != nil || doc == nil {
+			return nil, err
+		}
+	} else {
+		doc, err = RecordItemsToBsonDocument(items.ColToVal)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	id, hasID := idFromDocument(doc)
+	if len(keyColumns) > 0 {
+		id, err = documentID(keyColumns, items.ColToVal)
+		if err != nil {
+			return nil, err
+		}
+		hasID = true
+	}
+	if !hasID {
+		if _, ok := record.(*model.UpdateRecord[model.RecordItems]); ok {
+			return nil, fmt.Errorf("cannot update %s without primary key", record.GetDestinationTableName())
+		}
+		return []mongo.WriteModel{mongo.NewInsertOneModel().SetDocument(doc)}, nil
+	}
+	doc = setDocumentID(doc, id)
+	filter := bson.D{{Key: DefaultDocumentKeyColumnName, Value: id}}
+
+	update, isUpdate := record.(*model.UpdateRecord[model.RecordItems])
+	writeModels := make([]mongo.WriteModel, 0, 2)
+	if isUpdate && len(keyColumns) > 0 {
+		oldID, moved, err := movedDocumentID(keyColumns, update, id)
+		if err != nil {
+			return nil, err
+		} else if moved {
+			writeModels = append(writeModels,
+				mongo.NewDeleteOneModel().SetFilter(bson.D{{Key: DefaultDocumentKeyColumnName, Value: oldID}}))
+		}
+	}
+
+	// unchanged TOAST columns are absent from the record, only overwrite the columns we have
+	if isUpdate && len(update.UnchangedToastColumns) > 0 {
+		return append(writeModels, mongo.NewUpdateOneModel().
+			SetFilter(filter).
+			SetUpdate(bson.D{{Key: "$set", Value: doc[1:]}}).
+			SetUpsert(true)), nil
+	}
+	return append(writeModels, mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(doc).SetUpsert(true)), nil
+}
+
+func (c *MongoConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	writer := c.newBulkWriter()
+
+	ls, err := c.loadScript(ctx, req.Script, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	if ls != nil {
+		defer ls.Close()
+	}
+
+	var numRecords int64
+	for record := range req.Records.GetRecords() {
+		switch record.(type) {
+		case *model.InsertRecord[model.RecordItems],
+			*model.UpdateRecord[model.RecordItems],
+			*model.DeleteRecord[model.RecordItems]:
+		default:
+			continue
+		}
+
+		destinationTable := record.GetDestinationTableName()
+		writeModels, err := recordWriteModels(ls, req.TableNameSchemaMapping[destinationTable], record)
+		if err != nil {
+			return nil, fmt.Errorf("[mongo] failed to build write for %s: %w", destinationTable, err)
+		}
+		if writeModels == nil {
+			continue
+		}
+		for _, writeModel := range writeModels {
+			if err := writer.add(ctx, destinationTable, writeModel); err != nil {
+				return nil, err
+			}
+		}
+		record.PopulateCountMap(tableNameRowsMapping)
+		numRecords++
+	}
+
+	if err := writer.flush(ctx); err != nil {
+		return nil, err
+	}
+	c.logger.Info("[mongo] synced records", slog.Int64("numRecords", numRecords))
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		return nil, err
+	}
+
+	return &model.SyncResponse{
+		CurrentSyncBatchID:   req.SyncBatchID,
+		LastSyncedCheckpoint: lastCheckpoint,
+		NumRecordsSynced:     numRecords,
+		TableNameRowsMapping: tableNameRowsMapping,
+		TableSchemaDeltas:    req.Records.SchemaDeltas,
+	}, nil
+}
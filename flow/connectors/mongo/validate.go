@@ -2,8 +2,12 @@ package connmongo
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"slices"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/pkg/common"
 	shared_mongo "github.com/PeerDB-io/peerdb/flow/pkg/mongo"
 )
@@ -21,8 +25,17 @@ func (c *MongoConnector) ValidateCheck(ctx context.Context) error {
 }
 
 func (c *MongoConnector) ValidateMirrorSource(ctx context.Context, cfg *protos.FlowConnectionConfigsCore) error {
+	flatten, err := internal.PeerDBMongoDBFlattenDocuments(ctx, cfg.Env)
+	if err != nil {
+		return err
+	}
 	tables := make([]*common.QualifiedTable, 0, len(cfg.TableMappings))
 	for _, tm := range cfg.TableMappings {
+		// flattened tables are recognized by their overflow column
+		if flatten && slices.Contains(tm.Exclude, DefaultOverflowColumnName) {
+			return fmt.Errorf("column %s of %s cannot be excluded when flattening documents",
+				DefaultOverflowColumnName, tm.SourceTableIdentifier)
+		}
 		t, err := common.ParseTableIdentifier(tm.SourceTableIdentifier)
 		if err != nil {
 			return err
@@ -32,11 +45,83 @@ func (c *MongoConnector) ValidateMirrorSource(ctx context.Context, cfg *protos.F
 	if err := shared_mongo.ValidateCollections(ctx, c.client, tables); err != nil {
 		return err
 	}
+	if err := shared_mongo.ValidateShardedTopology(ctx, c.client); err != nil {
+		return err
+	}
+	if err := c.checkShardedCollections(ctx, tables); err != nil {
+		return err
+	}
 
 	// no need to check oplog retention for initial-snapshot-only mirrors
 	if cfg.DoInitialSnapshot && cfg.InitialSnapshotOnly {
 		return nil
 	}
+	if err := c.checkPreImages(ctx, tables); err != nil {
+		return err
+	}
 
 	return shared_mongo.ValidateOplogRetention(ctx, c.client)
 }
+
+// checkPreImages reports collections that replicate without pre-images. Pre-images are
+// optional: without them updates carry no old values and deletes reach the destination
+// with the document key alone.
+func (c *MongoConnector) checkPreImages(ctx context.Context, tables []*common.QualifiedTable) error {
+	supported, err := c.preImagesSupported(ctx)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		c.logger.Warn("[mongo] pre-images require MongoDB " + shared_mongo.MinPreImagesVersion +
+			", deleted documents replicate without their fields")
+		return nil
+	}
+
+	preImageCollections := make(map[string][]string)
+	for _, t := range tables {
+		collections, ok := preImageCollections[t.Namespace]
+		if !ok {
+			if collections, err = shared_mongo.GetPreImageCollectionNames(ctx, c.client, t.Namespace); err != nil {
+				return fmt.Errorf("failed to list collections with pre-images: %w", err)
+			}
+			preImageCollections[t.Namespace] = collections
+		}
+		if !slices.Contains(collections, t.Table) {
+			c.logger.Warn("[mongo] changeStreamPreAndPostImages is not enabled, deleted documents"+
+				" replicate without their fields", slog.String("collection", t.String()))
+		}
+	}
+	return nil
+}
+
+// checkShardedCollections reports the sharded collections of a mirror. Through mongos the
+// change stream already merges every shard, but _id is only unique per shard unless it
+// leads the shard key, and rows on the destination are keyed by _id alone.
+func (c *MongoConnector) checkShardedCollections(ctx context.Context, tables []*common.QualifiedTable) error {
+	for _, t := range tables {
+		collStats, err := shared_mongo.GetCollStats(ctx, c.client, t.Namespace, t.Table)
+		if err != nil {
+			return err
+		}
+		if !collStats.Sharded {
+			continue
+		}
+		sc, err := shared_mongo.GetShardedCollection(ctx, c.client, t.Namespace, t.Table)
+		if err != nil {
+			// the config database is not readable with every role, the shard key is informational
+			c.logger.Warn("[mongo] unable to read shard key of sharded collection",
+				slog.String("collection", t.String()), slog.Any("error", err))
+			continue
+		} else if sc == nil {
+			continue
+		}
+		c.logger.Info("[mongo] collection is sharded",
+			slog.String("collection", t.String()), slog.String("shardKey", sc.Key.String()))
+		if !sc.HasIDPrefix() {
+			c.logger.Warn("[mongo] _id does not lead the shard key, documents on different shards"+
+				" sharing an _id collapse into one row on the destination",
+				slog.String("collection", t.String()), slog.String("shardKey", sc.Key.String()))
+		}
+	}
+	return nil
+}
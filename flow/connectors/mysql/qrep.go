@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"slices"
 	"strconv"
 	"strings"
@@ -53,6 +54,10 @@ func (c *MySqlConnector) GetQRepPartitions(
 		return nil, fmt.Errorf("failed to parse watermark table %s: %w", config.WatermarkTable, err)
 	}
 
+	if keyColumns := shared.SplitPartitionKey(config.WatermarkColumn); len(keyColumns) > 1 {
+		return c.getCompositeKeyPartitions(ctx, config, parsedWatermarkTable, keyColumns, last)
+	}
+
 	minmaxQuery := fmt.Sprintf("SELECT MIN(`%[2]s`),MAX(`%[2]s`) FROM %[1]s",
 		parsedWatermarkTable.MySQL(), config.WatermarkColumn)
 	var minmaxHasCount bool
@@ -184,6 +189,52 @@ func (c *MySqlConnector) GetQRepPartitions(
 	return partitionHelper.GetPartitions(), nil
 }
 
+func (c *MySqlConnector) getCompositeKeyPartitions(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	table *common.QualifiedTable,
+	keyColumns []string,
+	last *protos.QRepPartition,
+) ([]*protos.QRepPartition, error) {
+	var after []*protos.CompositeKeyValue
+	if last != nil && last.Range != nil {
+		lastRange, ok := last.Range.Range.(*protos.PartitionRange_CompositeKeyRange)
+		if !ok || len(lastRange.CompositeKeyRange.End) != len(keyColumns) {
+			return nil, fmt.Errorf("cannot resume partitioning on %s from last partition %s",
+				config.WatermarkColumn, last.PartitionId)
+		}
+		after = lastRange.CompositeKeyRange.End
+	}
+
+	totalRows, err := c.tableRowEstimate(ctx, table.Namespace, table.Table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for total rows: %w", err)
+	}
+	rowsPerPartition := int64(config.NumRowsPerPartition)
+	if config.NumPartitionsOverride > 0 && totalRows > 0 {
+		rowsPerPartition = shared.DivCeil(totalRows, int64(config.NumPartitionsOverride))
+	} else if totalRows > 0 {
+		rowsPerPartition = shared.AdjustNumPartitions(totalRows, rowsPerPartition).AdjustedNumRowsPerPartition
+	}
+	if rowsPerPartition <= 0 {
+		c.logger.Warn("estimating no records to replicate, only using 1 partition")
+		rowsPerPartition = math.MaxInt64
+	}
+	c.logger.Info("[mysql] composite key partition details",
+		slog.String("partitionKey", config.WatermarkColumn),
+		slog.Int64("totalRowsEstimate", totalRows),
+		slog.Int64("numRowsPerPartition", rowsPerPartition))
+
+	partitions, err := buildCompositeKeyPartitions(ctx, c, table, keyColumns, after, rowsPerPartition)
+	if err != nil {
+		return nil, err
+	}
+	if config.AddNullPartition {
+		partitions = append(partitions, utils.CreateNullPartition())
+	}
+	return partitions, nil
+}
+
 func supportsRangePartition(qkind types.QValueKind) bool {
 	switch qkind {
 	// integer types
@@ -224,29 +275,48 @@ func (c *MySqlConnector) GetDefaultPartitionKeyForTables(
 			continue
 		}
 		pkColumn := schema.PrimaryKeyColumns[0]
-		var pkQKind types.QValueKind
-		for _, col := range schema.Columns {
-			if col.Name == pkColumn {
-				pkQKind = types.QValueKind(col.Type)
-				break
-			}
-		}
-		if !supportsRangePartition(pkQKind) {
+		if kind := columnQKind(schema, pkColumn); !supportsRangePartition(kind) {
 			c.logger.Info("[mysql] primary key type does not support range partitioning, defaulting to full table snapshot",
 				slog.String("table", source),
 				slog.String("column", pkColumn),
-				slog.String("qkind", string(pkQKind)))
+				slog.String("qkind", string(kind)))
 			continue
 		}
+		partitionKey := pkColumn
+		// composite primary keys are partitioned as keyset ranges over all of their columns when possible,
+		// otherwise by their leading column
+		if len(schema.PrimaryKeyColumns) > 1 && !slices.ContainsFunc(schema.PrimaryKeyColumns, func(column string) bool {
+			return !supportsRangePartition(columnQKind(schema, column))
+		}) {
+			if compositeKey, ok := shared.JoinPartitionKey(schema.PrimaryKeyColumns); ok {
+				partitionKey = compositeKey
+			}
+		}
 		c.logger.Info("[mysql] using primary key as default partition key",
 			slog.String("table", source),
-			slog.String("column", pkColumn),
-			slog.String("qkind", string(pkQKind)))
-		output.TableDefaultPartitionKeyMapping[source] = pkColumn
+			slog.String("partitionKey", partitionKey))
+		output.TableDefaultPartitionKeyMapping[source] = partitionKey
 	}
 	return output, nil
 }
 
+func columnQKind(schema *protos.TableSchema, column string) types.QValueKind {
+	for _, col := range schema.Columns {
+		if col.Name == column {
+			return types.QValueKind(col.Type)
+		}
+	}
+	return ""
+}
+
+func quoteMySQLKeyColumns(partitionKey string) []string {
+	columns := shared.SplitPartitionKey(partitionKey)
+	for i, column := range columns {
+		columns[i] = common.QuoteMySQLIdentifier(column)
+	}
+	return columns
+}
+
 func buildSelectedColumns(cols []*protos.FieldDescription, exclude []string) string {
 	columns := make([]string, 0, len(cols))
 	for _, col := range cols {
@@ -352,6 +422,7 @@ func (c *MySqlConnector) PullQRepRecords(
 	} else {
 		var rangeStart string
 		var rangeEnd string
+		templateParams := map[string]string{}
 
 		queryTemplate := config.Query
 		if queryTemplate == "" {
@@ -382,19 +453,30 @@ func (c *MySqlConnector) PullQRepRecords(
 					"SELECT %[1]s FROM %[2]s WHERE %[3]s >= {{.start}} AND %[3]s < {{.end}}",
 					selectedColumns, parsedSrcTable.MySQL(), common.QuoteMySQLIdentifier(config.WatermarkColumn))
 			}
+		case *protos.PartitionRange_CompositeKeyRange:
+			if config.Query != "" {
+				return 0, 0, errors.New("can't construct a composite key range partition for custom queries")
+			}
+			condition, err := utils.CompositeKeyCondition(
+				quoteMySQLKeyColumns(config.WatermarkColumn), x.CompositeKeyRange, compositeKeyLiteral)
+			if err != nil {
+				return 0, 0, err
+			}
+			// key values are passed as a parameter so they are never parsed as template actions
+			queryTemplate = fmt.Sprintf("SELECT %s FROM %s WHERE {{.condition}}", selectedColumns, parsedSrcTable.MySQL())
+			templateParams["condition"] = condition
 		case *protos.PartitionRange_NullRange:
 			if config.Query != "" {
 				return 0, 0, errors.New("can't construct a null range partition for custom queries")
 			}
 			queryTemplate = fmt.Sprintf(
-				"SELECT %s FROM %s WHERE %s IS NULL",
-				selectedColumns, parsedSrcTable.MySQL(), common.QuoteMySQLIdentifier(config.WatermarkColumn),
+				"SELECT %s FROM %s WHERE %s",
+				selectedColumns, parsedSrcTable.MySQL(), utils.NullKeyCondition(quoteMySQLKeyColumns(config.WatermarkColumn)),
 			)
 		default:
 			return 0, 0, fmt.Errorf("unknown range type: %v", x)
 		}
 
-		templateParams := map[string]string{}
 		if rangeStart != "" && rangeEnd != "" {
 			templateParams["start"] = rangeStart
 			templateParams["end"] = rangeEnd
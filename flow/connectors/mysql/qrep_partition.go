@@ -3,11 +3,14 @@ package connmysql
 import (
 	"container/heap"
 	"context"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log/slog"
 	"math/big"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"unicode/utf8"
 
@@ -371,3 +374,157 @@ func (c *MySqlConnector) estimateRowsInRange(
 	}
 	return rows, nil
 }
+
+// interface for unit-testing
+type compositeKeySeeker interface {
+	// seekCompositeKey returns the key offset rows past after in key order
+	seekCompositeKey(
+		ctx context.Context, tableName string, quotedCols []string, after []*protos.CompositeKeyValue, offset int64,
+	) ([]*protos.CompositeKeyValue, bool, error)
+	// maxCompositeKey returns the largest key past after
+	maxCompositeKey(
+		ctx context.Context, tableName string, quotedCols []string, after []*protos.CompositeKeyValue,
+	) ([]*protos.CompositeKeyValue, bool, error)
+}
+
+// buildCompositeKeyPartitions splits a table along a composite key such as (tenant_id, id)
+// into keyset partitions of roughly rowsPerPartition rows each. Boundaries are found by seeking
+// through the key's index, which needs neither window functions nor a full table sort,
+// and the last partition is closed at the largest key so resuming can continue past it.
+func buildCompositeKeyPartitions(
+	ctx context.Context,
+	seeker compositeKeySeeker,
+	table *common.QualifiedTable,
+	keyColumns []string,
+	after []*protos.CompositeKeyValue,
+	rowsPerPartition int64,
+) ([]*protos.QRepPartition, error) {
+	tableName := table.MySQL()
+	quotedCols := make([]string, 0, len(keyColumns))
+	for _, column := range keyColumns {
+		quotedCols = append(quotedCols, common.QuoteMySQLIdentifier(column))
+	}
+
+	var ends [][]*protos.CompositeKeyValue
+	cursor := after
+	for {
+		key, found, err := seeker.seekCompositeKey(ctx, tableName, quotedCols, cursor, max(rowsPerPartition, 1)-1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to seek partition boundary: %w", err)
+		}
+		if !found {
+			break
+		}
+		ends = append(ends, key)
+		cursor = key
+	}
+	key, found, err := seeker.maxCompositeKey(ctx, tableName, quotedCols, cursor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch maximum key: %w", err)
+	}
+	if found {
+		ends = append(ends, key)
+	}
+	return utils.CompositeKeyPartitions(after, ends), nil
+}
+
+func compositeKeyFilter(quotedCols []string, after []*protos.CompositeKeyValue) string {
+	conditions := make([]string, 0, len(quotedCols)+1)
+	for _, column := range quotedCols {
+		conditions = append(conditions, column+" IS NOT NULL")
+	}
+	if len(after) > 0 {
+		literals := make([]string, 0, len(after))
+		for _, value := range after {
+			literals = append(literals, compositeKeyLiteral(value))
+		}
+		conditions = append(conditions,
+			fmt.Sprintf("(%s) > (%s)", strings.Join(quotedCols, ","), strings.Join(literals, ",")))
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+func (c *MySqlConnector) seekCompositeKey(
+	ctx context.Context, tableName string, quotedCols []string, after []*protos.CompositeKeyValue, offset int64,
+) ([]*protos.CompositeKeyValue, bool, error) {
+	columns := strings.Join(quotedCols, ",")
+	return c.fetchCompositeKey(ctx, fmt.Sprintf("SELECT %[1]s FROM %[2]s WHERE %[3]s ORDER BY %[1]s LIMIT 1 OFFSET %[4]d",
+		columns, tableName, compositeKeyFilter(quotedCols, after), offset))
+}
+
+func (c *MySqlConnector) maxCompositeKey(
+	ctx context.Context, tableName string, quotedCols []string, after []*protos.CompositeKeyValue,
+) ([]*protos.CompositeKeyValue, bool, error) {
+	return c.fetchCompositeKey(ctx, fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s DESC LIMIT 1",
+		strings.Join(quotedCols, ","), tableName, compositeKeyFilter(quotedCols, after),
+		strings.Join(quotedCols, " DESC,")))
+}
+
+func (c *MySqlConnector) fetchCompositeKey(ctx context.Context, query string) ([]*protos.CompositeKeyValue, bool, error) {
+	rs, err := c.Execute(ctx, query)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rs.Close()
+	if rs.RowNumber() == 0 {
+		return nil, false, nil
+	}
+	key := make([]*protos.CompositeKeyValue, 0, len(rs.Fields))
+	for i, field := range rs.Fields {
+		value, err := compositeKeyValue(field, rs.Values[0][i])
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read key column %s: %w", field.Name, err)
+		}
+		key = append(key, value)
+	}
+	return key, true, nil
+}
+
+// compositeKeyValue keeps integers and binary strings typed, so boundaries are compared
+// exactly instead of going through MySQL's implicit string to double conversion
+func compositeKeyValue(field *mysql.Field, value mysql.FieldValue) (*protos.CompositeKeyValue, error) {
+	switch value.Type {
+	case mysql.FieldValueTypeSigned:
+		return &protos.CompositeKeyValue{Value: &protos.CompositeKeyValue_IntValue{IntValue: value.AsInt64()}}, nil
+	case mysql.FieldValueTypeUnsigned:
+		return &protos.CompositeKeyValue{Value: &protos.CompositeKeyValue_UintValue{UintValue: value.AsUint64()}}, nil
+	case mysql.FieldValueTypeFloat:
+		return &protos.CompositeKeyValue{Value: &protos.CompositeKeyValue_StringValue{
+			StringValue: strconv.FormatFloat(value.AsFloat64(), 'g', -1, 64),
+		}}, nil
+	case mysql.FieldValueTypeString:
+		if field.Charset == 63 && isStringType(field.Type) {
+			return &protos.CompositeKeyValue{Value: &protos.CompositeKeyValue_BytesValue{
+				BytesValue: slices.Clone(value.AsString()),
+			}}, nil
+		}
+		return &protos.CompositeKeyValue{Value: &protos.CompositeKeyValue_StringValue{StringValue: string(value.AsString())}}, nil
+	default:
+		return nil, errors.New("unexpected null in partition key")
+	}
+}
+
+func isStringType(mytype byte) bool {
+	switch mytype {
+	case mysql.MYSQL_TYPE_VARCHAR, mysql.MYSQL_TYPE_VAR_STRING, mysql.MYSQL_TYPE_STRING,
+		mysql.MYSQL_TYPE_TINY_BLOB, mysql.MYSQL_TYPE_MEDIUM_BLOB, mysql.MYSQL_TYPE_LONG_BLOB, mysql.MYSQL_TYPE_BLOB:
+		return true
+	default:
+		return false
+	}
+}
+
+func compositeKeyLiteral(value *protos.CompositeKeyValue) string {
+	switch v := value.GetValue().(type) {
+	case *protos.CompositeKeyValue_IntValue:
+		return strconv.FormatInt(v.IntValue, 10)
+	case *protos.CompositeKeyValue_UintValue:
+		return strconv.FormatUint(v.UintValue, 10)
+	case *protos.CompositeKeyValue_BytesValue:
+		return "X'" + hex.EncodeToString(v.BytesValue) + "'"
+	case *protos.CompositeKeyValue_StringValue:
+		return "'" + escapeWithNoBackslashEscapes(v.StringValue) + "'"
+	default:
+		return "NULL"
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"regexp"
 	"slices"
+	"sort"
 	"strings"
 	"testing"
 	"unicode/utf8"
@@ -16,6 +17,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.temporal.io/sdk/log"
 
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/pkg/common"
 )
@@ -500,3 +502,89 @@ func TestBuildAdaptiveStringPartitions_MixedCase_UUID(t *testing.T) {
 	require.Len(t, partitions, numPartitions)
 	verifyFullCoverage(t, partitions, keys, binaryLess)
 }
+
+// fakeCompositeKeySeeker serves (tenant, id) keys sorted in key order
+type fakeCompositeKeySeeker struct {
+	keys [][2]int64
+}
+
+func (f *fakeCompositeKeySeeker) firstAfter(after []*protos.CompositeKeyValue) int {
+	if len(after) == 0 {
+		return 0
+	}
+	bound := [2]int64{after[0].GetIntValue(), after[1].GetIntValue()}
+	return sort.Search(len(f.keys), func(i int) bool {
+		return f.keys[i][0] > bound[0] || (f.keys[i][0] == bound[0] && f.keys[i][1] > bound[1])
+	})
+}
+
+func (f *fakeCompositeKeySeeker) key(i int) []*protos.CompositeKeyValue {
+	return []*protos.CompositeKeyValue{
+		{Value: &protos.CompositeKeyValue_IntValue{IntValue: f.keys[i][0]}},
+		{Value: &protos.CompositeKeyValue_IntValue{IntValue: f.keys[i][1]}},
+	}
+}
+
+func (f *fakeCompositeKeySeeker) seekCompositeKey(
+	_ context.Context, _ string, _ []string, after []*protos.CompositeKeyValue, offset int64,
+) ([]*protos.CompositeKeyValue, bool, error) {
+	i := f.firstAfter(after) + int(offset)
+	if i >= len(f.keys) {
+		return nil, false, nil
+	}
+	return f.key(i), true, nil
+}
+
+func (f *fakeCompositeKeySeeker) maxCompositeKey(
+	_ context.Context, _ string, _ []string, after []*protos.CompositeKeyValue,
+) ([]*protos.CompositeKeyValue, bool, error) {
+	if f.firstAfter(after) >= len(f.keys) {
+		return nil, false, nil
+	}
+	return f.key(len(f.keys) - 1), true, nil
+}
+
+func TestBuildCompositeKeyPartitions(t *testing.T) {
+	seeker := &fakeCompositeKeySeeker{}
+	for tenant := int64(1); tenant <= 3; tenant++ {
+		for id := int64(1); id <= 7; id++ {
+			seeker.keys = append(seeker.keys, [2]int64{tenant, id})
+		}
+	}
+	table := &common.QualifiedTable{Namespace: "db", Table: "t"}
+
+	partitions, err := buildCompositeKeyPartitions(t.Context(), seeker, table, []string{"tenant", "id"}, nil, 5)
+	require.NoError(t, err)
+	bounds := make([]string, 0, len(partitions))
+	for _, partition := range partitions {
+		r := partition.Range.GetCompositeKeyRange()
+		bounds = append(bounds, utils.CompositeKeyString(r.Start)+"-"+utils.CompositeKeyString(r.End))
+	}
+	require.Equal(t, []string{"()-(1,5)", "(1,5)-(2,3)", "(2,3)-(3,1)", "(3,1)-(3,6)", "(3,6)-(3,7)"}, bounds)
+
+	// resuming past the last key yields nothing, the table was fully read
+	partitions, err = buildCompositeKeyPartitions(t.Context(), seeker, table, []string{"tenant", "id"}, seeker.key(20), 5)
+	require.NoError(t, err)
+	require.Empty(t, partitions)
+
+	partitions, err = buildCompositeKeyPartitions(t.Context(), seeker, table, []string{"tenant", "id"}, seeker.key(17), 10)
+	require.NoError(t, err)
+	require.Len(t, partitions, 1)
+	require.Equal(t, "(3,4)", utils.CompositeKeyString(partitions[0].Range.GetCompositeKeyRange().Start))
+	require.Equal(t, "(3,7)", utils.CompositeKeyString(partitions[0].Range.GetCompositeKeyRange().End))
+}
+
+func TestCompositeKeyLiteral(t *testing.T) {
+	require.Equal(t, "-3", compositeKeyLiteral(&protos.CompositeKeyValue{Value: &protos.CompositeKeyValue_IntValue{IntValue: -3}}))
+	require.Equal(t, "18446744073709551615",
+		compositeKeyLiteral(&protos.CompositeKeyValue{Value: &protos.CompositeKeyValue_UintValue{UintValue: 1<<64 - 1}}))
+	require.Equal(t, "'it''s'",
+		compositeKeyLiteral(&protos.CompositeKeyValue{Value: &protos.CompositeKeyValue_StringValue{StringValue: "it's"}}))
+	require.Equal(t, "X'00ff'",
+		compositeKeyLiteral(&protos.CompositeKeyValue{Value: &protos.CompositeKeyValue_BytesValue{BytesValue: []byte{0, 0xff}}}))
+	require.Equal(t, "`tenant` IS NOT NULL AND `id` IS NOT NULL AND (`tenant`,`id`) > (1,'x')",
+		compositeKeyFilter([]string{"`tenant`", "`id`"}, []*protos.CompositeKeyValue{
+			{Value: &protos.CompositeKeyValue_IntValue{IntValue: 1}},
+			{Value: &protos.CompositeKeyValue_StringValue{StringValue: "x"}},
+		}))
+}
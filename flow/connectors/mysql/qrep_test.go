@@ -180,7 +180,21 @@ func TestGetDefaultPartitionKeyForTables(t *testing.T) {
 					},
 				},
 			},
-			expected: map[string]string{"db.composite": "id"},
+			expected: map[string]string{"db.composite": "id,created_at"},
+		},
+		{
+			name:          "composite primary key with unsupported trailing column",
+			tableMappings: []*protos.TableMapping{tableMapping("db.composite3")},
+			schemas: map[string]*protos.TableSchema{
+				"db.composite3": {
+					PrimaryKeyColumns: []string{"id", "data"},
+					Columns: []*protos.FieldDescription{
+						fieldDesc("id", types.QValueKindInt32),
+						fieldDesc("data", types.QValueKindBytes),
+					},
+				},
+			},
+			expected: map[string]string{"db.composite3": "id"},
 		},
 		{
 			name:          "composite primary key with invalid first column",
@@ -0,0 +1,784 @@
+package connoracle
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
+	"github.com/PeerDB-io/peerdb/flow/pkg/common"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// logMinerSlotName is a placeholder slot identifier: Oracle has no replication slots,
+// but a non-empty name makes the snapshot workflow carry the captured SCN into the
+// QRep AS OF SCN reads.
+const logMinerSlotName = "logminer"
+
+const (
+	// logMinerWindowScns bounds the SCN range of a single LogMiner run, keeping
+	// the redo read per START_LOGMNR and the rows held per window manageable
+	logMinerWindowScns = 100_000
+	// logMinerPollInterval is the pause between LogMiner runs once mining caught
+	// up with the current SCN, START_LOGMNR is too costly to run back to back
+	logMinerPollInterval = 2 * time.Second
+)
+
+// notBackfilledDeleteSentinel keeps normalize from touching non-key columns of deletes
+// whose before image only carries part of the row, mirroring sparse postgres deletes
+const notBackfilledDeleteSentinel = "_peerdb_not_backfilled_delete"
+
+// scnCheckpoint is the CDC position of a mirror: LogMiner restarts at resumeScn,
+// the start of the oldest transaction still open when the checkpoint was taken,
+// and transactions committed before lastCommitScn are skipped as already replicated.
+// Several transactions can commit at the same SCN, so committedXids lists those at
+// lastCommitScn that were replicated, nil when all of them were, like at the snapshot SCN.
+// Stored as "resumeScn:lastCommitScn[:xid,...]" with lastCommitScn as the ID.
+type scnCheckpoint struct {
+	committedXids []string
+	resumeScn     uint64
+	lastCommitScn uint64
+}
+
+func (cp scnCheckpoint) String() string {
+	if cp.committedXids == nil {
+		return fmt.Sprintf("%d:%d", cp.resumeScn, cp.lastCommitScn)
+	}
+	return fmt.Sprintf("%d:%d:%s", cp.resumeScn, cp.lastCommitScn, strings.Join(cp.committedXids, ","))
+}
+
+func (cp scnCheckpoint) cdcCheckpoint() model.CdcCheckpoint {
+	return model.CdcCheckpoint{ID: int64(cp.lastCommitScn), Text: cp.String()}
+}
+
+// replicated reports whether the transaction committed as xid at scn was replicated before this checkpoint
+func (cp scnCheckpoint) replicated(scn uint64, xid string) bool {
+	if scn != cp.lastCommitScn {
+		return scn < cp.lastCommitScn
+	}
+	return cp.committedXids == nil || slices.Contains(cp.committedXids, xid)
+}
+
+// afterCommit is the checkpoint once the transaction committed as xid at scn is replicated
+func (cp scnCheckpoint) afterCommit(resumeScn uint64, scn uint64, xid string) scnCheckpoint {
+	committedXids := []string{xid}
+	if scn == cp.lastCommitScn {
+		committedXids = append(slices.Clone(cp.committedXids), xid)
+	}
+	return scnCheckpoint{resumeScn: resumeScn, lastCommitScn: scn, committedXids: committedXids}
+}
+
+func parseScnCheckpoint(text string) (scnCheckpoint, error) {
+	resumeText, rest, found := strings.Cut(text, ":")
+	resumeScn, err := strconv.ParseUint(resumeText, 10, 64)
+	if err != nil {
+		return scnCheckpoint{}, fmt.Errorf("invalid Oracle checkpoint %q: %w", text, err)
+	}
+	if !found {
+		// a bare SCN is both, nothing was open and everything up to it was replicated
+		return scnCheckpoint{resumeScn: resumeScn + 1, lastCommitScn: resumeScn}, nil
+	}
+	lastCommitText, xidsText, hasXids := strings.Cut(rest, ":")
+	lastCommitScn, err := strconv.ParseUint(lastCommitText, 10, 64)
+	if err != nil {
+		return scnCheckpoint{}, fmt.Errorf("invalid Oracle checkpoint %q: %w", text, err)
+	}
+	checkpoint := scnCheckpoint{resumeScn: resumeScn, lastCommitScn: lastCommitScn}
+	if hasXids {
+		checkpoint.committedXids = strings.Split(xidsText, ",")
+	}
+	return checkpoint, nil
+}
+
+// captureConsistentCheckpoint captures a consistent SCN for the initial snapshot along with
+// the checkpoint CDC has to resume from so that transactions open at that SCN are not lost.
+// Transactions open at the snapshot SCN either started before the first CURRENT_SCN read,
+// in which case they are still listed by V$TRANSACTION, or after it.
+func (c *OracleConnector) captureConsistentCheckpoint(ctx context.Context) (scnCheckpoint, error) {
+	lowerScn, err := c.currentScn(ctx, c.db)
+	if err != nil {
+		return scnCheckpoint{}, err
+	}
+	var oldestStart sql.NullString
+	if err := c.db.QueryRowContext(ctx, "SELECT TO_CHAR(MIN(START_SCN)) FROM V$TRANSACTION").Scan(&oldestStart); err != nil {
+		return scnCheckpoint{}, fmt.Errorf("failed to query open transactions: %w", err)
+	}
+	snapshotScn, err := c.currentScn(ctx, c.db)
+	if err != nil {
+		return scnCheckpoint{}, err
+	}
+	resumeScn := lowerScn
+	if oldestStart.Valid {
+		startScn, err := strconv.ParseUint(oldestStart.String, 10, 64)
+		if err != nil {
+			return scnCheckpoint{}, fmt.Errorf("invalid transaction start SCN %q: %w", oldestStart.String, err)
+		}
+		resumeScn = min(resumeScn, startScn)
+	}
+	return scnCheckpoint{resumeScn: resumeScn, lastCommitScn: snapshotScn}, nil
+}
+
+func (c *OracleConnector) EnsurePullability(
+	ctx context.Context, req *protos.EnsurePullabilityBatchInput,
+) (*protos.EnsurePullabilityBatchOutput, error) {
+	return nil, nil
+}
+
+// ExportTxSnapshot captures the current SCN so that initial-snapshot-only
+// mirrors read all tables AS OF one consistent SCN.
+func (c *OracleConnector) ExportTxSnapshot(
+	ctx context.Context, flowName string, env map[string]string,
+) (*protos.ExportTxSnapshotOutput, any, error) {
+	scn, err := c.currentScn(ctx, c.db)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &protos.ExportTxSnapshotOutput{SnapshotName: strconv.FormatUint(scn, 10)}, nil, nil
+}
+
+func (c *OracleConnector) FinishExport(any) error {
+	return nil
+}
+
+// SetupReplication captures the SCN the initial snapshot reads AS OF and stores
+// the checkpoint LogMiner resumes from, so there is no gap or overlap between them.
+func (c *OracleConnector) SetupReplication(
+	ctx context.Context,
+	catalogPool shared.CatalogPool,
+	req *protos.SetupReplicationInput,
+) (model.SetupReplicationResult, error) {
+	checkpoint, err := c.captureConsistentCheckpoint(ctx)
+	if err != nil {
+		return model.SetupReplicationResult{}, err
+	}
+	if err := c.SetLastOffset(ctx, req.FlowJobName, checkpoint.cdcCheckpoint()); err != nil {
+		return model.SetupReplicationResult{}, fmt.Errorf("failed to store initial LogMiner checkpoint: %w", err)
+	}
+	c.logger.Info("[oracle] SetupReplication stored initial LogMiner checkpoint", slog.String("checkpoint", checkpoint.String()))
+	return model.SetupReplicationResult{
+		SlotName:     logMinerSlotName,
+		SnapshotName: strconv.FormatUint(checkpoint.lastCommitScn, 10),
+	}, nil
+}
+
+func (c *OracleConnector) SetupReplConn(context.Context, map[string]string) error {
+	// LogMiner sessions are opened per PullRecords call
+	return nil
+}
+
+func (c *OracleConnector) UpdateReplStateLastOffset(ctx context.Context, lastOffset model.CdcCheckpoint) error {
+	if lastOffset.Text == "" {
+		return nil
+	}
+	flowName := ctx.Value(shared.FlowNameKey).(string)
+	return c.SetLastOffset(ctx, flowName, lastOffset)
+}
+
+func (c *OracleConnector) PullFlowCleanup(ctx context.Context, jobName string) error {
+	// LogMiner keeps no state on the server between sessions
+	return nil
+}
+
+// redoTableSchema is the per source table state used to turn redo into records
+type redoTableSchema struct {
+	table           *common.QualifiedTable
+	fields          map[string]types.QField
+	exclude         map[string]struct{}
+	ignored         map[string]struct{}
+	lobColumns      []oracleColumn
+	pkColumns       []string
+	nullableEnabled bool
+}
+
+type redoChange struct {
+	stmt   *redoStatement
+	source string
+}
+
+type oracleTransaction struct {
+	changes  []redoChange
+	startScn uint64
+}
+
+//nolint:govet // keeping related fields together over alignment
+type logMinerPullState struct {
+	req           *model.PullRecordsRequest[model.RecordItems]
+	sourceByTable map[[2]string]string
+	schemas       map[string]*redoTableSchema
+	transactions  map[string]*oracleTransaction
+	lookupConn    *sql.Conn
+	checkpoint    scnCheckpoint
+	recordCount   uint32
+	batchDeadline time.Time
+	done          bool
+	// skipUnsupported drops changes LogMiner cannot decode instead of failing the pull
+	skipUnsupported bool
+	deltaBytes      atomic.Int64
+	totalBytes      atomic.Int64
+}
+
+// resumeScnAt is the SCN LogMiner has to restart at once everything before
+// nextScn was processed: the start of the oldest transaction still buffered
+func (state *logMinerPullState) resumeScnAt(nextScn uint64) uint64 {
+	resumeScn := nextScn
+	for _, txn := range state.transactions {
+		resumeScn = min(resumeScn, txn.startScn)
+	}
+	return resumeScn
+}
+
+func (state *logMinerPullState) updateCheckpoint(checkpoint scnCheckpoint) {
+	state.checkpoint = checkpoint
+	state.req.RecordStream.UpdateLatestCheckpointText(checkpoint.String())
+	state.req.RecordStream.UpdateLatestCheckpointID(int64(checkpoint.lastCommitScn))
+}
+
+func (c *OracleConnector) PullRecords(
+	ctx context.Context,
+	catalogPool shared.CatalogPool,
+	otelManager *otel_metrics.OtelManager,
+	req *model.PullRecordsRequest[model.RecordItems],
+) error {
+	defer req.RecordStream.Close()
+
+	if req.LastOffset.Text == "" {
+		// SetupReplication seeds the offset before the first pull; mining from the
+		// current SCN instead would silently skip everything since then
+		return fmt.Errorf("no stored LogMiner checkpoint for mirror %s:"+
+			" the replication offset is missing from the catalog, resync the mirror", req.FlowJobName)
+	}
+	checkpoint, err := parseScnCheckpoint(req.LastOffset.Text)
+	if err != nil {
+		return err
+	}
+
+	skipUnsupported, err := internal.PeerDBOracleSkipUnsupportedChanges(ctx, req.Env)
+	if err != nil {
+		return err
+	}
+	state := &logMinerPullState{
+		req:             req,
+		sourceByTable:   make(map[[2]string]string, len(req.TableNameMapping)),
+		schemas:         make(map[string]*redoTableSchema, len(req.TableNameMapping)),
+		transactions:    make(map[string]*oracleTransaction),
+		skipUnsupported: skipUnsupported,
+	}
+	tables := make([]*common.QualifiedTable, 0, len(req.TableNameMapping))
+	for source, target := range req.TableNameMapping {
+		parsed, err := common.ParseTableIdentifier(source)
+		if err != nil {
+			return fmt.Errorf("invalid source table %s: %w", source, err)
+		}
+		tables = append(tables, parsed)
+		state.sourceByTable[[2]string{parsed.Namespace, parsed.Table}] = source
+		schema, err := c.newRedoTableSchema(ctx, parsed, req.TableNameSchemaMapping[target.Name], target.Exclude)
+		if err != nil {
+			return err
+		}
+		state.schemas[source] = schema
+	}
+	slices.SortFunc(tables, func(a, b *common.QualifiedTable) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	tableFilter := buildTableFilter(tables)
+
+	// seed the checkpoint so a batch without records re-persists the prior one instead of clearing it
+	state.updateCheckpoint(checkpoint)
+
+	session, err := c.openLogMinerSession(ctx)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	if state.lookupConn, err = c.openSessionConn(ctx); err != nil {
+		return err
+	}
+	defer state.lookupConn.Close()
+
+	c.logger.Info("[oracle] started PullRecords for mirror "+req.FlowJobName,
+		slog.String("checkpoint", checkpoint.String()),
+		slog.Uint64("maxBatchSize", uint64(req.MaxBatchSize)),
+		slog.Duration("syncInterval", req.IdleTimeout))
+
+	pullStart := time.Now()
+	defer func() {
+		if state.recordCount == 0 {
+			req.RecordStream.SignalAsEmpty()
+		}
+		span := trace.SpanFromContext(ctx)
+		span.SetAttributes(
+			attribute.Int64(otel_metrics.RowsInBatchKey, int64(state.recordCount)),
+			attribute.Int64(otel_metrics.BytesPulledKey, state.totalBytes.Load()),
+		)
+		c.logger.Info("[oracle] PullRecords finished streaming",
+			slog.Uint64("records", uint64(state.recordCount)),
+			slog.Int64("bytes", state.totalBytes.Load()),
+			slog.String("checkpoint", state.checkpoint.String()),
+			slog.Float64("elapsedMinutes", time.Since(pullStart).Minutes()))
+	}()
+
+	reportBytesShutdown := common.Interval(ctx, 10*time.Second, func() {
+		read := state.deltaBytes.Swap(0)
+		otelManager.Metrics.FetchedBytesCounter.Add(ctx, read)
+		otelManager.Metrics.AllFetchedBytesCounter.Add(ctx, read)
+	})
+	defer func() {
+		reportBytesShutdown()
+		read := state.deltaBytes.Swap(0)
+		otelManager.Metrics.FetchedBytesCounter.Add(ctx, read)
+		otelManager.Metrics.AllFetchedBytesCounter.Add(ctx, read)
+	}()
+
+	startScn := checkpoint.resumeScn
+	for {
+		currentScn, err := c.currentScn(ctx, session.conn)
+		if err != nil {
+			return err
+		}
+		if currentScn >= startScn {
+			endScn := min(currentScn, startScn+logMinerWindowScns-1)
+			if err := session.start(ctx, startScn, endScn); err != nil {
+				return err
+			}
+			if err := session.mine(ctx, tableFilter, func(row logMinerRow) error {
+				return c.processLogMinerRow(ctx, otelManager, state, row)
+			}); err != nil {
+				return err
+			}
+			if state.done {
+				return nil
+			}
+			startScn = endScn + 1
+			checkpoint := state.checkpoint
+			checkpoint.resumeScn = state.resumeScnAt(startScn)
+			state.updateCheckpoint(checkpoint)
+			if state.recordCount == 0 {
+				// nothing handed to the sync flow yet, safe to persist directly so an idle
+				// mirror keeps advancing and archived logs behind it can be purged
+				if err := c.SetLastOffset(ctx, req.FlowJobName, state.checkpoint.cdcCheckpoint()); err != nil {
+					c.logger.Error("[oracle] failed to persist LogMiner checkpoint",
+						slog.String("checkpoint", state.checkpoint.String()), slog.Any("error", err))
+				}
+			}
+			if endScn < currentScn {
+				// still catching up, mine the next window right away
+				continue
+			}
+		}
+
+		if state.recordCount > 0 && !time.Now().Before(state.batchDeadline) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(logMinerPollInterval):
+		}
+	}
+}
+
+func (c *OracleConnector) newRedoTableSchema(
+	ctx context.Context,
+	table *common.QualifiedTable,
+	tableSchema *protos.TableSchema,
+	exclude map[string]struct{},
+) (*redoTableSchema, error) {
+	if tableSchema == nil {
+		return nil, fmt.Errorf("no cached schema for source table %s; resync the mirror", table)
+	}
+	schema := &redoTableSchema{
+		table:           table,
+		fields:          make(map[string]types.QField, len(tableSchema.Columns)),
+		exclude:         exclude,
+		ignored:         make(map[string]struct{}),
+		pkColumns:       tableSchema.PrimaryKeyColumns,
+		nullableEnabled: tableSchema.NullableEnabled,
+	}
+	for _, col := range tableSchema.Columns {
+		schema.fields[col.Name] = fieldFromDescription(col)
+	}
+	columns, err := c.getColumns(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	for _, column := range columns {
+		if _, ok := schema.fields[column.name]; ok && isLobType(column.dataType) {
+			schema.lobColumns = append(schema.lobColumns, column)
+		}
+	}
+	return schema, nil
+}
+
+func fieldFromDescription(col *protos.FieldDescription) types.QField {
+	precision, scale := common.ParseNumericTypmod(col.TypeModifier)
+	return types.QField{
+		Name:      col.Name,
+		Type:      types.QValueKind(col.Type),
+		Precision: precision,
+		Scale:     scale,
+		Nullable:  col.Nullable,
+	}
+}
+
+func (c *OracleConnector) processLogMinerRow(
+	ctx context.Context,
+	otelManager *otel_metrics.OtelManager,
+	state *logMinerPullState,
+	row logMinerRow,
+) error {
+	redoBytes := int64(len(row.sqlRedo))
+	state.deltaBytes.Add(redoBytes)
+	state.totalBytes.Add(redoBytes)
+
+	switch row.operation {
+	case logMinerOpRollback:
+		delete(state.transactions, row.xid)
+		return nil
+	case logMinerOpCommit:
+		txn, ok := state.transactions[row.xid]
+		if !ok {
+			return nil
+		}
+		delete(state.transactions, row.xid)
+		if state.checkpoint.replicated(row.scn, row.xid) {
+			// replicated before the restart this resumed from, or covered by the initial snapshot
+			return nil
+		}
+		if err := c.emitTransaction(ctx, otelManager, state, txn, row); err != nil {
+			return err
+		}
+		if state.recordCount >= state.req.MaxBatchSize {
+			// full batch: everything before this commit is either emitted or buffered
+			// in a transaction the resume SCN still covers, and the rest of its SCN is mined again
+			state.done = true
+			return errStopMining
+		}
+		return nil
+	case logMinerOpUnsupported:
+		if _, ok := state.sourceByTable[[2]string{row.owner, row.table}]; !ok {
+			return nil
+		}
+		if !state.skipUnsupported {
+			return fmt.Errorf("LogMiner could not decode a change to %s.%s at SCN %d,"+
+				" set PEERDB_ORACLE_SKIP_UNSUPPORTED_CHANGES to skip such changes", row.owner, row.table, row.scn)
+		}
+		c.logger.Warn("[oracle] LogMiner could not decode a change, it is skipped",
+			slog.String("table", row.owner+"."+row.table),
+			slog.Uint64("scn", row.scn))
+		return nil
+	}
+
+	source, ok := state.sourceByTable[[2]string{row.owner, row.table}]
+	if !ok {
+		return nil
+	}
+	var stmt *redoStatement
+	var err error
+	if row.operation == logMinerOpSelLobLocator {
+		stmt, err = parseLobLocatorRedo(row.sqlRedo)
+	} else {
+		stmt, err = parseRedoSQL(row.sqlRedo)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse redo of %s at SCN %d: %w", source, row.scn, err)
+	}
+	txn, ok := state.transactions[row.xid]
+	if !ok {
+		txn = &oracleTransaction{startScn: row.scn}
+		state.transactions[row.xid] = txn
+	}
+	txn.changes = append(txn.changes, redoChange{stmt: stmt, source: source})
+	return nil
+}
+
+func (c *OracleConnector) emitTransaction(
+	ctx context.Context,
+	otelManager *otel_metrics.OtelManager,
+	state *logMinerPullState,
+	txn *oracleTransaction,
+	commit logMinerRow,
+) error {
+	for _, change := range txn.changes {
+		record, err := c.buildRecord(ctx, state, change, commit)
+		if err != nil {
+			return err
+		}
+		if record == nil {
+			continue
+		}
+		if err := state.req.RecordStream.AddRecord(ctx, record); err != nil {
+			return err
+		}
+		state.recordCount++
+		if state.recordCount == 1 {
+			state.req.RecordStream.SignalAsNotEmpty()
+			state.batchDeadline = time.Now().Add(state.req.IdleTimeout)
+		}
+		if state.recordCount%pullProgressLogInterval == 0 {
+			c.logger.Info("[oracle] PullRecords streaming",
+				slog.Uint64("records", uint64(state.recordCount)),
+				slog.Int64("bytes", state.totalBytes.Load()))
+		}
+	}
+
+	// the commit is fully emitted, LogMiner restarts at its SCN, where other transactions
+	// may still commit, or at the oldest open transaction
+	state.updateCheckpoint(state.checkpoint.afterCommit(state.resumeScnAt(commit.scn), commit.scn, commit.xid))
+	otelManager.Metrics.LatestConsumedLogEventGauge.Record(ctx, commit.timestamp.Unix())
+	otelManager.Metrics.SourceLagGauge.Record(ctx, time.Since(commit.timestamp).Milliseconds())
+	return nil
+}
+
+// recordItems converts parsed redo values, refreshing the schema when redo
+// carries columns it does not know about yet
+func (c *OracleConnector) recordItems(
+	ctx context.Context,
+	state *logMinerPullState,
+	source string,
+	schema *redoTableSchema,
+	values map[string]redoValue,
+) (model.RecordItems, error) {
+	items := model.NewRecordItems(len(values))
+	for column, value := range values {
+		if _, excluded := schema.exclude[column]; excluded {
+			continue
+		}
+		if _, ignored := schema.ignored[column]; ignored {
+			continue
+		}
+		field, ok := schema.fields[column]
+		if !ok {
+			if err := c.refreshSchema(ctx, state, source, schema); err != nil {
+				return items, err
+			}
+			if field, ok = schema.fields[column]; !ok {
+				schema.ignored[column] = struct{}{}
+				c.logger.Warn("[oracle] ignoring redo column absent from source schema",
+					slog.String("table", source), slog.String("column", column))
+				continue
+			}
+		}
+		qv, err := qvalueFromRedoValue(field, value)
+		if err != nil {
+			return items, fmt.Errorf("failed to convert %s.%s: %w", source, column, err)
+		}
+		items.AddColumn(column, qv)
+	}
+	return items, nil
+}
+
+// missingColumns lists the replicated columns absent from items
+func missingColumns(schema *redoTableSchema, items model.RecordItems) map[string]struct{} {
+	missing := make(map[string]struct{})
+	for column := range schema.fields {
+		if _, excluded := schema.exclude[column]; excluded {
+			continue
+		}
+		if _, ok := items.ColToVal[column]; !ok {
+			missing[column] = struct{}{}
+		}
+	}
+	return missing
+}
+
+func (c *OracleConnector) buildRecord(
+	ctx context.Context,
+	state *logMinerPullState,
+	change redoChange,
+	commit logMinerRow,
+) (model.Record[model.RecordItems], error) {
+	schema := state.schemas[change.source]
+	destination := state.req.TableNameMapping[change.source].Name
+	baseRecord := model.BaseRecord{
+		CheckpointID:   int64(commit.scn),
+		CommitTimeNano: commit.timestamp.UnixNano(),
+	}
+	stmt := change.stmt
+
+	switch stmt.op {
+	case redoInsert:
+		items, err := c.recordItems(ctx, state, change.source, schema, stmt.newValues)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.fetchLobColumns(ctx, state, schema, items, commit.scn); err != nil {
+			return nil, err
+		}
+		return &model.InsertRecord[model.RecordItems]{
+			BaseRecord:           baseRecord,
+			Items:                items,
+			SourceTableName:      change.source,
+			DestinationTableName: destination,
+		}, nil
+	case redoUpdate, redoLobLocator:
+		oldItems, err := c.recordItems(ctx, state, change.source, schema, stmt.oldValues)
+		if err != nil {
+			return nil, err
+		}
+		// the new image is the logged before image with the SET clause applied
+		newValues := make(map[string]redoValue, len(stmt.oldValues)+len(stmt.newValues))
+		maps.Copy(newValues, stmt.oldValues)
+		maps.Copy(newValues, stmt.newValues)
+		newItems, err := c.recordItems(ctx, state, change.source, schema, newValues)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.fetchLobColumns(ctx, state, schema, newItems, commit.scn); err != nil {
+			return nil, err
+		}
+		return &model.UpdateRecord[model.RecordItems]{
+			BaseRecord:            baseRecord,
+			OldItems:              oldItems,
+			NewItems:              newItems,
+			SourceTableName:       change.source,
+			DestinationTableName:  destination,
+			UnchangedToastColumns: missingColumns(schema, newItems),
+		}, nil
+	case redoDelete:
+		items, err := c.recordItems(ctx, state, change.source, schema, stmt.oldValues)
+		if err != nil {
+			return nil, err
+		}
+		record := &model.DeleteRecord[model.RecordItems]{
+			BaseRecord:           baseRecord,
+			Items:                items,
+			SourceTableName:      change.source,
+			DestinationTableName: destination,
+		}
+		if len(missingColumns(schema, items)) > 0 {
+			// without supplemental logging of all columns only the key is known
+			record.UnchangedToastColumns = map[string]struct{}{notBackfilledDeleteSentinel: {}}
+		}
+		return record, nil
+	default:
+		return nil, fmt.Errorf("unexpected redo operation %d", stmt.op)
+	}
+}
+
+// fetchLobColumns fills in LOB columns, which SQL_REDO leaves out or shows as EMPTY_LOB(),
+// with a flashback read of the row as of the commit. Rows of tables without primary key,
+// or gone by then, keep the columns out of the record so destinations leave them untouched.
+func (c *OracleConnector) fetchLobColumns(
+	ctx context.Context,
+	state *logMinerPullState,
+	schema *redoTableSchema,
+	items model.RecordItems,
+	commitScn uint64,
+) error {
+	var lobColumns []oracleColumn
+	for _, column := range schema.lobColumns {
+		if _, excluded := schema.exclude[column.name]; !excluded {
+			lobColumns = append(lobColumns, column)
+		}
+	}
+	if len(lobColumns) == 0 || len(schema.pkColumns) == 0 {
+		return nil
+	}
+
+	selectExprs := make([]string, 0, len(lobColumns))
+	for _, column := range lobColumns {
+		selectExprs = append(selectExprs, selectExpression(column.name, column.dataType))
+	}
+	conditions := make([]string, 0, len(schema.pkColumns))
+	args := []any{int64(commitScn)}
+	for _, pkColumn := range schema.pkColumns {
+		qv := items.GetColumnValue(pkColumn)
+		if qv == nil {
+			return nil
+		}
+		args = append(args, lookupArg(qv))
+		conditions = append(conditions, fmt.Sprintf("%s = :%d", common.QuoteIdentifier(pkColumn), len(args)))
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s AS OF SCN :1 WHERE %s",
+		strings.Join(selectExprs, ", "), schema.table.String(), strings.Join(conditions, " AND "))
+
+	values := make([]any, len(lobColumns))
+	scanArgs := make([]any, len(lobColumns))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+	if err := state.lookupConn.QueryRowContext(ctx, query, args...).Scan(scanArgs...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			for _, column := range lobColumns {
+				delete(items.ColToVal, column.name)
+			}
+			return nil
+		}
+		return classifySnapshotReadError(fmt.Errorf("failed to read LOB columns of %s: %w", schema.table, err))
+	}
+	for i, column := range lobColumns {
+		qv, err := qvalueFromOracleValue(schema.fields[column.name], values[i])
+		if err != nil {
+			return fmt.Errorf("failed to convert %s.%s: %w", schema.table, column.name, err)
+		}
+		items.AddColumn(column.name, qv)
+		size := approximateValueSize(values[i])
+		state.deltaBytes.Add(size)
+		state.totalBytes.Add(size)
+	}
+	return nil
+}
+
+// lookupArg binds a key value for a flashback lookup, dates and timestamps are bound
+// as text in the session NLS formats so they compare without time zone conversion
+func lookupArg(qv types.QValue) any {
+	switch v := qv.(type) {
+	case types.QValueTimestamp:
+		return v.Val.Format(redoTimestampLayout)
+	case types.QValueTimestampTZ:
+		return v.Val.Format(redoTimestampTZLayout)
+	case types.QValueNumeric:
+		return v.Val.String()
+	default:
+		return qv.Value()
+	}
+}
+
+// refreshSchema re-reads the schema of a source table when redo carries unknown
+// columns, LogMiner does not announce DDL, and emits a delta for the added columns
+func (c *OracleConnector) refreshSchema(
+	ctx context.Context,
+	state *logMinerPullState,
+	source string,
+	schema *redoTableSchema,
+) error {
+	freshSchemas, err := c.GetTableSchema(ctx, state.req.Env, 0, protos.TypeSystem_Q,
+		[]*protos.TableMapping{{SourceTableIdentifier: source}})
+	if err != nil {
+		return fmt.Errorf("failed to refresh schema for %s: %w", source, err)
+	}
+	delta := &protos.TableSchemaDelta{
+		SrcTableName:    source,
+		DstTableName:    state.req.TableNameMapping[source].Name,
+		System:          protos.TypeSystem_Q,
+		NullableEnabled: schema.nullableEnabled,
+	}
+	for _, col := range freshSchemas[source].Columns {
+		if _, ok := schema.fields[col.Name]; ok {
+			continue
+		}
+		delta.AddedColumns = append(delta.AddedColumns, col)
+		schema.fields[col.Name] = fieldFromDescription(col)
+	}
+	if len(delta.AddedColumns) > 0 {
+		state.req.RecordStream.AddSchemaDelta(state.req.TableNameMapping, delta)
+		c.logger.Info("[oracle] detected added columns from redo",
+			slog.String("table", source), slog.Any("delta", delta))
+	}
+	return nil
+}
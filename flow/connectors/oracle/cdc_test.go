@@ -0,0 +1,105 @@
+package connoracle
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric/noop"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
+	"github.com/PeerDB-io/peerdb/flow/pkg/common"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestCommitsAtSameScn(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	c := &OracleConnector{logger: internal.LoggerFromCtx(ctx)}
+	otelManager := &otel_metrics.OtelManager{}
+	otelManager.Metrics.LatestConsumedLogEventGauge = noop.Int64Gauge{}
+	otelManager.Metrics.SourceLagGauge = noop.Int64Gauge{}
+
+	newState := func(checkpoint scnCheckpoint, maxBatchSize uint32) *logMinerPullState {
+		state := &logMinerPullState{
+			req: &model.PullRecordsRequest[model.RecordItems]{
+				RecordStream:     model.NewCDCStream[model.RecordItems](10),
+				TableNameMapping: map[string]model.NameAndExclude{"APP.T": {Name: "t"}},
+				MaxBatchSize:     maxBatchSize,
+			},
+			sourceByTable: map[[2]string]string{{"APP", "T"}: "APP.T"},
+			schemas: map[string]*redoTableSchema{"APP.T": {
+				table:  &common.QualifiedTable{Namespace: "APP", Table: "T"},
+				fields: map[string]types.QField{"ID": {Name: "ID", Type: types.QValueKindInt64}},
+			}},
+			transactions: make(map[string]*oracleTransaction),
+		}
+		state.updateCheckpoint(checkpoint)
+		return state
+	}
+	// two transactions committing at the same SCN
+	rows := []logMinerRow{
+		{scn: 100, xid: "A", owner: "APP", table: "T", operation: logMinerOpInsert,
+			sqlRedo: `insert into "APP"."T"("ID") values ('1');`},
+		{scn: 100, xid: "B", owner: "APP", table: "T", operation: logMinerOpInsert,
+			sqlRedo: `insert into "APP"."T"("ID") values ('2');`},
+		{scn: 100, xid: "A", operation: logMinerOpCommit, timestamp: time.Now()},
+		{scn: 100, xid: "B", operation: logMinerOpCommit, timestamp: time.Now()},
+	}
+	mine := func(state *logMinerPullState) []int64 {
+		for _, row := range rows {
+			if row.scn < state.checkpoint.resumeScn {
+				continue
+			}
+			err := c.processLogMinerRow(ctx, otelManager, state, row)
+			if errors.Is(err, errStopMining) {
+				break
+			}
+			require.NoError(t, err)
+		}
+		state.req.RecordStream.Close()
+		var ids []int64
+		for record := range state.req.RecordStream.GetRecords() {
+			ids = append(ids, record.GetItems().GetColumnValue("ID").Value().(int64))
+		}
+		return ids
+	}
+
+	state := newState(scnCheckpoint{resumeScn: 90, lastCommitScn: 90}, 10)
+	require.Equal(t, []int64{1, 2}, mine(state))
+	require.Equal(t, scnCheckpoint{resumeScn: 100, lastCommitScn: 100, committedXids: []string{"A", "B"}}, state.checkpoint)
+
+	// a batch filled by the first commit resumes at the same SCN and only skips that commit
+	state = newState(scnCheckpoint{resumeScn: 90, lastCommitScn: 90}, 1)
+	require.Equal(t, []int64{1}, mine(state))
+	checkpoint, err := parseScnCheckpoint(state.checkpoint.String())
+	require.NoError(t, err)
+	require.Equal(t, "100:100:A", checkpoint.String())
+	require.Equal(t, []int64{2}, mine(newState(checkpoint, 10)))
+
+	// commits at the snapshot SCN are covered by the snapshot
+	require.Empty(t, mine(newState(scnCheckpoint{resumeScn: 100, lastCommitScn: 100}, 10)))
+}
+
+func TestUnsupportedChanges(t *testing.T) {
+	t.Parallel()
+	ctx := t.Context()
+	c := &OracleConnector{logger: internal.LoggerFromCtx(ctx)}
+	newState := func(skipUnsupported bool) *logMinerPullState {
+		return &logMinerPullState{
+			req:             &model.PullRecordsRequest[model.RecordItems]{RecordStream: model.NewCDCStream[model.RecordItems](10)},
+			sourceByTable:   map[[2]string]string{{"APP", "T"}: "APP.T"},
+			transactions:    make(map[string]*oracleTransaction),
+			skipUnsupported: skipUnsupported,
+		}
+	}
+	replicated := logMinerRow{scn: 100, xid: "A", owner: "APP", table: "T", operation: logMinerOpUnsupported}
+	other := logMinerRow{scn: 100, xid: "A", owner: "APP", table: "OTHER", operation: logMinerOpUnsupported}
+
+	require.Error(t, c.processLogMinerRow(ctx, nil, newState(false), replicated))
+	require.NoError(t, c.processLogMinerRow(ctx, nil, newState(false), other))
+	require.NoError(t, c.processLogMinerRow(ctx, nil, newState(true), replicated))
+}
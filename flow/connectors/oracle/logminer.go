@@ -0,0 +1,259 @@
+package connoracle
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peerdb/flow/pkg/common"
+	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
+)
+
+// V$LOGMNR_CONTENTS.OPERATION_CODE values LogMiner is queried for
+const (
+	logMinerOpInsert         = 1
+	logMinerOpDelete         = 2
+	logMinerOpUpdate         = 3
+	logMinerOpCommit         = 7
+	logMinerOpSelLobLocator  = 9
+	logMinerOpRollback       = 36
+	logMinerOpUnsupported    = 255
+	logMinerMaxTablesPerList = 1000
+)
+
+// logMinerSessionSettings make SQL_REDO render literals in formats redoTimestampLayout
+// and strconv parse, and let flashback lookups bind those literals back unchanged
+var logMinerSessionSettings = []string{
+	"ALTER SESSION SET NLS_DATE_FORMAT = 'YYYY-MM-DD HH24:MI:SS'",
+	"ALTER SESSION SET NLS_TIMESTAMP_FORMAT = 'YYYY-MM-DD HH24:MI:SS.FF9'",
+	"ALTER SESSION SET NLS_TIMESTAMP_TZ_FORMAT = 'YYYY-MM-DD HH24:MI:SS.FF9 TZH:TZM'",
+	"ALTER SESSION SET NLS_NUMERIC_CHARACTERS = '.,'",
+	"ALTER SESSION SET TIME_ZONE = '00:00'",
+}
+
+// logMinerSession is a LogMiner session bound to one database session: the
+// registered log files and V$LOGMNR_CONTENTS are only visible to the session
+// that called DBMS_LOGMNR, so it holds a dedicated connection out of the pool
+type logMinerSession struct {
+	conn    *sql.Conn
+	logger  log.Logger
+	started bool
+}
+
+type logMinerRow struct {
+	timestamp time.Time
+	xid       string
+	owner     string
+	table     string
+	sqlRedo   string
+	scn       uint64
+	operation int64
+}
+
+func (c *OracleConnector) openSessionConn(ctx context.Context) (*sql.Conn, error) {
+	conn, err := c.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	for _, stmt := range logMinerSessionSettings {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to configure session (%s): %w", stmt, err)
+		}
+	}
+	return conn, nil
+}
+
+func (c *OracleConnector) openLogMinerSession(ctx context.Context) (*logMinerSession, error) {
+	conn, err := c.openSessionConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &logMinerSession{conn: conn, logger: c.logger}, nil
+}
+
+func (s *logMinerSession) end(ctx context.Context) error {
+	if !s.started {
+		return nil
+	}
+	s.started = false
+	if _, err := s.conn.ExecContext(ctx, "BEGIN DBMS_LOGMNR.END_LOGMNR; END;"); err != nil {
+		return fmt.Errorf("failed to end LogMiner session: %w", err)
+	}
+	return nil
+}
+
+func (s *logMinerSession) Close() {
+	closeCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := s.end(closeCtx); err != nil {
+		s.logger.Warn("[oracle] failed to end LogMiner session", slog.Any("error", err))
+	}
+	if err := s.conn.Close(); err != nil {
+		s.logger.Warn("[oracle] failed to close LogMiner connection", slog.Any("error", err))
+	}
+}
+
+type redoLogFile struct {
+	name        string
+	thread      int64
+	sequence    int64
+	firstChange uint64
+}
+
+// redoLogFiles lists the archived and online redo logs covering [startScn, endScn],
+// one file per thread and sequence as a log may be archived to several destinations
+// and stays online for a while after being archived
+func (s *logMinerSession) redoLogFiles(ctx context.Context, startScn uint64, endScn uint64) ([]redoLogFile, error) {
+	rows, err := s.conn.QueryContext(ctx, `SELECT NAME, THREAD#, SEQUENCE#, TO_CHAR(FIRST_CHANGE#) FROM (
+			SELECT NAME, THREAD#, SEQUENCE#, FIRST_CHANGE#, 0 AS SRC FROM V$ARCHIVED_LOG
+			WHERE NAME IS NOT NULL AND STATUS = 'A' AND STANDBY_DEST = 'NO'
+			AND NEXT_CHANGE# > :1 AND FIRST_CHANGE# <= :2
+			UNION ALL
+			SELECT MIN(f.MEMBER), l.THREAD#, l.SEQUENCE#, l.FIRST_CHANGE#, 1 AS SRC
+			FROM V$LOG l JOIN V$LOGFILE f ON f.GROUP# = l.GROUP#
+			WHERE l.STATUS IN ('CURRENT', 'ACTIVE', 'INACTIVE')
+			AND l.NEXT_CHANGE# > :3 AND l.FIRST_CHANGE# <= :4
+			GROUP BY l.THREAD#, l.SEQUENCE#, l.FIRST_CHANGE#
+		) ORDER BY THREAD#, SEQUENCE#, SRC`,
+		int64(startScn), int64(endScn), int64(startScn), int64(endScn))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list redo log files: %w", err)
+	}
+	defer rows.Close()
+
+	var files []redoLogFile
+	seen := make(map[[2]int64]struct{})
+	for rows.Next() {
+		var file redoLogFile
+		var firstChange string
+		if err := rows.Scan(&file.name, &file.thread, &file.sequence, &firstChange); err != nil {
+			return nil, fmt.Errorf("failed to scan redo log file: %w", err)
+		}
+		if file.firstChange, err = strconv.ParseUint(firstChange, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid FIRST_CHANGE# %q: %w", firstChange, err)
+		}
+		key := [2]int64{file.thread, file.sequence}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		files = append(files, file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read redo log files: %w", err)
+	}
+	return files, nil
+}
+
+// start (re)starts LogMiner over [startScn, endScn] with the redo logs covering it
+func (s *logMinerSession) start(ctx context.Context, startScn uint64, endScn uint64) error {
+	if err := s.end(ctx); err != nil {
+		return err
+	}
+	files, err := s.redoLogFiles(ctx, startScn, endScn)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 || files[0].firstChange > startScn {
+		return exceptions.NewOracleRedoLogMissingError(startScn)
+	}
+	for i, file := range files {
+		option := "DBMS_LOGMNR.ADDFILE"
+		if i == 0 {
+			option = "DBMS_LOGMNR.NEW"
+		}
+		if _, err := s.conn.ExecContext(ctx,
+			"BEGIN DBMS_LOGMNR.ADD_LOGFILE(LOGFILENAME => :1, OPTIONS => "+option+"); END;", file.name,
+		); err != nil {
+			return fmt.Errorf("failed to add redo log file %s: %w", file.name, err)
+		}
+	}
+	// the online catalog decodes redo with the current table definitions,
+	// NO_ROWID_IN_STMT keeps ROWID terms out of the reconstructed WHERE clauses
+	if _, err := s.conn.ExecContext(ctx, `BEGIN DBMS_LOGMNR.START_LOGMNR(STARTSCN => :1, ENDSCN => :2,
+		OPTIONS => DBMS_LOGMNR.DICT_FROM_ONLINE_CATALOG + DBMS_LOGMNR.NO_ROWID_IN_STMT); END;`,
+		int64(startScn), int64(endScn),
+	); err != nil {
+		return fmt.Errorf("failed to start LogMiner: %w", err)
+	}
+	s.started = true
+	return nil
+}
+
+// buildTableFilter restricts V$LOGMNR_CONTENTS to the mirrored tables,
+// Oracle caps IN lists at 1000 entries so larger mirrors get several
+func buildTableFilter(tables []*common.QualifiedTable) string {
+	var lists []string
+	for chunk := range slices.Chunk(tables, logMinerMaxTablesPerList) {
+		pairs := make([]string, 0, len(chunk))
+		for _, table := range chunk {
+			pairs = append(pairs, fmt.Sprintf("(%s, %s)", quoteLiteral(table.Namespace), quoteLiteral(table.Table)))
+		}
+		lists = append(lists, "(SEG_OWNER, TABLE_NAME) IN ("+strings.Join(pairs, ", ")+")")
+	}
+	return strings.Join(lists, " OR ")
+}
+
+// errStopMining ends the iteration of mine early without failing it
+var errStopMining = errors.New("stop mining")
+
+// mine reads the started LogMiner range, reassembling SQL_REDO split over continuation rows
+func (s *logMinerSession) mine(ctx context.Context, tableFilter string, fn func(logMinerRow) error) error {
+	query := fmt.Sprintf(`SELECT TO_CHAR(SCN), OPERATION_CODE, RAWTOHEX(XID), SEG_OWNER, TABLE_NAME, SQL_REDO, CSF, TIMESTAMP
+		FROM V$LOGMNR_CONTENTS
+		WHERE OPERATION_CODE IN (%d, %d) OR (OPERATION_CODE IN (%d, %d, %d, %d, %d) AND (%s))`,
+		logMinerOpCommit, logMinerOpRollback,
+		logMinerOpInsert, logMinerOpDelete, logMinerOpUpdate, logMinerOpSelLobLocator, logMinerOpUnsupported,
+		tableFilter)
+	rows, err := s.conn.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to query LogMiner contents: %w", err)
+	}
+	defer rows.Close()
+
+	var pending *logMinerRow
+	var redo strings.Builder
+	for rows.Next() {
+		var scnText string
+		var owner, table, sqlRedo sql.NullString
+		var csf int64
+		row := logMinerRow{}
+		if err := rows.Scan(&scnText, &row.operation, &row.xid, &owner, &table, &sqlRedo, &csf, &row.timestamp); err != nil {
+			return fmt.Errorf("failed to scan LogMiner contents: %w", err)
+		}
+		if pending == nil {
+			if row.scn, err = strconv.ParseUint(scnText, 10, 64); err != nil {
+				return fmt.Errorf("invalid SCN %q: %w", scnText, err)
+			}
+			row.owner, row.table = owner.String, table.String
+			pending = &row
+			redo.Reset()
+		}
+		redo.WriteString(sqlRedo.String)
+		if csf == 1 {
+			// SQL_REDO continues on the next row
+			continue
+		}
+		pending.sqlRedo = redo.String()
+		if err := fn(*pending); err != nil {
+			if errors.Is(err, errStopMining) {
+				return nil
+			}
+			return err
+		}
+		pending = nil
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read LogMiner contents: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package connoracle
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	go_ora "github.com/sijms/go-ora/v2"
+	"github.com/sijms/go-ora/v2/network"
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
+)
+
+type OracleConnector struct {
+	*metadataStore.PostgresMetadata
+	logger  log.Logger
+	ssh     *utils.SSHTunnel
+	db      *sql.DB
+	config  *protos.OracleConfig
+	version string
+}
+
+func NewOracleConnector(ctx context.Context, config *protos.OracleConfig) (*OracleConnector, error) {
+	logger := internal.LoggerFromCtx(ctx)
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tunnel, err := utils.NewSSHTunnel(ctx, config.SshConfig)
+	if err != nil {
+		logger.Error("failed to create ssh tunnel", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to create ssh tunnel: %w", err)
+	}
+
+	oraConnector, ok := go_ora.NewConnector(buildConnectionURL(config)).(*go_ora.OracleConnector)
+	if !ok {
+		tunnel.Close()
+		return nil, errors.New("unexpected Oracle driver connector type")
+	}
+	if tunnel.IsActive() {
+		oraConnector.Dialer(tunnel)
+	}
+	db := sql.OpenDB(oraConnector)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		tunnel.Close()
+		err = classifyConnectError(err)
+		logger.Error("failed to connect to Oracle", slog.Any("error", err))
+		return nil, fmt.Errorf("failed to connect to Oracle: %w", err)
+	}
+
+	connector := &OracleConnector{
+		PostgresMetadata: pgMetadata,
+		logger:           logger,
+		ssh:              tunnel,
+		db:               db,
+		config:           config,
+	}
+	tunnel.StartKeepalive(context.Background(), func() {
+		connector.logger.Info("SSH keepalive failed, closing connection")
+		if err := connector.db.Close(); err != nil {
+			connector.logger.Error("failed to close Oracle connection on SSH keepalive failure", slog.Any("error", err))
+		}
+	})
+	return connector, nil
+}
+
+func buildConnectionURL(config *protos.OracleConfig) string {
+	options := map[string]string{}
+	if config.RequireTls {
+		options["SSL"] = "true"
+		if config.SkipCertVerification {
+			options["SSL VERIFY"] = "false"
+		}
+	}
+	port := int(config.Port)
+	if port == 0 {
+		port = 1521
+	}
+	return go_ora.BuildUrl(config.Host, port, config.ServiceName, config.User, config.Password, options)
+}
+
+// classifyConnectError wraps invalid credentials and missing CREATE SESSION
+// in exceptions.AuthError, like the postgres connector does for its SQLSTATEs
+func classifyConnectError(err error) error {
+	if oraErr, ok := errors.AsType[*network.OracleError](err); ok {
+		switch oraErr.ErrCode {
+		case 1017, // invalid username/password
+			1045,  // user lacks CREATE SESSION privilege
+			28000: // account is locked
+			return exceptions.NewAuthError(err)
+		}
+	}
+	return err
+}
+
+func (c *OracleConnector) Close() error {
+	var errs []error
+	if c.db != nil {
+		if err := c.db.Close(); err != nil {
+			c.logger.Error("failed to close connection", slog.Any("error", err))
+			errs = append(errs, fmt.Errorf("failed to close connection: %w", err))
+		}
+	}
+	if err := c.ssh.Close(); err != nil {
+		c.logger.Error("failed to close SSH tunnel", slog.Any("error", err))
+		errs = append(errs, fmt.Errorf("failed to close SSH tunnel: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+func (c *OracleConnector) ConnectionActive(ctx context.Context) error {
+	if c.db == nil {
+		return errors.New("connection is nil")
+	}
+	return c.db.PingContext(ctx)
+}
+
+func (c *OracleConnector) GetVersion(ctx context.Context) (string, error) {
+	if c.version != "" {
+		return c.version, nil
+	}
+	var version string
+	if err := c.db.QueryRowContext(ctx,
+		"SELECT VERSION FROM PRODUCT_COMPONENT_VERSION WHERE PRODUCT LIKE 'Oracle%' AND ROWNUM = 1",
+	).Scan(&version); err != nil {
+		return "", fmt.Errorf("failed to get Oracle version: %w", err)
+	}
+	c.version = version
+	return version, nil
+}
+
+// currentScn returns the SCN of the most recent commit known to the database
+func (c *OracleConnector) currentScn(ctx context.Context, q queryer) (uint64, error) {
+	var scnText string
+	if err := q.QueryRowContext(ctx, "SELECT TO_CHAR(CURRENT_SCN) FROM V$DATABASE").Scan(&scnText); err != nil {
+		return 0, fmt.Errorf("failed to query current SCN: %w", err)
+	}
+	scn, err := strconv.ParseUint(scnText, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid SCN %q: %w", scnText, err)
+	}
+	return scn, nil
+}
+
+// queryer is satisfied by both *sql.DB and the dedicated *sql.Conn of a LogMiner session
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
@@ -0,0 +1,443 @@
+package connoracle
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sijms/go-ora/v2/network"
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
+	"github.com/PeerDB-io/peerdb/flow/pkg/common"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// pullProgressLogInterval throttles pull progress logging to one line every this many records
+const pullProgressLogInterval = 50_000
+
+// timestamp partition bounds are bound as text, DATE and TIMESTAMP columns compare against them
+// without any time zone conversion
+const partitionTimestampFormat = "YYYY-MM-DD HH24:MI:SS.FF9"
+
+// classifySnapshotReadError marks flashback reads at an SCN whose undo is gone as
+// non-retryable: the snapshot can never be read again and the mirror needs a resync
+func classifySnapshotReadError(err error) error {
+	if oraErr, ok := errors.AsType[*network.OracleError](err); ok {
+		switch oraErr.ErrCode {
+		case 1555, // snapshot too old
+			8180, // no snapshot found based on specified time
+			8181: // specified number is not a valid system change number
+			return temporal.NewNonRetryableApplicationError(
+				"flashback snapshot SCN is no longer readable, increase UNDO_RETENTION and resync the mirror",
+				exceptions.ApplicationErrorTypeIrrecoverableInvalidSnapshot.String(), err)
+		}
+	}
+	return err
+}
+
+// asOfClause pins QRep reads to the SCN captured by SetupReplication or ExportTxSnapshot,
+// reads without a snapshot (standalone QRep mirrors) see the latest committed data
+func asOfClause(config *protos.QRepConfig) (string, error) {
+	if config.SnapshotName == "" {
+		return "", nil
+	}
+	// parse rather than splice the text so the clause stays injection safe
+	scn, err := strconv.ParseUint(config.SnapshotName, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid Oracle snapshot SCN %q", config.SnapshotName)
+	}
+	return fmt.Sprintf(" AS OF SCN %d", scn), nil
+}
+
+func supportsRangePartition(qkind types.QValueKind) bool {
+	return qkind == types.QValueKindInt64 || qkind == types.QValueKindTimestamp
+}
+
+func (c *OracleConnector) GetQRepPartitions(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	last *protos.QRepPartition,
+) ([]*protos.QRepPartition, error) {
+	if config.WatermarkColumn == "" || config.NumPartitionsOverride == 1 {
+		// if no watermark column is specified, return a single partition
+		return utils.FullTablePartition(), nil
+	}
+
+	if config.NumPartitionsOverride == 0 && config.NumRowsPerPartition == 0 {
+		return nil, errors.New("num rows per partition must be greater than 0")
+	}
+
+	parsedWatermarkTable, err := common.ParseTableIdentifier(config.WatermarkTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse watermark table %s: %w", config.WatermarkTable, err)
+	}
+	columns, err := c.getColumns(ctx, parsedWatermarkTable)
+	if err != nil {
+		return nil, err
+	}
+	columnIdx := slices.IndexFunc(columns, func(column oracleColumn) bool { return column.name == config.WatermarkColumn })
+	if columnIdx == -1 {
+		return nil, fmt.Errorf("watermark column %s not found in %s", config.WatermarkColumn, config.WatermarkTable)
+	}
+	watermarkKind := columns[columnIdx].qkind
+	if !supportsRangePartition(watermarkKind) {
+		c.logger.Info("[oracle] watermark column type does not support range partitioning, falling back to full table partition",
+			slog.String("column", config.WatermarkColumn), slog.String("type", columns[columnIdx].dataType))
+		return utils.FullTablePartition(), nil
+	}
+
+	asOf, err := asOfClause(config)
+	if err != nil {
+		return nil, err
+	}
+	quotedColumn := common.QuoteIdentifier(config.WatermarkColumn)
+
+	var lastRangeEnd any
+	resuming := last != nil && last.Range != nil
+	var whereClause string
+	var whereArgs []any
+	if resuming {
+		switch lastRange := last.Range.Range.(type) {
+		case *protos.PartitionRange_IntRange:
+			lastRangeEnd = lastRange.IntRange.End
+			whereClause = fmt.Sprintf(" WHERE %s > :1", quotedColumn)
+			whereArgs = []any{lastRangeEnd}
+		case *protos.PartitionRange_TimestampRange:
+			lastRangeEnd = lastRange.TimestampRange.End.AsTime()
+			whereClause = fmt.Sprintf(" WHERE %s > TO_TIMESTAMP(:1, '%s')", quotedColumn, partitionTimestampFormat)
+			whereArgs = []any{formatPartitionTimestamp(lastRange.TimestampRange.End.AsTime())}
+		default:
+			return nil, fmt.Errorf("unsupported range type %T in last partition after resuming QRep", lastRange)
+		}
+	}
+
+	numPartitions := int64(config.NumPartitionsOverride)
+	if numPartitions == 0 {
+		var totalRows int64
+		if !resuming {
+			var estimate sql.NullInt64
+			if err := c.db.QueryRowContext(ctx, "SELECT NUM_ROWS FROM ALL_TABLES WHERE OWNER = :1 AND TABLE_NAME = :2",
+				parsedWatermarkTable.Namespace, parsedWatermarkTable.Table).Scan(&estimate); err != nil {
+				c.logger.Warn("[oracle] failed to read row count statistics", slog.Any("error", err))
+			}
+			totalRows = estimate.Int64
+		}
+		if totalRows <= 0 {
+			// no statistics gathered or resuming past lastRangeEnd, count precisely
+			countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s%s%s", parsedWatermarkTable.String(), asOf, whereClause)
+			if err := c.db.QueryRowContext(ctx, countQuery, whereArgs...).Scan(&totalRows); err != nil {
+				return nil, classifySnapshotReadError(fmt.Errorf("failed to query for total rows: %w", err))
+			}
+		}
+
+		if totalRows == 0 {
+			c.logger.Warn("no records to replicate, only using 1 partition")
+			numPartitions = 1
+		} else {
+			adjustedPartitions := shared.AdjustNumPartitions(totalRows, int64(config.NumRowsPerPartition))
+			c.logger.Info("[oracle] partition details",
+				slog.Int64("totalRows", totalRows),
+				slog.Int64("desiredNumRowsPerPartition", int64(config.NumRowsPerPartition)),
+				slog.Int64("adjustedNumPartitions", adjustedPartitions.AdjustedNumPartitions),
+				slog.Int64("adjustedNumRowsPerPartition", adjustedPartitions.AdjustedNumRowsPerPartition))
+			numPartitions = adjustedPartitions.AdjustedNumPartitions
+		}
+	}
+
+	minmaxQuery := fmt.Sprintf("SELECT MIN(%[1]s), MAX(%[1]s) FROM %s%s%s",
+		quotedColumn, parsedWatermarkTable.String(), asOf, whereClause)
+	c.logger.Info("querying min/max", slog.String("query", minmaxQuery))
+	var minRaw, maxRaw any
+	if err := c.db.QueryRowContext(ctx, minmaxQuery, whereArgs...).Scan(&minRaw, &maxRaw); err != nil {
+		return nil, classifySnapshotReadError(fmt.Errorf("failed to query for min/max: %w", err))
+	}
+	field := types.QField{Name: config.WatermarkColumn, Type: watermarkKind, Nullable: true}
+	minVal, err := partitionBound(field, minRaw)
+	if err != nil {
+		return nil, err
+	}
+	maxVal, err := partitionBound(field, maxRaw)
+	if err != nil {
+		return nil, err
+	}
+
+	partitionHelper := utils.NewPartitionHelper(c.logger)
+	if err := partitionHelper.AddPartitionsWithRange(minVal, maxVal, numPartitions); err != nil {
+		return nil, fmt.Errorf("failed to add partitions: %w", err)
+	}
+
+	// add null values partition to the end, if nulls aren't present it will be an empty partition
+	// that gets skipped during replication
+	if config.AddNullPartition {
+		partitionHelper.AddNullPartition()
+	}
+
+	return partitionHelper.GetPartitions(), nil
+}
+
+// partitionBound converts a scanned MIN/MAX into the value the partition helper expects
+func partitionBound(field types.QField, raw any) (any, error) {
+	qv, err := qvalueFromOracleValue(field, raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert partition bound: %w", err)
+	}
+	switch v := qv.(type) {
+	case types.QValueInt64:
+		return v.Val, nil
+	case types.QValueTimestamp:
+		return v.Val, nil
+	default:
+		return nil, nil
+	}
+}
+
+func formatPartitionTimestamp(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05.000000000")
+}
+
+func (c *OracleConnector) GetDefaultPartitionKeyForTables(
+	ctx context.Context,
+	input *protos.GetDefaultPartitionKeyForTablesInput,
+) (*protos.GetDefaultPartitionKeyForTablesOutput, error) {
+	c.logger.Info("Evaluating if tables can perform parallel load")
+
+	output := &protos.GetDefaultPartitionKeyForTablesOutput{
+		TableDefaultPartitionKeyMapping: make(map[string]string, len(input.TableMappings)),
+	}
+	for _, tm := range input.TableMappings {
+		source := tm.SourceTableIdentifier
+		schema, ok := input.TableSchemaMapping[source]
+		if !ok || len(schema.PrimaryKeyColumns) == 0 {
+			c.logger.Info("[oracle] table has no known primary key, defaulting to full table snapshot",
+				slog.String("table", source))
+			continue
+		}
+		pkColumn := schema.PrimaryKeyColumns[0]
+		var pkQKind types.QValueKind
+		for _, col := range schema.Columns {
+			if col.Name == pkColumn {
+				pkQKind = types.QValueKind(col.Type)
+				break
+			}
+		}
+		if !supportsRangePartition(pkQKind) {
+			c.logger.Info("[oracle] primary key type does not support range partitioning, defaulting to full table snapshot",
+				slog.String("table", source),
+				slog.String("column", pkColumn),
+				slog.String("qkind", string(pkQKind)))
+			continue
+		}
+		output.TableDefaultPartitionKeyMapping[source] = pkColumn
+	}
+	return output, nil
+}
+
+func (c *OracleConnector) PullQRepRecords(
+	ctx context.Context,
+	_ shared.CatalogPool,
+	_ *otel_metrics.OtelManager,
+	config *protos.QRepConfig,
+	dstType protos.DBType,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, int64, error) {
+	partitionIdLog := slog.String(string(shared.PartitionIDKey), partition.PartitionId)
+
+	parsedSrcTable, err := common.ParseTableIdentifier(config.WatermarkTable)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to parse source table: %w", err)
+	}
+	columns, err := c.getColumns(ctx, parsedSrcTable)
+	if err != nil {
+		return 0, 0, err
+	}
+	columns = slices.DeleteFunc(columns, func(column oracleColumn) bool {
+		return slices.Contains(config.Exclude, column.name)
+	})
+	if len(columns) == 0 {
+		return 0, 0, fmt.Errorf("no columns selected for watermark table %s (check Exclude configuration)", config.WatermarkTable)
+	}
+
+	query, queryArgs, err := buildPullQuery(config, partition, columns, parsedSrcTable)
+	if err != nil {
+		return 0, 0, err
+	}
+	c.logger.Info("[oracle] pulling records start", partitionIdLog, slog.String("query", query))
+
+	rows, err := c.db.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return 0, 0, classifySnapshotReadError(fmt.Errorf("failed to execute query: %w", err))
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get column types: %w", err)
+	}
+	schema := qRecordSchemaFromColumnTypes(columns, columnTypes)
+	stream.SetSchema(schema)
+
+	values := make([]any, len(columnTypes))
+	scanArgs := make([]any, len(columnTypes))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+
+	var totalRecords, totalBytes int64
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return totalRecords, totalBytes, fmt.Errorf("failed to scan row: %w", err)
+		}
+		record := make([]types.QValue, len(values))
+		for i, value := range values {
+			qv, err := qvalueFromOracleValue(schema.Fields[i], value)
+			if err != nil {
+				return totalRecords, totalBytes, fmt.Errorf("failed to convert value for %s: %w", schema.Fields[i].Name, err)
+			}
+			record[i] = qv
+			totalBytes += approximateValueSize(value)
+		}
+		if err := stream.Send(ctx, record); err != nil {
+			return totalRecords, totalBytes, fmt.Errorf("failed to send record to stream: %w", err)
+		}
+
+		totalRecords++
+		if totalRecords%pullProgressLogInterval == 0 {
+			c.logger.Info("[oracle] pulling records",
+				partitionIdLog,
+				slog.Int64("records", totalRecords),
+				slog.Int64("bytes", totalBytes),
+				slog.Int("channelLen", len(stream.Records)))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return totalRecords, totalBytes, classifySnapshotReadError(fmt.Errorf("row iteration failed: %w", err))
+	}
+
+	c.logger.Info("[oracle] pulled records",
+		partitionIdLog,
+		slog.Int64("records", totalRecords),
+		slog.Int64("bytes", totalBytes),
+		slog.Int("channelLen", len(stream.Records)))
+	return totalRecords, totalBytes, nil
+}
+
+func buildPullQuery(
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	columns []oracleColumn,
+	srcTable *common.QualifiedTable,
+) (string, []any, error) {
+	asOf, err := asOfClause(config)
+	if err != nil {
+		return "", nil, err
+	}
+	selectExprs := make([]string, 0, len(columns))
+	for _, column := range columns {
+		selectExprs = append(selectExprs, selectExpression(column.name, column.dataType))
+	}
+	baseQuery := fmt.Sprintf("SELECT %s FROM %s%s", strings.Join(selectExprs, ", "), srcTable.String(), asOf)
+
+	if partition.FullTablePartition {
+		if config.Query != "" {
+			return config.Query, nil, nil
+		}
+		return baseQuery, nil, nil
+	}
+
+	quotedColumn := common.QuoteIdentifier(config.WatermarkColumn)
+	queryTemplate := config.Query
+	if queryTemplate == "" {
+		queryTemplate = fmt.Sprintf("%s WHERE %s BETWEEN {{.start}} AND {{.end}}", baseQuery, quotedColumn)
+	}
+	templateParams := map[string]string{"start": ":1", "end": ":2"}
+
+	var queryArgs []any
+	switch x := partition.Range.Range.(type) {
+	case *protos.PartitionRange_IntRange:
+		queryArgs = []any{x.IntRange.Start, x.IntRange.End}
+	case *protos.PartitionRange_TimestampRange:
+		templateParams = map[string]string{
+			"start": fmt.Sprintf("TO_TIMESTAMP(:1, '%s')", partitionTimestampFormat),
+			"end":   fmt.Sprintf("TO_TIMESTAMP(:2, '%s')", partitionTimestampFormat),
+		}
+		queryArgs = []any{
+			formatPartitionTimestamp(x.TimestampRange.Start.AsTime()),
+			formatPartitionTimestamp(x.TimestampRange.End.AsTime()),
+		}
+	case *protos.PartitionRange_NullRange:
+		if config.Query != "" {
+			return "", nil, errors.New("can't construct a null range partition for custom queries")
+		}
+		queryTemplate = fmt.Sprintf("%s WHERE %s IS NULL", baseQuery, quotedColumn)
+		templateParams = map[string]string{}
+	default:
+		return "", nil, fmt.Errorf("unknown range type: %v", x)
+	}
+
+	query, err := utils.ExecuteTemplate(queryTemplate, templateParams)
+	if err != nil {
+		return "", nil, err
+	}
+	return query, queryArgs, nil
+}
+
+// qRecordSchemaFromColumnTypes types the result columns by the table's columns,
+// falling back to the driver's type information for expressions of custom queries
+func qRecordSchemaFromColumnTypes(columns []oracleColumn, columnTypes []*sql.ColumnType) types.QRecordSchema {
+	fields := make([]types.QField, 0, len(columnTypes))
+	for _, columnType := range columnTypes {
+		name := columnType.Name()
+		var field types.QField
+		if idx := slices.IndexFunc(columns, func(column oracleColumn) bool { return column.name == name }); idx != -1 {
+			column := columns[idx]
+			field = columnQField(column)
+		} else {
+			precision, scale, ok := columnType.DecimalSize()
+			qkind := oracleTypeToQValueKind(columnType.DatabaseTypeName(),
+				sql.NullInt64{Int64: precision, Valid: ok && precision > 0}, sql.NullInt64{Int64: scale, Valid: ok})
+			if qkind == types.QValueKindInvalid {
+				qkind = types.QValueKindString
+			}
+			field = types.QField{Name: name, Type: qkind, Nullable: true}
+		}
+		fields = append(fields, field)
+	}
+	return types.NewQRecordSchema(fields)
+}
+
+func columnQField(column oracleColumn) types.QField {
+	precision, scale := common.ParseNumericTypmod(oracleTypeModifier(column.qkind, column.precision, column.scale))
+	return types.QField{
+		Name:      column.name,
+		Type:      column.qkind,
+		Nullable:  column.nullable,
+		Precision: precision,
+		Scale:     scale,
+	}
+}
+
+func approximateValueSize(value any) int64 {
+	switch v := value.(type) {
+	case nil:
+		return 0
+	case string:
+		return int64(len(v))
+	case []byte:
+		return int64(len(v))
+	default:
+		return 8
+	}
+}
@@ -0,0 +1,510 @@
+package connoracle
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+type redoOperation uint8
+
+const (
+	redoInsert redoOperation = iota
+	redoUpdate
+	redoDelete
+	// redoLobLocator is the SELECT ... FOR UPDATE LogMiner reconstructs for
+	// LOB writes, which change a row without any DML row of their own
+	redoLobLocator
+)
+
+type redoValueKind uint8
+
+const (
+	redoValueLiteral redoValueKind = iota
+	redoValueNull
+	// hex encoded RAW, from HEXTORAW('...')
+	redoValueHex
+	// EMPTY_CLOB() and EMPTY_BLOB(), the LOB content follows in separate LOB operations
+	redoValueEmptyLob
+)
+
+type redoValue struct {
+	text string
+	kind redoValueKind
+}
+
+// redoStatement is a parsed SQL_REDO row of V$LOGMNR_CONTENTS
+type redoStatement struct {
+	// values of an insert, or the SET clause of an update
+	newValues map[string]redoValue
+	// WHERE clause of an update or delete, the before image LogMiner
+	// reconstructs from the supplementally logged columns
+	oldValues map[string]redoValue
+	schema    string
+	table     string
+	// column selected by a LOB locator statement
+	lobColumn string
+	op        redoOperation
+}
+
+type redoTokenKind uint8
+
+const (
+	redoTokenEOF redoTokenKind = iota
+	redoTokenIdent
+	redoTokenQuotedIdent
+	redoTokenString
+	redoTokenNumber
+	redoTokenPunct
+)
+
+type redoToken struct {
+	text string
+	kind redoTokenKind
+}
+
+func (t redoToken) isKeyword(keyword string) bool {
+	return t.kind == redoTokenIdent && strings.EqualFold(t.text, keyword)
+}
+
+func (t redoToken) isPunct(punct string) bool {
+	return t.kind == redoTokenPunct && t.text == punct
+}
+
+func tokenizeRedo(sql string) ([]redoToken, error) {
+	var tokens []redoToken
+	for i := 0; i < len(sql); {
+		ch := sql[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '"' || ch == '\'':
+			// both quoted identifiers and string literals escape their quote by doubling it
+			var sb strings.Builder
+			j := i + 1
+			for {
+				if j >= len(sql) {
+					return nil, fmt.Errorf("unterminated %c at offset %d", ch, i)
+				}
+				if sql[j] == ch {
+					if j+1 < len(sql) && sql[j+1] == ch {
+						sb.WriteByte(ch)
+						j += 2
+						continue
+					}
+					break
+				}
+				sb.WriteByte(sql[j])
+				j++
+			}
+			kind := redoTokenString
+			if ch == '"' {
+				kind = redoTokenQuotedIdent
+			}
+			tokens = append(tokens, redoToken{kind: kind, text: sb.String()})
+			i = j + 1
+		case ch == '|' && i+1 < len(sql) && sql[i+1] == '|':
+			tokens = append(tokens, redoToken{kind: redoTokenPunct, text: "||"})
+			i += 2
+		case strings.IndexByte("(),=;.", ch) >= 0:
+			tokens = append(tokens, redoToken{kind: redoTokenPunct, text: string(ch)})
+			i++
+		case ch == '-' || ch == '+' || ch == '.' || (ch >= '0' && ch <= '9'):
+			j := i + 1
+			for j < len(sql) && (strings.IndexByte("0123456789.eE", sql[j]) >= 0 ||
+				((sql[j] == '-' || sql[j] == '+') && (sql[j-1] == 'e' || sql[j-1] == 'E'))) {
+				j++
+			}
+			tokens = append(tokens, redoToken{kind: redoTokenNumber, text: sql[i:j]})
+			i = j
+		case ch == '_' || ch == '$' || ch == '#' || (ch|0x20 >= 'a' && ch|0x20 <= 'z'):
+			j := i + 1
+			for j < len(sql) && (sql[j] == '_' || sql[j] == '$' || sql[j] == '#' ||
+				(sql[j]|0x20 >= 'a' && sql[j]|0x20 <= 'z') || (sql[j] >= '0' && sql[j] <= '9')) {
+				j++
+			}
+			tokens = append(tokens, redoToken{kind: redoTokenIdent, text: sql[i:j]})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q at offset %d", ch, i)
+		}
+	}
+	return tokens, nil
+}
+
+type redoParser struct {
+	tokens []redoToken
+	pos    int
+}
+
+func (p *redoParser) peek() redoToken {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return redoToken{kind: redoTokenEOF}
+}
+
+func (p *redoParser) next() redoToken {
+	token := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return token
+}
+
+func (p *redoParser) expectKeyword(keyword string) error {
+	if token := p.next(); !token.isKeyword(keyword) {
+		return fmt.Errorf("expected %s, got %q", keyword, token.text)
+	}
+	return nil
+}
+
+func (p *redoParser) expectPunct(punct string) error {
+	if token := p.next(); !token.isPunct(punct) {
+		return fmt.Errorf("expected %s, got %q", punct, token.text)
+	}
+	return nil
+}
+
+func (p *redoParser) identifier() (string, error) {
+	token := p.next()
+	if token.kind != redoTokenQuotedIdent && token.kind != redoTokenIdent {
+		return "", fmt.Errorf("expected identifier, got %q", token.text)
+	}
+	return token.text, nil
+}
+
+func (p *redoParser) tableName(stmt *redoStatement) error {
+	first, err := p.identifier()
+	if err != nil {
+		return err
+	}
+	if !p.peek().isPunct(".") {
+		stmt.table = first
+		return nil
+	}
+	p.next()
+	second, err := p.identifier()
+	if err != nil {
+		return err
+	}
+	stmt.schema, stmt.table = first, second
+	return nil
+}
+
+func (p *redoParser) value() (redoValue, error) {
+	v, err := p.term()
+	if err != nil {
+		return redoValue{}, err
+	}
+	// long strings may be split into concatenated literals
+	for p.peek().isPunct("||") {
+		p.next()
+		rhs, err := p.term()
+		if err != nil {
+			return redoValue{}, err
+		}
+		if v.kind != redoValueLiteral || rhs.kind != redoValueLiteral {
+			return redoValue{}, errors.New("unsupported concatenation of non literal values")
+		}
+		v.text += rhs.text
+	}
+	return v, nil
+}
+
+func (p *redoParser) term() (redoValue, error) {
+	token := p.next()
+	switch token.kind {
+	case redoTokenString, redoTokenNumber:
+		return redoValue{kind: redoValueLiteral, text: token.text}, nil
+	case redoTokenIdent:
+		if token.isKeyword("NULL") {
+			return redoValue{kind: redoValueNull}, nil
+		}
+		if !p.peek().isPunct("(") {
+			return redoValue{}, fmt.Errorf("unsupported expression %q", token.text)
+		}
+		p.next()
+		var args []redoValue
+		for !p.peek().isPunct(")") {
+			arg, err := p.value()
+			if err != nil {
+				return redoValue{}, err
+			}
+			args = append(args, arg)
+			if p.peek().isPunct(",") {
+				p.next()
+			}
+		}
+		p.next()
+		return applyRedoFunction(strings.ToUpper(token.text), args)
+	default:
+		return redoValue{}, fmt.Errorf("unexpected %q where a value was expected", token.text)
+	}
+}
+
+func applyRedoFunction(name string, args []redoValue) (redoValue, error) {
+	switch name {
+	case "EMPTY_CLOB", "EMPTY_BLOB":
+		return redoValue{kind: redoValueEmptyLob}, nil
+	}
+	if len(args) == 0 {
+		return redoValue{}, fmt.Errorf("%s without arguments", name)
+	}
+	arg := args[0]
+	if arg.kind == redoValueNull {
+		return arg, nil
+	}
+	switch name {
+	// the NLS formats of the LogMiner session make the literal itself parseable,
+	// the format argument is redundant
+	case "TO_DATE", "TO_TIMESTAMP", "TO_TIMESTAMP_TZ", "TO_YMINTERVAL", "TO_DSINTERVAL",
+		"TO_BINARY_FLOAT", "TO_BINARY_DOUBLE", "TO_NUMBER", "TO_CHAR", "TO_NCHAR":
+		return arg, nil
+	case "HEXTORAW":
+		return redoValue{kind: redoValueHex, text: arg.text}, nil
+	case "UNISTR":
+		decoded, err := decodeUnistr(arg.text)
+		if err != nil {
+			return redoValue{}, err
+		}
+		return redoValue{kind: redoValueLiteral, text: decoded}, nil
+	default:
+		return redoValue{}, fmt.Errorf("unsupported function %s", name)
+	}
+}
+
+// decodeUnistr decodes the argument of UNISTR, where \XXXX is a UTF-16 code unit and \\ a backslash
+func decodeUnistr(s string) (string, error) {
+	var units []uint16
+	var sb strings.Builder
+	flush := func() {
+		if len(units) > 0 {
+			sb.WriteString(string(utf16.Decode(units)))
+			units = units[:0]
+		}
+	}
+	for i := 0; i < len(s); {
+		if s[i] != '\\' {
+			flush()
+			sb.WriteByte(s[i])
+			i++
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '\\' {
+			flush()
+			sb.WriteByte('\\')
+			i += 2
+			continue
+		}
+		if i+5 > len(s) {
+			return "", fmt.Errorf("invalid UNISTR escape in %q", s)
+		}
+		unit, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+		if err != nil {
+			return "", fmt.Errorf("invalid UNISTR escape in %q: %w", s, err)
+		}
+		units = append(units, uint16(unit))
+		i += 5
+	}
+	flush()
+	return sb.String(), nil
+}
+
+// conditions parses `"A" = 'x' and "B" IS NULL ...`, skipping ROWID terms
+func (p *redoParser) conditions() (map[string]redoValue, error) {
+	values := make(map[string]redoValue)
+	for {
+		column := p.next()
+		if column.kind != redoTokenQuotedIdent && column.kind != redoTokenIdent {
+			return nil, fmt.Errorf("expected column in condition, got %q", column.text)
+		}
+		var value redoValue
+		if p.peek().isKeyword("IS") {
+			p.next()
+			if err := p.expectKeyword("NULL"); err != nil {
+				return nil, err
+			}
+			value = redoValue{kind: redoValueNull}
+		} else {
+			if err := p.expectPunct("="); err != nil {
+				return nil, err
+			}
+			var err error
+			if value, err = p.value(); err != nil {
+				return nil, err
+			}
+		}
+		if column.kind == redoTokenQuotedIdent || !column.isKeyword("ROWID") {
+			values[column.text] = value
+		}
+		if !p.peek().isKeyword("AND") {
+			return values, nil
+		}
+		p.next()
+	}
+}
+
+func (p *redoParser) end() error {
+	if p.peek().isPunct(";") {
+		p.next()
+	}
+	if token := p.peek(); token.kind != redoTokenEOF {
+		return fmt.Errorf("unexpected trailing %q", token.text)
+	}
+	return nil
+}
+
+func (p *redoParser) insert(stmt *redoStatement) error {
+	if err := p.expectKeyword("INTO"); err != nil {
+		return err
+	}
+	if err := p.tableName(stmt); err != nil {
+		return err
+	}
+	if err := p.expectPunct("("); err != nil {
+		return err
+	}
+	var columns []string
+	for {
+		column, err := p.identifier()
+		if err != nil {
+			return err
+		}
+		columns = append(columns, column)
+		if p.peek().isPunct(")") {
+			p.next()
+			break
+		}
+		if err := p.expectPunct(","); err != nil {
+			return err
+		}
+	}
+	if err := p.expectKeyword("VALUES"); err != nil {
+		return err
+	}
+	if err := p.expectPunct("("); err != nil {
+		return err
+	}
+	stmt.newValues = make(map[string]redoValue, len(columns))
+	for i, column := range columns {
+		value, err := p.value()
+		if err != nil {
+			return fmt.Errorf("column %s: %w", column, err)
+		}
+		stmt.newValues[column] = value
+		if i < len(columns)-1 {
+			if err := p.expectPunct(","); err != nil {
+				return err
+			}
+		}
+	}
+	return p.expectPunct(")")
+}
+
+func (p *redoParser) update(stmt *redoStatement) error {
+	if err := p.tableName(stmt); err != nil {
+		return err
+	}
+	if err := p.expectKeyword("SET"); err != nil {
+		return err
+	}
+	stmt.newValues = make(map[string]redoValue)
+	for {
+		column, err := p.identifier()
+		if err != nil {
+			return err
+		}
+		if err := p.expectPunct("="); err != nil {
+			return err
+		}
+		value, err := p.value()
+		if err != nil {
+			return fmt.Errorf("column %s: %w", column, err)
+		}
+		stmt.newValues[column] = value
+		if !p.peek().isPunct(",") {
+			break
+		}
+		p.next()
+	}
+	if p.peek().isKeyword("WHERE") {
+		p.next()
+		oldValues, err := p.conditions()
+		if err != nil {
+			return err
+		}
+		stmt.oldValues = oldValues
+	}
+	return nil
+}
+
+func (p *redoParser) delete(stmt *redoStatement) error {
+	if err := p.expectKeyword("FROM"); err != nil {
+		return err
+	}
+	if err := p.tableName(stmt); err != nil {
+		return err
+	}
+	if p.peek().isKeyword("WHERE") {
+		p.next()
+		oldValues, err := p.conditions()
+		if err != nil {
+			return err
+		}
+		stmt.oldValues = oldValues
+	}
+	return nil
+}
+
+// parseRedoSQL parses the SQL_REDO LogMiner generates for inserts, updates and deletes
+func parseRedoSQL(sql string) (*redoStatement, error) {
+	tokens, err := tokenizeRedo(sql)
+	if err != nil {
+		return nil, fmt.Errorf("failed to tokenize redo SQL: %w", err)
+	}
+	p := &redoParser{tokens: tokens}
+	stmt := &redoStatement{}
+	keyword := p.next()
+	switch {
+	case keyword.isKeyword("INSERT"):
+		stmt.op = redoInsert
+		err = p.insert(stmt)
+	case keyword.isKeyword("UPDATE"):
+		stmt.op = redoUpdate
+		err = p.update(stmt)
+	case keyword.isKeyword("DELETE"):
+		stmt.op = redoDelete
+		err = p.delete(stmt)
+	default:
+		return nil, fmt.Errorf("unsupported redo statement starting with %q", keyword.text)
+	}
+	if err == nil {
+		err = p.end()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse redo SQL: %w", err)
+	}
+	return stmt, nil
+}
+
+var lobLocatorRe = regexp.MustCompile(`(?is)\bselect\s+"((?:[^"]|"")+)"\s+into\s+\S+\s+from\s+(.+?)\s+for\s+update\s*;`)
+
+// parseLobLocatorRedo extracts the row a LOB write targets from the PL/SQL block LogMiner
+// generates for SEL_LOB_LOCATOR operations, its WHERE clause identifies the row like a delete's
+func parseLobLocatorRedo(sql string) (*redoStatement, error) {
+	match := lobLocatorRe.FindStringSubmatch(sql)
+	if match == nil {
+		return nil, errors.New("failed to find LOB locator selection in redo SQL")
+	}
+	stmt, err := parseRedoSQL("delete from " + match[2])
+	if err != nil {
+		return nil, err
+	}
+	stmt.op = redoLobLocator
+	stmt.lobColumn = strings.ReplaceAll(match[1], `""`, `"`)
+	return stmt, nil
+}
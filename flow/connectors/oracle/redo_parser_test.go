@@ -0,0 +1,116 @@
+package connoracle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func literal(text string) redoValue {
+	return redoValue{kind: redoValueLiteral, text: text}
+}
+
+func TestParseRedoInsert(t *testing.T) {
+	t.Parallel()
+
+	stmt, err := parseRedoSQL(`insert into "APP"."ORDERS"("ID","NAME","NOTE","CREATED","DATA","PRICE") values ` +
+		`('1','it''s',NULL,TO_DATE('2024-05-01 10:11:12', 'YYYY-MM-DD HH24:MI:SS'),HEXTORAW('0aff'),-1.5E3);`)
+	require.NoError(t, err)
+	require.Equal(t, redoInsert, stmt.op)
+	require.Equal(t, "APP", stmt.schema)
+	require.Equal(t, "ORDERS", stmt.table)
+	require.Equal(t, map[string]redoValue{
+		"ID":      literal("1"),
+		"NAME":    literal("it's"),
+		"NOTE":    {kind: redoValueNull},
+		"CREATED": literal("2024-05-01 10:11:12"),
+		"DATA":    {kind: redoValueHex, text: "0aff"},
+		"PRICE":   literal("-1.5E3"),
+	}, stmt.newValues)
+}
+
+func TestParseRedoUpdate(t *testing.T) {
+	t.Parallel()
+
+	stmt, err := parseRedoSQL(`update "APP"."ORDERS" set "NAME" = 'a' || 'b', "NOTE" = NULL ` +
+		`where "ID" = '1' and "NAME" = 'x' and "NOTE" IS NULL;`)
+	require.NoError(t, err)
+	require.Equal(t, redoUpdate, stmt.op)
+	require.Equal(t, map[string]redoValue{
+		"NAME": literal("ab"),
+		"NOTE": {kind: redoValueNull},
+	}, stmt.newValues)
+	require.Equal(t, map[string]redoValue{
+		"ID":   literal("1"),
+		"NAME": literal("x"),
+		"NOTE": {kind: redoValueNull},
+	}, stmt.oldValues)
+}
+
+func TestParseRedoDeleteSkipsRowid(t *testing.T) {
+	t.Parallel()
+
+	stmt, err := parseRedoSQL(`delete from "APP"."ORDERS" where "ID" = '7' and ROWID = 'AAAS5bAAEAAAADlAAA';`)
+	require.NoError(t, err)
+	require.Equal(t, redoDelete, stmt.op)
+	require.Equal(t, map[string]redoValue{"ID": literal("7")}, stmt.oldValues)
+}
+
+func TestParseRedoFunctions(t *testing.T) {
+	t.Parallel()
+
+	stmt, err := parseRedoSQL(`insert into "APP"."T"("A","B","C","D") values ` +
+		`(EMPTY_CLOB(),UNISTR('caf\00e9 \d83d\de00 \\'),TO_TIMESTAMP_TZ('2024-05-01 10:11:12.5 +02:00'),TO_NUMBER(NULL));`)
+	require.NoError(t, err)
+	require.Equal(t, map[string]redoValue{
+		"A": {kind: redoValueEmptyLob},
+		"B": literal("café 😀 \\"),
+		"C": literal("2024-05-01 10:11:12.5 +02:00"),
+		"D": {kind: redoValueNull},
+	}, stmt.newValues)
+}
+
+func TestParseRedoQuotedIdentifiers(t *testing.T) {
+	t.Parallel()
+
+	stmt, err := parseRedoSQL(`insert into "APP"."my ""odd"" table"("col one") values ('v');`)
+	require.NoError(t, err)
+	require.Equal(t, `my "odd" table`, stmt.table)
+	require.Equal(t, map[string]redoValue{"col one": literal("v")}, stmt.newValues)
+}
+
+func TestParseRedoErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, sql := range []string{
+		`create table "APP"."T" ("A" NUMBER);`,
+		`insert into "APP"."T"("A") values ('1') extra;`,
+		`insert into "APP"."T"("A") values (SOME_FUNC('1'));`,
+		`update "APP"."T" set "A" = 'unterminated;`,
+	} {
+		_, err := parseRedoSQL(sql)
+		require.Error(t, err, sql)
+	}
+}
+
+func TestParseLobLocatorRedo(t *testing.T) {
+	t.Parallel()
+
+	stmt, err := parseLobLocatorRedo(`DECLARE
+ loc_c CLOB;
+ buf_c VARCHAR2(6174);
+ loc_b BLOB;
+ buf_b RAW(6174);
+ loc_nc NCLOB;
+ buf_nc NVARCHAR2(6174);
+BEGIN
+ select "BODY" into loc_c from "APP"."DOCS" where "ID" = '3' for update;
+ buf_c := 'hello';
+ dbms_lob.write(loc_c, 5, 1, buf_c);
+END;`)
+	require.NoError(t, err)
+	require.Equal(t, redoLobLocator, stmt.op)
+	require.Equal(t, "BODY", stmt.lobColumn)
+	require.Equal(t, "DOCS", stmt.table)
+	require.Equal(t, map[string]redoValue{"ID": literal("3")}, stmt.oldValues)
+}
@@ -0,0 +1,253 @@
+package connoracle
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/pkg/common"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// userSchemasFilter hides the schemas Oracle creates and maintains itself (SYS, SYSTEM, XDB, ...)
+const userSchemasFilter = "OWNER IN (SELECT USERNAME FROM ALL_USERS WHERE ORACLE_MAINTAINED = 'N')"
+
+// userTablesFilter restricts ALL_TABLES to regular tables, skipping temporary, nested,
+// IOT overflow and recycle bin tables
+const userTablesFilter = userSchemasFilter + ` AND TEMPORARY = 'N' AND NESTED = 'NO' AND SECONDARY = 'N'
+	AND DROPPED = 'NO' AND (IOT_TYPE IS NULL OR IOT_TYPE = 'IOT')`
+
+type oracleColumn struct {
+	name      string
+	dataType  string
+	qkind     types.QValueKind
+	precision sql.NullInt64
+	scale     sql.NullInt64
+	nullable  bool
+}
+
+// getColumns lists the replicable columns of a table in column order. Virtual columns
+// are never logged in redo and hidden ones are internal, both are skipped.
+func (c *OracleConnector) getColumns(ctx context.Context, table *common.QualifiedTable) ([]oracleColumn, error) {
+	rows, err := c.db.QueryContext(ctx, `SELECT COLUMN_NAME, DATA_TYPE, DATA_PRECISION, DATA_SCALE, NULLABLE
+		FROM ALL_TAB_COLS
+		WHERE OWNER = :1 AND TABLE_NAME = :2 AND HIDDEN_COLUMN = 'NO' AND VIRTUAL_COLUMN = 'NO'
+		ORDER BY COLUMN_ID`, table.Namespace, table.Table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var columns []oracleColumn
+	for rows.Next() {
+		var column oracleColumn
+		var nullable string
+		if err := rows.Scan(&column.name, &column.dataType, &column.precision, &column.scale, &nullable); err != nil {
+			return nil, fmt.Errorf("failed to scan column of %s: %w", table, err)
+		}
+		column.nullable = nullable == "Y"
+		column.qkind = oracleTypeToQValueKind(column.dataType, column.precision, column.scale)
+		if column.qkind == types.QValueKindInvalid {
+			c.logger.Warn("[oracle] skipping column of unsupported type",
+				slog.String("table", table.String()),
+				slog.String("column", column.name),
+				slog.String("type", column.dataType))
+			continue
+		}
+		columns = append(columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	return columns, nil
+}
+
+func (c *OracleConnector) getPrimaryKeyColumns(ctx context.Context, table *common.QualifiedTable) ([]string, error) {
+	rows, err := c.db.QueryContext(ctx, `SELECT cc.COLUMN_NAME
+		FROM ALL_CONSTRAINTS c
+		JOIN ALL_CONS_COLUMNS cc ON cc.OWNER = c.OWNER AND cc.CONSTRAINT_NAME = c.CONSTRAINT_NAME
+		WHERE c.OWNER = :1 AND c.TABLE_NAME = :2 AND c.CONSTRAINT_TYPE = 'P'
+		ORDER BY cc.POSITION`, table.Namespace, table.Table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query primary key of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var pkCols []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, fmt.Errorf("failed to scan primary key column of %s: %w", table, err)
+		}
+		pkCols = append(pkCols, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read primary key of %s: %w", table, err)
+	}
+	return pkCols, nil
+}
+
+func (c *OracleConnector) GetTableSchema(
+	ctx context.Context,
+	env map[string]string,
+	version uint32,
+	system protos.TypeSystem,
+	tableMappings []*protos.TableMapping,
+) (map[string]*protos.TableSchema, error) {
+	nullableEnabled, err := internal.PeerDBNullable(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]*protos.TableSchema, len(tableMappings))
+	for _, tableMapping := range tableMappings {
+		parsedTable, err := common.ParseTableIdentifier(tableMapping.SourceTableIdentifier)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse table identifier: %w", err)
+		}
+		columns, err := c.getColumns(ctx, parsedTable)
+		if err != nil {
+			return nil, err
+		}
+		if len(columns) == 0 {
+			return nil, fmt.Errorf("table %s does not exist or has no supported columns", parsedTable)
+		}
+		pkCols, err := c.getPrimaryKeyColumns(ctx, parsedTable)
+		if err != nil {
+			return nil, err
+		}
+
+		fields := make([]*protos.FieldDescription, 0, len(columns))
+		for _, column := range columns {
+			fields = append(fields, &protos.FieldDescription{
+				Name:         column.name,
+				Type:         string(column.qkind),
+				TypeModifier: oracleTypeModifier(column.qkind, column.precision, column.scale),
+				Nullable:     column.nullable,
+			})
+		}
+		res[tableMapping.SourceTableIdentifier] = &protos.TableSchema{
+			TableIdentifier:   tableMapping.SourceTableIdentifier,
+			PrimaryKeyColumns: pkCols,
+			System:            system,
+			NullableEnabled:   nullableEnabled,
+			Columns:           fields,
+		}
+	}
+	return res, nil
+}
+
+func (c *OracleConnector) GetAllTables(ctx context.Context) (*protos.AllTablesResponse, error) {
+	rows, err := c.db.QueryContext(ctx,
+		"SELECT OWNER, TABLE_NAME FROM ALL_TABLES WHERE "+userTablesFilter+" ORDER BY OWNER, TABLE_NAME")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tableNames []string
+	for rows.Next() {
+		var schema, table string
+		if err := rows.Scan(&schema, &table); err != nil {
+			return nil, fmt.Errorf("failed to scan table: %w", err)
+		}
+		tableNames = append(tableNames, schema+"."+table)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read all tables: %w", err)
+	}
+	return &protos.AllTablesResponse{Tables: tableNames}, nil
+}
+
+func (c *OracleConnector) GetColumns(
+	ctx context.Context,
+	version uint32,
+	schema string,
+	table string,
+) (*protos.TableColumnsResponse, error) {
+	qualifiedTable := &common.QualifiedTable{Namespace: schema, Table: table}
+	columns, err := c.getColumns(ctx, qualifiedTable)
+	if err != nil {
+		return nil, err
+	}
+	pkCols, err := c.getPrimaryKeyColumns(ctx, qualifiedTable)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*protos.ColumnsItem, 0, len(columns))
+	for _, column := range columns {
+		items = append(items, &protos.ColumnsItem{
+			Name:  column.name,
+			Type:  column.dataType,
+			IsKey: slices.Contains(pkCols, column.name),
+			Qkind: string(column.qkind),
+		})
+	}
+	return &protos.TableColumnsResponse{Columns: items}, nil
+}
+
+func (c *OracleConnector) GetSchemas(ctx context.Context) (*protos.PeerSchemasResponse, error) {
+	rows, err := c.db.QueryContext(ctx,
+		"SELECT DISTINCT OWNER FROM ALL_TABLES WHERE "+userTablesFilter+" ORDER BY OWNER")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schemas: %w", err)
+	}
+	defer rows.Close()
+
+	var schemas []string
+	for rows.Next() {
+		var schema string
+		if err := rows.Scan(&schema); err != nil {
+			return nil, fmt.Errorf("failed to scan schema: %w", err)
+		}
+		schemas = append(schemas, schema)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read schemas: %w", err)
+	}
+	return &protos.PeerSchemasResponse{Schemas: schemas}, nil
+}
+
+func (c *OracleConnector) GetTablesInSchema(
+	ctx context.Context,
+	schema string,
+	cdcEnabled bool,
+) (*protos.SchemaTablesResponse, error) {
+	// LogMiner needs a primary key or all-column supplemental logging to identify
+	// updated and deleted rows, only the former is known from the dictionary
+	rows, err := c.db.QueryContext(ctx, `SELECT t.TABLE_NAME,
+		CASE WHEN EXISTS (
+			SELECT 1 FROM ALL_CONSTRAINTS c
+			WHERE c.OWNER = t.OWNER AND c.TABLE_NAME = t.TABLE_NAME AND c.CONSTRAINT_TYPE = 'P'
+		) THEN 1 ELSE 0 END
+		FROM ALL_TABLES t
+		WHERE t.OWNER = :1 AND t.TEMPORARY = 'N' AND t.NESTED = 'NO' AND t.SECONDARY = 'N'
+		AND t.DROPPED = 'NO' AND (t.IOT_TYPE IS NULL OR t.IOT_TYPE = 'IOT')
+		ORDER BY t.TABLE_NAME`, schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tables in schema: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []*protos.TableResponse
+	for rows.Next() {
+		var table string
+		var hasPrimaryKey int64
+		if err := rows.Scan(&table, &hasPrimaryKey); err != nil {
+			return nil, fmt.Errorf("failed to scan table: %w", err)
+		}
+		tables = append(tables, &protos.TableResponse{
+			TableName: table,
+			CanMirror: !cdcEnabled || hasPrimaryKey == 1,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tables in schema: %w", err)
+	}
+	return &protos.SchemaTablesResponse{Tables: tables}, nil
+}
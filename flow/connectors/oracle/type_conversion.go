@@ -0,0 +1,268 @@
+package connoracle
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peerdb/flow/pkg/common"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// int64 holds every NUMBER(18,0), NUMBER(19,0) already overflows it
+const maxInt64NumberPrecision = 18
+
+var typeLengthRe = regexp.MustCompile(`\(\d+\)`)
+
+// normalizeOracleType strips the precision embedded in ALL_TAB_COLUMNS.DATA_TYPE,
+// turning e.g. TIMESTAMP(6) WITH TIME ZONE into TIMESTAMP WITH TIME ZONE
+func normalizeOracleType(dataType string) string {
+	return typeLengthRe.ReplaceAllString(strings.ToUpper(strings.TrimSpace(dataType)), "")
+}
+
+// oracleTypeToQValueKind maps an Oracle column type to a QValueKind,
+// returning QValueKindInvalid for types that cannot be replicated (objects, spatial, etc.)
+func oracleTypeToQValueKind(dataType string, precision sql.NullInt64, scale sql.NullInt64) types.QValueKind {
+	switch normalizeOracleType(dataType) {
+	case "NUMBER":
+		if scale.Valid && scale.Int64 == 0 && precision.Valid && precision.Int64 <= maxInt64NumberPrecision {
+			return types.QValueKindInt64
+		}
+		return types.QValueKindNumeric
+	case "FLOAT", "BINARY_DOUBLE":
+		return types.QValueKindFloat64
+	case "BINARY_FLOAT":
+		return types.QValueKindFloat32
+	case "DATE", "TIMESTAMP":
+		return types.QValueKindTimestamp
+	case "TIMESTAMP WITH TIME ZONE", "TIMESTAMP WITH LOCAL TIME ZONE":
+		return types.QValueKindTimestampTZ
+	case "CHAR", "NCHAR", "VARCHAR", "VARCHAR2", "NVARCHAR2", "CLOB", "NCLOB", "LONG",
+		"ROWID", "UROWID", "XMLTYPE", "INTERVAL YEAR TO MONTH", "INTERVAL DAY TO SECOND":
+		return types.QValueKindString
+	case "RAW", "LONG RAW", "BLOB":
+		return types.QValueKindBytes
+	case "JSON":
+		return types.QValueKindJSON
+	case "BOOLEAN":
+		return types.QValueKindBoolean
+	default:
+		return types.QValueKindInvalid
+	}
+}
+
+// oracleTypeModifier returns the numeric typmod of NUMBER columns. Oracle allows
+// negative scales and scales above the precision, those are left unbounded.
+func oracleTypeModifier(qkind types.QValueKind, precision sql.NullInt64, scale sql.NullInt64) int32 {
+	if qkind != types.QValueKindNumeric || !precision.Valid || !scale.Valid {
+		return -1
+	}
+	if scale.Int64 < 0 || scale.Int64 > precision.Int64 {
+		return -1
+	}
+	return datatypes.MakeNumericTypmod(int32(precision.Int64), int32(scale.Int64))
+}
+
+// isLobType reports whether LogMiner leaves the column out of SQL_REDO, writing
+// its content through separate LOB operations instead
+func isLobType(dataType string) bool {
+	switch normalizeOracleType(dataType) {
+	case "CLOB", "NCLOB", "BLOB", "XMLTYPE", "JSON":
+		return true
+	default:
+		return false
+	}
+}
+
+// selectExpression renders a column for QRep reads, converting the types
+// the driver cannot decode into text it can
+func selectExpression(column string, dataType string) string {
+	quoted := common.QuoteIdentifier(column)
+	switch normalizeOracleType(dataType) {
+	case "XMLTYPE":
+		return fmt.Sprintf("XMLSERIALIZE(CONTENT %s AS CLOB) AS %s", quoted, quoted)
+	case "JSON":
+		return fmt.Sprintf("JSON_SERIALIZE(%s RETURNING CLOB) AS %s", quoted, quoted)
+	case "INTERVAL YEAR TO MONTH", "INTERVAL DAY TO SECOND":
+		return fmt.Sprintf("TO_CHAR(%s) AS %s", quoted, quoted)
+	case "ROWID", "UROWID":
+		return fmt.Sprintf("CAST(%s AS VARCHAR2(4000)) AS %s", quoted, quoted)
+	default:
+		return quoted
+	}
+}
+
+// wallClockUTC keeps the wall clock of DATE and TIMESTAMP values, which have no time zone,
+// the driver attaches the database server time zone to them
+func wallClockUTC(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// qvalueFromOracleValue converts a value scanned by the driver into a QValue of the column's kind
+func qvalueFromOracleValue(field types.QField, value any) (types.QValue, error) {
+	if value == nil {
+		return types.QValueNull(field.Type), nil
+	}
+
+	switch field.Type {
+	case types.QValueKindInt64:
+		switch v := value.(type) {
+		case int64:
+			return types.QValueInt64{Val: v}, nil
+		case float64:
+			return types.QValueInt64{Val: int64(v)}, nil
+		case string:
+			i, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid integer %q: %w", v, err)
+			}
+			return types.QValueInt64{Val: i}, nil
+		}
+	case types.QValueKindNumeric:
+		switch v := value.(type) {
+		case string:
+			d, err := decimal.NewFromString(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q: %w", v, err)
+			}
+			return types.QValueNumeric{Val: d, Precision: field.Precision, Scale: field.Scale}, nil
+		case int64:
+			return types.QValueNumeric{Val: decimal.NewFromInt(v), Precision: field.Precision, Scale: field.Scale}, nil
+		case float64:
+			return types.QValueNumeric{Val: decimal.NewFromFloat(v), Precision: field.Precision, Scale: field.Scale}, nil
+		}
+	case types.QValueKindFloat32:
+		switch v := value.(type) {
+		case float32:
+			return types.QValueFloat32{Val: v}, nil
+		case float64:
+			return types.QValueFloat32{Val: float32(v)}, nil
+		case string:
+			f, err := strconv.ParseFloat(v, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid float %q: %w", v, err)
+			}
+			return types.QValueFloat32{Val: float32(f)}, nil
+		}
+	case types.QValueKindFloat64:
+		switch v := value.(type) {
+		case float64:
+			return types.QValueFloat64{Val: v}, nil
+		case float32:
+			return types.QValueFloat64{Val: float64(v)}, nil
+		case int64:
+			return types.QValueFloat64{Val: float64(v)}, nil
+		case string:
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid float %q: %w", v, err)
+			}
+			return types.QValueFloat64{Val: f}, nil
+		}
+	case types.QValueKindTimestamp:
+		if v, ok := value.(time.Time); ok {
+			return types.QValueTimestamp{Val: wallClockUTC(v)}, nil
+		}
+	case types.QValueKindTimestampTZ:
+		if v, ok := value.(time.Time); ok {
+			return types.QValueTimestampTZ{Val: v.UTC()}, nil
+		}
+	case types.QValueKindString:
+		switch v := value.(type) {
+		case string:
+			return types.QValueString{Val: v}, nil
+		case []byte:
+			return types.QValueString{Val: string(v)}, nil
+		}
+	case types.QValueKindBytes:
+		switch v := value.(type) {
+		case []byte:
+			return types.QValueBytes{Val: v}, nil
+		case string:
+			return types.QValueBytes{Val: []byte(v)}, nil
+		}
+	case types.QValueKindJSON:
+		switch v := value.(type) {
+		case string:
+			return types.QValueJSON{Val: v}, nil
+		case []byte:
+			return types.QValueJSON{Val: string(v)}, nil
+		}
+	case types.QValueKindBoolean:
+		switch v := value.(type) {
+		case bool:
+			return types.QValueBoolean{Val: v}, nil
+		case int64:
+			return types.QValueBoolean{Val: v != 0}, nil
+		case string:
+			return types.QValueBoolean{Val: v == "1" || strings.EqualFold(v, "TRUE")}, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot convert %T to %s", value, field.Type)
+}
+
+const (
+	// layouts matching the NLS formats set on the LogMiner session, see logMinerSessionSettings
+	redoTimestampLayout   = "2006-01-02 15:04:05.999999999"
+	redoTimestampTZLayout = "2006-01-02 15:04:05.999999999 -07:00"
+)
+
+// qvalueFromRedoValue converts a value parsed from SQL_REDO into a QValue of the column's kind
+func qvalueFromRedoValue(field types.QField, value redoValue) (types.QValue, error) {
+	switch value.kind {
+	case redoValueNull:
+		return types.QValueNull(field.Type), nil
+	case redoValueEmptyLob:
+		switch field.Type {
+		case types.QValueKindBytes:
+			return types.QValueBytes{Val: []byte{}}, nil
+		case types.QValueKindJSON:
+			return types.QValueNull(field.Type), nil
+		default:
+			return types.QValueString{Val: ""}, nil
+		}
+	case redoValueHex:
+		decoded, err := hex.DecodeString(value.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid HEXTORAW value: %w", err)
+		}
+		if field.Type == types.QValueKindBytes {
+			return types.QValueBytes{Val: decoded}, nil
+		}
+		return qvalueFromOracleValue(field, string(decoded))
+	}
+
+	switch field.Type {
+	case types.QValueKindTimestamp:
+		t, err := time.Parse(redoTimestampLayout, value.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q: %w", value.text, err)
+		}
+		return types.QValueTimestamp{Val: t}, nil
+	case types.QValueKindTimestampTZ:
+		t, err := time.Parse(redoTimestampTZLayout, value.text)
+		if err != nil {
+			// WITH LOCAL TIME ZONE values render without offset, in the session time zone which is UTC
+			var plainErr error
+			if t, plainErr = time.Parse(redoTimestampLayout, value.text); plainErr != nil {
+				return nil, fmt.Errorf("invalid timestamp with time zone %q: %w", value.text, err)
+			}
+		}
+		return types.QValueTimestampTZ{Val: t.UTC()}, nil
+	case types.QValueKindBytes:
+		return types.QValueBytes{Val: []byte(value.text)}, nil
+	default:
+		return qvalueFromOracleValue(field, value.text)
+	}
+}
+
+func quoteLiteral(literal string) string {
+	return "'" + strings.ReplaceAll(literal, "'", "''") + "'"
+}
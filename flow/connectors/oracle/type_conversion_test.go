@@ -0,0 +1,92 @@
+package connoracle
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestOracleTypeToQValueKind(t *testing.T) {
+	t.Parallel()
+
+	valid := func(v int64) sql.NullInt64 { return sql.NullInt64{Int64: v, Valid: true} }
+	for _, tc := range []struct {
+		dataType  string
+		expected  types.QValueKind
+		precision sql.NullInt64
+		scale     sql.NullInt64
+	}{
+		{dataType: "NUMBER", precision: valid(10), scale: valid(0), expected: types.QValueKindInt64},
+		{dataType: "NUMBER", precision: valid(19), scale: valid(0), expected: types.QValueKindNumeric},
+		{dataType: "NUMBER", precision: valid(10), scale: valid(2), expected: types.QValueKindNumeric},
+		{dataType: "NUMBER", expected: types.QValueKindNumeric},
+		{dataType: "BINARY_FLOAT", expected: types.QValueKindFloat32},
+		{dataType: "DATE", expected: types.QValueKindTimestamp},
+		{dataType: "TIMESTAMP(6)", expected: types.QValueKindTimestamp},
+		{dataType: "TIMESTAMP(6) WITH TIME ZONE", expected: types.QValueKindTimestampTZ},
+		{dataType: "TIMESTAMP(9) WITH LOCAL TIME ZONE", expected: types.QValueKindTimestampTZ},
+		{dataType: "INTERVAL DAY(2) TO SECOND(6)", expected: types.QValueKindString},
+		{dataType: "NVARCHAR2", expected: types.QValueKindString},
+		{dataType: "BLOB", expected: types.QValueKindBytes},
+		{dataType: "SDO_GEOMETRY", expected: types.QValueKindInvalid},
+	} {
+		require.Equal(t, tc.expected, oracleTypeToQValueKind(tc.dataType, tc.precision, tc.scale), tc.dataType)
+	}
+}
+
+func TestQValueFromRedoValue(t *testing.T) {
+	t.Parallel()
+
+	qv, err := qvalueFromRedoValue(types.QField{Type: types.QValueKindTimestamp}, literal("2024-05-01 10:11:12.123"))
+	require.NoError(t, err)
+	require.Equal(t, types.QValueTimestamp{Val: time.Date(2024, 5, 1, 10, 11, 12, 123000000, time.UTC)}, qv)
+
+	qv, err = qvalueFromRedoValue(types.QField{Type: types.QValueKindTimestampTZ}, literal("2024-05-01 10:11:12 +02:00"))
+	require.NoError(t, err)
+	require.Equal(t, types.QValueTimestampTZ{Val: time.Date(2024, 5, 1, 8, 11, 12, 0, time.UTC)}, qv)
+
+	qv, err = qvalueFromRedoValue(types.QField{Type: types.QValueKindNumeric, Precision: 10, Scale: 2}, literal("12.34"))
+	require.NoError(t, err)
+	require.Equal(t, types.QValueNumeric{Val: decimal.RequireFromString("12.34"), Precision: 10, Scale: 2}, qv)
+
+	qv, err = qvalueFromRedoValue(types.QField{Type: types.QValueKindBytes}, redoValue{kind: redoValueHex, text: "0AFF"})
+	require.NoError(t, err)
+	require.Equal(t, types.QValueBytes{Val: []byte{0x0a, 0xff}}, qv)
+
+	qv, err = qvalueFromRedoValue(types.QField{Type: types.QValueKindInt64}, redoValue{kind: redoValueNull})
+	require.NoError(t, err)
+	require.Equal(t, types.QValueNull(types.QValueKindInt64), qv)
+
+	_, err = qvalueFromRedoValue(types.QField{Type: types.QValueKindInt64}, literal("1.5"))
+	require.Error(t, err)
+}
+
+func TestParseScnCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	cp, err := parseScnCheckpoint("100:250")
+	require.NoError(t, err)
+	require.Equal(t, scnCheckpoint{resumeScn: 100, lastCommitScn: 250}, cp)
+	require.Equal(t, "100:250", cp.String())
+
+	cp, err = parseScnCheckpoint("100:250:0A001B00,0B000200")
+	require.NoError(t, err)
+	require.Equal(t, scnCheckpoint{resumeScn: 100, lastCommitScn: 250, committedXids: []string{"0A001B00", "0B000200"}}, cp)
+	require.Equal(t, "100:250:0A001B00,0B000200", cp.String())
+	require.True(t, cp.replicated(249, "FF"))
+	require.True(t, cp.replicated(250, "0B000200"))
+	require.False(t, cp.replicated(250, "FF"))
+	require.False(t, cp.replicated(251, "0A001B00"))
+
+	cp, err = parseScnCheckpoint("250")
+	require.NoError(t, err)
+	require.Equal(t, scnCheckpoint{resumeScn: 251, lastCommitScn: 250}, cp)
+
+	_, err = parseScnCheckpoint("abc:1")
+	require.Error(t, err)
+}
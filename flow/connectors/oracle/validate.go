@@ -0,0 +1,124 @@
+package connoracle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/pkg/common"
+)
+
+// ORACLE_MAINTAINED, which filters out system schemas, was added in 12c
+const minOracleMajorVersion = 12
+
+func parseMajorVersion(version string) (int, error) {
+	major, _, _ := strings.Cut(version, ".")
+	return strconv.Atoi(major)
+}
+
+func (c *OracleConnector) ValidateCheck(ctx context.Context) error {
+	version, err := c.GetVersion(ctx)
+	if err != nil {
+		return err
+	}
+	majorVersion, err := parseMajorVersion(version)
+	if err != nil {
+		return fmt.Errorf("failed to parse Oracle version %q: %w", version, err)
+	}
+	if majorVersion < minOracleMajorVersion {
+		return fmt.Errorf("Oracle must be version %d or above. Current version: %s", minOracleMajorVersion, version)
+	}
+	return nil
+}
+
+func (c *OracleConnector) ValidateMirrorSource(ctx context.Context, cfg *protos.FlowConnectionConfigsCore) error {
+	var missingTables []common.QualifiedTable
+	parsedTables := make([]*common.QualifiedTable, 0, len(cfg.TableMappings))
+	for _, tm := range cfg.TableMappings {
+		parsedTable, err := common.ParseTableIdentifier(tm.SourceTableIdentifier)
+		if err != nil {
+			return fmt.Errorf("invalid source table identifier %s: %w", tm.SourceTableIdentifier, err)
+		}
+		var count int64
+		if err := c.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM ALL_TABLES WHERE OWNER = :1 AND TABLE_NAME = :2",
+			parsedTable.Namespace, parsedTable.Table).Scan(&count); err != nil {
+			return fmt.Errorf("failed to check source table %s: %w", tm.SourceTableIdentifier, err)
+		}
+		if count == 0 {
+			missingTables = append(missingTables, *parsedTable)
+		} else {
+			parsedTables = append(parsedTables, parsedTable)
+		}
+	}
+	if len(missingTables) > 0 {
+		return common.NewSourceTablesMissingError(missingTables)
+	}
+
+	// snapshot-only mirrors never read redo
+	if cfg.DoInitialSnapshot && cfg.InitialSnapshotOnly {
+		return nil
+	}
+
+	var logMode, minLogging, pkLogging, allLogging string
+	if err := c.db.QueryRowContext(ctx,
+		"SELECT LOG_MODE, SUPPLEMENTAL_LOG_DATA_MIN, SUPPLEMENTAL_LOG_DATA_PK, SUPPLEMENTAL_LOG_DATA_ALL FROM V$DATABASE",
+	).Scan(&logMode, &minLogging, &pkLogging, &allLogging); err != nil {
+		return fmt.Errorf("failed to check redo logging configuration, reading V$DATABASE needs SELECT_CATALOG_ROLE: %w", err)
+	}
+	if err := validateRedoLogging(logMode, minLogging); err != nil {
+		return err
+	}
+	if pkLogging == "YES" || allLogging == "YES" {
+		return nil
+	}
+	for _, table := range parsedTables {
+		if err := c.validateTableSupplementalLogging(ctx, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateRedoLogging(logMode string, minLogging string) error {
+	if logMode != "ARCHIVELOG" {
+		return errors.New("LogMiner based CDC needs the database in ARCHIVELOG mode so redo outlives log switches;" +
+			" enable it with ALTER DATABASE ARCHIVELOG while the database is mounted")
+	}
+	if minLogging == "NO" {
+		return errors.New("LogMiner based CDC needs minimal supplemental logging to reconstruct changes;" +
+			" enable it with ALTER DATABASE ADD SUPPLEMENTAL LOG DATA")
+	}
+	return nil
+}
+
+// validateTableSupplementalLogging checks that updates and deletes of a table log enough of the
+// row to identify it: the primary key, or every column for tables without one
+func (c *OracleConnector) validateTableSupplementalLogging(ctx context.Context, table *common.QualifiedTable) error {
+	var pkLogGroups, allLogGroups int64
+	if err := c.db.QueryRowContext(ctx, `SELECT
+		COUNT(CASE WHEN LOG_GROUP_TYPE = 'PRIMARY KEY LOGGING' THEN 1 END),
+		COUNT(CASE WHEN LOG_GROUP_TYPE = 'ALL COLUMN LOGGING' THEN 1 END)
+		FROM ALL_LOG_GROUPS WHERE OWNER = :1 AND TABLE_NAME = :2`,
+		table.Namespace, table.Table).Scan(&pkLogGroups, &allLogGroups); err != nil {
+		return fmt.Errorf("failed to check supplemental logging of %s: %w", table, err)
+	}
+	if allLogGroups > 0 {
+		return nil
+	}
+	pkCols, err := c.getPrimaryKeyColumns(ctx, table)
+	if err != nil {
+		return err
+	}
+	if len(pkCols) > 0 && pkLogGroups > 0 {
+		return nil
+	}
+	if len(pkCols) == 0 {
+		return fmt.Errorf("table %s has no primary key, enable all column supplemental logging with"+
+			" ALTER TABLE %s ADD SUPPLEMENTAL LOG DATA (ALL) COLUMNS", table, table)
+	}
+	return fmt.Errorf("table %s needs primary key supplemental logging to replicate updates and deletes,"+
+		" enable it with ALTER TABLE %s ADD SUPPLEMENTAL LOG DATA (PRIMARY KEY) COLUMNS", table, table)
+}
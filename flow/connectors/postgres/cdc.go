@@ -391,6 +391,8 @@ func (p *PostgresCDCSource) decodeColumnData(
 	var parsedData any
 	var err error
 
+	dataType, typmod = resolveDomain(dataType, typmod, customTypeMapping, version)
+
 	// Special handling for JSON types to use relaxed number parsing
 	if dataType == pgtype.JSONOID || dataType == pgtype.JSONBOID {
 		var text pgtype.Text
@@ -477,6 +479,8 @@ func (p *PostgresCDCSource) decodeColumnData(
 			}
 		case types.QValueKindHStore:
 			return types.QValueHStore{Val: string(data)}, nil
+		case types.QValueKindStruct:
+			return p.decodeCompositeText(string(data), typeData, protos.DBType_DBTYPE_UNKNOWN, customTypeMapping, version, p.jsonApi)
 		case types.QValueKindString:
 			return types.QValueString{Val: string(data)}, nil
 		case types.QValueKindEnum:
@@ -580,7 +584,7 @@ func PullCdcRecords[Items model.Items](
 	var cdcRecordsStorage *utils.CDCStore[Items]
 	if p.cdcStoreEnabled {
 		var err error
-		cdcRecordsStorage, err = utils.NewCDCStore[Items](ctx, req.Env, p.flowJobName)
+		cdcRecordsStorage, err = utils.NewCDCStore[Items](ctx, req.Env, p.otelManager, p.flowJobName)
 		if err != nil {
 			return err
 		}
@@ -641,7 +645,7 @@ func PullCdcRecords[Items model.Items](
 
 	addRecordWithKey := func(key model.TableWithPkey, rec model.Record[Items]) error {
 		if cdcRecordsStorage != nil {
-			if err := cdcRecordsStorage.Set(key, rec); err != nil {
+			if err := cdcRecordsStorage.Set(ctx, key, rec); err != nil {
 				return err
 			}
 		}
@@ -861,7 +865,7 @@ func PullCdcRecords[Items model.Items](
 							}
 
 							if cdcRecordsStorage != nil {
-								if latestRecord, found, err := cdcRecordsStorage.Get(tablePkeyVal); err != nil {
+								if latestRecord, found, err := cdcRecordsStorage.Get(ctx, tablePkeyVal); err != nil {
 									return err
 								} else if found {
 									// iterate through unchanged toast cols and set them in new record
@@ -913,7 +917,7 @@ func PullCdcRecords[Items model.Items](
 
 							backfilled := false
 							if cdcRecordsStorage != nil {
-								if latestRecord, found, err := cdcRecordsStorage.Get(tablePkeyVal); err != nil {
+								if latestRecord, found, err := cdcRecordsStorage.Get(ctx, tablePkeyVal); err != nil {
 									return err
 								} else if found {
 									r.Items = latestRecord.GetItems()
@@ -1373,14 +1377,21 @@ func processRelationMessage[Items model.Items](
 					defaultExpr = &literal
 				}
 			}
+			typmod := column.TypeModifier
+			if prevSchema.System == protos.TypeSystem_Q {
+				_, typmod = resolveDomain(column.DataType, typmod, customTypeMapping, p.internalVersion)
+			}
 			addedColumn := &protos.FieldDescription{
 				Name:           column.Name,
 				Type:           currRelMap[column.Name],
-				TypeModifier:   column.TypeModifier,
+				TypeModifier:   typmod,
 				Nullable:       !catalogInfo.notNull,
 				TypeSchemaName: typeSchemaNameMapping[column.DataType],
 				DefaultExpr:    defaultExpr,
 			}
+			if prevSchema.System == protos.TypeSystem_Q && types.QValueKind(addedColumn.Type).HasStructFields() {
+				addedColumn.Fields = p.structFieldDescriptions(column.DataType, customTypeMapping, p.internalVersion)
+			}
 			schemaDelta.AddedColumns = append(schemaDelta.AddedColumns, addedColumn)
 			p.logger.Info("Detected added column",
 				slog.String("columnName", addedColumn.Name),
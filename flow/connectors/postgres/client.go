@@ -654,6 +654,7 @@ func (c *PostgresConnector) createSlotAndPublication(
 				Conn:             nil,
 				SlotName:         res.SlotName,
 				SnapshotName:     "",
+				ConsistentPoint:  res.ConsistentPoint,
 				SupportsTIDScans: pgversion >= shared.POSTGRES_13,
 			}, nil
 		}
@@ -661,6 +662,7 @@ func (c *PostgresConnector) createSlotAndPublication(
 			Conn:             conn,
 			SlotName:         res.SlotName,
 			SnapshotName:     res.SnapshotName,
+			ConsistentPoint:  res.ConsistentPoint,
 			SupportsTIDScans: pgversion >= shared.POSTGRES_13,
 		}, nil
 	} else {
@@ -703,7 +705,7 @@ func generateCreateTableSQLForNormalizedTable(
 			}
 			pgColumnType = schemaQualifiedPgType.String()
 		} else if tableSchema.System == protos.TypeSystem_Q {
-			pgColumnType = qValueKindToPostgresType(pgColumnType)
+			pgColumnType = columnToPostgresType(column)
 		}
 
 		if column.Type == "numeric" && column.TypeModifier != -1 {
@@ -0,0 +1,233 @@
+package connpostgres
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/pkg/common"
+	pkg_pg "github.com/PeerDB-io/peerdb/flow/pkg/postgres"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// resolveDomain follows domains, which may be defined over other domains, down to their base type,
+// columns of a domain have no type modifier of their own so they take the one of the domain
+func resolveDomain(
+	oid uint32, typmod int32, customTypeMapping map[uint32]pkg_pg.CustomDataType, version uint32,
+) (uint32, int32) {
+	for {
+		typeData, ok := customTypeMapping[oid]
+		if !ok {
+			return oid, typmod
+		}
+		baseOID, baseTypmod, ok := DomainBaseOID(typeData, version)
+		if !ok {
+			return oid, typmod
+		}
+		oid = baseOID
+		if typmod == -1 {
+			typmod = baseTypmod
+		}
+	}
+}
+
+// parseCompositeText splits the text output of a composite value, like (1,"a ""b""",,), into its members,
+// unquoted empty members are NULL while "" is an empty string
+func parseCompositeText(s string) ([]pgtype.Text, error) {
+	if len(s) < 2 || s[0] != '(' || s[len(s)-1] != ')' {
+		return nil, fmt.Errorf("malformed composite literal %q", s)
+	}
+	s = s[1 : len(s)-1]
+
+	var members []pgtype.Text
+	var member strings.Builder
+	quoted := false
+	inQuotes := false
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch == '\\':
+			i++
+			if i == len(s) {
+				return nil, errors.New("malformed composite literal: trailing backslash")
+			}
+			member.WriteByte(s[i])
+		case ch == '"' && inQuotes && i+1 < len(s) && s[i+1] == '"':
+			member.WriteByte('"')
+			i++
+		case ch == '"':
+			inQuotes = !inQuotes
+			quoted = true
+		case ch == ',' && !inQuotes:
+			members = append(members, pgtype.Text{String: member.String(), Valid: quoted || member.Len() > 0})
+			member.Reset()
+			quoted = false
+		default:
+			member.WriteByte(ch)
+		}
+	}
+	if inQuotes {
+		return nil, errors.New("malformed composite literal: unterminated quote")
+	}
+	return append(members, pgtype.Text{String: member.String(), Valid: quoted || member.Len() > 0}), nil
+}
+
+// decodeCompositeText decodes the text output of a composite value into a struct,
+// members are decoded according to their own type so nested composites become nested structs
+func (c *PostgresConnector) decodeCompositeText(
+	data string,
+	typeData pkg_pg.CustomDataType,
+	dstType protos.DBType,
+	customTypeMapping map[uint32]pkg_pg.CustomDataType,
+	version uint32,
+	jsonApi jsoniter.API,
+) (types.QValueStruct, error) {
+	texts, err := parseCompositeText(data)
+	if err != nil {
+		return types.QValueStruct{}, err
+	}
+	if len(texts) != len(typeData.Fields) {
+		return types.QValueStruct{}, fmt.Errorf("composite %s has %d members, value has %d",
+			typeData.Name, len(typeData.Fields), len(texts))
+	}
+
+	members := make([]types.QValueStructField, len(texts))
+	for i, text := range texts {
+		field := typeData.Fields[i]
+		var val types.QValue
+		if text.Valid {
+			val, err = c.decodeTextValue(field.OID, text.String, dstType, customTypeMapping, version, jsonApi)
+			if err != nil {
+				return types.QValueStruct{}, fmt.Errorf("failed to decode member %s of %s: %w", field.Name, typeData.Name, err)
+			}
+		} else {
+			val = types.QValueNull(c.postgresOIDToQValueKind(field.OID, customTypeMapping, version))
+		}
+		members[i] = types.QValueStructField{Name: field.Name, Val: val}
+	}
+	return types.QValueStruct{Val: members}, nil
+}
+
+// decodeTextValue decodes a single value in text format, as found in composite members
+func (c *PostgresConnector) decodeTextValue(
+	oid uint32,
+	data string,
+	dstType protos.DBType,
+	customTypeMapping map[uint32]pkg_pg.CustomDataType,
+	version uint32,
+	jsonApi jsoniter.API,
+) (types.QValue, error) {
+	oid, typmod := resolveDomain(oid, -1, customTypeMapping, version)
+	if typeData, ok := customTypeMapping[oid]; ok && CustomTypeToQKind(typeData, version) == types.QValueKindStruct {
+		return c.decodeCompositeText(data, typeData, dstType, customTypeMapping, version, jsonApi)
+	}
+
+	var value any
+	switch oid {
+	case pgtype.JSONOID, pgtype.JSONBOID:
+		if err := jsonApi.UnmarshalFromString(data, &value); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal json: %w", err)
+		}
+		if value == nil {
+			// avoid confusing SQL null & JSON null by using pre-marshaled value
+			value = json.RawMessage("null")
+		}
+	default:
+		if dt, ok := c.typeMap.TypeForOID(oid); ok {
+			var err error
+			if value, err = dt.Codec.DecodeValue(c.typeMap, oid, pgtype.TextFormatCode, []byte(data)); err != nil {
+				return nil, err
+			}
+		} else {
+			value = data
+		}
+	}
+	return c.parseFieldFromPostgresOID(oid, typmod, true, dstType, value, customTypeMapping, version)
+}
+
+// structFieldDescriptions describes the members of a struct column, which holds either a range or a composite,
+// or of the elements of an array of structs column, which holds the ranges of a multirange
+func (c *PostgresConnector) structFieldDescriptions(
+	oid uint32,
+	customTypeMapping map[uint32]pkg_pg.CustomDataType,
+	version uint32,
+) []*protos.FieldDescription {
+	oid, _ = resolveDomain(oid, -1, customTypeMapping, version)
+	if _, ok := rangeElementOIDs[oid]; ok {
+		return c.rangeFieldDescriptions(oid, customTypeMapping, version)
+	}
+	if rangeOID, ok := multirangeRangeOIDs[oid]; ok {
+		return c.rangeFieldDescriptions(rangeOID, customTypeMapping, version)
+	}
+	return c.compositeFieldDescriptions(customTypeMapping[oid], customTypeMapping, version)
+}
+
+// structQFields is structFieldDescriptions for QRep schemas
+func (c *PostgresConnector) structQFields(
+	oid uint32,
+	customTypeMapping map[uint32]pkg_pg.CustomDataType,
+	version uint32,
+) []types.QField {
+	oid, _ = resolveDomain(oid, -1, customTypeMapping, version)
+	if _, ok := rangeElementOIDs[oid]; ok {
+		return c.rangeQFields(oid, customTypeMapping, version)
+	}
+	if rangeOID, ok := multirangeRangeOIDs[oid]; ok {
+		return c.rangeQFields(rangeOID, customTypeMapping, version)
+	}
+	return c.compositeQFields(customTypeMapping[oid], customTypeMapping, version)
+}
+
+// compositeFieldDescriptions describes the members of a composite type for table schemas,
+// members are always nullable since composites do not support NOT NULL members
+func (c *PostgresConnector) compositeFieldDescriptions(
+	typeData pkg_pg.CustomDataType,
+	customTypeMapping map[uint32]pkg_pg.CustomDataType,
+	version uint32,
+) []*protos.FieldDescription {
+	fields := make([]*protos.FieldDescription, 0, len(typeData.Fields))
+	for _, member := range typeData.Fields {
+		kind := c.postgresOIDToQValueKind(member.OID, customTypeMapping, version)
+		_, typmod := resolveDomain(member.OID, -1, customTypeMapping, version)
+		fd := &protos.FieldDescription{
+			Name:         member.Name,
+			Type:         string(kind),
+			TypeModifier: typmod,
+			Nullable:     true,
+		}
+		if kind.HasStructFields() {
+			fd.Fields = c.structFieldDescriptions(member.OID, customTypeMapping, version)
+		}
+		fields = append(fields, fd)
+	}
+	return fields
+}
+
+// compositeQFields is compositeFieldDescriptions for QRep schemas
+func (c *PostgresConnector) compositeQFields(
+	typeData pkg_pg.CustomDataType,
+	customTypeMapping map[uint32]pkg_pg.CustomDataType,
+	version uint32,
+) []types.QField {
+	fields := make([]types.QField, 0, len(typeData.Fields))
+	for _, member := range typeData.Fields {
+		field := types.QField{
+			Name:     member.Name,
+			Type:     c.postgresOIDToQValueKind(member.OID, customTypeMapping, version),
+			Nullable: true,
+		}
+		if field.Type == types.QValueKindNumeric || field.Type == types.QValueKindArrayNumeric {
+			_, typmod := resolveDomain(member.OID, -1, customTypeMapping, version)
+			field.Precision, field.Scale = common.ParseNumericTypmod(typmod)
+		} else if field.Type.HasStructFields() {
+			field.Fields = c.structQFields(member.OID, customTypeMapping, version)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
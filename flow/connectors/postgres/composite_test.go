@@ -0,0 +1,106 @@
+package connpostgres
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/pkg/common"
+	pkg_pg "github.com/PeerDB-io/peerdb/flow/pkg/postgres"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func TestParseCompositeText(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected []pgtype.Text
+	}{
+		{
+			name:     "plain",
+			input:    "(1,abc)",
+			expected: []pgtype.Text{{String: "1", Valid: true}, {String: "abc", Valid: true}},
+		},
+		{
+			name:     "nulls and empty string",
+			input:    `(,"",)`,
+			expected: []pgtype.Text{{}, {String: "", Valid: true}, {}},
+		},
+		{
+			name:  "quoted",
+			input: `("a, b","say ""hi""","back\\slash")`,
+			expected: []pgtype.Text{
+				{String: "a, b", Valid: true},
+				{String: `say "hi"`, Valid: true},
+				{String: `back\slash`, Valid: true},
+			},
+		},
+		{
+			name:  "nested composite",
+			input: `(1,"(2,""x y"")")`,
+			expected: []pgtype.Text{
+				{String: "1", Valid: true},
+				{String: `(2,"x y")`, Valid: true},
+			},
+		},
+		{
+			name:  "array member",
+			input: `(1,"{a,b}")`,
+			expected: []pgtype.Text{
+				{String: "1", Valid: true},
+				{String: "{a,b}", Valid: true},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			members, err := parseCompositeText(tc.input)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, members)
+		})
+	}
+}
+
+func TestParseCompositeTextMalformed(t *testing.T) {
+	t.Parallel()
+
+	for _, input := range []string{"", "1,2", `("unterminated)`, `(a\`} {
+		_, err := parseCompositeText(input)
+		require.Error(t, err, input)
+	}
+}
+
+func TestResolveDomain(t *testing.T) {
+	t.Parallel()
+
+	amountTypmod := int32((12 << 16) + 2 + 4)
+	customTypeMapping := map[uint32]pkg_pg.CustomDataType{
+		100: {Name: "amount", Type: 'd', BaseOID: pgtype.NumericOID, BaseTypmod: amountTypmod},
+		101: {Name: "price", Type: 'd', BaseOID: 100, BaseTypmod: -1},
+		102: {Name: "code", Type: 'd', BaseOID: pgtype.VarcharOID, BaseTypmod: 14},
+	}
+
+	oid, typmod := resolveDomain(101, -1, customTypeMapping, shared.InternalVersion_Latest)
+	require.Equal(t, uint32(pgtype.NumericOID), oid)
+	require.Equal(t, amountTypmod, typmod)
+	precision, scale := common.ParseNumericTypmod(typmod)
+	require.Equal(t, int16(12), precision)
+	require.Equal(t, int16(2), scale)
+
+	oid, typmod = resolveDomain(102, -1, customTypeMapping, shared.InternalVersion_Latest)
+	require.Equal(t, uint32(pgtype.VarcharOID), oid)
+	require.Equal(t, int32(14), typmod)
+
+	oid, typmod = resolveDomain(pgtype.NumericOID, -1, customTypeMapping, shared.InternalVersion_Latest)
+	require.Equal(t, uint32(pgtype.NumericOID), oid)
+	require.Equal(t, int32(-1), typmod)
+
+	oid, typmod = resolveDomain(100, -1, customTypeMapping, shared.InternalVersion_PgCompositeAndDomainTypes-1)
+	require.Equal(t, uint32(100), oid)
+	require.Equal(t, int32(-1), typmod)
+}
@@ -36,7 +36,7 @@ func (n *normalizeStmtGenerator) columnTypeToPg(schema *protos.TableSchema, colu
 	var pgType string
 	switch schema.System {
 	case protos.TypeSystem_Q:
-		pgType = qValueKindToPostgresType(column.Type)
+		pgType = columnToPostgresType(column)
 	case protos.TypeSystem_PG:
 		pgType = column.Type
 		// Add schema qualification for user-defined types
@@ -56,13 +56,22 @@ func (n *normalizeStmtGenerator) columnTypeToPg(schema *protos.TableSchema, colu
 
 func (n *normalizeStmtGenerator) generateExpr(
 	normalizedTableSchema *protos.TableSchema,
-	genericColumnType string,
+	column *protos.FieldDescription,
 	stringCol string,
 	pgType string,
 ) string {
 	if normalizedTableSchema.System == protos.TypeSystem_Q {
-		qkind := types.QValueKind(genericColumnType)
-		if qkind.IsArray() {
+		qkind := types.QValueKind(column.Type)
+		if rangeType, elementKind, ok := postgresRangeType(column); ok {
+			// ranges arrive as objects of their bounds
+			return fmt.Sprintf("CASE WHEN _peerdb_data->>%s IS NULL THEN NULL %s END",
+				stringCol, rangeCases("_peerdb_data->"+stringCol, rangeType, elementKind))
+		} else if multirangeType, rangeType, elementKind, ok := postgresMultirangeType(column); ok {
+			// multiranges arrive as arrays of range objects
+			return fmt.Sprintf("CASE WHEN _peerdb_data->>%[1]s IS NULL THEN NULL "+
+				"ELSE (SELECT COALESCE(range_agg(CASE %[2]s END),'{}'::%[3]s) FROM jsonb_array_elements(_peerdb_data->%[1]s) r) END",
+				stringCol, rangeCases("r", rangeType, elementKind), multirangeType)
+		} else if qkind.IsArray() {
 			return fmt.Sprintf("ARRAY(SELECT JSON_ARRAY_ELEMENTS_TEXT((_peerdb_data->>%s)::JSON))::%s", stringCol, pgType)
 		} else if qkind == types.QValueKindBytes {
 			return fmt.Sprintf("decode(_peerdb_data->>%s, 'base64')::%s", stringCol, pgType)
@@ -71,6 +80,19 @@ func (n *normalizeStmtGenerator) generateExpr(
 	return fmt.Sprintf("(_peerdb_data->>%s)::%s", stringCol, pgType)
 }
 
+// rangeCases are the CASE branches rebuilding a range from the object of its bounds in rangeJSON
+func rangeCases(rangeJSON string, rangeType string, elementKind types.QValueKind) string {
+	bound := func(member string) string {
+		return fmt.Sprintf("(%s->>'%s')::%s", rangeJSON, member, qValueKindToPostgresType(string(elementKind)))
+	}
+	inclusive := func(member string, inclusive string, exclusive string) string {
+		return fmt.Sprintf("CASE WHEN (%s->>'%s')::boolean THEN '%s' ELSE '%s' END", rangeJSON, member, inclusive, exclusive)
+	}
+	return fmt.Sprintf("WHEN (%[1]s->>'%[2]s')::boolean THEN 'empty'::%[3]s ELSE %[3]s(%[4]s,%[5]s,%[6]s||%[7]s)",
+		rangeJSON, types.RangeEmptyMember, rangeType, bound(types.RangeLowerMember), bound(types.RangeUpperMember),
+		inclusive(types.RangeLowerIncMember, "[", "("), inclusive(types.RangeUpperIncMember, "]", ")"))
+}
+
 func (n *normalizeStmtGenerator) generateNormalizeStatements(dstTable string) []string {
 	normalizedTableSchema := n.tableSchemaMapping[dstTable]
 
@@ -95,12 +117,11 @@ func (n *normalizeStmtGenerator) generateFallbackStatements(
 	flattenedCastsSQLArray := make([]string, 0, columnCount)
 	primaryKeyColumnCasts := make(map[string]string, len(normalizedTableSchema.PrimaryKeyColumns))
 	for _, column := range normalizedTableSchema.Columns {
-		genericColumnType := column.Type
 		quotedCol := common.QuoteIdentifier(column.Name)
 		stringCol := utils.QuoteLiteral(column.Name)
 		columnNames = append(columnNames, quotedCol)
 		pgType := n.columnTypeToPg(normalizedTableSchema, column)
-		expr := n.generateExpr(normalizedTableSchema, genericColumnType, stringCol, pgType)
+		expr := n.generateExpr(normalizedTableSchema, column, stringCol, pgType)
 
 		flattenedCastsSQLArray = append(flattenedCastsSQLArray, fmt.Sprintf("%s AS %s", expr, quotedCol))
 		if slices.Contains(normalizedTableSchema.PrimaryKeyColumns, column.Name) {
@@ -189,8 +210,7 @@ func (n *normalizeStmtGenerator) generateMergeStatement(
 				selectExprs = append(selectExprs, quotedCol)
 			}
 		} else {
-			genericColumnType := column.Type
-			expr := n.generateExpr(normalizedTableSchema, genericColumnType, stringCol, pgType)
+			expr := n.generateExpr(normalizedTableSchema, column, stringCol, pgType)
 			selectExprs = append(selectExprs, fmt.Sprintf("%s AS %s", expr, quotedCol))
 		}
 
@@ -15,6 +15,7 @@ import (
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 	"go.temporal.io/sdk/log"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
@@ -218,6 +219,22 @@ func ParseConfig(connectionString string, pgConfig *protos.PostgresConfig) (*pgx
 	return connConfig, nil
 }
 
+// ReplicaConfig returns the config for connecting to the peer's read replica, or nil if it has none.
+// The replica shares credentials, TLS and SSH settings with the primary.
+func ReplicaConfig(pgConfig *protos.PostgresConfig) *protos.PostgresConfig {
+	if pgConfig.GetReplicaHost() == "" {
+		return nil
+	}
+	replicaConfig := proto.CloneOf(pgConfig)
+	replicaConfig.Host = pgConfig.ReplicaHost
+	if pgConfig.ReplicaPort != 0 {
+		replicaConfig.Port = pgConfig.ReplicaPort
+	}
+	replicaConfig.ReplicaHost = ""
+	replicaConfig.ReplicaPort = 0
+	return replicaConfig
+}
+
 func (c *PostgresConnector) fetchCustomTypeMapping(ctx context.Context) (map[uint32]pkg_pg.CustomDataType, error) {
 	if c.customTypeMapping == nil {
 		customTypeMapping, err := pkg_pg.GetCustomDataTypes(ctx, c.conn)
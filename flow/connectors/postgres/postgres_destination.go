@@ -542,7 +542,7 @@ func (c *PostgresConnector) ReplayTableSchemaDeltas(
 			columnType := addedColumn.Type
 			switch schemaDelta.System {
 			case protos.TypeSystem_Q:
-				columnType = qValueKindToPostgresType(columnType)
+				columnType = columnToPostgresType(addedColumn)
 			case protos.TypeSystem_PG:
 				// schema qualification handled after numeric typmod check
 			default:
@@ -2,6 +2,7 @@ package connpostgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"maps"
@@ -29,6 +30,7 @@ import (
 	pkg_pg "github.com/PeerDB-io/peerdb/flow/pkg/postgres"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
 type SlotCheckResult struct {
@@ -628,12 +630,14 @@ func (c *PostgresConnector) getTableSchemaForTable(
 	for _, fieldDescription := range fields {
 		var colType string
 		var err error
+		typmod := fieldDescription.TypeModifier
 		switch system {
 		case protos.TypeSystem_PG:
 			colType, err = pkg_pg.OIDToName(c.typeMap, fieldDescription.DataTypeOID, customTypeMapping)
 		case protos.TypeSystem_Q:
 			qColType := c.postgresOIDToQValueKind(fieldDescription.DataTypeOID, customTypeMapping, version)
 			colType = string(qColType)
+			_, typmod = resolveDomain(fieldDescription.DataTypeOID, typmod, customTypeMapping, version)
 		}
 		if err != nil {
 			return nil, fmt.Errorf("error getting type name for %d: %w", fieldDescription.DataTypeOID, err)
@@ -641,13 +645,17 @@ func (c *PostgresConnector) getTableSchemaForTable(
 
 		columnNames = append(columnNames, fieldDescription.Name)
 		_, nullable := nullableCols[fieldDescription.Name]
-		columns = append(columns, &protos.FieldDescription{
+		column := &protos.FieldDescription{
 			Name:           fieldDescription.Name,
 			Type:           colType,
-			TypeModifier:   fieldDescription.TypeModifier,
+			TypeModifier:   typmod,
 			Nullable:       nullable,
 			TypeSchemaName: typeSchemaNameMapping[fieldDescription.DataTypeOID],
-		})
+		}
+		if system == protos.TypeSystem_Q && types.QValueKind(colType).HasStructFields() {
+			column.Fields = c.structFieldDescriptions(fieldDescription.DataTypeOID, customTypeMapping, version)
+		}
+		columns = append(columns, column)
 	}
 
 	// if we have no pkey, we will use all columns as the pkey for the MERGE statement
@@ -758,6 +766,61 @@ func (c *PostgresConnector) ExportTxSnapshot(
 	}, tx, err
 }
 
+// CurrentWALLSN returns the primary's current WAL position, a standby that replayed up to it
+// exports snapshots containing everything committed so far.
+func (c *PostgresConnector) CurrentWALLSN(ctx context.Context) (pglogrepl.LSN, error) {
+	lsn, err := c.getCurrentLSN(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if lsn.Null {
+		return 0, errors.New("current WAL LSN is null")
+	}
+	return lsn.LSN, nil
+}
+
+// WaitForReplayLSN blocks until a standby has replayed WAL up to lsn, so snapshots exported on it
+// include everything committed before a slot on the primary became consistent.
+// Long running snapshots on a standby can be canceled by recovery conflicts unless hot_standby_feedback is on.
+func (c *PostgresConnector) WaitForReplayLSN(ctx context.Context, lsn pglogrepl.LSN) error {
+	lastLogged := time.Time{}
+	for {
+		var inRecovery bool
+		var replayLSN pgtype.Text
+		if err := c.conn.QueryRow(ctx,
+			"SELECT pg_is_in_recovery(), pg_last_wal_replay_lsn()::text",
+		).Scan(&inRecovery, &replayLSN); err != nil {
+			return fmt.Errorf("[replica] error querying replay LSN: %w", err)
+		}
+		if !inRecovery {
+			return errors.New("[replica] read replica is not a standby in recovery")
+		}
+		if replayLSN.Valid {
+			replayed, err := pglogrepl.ParseLSN(replayLSN.String)
+			if err != nil {
+				return fmt.Errorf("[replica] error parsing replay LSN %s: %w", replayLSN.String, err)
+			}
+			if replayed >= lsn {
+				c.logger.Info("[replica] standby replayed past LSN",
+					slog.String("lsn", lsn.String()), slog.String("replayLSN", replayed.String()))
+				return nil
+			}
+			if time.Since(lastLogged) >= time.Minute {
+				c.logger.Info("[replica] waiting for standby to replay LSN",
+					slog.String("lsn", lsn.String()), slog.String("replayLSN", replayed.String()),
+					slog.Uint64("lagBytes", uint64(lsn-replayed)))
+				lastLogged = time.Now()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
 func (c *PostgresConnector) FinishExport(tx any) error {
 	if tx == nil {
 		return nil
@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
 )
@@ -19,3 +20,20 @@ func TestNewPostgresConnectorAuthError(t *testing.T) {
 	var authErr *exceptions.AuthError
 	require.ErrorAs(t, err, &authErr)
 }
+
+func TestReplicaConfig(t *testing.T) {
+	t.Parallel()
+	require.Nil(t, ReplicaConfig(&protos.PostgresConfig{Host: "primary", Port: 5432}))
+
+	primary := &protos.PostgresConfig{Host: "primary", Port: 5432, User: "u", Password: "p", ReplicaHost: "replica"}
+	replica := ReplicaConfig(primary)
+	require.Equal(t, "replica", replica.Host)
+	require.Equal(t, uint32(5432), replica.Port)
+	require.Equal(t, "u", replica.User)
+	require.Equal(t, "p", replica.Password)
+	require.Empty(t, replica.ReplicaHost)
+	require.Equal(t, "primary", primary.Host)
+
+	primary.ReplicaPort = 6432
+	require.Equal(t, uint32(6432), ReplicaConfig(primary).Port)
+}
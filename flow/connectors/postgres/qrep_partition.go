@@ -9,6 +9,8 @@ import (
 	"maps"
 	"math"
 	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -17,15 +19,18 @@ import (
 
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/pkg/common"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 )
 
 type PartitionParams struct {
-	tx               pgx.Tx
-	lastRangeEnd     any
-	logger           log.Logger
-	watermarkTable   string
-	watermarkColumn  string
+	tx              pgx.Tx
+	lastRangeEnd    any
+	logger          log.Logger
+	watermarkTable  string
+	watermarkColumn string
+	// quoted columns of a composite partition key, nil for single column keys
+	keyColumns       []string
 	numPartitions    int64
 	addNullPartition bool
 }
@@ -42,12 +47,7 @@ func NTileBucketPartitioningFunc(ctx context.Context, pp PartitionParams) ([]*pr
 		) subquery
 		GROUP BY bucket
 		ORDER BY start`
-	var whereClause string
-	var queryArgs []any
-	if pp.lastRangeEnd != nil {
-		whereClause = fmt.Sprintf("WHERE %s > $1", pp.watermarkColumn)
-		queryArgs = []any{pp.lastRangeEnd}
-	}
+	whereClause, queryArgs := pp.lastRangeFilter()
 	partitionsQuery := fmt.Sprintf(queryTemplate, pp.numPartitions, pp.watermarkColumn, pp.watermarkTable, whereClause)
 	pp.logger.Info("[NTileBucketPartitioning] partitions query", slog.String("query", partitionsQuery))
 
@@ -78,6 +78,111 @@ func NTileBucketPartitioningFunc(ctx context.Context, pp PartitionParams) ([]*pr
 	return partitionHelper.GetPartitions(), nil
 }
 
+// CompositeKeyPartitioningFunc divides rows into keyset partitions along a composite key such as
+// (tenant_id, id). Like NTileBucketPartitioningFunc it assigns rows to NTILE buckets, the last key
+// of each bucket ends a partition and rows are later selected with row-value comparisons.
+func CompositeKeyPartitioningFunc(ctx context.Context, pp PartitionParams) ([]*protos.QRepPartition, error) {
+	const queryTemplate = `SELECT DISTINCT ON (bucket) %[1]s
+		FROM (
+			SELECT NTILE(%[2]d) OVER (ORDER BY %[3]s) AS bucket, %[3]s FROM %[4]s WHERE %[5]s
+		) subquery
+		ORDER BY bucket, %[6]s`
+	textColumns := make([]string, 0, len(pp.keyColumns))
+	notNull := make([]string, 0, len(pp.keyColumns))
+	descending := make([]string, 0, len(pp.keyColumns))
+	for _, column := range pp.keyColumns {
+		// text is parsed back into the column type when bound as a parameter
+		textColumns = append(textColumns, column+"::text")
+		notNull = append(notNull, column+" IS NOT NULL")
+		descending = append(descending, column+" DESC")
+	}
+	// rows with NULLs in the key never compare, the null partition picks those up
+	filter := strings.Join(notNull, " AND ")
+	resumeFilter, queryArgs := pp.lastRangeFilter()
+	if resumeFilter != "" {
+		filter += " AND " + strings.TrimPrefix(resumeFilter, "WHERE ")
+	}
+	keyColumns := strings.Join(pp.keyColumns, ",")
+	partitionsQuery := fmt.Sprintf(queryTemplate, strings.Join(textColumns, ","), pp.numPartitions,
+		keyColumns, pp.watermarkTable, filter, strings.Join(descending, ","))
+	pp.logger.Info("[CompositeKeyPartitioning] partitions query", slog.String("query", partitionsQuery))
+
+	rows, err := pp.tx.Query(ctx, partitionsQuery, queryArgs...)
+	if err != nil {
+		return nil, shared.LogError(pp.logger, fmt.Errorf("failed to query for partitions: %w", err))
+	}
+	defer rows.Close()
+
+	var bucketEnds [][]*protos.CompositeKeyValue
+	values := make([]string, len(pp.keyColumns))
+	dest := make([]any, len(values))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		end := make([]*protos.CompositeKeyValue, 0, len(values))
+		for _, value := range values {
+			end = append(end, &protos.CompositeKeyValue{Value: &protos.CompositeKeyValue_StringValue{StringValue: value}})
+		}
+		bucketEnds = append(bucketEnds, end)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	after, _ := pp.lastRangeEnd.([]*protos.CompositeKeyValue)
+	partitions := utils.CompositeKeyPartitions(after, bucketEnds)
+	if pp.addNullPartition {
+		partitions = append(partitions, utils.CreateNullPartition())
+	}
+	return partitions, nil
+}
+
+// lastRangeFilter restricts a query to rows past the last partition when resuming
+func (pp PartitionParams) lastRangeFilter() (string, []any) {
+	if pp.lastRangeEnd == nil {
+		return "", nil
+	}
+	if end, ok := pp.lastRangeEnd.([]*protos.CompositeKeyValue); ok {
+		args := make([]any, 0, len(end))
+		placeholders := make([]string, 0, len(end))
+		for _, value := range end {
+			args = append(args, compositeKeyArg(value))
+			placeholders = append(placeholders, "$"+strconv.Itoa(len(args)))
+		}
+		return fmt.Sprintf("WHERE (%s) > (%s)", strings.Join(pp.keyColumns, ","), strings.Join(placeholders, ",")), args
+	}
+	return fmt.Sprintf("WHERE %s > $1", pp.watermarkColumn), []any{pp.lastRangeEnd}
+}
+
+// compositeKeyArg binds a composite key value, text is sent as is for Postgres to parse
+func compositeKeyArg(value *protos.CompositeKeyValue) any {
+	switch v := value.GetValue().(type) {
+	case *protos.CompositeKeyValue_IntValue:
+		return v.IntValue
+	case *protos.CompositeKeyValue_UintValue:
+		return v.UintValue
+	case *protos.CompositeKeyValue_BytesValue:
+		return v.BytesValue
+	case *protos.CompositeKeyValue_StringValue:
+		return v.StringValue
+	default:
+		return nil
+	}
+}
+
+// quoteKeyColumns quotes the columns of a partition key, composite keys list several
+func quoteKeyColumns(partitionKey string) []string {
+	columns := shared.SplitPartitionKey(partitionKey)
+	for i, column := range columns {
+		columns[i] = common.QuoteIdentifier(column)
+	}
+	return columns
+}
+
 // MinMaxRangePartitioningFunc is a table partition strategy where partitions are created
 // by uniformly splitting the min/max value range. Note that partition boundaries are uniform,
 // but actual row distribution may be skewed due to non-uniform data distribution, gaps in the
@@ -88,12 +193,7 @@ func MinMaxRangePartitioningFunc(ctx context.Context, pp PartitionParams) ([]*pr
 	}
 
 	const queryTemplate = "SELECT MIN(%[2]s),MAX(%[2]s) FROM %[1]s %[3]s"
-	var whereClause string
-	var queryArgs []any
-	if pp.lastRangeEnd != nil {
-		whereClause = fmt.Sprintf("WHERE %s > $1", pp.watermarkColumn)
-		queryArgs = []any{pp.lastRangeEnd}
-	}
+	whereClause, queryArgs := pp.lastRangeFilter()
 	partitionsQuery := fmt.Sprintf(queryTemplate, pp.watermarkTable, pp.watermarkColumn, whereClause)
 	pp.logger.Info("[MinMaxRangePartitioning] partitions query", slog.String("query", partitionsQuery))
 
@@ -593,8 +693,7 @@ func ComputeNumPartitions(ctx context.Context, pp PartitionParams, numRowsPerPar
 			}
 		}
 	} else {
-		whereClause = fmt.Sprintf("WHERE %s > $1", pp.watermarkColumn)
-		queryArgs = []any{pp.lastRangeEnd}
+		whereClause, queryArgs = pp.lastRangeFilter()
 	}
 
 	// estimated rows is <= 0 if:
@@ -108,7 +108,8 @@ func (qe *QRepQueryExecutor) cursorToSchema(
 		ctype := qe.postgresOIDToQValueKind(fd.DataTypeOID, qe.customTypeMapping, qe.version)
 
 		if ctype == types.QValueKindNumeric || ctype == types.QValueKindArrayNumeric {
-			precision, scale := common.ParseNumericTypmod(fd.TypeModifier)
+			_, typmod := resolveDomain(fd.DataTypeOID, fd.TypeModifier, qe.customTypeMapping, qe.version)
+			precision, scale := common.ParseNumericTypmod(typmod)
 			qfields[i] = types.QField{
 				Name:      fd.Name,
 				Type:      ctype,
@@ -122,6 +123,9 @@ func (qe *QRepQueryExecutor) cursorToSchema(
 				Type:     ctype,
 				Nullable: laxMode,
 			}
+			if ctype.HasStructFields() {
+				qfields[i].Fields = qe.structQFields(fd.DataTypeOID, qe.customTypeMapping, qe.version)
+			}
 		}
 
 		key := attId{relid: fd.TableOID, num: fd.TableAttributeNumber}
@@ -510,8 +514,12 @@ func (qe *QRepQueryExecutor) mapRowToQRecord(
 ) ([]types.QValue, error) {
 	rawValues := row.RawValues()
 	values := make([]any, len(fds))
+	// domains arrive in text format, decode them with the codec and type modifier of their base type
+	oids := make([]uint32, len(fds))
+	typmods := make([]int32, len(fds))
 	// Simulate the behavior of rows.Values() with a carveout for JSON
 	for i, fd := range fds {
+		oids[i], typmods[i] = resolveDomain(fd.DataTypeOID, fd.TypeModifier, qe.customTypeMapping, qe.version)
 		buf := rawValues[i]
 		if buf == nil {
 			values[i] = nil
@@ -519,7 +527,7 @@ func (qe *QRepQueryExecutor) mapRowToQRecord(
 		}
 
 		// Special handling for JSON types
-		switch fd.DataTypeOID {
+		switch oids[i] {
 		case pgtype.JSONOID, pgtype.JSONBOID:
 			if err := jsonApi.Unmarshal(buf, &values[i]); err != nil {
 				qe.logger.Error("[pg_query_executor] failed to unmarshal json", slog.Any("error", err))
@@ -531,7 +539,7 @@ func (qe *QRepQueryExecutor) mapRowToQRecord(
 			}
 		case pgtype.JSONArrayOID, pgtype.JSONBArrayOID:
 			var textArr pgtype.FlatArray[pgtype.Text]
-			if err := qe.conn.TypeMap().Scan(fd.DataTypeOID, fd.Format, buf, &textArr); err != nil {
+			if err := qe.conn.TypeMap().Scan(oids[i], fd.Format, buf, &textArr); err != nil {
 				qe.logger.Error("[pg_query_executor] failed to to scan json array", slog.Any("error", err))
 				return nil, fmt.Errorf("failed to scan json array: %w", err)
 			}
@@ -550,13 +558,21 @@ func (qe *QRepQueryExecutor) mapRowToQRecord(
 			values[i] = arr
 
 		default:
-			if dt, ok := qe.conn.TypeMap().TypeForOID(fd.DataTypeOID); ok {
-				value, err := dt.Codec.DecodeValue(qe.conn.TypeMap(), fd.DataTypeOID, fd.Format, buf)
+			if dt, ok := qe.conn.TypeMap().TypeForOID(oids[i]); ok {
+				value, err := dt.Codec.DecodeValue(qe.conn.TypeMap(), oids[i], fd.Format, buf)
 				if err != nil {
 					qe.logger.Error("[pg_query_executor] failed to decode value", slog.Any("error", err))
 					return nil, fmt.Errorf("failed to decode value: %w", err)
 				}
 				values[i] = value
+			} else if typeData, ok := qe.customTypeMapping[oids[i]]; ok && fd.Format == pgtype.TextFormatCode &&
+				CustomTypeToQKind(typeData, qe.version) == types.QValueKindStruct {
+				value, err := qe.decodeCompositeText(string(buf), typeData, dstType, qe.customTypeMapping, qe.version, jsonApi)
+				if err != nil {
+					qe.logger.Error("[pg_query_executor] failed to decode composite", slog.Any("error", err))
+					return nil, fmt.Errorf("failed to decode composite: %w", err)
+				}
+				values[i] = value
 			} else {
 				// Unknown type - treat as text or binary based on format
 				switch fd.Format {
@@ -578,8 +594,8 @@ func (qe *QRepQueryExecutor) mapRowToQRecord(
 	for i, fd := range fds {
 		_, nullable := nullableFields[fd.Name]
 		tmp, err := qe.parseFieldFromPostgresOID(
-			fd.DataTypeOID,
-			fd.TypeModifier,
+			oids[i],
+			typmods[i],
 			nullable,
 			dstType,
 			values[i],
@@ -14,6 +14,7 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/pkg/common"
 	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
 func setupDB(t *testing.T, testName string) (*PostgresConnector, string) {
@@ -674,7 +675,8 @@ func TestStringDataTypes(t *testing.T) {
 			_, err = conn.Exec(ctx, query)
 			require.NoError(t, err)
 
-			qe, err := connector.NewQRepQueryExecutor(ctx, nil, shared.InternalVersion_Latest, "test flow", "test part")
+			// ranges and multiranges are only strings for mirrors from before they were replicated as structs
+			qe, err := connector.NewQRepQueryExecutor(ctx, nil, shared.InternalVersion_PgRangesAsStructs-1, "test flow", "test part")
 			require.NoError(t, err)
 			// Select the row back out of the table
 			batch, err := qe.ExecuteAndProcessQuery(t.Context(),
@@ -694,3 +696,46 @@ func TestStringDataTypes(t *testing.T) {
 		})
 	}
 }
+
+func TestRangeDataTypes(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	connector, schemaName := setupDB(t, "range")
+	conn := connector.conn
+	defer conn.Close(ctx)
+	defer teardownDB(t, conn, schemaName)
+
+	_, err := conn.Exec(ctx, fmt.Sprintf(
+		"CREATE TABLE %s.test(col_int4 int4range, col_date daterange, col_empty numrange, col_multi int4multirange)",
+		common.QuoteIdentifier(schemaName)))
+	require.NoError(t, err)
+	_, err = conn.Exec(ctx, fmt.Sprintf(
+		"INSERT INTO %s.test VALUES ('[1,10]', '(,2024-01-31]', 'empty', '{[1,3),[5,)}')",
+		common.QuoteIdentifier(schemaName)))
+	require.NoError(t, err)
+
+	qe, err := connector.NewQRepQueryExecutor(ctx, nil, shared.InternalVersion_Latest, "test flow", "test part")
+	require.NoError(t, err)
+	batch, err := qe.ExecuteAndProcessQuery(t.Context(),
+		fmt.Sprintf("SELECT * FROM %s.test", common.QuoteIdentifier(schemaName)))
+	require.NoError(t, err)
+	require.Len(t, batch.Records, 1)
+	require.Equal(t, types.QValueKindStruct, batch.Schema.Fields[0].Type)
+	require.Len(t, batch.Schema.Fields[0].Fields, 5)
+
+	record := batch.Records[0]
+	require.Equal(t, map[string]any{"lower": int32(1), "upper": int32(11), "lower_inc": true, "upper_inc": false, "empty": false},
+		record[0].Value())
+	require.Equal(t, map[string]any{
+		"lower": nil, "upper": time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), "lower_inc": false, "upper_inc": false, "empty": false,
+	}, record[1].Value())
+	require.Equal(t, map[string]any{"lower": nil, "upper": nil, "lower_inc": false, "upper_inc": false, "empty": true},
+		record[2].Value())
+	require.Equal(t, types.QValueKindArrayStruct, batch.Schema.Fields[3].Type)
+	require.Len(t, batch.Schema.Fields[3].Fields, 5)
+	require.Equal(t, []any{
+		map[string]any{"lower": int32(1), "upper": int32(3), "lower_inc": true, "upper_inc": false, "empty": false},
+		map[string]any{"lower": int32(5), "upper": nil, "lower_inc": true, "upper_inc": false, "empty": false},
+	}, record[3].Value())
+}
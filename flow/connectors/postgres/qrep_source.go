@@ -98,7 +98,17 @@ func (c *PostgresConnector) GetDefaultPartitionKeyForTables(
 	}
 
 	if !supportsTidScans {
-		// older versions fall back to full table partitions anyway; nothing more to do
+		// older versions fall back to full table partitions, except tables with a composite
+		// primary key which are still split along the key
+		for _, tm := range input.TableMappings {
+			schema, ok := input.TableSchemaMapping[tm.SourceTableIdentifier]
+			if !ok || len(schema.PrimaryKeyColumns) < 2 {
+				continue
+			}
+			if key, ok := shared.JoinPartitionKey(schema.PrimaryKeyColumns); ok {
+				output.TableDefaultPartitionKeyMapping[tm.SourceTableIdentifier] = key
+			}
+		}
 		c.logger.Warn("Postgres version does not support TID scans, falling back to full table partitions")
 		return output, nil
 	}
@@ -168,6 +178,10 @@ func (c *PostgresConnector) getPartitions(
 	}
 	watermarkTable := schemaTable.String()
 	watermarkColumn := common.QuoteIdentifier(config.WatermarkColumn)
+	var keyColumns []string
+	if columns := quoteKeyColumns(config.WatermarkColumn); len(columns) > 1 {
+		keyColumns = columns
+	}
 
 	var lastRangeEnd any
 	if last != nil && last.Range != nil {
@@ -184,6 +198,12 @@ func (c *PostgresConnector) getPartitions(
 				OffsetNumber: uint16(lastRange.TidRange.End.OffsetNumber),
 				Valid:        true,
 			}
+		case *protos.PartitionRange_CompositeKeyRange:
+			if len(lastRange.CompositeKeyRange.End) != len(keyColumns) {
+				return nil, fmt.Errorf("last partition ends on %d key columns, partition key %s has %d",
+					len(lastRange.CompositeKeyRange.End), config.WatermarkColumn, len(keyColumns))
+			}
+			lastRangeEnd = lastRange.CompositeKeyRange.End
 		default:
 			return nil, fmt.Errorf("unknown range type %T", lastRange)
 		}
@@ -193,6 +213,7 @@ func (c *PostgresConnector) getPartitions(
 		tx:               tx,
 		watermarkTable:   watermarkTable,
 		watermarkColumn:  watermarkColumn,
+		keyColumns:       keyColumns,
 		numPartitions:    numPartitions,
 		lastRangeEnd:     lastRangeEnd,
 		logger:           c.logger,
@@ -221,6 +242,9 @@ func (c *PostgresConnector) getPartitions(
 	var partitionFunc PartitioningFunc
 	var partitionFuncName string
 	switch {
+	case keyColumns != nil:
+		partitionFunc = CompositeKeyPartitioningFunc
+		partitionFuncName = "CompositeKeyPartitioningFunc"
 	case isCTIDWatermarkCol && (hasCTIDOverride || hasPartitionOverride):
 		partitionFunc = CTIDBlockPartitioningFunc
 		partitionFuncName = "CTIDBlockPartitioningFunc"
@@ -331,10 +355,65 @@ func (c *PostgresConnector) PullQRepRecords(
 	partition *protos.QRepPartition,
 	stream *model.QRecordStream,
 ) (int64, int64, error) {
-	return corePullQRepRecords(c, ctx, config, partition, &RecordStreamSink{
+	sink := &RecordStreamSink{
 		QRecordStream:   stream,
 		DestinationType: dstType,
-	})
+	}
+	if interval := stream.ResumePointInterval(); interval > 0 && c.SupportsQRepResumePoints(config, partition) {
+		return c.pullQRepRecordsWithResumePoints(ctx, config, partition, sink, interval)
+	}
+	return corePullQRepRecords(c, ctx, config, partition, sink)
+}
+
+// SupportsQRepResumePoints returns true for ranges that can be pulled as a sequence of sub-ranges
+func (c *PostgresConnector) SupportsQRepResumePoints(_ *protos.QRepConfig, partition *protos.QRepPartition) bool {
+	if partition.FullTablePartition || len(partition.ChildTableRanges) > 0 || partition.Range == nil {
+		return false
+	}
+	switch partition.Range.Range.(type) {
+	case *protos.PartitionRange_IntRange, *protos.PartitionRange_TimestampRange, *protos.PartitionRange_TidRange:
+		return true
+	default:
+		return false
+	}
+}
+
+// pullQRepRecordsWithResumePoints pulls the partition one sub-range at a time, rows are not ordered
+// within a range scan, so the rest of the partition is a resume point only once a sub-range is done
+func (c *PostgresConnector) pullQRepRecordsWithResumePoints(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	sink *RecordStreamSink,
+	interval int64,
+) (int64, int64, error) {
+	numPieces := max(shared.DivCeil(int64(config.NumRowsPerPartition), interval), 2)
+	pieces, err := utils.SplitPartition(c.logger, partition, numPieces)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to split partition into sub-ranges: %w", err)
+	}
+	if len(pieces) == 0 {
+		return corePullQRepRecords(c, ctx, config, partition, sink)
+	}
+
+	var totalRecords, totalBytes int64
+	for i, piece := range pieces {
+		piece.PartitionId = partition.PartitionId
+		numRecords, numBytes, err := corePullQRepRecords(c, ctx, config, piece, sink)
+		totalRecords += numRecords
+		totalBytes += numBytes
+		if err != nil {
+			return totalRecords, totalBytes, err
+		}
+		if i+1 < len(pieces) {
+			remaining, err := utils.SpanPartitionRanges(pieces[i+1].Range, partition.Range)
+			if err != nil {
+				return totalRecords, totalBytes, err
+			}
+			sink.SetResumePoint(remaining)
+		}
+	}
+	return totalRecords, totalBytes, nil
 }
 
 func (c *PostgresConnector) PullPgQRepRecords(
@@ -432,13 +511,27 @@ func corePullQRepRecords(
 			OffsetNumber: uint16(x.TidRange.End.OffsetNumber),
 			Valid:        true,
 		}
+	case *protos.PartitionRange_CompositeKeyRange:
+		if config.Query != "" {
+			return 0, 0, errors.New("can't construct a composite key partition for custom queries")
+		}
+		condition, err := utils.CompositeKeyCondition(quoteKeyColumns(config.WatermarkColumn), x.CompositeKeyRange,
+			func(value *protos.CompositeKeyValue) string {
+				queryArgs = append(queryArgs, compositeKeyArg(value))
+				return "$" + strconv.Itoa(len(queryArgs))
+			})
+		if err != nil {
+			return 0, 0, err
+		}
+		queryTemplate = fmt.Sprintf("SELECT %s FROM %s WHERE %s", selectedColumns, parsedSrcTable.String(), condition)
+		templateParams = map[string]string{}
 	case *protos.PartitionRange_NullRange:
 		if config.Query != "" {
 			return 0, 0, errors.New("can't construct a null range partition for custom queries")
 		}
 		queryTemplate = fmt.Sprintf(
-			"SELECT %s FROM %s WHERE %s IS NULL",
-			selectedColumns, parsedSrcTable.String(), common.QuoteIdentifier(config.WatermarkColumn),
+			"SELECT %s FROM %s WHERE %s",
+			selectedColumns, parsedSrcTable.String(), utils.NullKeyCondition(quoteKeyColumns(config.WatermarkColumn)),
 		)
 		templateParams = map[string]string{}
 	default:
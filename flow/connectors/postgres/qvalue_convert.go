@@ -69,7 +69,7 @@ func qValueKindToPostgresType(colTypeStr string) string {
 		return "BYTEA"
 	case types.QValueKindJSON:
 		return "JSON"
-	case types.QValueKindJSONB:
+	case types.QValueKindJSONB, types.QValueKindStruct, types.QValueKindArrayStruct, types.QValueKindMap:
 		return "JSONB"
 	case types.QValueKindHStore:
 		return "HSTORE"
@@ -134,6 +134,18 @@ func qValueKindToPostgresType(colTypeStr string) string {
 	}
 }
 
+// columnToPostgresType is qValueKindToPostgresType for a column, struct columns holding a range keep their range type
+// and array of structs columns holding a multirange keep their multirange type
+func columnToPostgresType(column *protos.FieldDescription) string {
+	if rangeType, _, ok := postgresRangeType(column); ok {
+		return rangeType
+	}
+	if multirangeType, _, _, ok := postgresMultirangeType(column); ok {
+		return multirangeType
+	}
+	return qValueKindToPostgresType(column.Type)
+}
+
 func parseJSON(value any, isArray bool) (types.QValue, error) {
 	jsonVal, err := json.Marshal(value)
 	if err != nil {
@@ -729,6 +741,18 @@ func (c *PostgresConnector) parseFieldFromPostgresOID(
 		}, nil
 	case types.QValueKindHStore:
 		return types.QValueHStore{Val: fmt.Sprint(value)}, nil
+	case types.QValueKindStruct:
+		// composites are decoded from their text output before reaching here
+		switch v := value.(type) {
+		case types.QValueStruct:
+			return v, nil
+		case pgtype.Range[any]:
+			return c.rangeToStruct(oid, v, dstType, customTypeMapping, version)
+		}
+	case types.QValueKindArrayStruct:
+		if multirange, ok := value.(pgtype.Multirange[pgtype.Range[any]]); ok {
+			return c.multirangeToArray(oid, multirange, dstType, customTypeMapping, version)
+		}
 	case types.QValueKindGeography, types.QValueKindGeometry:
 		wkbString, ok := value.(string)
 		wkt, err := datatypes.GeoValidate(wkbString)
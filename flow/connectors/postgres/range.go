@@ -0,0 +1,198 @@
+package connpostgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model/qvalue"
+	pkg_pg "github.com/PeerDB-io/peerdb/flow/pkg/postgres"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// rangeElementOIDs maps built-in range types to the type of their bounds
+var rangeElementOIDs = map[uint32]uint32{
+	pgtype.Int4rangeOID: pgtype.Int4OID,
+	pgtype.Int8rangeOID: pgtype.Int8OID,
+	pgtype.NumrangeOID:  pgtype.NumericOID,
+	pgtype.DaterangeOID: pgtype.DateOID,
+	pgtype.TsrangeOID:   pgtype.TimestampOID,
+	pgtype.TstzrangeOID: pgtype.TimestamptzOID,
+}
+
+// multirangeRangeOIDs maps built-in multirange types to the range type of their members
+var multirangeRangeOIDs = map[uint32]uint32{
+	pgtype.Int4multirangeOID: pgtype.Int4rangeOID,
+	pgtype.Int8multirangeOID: pgtype.Int8rangeOID,
+	pgtype.NummultirangeOID:  pgtype.NumrangeOID,
+	pgtype.DatemultirangeOID: pgtype.DaterangeOID,
+	pgtype.TsmultirangeOID:   pgtype.TsrangeOID,
+	pgtype.TstzmultirangeOID: pgtype.TstzrangeOID,
+}
+
+// postgresRangeTypes maps the bound kind of range structs to the range type they are recreated as on Postgres
+var postgresRangeTypes = map[types.QValueKind]string{
+	types.QValueKindInt32:       "int4range",
+	types.QValueKindInt64:       "int8range",
+	types.QValueKindNumeric:     "numrange",
+	types.QValueKindDate:        "daterange",
+	types.QValueKindTimestamp:   "tsrange",
+	types.QValueKindTimestampTZ: "tstzrange",
+}
+
+// postgresRangeType returns the native range type for struct columns holding a range
+func postgresRangeType(column *protos.FieldDescription) (string, types.QValueKind, bool) {
+	elementKind, ok := qvalue.RangeElementKind(column)
+	if !ok {
+		return "", "", false
+	}
+	rangeType, ok := postgresRangeTypes[elementKind]
+	return rangeType, elementKind, ok
+}
+
+// postgresMultirangeType returns the native multirange type and the type of its ranges
+// for array of structs columns holding the ranges of a multirange
+func postgresMultirangeType(column *protos.FieldDescription) (string, string, types.QValueKind, bool) {
+	elementKind, ok := qvalue.MultirangeElementKind(column)
+	if !ok {
+		return "", "", "", false
+	}
+	rangeType, ok := postgresRangeTypes[elementKind]
+	return strings.TrimSuffix(rangeType, "range") + "multirange", rangeType, elementKind, ok
+}
+
+// rangeToStruct replicates a range as its bounds and whether each bound is inclusive,
+// infinite bounds become unbounded and discrete ranges are canonicalized like Postgres does, to [lower, upper)
+func (c *PostgresConnector) rangeToStruct(
+	oid uint32,
+	r pgtype.Range[any],
+	dstType protos.DBType,
+	customTypeMapping map[uint32]pkg_pg.CustomDataType,
+	version uint32,
+) (types.QValue, error) {
+	elementOID, ok := rangeElementOIDs[oid]
+	if !ok {
+		return nil, fmt.Errorf("unsupported range type %d", oid)
+	}
+	if !r.Valid {
+		return types.QValueNull(types.QValueKindStruct), nil
+	}
+	if r.LowerType == pgtype.Empty {
+		return types.NewQValueEmptyRange(c.postgresOIDToQValueKind(elementOID, customTypeMapping, version)), nil
+	}
+
+	bound := func(val any, boundType pgtype.BoundType) (types.QValue, error) {
+		if boundType == pgtype.Unbounded {
+			val = nil
+		}
+		return c.parseFieldFromPostgresOID(elementOID, -1, true, dstType, val, customTypeMapping, version)
+	}
+	lower, err := bound(r.Lower, r.LowerType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse lower bound of range: %w", err)
+	}
+	upper, err := bound(r.Upper, r.UpperType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse upper bound of range: %w", err)
+	}
+	lowerInc := r.LowerType == pgtype.Inclusive && lower.Value() != nil
+	upperInc := r.UpperType == pgtype.Inclusive && upper.Value() != nil
+	if next, ok := nextDiscreteValue(lower); ok && !lowerInc {
+		lower, lowerInc = next, true
+	}
+	if next, ok := nextDiscreteValue(upper); ok && upperInc {
+		upper, upperInc = next, false
+	}
+	if lower.Value() != nil && upper.Value() != nil && lowerInc && !upperInc && !discreteBefore(lower, upper) {
+		// like (1,2), nothing lies between the bounds
+		return types.NewQValueEmptyRange(lower.Kind()), nil
+	}
+	return types.NewQValueRange(lower, upper, lowerInc, upperInc), nil
+}
+
+// nextDiscreteValue returns the value following a bound of a discrete range, false for null and continuous bounds
+func nextDiscreteValue(bound types.QValue) (types.QValue, bool) {
+	switch v := bound.(type) {
+	case types.QValueInt32:
+		return types.QValueInt32{Val: v.Val + 1}, true
+	case types.QValueInt64:
+		return types.QValueInt64{Val: v.Val + 1}, true
+	case types.QValueDate:
+		return types.QValueDate{Val: v.Val.AddDate(0, 0, 1)}, true
+	default:
+		return nil, false
+	}
+}
+
+// discreteBefore compares bounds of a discrete range, continuous bounds are not compared
+func discreteBefore(lower types.QValue, upper types.QValue) bool {
+	switch l := lower.(type) {
+	case types.QValueInt32:
+		return l.Val < upper.(types.QValueInt32).Val
+	case types.QValueInt64:
+		return l.Val < upper.(types.QValueInt64).Val
+	case types.QValueDate:
+		return l.Val.Before(upper.(types.QValueDate).Val)
+	default:
+		return true
+	}
+}
+
+// multirangeToArray replicates a multirange as an array of its ranges, each shaped like rangeToStruct
+func (c *PostgresConnector) multirangeToArray(
+	oid uint32,
+	multirange pgtype.Multirange[pgtype.Range[any]],
+	dstType protos.DBType,
+	customTypeMapping map[uint32]pkg_pg.CustomDataType,
+	version uint32,
+) (types.QValue, error) {
+	rangeOID, ok := multirangeRangeOIDs[oid]
+	if !ok {
+		return nil, fmt.Errorf("unsupported multirange type %d", oid)
+	}
+	ranges := make([]types.QValueStruct, 0, len(multirange))
+	for _, r := range multirange {
+		val, err := c.rangeToStruct(rangeOID, r, dstType, customTypeMapping, version)
+		if err != nil {
+			return nil, err
+		}
+		if structVal, ok := val.(types.QValueStruct); ok {
+			ranges = append(ranges, structVal)
+		}
+	}
+	return types.QValueArrayStruct{Val: ranges}, nil
+}
+
+// rangeFieldDescriptions describes the members of the struct a range is replicated as
+func (c *PostgresConnector) rangeFieldDescriptions(
+	oid uint32,
+	customTypeMapping map[uint32]pkg_pg.CustomDataType,
+	version uint32,
+) []*protos.FieldDescription {
+	elementKind := c.postgresOIDToQValueKind(rangeElementOIDs[oid], customTypeMapping, version)
+	return []*protos.FieldDescription{
+		{Name: types.RangeLowerMember, Type: string(elementKind), TypeModifier: -1, Nullable: true},
+		{Name: types.RangeUpperMember, Type: string(elementKind), TypeModifier: -1, Nullable: true},
+		{Name: types.RangeLowerIncMember, Type: string(types.QValueKindBoolean), TypeModifier: -1},
+		{Name: types.RangeUpperIncMember, Type: string(types.QValueKindBoolean), TypeModifier: -1},
+		{Name: types.RangeEmptyMember, Type: string(types.QValueKindBoolean), TypeModifier: -1},
+	}
+}
+
+// rangeQFields is rangeFieldDescriptions for QRep schemas
+func (c *PostgresConnector) rangeQFields(
+	oid uint32,
+	customTypeMapping map[uint32]pkg_pg.CustomDataType,
+	version uint32,
+) []types.QField {
+	elementKind := c.postgresOIDToQValueKind(rangeElementOIDs[oid], customTypeMapping, version)
+	return []types.QField{
+		{Name: types.RangeLowerMember, Type: elementKind, Nullable: true},
+		{Name: types.RangeUpperMember, Type: elementKind, Nullable: true},
+		{Name: types.RangeLowerIncMember, Type: types.QValueKindBoolean},
+		{Name: types.RangeUpperIncMember, Type: types.QValueKindBoolean},
+		{Name: types.RangeEmptyMember, Type: types.QValueKindBoolean},
+	}
+}
@@ -0,0 +1,345 @@
+package connpostgres
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestRangeKinds(t *testing.T) {
+	t.Parallel()
+	typeMap := pgtype.NewMap()
+
+	kind, err := PostgresOIDToQValueKind(pgtype.Int4rangeOID, nil, typeMap, shared.InternalVersion_Latest)
+	require.NoError(t, err)
+	require.Equal(t, types.QValueKindStruct, kind)
+	kind, err = PostgresOIDToQValueKind(pgtype.TstzmultirangeOID, nil, typeMap, shared.InternalVersion_Latest)
+	require.NoError(t, err)
+	require.Equal(t, types.QValueKindArrayStruct, kind)
+
+	// existing mirrors keep ranges as strings
+	kind, _ = PostgresOIDToQValueKind(pgtype.Int4rangeOID, nil, typeMap, shared.InternalVersion_PgRangesAsStructs-1)
+	require.Equal(t, types.QValueKindString, kind)
+	// arrays of ranges are unchanged
+	oldKind, _ := PostgresOIDToQValueKind(pgtype.Int4rangeArrayOID, nil, typeMap, shared.InternalVersion_PgRangesAsStructs-1)
+	kind, _ = PostgresOIDToQValueKind(pgtype.Int4rangeArrayOID, nil, typeMap, shared.InternalVersion_Latest)
+	require.Equal(t, oldKind, kind)
+}
+
+func TestRangeToStruct(t *testing.T) {
+	t.Parallel()
+	c := &PostgresConnector{typeMap: pgtype.NewMap(), hushWarnOID: make(map[uint32]struct{})}
+
+	for _, tc := range []struct {
+		expected types.QValue
+		literal  string
+		oid      uint32
+	}{
+		{
+			oid:      pgtype.Int4rangeOID,
+			literal:  "[1,10)",
+			expected: types.NewQValueRange(types.QValueInt32{Val: 1}, types.QValueInt32{Val: 10}, true, false),
+		},
+		{
+			oid:     pgtype.Int8rangeOID,
+			literal: "(,5]",
+			expected: types.NewQValueRange(
+				types.QValueNull(types.QValueKindInt64), types.QValueInt64{Val: 6}, false, false),
+		},
+		{
+			oid:     pgtype.NumrangeOID,
+			literal: "[1.5,2.25]",
+			expected: types.NewQValueRange(
+				types.QValueNumeric{Val: decimal.RequireFromString("1.5")},
+				types.QValueNumeric{Val: decimal.RequireFromString("2.25")}, true, true),
+		},
+		{
+			oid:     pgtype.DaterangeOID,
+			literal: "[2024-01-01,infinity)",
+			expected: types.NewQValueRange(
+				types.QValueDate{Val: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+				types.QValueNull(types.QValueKindDate), true, false),
+		},
+		{
+			oid:     pgtype.TstzrangeOID,
+			literal: `["2024-01-01 00:00:00+00","2024-01-02 00:00:00+00")`,
+			expected: types.NewQValueRange(
+				types.QValueTimestampTZ{Val: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+				types.QValueTimestampTZ{Val: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}, true, false),
+		},
+		{
+			// discrete ranges are canonicalized to [lower, upper)
+			oid:     pgtype.DaterangeOID,
+			literal: "[2024-01-01,2024-01-05]",
+			expected: types.NewQValueRange(
+				types.QValueDate{Val: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+				types.QValueDate{Val: time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC)}, true, false),
+		},
+		{
+			oid:     pgtype.DaterangeOID,
+			literal: "(2024-01-01,2024-01-05)",
+			expected: types.NewQValueRange(
+				types.QValueDate{Val: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+				types.QValueDate{Val: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)}, true, false),
+		},
+		{
+			oid:     pgtype.DaterangeOID,
+			literal: "(,2024-01-05]",
+			expected: types.NewQValueRange(
+				types.QValueNull(types.QValueKindDate),
+				types.QValueDate{Val: time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC)}, false, false),
+		},
+		{
+			oid:      pgtype.Int8rangeOID,
+			literal:  "(1,2)",
+			expected: types.NewQValueEmptyRange(types.QValueKindInt64),
+		},
+		{
+			// continuous ranges keep their bounds
+			oid:     pgtype.TsrangeOID,
+			literal: `("2024-01-01 00:00:00","2024-01-02 00:00:00"]`,
+			expected: types.NewQValueRange(
+				types.QValueTimestamp{Val: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+				types.QValueTimestamp{Val: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}, false, true),
+		},
+		{
+			oid:      pgtype.Int4rangeOID,
+			literal:  "empty",
+			expected: types.NewQValueEmptyRange(types.QValueKindInt32),
+		},
+		{
+			oid:     pgtype.Int4rangeOID,
+			literal: "(,)",
+			expected: types.NewQValueRange(
+				types.QValueNull(types.QValueKindInt32), types.QValueNull(types.QValueKindInt32), false, false),
+		},
+	} {
+		dt, ok := c.typeMap.TypeForOID(tc.oid)
+		require.True(t, ok)
+		value, err := dt.Codec.DecodeValue(c.typeMap, tc.oid, pgtype.TextFormatCode, []byte(tc.literal))
+		require.NoError(t, err)
+		qv, err := c.parseFieldFromPostgresOID(tc.oid, -1, true, protos.DBType_DBTYPE_UNKNOWN, value, nil, shared.InternalVersion_Latest)
+		require.NoError(t, err)
+		if expected, ok := tc.expected.(types.QValueStruct); ok {
+			actual, ok := qv.(types.QValueStruct)
+			require.True(t, ok, tc.literal)
+			require.Len(t, actual.Val, len(expected.Val))
+			for i, member := range expected.Val {
+				require.Equal(t, member.Name, actual.Val[i].Name)
+				require.Equal(t, member.Val.Kind(), actual.Val[i].Val.Kind(), "%s %s", tc.literal, member.Name)
+				switch val := member.Val.(type) {
+				case types.QValueNumeric:
+					require.True(t, val.Val.Equal(actual.Val[i].Val.(types.QValueNumeric).Val), tc.literal)
+				case types.QValueTimestampTZ:
+					require.True(t, val.Val.Equal(actual.Val[i].Val.(types.QValueTimestampTZ).Val), tc.literal)
+				case types.QValueTimestamp:
+					require.True(t, val.Val.Equal(actual.Val[i].Val.(types.QValueTimestamp).Val), tc.literal)
+				case types.QValueDate:
+					require.True(t, val.Val.Equal(actual.Val[i].Val.(types.QValueDate).Val), tc.literal)
+				default:
+					require.Equal(t, member.Val.Value(), actual.Val[i].Val.Value(), "%s %s", tc.literal, member.Name)
+				}
+			}
+		} else {
+			require.Equal(t, tc.expected, qv, tc.literal)
+		}
+	}
+}
+
+func TestMultirangeToArray(t *testing.T) {
+	t.Parallel()
+	c := &PostgresConnector{typeMap: pgtype.NewMap(), hushWarnOID: make(map[uint32]struct{})}
+	date := func(s string) types.QValue {
+		d, err := time.Parse(time.DateOnly, s)
+		require.NoError(t, err)
+		return types.QValueDate{Val: d}
+	}
+
+	for _, tc := range []struct {
+		literal  string
+		expected []types.QValueStruct
+		oid      uint32
+	}{
+		{
+			oid:     pgtype.Int4multirangeOID,
+			literal: "{[1,3),[5,)}",
+			expected: []types.QValueStruct{
+				types.NewQValueRange(types.QValueInt32{Val: 1}, types.QValueInt32{Val: 3}, true, false),
+				types.NewQValueRange(types.QValueInt32{Val: 5}, types.QValueNull(types.QValueKindInt32), true, false),
+			},
+		},
+		{
+			oid:     pgtype.NummultirangeOID,
+			literal: "{[1.5,2.5]}",
+			expected: []types.QValueStruct{types.NewQValueRange(
+				types.QValueNumeric{Val: decimal.RequireFromString("1.5")},
+				types.QValueNumeric{Val: decimal.RequireFromString("2.5")}, true, true)},
+		},
+		{
+			oid:      pgtype.DatemultirangeOID,
+			literal:  "{[2024-01-01,2024-02-01)}",
+			expected: []types.QValueStruct{types.NewQValueRange(date("2024-01-01"), date("2024-02-01"), true, false)},
+		},
+		{
+			oid:      pgtype.Int8multirangeOID,
+			literal:  "{}",
+			expected: []types.QValueStruct{},
+		},
+	} {
+		dt, ok := c.typeMap.TypeForOID(tc.oid)
+		require.True(t, ok)
+		value, err := dt.Codec.DecodeValue(c.typeMap, tc.oid, pgtype.TextFormatCode, []byte(tc.literal))
+		require.NoError(t, err)
+		qv, err := c.parseFieldFromPostgresOID(tc.oid, -1, true, protos.DBType_DBTYPE_UNKNOWN, value, nil, shared.InternalVersion_Latest)
+		require.NoError(t, err)
+		arr, ok := qv.(types.QValueArrayStruct)
+		require.True(t, ok, tc.literal)
+		require.Len(t, arr.Val, len(tc.expected), tc.literal)
+		for i, expected := range tc.expected {
+			expectedBounds, _ := expected.RangeBounds()
+			bounds, ok := arr.Val[i].RangeBounds()
+			require.True(t, ok, tc.literal)
+			require.Equal(t, expectedBounds.LowerInc, bounds.LowerInc, tc.literal)
+			require.Equal(t, expectedBounds.UpperInc, bounds.UpperInc, tc.literal)
+			require.Equal(t, expectedBounds.Lower.Kind(), bounds.Lower.Kind(), tc.literal)
+			require.Equal(t, expectedBounds.Upper.Kind(), bounds.Upper.Kind(), tc.literal)
+			require.Equal(t, fmt.Sprint(expectedBounds.Lower.Value()), fmt.Sprint(bounds.Lower.Value()), tc.literal)
+			require.Equal(t, fmt.Sprint(expectedBounds.Upper.Value()), fmt.Sprint(bounds.Upper.Value()), tc.literal)
+		}
+	}
+}
+
+func TestRangeColumnsOnPostgres(t *testing.T) {
+	t.Parallel()
+	c := &PostgresConnector{typeMap: pgtype.NewMap(), hushWarnOID: make(map[uint32]struct{})}
+	column := &protos.FieldDescription{
+		Name:   "during",
+		Type:   string(types.QValueKindStruct),
+		Fields: c.structFieldDescriptions(pgtype.TsrangeOID, nil, shared.InternalVersion_Latest),
+	}
+	require.Equal(t, "tsrange", columnToPostgresType(column))
+	require.Equal(t, "JSONB", columnToPostgresType(&protos.FieldDescription{
+		Name:   "address",
+		Type:   string(types.QValueKindStruct),
+		Fields: []*protos.FieldDescription{{Name: "zip", Type: string(types.QValueKindInt32)}},
+	}))
+
+	n := normalizeStmtGenerator{}
+	expr := n.generateExpr(&protos.TableSchema{System: protos.TypeSystem_Q}, column, "'during'", "tsrange")
+	require.Equal(t, "CASE WHEN _peerdb_data->>'during' IS NULL THEN NULL "+
+		"WHEN (_peerdb_data->'during'->>'empty')::boolean THEN 'empty'::tsrange ELSE tsrange("+
+		"(_peerdb_data->'during'->>'lower')::TIMESTAMP,(_peerdb_data->'during'->>'upper')::TIMESTAMP,"+
+		"CASE WHEN (_peerdb_data->'during'->>'lower_inc')::boolean THEN '[' ELSE '(' END||"+
+		"CASE WHEN (_peerdb_data->'during'->>'upper_inc')::boolean THEN ']' ELSE ')' END) END", expr)
+
+	// multiranges keep their multirange type and are aggregated from their ranges
+	column = &protos.FieldDescription{
+		Name:   "slots",
+		Type:   string(types.QValueKindArrayStruct),
+		Fields: c.structFieldDescriptions(pgtype.Int4multirangeOID, nil, shared.InternalVersion_Latest),
+	}
+	require.Equal(t, "int4multirange", columnToPostgresType(column))
+	expr = n.generateExpr(&protos.TableSchema{System: protos.TypeSystem_Q}, column, "'slots'", "int4multirange")
+	require.Equal(t, "CASE WHEN _peerdb_data->>'slots' IS NULL THEN NULL ELSE (SELECT COALESCE(range_agg(CASE "+
+		"WHEN (r->>'empty')::boolean THEN 'empty'::int4range ELSE int4range("+
+		"(r->>'lower')::INTEGER,(r->>'upper')::INTEGER,"+
+		"CASE WHEN (r->>'lower_inc')::boolean THEN '[' ELSE '(' END||"+
+		"CASE WHEN (r->>'upper_inc')::boolean THEN ']' ELSE ')' END) END),'{}'::int4multirange) "+
+		"FROM jsonb_array_elements(_peerdb_data->'slots') r) END", expr)
+}
+
+// the timestamp policy of a mirror applies to values decoded from Postgres, range bounds included
+func TestTimestampPolicyOnDecodedValues(t *testing.T) {
+	t.Parallel()
+	c := &PostgresConnector{typeMap: pgtype.NewMap(), hushWarnOID: make(map[uint32]struct{})}
+	policy, err := types.NewTimestampPolicy("America/New_York", true)
+	require.NoError(t, err)
+	utc := func(s string) time.Time {
+		ts, err := time.Parse(time.DateTime, s)
+		require.NoError(t, err)
+		return ts
+	}
+	requireUTC := func(expected string, actual types.QValue, literal string) {
+		var ts time.Time
+		switch val := actual.(type) {
+		case types.QValueTimestamp:
+			ts = val.Val
+		case types.QValueTimestampTZ:
+			ts = val.Val
+			require.Equal(t, time.UTC, ts.Location(), literal)
+		default:
+			require.Failf(t, "unexpected value", "%s: %T", literal, actual)
+		}
+		require.True(t, utc(expected).Equal(ts), "%s: %s", literal, ts)
+	}
+
+	for _, tc := range []struct {
+		check   func(types.QValue, string)
+		literal string
+		oid     uint32
+	}{
+		{
+			oid:     pgtype.TimestampOID,
+			literal: "2024-03-10 01:30:00",
+			check: func(qv types.QValue, literal string) {
+				requireUTC("2024-03-10 06:30:00", qv, literal)
+			},
+		},
+		{
+			oid:     pgtype.TimestamptzOID,
+			literal: "2024-03-10 07:00:00+05:30",
+			check: func(qv types.QValue, literal string) {
+				requireUTC("2024-03-10 01:30:00", qv, literal)
+			},
+		},
+		{
+			oid:     pgtype.TimestampArrayOID,
+			literal: `{"2024-07-01 12:00:00"}`,
+			check: func(qv types.QValue, literal string) {
+				arr, ok := qv.(types.QValueArrayTimestamp)
+				require.True(t, ok, literal)
+				require.Len(t, arr.Val, 1)
+				requireUTC("2024-07-01 16:00:00", types.QValueTimestamp{Val: arr.Val[0]}, literal)
+			},
+		},
+		{
+			oid:     pgtype.TsrangeOID,
+			literal: `["2024-01-01 00:00:00","2024-01-02 00:00:00")`,
+			check: func(qv types.QValue, literal string) {
+				bounds, ok := qv.(types.QValueStruct).RangeBounds()
+				require.True(t, ok, literal)
+				requireUTC("2024-01-01 05:00:00", bounds.Lower, literal)
+				requireUTC("2024-01-02 05:00:00", bounds.Upper, literal)
+			},
+		},
+		{
+			oid:     pgtype.TstzmultirangeOID,
+			literal: `{["2024-01-01 00:00:00+05:30",)}`,
+			check: func(qv types.QValue, literal string) {
+				arr, ok := qv.(types.QValueArrayStruct)
+				require.True(t, ok, literal)
+				require.Len(t, arr.Val, 1)
+				bounds, ok := arr.Val[0].RangeBounds()
+				require.True(t, ok, literal)
+				requireUTC("2023-12-31 18:30:00", bounds.Lower, literal)
+				require.Nil(t, bounds.Upper.Value(), literal)
+			},
+		},
+	} {
+		dt, ok := c.typeMap.TypeForOID(tc.oid)
+		require.True(t, ok)
+		value, err := dt.Codec.DecodeValue(c.typeMap, tc.oid, pgtype.TextFormatCode, []byte(tc.literal))
+		require.NoError(t, err)
+		qv, err := c.parseFieldFromPostgresOID(tc.oid, -1, true, protos.DBType_DBTYPE_UNKNOWN, value, nil, shared.InternalVersion_Latest)
+		require.NoError(t, err)
+		tc.check(policy.Apply(qv), tc.literal)
+	}
+}
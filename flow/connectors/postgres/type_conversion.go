@@ -92,6 +92,14 @@ func PostgresOIDToQValueKind(
 	case pgtype.IntervalArrayOID:
 		return types.QValueKindArrayInterval, nil
 	default:
+		if version >= shared.InternalVersion_PgRangesAsStructs {
+			if _, ok := rangeElementOIDs[recvOID]; ok {
+				return types.QValueKindStruct, nil
+			}
+			if _, ok := multirangeRangeOIDs[recvOID]; ok {
+				return types.QValueKindArrayStruct, nil
+			}
+		}
 		if typeName, ok := typeMap.TypeForOID(recvOID); ok {
 			colType := types.QValueKindString
 			if typeData, ok := customTypeMapping[recvOID]; ok {
@@ -107,6 +115,9 @@ func PostgresOIDToQValueKind(
 				return types.QValueKindPoint, nil
 			default:
 				if typeData, ok := customTypeMapping[recvOID]; ok {
+					if baseOID, _, ok := DomainBaseOID(typeData, version); ok {
+						return PostgresOIDToQValueKind(baseOID, customTypeMapping, typeMap, version)
+					}
 					return CustomTypeToQKind(typeData, version), nil
 				}
 				return types.QValueKindString, nil
@@ -128,6 +139,10 @@ func CustomTypeToQKind(typeData pkg_pg.CustomDataType, version uint32) types.QVa
 		return types.QValueKindArrayString
 	}
 
+	if typeData.Type == 'c' && len(typeData.Fields) > 0 && version >= shared.InternalVersion_PgCompositeAndDomainTypes {
+		return types.QValueKindStruct
+	}
+
 	switch typeData.Name {
 	case "geometry":
 		return types.QValueKindGeometry
@@ -145,3 +160,13 @@ func CustomTypeToQKind(typeData pkg_pg.CustomDataType, version uint32) types.QVa
 		return types.QValueKindString
 	}
 }
+
+// DomainBaseOID returns the type a domain is defined over and the type modifier the domain applies to it,
+// domains are replicated as their base type
+func DomainBaseOID(typeData pkg_pg.CustomDataType, version uint32) (uint32, int32, bool) {
+	if typeData.Type == 'd' && typeData.Delim == 0 && typeData.BaseOID != 0 &&
+		version >= shared.InternalVersion_PgCompositeAndDomainTypes {
+		return typeData.BaseOID, typeData.BaseTypmod, true
+	}
+	return 0, -1, false
+}
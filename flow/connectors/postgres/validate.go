@@ -31,6 +31,21 @@ func (c *PostgresConnector) ValidateCheck(ctx context.Context) error {
 	if pgversion < shared.POSTGRES_12 {
 		return fmt.Errorf("postgres must be of PG12 or above. Current version: %d", pgversion)
 	}
+
+	if replicaConfig := ReplicaConfig(c.Config); replicaConfig != nil {
+		replicaConn, err := NewPostgresConnector(ctx, nil, replicaConfig)
+		if err != nil {
+			return fmt.Errorf("failed to connect to read replica: %w", err)
+		}
+		defer replicaConn.Close()
+		var inRecovery bool
+		if err := replicaConn.conn.QueryRow(ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery); err != nil {
+			return fmt.Errorf("failed to check read replica recovery status: %w", err)
+		}
+		if !inRecovery {
+			return errors.New("read replica must be a hot standby of the primary")
+		}
+	}
 	return nil
 }
 
@@ -3,6 +3,12 @@ package connsnowflake
 import (
 	"fmt"
 
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model/qvalue"
+	"github.com/PeerDB-io/peerdb/flow/pkg/common"
+	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
@@ -40,3 +46,16 @@ func snowflakeTypeToQValueKind(name string) (types.QValueKind, error) {
 	}
 	return "", fmt.Errorf("unsupported database type name: %s", name)
 }
+
+// numericStrategyColumn widens a NUMERIC column with the widest decimal strategy to the maximum precision of NUMBER
+func numericStrategyColumn(column *protos.FieldDescription, strategy protos.NumericStrategy) *protos.FieldDescription {
+	if types.QValueKind(column.Type) != types.QValueKindNumeric ||
+		strategy != protos.NumericStrategy_NUMERIC_STRATEGY_WIDEST_DECIMAL {
+		return column
+	}
+	precision, scale := common.ParseNumericTypmod(column.TypeModifier)
+	destType := qvalue.GetNumericDestinationTypeForStrategy(precision, scale, protos.DBType_SNOWFLAKE, false, strategy)
+	widened := proto.CloneOf(column)
+	widened.TypeModifier = datatypes.MakeNumericTypmod(int32(destType.Precision), int32(destType.Scale))
+	return widened
+}
@@ -324,7 +324,8 @@ func (c *SnowflakeConnector) SetupNormalizedTable(
 		return true, nil
 	}
 
-	normalizedTableCreateSQL := generateCreateTableSQLForNormalizedTable(ctx, config, normalizedSchemaTable, tableSchema)
+	normalizedTableCreateSQL := generateCreateTableSQLForNormalizedTable(
+		ctx, config, tableIdentifier, normalizedSchemaTable, tableSchema)
 	if _, err := c.execWithLogging(ctx, normalizedTableCreateSQL); err != nil {
 		return false, fmt.Errorf("[sf] error while creating normalized table: %w", err)
 	}
@@ -337,7 +338,7 @@ func (c *SnowflakeConnector) ReplayTableSchemaDeltas(
 	ctx context.Context,
 	env map[string]string,
 	flowJobName string,
-	_ []*protos.TableMapping,
+	tableMappings []*protos.TableMapping,
 	schemaDeltas []*protos.TableSchemaDelta,
 	_ []string,
 ) error {
@@ -364,8 +365,9 @@ func (c *SnowflakeConnector) ReplayTableSchemaDeltas(
 
 		for _, addedColumn := range schemaDelta.AddedColumns {
 			qvKind := types.QValueKind(addedColumn.Type)
-			sfColtype, err := qvalue.ToDWHColumnType(
-				ctx, qvKind, env, protos.DBType_SNOWFLAKE, nil, addedColumn, schemaDelta.NullableEnabled, nil,
+			sfColtype, err := qvalue.ToDWHColumnType(ctx, qvKind, env, protos.DBType_SNOWFLAKE, nil,
+				numericStrategyColumn(addedColumn, qvalue.ColumnNumericStrategy(tableMappings, schemaDelta.DstTableName, addedColumn.Name)),
+				schemaDelta.NullableEnabled, nil,
 			)
 			if err != nil {
 				return fmt.Errorf("failed to convert column type %s to snowflake type: %w",
@@ -654,6 +656,7 @@ func (c *SnowflakeConnector) checkIfTableExists(
 func generateCreateTableSQLForNormalizedTable(
 	ctx context.Context,
 	config *protos.SetupNormalizedTableBatchInput,
+	tableIdentifier string,
 	dstSchemaTable *common.QualifiedTable,
 	tableSchema *protos.TableSchema,
 ) string {
@@ -662,8 +665,9 @@ func generateCreateTableSQLForNormalizedTable(
 		genericColumnType := column.Type
 		normalizedColName := SnowflakeIdentifierNormalize(column.Name)
 		qvKind := types.QValueKind(genericColumnType)
-		sfColType, err := qvalue.ToDWHColumnType(
-			ctx, qvKind, config.Env, protos.DBType_SNOWFLAKE, nil, column, tableSchema.NullableEnabled, nil,
+		sfColType, err := qvalue.ToDWHColumnType(ctx, qvKind, config.Env, protos.DBType_SNOWFLAKE, nil,
+			numericStrategyColumn(column, qvalue.ColumnNumericStrategy(config.TableMappings, tableIdentifier, column.Name)),
+			tableSchema.NullableEnabled, nil,
 		)
 		if err != nil {
 			slog.WarnContext(ctx, fmt.Sprintf("failed to convert column type %s to snowflake type", genericColumnType),
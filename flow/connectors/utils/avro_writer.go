@@ -29,6 +29,8 @@ const (
 	AvroLocalStorage = iota
 	AvroS3Storage
 	AvroGCSStorage
+	// AvroDirectInsert records a batch whose rows were inserted without staging a file
+	AvroDirectInsert
 )
 
 type peerDBOCFWriter struct {
@@ -3,21 +3,32 @@ package utils
 import (
 	"bytes"
 	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/gob"
 	"errors"
 	"fmt"
+	"hash"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"runtime/metrics"
 	"sync/atomic"
 	"time"
 
 	"github.com/cockroachdb/pebble/v2"
+	"github.com/klauspost/compress/s2"
 	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"go.temporal.io/sdk/log"
 
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
 	"github.com/PeerDB-io/peerdb/flow/pkg/common"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
@@ -33,20 +44,33 @@ func encVal(val any) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// spilledBytes tracks bytes spilled to disk by all CDC stores on this worker, to enforce PEERDB_CDC_DISK_SPILL_MAX_BYTES
+var spilledBytes atomic.Int64
+
 type CDCStore[Items model.Items] struct {
-	logger                    log.Logger
-	inMemoryRecords           map[model.TableWithPkey]model.Record[Items]
-	pebbleDB                  *pebble.DB
+	logger          log.Logger
+	inMemoryRecords map[model.TableWithPkey]model.Record[Items]
+	pebbleDB        *pebble.DB
+	// spilled records are sealed with a key that only lives in memory for the lifetime of the store,
+	// and looked up by a keyed hash so neither table names nor primary key hashes reach disk
+	aead                      cipher.AEAD
+	keyHash                   hash.Hash
+	otelManager               *otel_metrics.OtelManager
 	flowJobName               string
 	dbFolderName              string
 	thresholdReason           string
 	memStats                  []metrics.Sample
+	encodeBuf                 []byte
 	memThresholdBytes         uint64
+	spillMaxBytes             int64
+	storeSpilledBytes         int64
 	numRecordsSwitchThreshold int
 	numRecords                atomic.Int32
 }
 
-func NewCDCStore[Items model.Items](ctx context.Context, env map[string]string, flowJobName string) (*CDCStore[Items], error) {
+func NewCDCStore[Items model.Items](
+	ctx context.Context, env map[string]string, otelManager *otel_metrics.OtelManager, flowJobName string,
+) (*CDCStore[Items], error) {
 	numRecordsSwitchThreshold, err := internal.PeerDBCDCDiskSpillRecordsThreshold(ctx, env)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get CDC disk spill records threshold: %w", err)
@@ -55,13 +79,42 @@ func NewCDCStore[Items model.Items](ctx context.Context, env map[string]string,
 	if err != nil {
 		return nil, fmt.Errorf("failed to get CDC disk spill memory percent threshold: %w", err)
 	}
+	spillDir := internal.PeerDBCDCDiskSpillDir()
+	if spillDir == "" {
+		spillDir = os.TempDir()
+	}
+	spillMaxBytes, err := internal.PeerDBCDCDiskSpillMaxBytes(ctx, env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CDC disk spill max bytes: %w", err)
+	}
+
+	encryptionKey := make([]byte, 32)
+	if _, err := rand.Read(encryptionKey); err != nil {
+		return nil, fmt.Errorf("failed to generate CDC store encryption key: %w", err)
+	}
+	block, err := aes.NewCipher(encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CDC store cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CDC store cipher: %w", err)
+	}
+	hashKey := make([]byte, 32)
+	if _, err := rand.Read(hashKey); err != nil {
+		return nil, fmt.Errorf("failed to generate CDC store hash key: %w", err)
+	}
 
 	return &CDCStore[Items]{
 		inMemoryRecords:           make(map[model.TableWithPkey]model.Record[Items]),
 		pebbleDB:                  nil,
+		aead:                      aead,
+		keyHash:                   hmac.New(sha256.New, hashKey),
+		otelManager:               otelManager,
 		numRecords:                atomic.Int32{},
 		flowJobName:               flowJobName,
-		dbFolderName:              fmt.Sprintf("%s/%s_%s", os.TempDir(), flowJobName, common.RandomString(8)),
+		dbFolderName:              filepath.Join(spillDir, flowJobName+"_"+common.RandomString(8)),
+		spillMaxBytes:             spillMaxBytes,
 		numRecordsSwitchThreshold: int(numRecordsSwitchThreshold),
 		memThresholdBytes: func() uint64 {
 			maxMemBytes := internal.PeerDBFlowWorkerMaxMemBytes()
@@ -131,6 +184,9 @@ func init() {
 	gob.Register(types.QValueArrayBoolean{})
 	gob.Register(types.QValueArrayUUID{})
 	gob.Register(types.QValueArrayNumeric{})
+	gob.Register(types.QValueStruct{})
+	gob.Register(types.QValueArrayStruct{})
+	gob.Register(types.QValueMap{})
 }
 
 func (c *CDCStore[T]) initPebbleDB() error {
@@ -144,6 +200,9 @@ func (c *CDCStore[T]) initPebbleDB() error {
 	gob.Register(&model.RelationRecord[T]{})
 	gob.Register(&model.MessageRecord[T]{})
 
+	if err := os.MkdirAll(filepath.Dir(c.dbFolderName), 0o700); err != nil {
+		return fmt.Errorf("failed to create CDC disk spill directory: %w", err)
+	}
 	var err error
 	// we don't want a WAL since cache, we don't want to overwrite another DB either
 	c.pebbleDB, err = pebble.Open(c.dbFolderName, &pebble.Options{
@@ -175,7 +234,7 @@ func (c *CDCStore[T]) diskSpillThresholdsExceeded() bool {
 	return false
 }
 
-func (c *CDCStore[T]) Set(key model.TableWithPkey, rec model.Record[T]) error {
+func (c *CDCStore[T]) Set(ctx context.Context, key model.TableWithPkey, rec model.Record[T]) error {
 	if key.TableName != "" {
 		_, ok := c.inMemoryRecords[key]
 		if ok || !c.diskSpillThresholdsExceeded() {
@@ -189,22 +248,9 @@ func (c *CDCStore[T]) Set(key model.TableWithPkey, rec model.Record[T]) error {
 				}
 			}
 
-			encodedKey, err := encVal(key)
-			if err != nil {
+			if err := c.spill(ctx, key, rec); err != nil {
 				return err
 			}
-			// necessary to point pointer to interface so the interface is exposed
-			// instead of the underlying type
-			encodedRec, err := encVal(&rec)
-			if err != nil {
-				return err
-			}
-			// we're using Pebble as a cache, no need for durability here.
-			if err := c.pebbleDB.Set(encodedKey, encodedRec, &pebble.WriteOptions{
-				Sync: false,
-			}); err != nil {
-				return fmt.Errorf("unable to store value in Pebble: %w", err)
-			}
 		}
 	}
 
@@ -212,17 +258,90 @@ func (c *CDCStore[T]) Set(key model.TableWithPkey, rec model.Record[T]) error {
 	return nil
 }
 
+func (c *CDCStore[T]) spill(ctx context.Context, key model.TableWithPkey, rec model.Record[T]) error {
+	start := time.Now()
+	encodedKey := c.encodeKey(key)
+	encodedRec, err := encodeSpillRecord(c.encodeBuf[:0], rec)
+	if err != nil {
+		return err
+	}
+	c.encodeBuf = encodedRec
+	sealedRec, err := c.seal(encodedKey, s2.Encode(nil, encodedRec))
+	if err != nil {
+		return err
+	}
+
+	size := int64(len(encodedKey) + len(sealedRec))
+	// an overwritten key only counts the difference from the entry it replaces
+	growth := size
+	if oldRec, closer, err := c.pebbleDB.Get(encodedKey); err == nil {
+		growth -= int64(len(encodedKey) + len(oldRec))
+		if err := closer.Close(); err != nil {
+			return fmt.Errorf("failed to close spilled record: %w", err)
+		}
+	} else if !errors.Is(err, pebble.ErrNotFound) {
+		return fmt.Errorf("unable to look up spilled record: %w", err)
+	}
+	if total := spilledBytes.Add(growth); c.spillMaxBytes > 0 && growth > 0 && total > c.spillMaxBytes {
+		spilledBytes.Add(-growth)
+		return fmt.Errorf("CDC disk spill exceeds PEERDB_CDC_DISK_SPILL_MAX_BYTES of %d bytes on this worker", c.spillMaxBytes)
+	}
+	c.storeSpilledBytes += growth
+
+	// we're using Pebble as a cache, no need for durability here.
+	if err := c.pebbleDB.Set(encodedKey, sealedRec, &pebble.WriteOptions{
+		Sync: false,
+	}); err != nil {
+		return fmt.Errorf("unable to store value in Pebble: %w", err)
+	}
+	if c.otelManager != nil {
+		c.otelManager.Metrics.CDCStoreSpillBytesCounter.Add(ctx, size)
+		c.otelManager.Metrics.CDCStoreSpillLatencyHistogram.Record(ctx, time.Since(start).Seconds(),
+			metric.WithAttributeSet(attribute.NewSet(attribute.String("operation", "write"))))
+	}
+	return nil
+}
+
+func (c *CDCStore[T]) encodeKey(key model.TableWithPkey) []byte {
+	c.keyHash.Reset()
+	c.keyHash.Write([]byte(key.TableName))
+	// table names can't contain NUL, so keys of different tables can't collide
+	c.keyHash.Write([]byte{0})
+	c.keyHash.Write(key.PkeyColVal[:])
+	return c.keyHash.Sum(nil)
+}
+
+// seal encrypts value with a random nonce prepended, bound to its key so values can't be swapped between keys
+func (c *CDCStore[T]) seal(encodedKey []byte, value []byte) ([]byte, error) {
+	nonceSize := c.aead.NonceSize()
+	sealed := make([]byte, nonceSize, nonceSize+len(value)+c.aead.Overhead())
+	if _, err := rand.Read(sealed); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return c.aead.Seal(sealed, sealed, value, encodedKey), nil
+}
+
+func (c *CDCStore[T]) open(encodedKey []byte, sealed []byte) ([]byte, error) {
+	nonceSize := c.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, errors.New("spilled record is truncated")
+	}
+	value, err := c.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt spilled record: %w", err)
+	}
+	return value, nil
+}
+
 // bool is to indicate if a record is found or not [similar to ok]
-func (c *CDCStore[T]) Get(key model.TableWithPkey) (model.Record[T], bool, error) {
+func (c *CDCStore[T]) Get(ctx context.Context, key model.TableWithPkey) (model.Record[T], bool, error) {
 	rec, ok := c.inMemoryRecords[key]
 	if ok {
 		return rec, true, nil
 	} else if c.pebbleDB != nil {
-		encodedKey, err := encVal(key)
-		if err != nil {
-			return nil, false, err
-		}
-		encodedRec, closer, err := c.pebbleDB.Get(encodedKey)
+		start := time.Now()
+		encodedKey := c.encodeKey(key)
+		sealedRec, closer, err := c.pebbleDB.Get(encodedKey)
 		if err != nil {
 			if errors.Is(err, pebble.ErrNotFound) {
 				return nil, false, nil
@@ -238,10 +357,21 @@ func (c *CDCStore[T]) Get(key model.TableWithPkey) (model.Record[T], bool, error
 			}
 		}()
 
-		dec := gob.NewDecoder(bytes.NewReader(encodedRec))
-		var rec model.Record[T]
-		if err := dec.Decode(&rec); err != nil {
-			return nil, false, fmt.Errorf("failed to decode record: %w", err)
+		compressedRec, err := c.open(encodedKey, sealedRec)
+		if err != nil {
+			return nil, false, err
+		}
+		encodedRec, err := s2.Decode(nil, compressedRec)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to decompress spilled record: %w", err)
+		}
+		rec, err := decodeSpillRecord[T](encodedRec)
+		if err != nil {
+			return nil, false, err
+		}
+		if c.otelManager != nil {
+			c.otelManager.Metrics.CDCStoreSpillLatencyHistogram.Record(ctx, time.Since(start).Seconds(),
+				metric.WithAttributeSet(attribute.NewSet(attribute.String("operation", "read"))))
 		}
 
 		return rec, true, nil
@@ -251,6 +381,8 @@ func (c *CDCStore[T]) Get(key model.TableWithPkey) (model.Record[T], bool, error
 
 func (c *CDCStore[T]) Close() error {
 	c.inMemoryRecords = nil
+	spilledBytes.Add(-c.storeSpilledBytes)
+	c.storeSpilledBytes = 0
 	if c.pebbleDB != nil {
 		if err := c.pebbleDB.Close(); err != nil {
 			return fmt.Errorf("failed to close database: %w", err)
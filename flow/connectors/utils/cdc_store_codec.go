@@ -0,0 +1,875 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// spilled records are written with a compact binary layout instead of gob,
+// which spends most of its time on reflection and type descriptors for every value.
+// Records and values the codec has no layout for are still gob encoded behind a marker.
+const (
+	spillRecordGob byte = iota
+	spillRecordInsert
+	spillRecordUpdate
+	spillRecordDelete
+)
+
+const (
+	spillValueNil byte = iota
+	spillValueNull
+	spillValueInvalid
+	spillValueFloat32
+	spillValueFloat64
+	spillValueInt8
+	spillValueInt16
+	spillValueInt32
+	spillValueInt64
+	spillValueInt256
+	spillValueUInt8
+	spillValueUInt16
+	spillValueUInt32
+	spillValueUInt64
+	spillValueUInt256
+	spillValueBoolean
+	spillValueQChar
+	spillValueString
+	spillValueEnum
+	spillValueUint16Enum
+	spillValueUint64Set
+	spillValueTimestamp
+	spillValueTimestampTZ
+	spillValueDate
+	spillValueTime
+	spillValueTimeTZ
+	spillValueInterval
+	spillValueNumeric
+	spillValueBytes
+	spillValueUUID
+	spillValueJSON
+	spillValueHStore
+	spillValueGeography
+	spillValueGeometry
+	spillValuePoint
+	spillValueCIDR
+	spillValueINET
+	spillValueMacaddr
+	spillValueArrayFloat32
+	spillValueArrayFloat64
+	spillValueArrayInt16
+	spillValueArrayInt32
+	spillValueArrayInt64
+	spillValueArrayString
+	spillValueArrayEnum
+	spillValueArrayInterval
+	spillValueArrayDate
+	spillValueArrayTimestamp
+	spillValueArrayTimestampTZ
+	spillValueArrayBoolean
+	spillValueArrayUUID
+	spillValueArrayNumeric
+	spillValueStruct
+	spillValueMap
+	spillValueArrayStruct
+	spillValueGob byte = 0xff
+)
+
+type spillEncoder struct {
+	buf []byte
+}
+
+func encodeSpillRecord[T model.Items](buf []byte, rec model.Record[T]) ([]byte, error) {
+	e := spillEncoder{buf: buf}
+	var err error
+	switch r := rec.(type) {
+	case *model.InsertRecord[T]:
+		e.buf = append(e.buf, spillRecordInsert)
+		e.baseRecord(r.BaseRecord)
+		e.string(r.SourceTableName)
+		e.string(r.DestinationTableName)
+		e.varint(r.CommitID)
+		err = e.items(r.Items)
+	case *model.UpdateRecord[T]:
+		e.buf = append(e.buf, spillRecordUpdate)
+		e.baseRecord(r.BaseRecord)
+		e.string(r.SourceTableName)
+		e.string(r.DestinationTableName)
+		e.columnSet(r.UnchangedToastColumns)
+		if err = e.items(r.OldItems); err == nil {
+			err = e.items(r.NewItems)
+		}
+	case *model.DeleteRecord[T]:
+		e.buf = append(e.buf, spillRecordDelete)
+		e.baseRecord(r.BaseRecord)
+		e.string(r.SourceTableName)
+		e.string(r.DestinationTableName)
+		e.columnSet(r.UnchangedToastColumns)
+		err = e.items(r.Items)
+	default:
+		e.buf = append(e.buf, spillRecordGob)
+		// necessary to point pointer to interface so the interface is exposed
+		// instead of the underlying type
+		err = e.gob(&rec)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+func (e *spillEncoder) uvarint(v uint64) {
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+func (e *spillEncoder) varint(v int64) {
+	e.buf = binary.AppendVarint(e.buf, v)
+}
+
+func (e *spillEncoder) bool(v bool) {
+	if v {
+		e.buf = append(e.buf, 1)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+// length writes n, shifted by one so nil maps and slices stay distinct from empty ones
+func (e *spillEncoder) length(n int, isNil bool) {
+	if isNil {
+		e.uvarint(0)
+	} else {
+		e.uvarint(uint64(n) + 1)
+	}
+}
+
+func (e *spillEncoder) string(v string) {
+	e.uvarint(uint64(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+func (e *spillEncoder) bytes(v []byte) {
+	e.length(len(v), v == nil)
+	e.buf = append(e.buf, v...)
+}
+
+func (e *spillEncoder) time(v time.Time) error {
+	b, err := v.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("unable to encode time %v: %w", v, err)
+	}
+	e.buf = append(e.buf, byte(len(b)))
+	e.buf = append(e.buf, b...)
+	return nil
+}
+
+func (e *spillEncoder) decimal(v decimal.Decimal) error {
+	b, err := v.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("unable to encode decimal %v: %w", v, err)
+	}
+	e.bytes(b)
+	return nil
+}
+
+func (e *spillEncoder) bigInt(v *big.Int) error {
+	if v == nil {
+		e.bytes(nil)
+		return nil
+	}
+	b, err := v.GobEncode()
+	if err != nil {
+		return fmt.Errorf("unable to encode integer %v: %w", v, err)
+	}
+	e.bytes(b)
+	return nil
+}
+
+func (e *spillEncoder) gob(v any) error {
+	encoded, err := encVal(v)
+	if err != nil {
+		return err
+	}
+	e.bytes(encoded)
+	return nil
+}
+
+func (e *spillEncoder) baseRecord(base model.BaseRecord) {
+	e.varint(base.CheckpointID)
+	e.varint(base.CommitTimeNano)
+	e.uvarint(base.TransactionID)
+}
+
+func (e *spillEncoder) columnSet(cols map[string]struct{}) {
+	e.length(len(cols), cols == nil)
+	for col := range cols {
+		e.string(col)
+	}
+}
+
+func (e *spillEncoder) items(items model.Items) error {
+	switch items := items.(type) {
+	case model.RecordItems:
+		e.varint(int64(items.TruncateThresholdBytes))
+		e.length(len(items.ColToVal), items.ColToVal == nil)
+		for col, qv := range items.ColToVal {
+			e.string(col)
+			if err := e.qvalue(qv); err != nil {
+				return fmt.Errorf("unable to encode column %s: %w", col, err)
+			}
+		}
+	case model.PgItems:
+		e.length(len(items.ColToVal), items.ColToVal == nil)
+		for col, val := range items.ColToVal {
+			e.string(col)
+			e.bytes(val)
+		}
+	default:
+		return fmt.Errorf("unable to encode items of type %T", items)
+	}
+	return nil
+}
+
+func (e *spillEncoder) structMembers(members []types.QValueStructField) error {
+	e.length(len(members), members == nil)
+	for _, member := range members {
+		e.string(member.Name)
+		if err := e.qvalue(member.Val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *spillEncoder) qvalue(qv types.QValue) error {
+	switch v := qv.(type) {
+	case nil:
+		e.buf = append(e.buf, spillValueNil)
+	case types.QValueNull:
+		e.buf = append(e.buf, spillValueNull)
+		e.string(string(v))
+	case types.QValueInvalid:
+		e.buf = append(e.buf, spillValueInvalid)
+		e.string(v.Val)
+	case types.QValueFloat32:
+		e.buf = append(e.buf, spillValueFloat32)
+		e.buf = binary.LittleEndian.AppendUint32(e.buf, math.Float32bits(v.Val))
+	case types.QValueFloat64:
+		e.buf = append(e.buf, spillValueFloat64)
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(v.Val))
+	case types.QValueInt8:
+		e.buf = append(e.buf, spillValueInt8)
+		e.varint(int64(v.Val))
+	case types.QValueInt16:
+		e.buf = append(e.buf, spillValueInt16)
+		e.varint(int64(v.Val))
+	case types.QValueInt32:
+		e.buf = append(e.buf, spillValueInt32)
+		e.varint(int64(v.Val))
+	case types.QValueInt64:
+		e.buf = append(e.buf, spillValueInt64)
+		e.varint(v.Val)
+	case types.QValueInt256:
+		e.buf = append(e.buf, spillValueInt256)
+		return e.bigInt(v.Val)
+	case types.QValueUInt8:
+		e.buf = append(e.buf, spillValueUInt8)
+		e.uvarint(uint64(v.Val))
+	case types.QValueUInt16:
+		e.buf = append(e.buf, spillValueUInt16)
+		e.uvarint(uint64(v.Val))
+	case types.QValueUInt32:
+		e.buf = append(e.buf, spillValueUInt32)
+		e.uvarint(uint64(v.Val))
+	case types.QValueUInt64:
+		e.buf = append(e.buf, spillValueUInt64)
+		e.uvarint(v.Val)
+	case types.QValueUInt256:
+		e.buf = append(e.buf, spillValueUInt256)
+		return e.bigInt(v.Val)
+	case types.QValueBoolean:
+		e.buf = append(e.buf, spillValueBoolean)
+		e.bool(v.Val)
+	case types.QValueQChar:
+		e.buf = append(e.buf, spillValueQChar, v.Val)
+	case types.QValueString:
+		e.buf = append(e.buf, spillValueString)
+		e.string(v.Val)
+	case types.QValueEnum:
+		e.buf = append(e.buf, spillValueEnum)
+		e.string(v.Val)
+	case types.QValueUint16Enum:
+		e.buf = append(e.buf, spillValueUint16Enum)
+		e.uvarint(uint64(v.Val))
+	case types.QValueUint64Set:
+		e.buf = append(e.buf, spillValueUint64Set)
+		e.uvarint(v.Val)
+	case types.QValueTimestamp:
+		e.buf = append(e.buf, spillValueTimestamp)
+		return e.time(v.Val)
+	case types.QValueTimestampTZ:
+		e.buf = append(e.buf, spillValueTimestampTZ)
+		return e.time(v.Val)
+	case types.QValueDate:
+		e.buf = append(e.buf, spillValueDate)
+		return e.time(v.Val)
+	case types.QValueTime:
+		e.buf = append(e.buf, spillValueTime)
+		e.varint(int64(v.Val))
+	case types.QValueTimeTZ:
+		e.buf = append(e.buf, spillValueTimeTZ)
+		e.varint(int64(v.Val))
+	case types.QValueInterval:
+		e.buf = append(e.buf, spillValueInterval)
+		e.string(v.Val)
+	case types.QValueNumeric:
+		e.buf = append(e.buf, spillValueNumeric)
+		e.varint(int64(v.Precision))
+		e.varint(int64(v.Scale))
+		return e.decimal(v.Val)
+	case types.QValueBytes:
+		e.buf = append(e.buf, spillValueBytes)
+		e.bytes(v.Val)
+	case types.QValueUUID:
+		e.buf = append(e.buf, spillValueUUID)
+		e.buf = append(e.buf, v.Val[:]...)
+	case types.QValueJSON:
+		e.buf = append(e.buf, spillValueJSON)
+		e.bool(v.IsArray)
+		e.string(v.Val)
+	case types.QValueHStore:
+		e.buf = append(e.buf, spillValueHStore)
+		e.string(v.Val)
+	case types.QValueGeography:
+		e.buf = append(e.buf, spillValueGeography)
+		e.string(v.Val)
+	case types.QValueGeometry:
+		e.buf = append(e.buf, spillValueGeometry)
+		e.string(v.Val)
+	case types.QValuePoint:
+		e.buf = append(e.buf, spillValuePoint)
+		e.string(v.Val)
+	case types.QValueCIDR:
+		e.buf = append(e.buf, spillValueCIDR)
+		e.string(v.Val)
+	case types.QValueINET:
+		e.buf = append(e.buf, spillValueINET)
+		e.string(v.Val)
+	case types.QValueMacaddr:
+		e.buf = append(e.buf, spillValueMacaddr)
+		e.string(v.Val)
+	case types.QValueArrayFloat32:
+		e.buf = append(e.buf, spillValueArrayFloat32)
+		e.length(len(v.Val), v.Val == nil)
+		for _, f := range v.Val {
+			e.buf = binary.LittleEndian.AppendUint32(e.buf, math.Float32bits(f))
+		}
+	case types.QValueArrayFloat64:
+		e.buf = append(e.buf, spillValueArrayFloat64)
+		e.length(len(v.Val), v.Val == nil)
+		for _, f := range v.Val {
+			e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(f))
+		}
+	case types.QValueArrayInt16:
+		e.buf = append(e.buf, spillValueArrayInt16)
+		e.length(len(v.Val), v.Val == nil)
+		for _, i := range v.Val {
+			e.varint(int64(i))
+		}
+	case types.QValueArrayInt32:
+		e.buf = append(e.buf, spillValueArrayInt32)
+		e.length(len(v.Val), v.Val == nil)
+		for _, i := range v.Val {
+			e.varint(int64(i))
+		}
+	case types.QValueArrayInt64:
+		e.buf = append(e.buf, spillValueArrayInt64)
+		e.length(len(v.Val), v.Val == nil)
+		for _, i := range v.Val {
+			e.varint(i)
+		}
+	case types.QValueArrayString:
+		e.buf = append(e.buf, spillValueArrayString)
+		e.strings(v.Val)
+	case types.QValueArrayEnum:
+		e.buf = append(e.buf, spillValueArrayEnum)
+		e.strings(v.Val)
+	case types.QValueArrayInterval:
+		e.buf = append(e.buf, spillValueArrayInterval)
+		e.strings(v.Val)
+	case types.QValueArrayDate:
+		e.buf = append(e.buf, spillValueArrayDate)
+		return e.times(v.Val)
+	case types.QValueArrayTimestamp:
+		e.buf = append(e.buf, spillValueArrayTimestamp)
+		return e.times(v.Val)
+	case types.QValueArrayTimestampTZ:
+		e.buf = append(e.buf, spillValueArrayTimestampTZ)
+		return e.times(v.Val)
+	case types.QValueArrayBoolean:
+		e.buf = append(e.buf, spillValueArrayBoolean)
+		e.length(len(v.Val), v.Val == nil)
+		for _, b := range v.Val {
+			e.bool(b)
+		}
+	case types.QValueArrayUUID:
+		e.buf = append(e.buf, spillValueArrayUUID)
+		e.length(len(v.Val), v.Val == nil)
+		for _, u := range v.Val {
+			e.buf = append(e.buf, u[:]...)
+		}
+	case types.QValueArrayNumeric:
+		e.buf = append(e.buf, spillValueArrayNumeric)
+		e.varint(int64(v.Precision))
+		e.varint(int64(v.Scale))
+		e.length(len(v.Val), v.Val == nil)
+		for _, d := range v.Val {
+			if err := e.decimal(d); err != nil {
+				return err
+			}
+		}
+	case types.QValueStruct:
+		e.buf = append(e.buf, spillValueStruct)
+		if err := e.structMembers(v.Val); err != nil {
+			return err
+		}
+	case types.QValueArrayStruct:
+		e.buf = append(e.buf, spillValueArrayStruct)
+		e.length(len(v.Val), v.Val == nil)
+		for _, element := range v.Val {
+			if err := e.structMembers(element.Val); err != nil {
+				return err
+			}
+		}
+	case types.QValueMap:
+		e.buf = append(e.buf, spillValueMap)
+		e.length(len(v.Val), v.Val == nil)
+		for key, val := range v.Val {
+			e.string(key)
+			if err := e.qvalue(val); err != nil {
+				return err
+			}
+		}
+	default:
+		e.buf = append(e.buf, spillValueGob)
+		return e.gob(&qv)
+	}
+	return nil
+}
+
+func (e *spillEncoder) strings(v []string) {
+	e.length(len(v), v == nil)
+	for _, s := range v {
+		e.string(s)
+	}
+}
+
+func (e *spillEncoder) times(v []time.Time) error {
+	e.length(len(v), v == nil)
+	for _, t := range v {
+		if err := e.time(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// spillDecoder reads what spillEncoder wrote, the first error sticks and zero values are returned after it
+type spillDecoder struct {
+	err error
+	buf []byte
+}
+
+var errSpillTruncated = errors.New("spilled record is truncated")
+
+func decodeSpillRecord[T model.Items](buf []byte) (model.Record[T], error) {
+	d := spillDecoder{buf: buf}
+	var rec model.Record[T]
+	switch kind := d.byte(); kind {
+	case spillRecordInsert:
+		r := &model.InsertRecord[T]{}
+		r.BaseRecord = d.baseRecord()
+		r.SourceTableName = d.string()
+		r.DestinationTableName = d.string()
+		r.CommitID = d.varint()
+		r.Items = decodeSpillItems[T](&d)
+		rec = r
+	case spillRecordUpdate:
+		r := &model.UpdateRecord[T]{}
+		r.BaseRecord = d.baseRecord()
+		r.SourceTableName = d.string()
+		r.DestinationTableName = d.string()
+		r.UnchangedToastColumns = d.columnSet()
+		r.OldItems = decodeSpillItems[T](&d)
+		r.NewItems = decodeSpillItems[T](&d)
+		rec = r
+	case spillRecordDelete:
+		r := &model.DeleteRecord[T]{}
+		r.BaseRecord = d.baseRecord()
+		r.SourceTableName = d.string()
+		r.DestinationTableName = d.string()
+		r.UnchangedToastColumns = d.columnSet()
+		r.Items = decodeSpillItems[T](&d)
+		rec = r
+	case spillRecordGob:
+		if err := gob.NewDecoder(bytes.NewReader(d.bytes())).Decode(&rec); err != nil && d.err == nil {
+			d.err = err
+		}
+	default:
+		if d.err == nil {
+			d.err = fmt.Errorf("unknown spilled record kind %d", kind)
+		}
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("failed to decode record: %w", d.err)
+	}
+	return rec, nil
+}
+
+func (d *spillDecoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+func (d *spillDecoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(d.buf) {
+		d.fail(errSpillTruncated)
+		return nil
+	}
+	b := d.buf[:n:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *spillDecoder) byte() byte {
+	if b := d.take(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *spillDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.fail(errSpillTruncated)
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *spillDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.fail(errSpillTruncated)
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *spillDecoder) bool() bool {
+	return d.byte() != 0
+}
+
+// length returns -1 for nil, see spillEncoder.length
+func (d *spillDecoder) length() int {
+	n := d.uvarint()
+	if n > uint64(len(d.buf))+1 {
+		// every element takes at least a byte, so this can only come from a corrupt record
+		d.fail(errSpillTruncated)
+		return -1
+	}
+	return int(n) - 1
+}
+
+func (d *spillDecoder) string() string {
+	n := d.uvarint()
+	if n > uint64(len(d.buf)) {
+		d.fail(errSpillTruncated)
+		return ""
+	}
+	return string(d.take(int(n)))
+}
+
+func (d *spillDecoder) bytes() []byte {
+	n := d.length()
+	if n < 0 {
+		return nil
+	}
+	return bytes.Clone(d.take(n))
+}
+
+func (d *spillDecoder) uint32() uint32 {
+	if b := d.take(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *spillDecoder) uint64() uint64 {
+	if b := d.take(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (d *spillDecoder) time() time.Time {
+	var t time.Time
+	if b := d.take(int(d.byte())); b != nil {
+		if err := t.UnmarshalBinary(b); err != nil {
+			d.fail(err)
+		}
+	}
+	return t
+}
+
+func (d *spillDecoder) decimal() decimal.Decimal {
+	var v decimal.Decimal
+	if b := d.bytes(); b != nil {
+		if err := v.UnmarshalBinary(b); err != nil {
+			d.fail(err)
+		}
+	}
+	return v
+}
+
+func (d *spillDecoder) bigInt() *big.Int {
+	b := d.bytes()
+	if b == nil {
+		return nil
+	}
+	v := new(big.Int)
+	if err := v.GobDecode(b); err != nil {
+		d.fail(err)
+	}
+	return v
+}
+
+func (d *spillDecoder) uuid() uuid.UUID {
+	var u uuid.UUID
+	copy(u[:], d.take(len(u)))
+	return u
+}
+
+func (d *spillDecoder) baseRecord() model.BaseRecord {
+	return model.BaseRecord{
+		CheckpointID:   d.varint(),
+		CommitTimeNano: d.varint(),
+		TransactionID:  d.uvarint(),
+	}
+}
+
+func (d *spillDecoder) columnSet() map[string]struct{} {
+	n := d.length()
+	if n < 0 {
+		return nil
+	}
+	cols := make(map[string]struct{}, n)
+	for range n {
+		cols[d.string()] = struct{}{}
+	}
+	return cols
+}
+
+func decodeSpillItems[T model.Items](d *spillDecoder) T {
+	var items T
+	switch items := any(&items).(type) {
+	case *model.RecordItems:
+		items.TruncateThresholdBytes = int(d.varint())
+		if n := d.length(); n >= 0 {
+			items.ColToVal = make(map[string]types.QValue, n)
+			for range n {
+				col := d.string()
+				items.ColToVal[col] = d.qvalue()
+			}
+		}
+	case *model.PgItems:
+		if n := d.length(); n >= 0 {
+			items.ColToVal = make(map[string][]byte, n)
+			for range n {
+				col := d.string()
+				items.ColToVal[col] = d.bytes()
+			}
+		}
+	default:
+		d.fail(fmt.Errorf("unable to decode items of type %T", items))
+	}
+	return items
+}
+
+func (d *spillDecoder) qvalue() types.QValue {
+	switch tag := d.byte(); tag {
+	case spillValueNil:
+		return nil
+	case spillValueNull:
+		return types.QValueNull(d.string())
+	case spillValueInvalid:
+		return types.QValueInvalid{Val: d.string()}
+	case spillValueFloat32:
+		return types.QValueFloat32{Val: math.Float32frombits(d.uint32())}
+	case spillValueFloat64:
+		return types.QValueFloat64{Val: math.Float64frombits(d.uint64())}
+	case spillValueInt8:
+		return types.QValueInt8{Val: int8(d.varint())}
+	case spillValueInt16:
+		return types.QValueInt16{Val: int16(d.varint())}
+	case spillValueInt32:
+		return types.QValueInt32{Val: int32(d.varint())}
+	case spillValueInt64:
+		return types.QValueInt64{Val: d.varint()}
+	case spillValueInt256:
+		return types.QValueInt256{Val: d.bigInt()}
+	case spillValueUInt8:
+		return types.QValueUInt8{Val: uint8(d.uvarint())}
+	case spillValueUInt16:
+		return types.QValueUInt16{Val: uint16(d.uvarint())}
+	case spillValueUInt32:
+		return types.QValueUInt32{Val: uint32(d.uvarint())}
+	case spillValueUInt64:
+		return types.QValueUInt64{Val: d.uvarint()}
+	case spillValueUInt256:
+		return types.QValueUInt256{Val: d.bigInt()}
+	case spillValueBoolean:
+		return types.QValueBoolean{Val: d.bool()}
+	case spillValueQChar:
+		return types.QValueQChar{Val: d.byte()}
+	case spillValueString:
+		return types.QValueString{Val: d.string()}
+	case spillValueEnum:
+		return types.QValueEnum{Val: d.string()}
+	case spillValueUint16Enum:
+		return types.QValueUint16Enum{Val: uint16(d.uvarint())}
+	case spillValueUint64Set:
+		return types.QValueUint64Set{Val: d.uvarint()}
+	case spillValueTimestamp:
+		return types.QValueTimestamp{Val: d.time()}
+	case spillValueTimestampTZ:
+		return types.QValueTimestampTZ{Val: d.time()}
+	case spillValueDate:
+		return types.QValueDate{Val: d.time()}
+	case spillValueTime:
+		return types.QValueTime{Val: time.Duration(d.varint())}
+	case spillValueTimeTZ:
+		return types.QValueTimeTZ{Val: time.Duration(d.varint())}
+	case spillValueInterval:
+		return types.QValueInterval{Val: d.string()}
+	case spillValueNumeric:
+		precision, scale := int16(d.varint()), int16(d.varint())
+		return types.QValueNumeric{Val: d.decimal(), Precision: precision, Scale: scale}
+	case spillValueBytes:
+		return types.QValueBytes{Val: d.bytes()}
+	case spillValueUUID:
+		return types.QValueUUID{Val: d.uuid()}
+	case spillValueJSON:
+		isArray := d.bool()
+		return types.QValueJSON{Val: d.string(), IsArray: isArray}
+	case spillValueHStore:
+		return types.QValueHStore{Val: d.string()}
+	case spillValueGeography:
+		return types.QValueGeography{Val: d.string()}
+	case spillValueGeometry:
+		return types.QValueGeometry{Val: d.string()}
+	case spillValuePoint:
+		return types.QValuePoint{Val: d.string()}
+	case spillValueCIDR:
+		return types.QValueCIDR{Val: d.string()}
+	case spillValueINET:
+		return types.QValueINET{Val: d.string()}
+	case spillValueMacaddr:
+		return types.QValueMacaddr{Val: d.string()}
+	case spillValueArrayFloat32:
+		return types.QValueArrayFloat32{Val: decodeSpillSlice(d, func() float32 { return math.Float32frombits(d.uint32()) })}
+	case spillValueArrayFloat64:
+		return types.QValueArrayFloat64{Val: decodeSpillSlice(d, func() float64 { return math.Float64frombits(d.uint64()) })}
+	case spillValueArrayInt16:
+		return types.QValueArrayInt16{Val: decodeSpillSlice(d, func() int16 { return int16(d.varint()) })}
+	case spillValueArrayInt32:
+		return types.QValueArrayInt32{Val: decodeSpillSlice(d, func() int32 { return int32(d.varint()) })}
+	case spillValueArrayInt64:
+		return types.QValueArrayInt64{Val: decodeSpillSlice(d, d.varint)}
+	case spillValueArrayString:
+		return types.QValueArrayString{Val: decodeSpillSlice(d, d.string)}
+	case spillValueArrayEnum:
+		return types.QValueArrayEnum{Val: decodeSpillSlice(d, d.string)}
+	case spillValueArrayInterval:
+		return types.QValueArrayInterval{Val: decodeSpillSlice(d, d.string)}
+	case spillValueArrayDate:
+		return types.QValueArrayDate{Val: decodeSpillSlice(d, d.time)}
+	case spillValueArrayTimestamp:
+		return types.QValueArrayTimestamp{Val: decodeSpillSlice(d, d.time)}
+	case spillValueArrayTimestampTZ:
+		return types.QValueArrayTimestampTZ{Val: decodeSpillSlice(d, d.time)}
+	case spillValueArrayBoolean:
+		return types.QValueArrayBoolean{Val: decodeSpillSlice(d, d.bool)}
+	case spillValueArrayUUID:
+		return types.QValueArrayUUID{Val: decodeSpillSlice(d, d.uuid)}
+	case spillValueArrayNumeric:
+		precision, scale := int16(d.varint()), int16(d.varint())
+		return types.QValueArrayNumeric{Val: decodeSpillSlice(d, d.decimal), Precision: precision, Scale: scale}
+	case spillValueStruct:
+		return d.structValue()
+	case spillValueArrayStruct:
+		return types.QValueArrayStruct{Val: decodeSpillSlice(d, d.structValue)}
+	case spillValueMap:
+		n := d.length()
+		if n < 0 {
+			return types.QValueMap{}
+		}
+		entries := make(map[string]types.QValue, n)
+		for range n {
+			key := d.string()
+			entries[key] = d.qvalue()
+		}
+		return types.QValueMap{Val: entries}
+	case spillValueGob:
+		var qv types.QValue
+		if err := gob.NewDecoder(bytes.NewReader(d.bytes())).Decode(&qv); err != nil {
+			d.fail(err)
+		}
+		return qv
+	default:
+		d.fail(fmt.Errorf("unknown spilled value type %d", tag))
+		return nil
+	}
+}
+
+func (d *spillDecoder) structValue() types.QValueStruct {
+	return types.QValueStruct{Val: decodeSpillSlice(d, func() types.QValueStructField {
+		name := d.string()
+		return types.QValueStructField{Name: name, Val: d.qvalue()}
+	})}
+}
+
+func decodeSpillSlice[V any](d *spillDecoder, elem func() V) []V {
+	n := d.length()
+	if n < 0 {
+		return nil
+	}
+	vals := make([]V, 0, n)
+	for range n {
+		vals = append(vals, elem())
+	}
+	return vals
+}
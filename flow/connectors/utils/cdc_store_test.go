@@ -1,10 +1,13 @@
 package utils
 
 import (
+	"bytes"
 	"crypto/rand"
+	"math/big"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
 
@@ -52,17 +55,17 @@ func genKeyAndRec(t *testing.T) (model.TableWithPkey, model.Record[model.RecordI
 
 func TestSingleRecord(t *testing.T) {
 	t.Parallel()
-	cdcRecordsStore, err := NewCDCStore[model.RecordItems](t.Context(), nil, "test_single_record")
+	cdcRecordsStore, err := NewCDCStore[model.RecordItems](t.Context(), nil, nil, "test_single_record")
 	require.NoError(t, err)
 	cdcRecordsStore.numRecordsSwitchThreshold = 10
 
 	key, rec := genKeyAndRec(t)
-	require.NoError(t, cdcRecordsStore.Set(key, rec))
+	require.NoError(t, cdcRecordsStore.Set(t.Context(), key, rec))
 	// should not spill into DB
 	require.Len(t, cdcRecordsStore.inMemoryRecords, 1)
 	require.Nil(t, cdcRecordsStore.pebbleDB)
 
-	reck, ok, err := cdcRecordsStore.Get(key)
+	reck, ok, err := cdcRecordsStore.Get(t.Context(), key)
 	require.NoError(t, err)
 	require.True(t, ok)
 	require.Equal(t, rec, reck)
@@ -72,14 +75,14 @@ func TestSingleRecord(t *testing.T) {
 
 func TestRecordsTillSpill(t *testing.T) {
 	t.Parallel()
-	cdcRecordsStore, err := NewCDCStore[model.RecordItems](t.Context(), nil, "test_records_till_spill")
+	cdcRecordsStore, err := NewCDCStore[model.RecordItems](t.Context(), nil, nil, "test_records_till_spill")
 	require.NoError(t, err)
 	cdcRecordsStore.numRecordsSwitchThreshold = 10
 
 	// add records upto set limit
 	for i := 1; i <= 10; i++ {
 		key, rec := genKeyAndRec(t)
-		err := cdcRecordsStore.Set(key, rec)
+		err := cdcRecordsStore.Set(t.Context(), key, rec)
 		require.NoError(t, err)
 		require.Len(t, cdcRecordsStore.inMemoryRecords, i)
 		require.Nil(t, cdcRecordsStore.pebbleDB)
@@ -87,12 +90,12 @@ func TestRecordsTillSpill(t *testing.T) {
 
 	// this record should be spilled to DB
 	key, rec := genKeyAndRec(t)
-	require.NoError(t, cdcRecordsStore.Set(key, rec))
+	require.NoError(t, cdcRecordsStore.Set(t.Context(), key, rec))
 	_, ok := cdcRecordsStore.inMemoryRecords[key]
 	require.False(t, ok)
 	require.NotNil(t, cdcRecordsStore.pebbleDB)
 
-	reck, ok, err := cdcRecordsStore.Get(key)
+	reck, ok, err := cdcRecordsStore.Get(t.Context(), key)
 	require.NoError(t, err)
 	require.True(t, ok)
 	require.Equal(t, rec, reck)
@@ -103,14 +106,14 @@ func TestRecordsTillSpill(t *testing.T) {
 func TestTimeAndDecimalEncoding(t *testing.T) {
 	t.Parallel()
 
-	cdcRecordsStore, err := NewCDCStore[model.RecordItems](t.Context(), nil, "test_time_encoding")
+	cdcRecordsStore, err := NewCDCStore[model.RecordItems](t.Context(), nil, nil, "test_time_encoding")
 	require.NoError(t, err)
 	cdcRecordsStore.numRecordsSwitchThreshold = 0
 
 	key, rec := genKeyAndRec(t)
-	require.NoError(t, cdcRecordsStore.Set(key, rec))
+	require.NoError(t, cdcRecordsStore.Set(t.Context(), key, rec))
 
-	retreived, ok, err := cdcRecordsStore.Get(key)
+	retreived, ok, err := cdcRecordsStore.Get(t.Context(), key)
 	require.NoError(t, err)
 	require.True(t, ok)
 	require.Equal(t, rec, retreived)
@@ -124,14 +127,14 @@ func TestTimeAndDecimalEncoding(t *testing.T) {
 func TestNullKeyDoesntStore(t *testing.T) {
 	t.Parallel()
 
-	cdcRecordsStore, err := NewCDCStore[model.RecordItems](t.Context(), nil, "test_time_encoding")
+	cdcRecordsStore, err := NewCDCStore[model.RecordItems](t.Context(), nil, nil, "test_time_encoding")
 	require.NoError(t, err)
 	cdcRecordsStore.numRecordsSwitchThreshold = 0
 
 	key, rec := genKeyAndRec(t)
-	require.NoError(t, cdcRecordsStore.Set(model.TableWithPkey{}, rec))
+	require.NoError(t, cdcRecordsStore.Set(t.Context(), model.TableWithPkey{}, rec))
 
-	retreived, ok, err := cdcRecordsStore.Get(key)
+	retreived, ok, err := cdcRecordsStore.Get(t.Context(), key)
 	require.Nil(t, retreived)
 	require.NoError(t, err)
 	require.False(t, ok)
@@ -140,3 +143,161 @@ func TestNullKeyDoesntStore(t *testing.T) {
 
 	require.NoError(t, cdcRecordsStore.Close())
 }
+
+func spillTestEnv(t *testing.T, maxBytes string) map[string]string {
+	t.Helper()
+	return map[string]string{
+		"PEERDB_CDC_DISK_SPILL_RECORDS_THRESHOLD":     "0",
+		"PEERDB_CDC_DISK_SPILL_MEM_PERCENT_THRESHOLD": "-1",
+		"PEERDB_CDC_DISK_SPILL_MAX_BYTES":             maxBytes,
+	}
+}
+
+func TestSpillCodecRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Date(2024, 2, 29, 12, 30, 45, 123456000, time.UTC)
+	id := uuid.New()
+	update := &model.UpdateRecord[model.RecordItems]{
+		BaseRecord: model.BaseRecord{
+			CheckpointID:   -1,
+			CommitTimeNano: ts.UnixNano(),
+			TransactionID:  1 << 40,
+		},
+		SourceTableName:       "public.src",
+		DestinationTableName:  "dst",
+		UnchangedToastColumns: map[string]struct{}{"big": {}},
+		OldItems:              model.RecordItems{},
+		NewItems: model.RecordItems{
+			ColToVal: map[string]types.QValue{
+				"null":    types.QValueNull(types.QValueKindString),
+				"f32":     types.QValueFloat32{Val: 1.5},
+				"f64":     types.QValueFloat64{Val: -2.25},
+				"i8":      types.QValueInt8{Val: -8},
+				"i16":     types.QValueInt16{Val: -16},
+				"i32":     types.QValueInt32{Val: -32},
+				"i64":     types.QValueInt64{Val: -64},
+				"i256":    types.QValueInt256{Val: big.NewInt(-256)},
+				"u64":     types.QValueUInt64{Val: 1 << 63},
+				"u256":    types.QValueUInt256{Val: nil},
+				"bool":    types.QValueBoolean{Val: true},
+				"qchar":   types.QValueQChar{Val: 'c'},
+				"string":  types.QValueString{Val: "str"},
+				"ts":      types.QValueTimestamp{Val: ts},
+				"tstz":    types.QValueTimestampTZ{Val: ts},
+				"date":    types.QValueDate{Val: ts.Truncate(24 * time.Hour)},
+				"time":    types.QValueTime{Val: timeForTesting},
+				"numeric": types.QValueNumeric{Val: decimalForTesting, Precision: 20, Scale: 5},
+				"bytes":   types.QValueBytes{Val: []byte{}},
+				"nobytes": types.QValueBytes{Val: nil},
+				"uuid":    types.QValueUUID{Val: id},
+				"json":    types.QValueJSON{Val: `[{"a":1}]`, IsArray: true},
+				"a_f64":   types.QValueArrayFloat64{Val: []float64{1, 2}},
+				"a_i32":   types.QValueArrayInt32{Val: []int32{}},
+				"a_str":   types.QValueArrayString{Val: []string{"a", ""}},
+				"a_ts":    types.QValueArrayTimestampTZ{Val: []time.Time{ts}},
+				"a_bool":  types.QValueArrayBoolean{Val: nil},
+				"a_uuid":  types.QValueArrayUUID{Val: []uuid.UUID{id}},
+				"a_num":   types.QValueArrayNumeric{Val: []decimal.Decimal{decimalForTesting}, Precision: 10, Scale: 2},
+				"struct": types.QValueStruct{Val: []types.QValueStructField{
+					{Name: "street", Val: types.QValueString{Val: "Main St"}},
+					{Name: "zip", Val: types.QValueNull(types.QValueKindInt32)},
+				}},
+				"map": types.QValueMap{Val: map[string]types.QValue{"k": types.QValueInt64{Val: 1}}},
+				"a_struct": types.QValueArrayStruct{Val: []types.QValueStruct{
+					types.NewQValueRange(types.QValueInt32{Val: 1}, types.QValueNull(types.QValueKindInt32), true, false),
+				}},
+			},
+			TruncateThresholdBytes: 1024,
+		},
+	}
+	encoded, err := encodeSpillRecord(nil, model.Record[model.RecordItems](update))
+	require.NoError(t, err)
+	decoded, err := decodeSpillRecord[model.RecordItems](encoded)
+	require.NoError(t, err)
+	require.Equal(t, model.Record[model.RecordItems](update), decoded)
+
+	_, err = decodeSpillRecord[model.RecordItems](encoded[:len(encoded)-1])
+	require.Error(t, err)
+
+	del := &model.DeleteRecord[model.PgItems]{
+		BaseRecord:           model.BaseRecord{CheckpointID: 3},
+		SourceTableName:      "public.src",
+		DestinationTableName: "dst",
+		Items: model.PgItems{ColToVal: map[string][]byte{
+			"id":   []byte("1"),
+			"null": nil,
+		}},
+	}
+	encoded, err = encodeSpillRecord(nil, model.Record[model.PgItems](del))
+	require.NoError(t, err)
+	decodedDel, err := decodeSpillRecord[model.PgItems](encoded)
+	require.NoError(t, err)
+	require.Equal(t, model.Record[model.PgItems](del), decodedDel)
+}
+
+func TestSpillIsEncrypted(t *testing.T) {
+	t.Parallel()
+
+	cdcRecordsStore, err := NewCDCStore[model.RecordItems](t.Context(), spillTestEnv(t, "0"), nil, "test_spill_encrypted")
+	require.NoError(t, err)
+
+	key, rec := genKeyAndRec(t)
+	secret := "4111-1111-1111-1111"
+	rec.GetItems().ColToVal["card"] = types.QValueString{Val: secret}
+	require.NoError(t, cdcRecordsStore.Set(t.Context(), key, rec))
+	require.NotNil(t, cdcRecordsStore.pebbleDB)
+	require.Positive(t, cdcRecordsStore.storeSpilledBytes)
+	// overwriting a key replaces its size instead of adding to it
+	spilled := cdcRecordsStore.storeSpilledBytes
+	require.NoError(t, cdcRecordsStore.Set(t.Context(), key, rec))
+	require.Equal(t, spilled, cdcRecordsStore.storeSpilledBytes)
+
+	iter, err := cdcRecordsStore.pebbleDB.NewIter(nil)
+	require.NoError(t, err)
+	numKeys := 0
+	for iter.First(); iter.Valid(); iter.Next() {
+		numKeys += 1
+		require.False(t, bytes.Contains(iter.Key(), []byte(key.TableName)))
+		require.False(t, bytes.Contains(iter.Key(), key.PkeyColVal[:]))
+		value := iter.Value()
+		require.False(t, bytes.Contains(value, []byte(secret)))
+		require.False(t, bytes.Contains(value, []byte("test_dst_tbl")))
+	}
+	require.NoError(t, iter.Close())
+	require.Equal(t, 1, numKeys)
+
+	retrieved, ok, err := cdcRecordsStore.Get(t.Context(), key)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, rec, retrieved)
+
+	// another store can't read these records, as each store has its own key
+	otherStore, err := NewCDCStore[model.RecordItems](t.Context(), spillTestEnv(t, "0"), nil, "test_spill_encrypted_other")
+	require.NoError(t, err)
+	sealed, closer, err := cdcRecordsStore.pebbleDB.Get(cdcRecordsStore.encodeKey(key))
+	require.NoError(t, err)
+	_, err = otherStore.open(cdcRecordsStore.encodeKey(key), sealed)
+	require.Error(t, err)
+	require.NoError(t, closer.Close())
+
+	require.NoError(t, otherStore.Close())
+	require.NoError(t, cdcRecordsStore.Close())
+	require.Zero(t, cdcRecordsStore.storeSpilledBytes)
+}
+
+func TestSpillQuota(t *testing.T) {
+	t.Parallel()
+
+	cdcRecordsStore, err := NewCDCStore[model.RecordItems](t.Context(), spillTestEnv(t, "1"), nil, "test_spill_quota")
+	require.NoError(t, err)
+
+	key, rec := genKeyAndRec(t)
+	require.ErrorContains(t, cdcRecordsStore.Set(t.Context(), key, rec), "PEERDB_CDC_DISK_SPILL_MAX_BYTES")
+	require.Zero(t, cdcRecordsStore.storeSpilledBytes)
+	_, ok, err := cdcRecordsStore.Get(t.Context(), key)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, cdcRecordsStore.Close())
+}
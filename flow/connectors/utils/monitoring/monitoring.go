@@ -3,6 +3,7 @@ package monitoring
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strconv"
@@ -11,16 +12,19 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/log"
 
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
 	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
 )
 
 type CDCBatchInfo struct {
@@ -78,6 +82,7 @@ func AddCDCBatchForFlow(ctx context.Context, pool shared.CatalogPool, flowJobNam
 	return nil
 }
 
+// UpdateNumRowsAndEndLSNForCDCBatch marks a batch synced, with the NUMERIC values that lost precision in it
 func UpdateNumRowsAndEndLSNForCDCBatch(
 	ctx context.Context,
 	pool shared.CatalogPool,
@@ -87,8 +92,14 @@ func UpdateNumRowsAndEndLSNForCDCBatch(
 	batchEndCheckpoint model.CdcCheckpoint,
 	firstRowReceivedAt *time.Time,
 	firstRowCommitTime *time.Time,
+	warnings shared.QRepWarnings,
 ) error {
-	if _, err := pool.Exec(ctx,
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error while beginning transaction for cdc_batch: %w", err)
+	}
+	defer shared.RollbackTx(tx, internal.LoggerFromCtx(ctx))
+	if _, err := tx.Exec(ctx,
 		`UPDATE peerdb_stats.cdc_batches
 		SET rows_in_batch=$1, batch_end_lsn=$2, batch_end_lsn_text=$3, sync_time=NOW(),
 		first_row_received_at=$4, first_row_commit_time=$5
@@ -98,6 +109,12 @@ func UpdateNumRowsAndEndLSNForCDCBatch(
 	); err != nil {
 		return fmt.Errorf("error while updating batch in cdc_batch: %w", err)
 	}
+	if err := insertNumericTruncationCounts(ctx, tx, flowJobName, strconv.FormatInt(batchID, 10), warnings); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error while committing batch in cdc_batch: %w", err)
+	}
 	return nil
 }
 
@@ -268,6 +285,46 @@ func AddCDCBatchTablesForFlow(
 	return nil
 }
 
+// insertNumericTruncationCounts records NUMERIC values that lost precision per destination column,
+// taken from the warnings of the sync identified by syncID. A retried sync is not counted again
+func insertNumericTruncationCounts(
+	ctx context.Context, tx pgx.Tx, flowJobName string, syncID string, warnings shared.QRepWarnings,
+) error {
+	type column struct {
+		table  string
+		column string
+	}
+	type counts struct {
+		truncated uint64
+		cleared   uint64
+	}
+	countsByColumn := make(map[column]counts)
+	for _, warning := range warnings {
+		if truncatedErr, ok := errors.AsType[*exceptions.NumericTruncatedError](warning); ok {
+			col := column{truncatedErr.DestinationTable, truncatedErr.DestinationColumn}
+			c := countsByColumn[col]
+			c.truncated += truncatedErr.Count
+			countsByColumn[col] = c
+		} else if outOfRangeErr, ok := errors.AsType[*exceptions.NumericOutOfRangeError](warning); ok {
+			col := column{outOfRangeErr.DestinationTable, outOfRangeErr.DestinationColumn}
+			c := countsByColumn[col]
+			c.cleared += outOfRangeErr.Count
+			countsByColumn[col] = c
+		}
+	}
+
+	for col, c := range countsByColumn {
+		if _, err := tx.Exec(ctx, `INSERT INTO peerdb_stats.numeric_truncation_counts (
+				flow_name, sync_id, destination_table_name, destination_column_name, truncated_count, cleared_count
+			) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT DO NOTHING`,
+			flowJobName, syncID, col.table, col.column, int64(c.truncated), int64(c.cleared),
+		); err != nil {
+			return fmt.Errorf("error while inserting into numeric_truncation_counts: %w", err)
+		}
+	}
+	return nil
+}
+
 func InitializeQRepRun(
 	ctx context.Context,
 	logger log.Logger,
@@ -301,6 +358,46 @@ func InitializeQRepRun(
 	return tx.Commit(ctx)
 }
 
+// RecordPartitionSplit adds the pieces a straggling partition was split into to its run and
+// closes the split partition, pieces that already exist are kept so retries are idempotent
+func RecordPartitionSplit(
+	ctx context.Context,
+	logger log.Logger,
+	pool shared.CatalogPool,
+	config *protos.QRepConfig,
+	runUUID string,
+	partition *protos.QRepPartition,
+	pieces []*protos.QRepPartition,
+) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error while starting transaction to record partition split: %w", err)
+	}
+	defer shared.RollbackTx(tx, logger)
+
+	pieceIDs := make([]string, 0, len(pieces))
+	for _, piece := range pieces {
+		if err := addPartitionToQRepRun(ctx, tx, config.FlowJobName, runUUID, piece, config.ParentMirrorName); err != nil {
+			return fmt.Errorf("unable to add split partition to qrep run: %w", err)
+		}
+		pieceIDs = append(pieceIDs, piece.PartitionId)
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE peerdb_stats.qrep_partitions SET split_from=$1, restart_count=0 WHERE run_uuid=$2 AND partition_uuid=ANY($3)`,
+		partition.PartitionId, runUUID, pieceIDs,
+	); err != nil {
+		return fmt.Errorf("error while linking split partitions in qrep_partitions: %w", err)
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE peerdb_stats.qrep_partitions SET end_time=$1 WHERE run_uuid=$2 AND partition_uuid=$3`,
+		time.Now(), runUUID, partition.PartitionId,
+	); err != nil {
+		return fmt.Errorf("error while closing split partition in qrep_partitions: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
 func UpdateStartTimeForQRepRun(ctx context.Context, pool shared.CatalogPool, runUUID string) error {
 	if _, err := pool.Exec(ctx,
 		"UPDATE peerdb_stats.qrep_runs SET start_time=$1, fetch_complete=true WHERE run_uuid=$2",
@@ -397,6 +494,13 @@ func addPartitionToQRepRun(ctx context.Context, tx pgx.Tx, flowJobName string,
 			// quote so bytes that postgres text columns reject (e.g. NUL, which
 			// would wedge the snapshot in a retry loop) become visible escapes
 			rangeStart, rangeEnd = new(strconv.Quote(x.StringRange.Start)), new(strconv.Quote(x.StringRange.End))
+		case *protos.PartitionRange_MongoShardKeyRange:
+			rangeStart, rangeEnd = new(bson.Raw(x.MongoShardKeyRange.Min).String()), new(bson.Raw(x.MongoShardKeyRange.Max).String())
+		case *protos.PartitionRange_CompositeKeyRange:
+			if len(x.CompositeKeyRange.Start) > 0 {
+				rangeStart = new(utils.CompositeKeyString(x.CompositeKeyRange.Start))
+			}
+			rangeEnd = new(utils.CompositeKeyString(x.CompositeKeyRange.End))
 		case *protos.PartitionRange_NullRange:
 			// leave rangeStart and rangeEnd as nil
 		default:
@@ -458,15 +562,27 @@ func UpdatePullEndTimeAndRowsForPartition(ctx context.Context, pool shared.Catal
 	return nil
 }
 
-func UpdateEndTimeForPartition(ctx context.Context, pool shared.CatalogPool, runUUID string,
-	partition *protos.QRepPartition,
+// UpdateEndTimeForPartition marks a partition synced, with the NUMERIC values that lost precision in it
+func UpdateEndTimeForPartition(ctx context.Context, pool shared.CatalogPool, flowJobName string, runUUID string,
+	partition *protos.QRepPartition, warnings shared.QRepWarnings,
 ) error {
-	if _, err := pool.Exec(ctx,
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error while beginning transaction for qrep_partitions: %w", err)
+	}
+	defer shared.RollbackTx(tx, internal.LoggerFromCtx(ctx))
+	if _, err := tx.Exec(ctx,
 		`UPDATE peerdb_stats.qrep_partitions SET end_time=$1 WHERE run_uuid=$2 AND partition_uuid=$3`,
 		time.Now(), runUUID, partition.PartitionId,
 	); err != nil {
 		return fmt.Errorf("error while updating qrep partition in qrep_partitions: %w", err)
 	}
+	if err := insertNumericTruncationCounts(ctx, tx, flowJobName, partition.PartitionId, warnings); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error while committing qrep partition in qrep_partitions: %w", err)
+	}
 	return nil
 }
 
@@ -482,6 +598,31 @@ func UpdateRowsSyncedForPartition(ctx context.Context, pool shared.CatalogPool,
 	return nil
 }
 
+// UpdateRowsSyncedForPartitionChunk updates the rows synced of a partition after one of its chunks was synced,
+// with the NUMERIC values that lost precision in the chunk
+func UpdateRowsSyncedForPartitionChunk(ctx context.Context, pool shared.CatalogPool, flowJobName string, rowsSynced int64,
+	runUUID string, partition *protos.QRepPartition, chunkID string, warnings shared.QRepWarnings,
+) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error while beginning transaction for qrep_partitions: %w", err)
+	}
+	defer shared.RollbackTx(tx, internal.LoggerFromCtx(ctx))
+	if _, err := tx.Exec(ctx,
+		`UPDATE peerdb_stats.qrep_partitions SET rows_synced=$1 WHERE run_uuid=$2 AND partition_uuid=$3`,
+		rowsSynced, runUUID, partition.PartitionId,
+	); err != nil {
+		return fmt.Errorf("error while updating rows_synced in qrep_partitions: %w", err)
+	}
+	if err := insertNumericTruncationCounts(ctx, tx, flowJobName, chunkID, warnings); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error while committing rows_synced in qrep_partitions: %w", err)
+	}
+	return nil
+}
+
 func DeleteMirrorStats(ctx context.Context, logger log.Logger, pool shared.CatalogPool, flowJobName string) error {
 	tx, err := pool.Begin(ctx)
 	if err != nil {
@@ -505,6 +646,10 @@ func DeleteMirrorStats(ctx context.Context, logger log.Logger, pool shared.Catal
 		return fmt.Errorf("error while deleting cdc_table_aggregate_counts: %w", err)
 	}
 
+	if _, err := tx.Exec(ctx, `DELETE FROM peerdb_stats.numeric_truncation_counts WHERE flow_name = $1`, flowJobName); err != nil {
+		return fmt.Errorf("error while deleting numeric_truncation_counts: %w", err)
+	}
+
 	if _, err := tx.Exec(ctx, `DELETE FROM peerdb_stats.cdc_flows WHERE flow_name = $1`, flowJobName); err != nil {
 		return fmt.Errorf("error while deleting cdc_flows: %w", err)
 	}
@@ -2,16 +2,25 @@ package utils
 
 import (
 	"cmp"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 	"go.temporal.io/sdk/log"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared"
 )
 
 const FullTablePartitionID = "full-table-partition-id"
@@ -163,10 +172,7 @@ func NewPartitionHelper(logger log.Logger) *PartitionHelper {
 }
 
 func (p *PartitionHelper) AddNullPartition() {
-	p.partitions = append(p.partitions, &protos.QRepPartition{
-		PartitionId: uuid.NewString(),
-		Range:       &protos.PartitionRange{Range: &protos.PartitionRange_NullRange{NullRange: &protos.NullPartitionRange{}}},
-	})
+	p.partitions = append(p.partitions, CreateNullPartition())
 }
 
 // Function to compare the end of a partition with the start of another
@@ -456,3 +462,275 @@ func CreateNumericPartition(start int64, end int64, endInclusive bool) *protos.Q
 		},
 	}
 }
+
+func CreateNullPartition() *protos.QRepPartition {
+	return &protos.QRepPartition{
+		PartitionId: uuid.NewString(),
+		Range:       &protos.PartitionRange{Range: &protos.PartitionRange_NullRange{NullRange: &protos.NullPartitionRange{}}},
+	}
+}
+
+func CreateCompositeKeyPartition(start []*protos.CompositeKeyValue, end []*protos.CompositeKeyValue) *protos.QRepPartition {
+	return &protos.QRepPartition{
+		PartitionId: uuid.NewString(),
+		Range: &protos.PartitionRange{
+			Range: &protos.PartitionRange_CompositeKeyRange{
+				CompositeKeyRange: &protos.CompositeKeyPartitionRange{
+					Start: start,
+					End:   end,
+				},
+			},
+		},
+	}
+}
+
+// CompositeKeyPartitions chains the last keys of consecutive buckets, in key order, into
+// contiguous keyset partitions (after, end0], (end0, end1], ... where after is the end of
+// the last partition when resuming and nil otherwise. Repeated ends are skipped, buckets
+// can end on the same key when the key is not unique.
+func CompositeKeyPartitions(after []*protos.CompositeKeyValue, bucketEnds [][]*protos.CompositeKeyValue) []*protos.QRepPartition {
+	partitions := make([]*protos.QRepPartition, 0, len(bucketEnds))
+	start := after
+	for _, end := range bucketEnds {
+		if start != nil && slices.EqualFunc(start, end, func(a, b *protos.CompositeKeyValue) bool {
+			return proto.Equal(a, b)
+		}) {
+			continue
+		}
+		partitions = append(partitions, CreateCompositeKeyPartition(start, end))
+		start = end
+	}
+	return partitions
+}
+
+// CompositeKeyCondition renders the row-value comparison selecting the rows of a composite
+// key partition, bound renders a key value as a literal or a query parameter
+func CompositeKeyCondition(
+	quotedColumns []string,
+	r *protos.CompositeKeyPartitionRange,
+	bound func(*protos.CompositeKeyValue) string,
+) (string, error) {
+	if len(r.End) != len(quotedColumns) || (len(r.Start) > 0 && len(r.Start) != len(quotedColumns)) {
+		return "", fmt.Errorf("composite key partition bounds do not match key columns %v", quotedColumns)
+	}
+	tuple := func(values []*protos.CompositeKeyValue) string {
+		rendered := make([]string, 0, len(values))
+		for _, value := range values {
+			rendered = append(rendered, bound(value))
+		}
+		return "(" + strings.Join(rendered, ",") + ")"
+	}
+	row := "(" + strings.Join(quotedColumns, ",") + ")"
+	var condition string
+	if len(r.Start) > 0 {
+		condition = row + " > " + tuple(r.Start) + " AND "
+	}
+	return condition + row + " <= " + tuple(r.End), nil
+}
+
+// NullKeyCondition matches rows with a NULL in any key column, comparisons never select those
+func NullKeyCondition(quotedColumns []string) string {
+	conditions := make([]string, 0, len(quotedColumns))
+	for _, column := range quotedColumns {
+		conditions = append(conditions, column+" IS NULL")
+	}
+	return strings.Join(conditions, " OR ")
+}
+
+// CompositeKeyString formats a composite key for logs and monitoring
+func CompositeKeyString(values []*protos.CompositeKeyValue) string {
+	rendered := make([]string, 0, len(values))
+	for _, value := range values {
+		switch v := value.GetValue().(type) {
+		case *protos.CompositeKeyValue_IntValue:
+			rendered = append(rendered, strconv.FormatInt(v.IntValue, 10))
+		case *protos.CompositeKeyValue_UintValue:
+			rendered = append(rendered, strconv.FormatUint(v.UintValue, 10))
+		case *protos.CompositeKeyValue_StringValue:
+			rendered = append(rendered, strconv.Quote(v.StringValue))
+		case *protos.CompositeKeyValue_BytesValue:
+			rendered = append(rendered, "0x"+hex.EncodeToString(v.BytesValue))
+		default:
+			rendered = append(rendered, "NULL")
+		}
+	}
+	return "(" + strings.Join(rendered, ",") + ")"
+}
+
+// ErrPartitionRangeNotSplittable is returned by SplitPartition for ranges whose keys cannot be divided
+// without sampling the table: strings, composite keys, MongoDB shard key ranges and NULL keys
+var ErrPartitionRangeNotSplittable = errors.New("partition range cannot be split")
+
+// SplitPartition splits the range of a partition into at most numPieces contiguous partitions,
+// which replace it. Piece ids derive from the partition id so that a retried split yields the same pieces.
+// Returns nil when the range is too narrow to split.
+func SplitPartition(logger log.Logger, partition *protos.QRepPartition, numPieces int64) ([]*protos.QRepPartition, error) {
+	if partition.Range == nil || numPieces < 2 {
+		return nil, nil
+	}
+
+	partitionHelper := NewPartitionHelper(logger)
+	switch r := partition.Range.Range.(type) {
+	case *protos.PartitionRange_IntRange:
+		if err := partitionHelper.AddPartitionsWithRange(r.IntRange.Start, r.IntRange.End, numPieces); err != nil {
+			return nil, err
+		}
+	case *protos.PartitionRange_UintRange:
+		if err := partitionHelper.AddPartitionsWithRange(r.UintRange.Start, r.UintRange.End, numPieces); err != nil {
+			return nil, err
+		}
+	case *protos.PartitionRange_TimestampRange:
+		if err := partitionHelper.AddPartitionsWithRange(
+			r.TimestampRange.Start.AsTime(), r.TimestampRange.End.AsTime(), numPieces,
+		); err != nil {
+			return nil, err
+		}
+	case *protos.PartitionRange_TidRange:
+		// split along blocks, offsets within a block are not evenly populated
+		start, end := r.TidRange.Start, r.TidRange.End
+		numBlocks := int64(end.BlockNumber) - int64(start.BlockNumber) + 1
+		for i := range numPieces {
+			blockStart := uint32(int64(start.BlockNumber) + i*numBlocks/numPieces)
+			nextBlockStart := uint32(int64(start.BlockNumber) + (i+1)*numBlocks/numPieces)
+			if nextBlockStart <= blockStart {
+				continue
+			}
+			pieceStart := pgtype.TID{BlockNumber: blockStart, OffsetNumber: 0, Valid: true}
+			if i == 0 {
+				pieceStart.OffsetNumber = uint16(start.OffsetNumber)
+			}
+			pieceEnd := pgtype.TID{BlockNumber: nextBlockStart - 1, OffsetNumber: math.MaxUint16, Valid: true}
+			if nextBlockStart-1 == end.BlockNumber {
+				pieceEnd.OffsetNumber = uint16(end.OffsetNumber)
+			}
+			if err := partitionHelper.AddPartition(pieceStart, pieceEnd); err != nil {
+				return nil, err
+			}
+		}
+	case *protos.PartitionRange_ObjectIdRange:
+		pieces, err := splitObjectIdRange(r.ObjectIdRange, numPieces)
+		if err != nil {
+			return nil, err
+		}
+		partitionHelper.AddPartitions(pieces)
+	case *protos.PartitionRange_NumericRange:
+		partitionHelper.AddPartitions(splitNumericRange(r.NumericRange, numPieces))
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrPartitionRangeNotSplittable, r)
+	}
+
+	pieces := partitionHelper.GetPartitions()
+	if len(pieces) < 2 {
+		return nil, nil
+	}
+	for i, piece := range pieces {
+		piece.PartitionId = splitPartitionID(partition.PartitionId, i)
+	}
+	return pieces, nil
+}
+
+// splitObjectIdRange splits an inclusive ObjectId range as 96-bit integers, like MongoDB partitions are built
+func splitObjectIdRange(r *protos.ObjectIdPartitionRange, numPieces int64) ([]*protos.QRepPartition, error) {
+	parse := func(objectID string) (*big.Int, error) {
+		b, err := hex.DecodeString(objectID)
+		if err != nil || len(b) != 12 {
+			return nil, fmt.Errorf("invalid ObjectId %s", objectID)
+		}
+		return new(big.Int).SetBytes(b), nil
+	}
+	start, err := parse(r.Start)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parse(r.End)
+	if err != nil {
+		return nil, err
+	}
+	if start.Cmp(end) > 0 {
+		return nil, nil
+	}
+
+	size := new(big.Int).Sub(end, start)
+	size.Add(size, big.NewInt(1))
+	step := shared.BigIntDivCeil(size, big.NewInt(numPieces))
+	pieces := make([]*protos.QRepPartition, 0, numPieces)
+	for pieceStart := new(big.Int).Set(start); pieceStart.Cmp(end) <= 0; pieceStart.Add(pieceStart, step) {
+		pieceEnd := new(big.Int).Add(pieceStart, step)
+		pieceEnd.Sub(pieceEnd, big.NewInt(1))
+		if pieceEnd.Cmp(end) > 0 {
+			pieceEnd.Set(end)
+		}
+		pieces = append(pieces, &protos.QRepPartition{
+			Range: &protos.PartitionRange{Range: &protos.PartitionRange_ObjectIdRange{
+				ObjectIdRange: &protos.ObjectIdPartitionRange{
+					Start: fmt.Sprintf("%024x", pieceStart),
+					End:   fmt.Sprintf("%024x", pieceEnd),
+				},
+			}},
+		})
+	}
+	return pieces, nil
+}
+
+// splitNumericRange splits a numeric range into [start, end) pieces, the last keeps the range's end bound
+func splitNumericRange(r *protos.NumericPartitionRange, numPieces int64) []*protos.QRepPartition {
+	if r.Start >= r.End {
+		return nil
+	}
+	start, end := big.NewInt(r.Start), big.NewInt(r.End)
+	size := new(big.Int).Sub(end, start)
+	pieces := make([]*protos.QRepPartition, 0, numPieces)
+	pieceStart := r.Start
+	for i := int64(1); i <= numPieces; i++ {
+		boundary := new(big.Int).Mul(size, big.NewInt(i))
+		boundary.Quo(boundary, big.NewInt(numPieces))
+		pieceEnd := boundary.Add(boundary, start).Int64()
+		if pieceEnd <= pieceStart {
+			continue
+		}
+		pieces = append(pieces, CreateNumericPartition(pieceStart, pieceEnd, i == numPieces && r.EndInclusive))
+		pieceStart = pieceEnd
+	}
+	return pieces
+}
+
+func splitPartitionID(partitionID string, piece int) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(partitionID+"/"+strconv.Itoa(piece))).String()
+}
+
+// SpanPartitionRanges returns the range from the start of first to the end of last,
+// both of the same type built by PartitionHelper
+func SpanPartitionRanges(first *protos.PartitionRange, last *protos.PartitionRange) (*protos.PartitionRange, error) {
+	switch r := first.Range.(type) {
+	case *protos.PartitionRange_IntRange:
+		if l, ok := last.Range.(*protos.PartitionRange_IntRange); ok {
+			return &protos.PartitionRange{Range: &protos.PartitionRange_IntRange{
+				IntRange: &protos.IntPartitionRange{Start: r.IntRange.Start, End: l.IntRange.End},
+			}}, nil
+		}
+	case *protos.PartitionRange_UintRange:
+		if l, ok := last.Range.(*protos.PartitionRange_UintRange); ok {
+			return &protos.PartitionRange{Range: &protos.PartitionRange_UintRange{
+				UintRange: &protos.UIntPartitionRange{Start: r.UintRange.Start, End: l.UintRange.End},
+			}}, nil
+		}
+	case *protos.PartitionRange_TimestampRange:
+		if l, ok := last.Range.(*protos.PartitionRange_TimestampRange); ok {
+			return &protos.PartitionRange{Range: &protos.PartitionRange_TimestampRange{
+				TimestampRange: &protos.TimestampPartitionRange{Start: r.TimestampRange.Start, End: l.TimestampRange.End},
+			}}, nil
+		}
+	case *protos.PartitionRange_TidRange:
+		if l, ok := last.Range.(*protos.PartitionRange_TidRange); ok {
+			return &protos.PartitionRange{Range: &protos.PartitionRange_TidRange{
+				TidRange: &protos.TIDPartitionRange{Start: r.TidRange.Start, End: l.TidRange.End},
+			}}, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot span partition ranges of type %T and %T", first.Range, last.Range)
+}
+
+// PartitionChunkID returns the id under which a chunk of a checkpointed partition is synced
+func PartitionChunkID(partitionID string, chunk int32) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(partitionID+"/chunk/"+strconv.Itoa(int(chunk)))).String()
+}
@@ -136,3 +136,177 @@ func TestAddPartitionsWithRange(t *testing.T) {
 		require.Equal(t, int64(5), intRangeOf(t, partitions[len(partitions)-1]).End)
 	})
 }
+
+func intKey(values ...int64) []*protos.CompositeKeyValue {
+	key := make([]*protos.CompositeKeyValue, 0, len(values))
+	for _, v := range values {
+		key = append(key, &protos.CompositeKeyValue{Value: &protos.CompositeKeyValue_IntValue{IntValue: v}})
+	}
+	return key
+}
+
+func TestCompositeKeyPartitions(t *testing.T) {
+	partitions := CompositeKeyPartitions(nil, [][]*protos.CompositeKeyValue{intKey(1, 5), intKey(1, 5), intKey(2, 1), intKey(3, 9)})
+	bounds := make([]string, 0, len(partitions))
+	for _, partition := range partitions {
+		r := partition.Range.GetCompositeKeyRange()
+		require.NotNil(t, r)
+		bounds = append(bounds, CompositeKeyString(r.Start)+"-"+CompositeKeyString(r.End))
+	}
+	require.Equal(t, []string{"()-(1,5)", "(1,5)-(2,1)", "(2,1)-(3,9)"}, bounds)
+
+	resumed := CompositeKeyPartitions(intKey(3, 9), [][]*protos.CompositeKeyValue{intKey(3, 9), intKey(4, 0)})
+	require.Len(t, resumed, 1)
+	require.Equal(t, "(3,9)", CompositeKeyString(resumed[0].Range.GetCompositeKeyRange().Start))
+
+	require.Empty(t, CompositeKeyPartitions(nil, nil))
+}
+
+func TestCompositeKeyCondition(t *testing.T) {
+	columns := []string{`"a"`, `"b"`}
+	param := 0
+	bound := func(*protos.CompositeKeyValue) string {
+		param++
+		return "$" + string(rune('0'+param))
+	}
+
+	condition, err := CompositeKeyCondition(columns, &protos.CompositeKeyPartitionRange{End: intKey(1, 2)}, bound)
+	require.NoError(t, err)
+	require.Equal(t, `("a","b") <= ($1,$2)`, condition)
+
+	param = 0
+	condition, err = CompositeKeyCondition(columns, &protos.CompositeKeyPartitionRange{Start: intKey(1, 2), End: intKey(3, 4)}, bound)
+	require.NoError(t, err)
+	require.Equal(t, `("a","b") > ($1,$2) AND ("a","b") <= ($3,$4)`, condition)
+
+	_, err = CompositeKeyCondition(columns, &protos.CompositeKeyPartitionRange{End: intKey(1)}, bound)
+	require.Error(t, err)
+
+	require.Equal(t, `"a" IS NULL OR "b" IS NULL`, NullKeyCondition(columns))
+}
+
+func TestSplitPartition(t *testing.T) {
+	logger := log.NewStructuredLogger(slog.Default())
+
+	t.Run("int range splits into contiguous pieces with stable ids", func(t *testing.T) {
+		partition := &protos.QRepPartition{
+			PartitionId: "straggler",
+			Range: &protos.PartitionRange{Range: &protos.PartitionRange_IntRange{
+				IntRange: &protos.IntPartitionRange{Start: 1, End: 100},
+			}},
+		}
+		pieces, err := SplitPartition(logger, partition, 4)
+		require.NoError(t, err)
+		require.Len(t, pieces, 4)
+		require.Equal(t, int64(1), intRangeOf(t, pieces[0]).Start)
+		require.Equal(t, int64(100), intRangeOf(t, pieces[len(pieces)-1]).End)
+		for i := 1; i < len(pieces); i++ {
+			require.Equal(t, intRangeOf(t, pieces[i-1]).End+1, intRangeOf(t, pieces[i]).Start)
+		}
+
+		retried, err := SplitPartition(logger, partition, 4)
+		require.NoError(t, err)
+		for i := range pieces {
+			require.Equal(t, pieces[i].PartitionId, retried[i].PartitionId)
+			require.NotEqual(t, partition.PartitionId, pieces[i].PartitionId)
+		}
+	})
+
+	t.Run("tid range splits along blocks", func(t *testing.T) {
+		partition := &protos.QRepPartition{
+			PartitionId: "straggler",
+			Range: &protos.PartitionRange{Range: &protos.PartitionRange_TidRange{
+				TidRange: &protos.TIDPartitionRange{
+					Start: &protos.TID{BlockNumber: 10, OffsetNumber: 5},
+					End:   &protos.TID{BlockNumber: 19, OffsetNumber: 7},
+				},
+			}},
+		}
+		pieces, err := SplitPartition(logger, partition, 2)
+		require.NoError(t, err)
+		require.Len(t, pieces, 2)
+		first := pieces[0].Range.GetTidRange()
+		second := pieces[1].Range.GetTidRange()
+		require.Equal(t, &protos.TID{BlockNumber: 10, OffsetNumber: 5}, first.Start)
+		require.Equal(t, &protos.TID{BlockNumber: 14, OffsetNumber: math.MaxUint16}, first.End)
+		require.Equal(t, &protos.TID{BlockNumber: 15, OffsetNumber: 0}, second.Start)
+		require.Equal(t, &protos.TID{BlockNumber: 19, OffsetNumber: 7}, second.End)
+	})
+
+	t.Run("single value range is not split", func(t *testing.T) {
+		pieces, err := SplitPartition(logger, &protos.QRepPartition{
+			PartitionId: "straggler",
+			Range: &protos.PartitionRange{Range: &protos.PartitionRange_IntRange{
+				IntRange: &protos.IntPartitionRange{Start: 7, End: 7},
+			}},
+		}, 4)
+		require.NoError(t, err)
+		require.Nil(t, pieces)
+	})
+
+	t.Run("object id range splits into contiguous pieces", func(t *testing.T) {
+		pieces, err := SplitPartition(logger, &protos.QRepPartition{
+			PartitionId: "straggler",
+			Range: &protos.PartitionRange{Range: &protos.PartitionRange_ObjectIdRange{
+				ObjectIdRange: &protos.ObjectIdPartitionRange{Start: "65a000000000000000000000", End: "65a0000000000000000000ff"},
+			}},
+		}, 2)
+		require.NoError(t, err)
+		require.Len(t, pieces, 2)
+		require.Equal(t, &protos.ObjectIdPartitionRange{Start: "65a000000000000000000000", End: "65a00000000000000000007f"},
+			pieces[0].Range.GetObjectIdRange())
+		require.Equal(t, &protos.ObjectIdPartitionRange{Start: "65a000000000000000000080", End: "65a0000000000000000000ff"},
+			pieces[1].Range.GetObjectIdRange())
+	})
+
+	t.Run("numeric range keeps its end bound on the last piece", func(t *testing.T) {
+		pieces, err := SplitPartition(logger, &protos.QRepPartition{
+			PartitionId: "straggler",
+			Range: &protos.PartitionRange{Range: &protos.PartitionRange_NumericRange{
+				NumericRange: &protos.NumericPartitionRange{Start: 0, End: 9, EndInclusive: true},
+			}},
+		}, 3)
+		require.NoError(t, err)
+		require.Len(t, pieces, 3)
+		require.Equal(t, &protos.NumericPartitionRange{Start: 0, End: 3}, pieces[0].Range.GetNumericRange())
+		require.Equal(t, &protos.NumericPartitionRange{Start: 3, End: 6}, pieces[1].Range.GetNumericRange())
+		require.Equal(t, &protos.NumericPartitionRange{Start: 6, End: 9, EndInclusive: true}, pieces[2].Range.GetNumericRange())
+	})
+
+	t.Run("ranges without divisible keys are rejected", func(t *testing.T) {
+		for _, partition := range []*protos.QRepPartition{
+			CreateNullPartition(),
+			CreateStringPartition("a", "z", true),
+			CreateCompositeKeyPartition(nil, []*protos.CompositeKeyValue{
+				{Value: &protos.CompositeKeyValue_IntValue{IntValue: 1}},
+			}),
+		} {
+			_, err := SplitPartition(logger, partition, 4)
+			require.ErrorIs(t, err, ErrPartitionRangeNotSplittable)
+		}
+	})
+}
+
+func TestSpanPartitionRanges(t *testing.T) {
+	first := &protos.PartitionRange{Range: &protos.PartitionRange_TidRange{TidRange: &protos.TIDPartitionRange{
+		Start: &protos.TID{BlockNumber: 5}, End: &protos.TID{BlockNumber: 9, OffsetNumber: math.MaxUint16},
+	}}}
+	last := &protos.PartitionRange{Range: &protos.PartitionRange_TidRange{TidRange: &protos.TIDPartitionRange{
+		Start: &protos.TID{BlockNumber: 10}, End: &protos.TID{BlockNumber: 20, OffsetNumber: 3},
+	}}}
+	span, err := SpanPartitionRanges(first, last)
+	require.NoError(t, err)
+	require.Equal(t, &protos.TID{BlockNumber: 5}, span.GetTidRange().Start)
+	require.Equal(t, &protos.TID{BlockNumber: 20, OffsetNumber: 3}, span.GetTidRange().End)
+
+	_, err = SpanPartitionRanges(first, &protos.PartitionRange{Range: &protos.PartitionRange_IntRange{
+		IntRange: &protos.IntPartitionRange{Start: 1, End: 2},
+	}})
+	require.Error(t, err)
+}
+
+func TestPartitionChunkID(t *testing.T) {
+	require.Equal(t, PartitionChunkID("partition", 1), PartitionChunkID("partition", 1))
+	require.NotEqual(t, PartitionChunkID("partition", 1), PartitionChunkID("partition", 2))
+	require.NotEqual(t, PartitionChunkID("partition", 1), splitPartitionID("partition", 1))
+}
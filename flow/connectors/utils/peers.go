@@ -99,6 +99,18 @@ func CreatePeerNoValidate(
 			return wrongConfigResponse, nil
 		}
 		innerConfig = crdbConfigObject.CockroachdbConfig
+	case protos.DBType_ORACLE:
+		oracleConfigObject, ok := config.(*protos.Peer_OracleConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = oracleConfigObject.OracleConfig
+	case protos.DBType_DYNAMODB:
+		dynamodbConfigObject, ok := config.(*protos.Peer_DynamodbConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = dynamodbConfigObject.DynamodbConfig
 	default:
 		return wrongConfigResponse, nil
 	}
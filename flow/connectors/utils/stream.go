@@ -89,9 +89,12 @@ func recordToQRecordOrError(
 	switch typedRecord := record.(type) {
 	case *model.InsertRecord[model.RecordItems]:
 		tableNumericTruncator := numericTruncator.Get(typedRecord.DestinationTableName)
-		preprocessedItems := truncateNumerics(
+		preprocessedItems, err := truncateNumerics(
 			typedRecord.Items, targetDWH, unboundedNumericAsString, tableNumericTruncator,
 		)
+		if err != nil {
+			return nil, err
+		}
 		itemsJSON, err := model.ItemsToJSON(preprocessedItems)
 		if err != nil {
 			return nil, fmt.Errorf("failed to serialize insert record items to JSON: %w", err)
@@ -103,9 +106,12 @@ func recordToQRecordOrError(
 		entries[7] = types.QValueString{Val: ""}
 	case *model.UpdateRecord[model.RecordItems]:
 		tableNumericTruncator := numericTruncator.Get(typedRecord.DestinationTableName)
-		preprocessedItems := truncateNumerics(
+		preprocessedItems, err := truncateNumerics(
 			typedRecord.NewItems, targetDWH, unboundedNumericAsString, tableNumericTruncator,
 		)
+		if err != nil {
+			return nil, err
+		}
 		newItemsJSON, err := model.ItemsToJSON(preprocessedItems)
 		if err != nil {
 			return nil, fmt.Errorf("failed to serialize update record new items to JSON: %w", err)
@@ -158,7 +164,7 @@ func InitialiseTableRowsMap(tableMaps []*protos.TableMapping) map[string]*model.
 func truncateNumerics(
 	recordItems model.RecordItems, targetDWH protos.DBType, unboundedNumericAsString bool,
 	numericTruncator model.CdcTableNumericTruncator,
-) model.RecordItems {
+) (model.RecordItems, error) {
 	hasNumerics := false
 	for col, val := range recordItems.ColToVal {
 		if numericTruncator.Get(col).Stat != nil {
@@ -169,7 +175,7 @@ func truncateNumerics(
 		}
 	}
 	if !hasNumerics {
-		return recordItems
+		return recordItems, nil
 	}
 
 	newItems := model.NewRecordItems(recordItems.Len())
@@ -180,16 +186,18 @@ func truncateNumerics(
 		if columnTruncator.Stat != nil {
 			switch numeric := val.(type) {
 			case types.QValueNumeric:
-				destType := qvalue.GetNumericDestinationType(
-					numeric.Precision, numeric.Scale, targetDWH, unboundedNumericAsString,
+				destType := qvalue.GetNumericDestinationTypeForStrategy(
+					numeric.Precision, numeric.Scale, targetDWH, unboundedNumericAsString, columnTruncator.Stat.Strategy,
 				)
 				if destType.IsString {
 					newVal = val
 				} else {
-					truncated, _, ok := qvalue.TruncateNumeric(
+					truncated, _, ok, err := qvalue.TruncateNumeric(
 						numeric.Val, destType.Precision, destType.Scale, targetDWH, columnTruncator.Stat,
 					)
-					if !ok {
+					if err != nil {
+						return model.RecordItems{}, err
+					} else if !ok {
 						truncated = decimal.Zero
 					}
 					newVal = types.QValueNumeric{
@@ -199,18 +207,20 @@ func truncateNumerics(
 					}
 				}
 			case types.QValueArrayNumeric:
-				destType := qvalue.GetNumericDestinationType(
-					numeric.Precision, numeric.Scale, targetDWH, unboundedNumericAsString,
+				destType := qvalue.GetNumericDestinationTypeForStrategy(
+					numeric.Precision, numeric.Scale, targetDWH, unboundedNumericAsString, columnTruncator.Stat.Strategy,
 				)
 				if destType.IsString {
 					newVal = val
 				} else {
 					truncatedArr := make([]decimal.Decimal, 0, len(numeric.Val))
 					for _, num := range numeric.Val {
-						truncated, _, ok := qvalue.TruncateNumeric(
+						truncated, _, ok, err := qvalue.TruncateNumeric(
 							num, destType.Precision, destType.Scale, targetDWH, columnTruncator.Stat,
 						)
-						if !ok {
+						if err != nil {
+							return model.RecordItems{}, err
+						} else if !ok {
 							truncated = decimal.Zero
 						}
 						truncatedArr = append(truncatedArr, truncated)
@@ -225,5 +235,5 @@ func truncateNumerics(
 		}
 		newItems.ColToVal[col] = newVal
 	}
-	return newItems
+	return newItems, nil
 }
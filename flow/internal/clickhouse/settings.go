@@ -20,6 +20,9 @@ const (
 	SettingThrowOnMaxPartitionsPerInsertBlock CHSetting = "throw_on_max_partitions_per_insert_block"
 	SettingParallelDistributedInsertSelect    CHSetting = "parallel_distributed_insert_select"
 	SettingMaxTableSizeToDrop                 CHSetting = "max_table_size_to_drop"
+	SettingInsertDeduplicate                  CHSetting = "insert_deduplicate"
+	SettingInsertDeduplicationToken           CHSetting = "insert_deduplication_token"
+	SettingFlattenNested                      CHSetting = "flatten_nested"
 )
 
 // CHSettingMinVersions maps setting names to their minimum required ClickHouse versions that PeerDB supports.
@@ -90,10 +93,8 @@ func (sg *CHSettings) String() string {
 	first := true
 	var sb strings.Builder
 	for _, name := range names {
-		if minVersion, exists := GetMinVersion(name); exists && sg.chVersion != nil {
-			if !chproto.CheckMinVersion(minVersion, *sg.chVersion) {
-				continue
-			}
+		if !sg.supported(name) {
+			continue
 		}
 
 		if first {
@@ -108,3 +109,22 @@ func (sg *CHSettings) String() string {
 	}
 	return sb.String()
 }
+
+// Map returns the settings that meet the ClickHouse version requirement,
+// for passing as query settings over the native protocol rather than in SQL
+func (sg *CHSettings) Map() map[string]string {
+	settings := make(map[string]string, len(sg.settings))
+	for name, val := range sg.settings {
+		if sg.supported(name) {
+			settings[string(name)] = val
+		}
+	}
+	return settings
+}
+
+func (sg *CHSettings) supported(name CHSetting) bool {
+	if minVersion, exists := GetMinVersion(name); exists && sg.chVersion != nil {
+		return chproto.CheckMinVersion(minVersion, *sg.chVersion)
+	}
+	return true
+}
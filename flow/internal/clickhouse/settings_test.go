@@ -56,3 +56,10 @@ func TestCHSettingsVersionFiltering(t *testing.T) {
 	require.Equal(t, " SETTINGS json_type_escape_dots_in_keys=1, throw_on_max_partitions_per_insert_block=0",
 		chSettings.String())
 }
+
+func TestCHSettingsMap(t *testing.T) {
+	chSettings := NewCHSettings(&chproto.Version{Major: 25, Minor: 7, Patch: 0})
+	chSettings.Add(SettingJsonTypeEscapeDotsInKeys, "1")
+	chSettings.Add(SettingInsertDeduplicationToken, "flow_1_0_100")
+	require.Equal(t, map[string]string{"insert_deduplication_token": "flow_1_0_100"}, chSettings.Map())
+}
@@ -43,6 +43,12 @@ func PeerDBFlowWorkerMaxMemBytes() uint64 {
 	return getEnvUint[uint64]("GOMEMLIMIT", 0)
 }
 
+// PEERDB_CDC_DISK_SPILL_DIR is the directory CDC records are spilled to, defaults to the system temp directory,
+// it is a worker setting so mirrors cannot point spill files at other paths
+func PeerDBCDCDiskSpillDir() string {
+	return GetEnvString("PEERDB_CDC_DISK_SPILL_DIR", "")
+}
+
 // PEERDB_CATALOG_HOST
 func PeerDBCatalogHost() string {
 	return GetEnvString("PEERDB_CATALOG_HOST", "")
@@ -132,6 +138,22 @@ func PeerDBClickHouseAllowedDomains() string {
 	return GetEnvString("PEERDB_CLICKHOUSE_ALLOWED_DOMAINS", "")
 }
 
+// PEERDB_CLICKHOUSE_AZURE_STORAGE_CONNECTION_STRING holds the shared key connection string for Azure staging,
+// when unset the staging store authenticates with Azure default credentials against the account URL
+func PeerDBClickHouseAzureStorageConnectionString(ctx context.Context) (string, error) {
+	return GetKmsDecryptedEnvString(ctx, "PEERDB_CLICKHOUSE_AZURE_STORAGE_CONNECTION_STRING", "")
+}
+
+func PeerDBClickHouseAzureStorageAccountURL() string {
+	return GetEnvString("PEERDB_CLICKHOUSE_AZURE_STORAGE_ACCOUNT_URL", "")
+}
+
+// PEERDB_CLICKHOUSE_LOCAL_STAGING_CLICKHOUSE_PATH is the local staging directory as seen by ClickHouse's file(),
+// defaults to the directory PeerDB writes to
+func PeerDBClickHouseLocalStagingClickHousePath() string {
+	return GetEnvString("PEERDB_CLICKHOUSE_LOCAL_STAGING_CLICKHOUSE_PATH", "")
+}
+
 func PeerDBTemporalEnableCertAuth() bool {
 	cert := GetEnvString("TEMPORAL_CLIENT_CERT", "")
 	return strings.TrimSpace(cert) != ""
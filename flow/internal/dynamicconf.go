@@ -95,6 +95,14 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
 		TargetForSetting: protos.DynconfTarget_ALL,
 	},
+	{
+		Name:             "PEERDB_CDC_DISK_SPILL_MAX_BYTES",
+		Description:      "CDC: maximum bytes of spilled records on a worker across all mirrors, syncs fail beyond it, 0 is unlimited",
+		DefaultValue:     "0",
+		ValueType:        protos.DynconfValueType_INT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
 	{
 		Name:             "PEERDB_ENABLE_WAL_HEARTBEAT",
 		Description:      "Enables WAL heartbeat to prevent replication slot lag from increasing during times of no activity",
@@ -126,6 +134,42 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 		ValueType:    protos.DynconfValueType_BOOL,
 		ApplyMode:    protos.DynconfApplyMode_APPLY_MODE_NEW_MIRROR,
 	},
+	{
+		Name: "PEERDB_QREP_PARTITION_SPLIT_FACTOR",
+		Description: "Splits a partition across idle workers once it runs this many times longer than the median " +
+			"partition of its table, 0 disables splitting",
+		DefaultValue:     "0",
+		ValueType:        protos.DynconfValueType_INT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_NEW_MIRROR,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_QREP_CHECKPOINT_ROWS",
+		Description: "Commits partitions to upserting or deduplicating destinations in chunks of about this many rows, " +
+			"so retried partitions resume after the last chunk, 0 disables checkpointing",
+		DefaultValue:     "0",
+		ValueType:        protos.DynconfValueType_INT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_ORACLE_SKIP_UNSUPPORTED_CHANGES",
+		Description: "Skips changes to replicated tables that Oracle LogMiner cannot decode instead of failing the mirror, " +
+			"skipped changes are lost",
+		DefaultValue:     "false",
+		ValueType:        protos.DynconfValueType_BOOL,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_SECRET_REFRESH_SECONDS",
+		Description: "How long values resolved from secret references in peer configs are cached before being fetched again, " +
+			"secrets leased by Vault are instead refreshed shortly before their lease expires",
+		DefaultValue:     "300",
+		ValueType:        protos.DynconfValueType_INT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
 	{
 		Name:             "PEERDB_NULLABLE",
 		Description:      "Propagate nullability in schema",
@@ -184,7 +228,7 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 	},
 	{
 		Name:             "PEERDB_CLICKHOUSE_STAGING_PROVIDER",
-		Description:      "Cloud storage provider for ClickHouse staging: s3 (default) or gcs",
+		Description:      "Storage provider for ClickHouse staging: s3 (default), gcs, azure or local",
 		DefaultValue:     "s3",
 		ValueType:        protos.DynconfValueType_STRING,
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_AFTER_RESUME,
@@ -297,6 +341,31 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
 		TargetForSetting: protos.DynconfTarget_CLICKHOUSE,
 	},
+	{
+		Name:             "PEERDB_CLICKHOUSE_DIRECT_INSERT_PARALLELISM",
+		Description:      "Number of batches inserted concurrently by ClickHouse peers using the direct insert sync method",
+		DefaultValue:     "4",
+		ValueType:        protos.DynconfValueType_UINT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_CLICKHOUSE,
+	},
+	{
+		Name:             "PEERDB_CLICKHOUSE_DIRECT_INSERT_BATCH_ROWS",
+		Description:      "Rows per insert block for ClickHouse peers using the direct insert sync method",
+		DefaultValue:     "100000",
+		ValueType:        protos.DynconfValueType_UINT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_CLICKHOUSE,
+	},
+	{
+		Name: "PEERDB_CLICKHOUSE_DIRECT_INSERT_MAX_RETRIES",
+		Description: "Times a failed insert block is retried by ClickHouse peers using the direct insert sync method, " +
+			"retries reuse the block's deduplication token",
+		DefaultValue:     "3",
+		ValueType:        protos.DynconfValueType_UINT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_CLICKHOUSE,
+	},
 	{
 		Name: "PEERDB_CLICKHOUSE_PARALLEL_VIEW_PROCESSING",
 		Description: "Enables parallel_view_processing setting on clickhouse, pushing to attached materialized views " +
@@ -322,6 +391,23 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_NEW_MIRROR,
 		TargetForSetting: protos.DynconfTarget_CLICKHOUSE,
 	},
+	{
+		Name: "PEERDB_NAIVE_TIMESTAMP_TIME_ZONE",
+		Description: "Time zone that source timestamps without time zone are recorded in, they are converted to UTC; " +
+			"empty keeps them as UTC",
+		DefaultValue:     "",
+		ValueType:        protos.DynconfValueType_STRING,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_NEW_MIRROR,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name:             "PEERDB_TIMESTAMPTZ_CONVERT_TO_UTC",
+		Description:      "Convert timestamps with time zone to UTC instead of preserving the offset they were read with",
+		DefaultValue:     "false",
+		ValueType:        protos.DynconfValueType_BOOL,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_NEW_MIRROR,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
 	{
 		Name:             "PEERDB_CLICKHOUSE_ENABLE_JSON",
 		Description:      "Map JSON datatype from source to JSON in ClickHouse instead of String",
@@ -528,6 +614,23 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_AFTER_RESUME,
 		TargetForSetting: protos.DynconfTarget_ALL,
 	},
+	{
+		Name: "PEERDB_MONGODB_FLATTEN_DOCUMENTS",
+		Description: "Replicate MongoDB documents as typed columns inferred from sampled documents, " +
+			"with fields that do not fit kept in an overflow JSON column, instead of one JSON column",
+		DefaultValue:     "false",
+		ValueType:        protos.DynconfValueType_BOOL,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_NEW_MIRROR,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name:             "PEERDB_MONGODB_FLATTEN_SAMPLE_SIZE",
+		Description:      "Number of documents sampled per MongoDB collection to infer typed columns",
+		DefaultValue:     "1000",
+		ValueType:        protos.DynconfValueType_INT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_NEW_MIRROR,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
 	{
 		Name: "PEERDB_POSTGRES_RAW_BATCH_CLEANUP_THRESHOLD",
 		Description: "Number of normalized batches to retain in raw table. After normalize, batches older " +
@@ -742,6 +845,10 @@ func PeerDBCDCDiskSpillMemPercentThreshold(ctx context.Context, env map[string]s
 	return dynamicConfSigned[int64](ctx, env, "PEERDB_CDC_DISK_SPILL_MEM_PERCENT_THRESHOLD")
 }
 
+func PeerDBCDCDiskSpillMaxBytes(ctx context.Context, env map[string]string) (int64, error) {
+	return dynamicConfSigned[int64](ctx, env, "PEERDB_CDC_DISK_SPILL_MAX_BYTES")
+}
+
 func PeerDBEnableWALHeartbeat(ctx context.Context, env map[string]string) (bool, error) {
 	return dynamicConfBool(ctx, env, "PEERDB_ENABLE_WAL_HEARTBEAT")
 }
@@ -758,6 +865,26 @@ func PeerDBFullRefreshOverwriteMode(ctx context.Context, env map[string]string)
 	return dynamicConfBool(ctx, env, "PEERDB_FULL_REFRESH_OVERWRITE_MODE")
 }
 
+func PeerDBQRepPartitionSplitFactor(ctx context.Context, env map[string]string) (int64, error) {
+	return dynamicConfSigned[int64](ctx, env, "PEERDB_QREP_PARTITION_SPLIT_FACTOR")
+}
+
+func PeerDBOracleSkipUnsupportedChanges(ctx context.Context, env map[string]string) (bool, error) {
+	return dynamicConfBool(ctx, env, "PEERDB_ORACLE_SKIP_UNSUPPORTED_CHANGES")
+}
+
+func PeerDBQRepCheckpointRows(ctx context.Context, env map[string]string) (int64, error) {
+	return dynamicConfSigned[int64](ctx, env, "PEERDB_QREP_CHECKPOINT_ROWS")
+}
+
+func PeerDBSecretRefreshSeconds(ctx context.Context, env map[string]string) (time.Duration, error) {
+	x, err := dynamicConfSigned[int64](ctx, env, "PEERDB_SECRET_REFRESH_SECONDS")
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(x) * time.Second, nil
+}
+
 func PeerDBNullable(ctx context.Context, env map[string]string) (bool, error) {
 	return dynamicConfBool(ctx, env, "PEERDB_NULLABLE")
 }
@@ -791,6 +918,18 @@ func PeerDBClickHouseMaxInsertThreads(ctx context.Context, env map[string]string
 	return dynamicConfSigned[int64](ctx, env, "PEERDB_CLICKHOUSE_MAX_INSERT_THREADS")
 }
 
+func PeerDBClickHouseDirectInsertParallelism(ctx context.Context, env map[string]string) (int, error) {
+	return dynamicConfSigned[int](ctx, env, "PEERDB_CLICKHOUSE_DIRECT_INSERT_PARALLELISM")
+}
+
+func PeerDBClickHouseDirectInsertBatchRows(ctx context.Context, env map[string]string) (int, error) {
+	return dynamicConfSigned[int](ctx, env, "PEERDB_CLICKHOUSE_DIRECT_INSERT_BATCH_ROWS")
+}
+
+func PeerDBClickHouseDirectInsertMaxRetries(ctx context.Context, env map[string]string) (int, error) {
+	return dynamicConfSigned[int](ctx, env, "PEERDB_CLICKHOUSE_DIRECT_INSERT_MAX_RETRIES")
+}
+
 func PeerDBClickHouseParallelViewProcessing(ctx context.Context, env map[string]string) (bool, error) {
 	return dynamicConfBool(ctx, env, "PEERDB_CLICKHOUSE_PARALLEL_VIEW_PROCESSING")
 }
@@ -803,6 +942,14 @@ func PeerDBEnableClickHouseNumericAsString(ctx context.Context, env map[string]s
 	return dynamicConfBool(ctx, env, "PEERDB_CLICKHOUSE_UNBOUNDED_NUMERIC_AS_STRING")
 }
 
+func PeerDBNaiveTimestampTimeZone(ctx context.Context, env map[string]string) (string, error) {
+	return dynLookup(ctx, env, "PEERDB_NAIVE_TIMESTAMP_TIME_ZONE")
+}
+
+func PeerDBTimestampTZConvertToUTC(ctx context.Context, env map[string]string) (bool, error) {
+	return dynamicConfBool(ctx, env, "PEERDB_TIMESTAMPTZ_CONVERT_TO_UTC")
+}
+
 func PeerDBEnableClickHouseJSON(ctx context.Context, env map[string]string) (bool, error) {
 	return dynamicConfBool(ctx, env, "PEERDB_CLICKHOUSE_ENABLE_JSON")
 }
@@ -934,6 +1081,14 @@ func PeerDBPGAutomatedSchemaDump(ctx context.Context, env map[string]string) (bo
 	return dynamicConfBool(ctx, env, "PEERDB_PG_AUTOMATED_SCHEMA_DUMP")
 }
 
+func PeerDBMongoDBFlattenDocuments(ctx context.Context, env map[string]string) (bool, error) {
+	return dynamicConfBool(ctx, env, "PEERDB_MONGODB_FLATTEN_DOCUMENTS")
+}
+
+func PeerDBMongoDBFlattenSampleSize(ctx context.Context, env map[string]string) (int32, error) {
+	return dynamicConfSigned[int32](ctx, env, "PEERDB_MONGODB_FLATTEN_SAMPLE_SIZE")
+}
+
 func PeerDBMongoDBExcludedOperationTypes(ctx context.Context, env map[string]string) ([]string, error) {
 	value, err := dynLookup(ctx, env, "PEERDB_MONGODB_EXCLUDED_OPERATION_TYPES")
 	if err != nil {
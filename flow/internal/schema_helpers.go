@@ -23,6 +23,9 @@ func AdditionalTablesHasOverlap(currentTableMappings []*protos.TableMapping,
 		currentSrcTables[currentTableMapping.SourceTableIdentifier] = struct{}{}
 		if checkDestination {
 			currentDstTables[currentTableMapping.DestinationTableIdentifier] = struct{}{}
+			for _, projection := range currentTableMapping.Projections {
+				currentDstTables[projection.DestinationTableIdentifier] = struct{}{}
+			}
 		}
 	}
 	for _, additionalTableMapping := range additionalTableMappings {
@@ -33,11 +36,43 @@ func AdditionalTablesHasOverlap(currentTableMappings []*protos.TableMapping,
 			if _, exists := currentDstTables[additionalTableMapping.DestinationTableIdentifier]; exists {
 				return true
 			}
+			for _, projection := range additionalTableMapping.Projections {
+				if _, exists := currentDstTables[projection.DestinationTableIdentifier]; exists {
+					return true
+				}
+			}
 		}
 	}
 	return false
 }
 
+// ExcludeColumns returns the schema without the excluded columns, or the schema itself when nothing is excluded
+func ExcludeColumns(tableSchema *protos.TableSchema, exclude []string) *protos.TableSchema {
+	if len(exclude) == 0 {
+		return tableSchema
+	}
+	columns := make([]*protos.FieldDescription, 0, len(tableSchema.Columns))
+	pkeyColumns := make([]string, 0, len(tableSchema.PrimaryKeyColumns))
+	for _, column := range tableSchema.Columns {
+		if !slices.Contains(exclude, column.Name) {
+			columns = append(columns, column)
+		}
+		if slices.Contains(tableSchema.PrimaryKeyColumns, column.Name) &&
+			!slices.Contains(exclude, column.Name) {
+			pkeyColumns = append(pkeyColumns, column.Name)
+		}
+	}
+	return &protos.TableSchema{
+		TableIdentifier:       tableSchema.TableIdentifier,
+		PrimaryKeyColumns:     pkeyColumns,
+		IsReplicaIdentityFull: tableSchema.IsReplicaIdentityFull,
+		NullableEnabled:       tableSchema.NullableEnabled,
+		System:                tableSchema.System,
+		Columns:               columns,
+		TableOid:              tableSchema.TableOid,
+	}
+}
+
 // given the output of GetTableSchema, processes it to be used by CDCFlow
 // 1) changes the map key to be the destination table name instead of the source table name
 // 2) performs column exclusion using protos.TableMapping as input.
@@ -55,28 +90,7 @@ func BuildProcessedSchemaMapping(
 		for _, mapping := range tableMappings {
 			if mapping.SourceTableIdentifier == srcTableName {
 				dstTableName = mapping.DestinationTableIdentifier
-				if len(mapping.Exclude) != 0 {
-					columns := make([]*protos.FieldDescription, 0, len(tableSchema.Columns))
-					pkeyColumns := make([]string, 0, len(tableSchema.PrimaryKeyColumns))
-					for _, column := range tableSchema.Columns {
-						if !slices.Contains(mapping.Exclude, column.Name) {
-							columns = append(columns, column)
-						}
-						if slices.Contains(tableSchema.PrimaryKeyColumns, column.Name) &&
-							!slices.Contains(mapping.Exclude, column.Name) {
-							pkeyColumns = append(pkeyColumns, column.Name)
-						}
-					}
-					tableSchema = &protos.TableSchema{
-						TableIdentifier:       tableSchema.TableIdentifier,
-						PrimaryKeyColumns:     pkeyColumns,
-						IsReplicaIdentityFull: tableSchema.IsReplicaIdentityFull,
-						NullableEnabled:       tableSchema.NullableEnabled,
-						System:                tableSchema.System,
-						Columns:               columns,
-						TableOid:              tableSchema.TableOid,
-					}
-				}
+				tableSchema = ExcludeColumns(tableSchema, mapping.Exclude)
 				break
 			}
 		}
@@ -0,0 +1,158 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/auth/credentials"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+// awsProvider calls Secrets Manager's GetSecretValue with the default AWS credentials chain,
+// in the region of the secret's ARN or else the default region
+type awsProvider struct{}
+
+func (awsProvider) fetch(ctx context.Context, secretID string) (string, time.Duration, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	region := cfg.Region
+	if parsed, err := arn.Parse(secretID); err == nil {
+		region = parsed.Region
+	}
+	if region == "" {
+		return "", 0, errors.New("no AWS region configured for Secrets Manager")
+	}
+	endpoint := internal.GetEnvString("AWS_ENDPOINT_URL_SECRETS_MANAGER", "")
+	if endpoint == "" && cfg.BaseEndpoint != nil {
+		endpoint = *cfg.BaseEndpoint
+	}
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + region + ".amazonaws.com"
+	}
+
+	body, err := json.Marshal(map[string]string{"SecretId": secretID})
+	if err != nil {
+		return "", 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	payloadHash := sha256.Sum256(body)
+	if err := v4.NewSigner().SignHTTP(
+		ctx, creds, req, hex.EncodeToString(payloadHash[:]), "secretsmanager", region, time.Now(),
+	); err != nil {
+		return "", 0, fmt.Errorf("failed to sign Secrets Manager request: %w", err)
+	}
+
+	var secret struct {
+		SecretString *string `json:"SecretString"`
+		Type         string  `json:"__type"`
+		Message      string  `json:"message"`
+		SecretBinary []byte  `json:"SecretBinary"`
+	}
+	status, err := doJSON(req, &secret)
+	if err != nil {
+		return "", 0, fmt.Errorf("request to AWS Secrets Manager failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return "", 0, fmt.Errorf("AWS Secrets Manager returned %d: %s %s", status, secret.Type, secret.Message)
+	}
+	if secret.SecretString != nil {
+		return *secret.SecretString, 0, nil
+	}
+	return string(secret.SecretBinary), 0, nil
+}
+
+// gcpProvider accesses Secret Manager versions with application default credentials
+type gcpProvider struct{}
+
+func (gcpProvider) fetch(ctx context.Context, name string) (string, time.Duration, error) {
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+	creds, err := credentials.DetectDefault(&credentials.DetectOptions{
+		Scopes: []string{"https://www.googleapis.com/auth/cloud-platform"},
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to detect GCP credentials: %w", err)
+	}
+	token, err := creds.Token(ctx)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to get GCP token: %w", err)
+	}
+	endpoint := internal.GetEnvString("PEERDB_GCP_SECRET_MANAGER_ENDPOINT", "https://secretmanager.googleapis.com")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(endpoint, "/")+"/v1/"+name+":access", nil)
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Authorization", token.Type+" "+token.Value)
+
+	var version struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+		Payload struct {
+			Data []byte `json:"data"`
+		} `json:"payload"`
+	}
+	status, err := doJSON(req, &version)
+	if err != nil {
+		return "", 0, fmt.Errorf("request to GCP Secret Manager failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return "", 0, fmt.Errorf("GCP Secret Manager returned %d: %s", status, version.Error.Message)
+	}
+	return string(version.Payload.Data), 0, nil
+}
+
+// fileProvider reads secrets mounted as files, like Kubernetes secret volumes which are updated in place on rotation
+type fileProvider struct{}
+
+func (fileProvider) fetch(_ context.Context, path string) (string, time.Duration, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, err
+	}
+	return strings.TrimRight(string(contents), "\r\n"), 0, nil
+}
+
+// doJSON sends req and decodes its JSON response into v, error responses included
+func doJSON(req *http.Request, v any) (int, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(body, v); err != nil && resp.StatusCode == http.StatusOK {
+		return 0, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
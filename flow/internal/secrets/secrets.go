@@ -0,0 +1,332 @@
+// Package secrets resolves references to externally managed secrets in peer configs,
+// so rotated credentials are picked up when connectors are created without recreating peers.
+//
+// A reference replaces the value of a field marked peerdb_secret_ref with one of:
+//
+//	vault://<api path>#<key>              HashiCorp Vault, e.g. vault://secret/data/pg#password or vault://database/creds/ro#username
+//	awssm://<secret id or arn>[#<key>]    AWS Secrets Manager
+//	gcpsm://projects/<p>/secrets/<s>[/versions/<v>][#<key>]  GCP Secret Manager, latest version by default
+//	file://<path>[#<key>]                 file mounted from a Kubernetes secret
+//
+// With a key the secret is parsed as a JSON object and the key's value is used.
+//
+// Anyone able to create a peer picks the references, so they are only resolved when the worker's operator
+// sets PEERDB_SECRET_REFS_ENABLED, and only for files under PEERDB_SECRET_REFS_FILE_DIRS and secrets whose
+// reference starts with one of PEERDB_SECRET_REFS_ALLOWED_PREFIXES, both comma separated.
+// These are read from the worker's environment rather than dynamic settings, which mirrors can override.
+// Otherwise values are used as literals.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+// provider fetches the raw secret at path, along with its lease duration, 0 if it has none
+type provider interface {
+	fetch(ctx context.Context, path string) (string, time.Duration, error)
+}
+
+var providers = map[string]provider{
+	"vault": vaultProvider{},
+	"awssm": awsProvider{},
+	"gcpsm": gcpProvider{},
+	"file":  fileProvider{},
+}
+
+var httpClient = &http.Client{Timeout: time.Minute}
+
+type Reference struct {
+	Scheme string
+	Path   string
+	Key    string
+}
+
+// ParseReference returns the reference held by value, or false if value is a literal
+func ParseReference(value string) (Reference, bool) {
+	scheme, rest, ok := strings.Cut(value, "://")
+	if !ok {
+		return Reference{}, false
+	}
+	if _, ok := providers[scheme]; !ok {
+		return Reference{}, false
+	}
+	path, key, _ := strings.Cut(rest, "#")
+	return Reference{Scheme: scheme, Path: path, Key: key}, true
+}
+
+func (r Reference) String() string {
+	if r.Key == "" {
+		return r.Scheme + "://" + r.Path
+	}
+	return r.Scheme + "://" + r.Path + "#" + r.Key
+}
+
+type cachedSecret struct {
+	fetchedAt time.Time
+	expiresAt time.Time
+	payload   string
+}
+
+type Resolver struct {
+	providers map[string]provider
+	cache     map[string]cachedSecret
+	now       func() time.Time
+	fetches   singleflight.Group
+	mu        sync.Mutex
+}
+
+func NewResolver() *Resolver {
+	return &Resolver{
+		providers: providers,
+		cache:     make(map[string]cachedSecret),
+		now:       time.Now,
+	}
+}
+
+var defaultResolver = NewResolver()
+
+// ResolvePeer returns peer with every secret reference replaced by its current value,
+// peer itself is returned when it holds no references
+func ResolvePeer(ctx context.Context, env map[string]string, peer *protos.Peer) (*protos.Peer, error) {
+	return defaultResolver.ResolvePeer(ctx, env, peer)
+}
+
+func (r *Resolver) ResolvePeer(ctx context.Context, env map[string]string, peer *protos.Peer) (*protos.Peer, error) {
+	if peer == nil || !referencesEnabled() || !hasReferences(peer.ProtoReflect()) {
+		return peer, nil
+	}
+	ttl, err := internal.PeerDBSecretRefreshSeconds(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	resolved := proto.CloneOf(peer)
+	if err := r.resolveMessage(ctx, resolved.ProtoReflect(), ttl); err != nil {
+		return nil, fmt.Errorf("failed to resolve secrets for peer %s: %w", peer.Name, err)
+	}
+	return resolved, nil
+}
+
+// Resolve returns the current value of the secret reference
+func (r *Resolver) Resolve(ctx context.Context, ref Reference, ttl time.Duration) (string, error) {
+	if err := loadPolicy().allows(ref); err != nil {
+		return "", err
+	}
+	payload, err := r.payload(ctx, ref, ttl)
+	if err != nil {
+		return "", err
+	}
+	if ref.Key == "" {
+		return payload, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(payload), &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object: %w", ref, err)
+	}
+	raw, ok := fields[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no key %s", ref, ref.Key)
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		// numbers and booleans are used as written
+		return string(raw), nil
+	}
+	return value, nil
+}
+
+func (r *Resolver) payload(ctx context.Context, ref Reference, ttl time.Duration) (string, error) {
+	// keys share a fetch, so dynamic credentials resolve to a matching username and password
+	cacheKey := ref.Scheme + "://" + ref.Path
+	r.mu.Lock()
+	cached, ok := r.cache[cacheKey]
+	r.mu.Unlock()
+	if ok && r.now().Before(cached.expiresAt) {
+		return cached.payload, nil
+	}
+
+	// the lock is not held while fetching, so a slow secret store only delays connectors needing that secret
+	payload, err, _ := r.fetches.Do(cacheKey, func() (any, error) {
+		payload, validFor, err := r.providers[ref.Scheme].fetch(ctx, ref.Path)
+		if err != nil {
+			return nil, err
+		}
+		if validFor > 0 {
+			// leased secrets like dynamic database credentials are new on every fetch,
+			// so they are kept for their lease and refreshed shortly before it runs out
+			ttl = validFor * 9 / 10
+		}
+		now := r.now()
+		r.mu.Lock()
+		r.cache[cacheKey] = cachedSecret{fetchedAt: now, expiresAt: now.Add(ttl), payload: payload}
+		r.mu.Unlock()
+		return payload, nil
+	})
+	if err != nil {
+		if ok {
+			internal.LoggerFromCtx(ctx).Warn("failed to refresh secret, using previously fetched value",
+				slog.String("secret", cacheKey), slog.Time("fetchedAt", cached.fetchedAt), slog.Any("error", err))
+			return cached.payload, nil
+		}
+		return "", fmt.Errorf("failed to fetch secret %s: %w", cacheKey, err)
+	}
+	return payload.(string), nil
+}
+
+func referencesEnabled() bool {
+	return internal.GetEnvBool("PEERDB_SECRET_REFS_ENABLED", false)
+}
+
+// policy is the allowlist references are checked against before anything is fetched
+type policy struct {
+	fileDirs []string
+	prefixes []string
+}
+
+func loadPolicy() policy {
+	return policy{
+		fileDirs: envList("PEERDB_SECRET_REFS_FILE_DIRS"),
+		prefixes: envList("PEERDB_SECRET_REFS_ALLOWED_PREFIXES"),
+	}
+}
+
+func envList(name string) []string {
+	var values []string
+	for value := range strings.SplitSeq(internal.GetEnvString(name, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func (p policy) allows(ref Reference) error {
+	if !referencesEnabled() {
+		return errors.New("secret references are not enabled on this worker")
+	}
+	if ref.Scheme == "file" {
+		return p.allowsFile(ref.Path)
+	}
+	if slices.Contains(strings.Split(ref.Path, "/"), "..") {
+		return fmt.Errorf("secret %s is not allowed", ref)
+	}
+	location := ref.Scheme + "://" + ref.Path
+	for _, prefix := range p.prefixes {
+		if strings.HasPrefix(location, prefix) {
+			return nil
+		}
+	}
+	return fmt.Errorf("secret %s does not match PEERDB_SECRET_REFS_ALLOWED_PREFIXES", ref)
+}
+
+// allowsFile checks the file's real location, so symlinks can't point outside the allowed directories
+func (p policy) allowsFile(path string) error {
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("secret file %s is not an absolute path", path)
+	}
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve secret file %s: %w", path, err)
+	}
+	for _, dir := range p.fileDirs {
+		realDir, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(realDir, realPath); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("secret file %s is not under PEERDB_SECRET_REFS_FILE_DIRS", path)
+}
+
+func holdsSecret(fd protoreflect.FieldDescriptor) bool {
+	if fd.Kind() != protoreflect.StringKind {
+		return false
+	}
+	return proto.GetExtension(fd.Options().(*descriptorpb.FieldOptions), protos.E_PeerdbSecretRef).(bool)
+}
+
+func hasReferences(message protoreflect.Message) bool {
+	found := false
+	rangeStrings(message, func(_ protoreflect.Message, fd protoreflect.FieldDescriptor, value string) bool {
+		if _, ok := ParseReference(value); ok && holdsSecret(fd) {
+			found = true
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+func (r *Resolver) resolveMessage(ctx context.Context, message protoreflect.Message, ttl time.Duration) error {
+	type secretField struct {
+		parent protoreflect.Message
+		fd     protoreflect.FieldDescriptor
+		ref    Reference
+	}
+	// fields are only set once ranging is done, as messages must not be mutated while ranged over
+	var fields []secretField
+	rangeStrings(message, func(parent protoreflect.Message, fd protoreflect.FieldDescriptor, value string) bool {
+		if ref, ok := ParseReference(value); ok && holdsSecret(fd) {
+			fields = append(fields, secretField{parent: parent, fd: fd, ref: ref})
+		}
+		return true
+	})
+	for _, field := range fields {
+		resolved, err := r.Resolve(ctx, field.ref, ttl)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.fd.Name(), err)
+		}
+		field.parent.Set(field.fd, protoreflect.ValueOfString(resolved))
+	}
+	return nil
+}
+
+// rangeStrings calls f on every populated singular string field in message and its nested messages
+func rangeStrings(
+	message protoreflect.Message, f func(protoreflect.Message, protoreflect.FieldDescriptor, string) bool,
+) bool {
+	cont := true
+	message.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Kind() == protoreflect.MessageKind {
+				v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+					cont = rangeStrings(mv.Message(), f)
+					return cont
+				})
+			}
+		case fd.IsList():
+			if fd.Kind() == protoreflect.MessageKind {
+				list := v.List()
+				for i := 0; i < list.Len() && cont; i++ {
+					cont = rangeStrings(list.Get(i).Message(), f)
+				}
+			}
+		case fd.Kind() == protoreflect.MessageKind:
+			cont = rangeStrings(v.Message(), f)
+		case fd.Kind() == protoreflect.StringKind:
+			cont = f(message, fd, v.String())
+		}
+		return cont
+	})
+	return cont
+}
@@ -0,0 +1,265 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+var testEnv = map[string]string{"PEERDB_SECRET_REFRESH_SECONDS": "60"}
+
+func TestParseReference(t *testing.T) {
+	ref, ok := ParseReference("vault://secret/data/pg#password")
+	require.True(t, ok)
+	require.Equal(t, Reference{Scheme: "vault", Path: "secret/data/pg", Key: "password"}, ref)
+
+	ref, ok = ParseReference("file:///var/run/secrets/pg/password")
+	require.True(t, ok)
+	require.Equal(t, Reference{Scheme: "file", Path: "/var/run/secrets/pg/password"}, ref)
+	require.Equal(t, "file:///var/run/secrets/pg/password", ref.String())
+
+	for _, literal := range []string{"hunter2", "", "s3://bucket/prefix", "postgres://user@host/db"} {
+		_, ok := ParseReference(literal)
+		require.False(t, ok, literal)
+	}
+}
+
+type countingProvider struct {
+	err      error
+	payload  string
+	validFor time.Duration
+	fetches  int
+}
+
+func (p *countingProvider) fetch(context.Context, string) (string, time.Duration, error) {
+	p.fetches++
+	return p.payload, p.validFor, p.err
+}
+
+func allowVault(t *testing.T) {
+	t.Helper()
+	t.Setenv("PEERDB_SECRET_REFS_ENABLED", "true")
+	t.Setenv("PEERDB_SECRET_REFS_ALLOWED_PREFIXES", "vault://secret/data/pg,vault://database/creds/")
+}
+
+func TestPolicy(t *testing.T) {
+	dir := t.TempDir()
+	credsPath := filepath.Join(dir, "allowed", "creds")
+	require.NoError(t, os.Mkdir(filepath.Dir(credsPath), 0o700))
+	require.NoError(t, os.WriteFile(credsPath, []byte("s3cret"), 0o600))
+	outsidePath := filepath.Join(dir, "outside")
+	require.NoError(t, os.WriteFile(outsidePath, []byte("other"), 0o600))
+	linkPath := filepath.Join(dir, "allowed", "link")
+	require.NoError(t, os.Symlink(outsidePath, linkPath))
+
+	ref := Reference{Scheme: "file", Path: credsPath}
+	require.ErrorContains(t, loadPolicy().allows(ref), "not enabled")
+
+	t.Setenv("PEERDB_SECRET_REFS_ENABLED", "true")
+	t.Setenv("PEERDB_SECRET_REFS_FILE_DIRS", filepath.Join(dir, "allowed"))
+	t.Setenv("PEERDB_SECRET_REFS_ALLOWED_PREFIXES", "vault://secret/data/peerdb/, awssm://peerdb/")
+	policy := loadPolicy()
+	require.NoError(t, policy.allows(ref))
+	require.Error(t, policy.allows(Reference{Scheme: "file", Path: outsidePath}))
+	require.Error(t, policy.allows(Reference{Scheme: "file", Path: filepath.Join(dir, "allowed", "..", "outside")}))
+	require.Error(t, policy.allows(Reference{Scheme: "file", Path: linkPath}))
+	require.Error(t, policy.allows(Reference{Scheme: "file", Path: "/etc/passwd"}))
+
+	require.NoError(t, policy.allows(Reference{Scheme: "vault", Path: "secret/data/peerdb/pg", Key: "password"}))
+	require.NoError(t, policy.allows(Reference{Scheme: "awssm", Path: "peerdb/pg"}))
+	require.Error(t, policy.allows(Reference{Scheme: "vault", Path: "secret/data/peerdb/../admin"}))
+	require.Error(t, policy.allows(Reference{Scheme: "vault", Path: "secret/data/admin"}))
+	require.Error(t, policy.allows(Reference{Scheme: "gcpsm", Path: "projects/p/secrets/s"}))
+}
+
+func TestResolverCache(t *testing.T) {
+	allowVault(t)
+	now := time.Unix(1_700_000_000, 0)
+	fake := &countingProvider{payload: `{"username":"u1","password":"p1","port":5432}`}
+	resolver := NewResolver()
+	resolver.providers = map[string]provider{"vault": fake}
+	resolver.now = func() time.Time { return now }
+	ctx := t.Context()
+
+	user, err := resolver.Resolve(ctx, Reference{Scheme: "vault", Path: "database/creds/ro", Key: "username"}, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, "u1", user)
+	password, err := resolver.Resolve(ctx, Reference{Scheme: "vault", Path: "database/creds/ro", Key: "password"}, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, "p1", password)
+	port, err := resolver.Resolve(ctx, Reference{Scheme: "vault", Path: "database/creds/ro", Key: "port"}, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, "5432", port)
+	require.Equal(t, 1, fake.fetches)
+
+	_, err = resolver.Resolve(ctx, Reference{Scheme: "vault", Path: "database/creds/ro", Key: "missing"}, time.Minute)
+	require.ErrorContains(t, err, "has no key missing")
+
+	// rotated after the TTL
+	fake.payload = `{"username":"u2","password":"p2"}`
+	now = now.Add(2 * time.Minute)
+	password, err = resolver.Resolve(ctx, Reference{Scheme: "vault", Path: "database/creds/ro", Key: "password"}, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, "p2", password)
+	require.Equal(t, 2, fake.fetches)
+
+	// leases bound caching instead of the TTL
+	fake.validFor = 10 * time.Minute
+	now = now.Add(2 * time.Minute)
+	_, err = resolver.Resolve(ctx, Reference{Scheme: "vault", Path: "database/creds/ro", Key: "password"}, time.Minute)
+	require.NoError(t, err)
+	now = now.Add(5 * time.Minute)
+	_, err = resolver.Resolve(ctx, Reference{Scheme: "vault", Path: "database/creds/ro", Key: "password"}, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, 3, fake.fetches)
+
+	// failed refreshes fall back to the last value
+	fake.err = os.ErrDeadlineExceeded
+	now = now.Add(time.Hour)
+	password, err = resolver.Resolve(ctx, Reference{Scheme: "vault", Path: "database/creds/ro", Key: "password"}, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, "p2", password)
+	_, err = resolver.Resolve(ctx, Reference{Scheme: "vault", Path: "other"}, time.Minute)
+	require.Error(t, err)
+}
+
+type blockingProvider struct {
+	release chan struct{}
+}
+
+func (p blockingProvider) fetch(_ context.Context, path string) (string, time.Duration, error) {
+	if path == "slow" {
+		<-p.release
+	}
+	return path, 0, nil
+}
+
+func TestResolverFetchesConcurrently(t *testing.T) {
+	t.Setenv("PEERDB_SECRET_REFS_ENABLED", "true")
+	t.Setenv("PEERDB_SECRET_REFS_ALLOWED_PREFIXES", "vault://")
+	fake := blockingProvider{release: make(chan struct{})}
+	resolver := NewResolver()
+	resolver.providers = map[string]provider{"vault": fake}
+
+	slow := make(chan string)
+	go func() {
+		value, _ := resolver.Resolve(t.Context(), Reference{Scheme: "vault", Path: "slow"}, time.Minute)
+		slow <- value
+	}()
+	// a secret stuck fetching does not hold up others
+	value, err := resolver.Resolve(t.Context(), Reference{Scheme: "vault", Path: "fast"}, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, "fast", value)
+	close(fake.release)
+	require.Equal(t, "slow", <-slow)
+}
+
+func TestResolvePeerFile(t *testing.T) {
+	dir := t.TempDir()
+	credsPath := filepath.Join(dir, "creds.json")
+	require.NoError(t, os.WriteFile(credsPath, []byte(`{"user":"replicator","password":"s3cret"}`), 0o600))
+	keyPath := filepath.Join(dir, "ssh_key")
+	require.NoError(t, os.WriteFile(keyPath, []byte("-----BEGIN KEY-----\n"), 0o600))
+
+	t.Setenv("PEERDB_SECRET_REFS_ENABLED", "true")
+	t.Setenv("PEERDB_SECRET_REFS_FILE_DIRS", dir)
+
+	peer := &protos.Peer{
+		Name: "pg",
+		Type: protos.DBType_POSTGRES,
+		Config: &protos.Peer_PostgresConfig{PostgresConfig: &protos.PostgresConfig{
+			Host:     "file://" + credsPath,
+			User:     "file://" + credsPath + "#user",
+			Password: "file://" + credsPath + "#password",
+			SshConfig: &protos.SSHConfig{
+				Host:       "bastion",
+				PrivateKey: "file://" + keyPath,
+			},
+		}},
+	}
+	resolved, err := NewResolver().ResolvePeer(t.Context(), testEnv, peer)
+	require.NoError(t, err)
+	pgConfig := resolved.GetPostgresConfig()
+	require.Equal(t, "replicator", pgConfig.User)
+	require.Equal(t, "s3cret", pgConfig.Password)
+	require.Equal(t, "-----BEGIN KEY-----", pgConfig.SshConfig.PrivateKey)
+	// only secret fields are resolved
+	require.Equal(t, "file://"+credsPath, pgConfig.Host)
+	// the stored peer is left untouched
+	require.Equal(t, "file://"+credsPath+"#password", peer.GetPostgresConfig().Password)
+
+	literal := &protos.Peer{Name: "pg", Config: &protos.Peer_PostgresConfig{PostgresConfig: &protos.PostgresConfig{Password: "p"}}}
+	same, err := NewResolver().ResolvePeer(t.Context(), nil, literal)
+	require.NoError(t, err)
+	require.Same(t, literal, same)
+
+	// files outside the allowed directories are not read
+	t.Setenv("PEERDB_SECRET_REFS_FILE_DIRS", filepath.Join(dir, "other"))
+	_, err = NewResolver().ResolvePeer(t.Context(), testEnv, peer)
+	require.ErrorContains(t, err, "PEERDB_SECRET_REFS_FILE_DIRS")
+
+	// references are literals unless enabled
+	t.Setenv("PEERDB_SECRET_REFS_ENABLED", "false")
+	same, err = NewResolver().ResolvePeer(t.Context(), testEnv, peer)
+	require.NoError(t, err)
+	require.Same(t, peer, same)
+}
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			_ = json.NewEncoder(w).Encode(map[string]any{"errors": []string{"permission denied"}})
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/pg":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"lease_duration": 0,
+				"data": map[string]any{
+					"data":     map[string]any{"password": "kv-password"},
+					"metadata": map[string]any{"version": 3},
+				},
+			})
+		case "/v1/database/creds/ro":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"lease_id":       "database/creds/ro/abc",
+				"lease_duration": 3600,
+				"data":           map[string]any{"username": "v-ro-abc", "password": "dyn-password"},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]any{"errors": []string{}})
+		}
+	}))
+	defer server.Close()
+	allowVault(t)
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "root")
+
+	resolver := NewResolver()
+	password, err := resolver.Resolve(t.Context(), Reference{Scheme: "vault", Path: "secret/data/pg", Key: "password"}, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, "kv-password", password)
+
+	payload, validFor, err := vaultProvider{}.fetch(t.Context(), "database/creds/ro")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"username":"v-ro-abc","password":"dyn-password"}`, payload)
+	require.Equal(t, time.Hour, validFor)
+
+	_, _, err = vaultProvider{}.fetch(t.Context(), "secret/data/missing")
+	require.ErrorContains(t, err, "404")
+
+	t.Setenv("VAULT_TOKEN", "wrong")
+	_, _, err = vaultProvider{}.fetch(t.Context(), "secret/data/pg")
+	require.ErrorContains(t, err, "permission denied")
+}
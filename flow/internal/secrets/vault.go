@@ -0,0 +1,99 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+// vaultProvider reads secrets over Vault's HTTP API, configured through VAULT_ADDR, VAULT_NAMESPACE,
+// and VAULT_TOKEN or PEERDB_VAULT_TOKEN_FILE for a token kept fresh by a Vault agent
+type vaultProvider struct{}
+
+type vaultResponse struct {
+	Data          map[string]json.RawMessage `json:"data"`
+	LeaseID       string                     `json:"lease_id"`
+	Errors        []string                   `json:"errors"`
+	LeaseDuration int64                      `json:"lease_duration"`
+}
+
+func (vaultProvider) fetch(ctx context.Context, path string) (string, time.Duration, error) {
+	addr := internal.GetEnvString("VAULT_ADDR", "")
+	if addr == "" {
+		return "", 0, errors.New("VAULT_ADDR is not set")
+	}
+	token, err := vaultToken()
+	if err != nil {
+		return "", 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := internal.GetEnvString("VAULT_NAMESPACE", ""); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read vault response: %w", err)
+	}
+
+	var vaultResp vaultResponse
+	if err := json.Unmarshal(body, &vaultResp); err != nil && resp.StatusCode == http.StatusOK {
+		return "", 0, fmt.Errorf("failed to parse vault response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("vault returned %s: %s", resp.Status, strings.Join(vaultResp.Errors, ", "))
+	}
+
+	data := vaultResp.Data
+	// KV version 2 nests the secret under data, next to its metadata
+	if nested, ok := data["data"]; ok {
+		if _, hasMetadata := data["metadata"]; hasMetadata {
+			var kv map[string]json.RawMessage
+			if err := json.Unmarshal(nested, &kv); err != nil {
+				return "", 0, fmt.Errorf("failed to parse vault KV data: %w", err)
+			}
+			data = kv
+		}
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return "", 0, err
+	}
+	if vaultResp.LeaseID == "" {
+		// KV v1 reports a refresh hint as lease duration, only actual leases bound how long values stay valid
+		return string(payload), 0, nil
+	}
+	return string(payload), time.Duration(vaultResp.LeaseDuration) * time.Second, nil
+}
+
+func vaultToken() (string, error) {
+	if token := internal.GetEnvString("VAULT_TOKEN", ""); token != "" {
+		return token, nil
+	}
+	if tokenFile := internal.GetEnvString("PEERDB_VAULT_TOKEN_FILE", ""); tokenFile != "" {
+		token, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read vault token: %w", err)
+		}
+		return strings.TrimSpace(string(token)), nil
+	}
+	return "", errors.New("neither VAULT_TOKEN nor PEERDB_VAULT_TOKEN_FILE is set")
+}
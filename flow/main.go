@@ -131,6 +131,30 @@ func main() {
 		Sources: cli.EnvVars("USE_MAINTENANCE_TASK_QUEUE"),
 	}
 
+	workerPoolsFlag := &cli.StringSliceFlag{
+		Name:    "worker-pools",
+		Usage:   "Worker pools whose task queues the worker subscribes to, defaults to the default pool",
+		Sources: cli.EnvVars("PEERDB_WORKER_POOLS"),
+	}
+
+	maxConcurrentSyncsFlag := &cli.StringSliceFlag{
+		Name:    "max-concurrent-syncs",
+		Usage:   "Maximum number of concurrent CDC syncs per worker pool as pool=limit, a bare limit applies to other pools, 0 for unbounded",
+		Sources: cli.EnvVars("PEERDB_WORKER_POOL_MAX_CONCURRENT_SYNCS"),
+	}
+
+	maxConcurrentNormalizesFlag := &cli.StringSliceFlag{
+		Name:    "max-concurrent-normalizes",
+		Usage:   "Maximum number of concurrent CDC normalizes per worker pool as pool=limit, a bare limit applies to other pools, 0 for unbounded",
+		Sources: cli.EnvVars("PEERDB_WORKER_POOL_MAX_CONCURRENT_NORMALIZES"),
+	}
+
+	maxConcurrentQRepPartitionsFlag := &cli.StringSliceFlag{
+		Name:    "max-concurrent-qrep-partitions",
+		Usage:   "Maximum number of concurrent QRep partitions per worker pool as pool=limit, a bare limit applies to other pools, 0 for unbounded",
+		Sources: cli.EnvVars("PEERDB_WORKER_POOL_MAX_CONCURRENT_QREP_PARTITIONS"),
+	}
+
 	assumedSkippedMaintenanceWorkflowsFlag := &cli.BoolFlag{
 		Name:  "assume-skipped-workflow",
 		Value: false,
@@ -204,12 +228,16 @@ func main() {
 						UseMaintenanceTaskQueue:            clicmd.Bool(useMaintenanceTaskQueueFlag.Name),
 						EnableOtelMetrics:                  clicmd.Bool(otelMetricsFlag.Name),
 						EnableOtelTraces:                   clicmd.Bool(otelTracesFlag.Name),
+						WorkerPools:                        clicmd.StringSlice(workerPoolsFlag.Name),
+						MaxConcurrentSyncs:                 clicmd.StringSlice(maxConcurrentSyncsFlag.Name),
+						MaxConcurrentNormalizes:            clicmd.StringSlice(maxConcurrentNormalizesFlag.Name),
+						MaxConcurrentQRepPartitions:        clicmd.StringSlice(maxConcurrentQRepPartitionsFlag.Name),
 					})
 					if err != nil {
 						return err
 					}
 					defer res.Close(context.Background())
-					return res.Worker.Run(worker.InterruptCh())
+					return res.Run(worker.InterruptCh())
 				},
 				Flags: []cli.Flag{
 					temporalHostPortFlag,
@@ -219,6 +247,10 @@ func main() {
 					useMaintenanceTaskQueueFlag,
 					otelMetricsFlag,
 					otelTracesFlag,
+					workerPoolsFlag,
+					maxConcurrentSyncsFlag,
+					maxConcurrentNormalizesFlag,
+					maxConcurrentQRepPartitionsFlag,
 				},
 			},
 			{
@@ -234,7 +266,7 @@ func main() {
 						return err
 					}
 					defer res.Close(context.Background())
-					return res.Worker.Run(worker.InterruptCh())
+					return res.Run(worker.InterruptCh())
 				},
 				Flags: []cli.Flag{
 					temporalHostPortFlag,
@@ -7,12 +7,15 @@ import (
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
 type CDCStream[T Items] struct {
 	// empty signal to indicate if the records are going to be empty or not.
 	emptySignal chan struct{}
 	records     chan Record[T]
+	// timestampPolicy normalizes timestamps of records as they are added
+	timestampPolicy types.TimestampPolicy
 
 	firstRowReceivedAt time.Time
 	firstRowCommitTime time.Time
@@ -64,7 +67,38 @@ func (r *CDCStream[T]) GetLastCheckpoint() CdcCheckpoint {
 	return CdcCheckpoint{ID: r.lastCheckpointID, Text: r.lastCheckpointText}
 }
 
+func (r *CDCStream[T]) SetTimestampPolicy(policy types.TimestampPolicy) {
+	r.timestampPolicy = policy
+}
+
+// applyTimestampPolicy rewrites timestamps of row records in place, raw items of pg to pg mirrors are left as is
+// since mirrors with the PG type system reject a timestamp policy
+func (r *CDCStream[T]) applyTimestampPolicy(record Record[T]) {
+	var items []T
+	switch rec := record.(type) {
+	case *InsertRecord[T]:
+		items = []T{rec.Items}
+	case *UpdateRecord[T]:
+		items = []T{rec.OldItems, rec.NewItems}
+	case *DeleteRecord[T]:
+		items = []T{rec.Items}
+	}
+	for _, item := range items {
+		if recordItems, ok := any(item).(RecordItems); ok {
+			for col, val := range recordItems.ColToVal {
+				if val != nil {
+					recordItems.ColToVal[col] = r.timestampPolicy.Apply(val)
+				}
+			}
+		}
+	}
+}
+
 func (r *CDCStream[T]) AddRecord(ctx context.Context, record Record[T]) error {
+	if !r.timestampPolicy.IsNoop() {
+		r.applyTimestampPolicy(record)
+	}
+
 	if !r.needsNormalize {
 		switch record.(type) {
 		case *InsertRecord[T], *UpdateRecord[T], *DeleteRecord[T]:
@@ -125,7 +125,7 @@ func GetAvroSchemaDefinition(
 	namedSchemaSeen := make(map[string]avro.NamedSchema)
 
 	for _, qField := range qRecordSchema.Fields {
-		avroType, err := qvalue.GetAvroSchemaFromQValueKind(ctx, env, qField.Type, targetDWH, qField.Precision, qField.Scale)
+		avroType, err := qvalue.GetAvroSchemaFromQField(ctx, env, &qField, targetDWH)
 		if err != nil {
 			return nil, err
 		}
@@ -9,8 +9,10 @@ import (
 	"github.com/jackc/pglogrepl"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
 type NameAndExclude struct {
@@ -111,6 +113,19 @@ func NewToJSONOptions(unnestCols []string, hstoreAsJSON bool) ToJSONOptions {
 	}
 }
 
+// NewTimestampPolicy loads the timestamp policy of a mirror from its env
+func NewTimestampPolicy(ctx context.Context, env map[string]string) (types.TimestampPolicy, error) {
+	naiveTimeZone, err := internal.PeerDBNaiveTimestampTimeZone(ctx, env)
+	if err != nil {
+		return types.TimestampPolicy{}, err
+	}
+	convertToUTC, err := internal.PeerDBTimestampTZConvertToUTC(ctx, env)
+	if err != nil {
+		return types.TimestampPolicy{}, err
+	}
+	return types.NewTimestampPolicy(naiveTimeZone, convertToUTC)
+}
+
 type TableWithPkey struct {
 	TableName string
 	// SHA256 hash of the primary key columns
@@ -169,6 +184,7 @@ type NormalizeRecordsRequest struct {
 	TableMappings          []*protos.TableMapping
 	SyncBatchID            int64
 	Version                uint32
+	ClickHouseDeleteMode   protos.ClickHouseDeleteMode
 }
 
 //nolint:govet // no need to save on fieldalignment
@@ -201,6 +217,7 @@ type SetupReplicationResult struct {
 	Conn             interface{ Close(context.Context) error }
 	SlotName         string
 	SnapshotName     string
+	ConsistentPoint  string
 	SupportsTIDScans bool
 }
 
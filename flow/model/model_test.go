@@ -6,6 +6,9 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
 func TestCdcStreamGetLastCheckpointPanic(t *testing.T) {
@@ -34,3 +37,47 @@ func TestJsonOptionsUnnestCols(t *testing.T) {
 	require.True(t, ok1)
 	require.False(t, ok2)
 }
+
+func TestArrayStructToJSONAndCopyLiteral(t *testing.T) {
+	ranges := types.QValueArrayStruct{Val: []types.QValueStruct{
+		types.NewQValueRange(types.QValueInt32{Val: 1}, types.QValueInt32{Val: 3}, true, false),
+		types.NewQValueEmptyRange(types.QValueKindInt32),
+		types.NewQValueRange(types.QValueInt32{Val: 5}, types.QValueNull(types.QValueKindInt32), true, false),
+	}}
+
+	literal, ok := multirangeLiteral(ranges)
+	require.True(t, ok)
+	require.Equal(t, "{[1,3),[5,)}", literal)
+	literal, ok = multirangeLiteral(types.QValueArrayStruct{})
+	require.True(t, ok)
+	require.Equal(t, "{}", literal)
+
+	items := NewRecordItems(1)
+	items.AddColumn("slots", ranges)
+	json, err := items.ToJSONWithOptions(NewToJSONOptions(nil, true))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"slots":[`+
+		`{"lower":1,"upper":3,"lower_inc":true,"upper_inc":false,"empty":false},`+
+		`{"lower":null,"upper":null,"lower_inc":false,"upper_inc":false,"empty":true},`+
+		`{"lower":5,"upper":null,"lower_inc":true,"upper_inc":false,"empty":false}]}`, json)
+}
+
+func TestNumericTruncatorStatsUseDestinationNames(t *testing.T) {
+	columnSettings := []*protos.ColumnSetting{{
+		SourceName:      "amount",
+		DestinationName: "total",
+		NumericStrategy: protos.NumericStrategy_NUMERIC_STRATEGY_ROUND,
+	}}
+
+	// records name columns by source, stats by destination
+	cdcTruncator := NewCdcTableNumericTruncator("t", columnSettings, nil)
+	require.Equal(t, "total", cdcTruncator.Get("amount").Stat.DestinationColumn)
+
+	snapshotTruncator := NewSnapshotTableNumericTruncator("t", []types.QField{
+		{Name: "id", Type: types.QValueKindInt64},
+		{Name: "amount", Type: types.QValueKindNumeric},
+	}, columnSettings)
+	require.Equal(t, "id", snapshotTruncator.Get(0).DestinationColumn)
+	require.Equal(t, "total", snapshotTruncator.Get(1).DestinationColumn)
+	require.Equal(t, protos.NumericStrategy_NUMERIC_STRATEGY_ROUND, snapshotTruncator.Get(1).Strategy)
+}
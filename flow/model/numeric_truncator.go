@@ -48,12 +48,16 @@ func NewCdcTableNumericTruncator(
 ) CdcTableNumericTruncator {
 	truncatorsByColumn := map[string]CdcColumnNumericTruncator{}
 	for _, columnSetting := range columnSettings {
-		if _, ok := typesToSkip[columnSetting.DestinationType]; ok {
-			destinationName := columnSetting.DestinationName
-			if destinationName == "" {
-				destinationName = columnSetting.SourceName
+		// records are keyed by source column
+		if _, ok := typesToSkip[columnSetting.DestinationType]; ok ||
+			columnSetting.NumericStrategy == protos.NumericStrategy_NUMERIC_STRATEGY_STRING {
+			truncatorsByColumn[columnSetting.SourceName] = CdcColumnNumericTruncator{}
+		} else if columnSetting.NumericStrategy != protos.NumericStrategy_NUMERIC_STRATEGY_TRUNCATE {
+			numericStat := qvalue.NewNumericStat(
+				destinationTable, destinationColumnName(columnSetting), columnSetting.NumericStrategy)
+			truncatorsByColumn[columnSetting.SourceName] = CdcColumnNumericTruncator{
+				Stat: &numericStat,
 			}
-			truncatorsByColumn[destinationName] = CdcColumnNumericTruncator{}
 		}
 	}
 	return CdcTableNumericTruncator{
@@ -68,7 +72,7 @@ func (ts CdcTableNumericTruncator) Get(destinationColumn string) CdcColumnNumeri
 	}
 	stat, ok := ts.TruncatorsByColumn[destinationColumn]
 	if !ok {
-		numericStat := qvalue.NewNumericStat(ts.DestinationTable, destinationColumn)
+		numericStat := qvalue.NewNumericStat(ts.DestinationTable, destinationColumn, protos.NumericStrategy_NUMERIC_STRATEGY_TRUNCATE)
 		stat = CdcColumnNumericTruncator{
 			Stat: &numericStat,
 		}
@@ -91,14 +95,33 @@ type CdcColumnNumericTruncator struct {
 
 type SnapshotTableNumericTruncator []qvalue.NumericStat
 
-func NewSnapshotTableNumericTruncator(destinationTable string, fields []types.QField) SnapshotTableNumericTruncator {
+func NewSnapshotTableNumericTruncator(
+	destinationTable string, fields []types.QField, columnSettings []*protos.ColumnSetting,
+) SnapshotTableNumericTruncator {
 	stats := make([]qvalue.NumericStat, 0, len(fields))
 	for _, field := range fields {
-		stats = append(stats, qvalue.NewNumericStat(destinationTable, field.Name))
+		destinationColumn := field.Name
+		strategy := protos.NumericStrategy_NUMERIC_STRATEGY_TRUNCATE
+		for _, columnSetting := range columnSettings {
+			if columnSetting.SourceName == field.Name {
+				destinationColumn = destinationColumnName(columnSetting)
+				strategy = columnSetting.NumericStrategy
+				break
+			}
+		}
+		stats = append(stats, qvalue.NewNumericStat(destinationTable, destinationColumn, strategy))
 	}
 	return SnapshotTableNumericTruncator(stats)
 }
 
+// destinationColumnName is the column name stats are reported under, which differs from the source for renamed columns
+func destinationColumnName(columnSetting *protos.ColumnSetting) string {
+	if columnSetting.DestinationName != "" {
+		return columnSetting.DestinationName
+	}
+	return columnSetting.SourceName
+}
+
 func (ts SnapshotTableNumericTruncator) Get(idx int) *qvalue.NumericStat {
 	if ts == nil {
 		return nil
@@ -733,6 +733,10 @@ var sizeTestSkippedKinds = []string{
 	"QValueKindJSONB",
 	"QValueKindArrayJSON",
 	"QValueKindArrayJSONB",
+	// nested kinds need member fields, covered by TestAvroStructAndMapSize in qvalue
+	"QValueKindStruct",
+	"QValueKindArrayStruct",
+	"QValueKindMap",
 }
 
 // Parses QValueKind constants from kind.go via AST and asserts every kind
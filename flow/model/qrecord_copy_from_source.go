@@ -51,6 +51,74 @@ func geoWktToWkb(wkt string) ([]byte, error) {
 	return geo.GeoToWKB(wkt)
 }
 
+// multirangeLiteral renders the ranges of a multirange in multirange input syntax,
+// empty ranges are left out like Postgres does
+func multirangeLiteral(v types.QValueArrayStruct) (string, bool) {
+	var literal strings.Builder
+	literal.WriteByte('{')
+	for _, element := range v.Val {
+		rangeText, ok := rangeLiteral(element)
+		if !ok {
+			return "", false
+		}
+		if rangeText == "empty" {
+			continue
+		}
+		if literal.Len() > 1 {
+			literal.WriteByte(',')
+		}
+		literal.WriteString(rangeText)
+	}
+	literal.WriteByte('}')
+	return literal.String(), true
+}
+
+// rangeLiteral renders a struct built by types.NewQValueRange in range input syntax,
+// COPY parses it as the range type of the destination column
+func rangeLiteral(v types.QValueStruct) (string, bool) {
+	bounds, ok := v.RangeBounds()
+	if !ok {
+		return "", false
+	}
+	if bounds.Empty {
+		return "empty", true
+	}
+	var literal strings.Builder
+	if bounds.LowerInc {
+		literal.WriteByte('[')
+	} else {
+		literal.WriteByte('(')
+	}
+	literal.WriteString(rangeBoundLiteral(bounds.Lower))
+	literal.WriteByte(',')
+	literal.WriteString(rangeBoundLiteral(bounds.Upper))
+	if bounds.UpperInc {
+		literal.WriteByte(']')
+	} else {
+		literal.WriteByte(')')
+	}
+	return literal.String(), true
+}
+
+// rangeBoundLiteral renders a bound of a range literal, null bounds are unbounded
+func rangeBoundLiteral(bound types.QValue) string {
+	if bound == nil || bound.Value() == nil {
+		return ""
+	}
+	switch v := bound.(type) {
+	case types.QValueNumeric:
+		return v.Val.String()
+	case types.QValueDate:
+		return v.Val.Format(time.DateOnly)
+	case types.QValueTimestamp:
+		return `"` + v.Val.Format("2006-01-02 15:04:05.999999") + `"`
+	case types.QValueTimestampTZ:
+		return `"` + v.Val.Format("2006-01-02 15:04:05.999999Z07:00") + `"`
+	default:
+		return fmt.Sprint(bound.Value())
+	}
+}
+
 func (src *QRecordCopyFromSource) Values() ([]any, error) {
 	if err := src.Err(); err != nil {
 		return nil, err
@@ -122,6 +190,20 @@ func (src *QRecordCopyFromSource) Values() ([]any, error) {
 			values[i] = pgtype.Date{Time: v.Val, Valid: true}
 		case types.QValueHStore:
 			values[i] = v.Val
+		case types.QValueStruct:
+			if literal, ok := rangeLiteral(v); ok {
+				values[i] = literal
+			} else {
+				values[i] = v.Value()
+			}
+		case types.QValueArrayStruct:
+			if literal, ok := multirangeLiteral(v); ok {
+				values[i] = literal
+			} else {
+				values[i] = v.Value()
+			}
+		case types.QValueMap:
+			values[i] = v.Value()
 		case types.QValueGeography:
 			wkb, err := geoWktToWkb(v.Val)
 			if err != nil {
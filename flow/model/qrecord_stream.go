@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/shared/concurrency"
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
@@ -12,8 +13,20 @@ type QRecordStream struct {
 	schemaLatch *concurrency.Latch[types.QRecordSchema]
 	Records     chan []types.QValue
 	schemaDebug *types.NullableSchemaDebug
-	err         error
-	closeOnce   sync.Once
+	// timestampPolicy normalizes timestamps of records as they are sent
+	timestampPolicy types.TimestampPolicy
+	err             error
+	resumePoints    []resumePoint
+	// records sent so far, only touched by the sender
+	sent                int64
+	resumePointInterval int64
+	resumeMu            sync.Mutex
+	closeOnce           sync.Once
+}
+
+type resumePoint struct {
+	remaining *protos.PartitionRange
+	sent      int64
 }
 
 func NewQRecordStream(buffer int) *QRecordStream {
@@ -52,19 +65,61 @@ func (s *QRecordStream) HandleQRepSyncError(err error) {
 	// no-op for QRecordStream
 }
 
+func (s *QRecordStream) SetTimestampPolicy(policy types.TimestampPolicy) {
+	s.timestampPolicy = policy
+}
+
 // Sends the record into the channel, erroring out on context cancellation instead of waiting for the reader indefinitely
 func (s *QRecordStream) Send(ctx context.Context, record []types.QValue) error {
 	if s.err != nil {
 		return s.err
 	}
+	if !s.timestampPolicy.IsNoop() {
+		for i, val := range record {
+			if val != nil {
+				record[i] = s.timestampPolicy.Apply(val)
+			}
+		}
+	}
 	select {
 	case s.Records <- record:
+		s.sent += 1
 		return nil
 	case <-ctx.Done():
 		return ctx.Err()
 	}
 }
 
+// EnableResumePoints asks the sender for a resume point about every interval records, must be called before sending
+func (s *QRecordStream) EnableResumePoints(interval int64) {
+	s.resumePointInterval = interval
+}
+
+// ResumePointInterval returns how many records the sender should send between resume points, 0 if none are wanted
+func (s *QRecordStream) ResumePointInterval() int64 {
+	return s.resumePointInterval
+}
+
+// SetResumePoint records that every record outside of remaining was sent, so that pulling remaining
+// continues the partition after the records sent so far
+func (s *QRecordStream) SetResumePoint(remaining *protos.PartitionRange) {
+	s.resumeMu.Lock()
+	defer s.resumeMu.Unlock()
+	s.resumePoints = append(s.resumePoints, resumePoint{remaining: remaining, sent: s.sent})
+}
+
+// TakeResumePoint returns the latest resume point reached once received records were read, or nil
+func (s *QRecordStream) TakeResumePoint(received int64) *protos.PartitionRange {
+	s.resumeMu.Lock()
+	defer s.resumeMu.Unlock()
+	var remaining *protos.PartitionRange
+	for len(s.resumePoints) > 0 && s.resumePoints[0].sent <= received {
+		remaining = s.resumePoints[0].remaining
+		s.resumePoints = s.resumePoints[1:]
+	}
+	return remaining
+}
+
 func (s *QRecordStream) Err() error {
 	return s.err
 }
@@ -10,6 +10,7 @@ import (
 	"math/big"
 	"math/bits"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -157,6 +158,77 @@ func GetAvroSchemaFromQValueKind(
 	}
 }
 
+// GetAvroSchemaFromQField extends GetAvroSchemaFromQValueKind to nested types,
+// structs become records named after the path to the field, arrays of structs become arrays of those records
+// and maps become Avro maps of their value field.
+func GetAvroSchemaFromQField(
+	ctx context.Context,
+	env map[string]string,
+	field *types.QField,
+	targetDWH protos.DBType,
+) (avro.Schema, error) {
+	return getAvroSchemaFromQField(ctx, env, field, targetDWH, field.Name)
+}
+
+func getAvroSchemaFromQField(
+	ctx context.Context,
+	env map[string]string,
+	field *types.QField,
+	targetDWH protos.DBType,
+	path string,
+) (avro.Schema, error) {
+	switch field.Type {
+	case types.QValueKindStruct:
+		if len(field.Fields) == 0 {
+			return nil, fmt.Errorf("struct field %s has no members", field.Name)
+		}
+		avroFields := make([]*avro.Field, 0, len(field.Fields))
+		for i := range field.Fields {
+			member := &field.Fields[i]
+			memberSchema, err := getAvroSchemaFromQField(ctx, env, member, targetDWH, path+"_"+member.Name)
+			if err != nil {
+				return nil, err
+			}
+			if member.Nullable {
+				if memberSchema, err = NullableAvroSchema(memberSchema); err != nil {
+					return nil, err
+				}
+			}
+			avroField, err := avro.NewField(ConvertToAvroCompatibleName(member.Name), memberSchema)
+			if err != nil {
+				return nil, err
+			}
+			avroFields = append(avroFields, avroField)
+		}
+		return avro.NewRecordSchema(ConvertToAvroCompatibleName("struct_"+path), "", avroFields)
+	case types.QValueKindArrayStruct:
+		elementField := *field
+		elementField.Type = types.QValueKindStruct
+		elementSchema, err := getAvroSchemaFromQField(ctx, env, &elementField, targetDWH, path)
+		if err != nil {
+			return nil, err
+		}
+		return avro.NewArraySchema(elementSchema), nil
+	case types.QValueKindMap:
+		if len(field.Fields) != 2 {
+			return nil, fmt.Errorf("map field %s needs a key and a value field", field.Name)
+		}
+		valueField := &field.Fields[1]
+		valueSchema, err := getAvroSchemaFromQField(ctx, env, valueField, targetDWH, path+"_value")
+		if err != nil {
+			return nil, err
+		}
+		if valueField.Nullable {
+			if valueSchema, err = NullableAvroSchema(valueSchema); err != nil {
+				return nil, err
+			}
+		}
+		return avro.NewMapSchema(valueSchema), nil
+	default:
+		return GetAvroSchemaFromQValueKind(ctx, env, field.Type, targetDWH, field.Precision, field.Scale)
+	}
+}
+
 func NullableAvroSchema(schema avro.Schema) (avro.Schema, error) {
 	return avro.NewUnionSchema([]avro.Schema{avro.NewNullSchema(), schema})
 }
@@ -305,8 +377,7 @@ func QValueToAvro(
 	case types.QValueBoolean:
 		return c.processNullableUnion(v.Val), constSize(1, sizeOpt), nil
 	case types.QValueNumeric:
-		val, size := c.processNumeric(v.Val, sizeOpt)
-		return val, size, nil
+		return c.processNumeric(v.Val, sizeOpt)
 	case types.QValueBytes:
 		val, size := c.processBytes(v.Val, sizeOpt)
 		return val, size, nil
@@ -352,8 +423,25 @@ func QValueToAvro(
 		val, size := c.processArrayUUID(v.Val, sizeOpt)
 		return val, size, nil
 	case types.QValueArrayNumeric:
-		val, size := c.processArrayNumeric(v.Val, sizeOpt)
-		return val, size, nil
+		return c.processArrayNumeric(v.Val, sizeOpt)
+	case types.QValueStruct:
+		val, size, err := c.processStruct(ctx, v.Val, calcSize)
+		if err != nil {
+			return nil, 0, err
+		}
+		return c.processNullableUnion(val), size + sizeOpt.nullableSize(), nil
+	case types.QValueArrayStruct:
+		val, size, err := c.processArrayStruct(ctx, v.Val, calcSize)
+		if err != nil {
+			return nil, 0, err
+		}
+		return c.processNullableUnion(val), size + sizeOpt.nullableSize(), nil
+	case types.QValueMap:
+		val, size, err := c.processMap(ctx, v.Val, calcSize)
+		if err != nil {
+			return nil, 0, err
+		}
+		return c.processNullableUnion(val), size + sizeOpt.nullableSize(), nil
 	default:
 		return nil, 0, fmt.Errorf("[QValueToAvro] unsupported %T", value)
 	}
@@ -441,22 +529,32 @@ func (c *QValueAvroConverter) processNullableUnion(
 // Avro size of zero: varint(1) + 1 byte = 2
 const zeroDecimalSize = 2
 
-func (c *QValueAvroConverter) processNumeric(num decimal.Decimal, so sizeOpt) (any, int64) {
-	destType := GetNumericDestinationType(c.Precision, c.Scale, c.TargetDWH, c.UnboundedNumericAsString)
+// numericDestinationType follows the NUMERIC strategy of the column when it has stats
+func (c *QValueAvroConverter) numericDestinationType() NumericDestinationType {
+	if c.Stat != nil {
+		return GetNumericDestinationTypeForStrategy(c.Precision, c.Scale, c.TargetDWH, c.UnboundedNumericAsString, c.Stat.Strategy)
+	}
+	return GetNumericDestinationType(c.Precision, c.Scale, c.TargetDWH, c.UnboundedNumericAsString)
+}
+
+func (c *QValueAvroConverter) processNumeric(num decimal.Decimal, so sizeOpt) (any, int64, error) {
+	destType := c.numericDestinationType()
 	if destType.IsString {
 		s := num.String()
-		return c.processNullableUnion(s), stringSize(s, so)
+		return c.processNullableUnion(s), stringSize(s, so), nil
 	}
 
-	num, intDigits, ok := TruncateNumeric(num, destType.Precision, destType.Scale, c.TargetDWH, c.Stat)
-	if !ok {
+	num, intDigits, ok, err := TruncateNumeric(num, destType.Precision, destType.Scale, c.TargetDWH, c.Stat)
+	if err != nil {
+		return nil, 0, err
+	} else if !ok {
 		if c.Nullable {
-			return nil, so.nullableSize()
+			return nil, so.nullableSize(), nil
 		}
-		return big.Rat{}, constSize(zeroDecimalSize, so)
+		return big.Rat{}, constSize(zeroDecimalSize, so), nil
 	}
 
-	return c.processNullableUnion(num.Rat()), decimalSize(num, intDigits, int(destType.Scale), so)
+	return c.processNullableUnion(num.Rat()), decimalSize(num, intDigits, int(destType.Scale), so), nil
 }
 
 // decimalSize estimates Avro-encoded size from integer digit count and scale.
@@ -523,8 +621,8 @@ func (c *QValueAvroConverter) processUInt256(num *big.Int) any {
 	return c.processNullableUnion(bigIntTo32Bytes(num))
 }
 
-func (c *QValueAvroConverter) processArrayNumeric(arrayNum []decimal.Decimal, so sizeOpt) (any, int64) {
-	destType := GetNumericDestinationType(c.Precision, c.Scale, c.TargetDWH, c.UnboundedNumericAsString)
+func (c *QValueAvroConverter) processArrayNumeric(arrayNum []decimal.Decimal, so sizeOpt) (any, int64, error) {
+	destType := c.numericDestinationType()
 	if destType.IsString {
 		transformedNumArr := make([]string, 0, len(arrayNum))
 		totalElemSize := int64(0)
@@ -533,14 +631,16 @@ func (c *QValueAvroConverter) processArrayNumeric(arrayNum []decimal.Decimal, so
 			transformedNumArr = append(transformedNumArr, s)
 			totalElemSize += stringSize(s, sizePlain)
 		}
-		return transformedNumArr, arraySize(len(arrayNum), totalElemSize, so)
+		return transformedNumArr, arraySize(len(arrayNum), totalElemSize, so), nil
 	}
 
 	transformedNumArr := make([]*big.Rat, 0, len(arrayNum))
 	totalElemSize := int64(0)
 	for _, num := range arrayNum {
-		num, intDigits, ok := TruncateNumeric(num, destType.Precision, destType.Scale, c.TargetDWH, c.Stat)
-		if !ok {
+		num, intDigits, ok, err := TruncateNumeric(num, destType.Precision, destType.Scale, c.TargetDWH, c.Stat)
+		if err != nil {
+			return nil, 0, err
+		} else if !ok {
 			transformedNumArr = append(transformedNumArr, &big.Rat{})
 			totalElemSize += zeroDecimalSize
 			continue
@@ -548,7 +648,7 @@ func (c *QValueAvroConverter) processArrayNumeric(arrayNum []decimal.Decimal, so
 		transformedNumArr = append(transformedNumArr, num.Rat())
 		totalElemSize += decimalSize(num, intDigits, int(destType.Scale), sizePlain)
 	}
-	return transformedNumArr, arraySize(len(arrayNum), totalElemSize, so)
+	return transformedNumArr, arraySize(len(arrayNum), totalElemSize, so), nil
 }
 
 func (c *QValueAvroConverter) processBytes(byteData []byte, so sizeOpt) (any, int64) {
@@ -666,6 +766,80 @@ func (c *QValueAvroConverter) processHStore(hstore string) (any, error) {
 	return jsonString, nil
 }
 
+func (c *QValueAvroConverter) processStruct(ctx context.Context, members []types.QValueStructField, calcSize bool) (any, int64, error) {
+	record := make(map[string]any, len(members))
+	var size int64
+	for i, member := range members {
+		var memberField *types.QField
+		if i < len(c.Fields) && c.Fields[i].Name == member.Name {
+			memberField = &c.Fields[i]
+		} else if idx := slices.IndexFunc(c.Fields, func(f types.QField) bool { return f.Name == member.Name }); idx >= 0 {
+			memberField = &c.Fields[idx]
+		} else {
+			return nil, 0, fmt.Errorf("struct member %s missing from schema of %s", member.Name, c.Name)
+		}
+		val, memberSize, err := QValueToAvro(ctx, member.Val, memberField, c.TargetDWH, c.logger,
+			c.UnboundedNumericAsString, nil, c.binaryFormat, calcSize)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to convert struct member %s: %w", member.Name, err)
+		}
+		record[ConvertToAvroCompatibleName(member.Name)] = val
+		size += memberSize
+	}
+	// members absent from the value are written as null
+	for i := range c.Fields {
+		name := ConvertToAvroCompatibleName(c.Fields[i].Name)
+		if _, ok := record[name]; !ok {
+			record[name] = nil
+			if calcSize {
+				size++
+			}
+		}
+	}
+	return record, size, nil
+}
+
+func (c *QValueAvroConverter) processArrayStruct(ctx context.Context, elements []types.QValueStruct, calcSize bool) (any, int64, error) {
+	records := make([]any, 0, len(elements))
+	var size int64
+	for _, element := range elements {
+		record, recordSize, err := c.processStruct(ctx, element.Val, calcSize)
+		if err != nil {
+			return nil, 0, err
+		}
+		records = append(records, record)
+		size += recordSize
+	}
+	if calcSize {
+		size = arraySize(len(elements), size, sizePlain)
+	}
+	return records, size, nil
+}
+
+func (c *QValueAvroConverter) processMap(ctx context.Context, entries map[string]types.QValue, calcSize bool) (any, int64, error) {
+	if len(c.Fields) != 2 {
+		return nil, 0, fmt.Errorf("map field %s needs a key and a value field", c.Name)
+	}
+	avroMap := make(map[string]any, len(entries))
+	var size int64
+	for key, val := range entries {
+		avroVal, valSize, err := QValueToAvro(ctx, val, &c.Fields[1], c.TargetDWH, c.logger,
+			c.UnboundedNumericAsString, nil, c.binaryFormat, calcSize)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to convert map entry %s: %w", key, err)
+		}
+		avroMap[key] = avroVal
+		if calcSize {
+			size += stringSize(key, sizePlain) + valSize
+		}
+	}
+	if calcSize {
+		// maps are written in blocks like arrays
+		size = arraySize(len(entries), size, sizePlain)
+	}
+	return avroMap, size, nil
+}
+
 func (c *QValueAvroConverter) processUUID(byteData uuid.UUID, so sizeOpt) (any, int64) {
 	uuidString := byteData.String()
 	size := stringSize(uuidString, so)
@@ -720,41 +894,70 @@ func (c *QValueAvroConverter) processArrayString(arrayData []string) any {
 	return arrayData
 }
 
+func integerDigits(num decimal.Decimal) int {
+	bi := num.BigInt()
+	if bi.Sign() == 0 {
+		return 0
+	}
+	return datatypes.CountDigits(bi)
+}
+
 // TruncateNumeric truncates a decimal to fit within the target precision and scale.
 // Returns the truncated decimal, the number of integer digits, and whether truncation succeeded.
 // If ok is false, the value was too large and should be treated as zero.
+// The strategy of stat decides whether digits beyond the scale are truncated or rounded,
+// or whether a value that doesn't fit is an error instead.
 func TruncateNumeric(
 	num decimal.Decimal, targetPrecision, targetScale int16, targetDWH protos.DBType, stat *NumericStat,
-) (decimal.Decimal, int, bool) {
+) (decimal.Decimal, int, bool, error) {
 	switch targetDWH {
 	case protos.DBType_CLICKHOUSE, protos.DBType_SNOWFLAKE, protos.DBType_BIGQUERY:
-		bi := num.BigInt()
-		bidigi := datatypes.CountDigits(bi)
-		if bi.Sign() == 0 {
-			bidigi = 0
+		strategy := protos.NumericStrategy_NUMERIC_STRATEGY_TRUNCATE
+		if stat != nil {
+			strategy = stat.Strategy
 		}
+		bidigi := integerDigits(num)
 		if bidigi+int(targetScale) > int(targetPrecision) {
+			if strategy == protos.NumericStrategy_NUMERIC_STRATEGY_FAIL {
+				return decimal.Zero, 0, false, stat.valueError(num, targetPrecision, targetScale, true)
+			}
 			if stat != nil {
 				stat.LongIntegersClearedCount++
 				stat.MaxIntegerDigits = max(int32(bidigi), stat.MaxIntegerDigits)
 			}
-			return decimal.Zero, 0, false
+			return decimal.Zero, 0, false, nil
 		} else if num.Exponent() < -int32(targetScale) {
+			switch strategy {
+			case protos.NumericStrategy_NUMERIC_STRATEGY_FAIL:
+				return decimal.Zero, 0, false, stat.valueError(num, targetPrecision, targetScale, false)
+			case protos.NumericStrategy_NUMERIC_STRATEGY_ROUND:
+				rounded := num.Round(int32(targetScale))
+				// rounding up can carry into another integer digit
+				if roundedDigits := integerDigits(rounded); roundedDigits+int(targetScale) > int(targetPrecision) {
+					stat.LongIntegersClearedCount++
+					stat.MaxIntegerDigits = max(int32(roundedDigits), stat.MaxIntegerDigits)
+					return decimal.Zero, 0, false, nil
+				}
+				stat.TruncatedCount++
+				stat.MaxExponent = max(-num.Exponent(), stat.MaxExponent)
+				return rounded, integerDigits(rounded), true, nil
+			}
 			if stat != nil {
 				stat.TruncatedCount++
 				stat.MaxExponent = max(-num.Exponent(), stat.MaxExponent)
 			}
-			return num.Truncate(int32(targetScale)), bidigi, true
+			return num.Truncate(int32(targetScale)), bidigi, true, nil
 		}
-		return num, bidigi, true
+		return num, bidigi, true, nil
 	}
-	return num, 0, true
+	return num, 0, true, nil
 }
 
 //nolint:govet // logically grouped, fieldalignment confuses things
 type NumericStat struct {
 	DestinationTable         string
 	DestinationColumn        string
+	Strategy                 protos.NumericStrategy
 	TruncatedCount           uint64
 	MaxExponent              int32
 	LongIntegersClearedCount uint64
@@ -762,13 +965,26 @@ type NumericStat struct {
 	BigInt256ClearedCount    uint64
 }
 
-func NewNumericStat(destinationTable, destinationColumn string) NumericStat {
+func NewNumericStat(destinationTable, destinationColumn string, strategy protos.NumericStrategy) NumericStat {
 	return NumericStat{
 		DestinationTable:  destinationTable,
 		DestinationColumn: destinationColumn,
+		Strategy:          strategy,
 	}
 }
 
+// valueError is returned by TruncateNumeric for a value that doesn't fit a column with the fail strategy
+func (ns *NumericStat) valueError(num decimal.Decimal, targetPrecision, targetScale int16, outOfRange bool) error {
+	if outOfRange {
+		err := fmt.Errorf("column %s.%s: NUMERIC value %s is too big to fit into the destination column with precision %d and scale %d",
+			ns.DestinationTable, ns.DestinationColumn, num, targetPrecision, targetScale)
+		return exceptions.NewNumericOutOfRangeError(err, ns.DestinationTable, ns.DestinationColumn, 1)
+	}
+	err := fmt.Errorf("column %s.%s: NUMERIC value %s is too precise to fit into the destination column with scale %d",
+		ns.DestinationTable, ns.DestinationColumn, num, targetScale)
+	return exceptions.NewNumericTruncatedError(err, ns.DestinationTable, ns.DestinationColumn, 1)
+}
+
 func (ns *NumericStat) CollectWarnings(warnings *shared.QRepWarnings) {
 	if ns.LongIntegersClearedCount > 0 {
 		plural := ""
@@ -778,7 +994,7 @@ func (ns *NumericStat) CollectWarnings(warnings *shared.QRepWarnings) {
 		err := fmt.Errorf(
 			"column %s.%s: cleared %d NUMERIC value%s too big to fit into the destination column (got %d integer digits)",
 			ns.DestinationTable, ns.DestinationColumn, ns.LongIntegersClearedCount, plural, ns.MaxIntegerDigits)
-		warning := exceptions.NewNumericOutOfRangeError(err, ns.DestinationTable, ns.DestinationColumn)
+		warning := exceptions.NewNumericOutOfRangeError(err, ns.DestinationTable, ns.DestinationColumn, ns.LongIntegersClearedCount)
 		*warnings = append(*warnings, warning)
 	}
 	if ns.TruncatedCount > 0 {
@@ -786,10 +1002,14 @@ func (ns *NumericStat) CollectWarnings(warnings *shared.QRepWarnings) {
 		if ns.TruncatedCount > 1 {
 			plural = "s"
 		}
+		verb := "truncated"
+		if ns.Strategy == protos.NumericStrategy_NUMERIC_STRATEGY_ROUND {
+			verb = "rounded"
+		}
 		err := fmt.Errorf(
-			"column %s.%s: truncated %d NUMERIC value%s too precise to fit into the destination column (got %d digits of exponent)",
-			ns.DestinationTable, ns.DestinationColumn, ns.TruncatedCount, plural, ns.MaxExponent)
-		warning := exceptions.NewNumericTruncatedError(err, ns.DestinationTable, ns.DestinationColumn)
+			"column %s.%s: %s %d NUMERIC value%s too precise to fit into the destination column (got %d digits of exponent)",
+			ns.DestinationTable, ns.DestinationColumn, verb, ns.TruncatedCount, plural, ns.MaxExponent)
+		warning := exceptions.NewNumericTruncatedError(err, ns.DestinationTable, ns.DestinationColumn, ns.TruncatedCount)
 		*warnings = append(*warnings, warning)
 	}
 	if ns.BigInt256ClearedCount > 0 {
@@ -800,7 +1020,7 @@ func (ns *NumericStat) CollectWarnings(warnings *shared.QRepWarnings) {
 		err := fmt.Errorf(
 			"column %s.%s: cleared %d NUMERIC value%s that do not fit into (U)Int256 type in the destination column",
 			ns.DestinationTable, ns.DestinationColumn, ns.BigInt256ClearedCount, plural)
-		warning := exceptions.NewNumericOutOfRangeError(err, ns.DestinationTable, ns.DestinationColumn)
+		warning := exceptions.NewNumericOutOfRangeError(err, ns.DestinationTable, ns.DestinationColumn, ns.BigInt256ClearedCount)
 		*warnings = append(*warnings, warning)
 	}
 }
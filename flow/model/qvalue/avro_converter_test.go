@@ -394,6 +394,89 @@ func TestDecimalSize(t *testing.T) {
 	}
 }
 
+func TestAvroStructAndMapSize(t *testing.T) {
+	ctx := context.Background()
+	env := map[string]string{}
+
+	point := types.QField{Name: "point", Type: types.QValueKindStruct, Fields: []types.QField{
+		{Name: "x", Type: types.QValueKindInt64, Nullable: true},
+		{Name: "label", Type: types.QValueKindString, Nullable: true},
+	}}
+	nested := types.QField{Name: "nested", Type: types.QValueKindStruct, Fields: []types.QField{
+		{Name: "id", Type: types.QValueKindInt32, Nullable: true},
+		point,
+	}}
+	nested.Fields[1].Nullable = true
+	counts := types.QField{Name: "counts", Type: types.QValueKindMap, Fields: []types.QField{
+		{Name: "key", Type: types.QValueKindString},
+		{Name: "value", Type: types.QValueKindInt64, Nullable: true},
+	}}
+	points := point
+	points.Name = "points"
+	points.Type = types.QValueKindArrayStruct
+
+	tests := []struct {
+		value types.QValue
+		name  string
+		field types.QField
+	}{
+		{name: "struct", field: point, value: types.QValueStruct{Val: []types.QValueStructField{
+			{Name: "x", Val: types.QValueInt64{Val: 42}},
+			{Name: "label", Val: types.QValueString{Val: "hello"}},
+		}}},
+		{name: "struct_null_member", field: point, value: types.QValueStruct{Val: []types.QValueStructField{
+			{Name: "x", Val: types.QValueNull(types.QValueKindInt64)},
+			{Name: "label", Val: types.QValueString{Val: "hello"}},
+		}}},
+		{name: "struct_missing_member", field: point, value: types.QValueStruct{Val: []types.QValueStructField{
+			{Name: "label", Val: types.QValueString{Val: "hello"}},
+		}}},
+		{name: "nested_struct", field: nested, value: types.QValueStruct{Val: []types.QValueStructField{
+			{Name: "id", Val: types.QValueInt32{Val: 7}},
+			{Name: "point", Val: types.QValueStruct{Val: []types.QValueStructField{
+				{Name: "x", Val: types.QValueInt64{Val: 1}},
+				{Name: "label", Val: types.QValueNull(types.QValueKindString)},
+			}}},
+		}}},
+		{name: "map", field: counts, value: types.QValueMap{Val: map[string]types.QValue{
+			"a": types.QValueInt64{Val: 1},
+			"b": types.QValueNull(types.QValueKindInt64),
+		}}},
+		{name: "empty_map", field: counts, value: types.QValueMap{Val: map[string]types.QValue{}}},
+		{name: "array_struct", field: points, value: types.QValueArrayStruct{Val: []types.QValueStruct{
+			{Val: []types.QValueStructField{
+				{Name: "x", Val: types.QValueInt64{Val: 1}},
+				{Name: "label", Val: types.QValueString{Val: "a"}},
+			}},
+			{Val: []types.QValueStructField{
+				{Name: "x", Val: types.QValueNull(types.QValueKindInt64)},
+			}},
+		}}},
+		{name: "empty_array_struct", field: points, value: types.QValueArrayStruct{Val: []types.QValueStruct{}}},
+	}
+
+	for _, tc := range tests {
+		for _, nullable := range []bool{false, true} {
+			field := tc.field
+			field.Nullable = nullable
+			name := tc.name
+			if nullable {
+				name += "_nullable"
+			}
+			t.Run(name, func(t *testing.T) {
+				schema, err := GetAvroSchemaFromQField(ctx, env, &field, protos.DBType_CLICKHOUSE)
+				require.NoError(t, err)
+				if nullable {
+					schema, err = NullableAvroSchema(schema)
+					require.NoError(t, err)
+				}
+				avroVal, computedSize := qvalueToAvro(t, ctx, tc.value, &field, false)
+				assert.Equal(t, avroEncodedSize(t, schema, avroVal), computedSize)
+			})
+		}
+	}
+}
+
 func getAvroSchema(
 	t *testing.T, ctx context.Context, env map[string]string, qv types.QValue, field *types.QField,
 ) avro.Schema {
@@ -423,3 +506,68 @@ func avroEncodedSize(t *testing.T, schema avro.Schema, value any) int64 {
 	require.NoError(t, err)
 	return int64(len(data))
 }
+
+func TestTruncateNumericStrategies(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name       string
+		value      string
+		expected   string
+		truncated  uint64
+		cleared    uint64
+		strategy   protos.NumericStrategy
+		expectedOk bool
+		expectErr  bool
+	}{
+		{name: "truncate fits", strategy: protos.NumericStrategy_NUMERIC_STRATEGY_TRUNCATE, value: "12.34", expected: "12.34", expectedOk: true},
+		{name: "truncate scale", strategy: protos.NumericStrategy_NUMERIC_STRATEGY_TRUNCATE, value: "12.345", expected: "12.34",
+			expectedOk: true, truncated: 1},
+		{name: "truncate too big", strategy: protos.NumericStrategy_NUMERIC_STRATEGY_TRUNCATE, value: "12345", expected: "0", cleared: 1},
+		{name: "round scale", strategy: protos.NumericStrategy_NUMERIC_STRATEGY_ROUND, value: "12.345", expected: "12.35",
+			expectedOk: true, truncated: 1},
+		{name: "round negative", strategy: protos.NumericStrategy_NUMERIC_STRATEGY_ROUND, value: "-12.345", expected: "-12.35",
+			expectedOk: true, truncated: 1},
+		{name: "round carries", strategy: protos.NumericStrategy_NUMERIC_STRATEGY_ROUND, value: "999.996", expected: "0", cleared: 1},
+		{name: "fail fits", strategy: protos.NumericStrategy_NUMERIC_STRATEGY_FAIL, value: "12.34", expected: "12.34", expectedOk: true},
+		{name: "fail scale", strategy: protos.NumericStrategy_NUMERIC_STRATEGY_FAIL, value: "12.345", expectErr: true},
+		{name: "fail too big", strategy: protos.NumericStrategy_NUMERIC_STRATEGY_FAIL, value: "12345", expectErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			stat := NewNumericStat("t", "c", tc.strategy)
+			num, _, ok, err := TruncateNumeric(decimal.RequireFromString(tc.value), 5, 2, protos.DBType_CLICKHOUSE, &stat)
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedOk, ok)
+			require.Equal(t, tc.expected, num.String())
+			require.Equal(t, tc.truncated, stat.TruncatedCount)
+			require.Equal(t, tc.cleared, stat.LongIntegersClearedCount)
+		})
+	}
+}
+
+func TestGetNumericDestinationTypeForStrategy(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, NumericDestinationType{IsString: true},
+		GetNumericDestinationTypeForStrategy(10, 2, protos.DBType_CLICKHOUSE, false, protos.NumericStrategy_NUMERIC_STRATEGY_STRING))
+	require.Equal(t, NumericDestinationType{Precision: 76, Scale: 2},
+		GetNumericDestinationTypeForStrategy(10, 2, protos.DBType_CLICKHOUSE, false, protos.NumericStrategy_NUMERIC_STRATEGY_WIDEST_DECIMAL))
+	// unbounded NUMERIC stays a decimal even when unbounded values are otherwise strings
+	require.Equal(t, NumericDestinationType{Precision: 76, Scale: 38},
+		GetNumericDestinationTypeForStrategy(0, 0, protos.DBType_CLICKHOUSE, true, protos.NumericStrategy_NUMERIC_STRATEGY_WIDEST_DECIMAL))
+	require.Equal(t, NumericDestinationType{Precision: 76, Scale: 10},
+		GetNumericDestinationTypeForStrategy(100, 10, protos.DBType_CLICKHOUSE, false, protos.NumericStrategy_NUMERIC_STRATEGY_WIDEST_DECIMAL))
+	require.Equal(t, NumericDestinationType{Precision: 10, Scale: 2},
+		GetNumericDestinationTypeForStrategy(10, 2, protos.DBType_CLICKHOUSE, false, protos.NumericStrategy_NUMERIC_STRATEGY_ROUND))
+	require.Equal(t, NumericDestinationType{Precision: 40, Scale: 2},
+		GetNumericDestinationTypeForStrategy(10, 2, protos.DBType_BIGQUERY, false, protos.NumericStrategy_NUMERIC_STRATEGY_WIDEST_DECIMAL))
+	require.Equal(t, NumericDestinationType{Precision: 76, Scale: 38},
+		GetNumericDestinationTypeForStrategy(0, 0, protos.DBType_BIGQUERY, false, protos.NumericStrategy_NUMERIC_STRATEGY_WIDEST_DECIMAL))
+	require.Equal(t, NumericDestinationType{Precision: 38, Scale: 2},
+		GetNumericDestinationTypeForStrategy(10, 2, protos.DBType_SNOWFLAKE, false, protos.NumericStrategy_NUMERIC_STRATEGY_WIDEST_DECIMAL))
+	require.Equal(t, NumericDestinationType{Precision: 38, Scale: 20},
+		GetNumericDestinationTypeForStrategy(0, 0, protos.DBType_SNOWFLAKE, false, protos.NumericStrategy_NUMERIC_STRATEGY_WIDEST_DECIMAL))
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"slices"
+	"strings"
 
 	chproto "github.com/ClickHouse/clickhouse-go/v2/lib/proto"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
+	peerdb_clickhouse "github.com/PeerDB-io/peerdb/flow/pkg/clickhouse"
 	"github.com/PeerDB-io/peerdb/flow/pkg/common"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 	"github.com/PeerDB-io/peerdb/flow/shared/datatypes"
@@ -39,6 +41,65 @@ func GetNumericDestinationType(
 	}
 }
 
+// GetNumericDestinationTypeForStrategy is GetNumericDestinationType for a column with a NUMERIC strategy,
+// only ClickHouse has a type for the string strategy, the widest decimal strategy
+// uses Decimal256 on ClickHouse, BIGNUMERIC on BigQuery and NUMBER(38) on Snowflake
+func GetNumericDestinationTypeForStrategy(
+	precision, scale int16, targetDWH protos.DBType, unboundedNumericAsString bool, strategy protos.NumericStrategy,
+) NumericDestinationType {
+	switch targetDWH {
+	case protos.DBType_CLICKHOUSE:
+		switch strategy {
+		case protos.NumericStrategy_NUMERIC_STRATEGY_STRING:
+			return NumericDestinationType{IsString: true}
+		case protos.NumericStrategy_NUMERIC_STRATEGY_WIDEST_DECIMAL:
+			_, destScale := DetermineNumericSettingForDWH(min(precision, datatypes.PeerDBClickHouseMaxPrecision), scale, targetDWH)
+			return NumericDestinationType{
+				Precision: datatypes.PeerDBClickHouseMaxPrecision,
+				Scale:     destScale,
+			}
+		}
+	case protos.DBType_BIGQUERY:
+		if strategy == protos.NumericStrategy_NUMERIC_STRATEGY_WIDEST_DECIMAL {
+			// BIGNUMERIC keeps as many integer digits as its maximum scale next to the scale of the column
+			destScale := int16(datatypes.PeerDBBigQueryBigNumericMaxScale)
+			if precision != 0 || scale != 0 {
+				destScale = min(max(scale, 0), destScale)
+			}
+			return NumericDestinationType{
+				Precision: destScale + datatypes.PeerDBBigQueryBigNumericMaxScale,
+				Scale:     destScale,
+			}
+		}
+	case protos.DBType_SNOWFLAKE:
+		if strategy == protos.NumericStrategy_NUMERIC_STRATEGY_WIDEST_DECIMAL {
+			maxPrecision := datatypes.SnowflakeNumericCompatibility{}.MaxPrecision()
+			_, destScale := DetermineNumericSettingForDWH(min(precision, maxPrecision), scale, targetDWH)
+			return NumericDestinationType{
+				Precision: maxPrecision,
+				Scale:     destScale,
+			}
+		}
+	}
+	return GetNumericDestinationType(precision, scale, targetDWH, unboundedNumericAsString)
+}
+
+// ColumnNumericStrategy returns the NUMERIC strategy of a source column in the table mapping of destinationTable
+func ColumnNumericStrategy(
+	tableMappings []*protos.TableMapping, destinationTable string, sourceColumn string,
+) protos.NumericStrategy {
+	for _, tableMapping := range tableMappings {
+		if tableMapping.DestinationTableIdentifier == destinationTable {
+			for _, col := range tableMapping.Columns {
+				if col.SourceName == sourceColumn {
+					return col.NumericStrategy
+				}
+			}
+		}
+	}
+	return protos.NumericStrategy_NUMERIC_STRATEGY_TRUNCATE
+}
+
 func getClickHouseTypeForNumericColumn(ctx context.Context, env map[string]string, typeModifier int32) (string, error) {
 	precision, scale := common.ParseNumericTypmod(typeModifier)
 	asString, err := internal.PeerDBEnableClickHouseNumericAsString(ctx, env)
@@ -95,12 +156,20 @@ func ToDWHColumnType(
 		} else if (kind == types.QValueKindTime || kind == types.QValueKindTimeTZ) &&
 			slices.Contains(flags, shared.Flag_ClickHouseTime64Enabled) {
 			colType = "Time64(6)"
+		} else if kind.HasStructFields() || kind == types.QValueKindMap {
+			var err error
+			colType, err = getClickHouseTypeForNestedColumn(ctx, kind, env, dwhVersion, column, flags)
+			if err != nil {
+				return "", err
+			}
 		} else if val, ok := types.QValueKindToClickHouseTypeMap[kind]; ok {
 			colType = val
 		} else {
 			colType = "String"
 		}
-		if nullableEnabled && column.Nullable && !kind.IsArray() {
+		// ClickHouse does not allow Tuple or Map to be Nullable, only their members
+		if nullableEnabled && column.Nullable && !kind.IsArray() &&
+			kind != types.QValueKindStruct && kind != types.QValueKindMap {
 			if colType == "LowCardinality(String)" {
 				colType = "LowCardinality(Nullable(String))"
 			} else {
@@ -113,6 +182,83 @@ func ToDWHColumnType(
 	return colType, nil
 }
 
+// getClickHouseTypeForNestedColumn maps structs to named tuples, arrays of structs to arrays of those tuples
+// and maps to Map(String, T)
+func getClickHouseTypeForNestedColumn(
+	ctx context.Context,
+	kind types.QValueKind,
+	env map[string]string,
+	dwhVersion *chproto.Version,
+	column *protos.FieldDescription,
+	flags []string,
+) (string, error) {
+	memberType := func(member *protos.FieldDescription) (string, error) {
+		return ToDWHColumnType(ctx, types.QValueKind(member.Type), env, protos.DBType_CLICKHOUSE, dwhVersion, member, true, flags)
+	}
+
+	switch kind {
+	case types.QValueKindStruct, types.QValueKindArrayStruct:
+		if len(column.Fields) == 0 {
+			return "", fmt.Errorf("struct column %s has no members", column.Name)
+		}
+		members := make([]string, 0, len(column.Fields))
+		for _, member := range column.Fields {
+			memberColType, err := memberType(member)
+			if err != nil {
+				return "", err
+			}
+			members = append(members, peerdb_clickhouse.QuoteIdentifier(member.Name)+" "+memberColType)
+		}
+		if kind == types.QValueKindArrayStruct {
+			return "Array(Tuple(" + strings.Join(members, ", ") + "))", nil
+		}
+		return "Tuple(" + strings.Join(members, ", ") + ")", nil
+	case types.QValueKindMap:
+		if len(column.Fields) != 2 {
+			return "", fmt.Errorf("map column %s needs a key and a value field", column.Name)
+		}
+		valueColType, err := memberType(column.Fields[1])
+		if err != nil {
+			return "", err
+		}
+		return "Map(String, " + valueColType + ")", nil
+	default:
+		return "", fmt.Errorf("%s is not a nested kind", kind)
+	}
+}
+
+// RangeElementKind returns the kind of the bounds when a struct column holds a range built by types.NewQValueRange
+func RangeElementKind(column *protos.FieldDescription) (types.QValueKind, bool) {
+	if types.QValueKind(column.Type) != types.QValueKindStruct {
+		return "", false
+	}
+	return rangeFieldsElementKind(column.Fields)
+}
+
+// MultirangeElementKind returns the kind of the bounds when an array of structs column holds the ranges of a multirange
+func MultirangeElementKind(column *protos.FieldDescription) (types.QValueKind, bool) {
+	if types.QValueKind(column.Type) != types.QValueKindArrayStruct {
+		return "", false
+	}
+	return rangeFieldsElementKind(column.Fields)
+}
+
+func rangeFieldsElementKind(fields []*protos.FieldDescription) (types.QValueKind, bool) {
+	if len(fields) != 5 {
+		return "", false
+	}
+	lower, upper := fields[0], fields[1]
+	if lower.Name != types.RangeLowerMember || upper.Name != types.RangeUpperMember || lower.Type != upper.Type {
+		return "", false
+	}
+	for i, name := range []string{types.RangeLowerIncMember, types.RangeUpperIncMember, types.RangeEmptyMember} {
+		if flag := fields[i+2]; flag.Name != name || types.QValueKind(flag.Type) != types.QValueKindBoolean {
+			return "", false
+		}
+	}
+	return types.QValueKind(lower.Type), true
+}
+
 func ShouldUseNativeJSONType(ctx context.Context, env map[string]string, chVersion *chproto.Version) bool {
 	if chVersion == nil {
 		return false
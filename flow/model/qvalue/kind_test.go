@@ -0,0 +1,90 @@
+package qvalue
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestClickHouseNestedColumnType(t *testing.T) {
+	ctx := context.Background()
+
+	column := &protos.FieldDescription{
+		Name:     "address",
+		Type:     string(types.QValueKindStruct),
+		Nullable: true,
+		Fields: []*protos.FieldDescription{
+			{Name: "street", Type: string(types.QValueKindString), Nullable: true},
+			{Name: "tags", Type: string(types.QValueKindArrayString), Nullable: true},
+			{Name: "geo", Type: string(types.QValueKindStruct), Nullable: true, Fields: []*protos.FieldDescription{
+				{Name: "lat", Type: string(types.QValueKindFloat64), Nullable: true},
+			}},
+			{Name: "counts", Type: string(types.QValueKindMap), Nullable: true, Fields: []*protos.FieldDescription{
+				{Name: "key", Type: string(types.QValueKindString)},
+				{Name: "value", Type: string(types.QValueKindInt64), Nullable: true},
+			}},
+		},
+	}
+
+	colType, err := ToDWHColumnType(ctx, types.QValueKindStruct, nil, protos.DBType_CLICKHOUSE, nil, column, true, nil)
+	require.NoError(t, err)
+	require.Equal(t, "Tuple(`street` Nullable(String), `tags` Array(String), "+
+		"`geo` Tuple(`lat` Nullable(Float64)), `counts` Map(String, Nullable(Int64)))", colType)
+
+	_, err = ToDWHColumnType(ctx, types.QValueKindStruct, nil, protos.DBType_CLICKHOUSE, nil,
+		&protos.FieldDescription{Name: "empty", Type: string(types.QValueKindStruct)}, true, nil)
+	require.Error(t, err)
+
+	// arrays of structs are arrays of tuples, which are never Nullable
+	colType, err = ToDWHColumnType(ctx, types.QValueKindArrayStruct, nil, protos.DBType_CLICKHOUSE, nil,
+		&protos.FieldDescription{
+			Name:     "slots",
+			Type:     string(types.QValueKindArrayStruct),
+			Nullable: true,
+			Fields: []*protos.FieldDescription{
+				{Name: types.RangeLowerMember, Type: string(types.QValueKindInt32), Nullable: true},
+				{Name: types.RangeLowerIncMember, Type: string(types.QValueKindBoolean)},
+			},
+		}, true, nil)
+	require.NoError(t, err)
+	require.Equal(t, "Array(Tuple(`lower` Nullable(Int32), `lower_inc` Bool))", colType)
+}
+
+func TestRangeElementKind(t *testing.T) {
+	rangeColumn := func(lowerType types.QValueKind, incType types.QValueKind) *protos.FieldDescription {
+		return &protos.FieldDescription{
+			Name: "during",
+			Type: string(types.QValueKindStruct),
+			Fields: []*protos.FieldDescription{
+				{Name: types.RangeLowerMember, Type: string(lowerType), Nullable: true},
+				{Name: types.RangeUpperMember, Type: string(types.QValueKindInt64), Nullable: true},
+				{Name: types.RangeLowerIncMember, Type: string(incType)},
+				{Name: types.RangeUpperIncMember, Type: string(types.QValueKindBoolean)},
+				{Name: types.RangeEmptyMember, Type: string(types.QValueKindBoolean)},
+			},
+		}
+	}
+
+	kind, ok := RangeElementKind(rangeColumn(types.QValueKindInt64, types.QValueKindBoolean))
+	require.True(t, ok)
+	require.Equal(t, types.QValueKindInt64, kind)
+
+	_, ok = RangeElementKind(rangeColumn(types.QValueKindInt32, types.QValueKindBoolean))
+	require.False(t, ok)
+	_, ok = RangeElementKind(rangeColumn(types.QValueKindInt64, types.QValueKindString))
+	require.False(t, ok)
+
+	multirangeColumn := rangeColumn(types.QValueKindInt64, types.QValueKindBoolean)
+	_, ok = MultirangeElementKind(multirangeColumn)
+	require.False(t, ok)
+	multirangeColumn.Type = string(types.QValueKindArrayStruct)
+	kind, ok = MultirangeElementKind(multirangeColumn)
+	require.True(t, ok)
+	require.Equal(t, types.QValueKindInt64, kind)
+	_, ok = RangeElementKind(multirangeColumn)
+	require.False(t, ok)
+}
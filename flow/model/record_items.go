@@ -145,6 +145,45 @@ func (r RecordItems) toMap(opts ToJSONOptions) (map[string]any, error) {
 				}
 			}
 
+		case types.QValueStruct:
+			members := RecordItems{
+				ColToVal:               make(map[string]types.QValue, len(v.Val)),
+				TruncateThresholdBytes: r.TruncateThresholdBytes,
+			}
+			for _, member := range v.Val {
+				members.ColToVal[member.Name] = member.Val
+			}
+			memberMap, err := members.toMap(NewToJSONOptions(nil, opts.HStoreAsJSON))
+			if err != nil {
+				return nil, fmt.Errorf("unable to convert struct column %s to json: %w", col, err)
+			}
+			jsonStruct[col] = memberMap
+		case types.QValueArrayStruct:
+			elements := make([]map[string]any, 0, len(v.Val))
+			for _, element := range v.Val {
+				members := RecordItems{
+					ColToVal:               make(map[string]types.QValue, len(element.Val)),
+					TruncateThresholdBytes: r.TruncateThresholdBytes,
+				}
+				for _, member := range element.Val {
+					members.ColToVal[member.Name] = member.Val
+				}
+				memberMap, err := members.toMap(NewToJSONOptions(nil, opts.HStoreAsJSON))
+				if err != nil {
+					return nil, fmt.Errorf("unable to convert struct array column %s to json: %w", col, err)
+				}
+				elements = append(elements, memberMap)
+			}
+			jsonStruct[col] = elements
+		case types.QValueMap:
+			entryMap, err := RecordItems{
+				ColToVal:               v.Val,
+				TruncateThresholdBytes: r.TruncateThresholdBytes,
+			}.toMap(NewToJSONOptions(nil, opts.HStoreAsJSON))
+			if err != nil {
+				return nil, fmt.Errorf("unable to convert map column %s to json: %w", col, err)
+			}
+			jsonStruct[col] = entryMap
 		case types.QValueTimestamp:
 			jsonStruct[col] = v.Val.Format("2006-01-02 15:04:05.999999")
 		case types.QValueTimestampTZ:
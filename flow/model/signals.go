@@ -138,6 +138,11 @@ var StartMaintenanceSignal = TypedSignal[*protos.StartMaintenanceSignal]{
 	Name: "start-maintenance-signal",
 }
 
+// WorkerPoolSignal moves a mirror to another worker pool when it next continues as new
+var WorkerPoolSignal = TypedSignal[string]{
+	Name: "worker-pool-signal",
+}
+
 func SleepFuture(ctx workflow.Context, d time.Duration) workflow.Future {
 	f, set := workflow.NewFuture(ctx)
 	workflow.Go(ctx, func(ctx workflow.Context) {
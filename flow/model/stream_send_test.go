@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 )
 
 func TestQRecordStreamSendReturnsCanceledWhenBufferFull(t *testing.T) {
@@ -32,3 +34,32 @@ func TestQObjectStreamSendReturnsCanceledWhenBufferFull(t *testing.T) {
 	err := stream.Send(ctx, &Object{URL: "v", Size: 2})
 	require.ErrorIs(t, err, context.Canceled)
 }
+
+func TestQRecordStreamTakeResumePoint(t *testing.T) {
+	t.Parallel()
+
+	rangeFrom := func(start int64) *protos.PartitionRange {
+		return &protos.PartitionRange{Range: &protos.PartitionRange_IntRange{
+			IntRange: &protos.IntPartitionRange{Start: start, End: 100},
+		}}
+	}
+
+	stream := NewQRecordStream(10)
+	stream.EnableResumePoints(2)
+	require.Equal(t, int64(2), stream.ResumePointInterval())
+
+	for range 2 {
+		require.NoError(t, stream.Send(t.Context(), nil))
+	}
+	stream.SetResumePoint(rangeFrom(3))
+	for range 2 {
+		require.NoError(t, stream.Send(t.Context(), nil))
+	}
+	stream.SetResumePoint(rangeFrom(5))
+
+	require.Nil(t, stream.TakeResumePoint(1))
+	// only points covered by the records received so far are taken
+	require.Equal(t, int64(3), stream.TakeResumePoint(3).GetIntRange().Start)
+	require.Equal(t, int64(5), stream.TakeResumePoint(4).GetIntRange().Start)
+	require.Nil(t, stream.TakeResumePoint(4))
+}
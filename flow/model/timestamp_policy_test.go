@@ -0,0 +1,155 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// golden values of timestamps from each source as written by CDC raw records and by initial load to each destination,
+// avro destinations other than Snowflake store instants so they are compared in UTC, like Mongo which stores UTC dates
+func TestTimestampPolicyGolden(t *testing.T) {
+	t.Parallel()
+	ist := time.FixedZone("IST", 5*3600+1800)
+	sources := map[string]types.QValue{
+		"postgres timestamp":   types.QValueTimestamp{Val: time.Date(2024, 3, 10, 1, 30, 0, 0, time.UTC)},
+		"mysql datetime":       types.QValueTimestamp{Val: time.Date(2024, 7, 1, 12, 0, 0, 5e8, time.UTC)},
+		"postgres timestamptz": types.QValueTimestampTZ{Val: time.Date(2024, 3, 10, 7, 0, 0, 0, ist)},
+		"mysql timestamp":      types.QValueTimestampTZ{Val: time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)},
+	}
+	policyEnv := func(naiveTimeZone string, convertToUTC string) map[string]string {
+		return map[string]string{
+			"PEERDB_NAIVE_TIMESTAMP_TIME_ZONE":              naiveTimeZone,
+			"PEERDB_TIMESTAMPTZ_CONVERT_TO_UTC":             convertToUTC,
+			"PEERDB_CLICKHOUSE_UNBOUNDED_NUMERIC_AS_STRING": "false",
+		}
+	}
+	policies := map[string]map[string]string{
+		"default":        policyEnv("", "false"),
+		"new york":       policyEnv("America/New_York", "false"),
+		"convert to utc": policyEnv("", "true"),
+	}
+	avroDestinations := []protos.DBType{protos.DBType_CLICKHOUSE, protos.DBType_BIGQUERY, protos.DBType_SNOWFLAKE}
+
+	for _, tc := range []struct {
+		policy        string
+		source        string
+		cdc           string
+		instant       string
+		snowflake     string
+		postgres      string
+		elasticsearch string
+	}{
+		{"default", "postgres timestamp", "2024-03-10 01:30:00", "2024-03-10T01:30:00Z", "2024-03-10 01:30:00",
+			"2024-03-10 01:30:00", `"2024-03-10T01:30:00Z"`},
+		{"default", "mysql datetime", "2024-07-01 12:00:00.5", "2024-07-01T12:00:00.5Z", "2024-07-01 12:00:00.5",
+			"2024-07-01 12:00:00.5", `"2024-07-01T12:00:00.5Z"`},
+		{"default", "postgres timestamptz", "2024-03-10 07:00:00+0530", "2024-03-10T01:30:00Z", "2024-03-10 07:00:00+0530",
+			"2024-03-10 01:30:00Z", `"2024-03-10T07:00:00+05:30"`},
+		{"default", "mysql timestamp", "2024-07-01 12:00:00+0000", "2024-07-01T12:00:00Z", "2024-07-01 12:00:00+0000",
+			"2024-07-01 12:00:00Z", `"2024-07-01T12:00:00Z"`},
+		{"new york", "postgres timestamp", "2024-03-10 06:30:00", "2024-03-10T06:30:00Z", "2024-03-10 06:30:00",
+			"2024-03-10 06:30:00", `"2024-03-10T06:30:00Z"`},
+		{"new york", "mysql datetime", "2024-07-01 16:00:00.5", "2024-07-01T16:00:00.5Z", "2024-07-01 16:00:00.5",
+			"2024-07-01 16:00:00.5", `"2024-07-01T16:00:00.5Z"`},
+		{"new york", "postgres timestamptz", "2024-03-10 07:00:00+0530", "2024-03-10T01:30:00Z", "2024-03-10 07:00:00+0530",
+			"2024-03-10 01:30:00Z", `"2024-03-10T07:00:00+05:30"`},
+		{"new york", "mysql timestamp", "2024-07-01 12:00:00+0000", "2024-07-01T12:00:00Z", "2024-07-01 12:00:00+0000",
+			"2024-07-01 12:00:00Z", `"2024-07-01T12:00:00Z"`},
+		{"convert to utc", "postgres timestamp", "2024-03-10 01:30:00", "2024-03-10T01:30:00Z", "2024-03-10 01:30:00",
+			"2024-03-10 01:30:00", `"2024-03-10T01:30:00Z"`},
+		{"convert to utc", "mysql datetime", "2024-07-01 12:00:00.5", "2024-07-01T12:00:00.5Z", "2024-07-01 12:00:00.5",
+			"2024-07-01 12:00:00.5", `"2024-07-01T12:00:00.5Z"`},
+		{"convert to utc", "postgres timestamptz", "2024-03-10 01:30:00+0000", "2024-03-10T01:30:00Z", "2024-03-10 01:30:00+0000",
+			"2024-03-10 01:30:00Z", `"2024-03-10T01:30:00Z"`},
+		{"convert to utc", "mysql timestamp", "2024-07-01 12:00:00+0000", "2024-07-01T12:00:00Z", "2024-07-01 12:00:00+0000",
+			"2024-07-01 12:00:00Z", `"2024-07-01T12:00:00Z"`},
+	} {
+		env := policies[tc.policy]
+		value := sources[tc.source]
+
+		policy, err := NewTimestampPolicy(t.Context(), env)
+		require.NoError(t, err)
+		stream := NewCDCStream[RecordItems](1)
+		stream.SetTimestampPolicy(policy)
+		items := NewRecordItems(1)
+		items.AddColumn("ts", value)
+		require.NoError(t, stream.AddRecord(t.Context(), &InsertRecord[RecordItems]{Items: items}))
+		record := <-stream.GetRecords()
+		raw, err := record.GetItems().toMap(NewToJSONOptions(nil, false))
+		require.NoError(t, err)
+		require.Equal(t, tc.cdc, raw["ts"], "%s %s cdc", tc.policy, tc.source)
+
+		// initial load applies the policy as records are sent through the stream every destination reads
+		schema := types.NewQRecordSchema([]types.QField{{Name: "ts", Type: value.Kind()}})
+		sendQRep := func() *QRecordStream {
+			qrepStream := NewQRecordStream(1)
+			qrepStream.SetTimestampPolicy(policy)
+			qrepStream.SetSchema(schema)
+			require.NoError(t, qrepStream.Send(t.Context(), []types.QValue{value}))
+			qrepStream.Close(nil)
+			return qrepStream
+		}
+		qrepRecord := func() []types.QValue {
+			return <-sendQRep().Records
+		}
+		for _, dwh := range avroDestinations {
+			avroSchema, err := GetAvroSchemaDefinition(t.Context(), env, "t", schema, dwh, nil)
+			require.NoError(t, err)
+			converter, err := NewQRecordAvroConverter(t.Context(), env, avroSchema, dwh, []string{"ts"}, nil)
+			require.NoError(t, err)
+			avroMap, _, err := converter.Convert(t.Context(), env, qrepRecord(), nil, nil, internal.BinaryFormatRaw, false)
+			require.NoError(t, err)
+			if dwh == protos.DBType_SNOWFLAKE {
+				require.Equal(t, tc.snowflake, avroMap["ts"], "%s %s %s", tc.policy, tc.source, dwh)
+			} else {
+				require.Equal(t, tc.instant, avroMap["ts"].(time.Time).UTC().Format(time.RFC3339Nano),
+					"%s %s %s", tc.policy, tc.source, dwh)
+			}
+		}
+
+		copyFromSource := NewQRecordCopyFromSource(sendQRep())
+		require.True(t, copyFromSource.Next())
+		values, err := copyFromSource.Values()
+		require.NoError(t, err)
+		switch pgValue := values[0].(type) {
+		case pgtype.Timestamp:
+			require.Equal(t, tc.postgres, pgValue.Time.Format("2006-01-02 15:04:05.999999"), "%s %s postgres", tc.policy, tc.source)
+		case pgtype.Timestamptz:
+			require.Equal(t, tc.postgres, pgValue.Time.UTC().Format("2006-01-02 15:04:05.999999Z07:00"),
+				"%s %s postgres", tc.policy, tc.source)
+		default:
+			require.Failf(t, "unexpected postgres value", "%T", pgValue)
+		}
+
+		// Elasticsearch indexes the JSON of values, Mongo stores their instant
+		qrepValue := qrepRecord()[0].Value()
+		document, err := json.Marshal(qrepValue)
+		require.NoError(t, err)
+		require.JSONEq(t, tc.elasticsearch, string(document), "%s %s elasticsearch", tc.policy, tc.source)
+		require.Equal(t, tc.instant, qrepValue.(time.Time).UTC().Format(time.RFC3339Nano), "%s %s mongo", tc.policy, tc.source)
+	}
+}
+
+func TestNewTimestampPolicy(t *testing.T) {
+	t.Parallel()
+	policy, err := types.NewTimestampPolicy("UTC", false)
+	require.NoError(t, err)
+	require.True(t, policy.IsNoop())
+
+	_, err = types.NewTimestampPolicy("Mars/Olympus_Mons", false)
+	require.Error(t, err)
+
+	policy, err = types.NewTimestampPolicy("Asia/Kolkata", true)
+	require.NoError(t, err)
+	require.Equal(t, types.QValueArrayTimestamp{Val: []time.Time{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		policy.Apply(types.QValueArrayTimestamp{Val: []time.Time{time.Date(2024, 1, 1, 5, 30, 0, 0, time.UTC)}}))
+	require.Equal(t, types.QValueInt64{Val: 1}, policy.Apply(types.QValueInt64{Val: 1}))
+}
@@ -210,6 +210,16 @@ func (a *ContextAwareInt64Counter) Add(ctx context.Context, value int64, options
 	a.Int64Counter.Add(ctx, value, newOptions...)
 }
 
+type ContextAwareFloat64Histogram struct {
+	metric.Float64Histogram
+	attrsCache contextAttributesCache
+}
+
+func (a *ContextAwareFloat64Histogram) Record(ctx context.Context, value float64, options ...metric.RecordOption) {
+	newOptions := append([]metric.RecordOption{a.attrsCache.forContext(ctx)}, options...)
+	a.Float64Histogram.Record(ctx, value, newOptions...)
+}
+
 func Int64Gauge(meter metric.Meter, name string, opts ...metric.Int64GaugeOption) (metric.Int64Gauge, error) {
 	gaugeConfig := metric.NewInt64GaugeConfig(opts...)
 	return NewInt64SyncGauge(meter, name,
@@ -254,3 +264,22 @@ func NewContextAwareInt64Counter(meter metric.Meter, name string, opts ...metric
 
 	return &ContextAwareInt64Counter{Int64Counter: counter}, nil
 }
+
+func NewContextAwareFloat64Histogram(
+	meter metric.Meter, name string, opts ...metric.Float64HistogramOption,
+) (metric.Float64Histogram, error) {
+	histogramConfig := metric.NewFloat64HistogramConfig(opts...)
+	histogramOpts := []metric.Float64HistogramOption{
+		metric.WithDescription(histogramConfig.Description()),
+		metric.WithUnit(histogramConfig.Unit()),
+	}
+	if boundaries := histogramConfig.ExplicitBucketBoundaries(); len(boundaries) > 0 {
+		histogramOpts = append(histogramOpts, metric.WithExplicitBucketBoundaries(boundaries...))
+	}
+	histogram, err := meter.Float64Histogram(name, histogramOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ContextAwareFloat64Histogram{Float64Histogram: histogram}, nil
+}
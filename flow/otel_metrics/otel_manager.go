@@ -92,6 +92,8 @@ const (
 	ParseSQLErrorsCounterName            = "parse_sql_errors"
 	OnlineSchemaMigrationsName           = "online_schema_migrations"
 	UnsupportedBinlogEventName           = "unsupported_binlog_event"
+	CDCStoreSpillBytesCounterName        = "cdc_store_spill_bytes"
+	CDCStoreSpillLatencyHistogramName    = "cdc_store_spill_latency"
 )
 
 type Metrics struct {
@@ -156,6 +158,8 @@ type Metrics struct {
 	UnsupportedBinlogEventCounter     metric.Int64Counter
 	CockroachDBResolvedLagGauge       metric.Float64Gauge
 	CockroachDBRecordsReceivedCounter metric.Int64Counter
+	CDCStoreSpillBytesCounter         metric.Int64Counter
+	CDCStoreSpillLatencyHistogram     metric.Float64Histogram
 }
 
 type SlotMetricGauges struct {
@@ -189,14 +193,15 @@ func BuildMetricName(baseName string) string {
 }
 
 type OtelManager struct {
-	Metrics            Metrics
-	MetricsProvider    metric.MeterProvider
-	Meter              metric.Meter
-	Tracer             trace.Tracer
-	Float64GaugesCache map[string]metric.Float64Gauge
-	Int64GaugesCache   map[string]metric.Int64Gauge
-	Int64CountersCache map[string]metric.Int64Counter
-	Enabled            bool
+	Metrics                Metrics
+	MetricsProvider        metric.MeterProvider
+	Meter                  metric.Meter
+	Tracer                 trace.Tracer
+	Float64GaugesCache     map[string]metric.Float64Gauge
+	Int64GaugesCache       map[string]metric.Int64Gauge
+	Int64CountersCache     map[string]metric.Int64Counter
+	Float64HistogramsCache map[string]metric.Float64Histogram
+	Enabled                bool
 }
 
 func NewOtelManager(ctx context.Context, serviceName string, enabled bool) (*OtelManager, error) {
@@ -206,13 +211,14 @@ func NewOtelManager(ctx context.Context, serviceName string, enabled bool) (*Ote
 	}
 
 	otelManager := OtelManager{
-		Enabled:            enabled,
-		MetricsProvider:    metricsProvider,
-		Meter:              metricsProvider.Meter("io.peerdb." + serviceName),
-		Tracer:             Tracer(),
-		Float64GaugesCache: make(map[string]metric.Float64Gauge),
-		Int64GaugesCache:   make(map[string]metric.Int64Gauge),
-		Int64CountersCache: make(map[string]metric.Int64Counter),
+		Enabled:                enabled,
+		MetricsProvider:        metricsProvider,
+		Meter:                  metricsProvider.Meter("io.peerdb." + serviceName),
+		Tracer:                 Tracer(),
+		Float64GaugesCache:     make(map[string]metric.Float64Gauge),
+		Int64GaugesCache:       make(map[string]metric.Int64Gauge),
+		Int64CountersCache:     make(map[string]metric.Int64Counter),
+		Float64HistogramsCache: make(map[string]metric.Float64Histogram),
 	}
 	if err := otelManager.setupMetrics(ctx); err != nil {
 		return nil, err
@@ -261,6 +267,10 @@ func (om *OtelManager) GetOrInitInt64Counter(name string, opts ...metric.Int64Co
 	return getOrInitMetric(NewContextAwareInt64Counter, om.Meter, om.Int64CountersCache, name, opts...)
 }
 
+func (om *OtelManager) GetOrInitFloat64Histogram(name string, opts ...metric.Float64HistogramOption) (metric.Float64Histogram, error) {
+	return getOrInitMetric(NewContextAwareFloat64Histogram, om.Meter, om.Float64HistogramsCache, name, opts...)
+}
+
 // CodeNotificationCounter is a global counter for emitting notifications for one-off things we want to know about with the least effort.
 // In ClickPipes, there is a generic (non-paging) alert set up on this, so just emit it in the code with a unique message
 // and it'll show up on Slack.
@@ -684,6 +694,21 @@ func (om *OtelManager) setupMetrics(ctx context.Context) error {
 		return err
 	}
 
+	if om.Metrics.CDCStoreSpillBytesCounter, err = om.GetOrInitInt64Counter(BuildMetricName(CDCStoreSpillBytesCounterName),
+		metric.WithUnit("By"),
+		metric.WithDescription("Bytes of encrypted CDC records spilled to disk by the CDC store"),
+	); err != nil {
+		return err
+	}
+
+	if om.Metrics.CDCStoreSpillLatencyHistogram, err = om.GetOrInitFloat64Histogram(BuildMetricName(CDCStoreSpillLatencyHistogramName),
+		metric.WithUnit("s"),
+		metric.WithDescription("Time taken to encode, encrypt and write or read a CDC record spilled to disk, with `operation` label"),
+		metric.WithExplicitBucketBoundaries(0.00001, 0.00005, 0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1),
+	); err != nil {
+		return err
+	}
+
 	if CodeNotificationCounter, err = om.GetOrInitInt64Counter(BuildMetricName(CodeNotificationCounterName),
 		metric.WithDescription("One-off notifications with unique `message` attribute, triggers generic non-paging alert"),
 	); err != nil {
@@ -1,5 +1,7 @@
 package clickhouse
 
+import "strings"
+
 const (
 	EngineReplacingMergeTree           = "ReplacingMergeTree"
 	EngineMergeTree                    = "MergeTree"
@@ -8,3 +10,10 @@ const (
 	EngineReplicatedMergeTree          = "ReplicatedMergeTree"
 	EngineCoalescingMergeTree          = "CoalescingMergeTree"
 )
+
+// EngineDeduplicates returns true if the table engine collapses rows with the same sorting key on merge,
+// replicated and shared variants included
+func EngineDeduplicates(engine string) bool {
+	engine = strings.TrimPrefix(strings.TrimPrefix(engine, "Shared"), "Replicated")
+	return engine == EngineReplacingMergeTree || engine == EngineCoalescingMergeTree
+}
@@ -138,7 +138,9 @@ func ValidateClickHouseHost(ctx context.Context, chHost string, allowedDomainStr
 }
 
 func validateStagingAccessGrant(ctx context.Context, logger log.Logger, conn clickhouse.Conn, accessMethod string) error {
-	if accessMethod != "S3" && accessMethod != "URL" {
+	switch accessMethod {
+	case "S3", "URL", "AZURE", "FILE":
+	default:
 		return fmt.Errorf("unsupported ClickHouse staging access method %q", accessMethod)
 	}
 
@@ -254,9 +256,12 @@ func ValidateClickHousePeer(
 		return fmt.Errorf("failed to drop validation table %s: %w", validateDummyTableNameRenamed, err)
 	}
 
-	// Validate that ClickHouse has access permissions to the staging access bucket.
-	if err := validateStagingAccessGrant(ctx, logger, conn, stagingAccessMethod); err != nil {
-		return err
+	// Validate that ClickHouse has access permissions to the staging access bucket,
+	// peers inserting directly pass no access method as ClickHouse never reads from staging.
+	if stagingAccessMethod != "" {
+		if err := validateStagingAccessGrant(ctx, logger, conn, stagingAccessMethod); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -158,6 +158,22 @@ func TestCheckIfTablesEmptyAndEngine(t *testing.T) {
 	}
 }
 
+func TestEngineDeduplicates(t *testing.T) {
+	for engine, expected := range map[string]bool{
+		EngineReplacingMergeTree:           true,
+		EngineReplicatedReplacingMergeTree: true,
+		"SharedReplacingMergeTree":         true,
+		EngineCoalescingMergeTree:          true,
+		EngineMergeTree:                    false,
+		EngineReplicatedMergeTree:          false,
+		"SharedMergeTree":                  false,
+		EngineNull:                         false,
+		"Distributed":                      false,
+	} {
+		require.Equal(t, expected, EngineDeduplicates(engine), engine)
+	}
+}
+
 func TestValidateClickHouseHost(t *testing.T) {
 	tests := []struct {
 		name           string
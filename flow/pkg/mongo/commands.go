@@ -24,7 +24,13 @@ type StorageEngine struct {
 	Name string `bson:"name"`
 }
 
+type Sharding struct {
+	ConfigsvrConnectionString string `bson:"configsvrConnectionString"`
+}
+
 type ServerStatus struct {
+	// only reported by mongos and by members of a sharded cluster's replica sets
+	Sharding        *Sharding       `bson:"sharding,omitempty"`
 	StorageEngine   StorageEngine   `bson:"storageEngine"`
 	Host            string          `bson:"host"`
 	OplogTruncation OplogTruncation `bson:"oplogTruncation"`
@@ -65,6 +71,8 @@ type CollStats struct {
 	Size int64 `bson:"size"`
 	// compressed
 	StorageSize int64 `bson:"storageSize"`
+	// only true when run through mongos
+	Sharded bool `bson:"sharded"`
 }
 
 func GetCollStats(ctx context.Context, client *mongo.Client, database string, collection string) (CollStats, error) {
@@ -65,3 +65,25 @@ func GetCollectionNames(ctx context.Context, client *mongo.Client, databaseName
 	slices.Sort(filteredCollNames)
 	return filteredCollNames, nil
 }
+
+// GetPreImageCollectionNames lists the collections of a database that record pre-images for
+// change streams, enabled per collection with changeStreamPreAndPostImages since MongoDB 6.0
+func GetPreImageCollectionNames(ctx context.Context, client *mongo.Client, databaseName string) ([]string, error) {
+	cur, err := client.Database(databaseName).ListCollections(ctx,
+		bson.D{{Key: "options.changeStreamPreAndPostImages.enabled", Value: true}})
+	if err != nil {
+		return nil, err
+	}
+	var collections []struct {
+		Name string `bson:"name"`
+	}
+	if err := cur.All(ctx, &collections); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(collections))
+	for _, coll := range collections {
+		names = append(names, coll.Name)
+	}
+	slices.Sort(names)
+	return names, nil
+}
@@ -0,0 +1,78 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ShardedCollection is the config.collections entry of a sharded collection
+type ShardedCollection struct {
+	// chunks reference their collection by uuid since 5.0 and by namespace before
+	UUID bson.RawValue `bson:"uuid"`
+	// the namespace, database.collection
+	ID     string   `bson:"_id"`
+	Key    bson.Raw `bson:"key"`
+	Unique bool     `bson:"unique"`
+}
+
+// HasIDPrefix reports whether _id leads the shard key, only then does the
+// cluster enforce _id uniqueness across shards
+func (sc *ShardedCollection) HasIDPrefix() bool {
+	elements, err := sc.Key.Elements()
+	if err != nil || len(elements) == 0 {
+		return false
+	}
+	return elements[0].Key() == "_id"
+}
+
+// GetShardedCollection looks a collection up in the config database of a sharded
+// cluster, returns nil when the collection is not sharded
+func GetShardedCollection(
+	ctx context.Context, client *mongo.Client, database string, collection string,
+) (*ShardedCollection, error) {
+	var sc ShardedCollection
+	if err := client.Database("config").Collection("collections").FindOne(ctx, bson.D{
+		{Key: "_id", Value: database + "." + collection},
+		// collections dropped before 5.0 keep their entry with dropped: true
+		{Key: "dropped", Value: bson.D{{Key: "$ne", Value: true}}},
+	}).Decode(&sc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read config.collections for %s.%s: %w", database, collection, err)
+	}
+	return &sc, nil
+}
+
+// Chunk is a contiguous range of shard key values owned by one shard, [Min, Max)
+type Chunk struct {
+	Shard string   `bson:"shard"`
+	Min   bson.Raw `bson:"min"`
+	Max   bson.Raw `bson:"max"`
+}
+
+// GetChunks lists the chunks of a sharded collection in shard key order
+func GetChunks(ctx context.Context, client *mongo.Client, sc *ShardedCollection) ([]Chunk, error) {
+	owner := bson.A{bson.D{{Key: "ns", Value: sc.ID}}}
+	if !sc.UUID.IsZero() {
+		owner = append(owner, bson.D{{Key: "uuid", Value: sc.UUID}})
+	}
+	cursor, err := client.Database("config").Collection("chunks").Find(ctx,
+		bson.D{{Key: "$or", Value: owner}},
+		options.Find().
+			SetSort(bson.D{{Key: "min", Value: 1}}).
+			SetProjection(bson.D{{Key: "min", Value: 1}, {Key: "max", Value: 1}, {Key: "shard", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config.chunks for %s: %w", sc.ID, err)
+	}
+	var chunks []Chunk
+	if err := cursor.All(ctx, &chunks); err != nil {
+		return nil, fmt.Errorf("failed to decode config.chunks for %s: %w", sc.ID, err)
+	}
+	return chunks, nil
+}
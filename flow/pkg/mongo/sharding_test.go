@@ -0,0 +1,27 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestShardedCollectionKey(t *testing.T) {
+	t.Parallel()
+
+	shardedOn := func(key bson.D) *ShardedCollection {
+		raw, err := bson.Marshal(key)
+		require.NoError(t, err)
+		return &ShardedCollection{ID: "db.coll", Key: raw}
+	}
+
+	ranged := shardedOn(bson.D{{Key: "_id", Value: 1}})
+	require.True(t, ranged.HasIDPrefix())
+
+	hashed := shardedOn(bson.D{{Key: "_id", Value: "hashed"}})
+	require.True(t, hashed.HasIDPrefix())
+
+	compound := shardedOn(bson.D{{Key: "tenant", Value: 1}, {Key: "_id", Value: 1}})
+	require.False(t, compound.HasIDPrefix())
+}
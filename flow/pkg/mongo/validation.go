@@ -14,6 +14,8 @@ import (
 const (
 	MinSupportedVersion    = "4.4.0"
 	MinOplogRetentionHours = 24
+	// change streams accept fullDocumentBeforeChange from this version on
+	MinPreImagesVersion = "6.0.0"
 
 	ReplicaSet     = "ReplicaSet"
 	ShardedCluster = "ShardedCluster"
@@ -109,6 +111,26 @@ func ValidateOplogRetention(ctx context.Context, client *mongo.Client) error {
 	}
 }
 
+// ValidateShardedTopology rejects connections made straight to a replica set of a sharded
+// cluster, a change stream opened there misses every write that lands on the other shards
+func ValidateShardedTopology(ctx context.Context, client *mongo.Client) error {
+	topology, err := GetTopologyType(ctx, client)
+	if err != nil {
+		return err
+	}
+	if topology != ReplicaSet {
+		return nil
+	}
+	ss, err := GetServerStatus(ctx, client)
+	if err != nil {
+		return err
+	}
+	if ss.Sharding != nil {
+		return fmt.Errorf("server %s is a member of a sharded cluster, connect through mongos instead", ss.Host)
+	}
+	return nil
+}
+
 func ValidateCollections(ctx context.Context, client *mongo.Client, tables []*common.QualifiedTable) error {
 	databaseCollectionsMapping := make(map[string][]string)
 
@@ -10,16 +10,26 @@ import (
 
 // CustomDataType holds metadata for a PostgreSQL custom type (enum, composite, domain, array).
 type CustomDataType struct {
-	Name  string
-	Type  byte
-	Delim byte // non-zero for array types
+	Name   string
+	Fields []CustomDataTypeField // members of composite types, in attribute order
+	// BaseOID is the underlying type of a domain, BaseTypmod its type modifier like the (12,2) of numeric(12,2)
+	BaseOID    uint32
+	BaseTypmod int32
+	Type       byte
+	Delim      byte // non-zero for array types
+}
+
+// CustomDataTypeField is a member of a composite type.
+type CustomDataTypeField struct {
+	Name string
+	OID  uint32
 }
 
 // GetCustomDataTypes fetches all types from the PostgreSQL catalog.
 // pg_catalog stays included: pgtype's map does not know every built-in (regclass, pg_lsn, oidvector, ...) and OIDToName falls back here.
 func GetCustomDataTypes(ctx context.Context, conn *pgx.Conn) (map[uint32]CustomDataType, error) {
 	rows, err := conn.Query(ctx, `
-		SELECT t.oid, t.typname, coalesce(at.typtype, t.typtype), coalesce(at.typdelim, 0::"char")
+		SELECT t.oid, t.typname, coalesce(at.typtype, t.typtype), coalesce(at.typdelim, 0::"char"), t.typbasetype, t.typtypmod
 		FROM pg_catalog.pg_type t
 		LEFT JOIN pg_catalog.pg_class c ON c.oid = t.typrelid
 		LEFT JOIN pg_catalog.pg_type at ON at.typarray = t.oid
@@ -32,12 +42,37 @@ func GetCustomDataTypes(ctx context.Context, conn *pgx.Conn) (map[uint32]CustomD
 	customTypeMap := map[uint32]CustomDataType{}
 	var typeID pgtype.Uint32
 	var cdt CustomDataType
-	if _, err := pgx.ForEachRow(rows, []any{&typeID, &cdt.Name, &cdt.Type, &cdt.Delim}, func() error {
+	var baseOID pgtype.Uint32
+	if _, err := pgx.ForEachRow(rows, []any{&typeID, &cdt.Name, &cdt.Type, &cdt.Delim, &baseOID, &cdt.BaseTypmod}, func() error {
+		cdt.BaseOID = baseOID.Uint32
 		customTypeMap[typeID.Uint32] = cdt
 		return nil
 	}); err != nil {
 		return nil, fmt.Errorf("failed to scan into custom type mapping: %w", err)
 	}
+
+	fieldRows, err := conn.Query(ctx, `
+		SELECT t.oid, a.attname, a.atttypid
+		FROM pg_catalog.pg_type t
+		JOIN pg_catalog.pg_class c ON c.oid = t.typrelid
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = t.typrelid
+		WHERE t.typtype = 'c' AND c.relkind = 'c' AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY t.oid, a.attnum
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get composite type fields: %w", err)
+	}
+	var fieldName string
+	var fieldOID pgtype.Uint32
+	if _, err := pgx.ForEachRow(fieldRows, []any{&typeID, &fieldName, &fieldOID}, func() error {
+		if typeData, ok := customTypeMap[typeID.Uint32]; ok {
+			typeData.Fields = append(typeData.Fields, CustomDataTypeField{Name: fieldName, OID: fieldOID.Uint32})
+			customTypeMap[typeID.Uint32] = typeData
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to scan composite type fields: %w", err)
+	}
 	return customTypeMap, nil
 }
 
@@ -0,0 +1,37 @@
+package concurrency
+
+import "context"
+
+// Semaphore bounds how many holders run concurrently.
+// A nil Semaphore is unbounded, Acquire never blocks and Release is a no-op.
+type Semaphore struct {
+	slots chan struct{}
+}
+
+// NewSemaphore creates a Semaphore admitting limit holders, returning nil (unbounded) when limit is not positive.
+func NewSemaphore(limit int) *Semaphore {
+	if limit <= 0 {
+		return nil
+	}
+	return &Semaphore{slots: make(chan struct{}, limit)}
+}
+
+// Acquire blocks until a slot is free or ctx is done, Release must be called iff Acquire returns nil.
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// Release frees a slot taken by Acquire.
+func (s *Semaphore) Release() {
+	if s != nil {
+		<-s.slots
+	}
+}
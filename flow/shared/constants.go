@@ -41,6 +41,10 @@ const (
 	InternalVersion_MySQLConvertBitToUInt64
 	// MySQL: convert sets to integers for older versions without binlog row metadata support
 	InternalVersion_MySQL5ConvertSetsToInts
+	// Postgres: domains replicated as their base type, composite types as structs instead of string
+	InternalVersion_PgCompositeAndDomainTypes
+	// Postgres: built-in ranges replicated as structs of their bounds, multiranges as JSON arrays of those instead of string
+	InternalVersion_PgRangesAsStructs
 
 	TotalNumberOfInternalVersions
 	InternalVersion_Latest = TotalNumberOfInternalVersions - 1
@@ -64,8 +68,19 @@ const (
 	// Queries
 	CDCFlowStateQuery  = "q-cdc-flow-state"
 	QRepFlowStateQuery = "q-qrep-flow-state"
+
+	DefaultWorkerPool = "default"
 )
 
+// WorkerPoolTaskQueue returns the task queue of mirrors assigned to pool,
+// the default pool keeps running on PeerFlowTaskQueue
+func WorkerPoolTaskQueue(pool string) TaskQueueID {
+	if pool == "" || pool == DefaultWorkerPool {
+		return PeerFlowTaskQueue
+	}
+	return PeerFlowTaskQueue + "-" + TaskQueueID(pool)
+}
+
 var MirrorNameSearchAttribute = temporal.NewSearchAttributeKeyString("MirrorName")
 
 func NewSearchAttributes(mirrorName string) temporal.SearchAttributes {
@@ -10,6 +10,9 @@ const (
 
 	PeerDBClickHouseMaxPrecision = 76
 	VARHDRSZ                     = 4
+
+	// BIGNUMERIC(P, S) allows a scale of up to 38 and a precision of up to S + 38
+	PeerDBBigQueryBigNumericMaxScale = 38
 )
 
 type WarehouseNumericCompatibility interface {
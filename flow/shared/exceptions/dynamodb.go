@@ -0,0 +1,20 @@
+package exceptions
+
+import "fmt"
+
+// DynamoDBStreamHistoryLostError is returned when stream records needed to resume CDC
+// were trimmed, stream records are only retained for 24 hours, or when the stream of
+// a table was disabled and re-enabled, which starts a new stream. Either way changes
+// were lost and the mirror has to be resynced.
+type DynamoDBStreamHistoryLostError struct {
+	Table  string
+	Reason string
+}
+
+func NewDynamoDBStreamHistoryLostError(table string, reason string) *DynamoDBStreamHistoryLostError {
+	return &DynamoDBStreamHistoryLostError{Table: table, Reason: reason}
+}
+
+func (e *DynamoDBStreamHistoryLostError) Error() string {
+	return fmt.Sprintf("DynamoDB stream history of table %s was lost (%s); a resync is required", e.Table, e.Reason)
+}
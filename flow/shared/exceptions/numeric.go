@@ -4,10 +4,12 @@ type NumericTruncatedError struct {
 	error
 	DestinationTable  string
 	DestinationColumn string
+	// number of values that lost digits beyond the destination scale
+	Count uint64
 }
 
-func NewNumericTruncatedError(err error, destinationTable, destinationColumn string) *NumericTruncatedError {
-	return &NumericTruncatedError{err, destinationTable, destinationColumn}
+func NewNumericTruncatedError(err error, destinationTable, destinationColumn string, count uint64) *NumericTruncatedError {
+	return &NumericTruncatedError{err, destinationTable, destinationColumn, count}
 }
 
 func (e *NumericTruncatedError) Error() string {
@@ -22,10 +24,12 @@ type NumericOutOfRangeError struct {
 	error
 	DestinationTable  string
 	DestinationColumn string
+	// number of values cleared for not fitting the destination column
+	Count uint64
 }
 
-func NewNumericOutOfRangeError(err error, destinationTable, destinationColumn string) *NumericOutOfRangeError {
-	return &NumericOutOfRangeError{err, destinationTable, destinationColumn}
+func NewNumericOutOfRangeError(err error, destinationTable, destinationColumn string, count uint64) *NumericOutOfRangeError {
+	return &NumericOutOfRangeError{err, destinationTable, destinationColumn, count}
 }
 
 func (e *NumericOutOfRangeError) Error() string {
@@ -0,0 +1,20 @@
+package exceptions
+
+import "fmt"
+
+// OracleRedoLogMissingError is returned when the redo needed to resume LogMiner
+// is no longer available, typically because archived logs were deleted by RMAN
+// or a retention policy before the mirror could consume them. No retry can fix
+// this, the mirror has to be resynced.
+type OracleRedoLogMissingError struct {
+	StartScn uint64
+}
+
+func NewOracleRedoLogMissingError(startScn uint64) *OracleRedoLogMissingError {
+	return &OracleRedoLogMissingError{StartScn: startScn}
+}
+
+func (e *OracleRedoLogMissingError) Error() string {
+	return fmt.Sprintf("no online or archived redo log contains SCN %d, the logs were likely purged; a resync is required",
+		e.StartScn)
+}
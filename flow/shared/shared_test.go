@@ -242,3 +242,20 @@ func TestParsePgArray(t *testing.T) {
 		}
 	}
 }
+
+func TestPartitionKeyColumns(t *testing.T) {
+	if columns := SplitPartitionKey(" tenant_id, id ,"); !slices.Equal(columns, []string{"tenant_id", "id"}) {
+		t.Errorf("unexpected columns %v", columns)
+	}
+	if columns := SplitPartitionKey("id"); !slices.Equal(columns, []string{"id"}) {
+		t.Errorf("unexpected columns %v", columns)
+	}
+	if key, ok := JoinPartitionKey([]string{"tenant_id", "id"}); !ok || key != "tenant_id,id" {
+		t.Errorf("unexpected partition key %q", key)
+	}
+	for _, columns := range [][]string{{"a,b", "c"}, {"a", ""}, {" a", "b"}} {
+		if _, ok := JoinPartitionKey(columns); ok {
+			t.Errorf("expected %v to not form a partition key", columns)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net"
 	"regexp"
 	"strconv"
+	"strings"
 	"unsafe"
 
 	"golang.org/x/exp/constraints"
@@ -33,3 +34,26 @@ func IsValidReplicationName(s string) bool {
 func JoinHostPort[I constraints.Integer](host string, port I) string {
 	return net.JoinHostPort(host, strconv.FormatUint(uint64(port), 10))
 }
+
+// SplitPartitionKey splits a partition key into its columns, composite keys
+// list their columns separated by commas, e.g. "tenant_id,id"
+func SplitPartitionKey(partitionKey string) []string {
+	var columns []string
+	for column := range strings.SplitSeq(partitionKey, ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// JoinPartitionKey is the inverse of SplitPartitionKey, it fails for
+// columns that cannot be told apart once joined
+func JoinPartitionKey(columns []string) (string, bool) {
+	for _, column := range columns {
+		if column == "" || strings.Contains(column, ",") || strings.TrimSpace(column) != column {
+			return "", false
+		}
+	}
+	return strings.Join(columns, ","), true
+}
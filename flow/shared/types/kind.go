@@ -42,6 +42,10 @@ const (
 	QValueKindGeometry    QValueKind = "geometry"
 	QValueKindPoint       QValueKind = "point"
 
+	// nested types, member types are carried by QField.Fields and FieldDescription.Fields
+	QValueKindStruct QValueKind = "struct"
+	QValueKindMap    QValueKind = "map"
+
 	// network types
 	QValueKindCIDR    QValueKind = "cidr"
 	QValueKindINET    QValueKind = "inet"
@@ -64,12 +68,46 @@ const (
 	QValueKindArrayJSONB       QValueKind = "array_jsonb"
 	QValueKindArrayUUID        QValueKind = "array_uuid"
 	QValueKindArrayNumeric     QValueKind = "array_numeric"
+	QValueKindArrayStruct      QValueKind = "array_struct"
 )
 
 func (kind QValueKind) IsArray() bool {
 	return strings.HasPrefix(string(kind), "array_")
 }
 
+// IntegerWidth returns the bits and signedness of integer kinds, and false for other kinds
+func (kind QValueKind) IntegerWidth() (int, bool, bool) {
+	switch kind {
+	case QValueKindInt8:
+		return 8, true, true
+	case QValueKindInt16:
+		return 16, true, true
+	case QValueKindInt32:
+		return 32, true, true
+	case QValueKindInt64:
+		return 64, true, true
+	case QValueKindInt256:
+		return 256, true, true
+	case QValueKindUInt8:
+		return 8, false, true
+	case QValueKindUInt16, QValueKindUint16Enum:
+		return 16, false, true
+	case QValueKindUInt32:
+		return 32, false, true
+	case QValueKindUInt64, QValueKindUint64Set:
+		return 64, false, true
+	case QValueKindUInt256:
+		return 256, false, true
+	default:
+		return 0, false, false
+	}
+}
+
+// HasStructFields reports whether columns of kind carry the members of their structs in Fields
+func (kind QValueKind) HasStructFields() bool {
+	return kind == QValueKindStruct || kind == QValueKindArrayStruct
+}
+
 var QValueKindToSnowflakeTypeMap = map[QValueKind]string{
 	QValueKindBoolean:     "BOOLEAN",
 	QValueKindInt8:        "INTEGER",
@@ -102,6 +140,8 @@ var QValueKindToSnowflakeTypeMap = map[QValueKind]string{
 	QValueKindGeography:   "GEOGRAPHY",
 	QValueKindGeometry:    "GEOMETRY",
 	QValueKindPoint:       "GEOMETRY",
+	QValueKindStruct:      "OBJECT",
+	QValueKindMap:         "OBJECT",
 
 	// array types will be mapped to VARIANT
 	QValueKindArrayFloat32:     "VARIANT",
@@ -120,6 +160,7 @@ var QValueKindToSnowflakeTypeMap = map[QValueKind]string{
 	QValueKindArrayJSONB:       "VARIANT",
 	QValueKindArrayUUID:        "VARIANT",
 	QValueKindArrayNumeric:     "VARIANT",
+	QValueKindArrayStruct:      "ARRAY",
 }
 
 var QValueKindToClickHouseTypeMap = map[QValueKind]string{
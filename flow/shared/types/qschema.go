@@ -11,9 +11,11 @@ type QField struct {
 	// OriginalType is the original source type as a string
 	// Useful for custom type mappings, like BigQuery RECORD
 	OriginalType string
-	Precision    int16
-	Scale        int16
-	Nullable     bool
+	// Fields are the members of struct fields, map fields have a key and a value field
+	Fields    []QField
+	Precision int16
+	Scale     int16
+	Nullable  bool
 }
 
 type QRecordSchema struct {
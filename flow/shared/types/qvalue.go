@@ -1,6 +1,7 @@
 package types
 
 import (
+	"fmt"
 	"math/big"
 	"time"
 
@@ -552,6 +553,152 @@ func (v QValueHStore) LValue(ls *lua.LState) lua.LValue {
 	return lua.LString(v.Val)
 }
 
+// QValueStructField is a named member of a struct value
+type QValueStructField struct {
+	Val  QValue
+	Name string
+}
+
+// QValueStruct holds the members of a composite value in declaration order
+type QValueStruct struct {
+	Val []QValueStructField
+}
+
+func (QValueStruct) Kind() QValueKind {
+	return QValueKindStruct
+}
+
+func (v QValueStruct) Value() any {
+	members := make(map[string]any, len(v.Val))
+	for _, member := range v.Val {
+		members[member.Name] = member.Val.Value()
+	}
+	return members
+}
+
+func (v QValueStruct) LValue(ls *lua.LState) lua.LValue {
+	tbl := ls.CreateTable(0, len(v.Val))
+	for _, member := range v.Val {
+		tbl.RawSetString(member.Name, member.Val.LValue(ls))
+	}
+	return tbl
+}
+
+// members of the structs ranges are replicated as, an unbounded side has a null bound
+// and empty ranges have neither bound, which keeps them apart from null and unbounded ranges
+const (
+	RangeLowerMember    = "lower"
+	RangeUpperMember    = "upper"
+	RangeLowerIncMember = "lower_inc"
+	RangeUpperIncMember = "upper_inc"
+	RangeEmptyMember    = "empty"
+)
+
+// RangeBounds are the members of a struct built by NewQValueRange or NewQValueEmptyRange
+type RangeBounds struct {
+	Lower    QValue
+	Upper    QValue
+	LowerInc bool
+	UpperInc bool
+	Empty    bool
+}
+
+// NewQValueRange builds the struct a range is replicated as
+func NewQValueRange(lower QValue, upper QValue, lowerInc bool, upperInc bool) QValueStruct {
+	return newQValueRange(RangeBounds{Lower: lower, Upper: upper, LowerInc: lowerInc, UpperInc: upperInc})
+}
+
+// NewQValueEmptyRange builds the struct an empty range with bounds of elementKind is replicated as
+func NewQValueEmptyRange(elementKind QValueKind) QValueStruct {
+	return newQValueRange(RangeBounds{Lower: QValueNull(elementKind), Upper: QValueNull(elementKind), Empty: true})
+}
+
+func newQValueRange(bounds RangeBounds) QValueStruct {
+	return QValueStruct{Val: []QValueStructField{
+		{Name: RangeLowerMember, Val: bounds.Lower},
+		{Name: RangeUpperMember, Val: bounds.Upper},
+		{Name: RangeLowerIncMember, Val: QValueBoolean{Val: bounds.LowerInc}},
+		{Name: RangeUpperIncMember, Val: QValueBoolean{Val: bounds.UpperInc}},
+		{Name: RangeEmptyMember, Val: QValueBoolean{Val: bounds.Empty}},
+	}}
+}
+
+// RangeBounds returns the bounds of a struct built by NewQValueRange or NewQValueEmptyRange
+func (v QValueStruct) RangeBounds() (RangeBounds, bool) {
+	if len(v.Val) != 5 || v.Val[0].Name != RangeLowerMember || v.Val[1].Name != RangeUpperMember ||
+		v.Val[2].Name != RangeLowerIncMember || v.Val[3].Name != RangeUpperIncMember || v.Val[4].Name != RangeEmptyMember {
+		return RangeBounds{}, false
+	}
+	lowerInc, ok := v.Val[2].Val.(QValueBoolean)
+	if !ok {
+		return RangeBounds{}, false
+	}
+	upperInc, ok := v.Val[3].Val.(QValueBoolean)
+	if !ok {
+		return RangeBounds{}, false
+	}
+	empty, ok := v.Val[4].Val.(QValueBoolean)
+	if !ok {
+		return RangeBounds{}, false
+	}
+	return RangeBounds{
+		Lower:    v.Val[0].Val,
+		Upper:    v.Val[1].Val,
+		LowerInc: lowerInc.Val,
+		UpperInc: upperInc.Val,
+		Empty:    empty.Val,
+	}, true
+}
+
+// QValueArrayStruct holds structs sharing the members described by the Fields of its column,
+// like the ranges of a multirange
+type QValueArrayStruct struct {
+	Val []QValueStruct
+}
+
+func (QValueArrayStruct) Kind() QValueKind {
+	return QValueKindArrayStruct
+}
+
+func (v QValueArrayStruct) Value() any {
+	elements := make([]any, 0, len(v.Val))
+	for _, element := range v.Val {
+		elements = append(elements, element.Value())
+	}
+	return elements
+}
+
+func (v QValueArrayStruct) LValue(ls *lua.LState) lua.LValue {
+	return shared.SliceToLTable(ls, v.Val, func(x QValueStruct) lua.LValue {
+		return x.LValue(ls)
+	})
+}
+
+// QValueMap holds a map with string keys, values share a single kind
+type QValueMap struct {
+	Val map[string]QValue
+}
+
+func (QValueMap) Kind() QValueKind {
+	return QValueKindMap
+}
+
+func (v QValueMap) Value() any {
+	entries := make(map[string]any, len(v.Val))
+	for key, val := range v.Val {
+		entries[key] = val.Value()
+	}
+	return entries
+}
+
+func (v QValueMap) LValue(ls *lua.LState) lua.LValue {
+	tbl := ls.CreateTable(0, len(v.Val))
+	for key, val := range v.Val {
+		tbl.RawSetString(key, val.LValue(ls))
+	}
+	return tbl
+}
+
 type QValueGeography struct {
 	Val string
 }
@@ -865,3 +1012,101 @@ func (v QValueArrayNumeric) LValue(ls *lua.LState) lua.LValue {
 		return shared.LuaDecimal.New(ls, x)
 	})
 }
+
+// TimestampPolicy normalizes timestamp values before they reach destinations
+type TimestampPolicy struct {
+	// NaiveZone is the time zone timestamps without time zone were recorded in,
+	// their wall clock is converted to UTC unless it is nil or UTC
+	NaiveZone *time.Location
+	// ConvertToUTC converts timestamps with time zone to UTC instead of keeping the offset they were read with
+	ConvertToUTC bool
+}
+
+func NewTimestampPolicy(naiveTimeZone string, convertToUTC bool) (TimestampPolicy, error) {
+	policy := TimestampPolicy{ConvertToUTC: convertToUTC}
+	if naiveTimeZone != "" {
+		zone, err := time.LoadLocation(naiveTimeZone)
+		if err != nil {
+			return TimestampPolicy{}, fmt.Errorf("invalid time zone for timestamps without time zone: %w", err)
+		}
+		if zone != time.UTC {
+			policy.NaiveZone = zone
+		}
+	}
+	return policy, nil
+}
+
+// IsNoop reports whether the policy leaves all values unchanged
+func (p TimestampPolicy) IsNoop() bool {
+	return p.NaiveZone == nil && !p.ConvertToUTC
+}
+
+func (p TimestampPolicy) naive(t time.Time) time.Time {
+	if p.NaiveZone == nil {
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), p.NaiveZone).UTC()
+}
+
+func (p TimestampPolicy) withZone(t time.Time) time.Time {
+	if !p.ConvertToUTC {
+		return t
+	}
+	return t.UTC()
+}
+
+// Apply returns the value normalized by the policy, members of structs like range bounds and map entries included,
+// values other than timestamps are returned as is
+func (p TimestampPolicy) Apply(val QValue) QValue {
+	switch v := val.(type) {
+	case QValueStruct:
+		return p.applyStruct(v)
+	case QValueArrayStruct:
+		arr := make([]QValueStruct, 0, len(v.Val))
+		for _, member := range v.Val {
+			arr = append(arr, p.applyStruct(member))
+		}
+		return QValueArrayStruct{Val: arr}
+	case QValueMap:
+		entries := make(map[string]QValue, len(v.Val))
+		for key, entry := range v.Val {
+			if entry != nil {
+				entry = p.Apply(entry)
+			}
+			entries[key] = entry
+		}
+		return QValueMap{Val: entries}
+	case QValueTimestamp:
+		return QValueTimestamp{Val: p.naive(v.Val)}
+	case QValueTimestampTZ:
+		return QValueTimestampTZ{Val: p.withZone(v.Val)}
+	case QValueArrayTimestamp:
+		if p.NaiveZone != nil {
+			arr := make([]time.Time, 0, len(v.Val))
+			for _, t := range v.Val {
+				arr = append(arr, p.naive(t))
+			}
+			return QValueArrayTimestamp{Val: arr}
+		}
+	case QValueArrayTimestampTZ:
+		if p.ConvertToUTC {
+			arr := make([]time.Time, 0, len(v.Val))
+			for _, t := range v.Val {
+				arr = append(arr, p.withZone(t))
+			}
+			return QValueArrayTimestampTZ{Val: arr}
+		}
+	}
+	return val
+}
+
+func (p TimestampPolicy) applyStruct(v QValueStruct) QValueStruct {
+	members := make([]QValueStructField, 0, len(v.Val))
+	for _, member := range v.Val {
+		if member.Val != nil {
+			member.Val = p.Apply(member.Val)
+		}
+		members = append(members, member)
+	}
+	return QValueStruct{Val: members}
+}
@@ -1,5 +1,10 @@
 package types
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 //nolint:iface
 type TypeConversion interface {
 	SchemaConversion(QField) QField
@@ -65,3 +70,60 @@ func NumericToUInt256SchemaConversion(val QField) QField {
 func NumericToUInt256ValueConversion(val QValueNumeric) QValueUInt256 {
 	return QValueUInt256{Val: val.Val.BigInt()}
 }
+
+func NumericToFloat64SchemaConversion(val QField) QField {
+	val.Type = QValueKindFloat64
+	return val
+}
+
+func NumericToFloat64ValueConversion(val QValueNumeric) QValueFloat64 {
+	return QValueFloat64{Val: val.Val.InexactFloat64()}
+}
+
+func ToStringSchemaConversion(val QField) QField {
+	val.Type = QValueKindString
+	return val
+}
+
+// ToStringValueConversion formats values the way they appear in raw CDC records,
+// so that initial load and CDC agree on the contents of String columns
+func ToStringValueConversion[T QValue](val T) QValueString {
+	switch v := any(val).(type) {
+	case QValueUUID:
+		return QValueString{Val: v.Val.String()}
+	case QValueDate:
+		return QValueString{Val: v.Val.Format("2006-01-02")}
+	case QValueTimestamp:
+		return QValueString{Val: v.Val.Format("2006-01-02 15:04:05.999999")}
+	case QValueTimestampTZ:
+		return QValueString{Val: v.Val.Format("2006-01-02 15:04:05.999999-0700")}
+	case QValueTime:
+		return QValueString{Val: FormatExtendedTimeDuration(v.Val)}
+	case QValueTimeTZ:
+		return QValueString{Val: FormatExtendedTimeDuration(v.Val)}
+	}
+	if b, err := json.Marshal(val.Value()); err == nil {
+		return QValueString{Val: string(b)}
+	}
+	return QValueString{Val: fmt.Sprint(val.Value())}
+}
+
+// TypeCompatibility classifies storing values of a source kind in a destination type
+type TypeCompatibility int8
+
+const (
+	TypeCompatibilityInvalid TypeCompatibility = iota
+	TypeCompatibilityLossy
+	TypeCompatibilityLossless
+)
+
+func (c TypeCompatibility) String() string {
+	switch c {
+	case TypeCompatibilityLossless:
+		return "lossless"
+	case TypeCompatibilityLossy:
+		return "lossy"
+	default:
+		return "invalid"
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/connectors"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/internal/secrets"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 )
 
@@ -108,6 +109,10 @@ func NewUpstream(ctx context.Context, catalogPool shared.CatalogPool, peerName s
 	if err != nil {
 		return nil, fmt.Errorf("peer '%s' not found", peerName)
 	}
+	peer, err = secrets.ResolvePeer(ctx, nil, peer)
+	if err != nil {
+		return nil, err
+	}
 
 	switch peer.Type {
 	case protos.DBType_POSTGRES:
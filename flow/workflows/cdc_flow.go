@@ -661,6 +661,11 @@ func startSetupAndSnapshot(
 			if mapping.Engine != protos.TableEngine_CH_ENGINE_NULL {
 				mapping.DestinationTableIdentifier += "_resync"
 			}
+			for _, projection := range mapping.Projections {
+				if projection.Engine != protos.TableEngine_CH_ENGINE_NULL {
+					projection.DestinationTableIdentifier += "_resync"
+				}
+			}
 		}
 		// because we have renamed the tables.
 		cfg.TableMappings = state.SyncFlowOptions.TableMappings
@@ -775,19 +780,22 @@ func startSetupAndSnapshot(
 		}
 
 		for _, mapping := range state.SyncFlowOptions.TableMappings {
-			if mapping.Engine != protos.TableEngine_CH_ENGINE_NULL {
-				oldName := mapping.DestinationTableIdentifier
-				newName := strings.TrimSuffix(oldName, "_resync")
-				renameOpts.RenameTableOptions = append(renameOpts.RenameTableOptions, &protos.RenameTableOption{
-					CurrentName: oldName,
-					NewName:     newName,
-				})
-				mapping.DestinationTableIdentifier = newName
-			} else {
-				renameOpts.RenameTableOptions = append(renameOpts.RenameTableOptions, &protos.RenameTableOption{
-					CurrentName: mapping.DestinationTableIdentifier,
-					NewName:     mapping.DestinationTableIdentifier,
-				})
+			// projections are swapped along with the table they project
+			for _, tm := range append([]*protos.TableMapping{mapping}, mapping.Projections...) {
+				if tm.Engine != protos.TableEngine_CH_ENGINE_NULL {
+					oldName := tm.DestinationTableIdentifier
+					newName := strings.TrimSuffix(oldName, "_resync")
+					renameOpts.RenameTableOptions = append(renameOpts.RenameTableOptions, &protos.RenameTableOption{
+						CurrentName: oldName,
+						NewName:     newName,
+					})
+					tm.DestinationTableIdentifier = newName
+				} else {
+					renameOpts.RenameTableOptions = append(renameOpts.RenameTableOptions, &protos.RenameTableOption{
+						CurrentName: tm.DestinationTableIdentifier,
+						NewName:     tm.DestinationTableIdentifier,
+					})
+				}
 			}
 		}
 
@@ -1013,6 +1021,11 @@ func CDCFlowWorkflow(
 
 	switch next {
 	case nextRunCDC:
+		if pool, moved := receiveWorkerPoolMove(ctx, logger); moved {
+			cfg.WorkerPool = pool
+			syncStateToConfigProtoInCatalog(ctx, cfg, state)
+			continueAsNewCtx = withWorkerPoolTaskQueue(continueAsNewCtx, pool)
+		}
 		return state, workflow.NewContinueAsNewError(continueAsNewCtx, CDCFlowWorkflow, cfg, state)
 	case nextRunDrop:
 		if state.DropFlowInput == nil {
@@ -1,8 +1,10 @@
 package peerflow
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 	"time"
 
@@ -11,6 +13,7 @@ import (
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 
+	"github.com/PeerDB-io/peerdb/flow/activities"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/shared"
@@ -35,6 +38,29 @@ type QRepPartitionFlowExecution struct {
 	runUUID         string
 }
 
+type runningPartition struct {
+	startTime time.Time
+	partition *protos.QRepPartition
+	future    workflow.ChildWorkflowFuture
+	cancel    workflow.CancelFunc
+	depth     int
+	// set when the source could not split the partition any further
+	unsplittable bool
+}
+
+type pendingPartition struct {
+	partition *protos.QRepPartition
+	depth     int
+}
+
+const (
+	// partitions younger than this are never split, so short snapshots don't churn
+	minPartitionAgeForSplit = 10 * time.Minute
+	// pieces of a split partition can be split again, up to this many times
+	maxPartitionSplitDepth      = 3
+	partitionSplitCheckInterval = time.Minute
+)
+
 var InitialLastPartition = &protos.QRepPartition{
 	PartitionId: "not-applicable-partition",
 	Range:       nil,
@@ -258,6 +284,9 @@ func (q *QRepFlowExecution) processPartitions(
 		q.logger.Info("no partitions to process")
 		return nil
 	}
+	if splitFactor := getQRepPartitionSplitFactor(ctx, q.logger, q.config); splitFactor > 0 {
+		return q.processPartitionsWithSplitting(ctx, maxParallelWorkers, splitFactor, partitions)
+	}
 	batches := distributePartitions(partitions, maxParallelWorkers)
 
 	q.logger.Info("processing partitions in batches", "num batches", len(batches))
@@ -294,6 +323,203 @@ func distributePartitions(partitions []*protos.QRepPartition, numBatches int) []
 	return batches
 }
 
+// processPartitionsWithSplitting replicates each partition in its own child workflow, handing them out
+// to at most maxParallelWorkers workers. Once no partitions are left to hand out, a partition running
+// splitFactor times longer than the median partition is cancelled and split across the idle workers.
+func (q *QRepFlowExecution) processPartitionsWithSplitting(
+	ctx workflow.Context,
+	maxParallelWorkers int,
+	splitFactor int64,
+	partitions []*protos.QRepPartition,
+) error {
+	pending := make([]pendingPartition, 0, len(partitions))
+	for _, partition := range partitions {
+		pending = append(pending, pendingPartition{partition: partition})
+	}
+	running := make([]*runningPartition, 0, maxParallelWorkers)
+	durations := make([]time.Duration, 0, len(partitions))
+	batchID := int32(0)
+
+	q.logger.Info("processing partitions with splitting",
+		slog.Int("numPartitions", len(partitions)), slog.Int64("splitFactor", splitFactor))
+
+	for len(pending) > 0 || len(running) > 0 {
+		for len(running) < maxParallelWorkers && len(pending) > 0 {
+			next := pending[0]
+			pending = pending[1:]
+			batchID += 1
+			childCtx, cancel := workflow.WithCancel(ctx)
+			running = append(running, &runningPartition{
+				startTime: workflow.Now(ctx),
+				partition: next.partition,
+				future: q.startChildWorkflow(childCtx, &protos.QRepPartitionBatch{
+					Partitions: []*protos.QRepPartition{next.partition},
+					BatchId:    batchID,
+				}),
+				cancel: cancel,
+				depth:  next.depth,
+			})
+		}
+
+		var finished *runningPartition
+		var finishedErr error
+		selector := workflow.NewNamedSelector(ctx, "QRepPartitionSplitting")
+		for _, r := range running {
+			selector.AddFuture(r.future, func(f workflow.Future) {
+				finished = r
+				finishedErr = f.Get(ctx, nil)
+			})
+		}
+		selector.AddReceive(ctx.Done(), func(_ workflow.ReceiveChannel, _ bool) {})
+
+		threshold := partitionSplitThreshold(durations, splitFactor)
+		cancelTimer := func() {}
+		// only split once there is nothing left to hand out to idle workers
+		if len(pending) == 0 && len(running) < maxParallelWorkers && threshold > 0 {
+			if wait, ok := nextPartitionSplitCheck(running, workflow.Now(ctx), threshold); ok {
+				var timerCtx workflow.Context
+				timerCtx, cancelTimer = workflow.WithCancel(ctx)
+				selector.AddFuture(workflow.NewTimer(timerCtx, wait), func(workflow.Future) {})
+			}
+		}
+
+		selector.Select(ctx)
+		cancelTimer()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if finished != nil {
+			running = slices.DeleteFunc(running, func(r *runningPartition) bool { return r == finished })
+			if finishedErr != nil {
+				return fmt.Errorf("failed to wait for child workflow: %w", finishedErr)
+			}
+			durations = append(durations, workflow.Now(ctx).Sub(finished.startTime))
+			continue
+		}
+
+		straggler := findStragglingPartition(running, workflow.Now(ctx), threshold)
+		if straggler == nil {
+			continue
+		}
+		pieces, err := q.splitPartition(ctx, straggler, int64(maxParallelWorkers-len(running)+1))
+		if err != nil {
+			return err
+		}
+		if pieces == nil {
+			continue
+		}
+		running = slices.DeleteFunc(running, func(r *runningPartition) bool { return r == straggler })
+		for _, piece := range pieces {
+			pending = append(pending, pendingPartition{partition: piece, depth: straggler.depth + 1})
+		}
+	}
+
+	q.logger.Info("all partitions processed")
+	return nil
+}
+
+// splitPartition splits a straggling partition and cancels its child workflow. Returns nil pieces
+// if the partition cannot be split or finished before it could be cancelled.
+func (q *QRepFlowExecution) splitPartition(
+	ctx workflow.Context,
+	straggler *runningPartition,
+	numPieces int64,
+) ([]*protos.QRepPartition, error) {
+	splitCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 5 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:        10 * time.Second,
+			BackoffCoefficient:     2.,
+			MaximumInterval:        time.Minute,
+			MaximumAttempts:        0,
+			NonRetryableErrorTypes: nil,
+		},
+	})
+
+	var pieces []*protos.QRepPartition
+	if err := workflow.ExecuteActivity(
+		splitCtx, flowable.SplitQRepPartition, q.config, straggler.partition, numPieces, q.runUUID,
+	).Get(splitCtx, &pieces); err != nil {
+		if appErr, ok := errors.AsType[*temporal.ApplicationError](err); ok && appErr.Type() == activities.UnsplittablePartitionErrorType {
+			q.logger.Warn("straggling partition cannot be split",
+				slog.String("partitionId", straggler.partition.PartitionId), slog.Any("error", err))
+			straggler.unsplittable = true
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to split partition: %w", err)
+	}
+	if len(pieces) == 0 {
+		straggler.unsplittable = true
+		return nil, nil
+	}
+
+	q.logger.Info("splitting straggling partition",
+		slog.String("partitionId", straggler.partition.PartitionId), slog.Int("numPieces", len(pieces)))
+	// the pieces replace the partition like a retry would, rows it already wrote are deduplicated the same way,
+	// partitions are only split when the destination deduplicates, see QRepPartitionSplitFactor
+	straggler.cancel()
+	if err := straggler.future.Get(ctx, nil); err == nil {
+		// finished before cancellation took effect, it will be picked up as finished
+		straggler.unsplittable = true
+		return nil, nil
+	} else if !temporal.IsCanceledError(err) {
+		return nil, fmt.Errorf("failed to wait for child workflow: %w", err)
+	}
+
+	if err := workflow.ExecuteActivity(
+		splitCtx, flowable.RecordQRepPartitionSplit, q.config, straggler.partition, pieces, q.runUUID,
+	).Get(splitCtx, nil); err != nil {
+		return nil, fmt.Errorf("failed to record partition split: %w", err)
+	}
+	return pieces, nil
+}
+
+// partitionSplitThreshold returns how long a partition may run before it is split,
+// 0 while no partition finished to compare against
+func partitionSplitThreshold(durations []time.Duration, splitFactor int64) time.Duration {
+	if len(durations) == 0 || splitFactor <= 0 {
+		return 0
+	}
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+	median := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		median = (sorted[len(sorted)/2-1] + median) / 2
+	}
+	return max(median*time.Duration(splitFactor), minPartitionAgeForSplit)
+}
+
+func isSplittable(r *runningPartition) bool {
+	return !r.unsplittable && r.depth < maxPartitionSplitDepth
+}
+
+// findStragglingPartition returns the longest running partition past threshold that can still be split
+func findStragglingPartition(running []*runningPartition, now time.Time, threshold time.Duration) *runningPartition {
+	var straggler *runningPartition
+	for _, r := range running {
+		if isSplittable(r) && now.Sub(r.startTime) >= threshold &&
+			(straggler == nil || r.startTime.Before(straggler.startTime)) {
+			straggler = r
+		}
+	}
+	return straggler
+}
+
+// nextPartitionSplitCheck returns how long to wait until a splittable partition passes threshold,
+// checking at least every partitionSplitCheckInterval, or false if no partition can be split
+func nextPartitionSplitCheck(running []*runningPartition, now time.Time, threshold time.Duration) (time.Duration, bool) {
+	wait := partitionSplitCheckInterval
+	found := false
+	for _, r := range running {
+		if isSplittable(r) {
+			found = true
+			wait = min(wait, max(threshold-now.Sub(r.startTime), time.Second))
+		}
+	}
+	return wait, found
+}
+
 // For some targets we need to consolidate all the partitions from stages before
 // we proceed to next batch.
 func (q *QRepFlowExecution) consolidatePartitions(ctx workflow.Context) error {
@@ -670,6 +896,10 @@ func QRepFlowWorkflow(
 	if q.activeSignal == model.PauseSignal {
 		updateStatus(ctx, q.logger, state, protos.FlowStatus_STATUS_PAUSED)
 	}
+	if pool, moved := receiveWorkerPoolMove(ctx, q.logger); moved {
+		config.WorkerPool = pool
+		ctx = withWorkerPoolTaskQueue(ctx, pool)
+	}
 	return state, workflow.NewContinueAsNewError(ctx, QRepFlowWorkflow, config, state)
 }
 
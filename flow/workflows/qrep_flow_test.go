@@ -2,6 +2,7 @@ package peerflow
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -99,3 +100,36 @@ func TestDistributePartitions(t *testing.T) {
 		})
 	}
 }
+
+func TestPartitionSplitThreshold(t *testing.T) {
+	require.Zero(t, partitionSplitThreshold(nil, 4))
+	require.Zero(t, partitionSplitThreshold([]time.Duration{time.Hour}, 0))
+	// short partitions never split before the minimum age
+	require.Equal(t, minPartitionAgeForSplit, partitionSplitThreshold([]time.Duration{time.Second}, 4))
+	require.Equal(t, 12*time.Hour, partitionSplitThreshold([]time.Duration{5 * time.Hour, time.Hour, 3 * time.Hour}, 4))
+	require.Equal(t, 10*time.Hour, partitionSplitThreshold([]time.Duration{time.Hour, 4 * time.Hour, 6 * time.Hour, 8 * time.Hour}, 2))
+}
+
+func TestFindStragglingPartition(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	young := &runningPartition{startTime: now.Add(-time.Hour)}
+	old := &runningPartition{startTime: now.Add(-3 * time.Hour)}
+	older := &runningPartition{startTime: now.Add(-4 * time.Hour), unsplittable: true}
+	oldest := &runningPartition{startTime: now.Add(-5 * time.Hour), depth: maxPartitionSplitDepth}
+
+	running := []*runningPartition{young, older, old, oldest}
+	require.Same(t, old, findStragglingPartition(running, now, 2*time.Hour))
+	require.Nil(t, findStragglingPartition(running, now, 4*time.Hour))
+
+	wait, ok := nextPartitionSplitCheck(running, now, 90*time.Minute)
+	require.True(t, ok)
+	require.Equal(t, time.Second, wait)
+	wait, ok = nextPartitionSplitCheck([]*runningPartition{young}, now, 61*time.Minute-30*time.Second)
+	require.True(t, ok)
+	require.Equal(t, 30*time.Second, wait)
+	wait, ok = nextPartitionSplitCheck([]*runningPartition{young}, now, 10*time.Hour)
+	require.True(t, ok)
+	require.Equal(t, partitionSplitCheckInterval, wait)
+	_, ok = nextPartitionSplitCheck([]*runningPartition{older, oldest}, now, time.Hour)
+	require.False(t, ok)
+}
@@ -222,15 +222,16 @@ func (s *SetupFlowExecution) createNormalizedTables(
 	})
 
 	setupConfig := &protos.SetupNormalizedTableBatchInput{
-		PeerName:          flowConnectionConfigs.DestinationName,
-		TableMappings:     flowConnectionConfigs.TableMappings,
-		SoftDeleteColName: flowConnectionConfigs.SoftDeleteColName,
-		SyncedAtColName:   flowConnectionConfigs.SyncedAtColName,
-		FlowName:          flowConnectionConfigs.FlowJobName,
-		Env:               flowConnectionConfigs.Env,
-		IsResync:          flowConnectionConfigs.Resync,
-		Version:           flowConnectionConfigs.Version,
-		Flags:             flowConnectionConfigs.Flags,
+		PeerName:             flowConnectionConfigs.DestinationName,
+		TableMappings:        flowConnectionConfigs.TableMappings,
+		SoftDeleteColName:    flowConnectionConfigs.SoftDeleteColName,
+		SyncedAtColName:      flowConnectionConfigs.SyncedAtColName,
+		FlowName:             flowConnectionConfigs.FlowJobName,
+		Env:                  flowConnectionConfigs.Env,
+		IsResync:             flowConnectionConfigs.Resync,
+		Version:              flowConnectionConfigs.Version,
+		Flags:                flowConnectionConfigs.Flags,
+		ClickhouseDeleteMode: flowConnectionConfigs.ClickhouseDeleteMode,
 	}
 
 	if err := workflow.ExecuteActivity(ctx, flowable.CreateNormalizedTable, setupConfig).Get(ctx, nil); err != nil {
@@ -4,6 +4,7 @@ import (
 	"cmp"
 	"fmt"
 	"log/slog"
+	"slices"
 	"time"
 
 	"go.temporal.io/sdk/log"
@@ -24,9 +25,13 @@ const (
 	SNAPSHOT_TYPE_TX
 )
 
+const snapshotSessionExecutionTimeout = time.Hour * 24 * 365 * 100 // 100 years
+
 type SnapshotFlowExecution struct {
 	config *protos.FlowConnectionConfigsCore
 	logger log.Logger
+	// initial load reads from the source peer's read replica
+	readFromReplica bool
 }
 
 func getPeerType(wCtx workflow.Context, name string) (protos.DBType, error) {
@@ -78,6 +83,25 @@ func (s *SnapshotFlowExecution) setupReplication(
 	return res, nil
 }
 
+// replicaSnapshotLSN returns the WAL position the source's read replica has to replay before
+// an initial snapshot only load reads from it, or empty when there is no read replica
+func (s *SnapshotFlowExecution) replicaSnapshotLSN(ctx workflow.Context) (string, error) {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 5 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval: 1 * time.Minute,
+			MaximumAttempts: 5,
+		},
+	})
+
+	var lsn string
+	if err := workflow.ExecuteActivity(ctx, snapshot.ReplicaSnapshotLSN,
+		s.config.FlowJobName, s.config.SourceName).Get(ctx, &lsn); err != nil {
+		return "", fmt.Errorf("failed to get replica snapshot LSN: %w", err)
+	}
+	return lsn, nil
+}
+
 func (s *SnapshotFlowExecution) closeSlotKeepAlive(
 	ctx workflow.Context,
 ) error {
@@ -124,7 +148,8 @@ func (s *SnapshotFlowExecution) cloneTable(
 	s.logger.Info(fmt.Sprintf("Obtained child id %s for source table %s and destination table %s",
 		childWorkflowID, srcName, dstName), cloneLog)
 
-	taskQueue := internal.PeerFlowTaskQueueName(shared.PeerFlowTaskQueue)
+	// partitions are replicated by the mirror's own worker pool, the snapshot worker only holds the snapshot
+	taskQueue := internal.PeerFlowTaskQueueName(shared.WorkerPoolTaskQueue(s.config.WorkerPool))
 	childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
 		WorkflowID:          childWorkflowID,
 		WorkflowTaskTimeout: 5 * time.Minute,
@@ -174,7 +199,7 @@ func (s *SnapshotFlowExecution) cloneTable(
 
 	// ensure document IDs are synchronized across initial load and CDC
 	// for the same document
-	if destinationPeerType == protos.DBType_ELASTICSEARCH {
+	if destinationPeerType == protos.DBType_ELASTICSEARCH || destinationPeerType == protos.DBType_MONGO {
 		if err := initTableSchema(); err != nil {
 			return err
 		}
@@ -189,8 +214,10 @@ func (s *SnapshotFlowExecution) cloneTable(
 		if err := initTableSchema(); err != nil {
 			return err
 		}
+		// composite keys need a null partition as soon as one of their columns is nullable
+		keyColumns := shared.SplitPartitionKey(mapping.PartitionKey)
 		for _, col := range tableSchema.Columns {
-			if col.Name == mapping.PartitionKey && col.Nullable {
+			if col.Nullable && slices.Contains(keyColumns, col.Name) {
 				watermarkColumnNullable = true
 				break
 			}
@@ -224,6 +251,9 @@ func (s *SnapshotFlowExecution) cloneTable(
 		Columns:                    mapping.Columns,
 		Version:                    s.config.Version,
 		Flags:                      s.config.Flags,
+		ReadFromReplica:            s.readFromReplica,
+		WorkerPool:                 s.config.WorkerPool,
+		Projections:                mapping.Projections,
 	}
 
 	return boundSelector.SpawnChild(childCtx, QRepFlowWorkflow, nil, config, nil)
@@ -313,6 +343,26 @@ func (s *SnapshotFlowExecution) cloneTablesWithSlot(
 	if slotInfo != nil {
 		slotName = slotInfo.SlotName
 		snapshotName = slotInfo.SnapshotName
+		if slotInfo.InitialLoadFromReplica {
+			// CDC replays everything since the consistent point, so a later replica snapshot only overlaps with upserts
+			txnSnapshotState, err := s.exportTxSnapshot(ctx, sessionCtx,
+				snapshot.MaintainReplicaTx,
+				workflow.GetSessionInfo(sessionCtx).SessionID,
+				s.config.FlowJobName,
+				s.config.SourceName,
+				slotInfo.ConsistentPoint,
+				s.config.Env,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to export snapshot on read replica: %w", err)
+			}
+			snapshotName = txnSnapshotState.SnapshotName
+			s.readFromReplica = true
+			// primary's exported snapshot is no longer needed, release it so it stops holding back vacuum
+			if err := s.closeSlotKeepAlive(sessionCtx); err != nil {
+				return fmt.Errorf("failed to release primary snapshot: %w", err)
+			}
+		}
 	}
 
 	s.logger.Info("cloning tables in parallel", slog.Int("parallelism", numTablesInParallel))
@@ -330,6 +380,51 @@ func (s *SnapshotFlowExecution) cloneTablesWithSlot(
 	return nil
 }
 
+// exportTxSnapshot starts a session activity holding an exported snapshot open and waits for its name
+func (s *SnapshotFlowExecution) exportTxSnapshot(
+	ctx workflow.Context,
+	sessionCtx workflow.Context,
+	maintainActivity any,
+	maintainArgs ...any,
+) (*activities.TxSnapshotState, error) {
+	exportCtx := workflow.WithActivityOptions(sessionCtx, workflow.ActivityOptions{
+		StartToCloseTimeout: snapshotSessionExecutionTimeout,
+		HeartbeatTimeout:    10 * time.Minute,
+		WaitForCancellation: true,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval: 1 * time.Minute,
+		},
+	})
+
+	fMaintain := workflow.ExecuteActivity(exportCtx, maintainActivity, maintainArgs...)
+
+	fExportSnapshot := workflow.ExecuteActivity(
+		exportCtx,
+		snapshot.WaitForExportSnapshot,
+		workflow.GetSessionInfo(sessionCtx).SessionID,
+	)
+
+	var sessionError error
+	var txnSnapshotState *activities.TxSnapshotState
+	sessionSelector := workflow.NewNamedSelector(ctx, "ExportSnapshotSetup")
+	sessionSelector.AddFuture(fMaintain, func(f workflow.Future) {
+		// MaintainTx should never exit without an error before this point
+		sessionError = f.Get(exportCtx, nil)
+	})
+	sessionSelector.AddFuture(fExportSnapshot, func(f workflow.Future) {
+		// Happy path is waiting for this to return without error
+		sessionError = f.Get(exportCtx, &txnSnapshotState)
+	})
+	sessionSelector.AddReceive(ctx.Done(), func(_ workflow.ReceiveChannel, _ bool) {
+		sessionError = ctx.Err()
+	})
+	sessionSelector.Select(ctx)
+	if sessionError != nil {
+		return nil, sessionError
+	}
+	return txnSnapshotState, nil
+}
+
 func SnapshotFlowWorkflow(
 	ctx workflow.Context,
 	config *protos.FlowConnectionConfigsCore,
@@ -345,7 +440,7 @@ func SnapshotFlowWorkflow(
 
 	sessionOpts := &workflow.SessionOptions{
 		CreationTimeout:  5 * time.Minute,
-		ExecutionTimeout: time.Hour * 24 * 365 * 100, // 100 years
+		ExecutionTimeout: snapshotSessionExecutionTimeout,
 		HeartbeatTimeout: time.Hour,
 	}
 	sessionCtx, err := workflow.CreateSession(ctx, sessionOpts)
@@ -370,49 +465,35 @@ func SnapshotFlowWorkflow(
 	}
 
 	if config.InitialSnapshotOnly && config.DoInitialSnapshot {
-		sessionInfo := workflow.GetSessionInfo(sessionCtx)
-
-		exportCtx := workflow.WithActivityOptions(sessionCtx, workflow.ActivityOptions{
-			StartToCloseTimeout: sessionOpts.ExecutionTimeout,
-			HeartbeatTimeout:    10 * time.Minute,
-			WaitForCancellation: true,
-			RetryPolicy: &temporal.RetryPolicy{
-				InitialInterval: 1 * time.Minute,
-			},
-		})
-
-		fMaintain := workflow.ExecuteActivity(
-			exportCtx,
-			snapshot.MaintainTx,
-			sessionInfo.SessionID,
-			config.FlowJobName,
-			config.SourceName,
-			config.Env,
-		)
-
-		fExportSnapshot := workflow.ExecuteActivity(
-			exportCtx,
-			snapshot.WaitForExportSnapshot,
-			sessionInfo.SessionID,
-		)
-
-		var sessionError error
+		replicaLSN, err := se.replicaSnapshotLSN(ctx)
+		if err != nil {
+			return err
+		}
 		var txnSnapshotState *activities.TxSnapshotState
-		sessionSelector := workflow.NewNamedSelector(ctx, "ExportSnapshotSetup")
-		sessionSelector.AddFuture(fMaintain, func(f workflow.Future) {
-			// MaintainTx should never exit without an error before this point
-			sessionError = f.Get(exportCtx, nil)
-		})
-		sessionSelector.AddFuture(fExportSnapshot, func(f workflow.Future) {
-			// Happy path is waiting for this to return without error
-			sessionError = f.Get(exportCtx, &txnSnapshotState)
-		})
-		sessionSelector.AddReceive(ctx.Done(), func(_ workflow.ReceiveChannel, _ bool) {
-			sessionError = ctx.Err()
-		})
-		sessionSelector.Select(ctx)
-		if sessionError != nil {
-			return sessionError
+		if replicaLSN != "" {
+			txnSnapshotState, err = se.exportTxSnapshot(ctx, sessionCtx,
+				snapshot.MaintainReplicaTx,
+				workflow.GetSessionInfo(sessionCtx).SessionID,
+				config.FlowJobName,
+				config.SourceName,
+				replicaLSN,
+				config.Env,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to export snapshot on read replica: %w", err)
+			}
+			se.readFromReplica = true
+		} else {
+			txnSnapshotState, err = se.exportTxSnapshot(ctx, sessionCtx,
+				snapshot.MaintainTx,
+				workflow.GetSessionInfo(sessionCtx).SessionID,
+				config.FlowJobName,
+				config.SourceName,
+				config.Env,
+			)
+			if err != nil {
+				return err
+			}
 		}
 
 		if err := se.cloneTables(ctx,
@@ -72,6 +72,20 @@ func getQRepOverwriteFullRefreshMode(wCtx workflow.Context, logger log.Logger, e
 	return fullRefreshEnabled
 }
 
+func getQRepPartitionSplitFactor(wCtx workflow.Context, logger log.Logger, config *protos.QRepConfig) int64 {
+	checkCtx := workflow.WithActivityOptions(wCtx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+	})
+
+	var splitFactor int64
+	future := workflow.ExecuteActivity(checkCtx, flowable.QRepPartitionSplitFactor, config)
+	if err := future.Get(checkCtx, &splitFactor); err != nil {
+		logger.Warn("Failed to get partition split factor, not splitting partitions", slog.Any("error", err))
+		return 0
+	}
+	return splitFactor
+}
+
 func getClickHouseInitialLoadAllowNonEmptyTables(wCtx workflow.Context, logger log.Logger, env map[string]string) bool {
 	checkCtx := workflow.WithActivityOptions(wCtx, workflow.ActivityOptions{
 		StartToCloseTimeout: time.Minute,
@@ -0,0 +1,41 @@
+package peerflow
+
+import (
+	"log/slog"
+
+	"go.temporal.io/sdk/log"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// receiveWorkerPoolMove drains pool moves signalled during this run, returning the last requested pool.
+// Signals left unread are lost on continue-as-new, so this must run right before continuing
+func receiveWorkerPoolMove(ctx workflow.Context, logger log.Logger) (string, bool) {
+	workerPoolChan := model.WorkerPoolSignal.GetSignalChannel(ctx)
+	var pool string
+	moved := false
+	for {
+		val, ok := workerPoolChan.ReceiveAsync()
+		if !ok {
+			break
+		}
+		pool = val
+		moved = true
+	}
+	if moved {
+		logger.Info("moving mirror to worker pool on continue-as-new", slog.String("workerPool", pool))
+	}
+	return pool, moved
+}
+
+// withWorkerPoolTaskQueue points continue-as-new at the task queue of pool when the workflow runs on another queue
+func withWorkerPoolTaskQueue(ctx workflow.Context, pool string) workflow.Context {
+	taskQueue := internal.PeerFlowTaskQueueName(shared.WorkerPoolTaskQueue(pool))
+	if workflow.GetInfo(ctx).TaskQueueName == taskQueue {
+		return ctx
+	}
+	return workflow.WithWorkflowTaskQueue(ctx, taskQueue)
+}
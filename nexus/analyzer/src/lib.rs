@@ -12,10 +12,10 @@ use pt::peerdb_peers::{MySqlAuthType, PostgresAuthType};
 use pt::{
     flow_model::{FlowJob, FlowJobTableMapping, QRepFlowJob},
     peerdb_peers::{
-        BigqueryConfig, ClickhouseConfig, ClientTlsConfig, DbType, EventHubConfig,
-        GcpServiceAccount, KafkaConfig, MongoConfig, MySqlFlavor, MySqlReplicationMechanism, Peer,
-        PostgresConfig, PubSubConfig, S3Config, SnowflakeConfig, SqlServerConfig, SshConfig,
-        peer::Config,
+        BigqueryConfig, ClickHouseSyncMethod, ClickhouseConfig, ClientTlsConfig, DbType,
+        EventHubConfig, GcpServiceAccount, KafkaConfig, MongoConfig, MySqlFlavor,
+        MySqlReplicationMechanism, Peer, PostgresConfig, PubSubConfig, S3Config, SnowflakeConfig,
+        SqlServerConfig, SshConfig, peer::Config,
     },
 };
 use qrep::process_options;
@@ -372,6 +372,14 @@ pub fn analyze_peerdb_stmt(statement: &PeerDBStatement) -> anyhow::Result<Option
                     _ => false,
                 };
 
+                let worker_pool: Option<String> = match raw_options.remove("worker_pool") {
+                    Some(Expr::Value(ValueWithSpan {
+                        value: ast::Value::SingleQuotedString(s),
+                        ..
+                    })) => Some(s.clone()),
+                    _ => None,
+                };
+
                 let flow_job = FlowJob {
                     name: cdc.mirror_name.to_string().to_lowercase(),
                     source_peer: cdc.source_peer.to_string().to_lowercase(),
@@ -395,6 +403,7 @@ pub fn analyze_peerdb_stmt(statement: &PeerDBStatement) -> anyhow::Result<Option
                     script,
                     system,
                     disable_peerdb_columns,
+                    worker_pool,
                 };
 
                 if initial_copy_only && !do_initial_copy {
@@ -827,6 +836,16 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                 auth_type: PostgresAuthType::PostgresPassword.into(),
                 aws_auth: None,
                 client_tls,
+                replica_host: opts
+                    .get("replica_host")
+                    .map(|s| s.to_string())
+                    .unwrap_or_default(),
+                replica_port: opts
+                    .get("replica_port")
+                    .map(|s| s.parse::<u32>())
+                    .transpose()
+                    .context("unable to parse replica_port as valid int")?
+                    .unwrap_or_default(),
             };
 
             Config::PostgresConfig(postgres_config)
@@ -936,6 +955,11 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                 tls_certificate_directory: opts
                     .get("tls_certificate_directory")
                     .map(|s| s.to_string()),
+                sync_method: match opts.get("sync_method") {
+                    Some(&"direct_insert") => ClickHouseSyncMethod::ClickhouseSyncDirectInsert,
+                    _ => ClickHouseSyncMethod::ClickhouseSyncStaging,
+                }
+                .into(),
             };
             Config::ClickhouseConfig(clickhouse_config)
         }
@@ -1078,7 +1102,30 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
             let api_key = opts.get("api_key").map(|s| s.to_string());
             let username = opts.get("username").map(|s| s.to_string());
             let password = opts.get("password").map(|s| s.to_string());
-            if api_key.is_some() {
+            let aws_serverless = opts
+                .get("aws_serverless")
+                .map(|s| s.parse::<bool>().unwrap_or_default())
+                .unwrap_or_default();
+            if opts.get("auth_type") == Some(&"aws_sigv4") {
+                if api_key.is_some() || username.is_some() || password.is_some() {
+                    return Err(anyhow::anyhow!(
+                        "AWS SigV4 auth cannot be combined with API key or basic auth"
+                    ));
+                }
+                Config::ElasticsearchConfig(pt::peerdb_peers::ElasticsearchConfig {
+                    addresses,
+                    auth_type: pt::peerdb_peers::ElasticsearchAuthType::AwsSigv4.into(),
+                    username: None,
+                    password: None,
+                    api_key: None,
+                    aws_auth: Some(parse_aws_auth(&opts)?),
+                    aws_serverless,
+                })
+            } else if aws_serverless {
+                return Err(anyhow::anyhow!(
+                    "aws_serverless requires auth_type = 'aws_sigv4'"
+                ));
+            } else if api_key.is_some() {
                 if username.is_some() || password.is_some() {
                     return Err(anyhow::anyhow!(
                         "both API key auth and basic auth specified"
@@ -1090,6 +1137,8 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                     username: None,
                     password: None,
                     api_key,
+                    aws_auth: None,
+                    aws_serverless: false,
                 })
             } else if username.is_some() && password.is_some() {
                 Config::ElasticsearchConfig(pt::peerdb_peers::ElasticsearchConfig {
@@ -1098,6 +1147,8 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                     username,
                     password,
                     api_key: None,
+                    aws_auth: None,
+                    aws_serverless: false,
                 })
             } else {
                 Config::ElasticsearchConfig(pt::peerdb_peers::ElasticsearchConfig {
@@ -1106,6 +1157,8 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                     username: None,
                     password: None,
                     api_key: None,
+                    aws_auth: None,
+                    aws_serverless: false,
                 })
             }
         }
@@ -1239,6 +1292,99 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                 client_tls,
             })
         }
+        DbType::Oracle => {
+            let ssh_fields: Option<SshConfig> = match opts.get("ssh_config") {
+                Some(ssh_config) => {
+                    let ssh_config_str = ssh_config.to_string();
+                    if ssh_config_str.is_empty() {
+                        None
+                    } else {
+                        serde_json::from_str(&ssh_config_str)
+                            .context("failed to deserialize ssh_config")?
+                    }
+                }
+                None => None,
+            };
+
+            Config::OracleConfig(pt::peerdb_peers::OracleConfig {
+                host: opts.get("host").context("no host specified")?.to_string(),
+                port: opts
+                    .get("port")
+                    .map(|s| s.parse::<u32>())
+                    .transpose()
+                    .context("unable to parse port as valid int")?
+                    .unwrap_or(1521),
+                user: opts
+                    .get("user")
+                    .context("no username specified")?
+                    .to_string(),
+                password: opts
+                    .get("password")
+                    .context("no password specified")?
+                    .to_string(),
+                service_name: opts
+                    .get("service_name")
+                    .context("no service name specified")?
+                    .to_string(),
+                ssh_config: ssh_fields,
+                require_tls: opts
+                    .get("require_tls")
+                    .map(|s| s.parse::<bool>().unwrap_or_default())
+                    .unwrap_or_default(),
+                skip_cert_verification: opts
+                    .get("skip_cert_verification")
+                    .map(|s| s.parse::<bool>().unwrap_or_default())
+                    .unwrap_or_default(),
+            })
+        }
+        DbType::Dynamodb => Config::DynamodbConfig(pt::peerdb_peers::DynamoDbConfig {
+            aws_auth: Some(parse_aws_auth(&opts)?),
+            endpoint: opts.get("endpoint").map(|s| s.to_string()),
+        }),
         DbType::DbtypeUnknown => return Ok(None),
     }))
 }
+
+fn parse_aws_auth(
+    opts: &HashMap<&str, &str>,
+) -> anyhow::Result<pt::peerdb_peers::AwsAuthenticationConfig> {
+    let (auth_type, auth_config) = match (opts.get("access_key_id"), opts.get("role_arn")) {
+        (Some(access_key_id), _) => (
+            pt::peerdb_peers::AwsIamAuthConfigType::IamAuthStaticCredentials,
+            Some(
+                pt::peerdb_peers::aws_authentication_config::AuthConfig::StaticCredentials(
+                    pt::peerdb_peers::AwsAuthStaticCredentialsConfig {
+                        access_key_id: access_key_id.to_string(),
+                        secret_access_key: opts
+                            .get("secret_access_key")
+                            .context("no secret_access_key specified")?
+                            .to_string(),
+                    },
+                ),
+            ),
+        ),
+        (None, Some(role_arn)) => (
+            pt::peerdb_peers::AwsIamAuthConfigType::IamAuthAssumeRole,
+            Some(
+                pt::peerdb_peers::aws_authentication_config::AuthConfig::Role(
+                    pt::peerdb_peers::AwsAuthAssumeRoleConfig {
+                        assume_role_arn: role_arn.to_string(),
+                        chained_role_arn: opts.get("chained_role_arn").map(|s| s.to_string()),
+                    },
+                ),
+            ),
+        ),
+        (None, None) => (
+            pt::peerdb_peers::AwsIamAuthConfigType::IamAuthAutomatic,
+            None,
+        ),
+    };
+    Ok(pt::peerdb_peers::AwsAuthenticationConfig {
+        region: opts
+            .get("region")
+            .context("no region specified")?
+            .to_string(),
+        auth_type: auth_type as i32,
+        auth_config,
+    })
+}
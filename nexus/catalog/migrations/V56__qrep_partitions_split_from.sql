@@ -0,0 +1,3 @@
+ALTER TABLE peerdb_stats.qrep_partitions ADD COLUMN IF NOT EXISTS split_from TEXT;
+COMMENT ON COLUMN peerdb_stats.qrep_partitions.split_from IS
+    'partition_uuid of the straggling partition this partition was split from, its remaining range is read by the split partitions';
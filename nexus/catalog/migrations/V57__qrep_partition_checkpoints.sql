@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS metadata_qrep_partition_checkpoints (
+    parent_mirror_name TEXT NOT NULL,
+    run_uuid TEXT NOT NULL,
+    partition_uuid TEXT NOT NULL,
+    next_chunk INTEGER NOT NULL,
+    rows_synced BIGINT NOT NULL,
+    enc_key_id TEXT,
+    range_payload BYTEA,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (run_uuid, partition_uuid)
+);
+
+COMMENT ON COLUMN metadata_qrep_partition_checkpoints.range_payload IS
+    'encrypted range left to replicate, NULL once the partition completed';
+
+CREATE INDEX IF NOT EXISTS idx_metadata_qrep_partition_checkpoints_parent_mirror_name
+    ON metadata_qrep_partition_checkpoints (parent_mirror_name);
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS peerdb_stats.numeric_truncation_counts (
+    flow_name TEXT NOT NULL,
+    sync_id TEXT NOT NULL,
+    destination_table_name TEXT NOT NULL,
+    destination_column_name TEXT NOT NULL,
+    truncated_count BIGINT NOT NULL DEFAULT 0,
+    cleared_count BIGINT NOT NULL DEFAULT 0,
+    recorded_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (flow_name, sync_id, destination_table_name, destination_column_name)
+);
+
+COMMENT ON TABLE peerdb_stats.numeric_truncation_counts IS
+'Counts NUMERIC values that lost precision while syncing, by flow, sync, table and column.';
+
+COMMENT ON COLUMN peerdb_stats.numeric_truncation_counts.sync_id IS
+    'CDC batch id, or QRep partition id, counted once even when the batch or partition is retried';
+
+COMMENT ON COLUMN peerdb_stats.numeric_truncation_counts.truncated_count IS
+    'values with digits beyond the destination scale, truncated or rounded depending on the column strategy';
+
+COMMENT ON COLUMN peerdb_stats.numeric_truncation_counts.cleared_count IS
+    'values too big for the destination column, written as zero or NULL';
@@ -185,6 +185,8 @@ impl CatalogConfig<'_> {
             auth_type: PostgresAuthType::PostgresPassword.into(),
             aws_auth: None,
             client_tls: None,
+            replica_host: String::new(),
+            replica_port: 0,
         }
     }
 
@@ -430,6 +432,16 @@ impl Catalog {
                         pt::peerdb_peers::CockroachDbConfig::decode(&options[..]).with_context(err)?;
                     Config::CockroachdbConfig(crdb_config)
                 }
+                DbType::Oracle => {
+                    let oracle_config =
+                        pt::peerdb_peers::OracleConfig::decode(&options[..]).with_context(err)?;
+                    Config::OracleConfig(oracle_config)
+                }
+                DbType::Dynamodb => {
+                    let dynamodb_config =
+                        pt::peerdb_peers::DynamoDbConfig::decode(&options[..]).with_context(err)?;
+                    Config::DynamodbConfig(dynamodb_config)
+                }
                 DbType::DbtypeUnknown => return Ok(None),
             })
         } else {
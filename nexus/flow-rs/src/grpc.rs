@@ -149,6 +149,7 @@ impl FlowGrpcClient {
             version: 0, // filled in by server
             flags: Default::default(),
             skip_validation: Some(false),
+            worker_pool: job.worker_pool.clone().unwrap_or_default(),
         };
 
         if job.disable_peerdb_columns {
@@ -207,6 +208,7 @@ impl FlowGrpcClient {
                         }
                     }
                     "staging_path" => cfg.staging_path.clone_from(s),
+                    "worker_pool" => cfg.worker_pool.clone_from(s),
                     _ => return anyhow::Result::Err(anyhow::anyhow!("invalid str option {}", key)),
                 },
                 Value::Number(n) => match key.as_str() {
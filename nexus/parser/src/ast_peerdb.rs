@@ -17,6 +17,8 @@ pub enum PeerType {
     Elasticsearch,
     Clickhouse,
     CockroachDB,
+    Oracle,
+    DynamoDB,
 }
 
 impl fmt::Display for PeerType {
@@ -35,6 +37,8 @@ impl fmt::Display for PeerType {
             PeerType::Elasticsearch => write!(f, "ELASTICSEARCH"),
             PeerType::Clickhouse => write!(f, "CLICKHOUSE"),
             PeerType::CockroachDB => write!(f, "COCKROACHDB"),
+            PeerType::Oracle => write!(f, "ORACLE"),
+            PeerType::DynamoDB => write!(f, "DYNAMODB"),
         }
     }
 }
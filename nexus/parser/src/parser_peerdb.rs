@@ -90,6 +90,8 @@ fn parse_peer_type(parser: &mut Parser) -> Result<PeerType, ParserError> {
             "ELASTICSEARCH" => Ok(PeerType::Elasticsearch),
             "CLICKHOUSE" => Ok(PeerType::Clickhouse),
             "COCKROACHDB" => Ok(PeerType::CockroachDB),
+            "ORACLE" => Ok(PeerType::Oracle),
+            "DYNAMODB" => Ok(PeerType::DynamoDB),
             other => Err(ParserError::ParserError(format!(
                 "expected peer type, got {other}"
             ))),
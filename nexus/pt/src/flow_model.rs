@@ -35,6 +35,7 @@ pub struct FlowJob {
     pub script: String,
     pub system: String,
     pub disable_peerdb_columns: bool,
+    pub worker_pool: Option<String>,
 }
 
 #[derive(Debug, PartialEq, Eq, Serialize, Deserialize, Clone)]
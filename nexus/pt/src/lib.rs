@@ -33,6 +33,8 @@ impl From<PeerType> for DbType {
             PeerType::Elasticsearch => DbType::Elasticsearch,
             PeerType::Clickhouse => DbType::Clickhouse,
             PeerType::CockroachDB => DbType::Cockroachdb,
+            PeerType::Oracle => DbType::Oracle,
+            PeerType::DynamoDB => DbType::Dynamodb,
         }
     }
 }
@@ -1,4 +1,5 @@
 import {
+  AwsIAMAuthConfigType,
   ElasticsearchAuthType,
   elasticsearchAuthTypeFromJSON,
   ElasticsearchConfig,
@@ -27,6 +28,7 @@ export const esSetting: PeerSetting[] = [
       { value: 'NONE', label: 'None' },
       { value: 'BASIC', label: 'Basic' },
       { value: 'APIKEY', label: 'API Key' },
+      { value: 'AWS_SIGV4', label: 'AWS SigV4 (OpenSearch)' },
     ],
   },
   // remaining fields are optional but displayed conditionally so not optional style wise
@@ -47,6 +49,18 @@ export const esSetting: PeerSetting[] = [
     stateHandler: (value, setter) =>
       setter((curr) => ({ ...curr, apiKey: value as string })),
   },
+  {
+    label: 'AWS Region',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({
+        ...curr,
+        awsAuth: {
+          region: value as string,
+          authType: AwsIAMAuthConfigType.IAM_AUTH_AUTOMATIC,
+        },
+      })),
+    tips: 'Requests are signed with the credentials available to PeerDB.',
+  },
 ];
 
 export const blankElasticsearchSetting: ElasticsearchConfig = {
@@ -55,4 +69,5 @@ export const blankElasticsearchSetting: ElasticsearchConfig = {
   username: '',
   password: '',
   apiKey: '',
+  awsServerless: false,
 };
@@ -52,6 +52,26 @@ export const postgresSetting: PeerSetting[] = [
     helpfulLink:
       'https://www.postgresql.org/docs/current/sql-createdatabase.html',
   },
+  {
+    label: 'Read Replica Host',
+    field: 'replicaHost',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, replicaHost: value as string })),
+    tips: 'Optional hot standby used for initial load so that snapshot reads do not hit the primary. Uses the same credentials and TLS settings as the primary.',
+    optional: true,
+  },
+  {
+    label: 'Read Replica Port',
+    field: 'replicaPort',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({
+        ...curr,
+        replicaPort: parseInt(value as string, 10) || 0,
+      })),
+    type: 'number',
+    tips: 'Port of the read replica, defaults to the primary port.',
+    optional: true,
+  },
   {
     label: 'Require TLS?',
     stateHandler: (value, setter) =>
@@ -265,4 +285,6 @@ export const blankPostgresSetting: PostgresConfig = {
     authType: AwsIAMAuthConfigType.IAM_AUTH_AUTOMATIC,
   },
   tlsHost: '',
+  replicaHost: '',
+  replicaPort: 0,
 };
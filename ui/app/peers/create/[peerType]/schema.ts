@@ -123,6 +123,15 @@ export const pgSchema = z.object({
     .optional()
     .transform((e) => (e === '' ? undefined : e)),
   tlsHost: z.string(),
+  replicaHost: z
+    .string()
+    .max(255, 'Replica host must be less than 256 characters')
+    .optional(),
+  replicaPort: z
+    .int()
+    .min(0, 'Replica port must be a positive integer')
+    .max(65535, 'Replica port must be below 65536')
+    .optional(),
   clientTls: z
     .object({
       certificate: z
@@ -702,11 +711,16 @@ export const esSchema = z
       error: (issue) =>
         issue.input === undefined
           ? 'Auth type cannot be empty'
-          : 'Auth type must be one of [none,basic,apikey]',
+          : 'Auth type must be one of [none,basic,apikey,aws_sigv4]',
     }),
     username: z.string({ error: () => 'Username must be a string' }).optional(),
     password: z.string({ error: () => 'Password must be a string' }).optional(),
     apiKey: z.string({ error: () => 'API key must be a string' }).optional(),
+    awsAuth: z
+      .object({
+        region: z.string({ error: () => 'AWS region must be a string' }),
+      })
+      .optional(),
   })
   .refine(
     (esSchema) => {
@@ -722,6 +736,13 @@ export const esSchema = z
           !isString(esSchema.password) &&
           isString(esSchema.apiKey)
         );
+      } else if (esSchema.authType === ElasticsearchAuthType.AWS_SIGV4) {
+        return (
+          !isString(esSchema.username) &&
+          !isString(esSchema.password) &&
+          !isString(esSchema.apiKey) &&
+          isString(esSchema.awsAuth?.region)
+        );
       } else if (esSchema.authType === ElasticsearchAuthType.NONE) {
         return (
           !isString(esSchema.username) &&
@@ -43,7 +43,9 @@ export default function ElasticsearchConfigForm({
           />
         ) : (setting.label === 'API Key' &&
             config.authType === ElasticsearchAuthType.APIKEY) ||
-          (setting.label !== 'API Key' &&
+          (setting.label === 'AWS Region' &&
+            config.authType === ElasticsearchAuthType.AWS_SIGV4) ||
+          ((setting.label === 'Username' || setting.label === 'Password') &&
             config.authType === ElasticsearchAuthType.BASIC) ||
           setting.label === 'Addresses' ? (
           <RowWithTextField